@@ -650,10 +650,10 @@ export class BlockchainService {
    * 获取医疗记录
    */
   async getMedicalRecord(recordId: string): Promise<BlockchainResult<unknown>> {
-    // Prefer newer/read methods in chaincode
-    let result = await this.evaluateTransaction('ReadRecord', recordId);
+    // ReadRecord 会扣减限次授权并写入披露记录，必须提交交易，evaluate 的读取不会上链
+    let result = await this.submitTransaction('ReadRecord', recordId);
     if (!result.success) {
-      result = await this.evaluateTransaction('GetRecord', recordId);
+      result = await this.submitTransaction('GetRecord', recordId);
     }
 
     if (result.success && result.data) {
@@ -890,12 +890,12 @@ export class BlockchainService {
       }
     }
 
-    // 尝试 ReadRecord
-    let result = await this.evaluateTransaction('ReadRecord', recordId);
+    // 尝试 ReadRecord（须提交，理由同 getMedicalRecord）
+    let result = await this.submitTransaction('ReadRecord', recordId);
     if (result.success) return result;
 
     // 尝试 GetRecord
-    result = await this.evaluateTransaction('GetRecord', recordId);
+    result = await this.submitTransaction('GetRecord', recordId);
     if (result.success) return result;
 
     // 回退到优化服务
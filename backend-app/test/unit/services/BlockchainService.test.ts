@@ -279,6 +279,23 @@ describe('BlockchainService 单元测试', () => {
     });
   });
 
+  describe('getMedicalRecord', () => {
+    beforeEach(async () => {
+      await blockchainService.initialize();
+    });
+
+    it('应该以提交交易读取记录，使限次授权与披露记录生效', async () => {
+      mockContract.submitTransaction.mockResolvedValueOnce(Buffer.from('{"recordId":"rec1"}'));
+
+      const result = await blockchainService.getMedicalRecord('rec1');
+
+      expect(result.success).toBe(true);
+      expect(result.data).toEqual({ recordId: 'rec1' });
+      expect(mockContract.submitTransaction).toHaveBeenCalledWith('ReadRecord', 'rec1');
+      expect(mockContract.evaluateTransaction).not.toHaveBeenCalledWith('ReadRecord', 'rec1');
+    });
+  });
+
   describe('evaluateTransaction', () => {
     beforeEach(async () => {
       await blockchainService.initialize();
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// checkAccessReads 返回一次 CheckAccess 的结果及其 GetState 次数
+func (e *testEnv) checkAccessReads(recordID, userID string) (bool, int) {
+	before := e.stub.reads
+	allowed := e.checkAccess(recordID, userID)
+	return allowed, e.stub.reads - before
+}
+
+func TestCheckAccessSingleRead(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+
+	// 冻结标记与访问条目各读一次
+	for _, id := range []string{patient.id, doctor.id, nurse.id} {
+		allowed, reads := env.checkAccessReads("rec1", id)
+		if !allowed || reads != 2 {
+			t.Fatalf("%s: allowed=%v reads=%d, want true with two reads", id, allowed, reads)
+		}
+	}
+
+	// 撤销与过期都回落到完整判断，结果不变
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+	if allowed, reads := env.checkAccessReads("rec1", nurse.id); allowed || reads == 2 {
+		t.Fatalf("revoked grant: allowed=%v reads=%d", allowed, reads)
+	}
+	env.grant(patient, "rec1", specialist.id, "read", env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	env.advance(2 * time.Hour)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("expired grant must not pass the fast path")
+	}
+}
+
+func TestCheckAccessLegacyRecordFallsBack(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.dropAccessEntries("rec1", patient.id, nurse.id)
+
+	for _, id := range []string{patient.id, nurse.id} {
+		if allowed, reads := env.checkAccessReads("rec1", id); !allowed || reads < 2 {
+			t.Fatalf("%s: allowed=%v reads=%d, want fallback to the full check", id, allowed, reads)
+		}
+	}
+}
+
+// dropAccessEntries 删除快速路径条目，模拟本功能上线前创建的记录
+func (e *testEnv) dropAccessEntries(recordID string, userIDs ...string) {
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		for _, userID := range userIDs {
+			key, err := accessEntryKey(ctx, recordID, userID)
+			if err != nil {
+				return err
+			}
+			if err := ctx.GetStub().DelState(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func benchmarkCheckAccess(b *testing.B, userID string, legacy bool) {
+	env := newTestEnv(b)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	if legacy {
+		env.dropAccessEntries("rec1", patient.id, nurse.id)
+	}
+	env.stub.reads = 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		env.checkAccess("rec1", userID)
+	}
+	b.ReportMetric(float64(env.stub.reads)/float64(b.N), "reads/op")
+}
+
+func BenchmarkCheckAccessOwner(b *testing.B)       { benchmarkCheckAccess(b, patient.id, false) }
+func BenchmarkCheckAccessGrant(b *testing.B)       { benchmarkCheckAccess(b, nurse.id, false) }
+func BenchmarkCheckAccessDenied(b *testing.B)      { benchmarkCheckAccess(b, other.id, false) }
+func BenchmarkCheckAccessLegacyOwner(b *testing.B) { benchmarkCheckAccess(b, patient.id, true) }
+func BenchmarkCheckAccessLegacyGrant(b *testing.B) { benchmarkCheckAccess(b, nurse.id, true) }
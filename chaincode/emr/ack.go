@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ShareAcknowledgement 需确认的分享（如危急值化验结果）：ReadTxID 为被授权人确认前最近一次读取的交易，
+// 确认时 AuditEntryID 须与之相同，即对应该次读取的 RecordAccessed 审计条目
+type ShareAcknowledgement struct {
+	RecordID       string `json:"recordId"`
+	GranteeID      string `json:"granteeId"`
+	SharedBy       string `json:"sharedBy"`
+	SharedAt       string `json:"sharedAt"`
+	GrantTxID      string `json:"grantTxId"`
+	ReadTxID       string `json:"readTxId,omitempty"`
+	ReadAt         string `json:"readAt,omitempty"`
+	AuditEntryID   string `json:"auditEntryId,omitempty"`
+	NoteHash       string `json:"noteHash,omitempty"`
+	AcknowledgedAt string `json:"acknowledgedAt,omitempty"`
+}
+
+type AccessAcknowledgedEvent struct {
+	RecordID     string `json:"recordId"`
+	GranteeID    string `json:"granteeId"`
+	SharedBy     string `json:"sharedBy"`
+	AuditEntryID string `json:"auditEntryId"`
+	NoteHash     string `json:"noteHash,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	EventType    string `json:"eventType"`
+}
+
+// ackKey ack:{recordId}:{granteeId}
+func ackKey(recordID, granteeID string) string {
+	return "ack:" + keySegment(recordID) + ":" + keySegment(granteeID)
+}
+
+// ackPendingKey ack-pending:{sharedBy}:{recordId}:{granteeId}，值为 ackKey；确认后删除
+func ackPendingKey(sharedBy, recordID, granteeID string) string {
+	return ackPendingPrefix(sharedBy) + keySegment(recordID) + ":" + keySegment(granteeID)
+}
+
+func ackPendingPrefix(sharedBy string) string {
+	return "ack-pending:" + keySegment(sharedBy) + ":"
+}
+
+// requestAck grantAccess 调用：写入待确认的分享并登记到分享人名下；再次分享覆盖原条目，重新等待确认
+func requestAck(ctx contractapi.TransactionContextInterface, perm AccessPermission) error {
+	var previous ShareAcknowledgement
+	found, err := getJSON(ctx, ackKey(perm.RecordID, perm.GranteeID), &previous)
+	if err != nil {
+		return err
+	}
+	if found && previous.AcknowledgedAt == "" && previous.SharedBy != perm.GrantedBy {
+		if err := ctx.GetStub().DelState(ackPendingKey(previous.SharedBy, perm.RecordID, perm.GranteeID)); err != nil {
+			return fmt.Errorf("failed to delete pending acknowledgement: %w", err)
+		}
+	}
+	key := ackKey(perm.RecordID, perm.GranteeID)
+	if err := putJSON(ctx, key, ShareAcknowledgement{
+		RecordID:  perm.RecordID,
+		GranteeID: perm.GranteeID,
+		SharedBy:  perm.GrantedBy,
+		SharedAt:  perm.GrantedAt,
+		GrantTxID: perm.TxID,
+	}); err != nil {
+		return err
+	}
+	return putJSON(ctx, ackPendingKey(perm.GrantedBy, perm.RecordID, perm.GranteeID), key)
+}
+
+// noteAckRead recordAllowedRead 调用：被授权人读取待确认的分享时记下读取交易
+func noteAckRead(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string) error {
+	var ack ShareAcknowledgement
+	found, err := getJSON(ctx, ackKey(record.RecordID, callerID), &ack)
+	if err != nil || !found || ack.AcknowledgedAt != "" {
+		return err
+	}
+	ack.ReadTxID = ctx.GetStub().GetTxID()
+	ack.ReadAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, ackKey(record.RecordID, callerID), ack)
+}
+
+// GrantAccessRequiringAck 与 GrantAccessWithExpiry 相同，但被授权人读取后须以 AcknowledgeAccess 确认，
+// 未确认的分享列在分享人的 ListUnacknowledgedShares 中
+func (s *SmartContract) GrantAccessRequiringAck(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt string) error {
+	return grantAccess(ctx, recordID, granteeID, action, expiresAt, 0, grantScope{requiresAck: true})
+}
+
+// AcknowledgeAccess 被授权人确认已查看分享的记录；auditEntryID 为确认前最近一次读取的交易 ID，
+// noteHash 为线下留存的确认说明的 sha256，可为空。授权此后撤销或过期不影响确认
+func (s *SmartContract) AcknowledgeAccess(ctx contractapi.TransactionContextInterface, recordID, auditEntryID, noteHash string) error {
+	if noteHash != "" && !sha256HexPattern.MatchString(noteHash) {
+		return fmt.Errorf("noteHash must be a lowercase hex sha256 digest")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	var ack ShareAcknowledgement
+	found, err := getJSON(ctx, ackKey(recordID, callerID), &ack)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no share of record %s to %s requires acknowledgement", recordID, callerID)
+	}
+	if ack.AcknowledgedAt != "" {
+		return fmt.Errorf("share of record %s was already acknowledged", recordID)
+	}
+	if ack.ReadTxID == "" {
+		return fmt.Errorf("record %s must be read before it can be acknowledged", recordID)
+	}
+	if auditEntryID != ack.ReadTxID {
+		return fmt.Errorf("auditEntryID must be the latest read of record %s: %s", recordID, ack.ReadTxID)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	ack.AuditEntryID = auditEntryID
+	ack.NoteHash = noteHash
+	ack.AcknowledgedAt = now.Format(time.RFC3339)
+	if err := putJSON(ctx, ackKey(recordID, callerID), ack); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(ackPendingKey(ack.SharedBy, recordID, callerID)); err != nil {
+		return fmt.Errorf("failed to delete pending acknowledgement: %w", err)
+	}
+	return emitEvent(ctx, "AccessAcknowledged", AccessAcknowledgedEvent{
+		RecordID:     recordID,
+		GranteeID:    callerID,
+		SharedBy:     ack.SharedBy,
+		AuditEntryID: auditEntryID,
+		NoteHash:     noteHash,
+		Timestamp:    ack.AcknowledgedAt,
+		EventType:    "AccessAcknowledged",
+	})
+}
+
+// GetShareAcknowledgement 返回分享的确认状态；限分享人、被授权人或记录的患者
+func (s *SmartContract) GetShareAcknowledgement(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*ShareAcknowledgement, error) {
+	var ack ShareAcknowledgement
+	found, err := getJSON(ctx, ackKey(recordID, granteeID), &ack)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no share of record %s to %s requires acknowledgement", recordID, granteeID)
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	for _, subjectID := range []string{ack.SharedBy, ack.GranteeID, record.PatientID} {
+		allowed, err := callerIs(ctx, subjectID)
+		if err != nil || allowed {
+			return &ack, err
+		}
+	}
+	return nil, fmt.Errorf("access denied: only the sharer, the grantee or the patient can view the acknowledgement")
+}
+
+// ListUnacknowledgedShares 返回 sharerID 名下需要确认但尚未确认的分享；限分享人本人
+func (s *SmartContract) ListUnacknowledgedShares(ctx contractapi.TransactionContextInterface, sharerID string) ([]*ShareAcknowledgement, error) {
+	if err := validateAddress(sharerID); err != nil {
+		return nil, fmt.Errorf("invalid sharerID: %w", err)
+	}
+	isSharer, err := callerIs(ctx, sharerID)
+	if err != nil {
+		return nil, err
+	}
+	if !isSharer {
+		return nil, fmt.Errorf("access denied: only the sharer can list unacknowledged shares")
+	}
+
+	prefix := ackPendingPrefix(sharerID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pending acknowledgements: %w", err)
+	}
+	defer iterator.Close()
+
+	shares := []*ShareAcknowledgement{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate pending acknowledgements: %w", err)
+		}
+		var key string
+		if err := json.Unmarshal(kv.Value, &key); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending acknowledgement: %w", err)
+		}
+		var ack ShareAcknowledgement
+		found, err := getJSON(ctx, key, &ack)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			shares = append(shares, &ack)
+		}
+	}
+	return shares, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// readTx 以 identity 身份读取记录，返回读取交易 ID
+func (e *testEnv) readTx(identity *testIdentity, recordID string) string {
+	e.t.Helper()
+	var txID string
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		txID = ctx.GetStub().GetTxID()
+		_, err := e.cc.ReadRecord(ctx, recordID)
+		return err
+	})
+	return txID
+}
+
+func (e *testEnv) unacknowledgedShares(sharer *testIdentity) []*ShareAcknowledgement {
+	e.t.Helper()
+	var shares []*ShareAcknowledgement
+	e.mustInvoke(sharer, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		shares, err = e.cc.ListUnacknowledgedShares(ctx, sharer.id)
+		return err
+	})
+	return shares
+}
+
+func TestAcknowledgeSharedResults(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "lab1", patient.id)
+	env.createRecord(doctor, "lab2", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessRequiringAck(ctx, "lab1", specialist.id, "read", "")
+	})
+	var granted AccessGrantedEvent
+	env.expectEvent("AccessGranted", &granted)
+	if !granted.RequiresAck {
+		t.Fatalf("unexpected event: %+v", granted)
+	}
+	env.grant(patient, "lab2", specialist.id, "read", "")
+	if shares := env.unacknowledgedShares(patient); len(shares) != 1 || shares[0].RecordID != "lab1" || shares[0].GranteeID != specialist.id {
+		t.Fatalf("unexpected pending shares: %+v", shares)
+	}
+	env.mustFail(doctor, "only the sharer", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListUnacknowledgedShares(ctx, patient.id)
+		return err
+	})
+
+	acknowledge := func(recordID, auditEntryID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AcknowledgeAccess(ctx, recordID, auditEntryID, strings.Repeat("ab", 32))
+		}
+	}
+	env.mustFail(specialist, "must be read before", acknowledge("lab1", "tx0001"))
+	env.mustFail(specialist, "no share of record lab2", acknowledge("lab2", "tx0001"))
+	first := env.readTx(specialist, "lab1")
+	latest := env.readTx(specialist, "lab1")
+	env.mustFail(specialist, "must be the latest read", acknowledge("lab1", first))
+	env.mustInvoke(specialist, acknowledge("lab1", latest))
+	var event AccessAcknowledgedEvent
+	env.expectEvent("AccessAcknowledged", &event)
+	if event.AuditEntryID != latest || event.SharedBy != patient.id || event.GranteeID != specialist.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(specialist, "already acknowledged", acknowledge("lab1", latest))
+	if shares := env.unacknowledgedShares(patient); len(shares) != 0 {
+		t.Fatalf("acknowledged shares must not be pending: %+v", shares)
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		ack, err := env.cc.GetShareAcknowledgement(ctx, "lab1", specialist.id)
+		if err == nil && (ack.AcknowledgedAt == "" || ack.ReadTxID != latest || ack.GrantTxID == "") {
+			t.Fatalf("unexpected acknowledgement: %+v", ack)
+		}
+		return err
+	})
+	env.mustFail(doctor, "only the sharer, the grantee or the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetShareAcknowledgement(ctx, "lab1", specialist.id)
+		return err
+	})
+
+	// 再次分享重新等待确认
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessRequiringAck(ctx, "lab1", specialist.id, "read", "")
+	})
+	if shares := env.unacknowledgedShares(patient); len(shares) != 1 || shares[0].ReadTxID != "" {
+		t.Fatalf("a new share must wait for a new acknowledgement: %+v", shares)
+	}
+}
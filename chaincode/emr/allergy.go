@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	AllergyActive   = "active"
+	AllergyResolved = "resolved"
+
+	allergyIndex = "allergy"
+
+	// 过敏信息属于安全关键数据，读取规则较普通记录宽松
+	classificationCriticalSafety = "critical-safety"
+)
+
+var allergySeverities = []string{"mild", "moderate", "severe", "life-threatening"}
+
+// Allergy 过敏与不良反应条目
+type Allergy struct {
+	AllergyID      string `json:"allergyId"`
+	PatientID      string `json:"patientId"`
+	SubstanceCode  string `json:"substanceCode"`
+	Reaction       string `json:"reaction"`
+	Severity       string `json:"severity"`
+	Status         string `json:"status"`
+	Classification string `json:"classification"`
+	RecordedBy     string `json:"recordedBy"`
+	RecordedAt     string `json:"recordedAt"`
+	ResolvedBy     string `json:"resolvedBy,omitempty"`
+	ResolvedAt     string `json:"resolvedAt,omitempty"`
+	ResolveReason  string `json:"resolveReason,omitempty"`
+}
+
+type AllergyEvent struct {
+	AllergyID string `json:"allergyId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func allergyKey(ctx contractapi.TransactionContextInterface, patientID, allergyID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(allergyIndex, []string{patientID, allergyID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create allergy key: %w", err)
+	}
+	return key, nil
+}
+
+// anyPatientRecord 经 patient~record 索引遍历患者记录，match 命中任一条即返回 true
+func anyPatientRecord(ctx contractapi.TransactionContextInterface, patientID string, match func(recordID string) (bool, error)) (bool, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(patientRecordIndex, []string{patientID})
+	if err != nil {
+		return false, fmt.Errorf("failed to query %s index: %w", patientRecordIndex, err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return false, fmt.Errorf("failed to iterate %s index: %w", patientRecordIndex, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return false, fmt.Errorf("failed to split %s index key: %w", patientRecordIndex, err)
+		}
+		ok, err := match(attrs[1])
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// requireAllergyWriter 患者本人，或对其任一记录持有 write 权限
+func (s *SmartContract) requireAllergyWriter(ctx contractapi.TransactionContextInterface, patientID string) (string, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if callerID == patientID {
+		return callerID, nil
+	}
+	allowed, err := anyPatientRecord(ctx, patientID, func(recordID string) (bool, error) {
+		return s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	})
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("access denied: write permission on a record of %s required", patientID)
+	}
+	return callerID, nil
+}
+
+// AddAllergy 登记过敏条目
+func (s *SmartContract) AddAllergy(ctx contractapi.TransactionContextInterface, patientID, allergyJson string) error {
+	var allergy Allergy
+	if err := unmarshalArg(allergyJson, &allergy); err != nil {
+		return fmt.Errorf("invalid allergy json: %w", err)
+	}
+	if allergy.AllergyID == "" || allergy.SubstanceCode == "" || allergy.Reaction == "" {
+		return fmt.Errorf("missing required fields: allergyId, substanceCode and reaction are required")
+	}
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validateAddress(allergy.AllergyID); err != nil {
+		return fmt.Errorf("invalid allergyId: %w", err)
+	}
+	if !containsString(allergySeverities, allergy.Severity) {
+		return fmt.Errorf("invalid severity: %s", allergy.Severity)
+	}
+
+	callerID, err := s.requireAllergyWriter(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	key, err := allergyKey(ctx, patientID, allergy.AllergyID)
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("allergy already exists: %s", allergy.AllergyID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	allergy.PatientID = patientID
+	allergy.Status = AllergyActive
+	allergy.Classification = classificationCriticalSafety
+	allergy.RecordedBy = callerID
+	allergy.RecordedAt = now
+	allergy.ResolvedBy, allergy.ResolvedAt, allergy.ResolveReason = "", "", ""
+	if err := putJSON(ctx, key, allergy); err != nil {
+		return err
+	}
+	return emitAllergyEvent(ctx, "AllergyAdded", &allergy, callerID, now)
+}
+
+// ResolveAllergy 将过敏条目标记为已排除；条目保留供追溯
+func (s *SmartContract) ResolveAllergy(ctx contractapi.TransactionContextInterface, patientID, allergyID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	callerID, err := s.requireAllergyWriter(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	key, err := allergyKey(ctx, patientID, allergyID)
+	if err != nil {
+		return err
+	}
+	var allergy Allergy
+	found, err := getJSON(ctx, key, &allergy)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("allergy not found: %s", allergyID)
+	}
+	if allergy.Status != AllergyActive {
+		return fmt.Errorf("allergy already resolved: %s", allergyID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	allergy.Status = AllergyResolved
+	allergy.ResolvedBy = callerID
+	allergy.ResolvedAt = now
+	allergy.ResolveReason = reason
+	if err := putJSON(ctx, key, allergy); err != nil {
+		return err
+	}
+	return emitAllergyEvent(ctx, "AllergyResolved", &allergy, callerID, now)
+}
+
+// GetAllergies 患者本人，或对其任一记录可访问的 doctor/nurse 均可读取
+func (s *SmartContract) GetAllergies(ctx contractapi.TransactionContextInterface, patientID string) ([]*Allergy, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		allowed := false
+		if isClinician {
+			allowed, err = anyPatientRecord(ctx, patientID, func(recordID string) (bool, error) {
+				return s.CheckAccess(ctx, recordID, callerID)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("access denied: %s cannot view allergies of %s", callerID, patientID)
+		}
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(allergyIndex, []string{patientID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allergies: %w", err)
+	}
+	defer iterator.Close()
+
+	allergies := []*Allergy{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate allergies: %w", err)
+		}
+		var allergy Allergy
+		if err := unmarshalArg(string(kv.Value), &allergy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allergy: %w", err)
+		}
+		allergies = append(allergies, &allergy)
+	}
+	return allergies, nil
+}
+
+func emitAllergyEvent(ctx contractapi.TransactionContextInterface, name string, allergy *Allergy, callerID, now string) error {
+	return emitEvent(ctx, name, AllergyEvent{
+		AllergyID: allergy.AllergyID,
+		PatientID: allergy.PatientID,
+		Status:    allergy.Status,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testAllergy = `{"allergyId":"alg1","substanceCode":"RXN-7980","reaction":"hives","severity":"moderate"}`
+
+func TestAllergyRelaxedReadPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	nurse2 := newIdentity("nurse2", "Org2MSP", "role", "nurse")
+
+	env.mustFail(nurse, "write permission", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddAllergy(ctx, patient.id, testAllergy)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddAllergy(ctx, patient.id, testAllergy)
+	})
+	env.expectEvent("AllergyAdded", nil)
+
+	readAllergies := func(id *testIdentity) []*Allergy {
+		var allergies []*Allergy
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			allergies, err = env.cc.GetAllergies(ctx, patient.id)
+			return err
+		})
+		return allergies
+	}
+
+	// 对 rec2 的只读授权即可读取整个患者的过敏清单
+	env.mustFail(nurse2, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAllergies(ctx, patient.id)
+		return err
+	})
+	env.grant(patient, "rec2", nurse2.id, "read", "")
+	if got := readAllergies(nurse2); len(got) != 1 || got[0].Classification != classificationCriticalSafety {
+		t.Fatalf("unexpected allergies: %+v", got)
+	}
+
+	// 非临床角色即使持有授权也不适用宽松规则
+	env.grant(patient, "rec1", other.id, "read", "")
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAllergies(ctx, patient.id)
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResolveAllergy(ctx, patient.id, "alg1", "challenge test negative")
+	})
+	env.expectEvent("AllergyResolved", nil)
+	if got := readAllergies(patient); got[0].Status != AllergyResolved || got[0].ResolvedBy != patient.id {
+		t.Fatalf("allergy must be resolved: %+v", got[0])
+	}
+}
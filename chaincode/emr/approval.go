@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+type UpdateApprovalSetEvent struct {
+	PatientID string `json:"patientId"`
+	RecordID  string `json:"recordId,omitempty"`
+	Required  bool   `json:"required"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// updateApprovalKey update-approval:{patientId}，患者偏好：其全部记录的他人更新须经批准
+func updateApprovalKey(patientID string) string {
+	return "update-approval:" + keySegment(patientID)
+}
+
+// needsPatientApproval 调用者不是患者本人，且记录或患者偏好要求他人的更新经患者批准
+func needsPatientApproval(ctx contractapi.TransactionContextInterface, record *MedicalRecord) (bool, error) {
+	isPatient, err := callerIs(ctx, record.PatientID)
+	if err != nil || isPatient {
+		return false, err
+	}
+	if record.RequirePatientApproval {
+		return true, nil
+	}
+	var required bool
+	if _, err := getJSON(ctx, updateApprovalKey(record.PatientID), &required); err != nil {
+		return false, err
+	}
+	return required, nil
+}
+
+// SetPatientUpdateApproval 设置患者偏好：required 为 true 时他人对该患者任一记录的 UpdateMedicalRecord
+// 只生成待批准的提议；患者本人或有 consent 委托范围的代理人可设置
+func (s *SmartContract) SetPatientUpdateApproval(ctx contractapi.TransactionContextInterface, patientID string, required bool) error {
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "set update approval")
+	if err != nil {
+		return err
+	}
+	if required {
+		err = putJSON(ctx, updateApprovalKey(patientID), true)
+	} else {
+		err = ctx.GetStub().DelState(updateApprovalKey(patientID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store update approval: %w", err)
+	}
+	return emitUpdateApprovalSet(ctx, patientID, "", required, callerID)
+}
+
+// SetRecordUpdateApproval 仅对单条记录设置同样的要求；患者偏好开启时关闭记录级开关不生效
+func (s *SmartContract) SetRecordUpdateApproval(ctx contractapi.TransactionContextInterface, recordID string, required bool) error {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	callerID, err := requirePatientOrAgent(ctx, record.PatientID, "consent", "set update approval")
+	if err != nil {
+		return err
+	}
+	record.RequirePatientApproval = required
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	return emitUpdateApprovalSet(ctx, record.PatientID, recordID, required, callerID)
+}
+
+func emitUpdateApprovalSet(ctx contractapi.TransactionContextInterface, patientID, recordID string, required bool, callerID string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "UpdateApprovalSet", UpdateApprovalSetEvent{
+		PatientID: patientID,
+		RecordID:  recordID,
+		Required:  required,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "UpdateApprovalSet",
+	})
+}
+
+// approverProposal 待批准的提议，调用者须为提议指定的患者本人
+func approverProposal(ctx contractapi.TransactionContextInterface, proposalID, commentHash string) (*RecordUpdateProposal, string, time.Time, error) {
+	if commentHash != "" && !sha256HexPattern.MatchString(commentHash) {
+		return nil, "", time.Time{}, fmt.Errorf("commentHash must be a lowercase hex sha256 digest")
+	}
+	proposal, now, err := pendingProposal(ctx, proposalID)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if proposal.Approver == "" {
+		return nil, "", time.Time{}, fmt.Errorf("update proposal %s does not await approval: use CommitRecordUpdate", proposalID)
+	}
+	isApprover, err := callerIs(ctx, proposal.Approver)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if !isApprover {
+		return nil, "", time.Time{}, fmt.Errorf("access denied: only the patient can approve or reject the update")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	proposal.CommentHash = commentHash
+	return proposal, callerID, now, nil
+}
+
+// ApproveUpdate 患者批准他人提交的更新并写入记录，发出带 proposalId 的 RecordUpdated；
+// commentHash 为线下留存的批注的 sha256，可为空
+func (s *SmartContract) ApproveUpdate(ctx contractapi.TransactionContextInterface, proposalID, commentHash string) error {
+	proposal, callerID, now, err := approverProposal(ctx, proposalID, commentHash)
+	if err != nil {
+		return err
+	}
+	record, err := resolveProposal(ctx, proposal, ProposalApproved, callerID, now)
+	if err != nil {
+		return err
+	}
+	return emitProposalApplied(ctx, proposal, record)
+}
+
+// RejectUpdate 患者拒绝他人提交的更新，记录不变；commentHash 同 ApproveUpdate
+func (s *SmartContract) RejectUpdate(ctx contractapi.TransactionContextInterface, proposalID, commentHash string) error {
+	proposal, callerID, now, err := approverProposal(ctx, proposalID, commentHash)
+	if err != nil {
+		return err
+	}
+	if _, err := resolveProposal(ctx, proposal, ProposalRejected, callerID, now); err != nil {
+		return err
+	}
+	return emitProposalEvent(ctx, "UpdateRejected", proposal, proposal.ResolvedAt, callerID)
+}
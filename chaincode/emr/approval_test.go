@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestPatientApprovesUpdates(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetRecordUpdateApproval(ctx, "rec1", true)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetRecordUpdateApproval(ctx, "rec1", true)
+	})
+	original := env.storedRecord("rec1").ContentHash
+	newHash := strings.Repeat("b", 64)
+	update := func(recordID, contentHash string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.UpdateMedicalRecord(ctx, recordID, "bafy"+contentHash[:8], contentHash)
+		}
+	}
+
+	var proposalID string
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		proposalID = ctx.GetStub().GetTxID()
+		return update("rec1", newHash)(ctx)
+	})
+	var pending RecordUpdateProposalEvent
+	env.expectEvent("UpdatePendingApproval", &pending)
+	if pending.ProposalID != proposalID || pending.PatientID != patient.id {
+		t.Fatalf("unexpected event: %+v", pending)
+	}
+	if record := env.storedRecord("rec1"); record.ContentHash != original {
+		t.Fatalf("a pending update must not change the record: %+v", record)
+	}
+	// 批量更新不能绕过批准，其他记录不受影响
+	env.mustFail(doctor, "requires the patient's approval", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, `[{"recordId":"rec1","ipfsCid":"bafybatch","contentHash":"`+newHash+`"}]`)
+	})
+	env.mustInvoke(doctor, update("rec2", newHash))
+	env.mustFail(doctor, "awaits the patient's approval", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, proposalID)
+	})
+
+	comment := strings.Repeat("cd", 32)
+	approve := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveUpdate(ctx, proposalID, comment)
+	}
+	env.mustFail(doctor, "only the patient", approve)
+	env.mustFail(patient, "commentHash must be", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveUpdate(ctx, proposalID, "not-a-hash")
+	})
+	env.mustInvoke(patient, approve)
+	var updated RecordUpdatedEvent
+	env.expectEvent("RecordUpdated", &updated)
+	if updated.ProposalID != proposalID || updated.ContentHash != newHash || updated.CallerID != patient.id {
+		t.Fatalf("unexpected event: %+v", updated)
+	}
+	if record := env.storedRecord("rec1"); record.ContentHash != newHash {
+		t.Fatalf("approved update must be applied: %+v", record)
+	}
+	env.mustFail(patient, "is approved", approve)
+
+	// 患者本人的更新直接生效
+	env.mustInvoke(patient, update("rec1", strings.Repeat("c", 64)))
+	env.expectEvent("RecordUpdated", nil)
+}
+
+func TestPatientRejectsUpdates(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPatientUpdateApproval(ctx, patient.id, true)
+	})
+	original := env.storedRecord("rec1").ContentHash
+	var proposalID string
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		proposalID = ctx.GetStub().GetTxID()
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", strings.Repeat("b", 64))
+	})
+	env.expectEvent("UpdatePendingApproval", nil)
+	// 走两阶段提议也须患者提交
+	second := env.proposeUpdate(doctor, "rec1", strings.Repeat("d", 64))
+	env.grant(patient, "rec1", specialist.id, "write", "")
+	env.mustFail(specialist, "only the patient can commit", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, second)
+	})
+
+	comment := strings.Repeat("ef", 32)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RejectUpdate(ctx, proposalID, comment)
+	})
+	var rejected RecordUpdateProposalEvent
+	env.expectEvent("UpdateRejected", &rejected)
+	if rejected.Status != ProposalRejected {
+		t.Fatalf("unexpected event: %+v", rejected)
+	}
+	if record := env.storedRecord("rec1"); record.ContentHash != original {
+		t.Fatalf("a rejected update must not change the record: %+v", record)
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		proposal, err := env.cc.GetRecordUpdateProposal(ctx, proposalID)
+		if err == nil && (proposal.CommentHash != comment || proposal.ResolvedBy != patient.id) {
+			t.Fatalf("unexpected proposal: %+v", proposal)
+		}
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPatientUpdateApproval(ctx, patient.id, false)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", strings.Repeat("b", 64))
+	})
+	env.expectEvent("RecordUpdated", nil)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// 第三方应用的 OAuth 风格授权范围
+const (
+	AppScopeRecordsRead     = "records:read"
+	AppScopeRecordsMetadata = "records:metadata"
+	AppScopeConsentsManage  = "consents:manage"
+)
+
+var appScopes = []string{AppScopeRecordsRead, AppScopeRecordsMetadata, AppScopeConsentsManage}
+
+// appFunctionScopes 应用身份可调用的函数及其所需范围；不在表中的函数一律拒绝。
+// 范围按患者校验：records:read 经 CheckAccess 的派生规则，records:metadata 在 GetRecordMetadata，
+// consents:manage 在 actsForPatient
+var appFunctionScopes = map[string]string{
+	"ReadRecord":            AppScopeRecordsRead,
+	"GetRecord":             AppScopeRecordsRead,
+	"ReadRecordAudited":     AppScopeRecordsRead,
+	"GetRecordMetadata":     AppScopeRecordsMetadata,
+	"GrantAccess":           AppScopeConsentsManage,
+	"GrantAccessWithExpiry": AppScopeConsentsManage,
+	"GrantAccessWithUses":   AppScopeConsentsManage,
+	"RevokeAccess":          AppScopeConsentsManage,
+	"SuspendAccess":         AppScopeConsentsManage,
+	"ResumeAccess":          AppScopeConsentsManage,
+}
+
+// RegisteredApp 已登记的第三方应用及其证书
+type RegisteredApp struct {
+	AppID        string `json:"appId"`
+	CertID       string `json:"certId"`
+	Name         string `json:"name"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+// AppAuthorization 患者授予应用的范围
+type AppAuthorization struct {
+	PatientID string   `json:"patientId"`
+	AppID     string   `json:"appId"`
+	Scopes    []string `json:"scopes"`
+	GrantedBy string   `json:"grantedBy"`
+	GrantedAt string   `json:"grantedAt"`
+}
+
+type AppAuthorizationEvent struct {
+	PatientID string   `json:"patientId"`
+	AppID     string   `json:"appId"`
+	Scopes    []string `json:"scopes"`
+	Timestamp string   `json:"timestamp"`
+	CallerID  string   `json:"callerId"`
+	EventType string   `json:"eventType"`
+}
+
+func appKey(appID string) string {
+	return "app:" + appID
+}
+
+func appCertKey(certID string) string {
+	return "app-cert:" + certID
+}
+
+// appScopeKey appscope:{patientId}:{appId}
+func appScopeKey(patientID, appID string) string {
+	return "appscope:" + keySegment(patientID) + ":" + keySegment(appID)
+}
+
+// appForIdentity 返回证书所属应用 ID，非应用身份返回空串
+func appForIdentity(ctx contractapi.TransactionContextInterface, certID string) (string, error) {
+	data, err := ctx.GetStub().GetState(appCertKey(certID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read app certificate: %w", err)
+	}
+	return string(data), nil
+}
+
+// appHasScope 应用身份 userID 是否持有患者授予的 scope；records:read 隐含 records:metadata
+func appHasScope(ctx contractapi.TransactionContextInterface, patientID, userID, scope string) (bool, error) {
+	appID, err := appForIdentity(ctx, userID)
+	if err != nil || appID == "" {
+		return false, err
+	}
+	var auth AppAuthorization
+	found, err := getJSON(ctx, appScopeKey(patientID, appID), &auth)
+	if err != nil || !found {
+		return false, err
+	}
+	if scope == AppScopeRecordsMetadata && containsString(auth.Scopes, AppScopeRecordsRead) {
+		return true, nil
+	}
+	return containsString(auth.Scopes, scope), nil
+}
+
+// appGrantsAccess 派生访问规则：患者授予 records:read 的应用可读取其记录
+func appGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	return appHasScope(ctx, record.PatientID, userID, AppScopeRecordsRead)
+}
+
+// assertAppScope 应用身份只能调用 appFunctionScopes 中的函数
+func assertAppScope(ctx contractapi.TransactionContextInterface) error {
+	certID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	appID, err := appForIdentity(ctx, certID)
+	if err != nil || appID == "" {
+		return err
+	}
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	// 非默认合约的函数名带 "{合约名}:" 前缀
+	function = function[strings.LastIndex(function, ":")+1:]
+	if _, ok := appFunctionScopes[function]; !ok {
+		return fmt.Errorf("access denied: application %s cannot call %s", appID, function)
+	}
+	return nil
+}
+
+// beforeTransaction 各合约的 BeforeTransaction：先拦截被冻结的身份，再限制应用身份可调用的函数
+func beforeTransaction(ctx contractapi.TransactionContextInterface) error {
+	if err := assertNotFrozen(ctx); err != nil {
+		return err
+	}
+	return assertAppScope(ctx)
+}
+
+func emitAppEvent(ctx contractapi.TransactionContextInterface, eventName, patientID, appID string, scopes []string, now, callerID string) error {
+	return emitEvent(ctx, eventName, AppAuthorizationEvent{
+		PatientID: patientID,
+		AppID:     appID,
+		Scopes:    scopes,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: eventName,
+	})
+}
+
+// RegisterApp admin 登记应用及其证书 ID；一张证书只能属于一个应用
+func (s *SmartContract) RegisterApp(ctx contractapi.TransactionContextInterface, appID, certID, name string) error {
+	if err := validateAddress(appID); err != nil {
+		return fmt.Errorf("invalid appID: %w", err)
+	}
+	if certID == "" || name == "" {
+		return fmt.Errorf("certID and name are required")
+	}
+	callerID, _, err := requireAdminCaller(ctx, "register applications")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, appKey(appID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("application already registered: %s", appID)
+	}
+	owner, err := appForIdentity(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		return fmt.Errorf("certificate already belongs to application %s", owner)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	app := RegisteredApp{AppID: appID, CertID: certID, Name: name, RegisteredBy: callerID, RegisteredAt: now.Format(time.RFC3339)}
+	if err := putJSON(ctx, appKey(appID), app); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(appCertKey(certID), []byte(appID)); err != nil {
+		return fmt.Errorf("failed to store app certificate: %w", err)
+	}
+	return nil
+}
+
+// SetAppScopes 患者（或有 consent 范围的代理人）整体设置应用的授权范围
+func (s *SmartContract) SetAppScopes(ctx contractapi.TransactionContextInterface, patientID, appID, scopesJson string) error {
+	var scopes []string
+	if err := unmarshalArg(scopesJson, &scopes); err != nil {
+		return fmt.Errorf("invalid scopes json: %w", err)
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required: use RevokeApp to remove an application")
+	}
+	for _, scope := range scopes {
+		if !containsString(appScopes, scope) {
+			return fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "authorize applications")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, appKey(appID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("application not registered: %s", appID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	auth := AppAuthorization{PatientID: patientID, AppID: appID, Scopes: scopes, GrantedBy: callerID, GrantedAt: now}
+	if err := putJSON(ctx, appScopeKey(patientID, appID), auth); err != nil {
+		return err
+	}
+	return emitAppEvent(ctx, "AppScopesUpdated", patientID, appID, scopes, now, callerID)
+}
+
+// RevokeApp 一笔交易删除患者授予应用的全部范围；应用的访问均由范围实时派生，删除即生效
+func (s *SmartContract) RevokeApp(ctx contractapi.TransactionContextInterface, patientID, appID string) error {
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "revoke applications")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, appScopeKey(patientID, appID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("application %s is not authorized by %s", appID, patientID)
+	}
+	if err := ctx.GetStub().DelState(appScopeKey(patientID, appID)); err != nil {
+		return fmt.Errorf("failed to delete app scopes: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitAppEvent(ctx, "AppRevoked", patientID, appID, nil, now, callerID)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var portalApp = newIdentity("eDUwOTo6Q049cG9ydGFsLWFwcCxPVT1jbGllbnQ6OkNOPWNhLm9yZzE=", "Org1MSP")
+
+// callAs 以 identity 身份先执行 beforeTransaction 再执行 fn，模拟合约对 function 的一次调用
+func (e *testEnv) callAs(identity *testIdentity, function string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	e.stub.function = function
+	defer func() { e.stub.function = "" }()
+	return e.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		if err := beforeTransaction(ctx); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
+
+func TestAppScopesLimitApplicationCalls(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterApp(ctx, "portal", portalApp.id, "Patient Portal")
+	})
+	readRecord := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	}
+	readMetadata := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetRecordMetadata(ctx, "rec1")
+		return err
+	}
+	grantNurse := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	}
+
+	if err := env.callAs(portalApp, "GetRecordMetadata", readMetadata); err == nil {
+		t.Fatal("unauthorized app must not read metadata")
+	}
+	env.mustFail(patient, "invalid scope", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetAppScopes(ctx, patient.id, "portal", `["records:write"]`)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetAppScopes(ctx, patient.id, "portal", `["records:metadata"]`)
+	})
+	env.expectEvent("AppScopesUpdated", nil)
+	if err := env.callAs(portalApp, "GetRecordMetadata", readMetadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.callAs(portalApp, "ReadRecord", readRecord); err == nil {
+		t.Fatal("records:metadata must not allow reading the record")
+	}
+	if err := env.callAs(portalApp, "GrantAccess", grantNurse); err == nil {
+		t.Fatal("records:metadata must not allow managing consents")
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetAppScopes(ctx, patient.id, "portal", `["records:read","consents:manage"]`)
+	})
+	if err := env.callAs(portalApp, "SmartContract:ReadRecord", readRecord); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.callAs(portalApp, "GrantAccess", grantNurse); err != nil {
+		t.Fatal(err)
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("app with consents:manage must be able to grant")
+	}
+	// 不在范围表中的函数一律拒绝，即便应用持有全部范围
+	if err := env.callAs(portalApp, "SetAppScopes", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetAppScopes(ctx, patient.id, "portal", `["records:read"]`)
+	}); err == nil {
+		t.Fatal("apps must not call functions outside their scope table")
+	}
+	// 非应用身份不受限
+	if err := env.callAs(doctor, "ReadRecord", readRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeApp(ctx, patient.id, "portal")
+	})
+	env.expectEvent("AppRevoked", nil)
+	if err := env.callAs(portalApp, "ReadRecord", readRecord); err == nil {
+		t.Fatal("revoked app must lose access in the same transaction")
+	}
+	if err := env.callAs(portalApp, "GetRecordMetadata", readMetadata); err == nil {
+		t.Fatal("revoked app must lose metadata access")
+	}
+}
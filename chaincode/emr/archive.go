@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// archivedRecordIndex 患者 → 已归档记录索引，供 ListArchivedRecords 分页
+const archivedRecordIndex = "archived~record"
+
+type RecordStatusEvent struct {
+	RecordID  string `json:"recordId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// listable 默认列表只返回未归档记录；早于 status 字段创建的记录按 active 处理
+func listable(record *MedicalRecord) bool {
+	return record.Status != RecordArchived
+}
+
+// setRecordStatus 患者本人或持 consent 委托的代理人切换记录的归档状态
+func setRecordStatus(ctx contractapi.TransactionContextInterface, recordID, status, eventName string) error {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	callerID, err := requirePatientOrAgent(ctx, record.PatientID, "consent", "change the archive status of a record")
+	if err != nil {
+		return err
+	}
+	current := record.Status
+	if current == "" {
+		current = RecordActive
+	}
+	if current == status {
+		return fmt.Errorf("record %s is already %s", recordID, status)
+	}
+
+	record.Status = status
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	if status == RecordArchived {
+		err = putIndex(ctx, archivedRecordIndex, record.PatientID, recordID)
+	} else {
+		err = delIndex(ctx, archivedRecordIndex, record.PatientID, recordID)
+	}
+	if err != nil {
+		return err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, RecordStatusEvent{
+		RecordID:  recordID,
+		PatientID: record.PatientID,
+		Status:    status,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: eventName,
+	})
+}
+
+// ArchiveRecord 归档记录：不再出现在默认列表中，也不能再更新，锚点与哈希仍可读取核验
+func (s *SmartContract) ArchiveRecord(ctx contractapi.TransactionContextInterface, recordID string) error {
+	return setRecordStatus(ctx, recordID, RecordArchived, "RecordArchived")
+}
+
+// UnarchiveRecord 恢复为 active
+func (s *SmartContract) UnarchiveRecord(ctx contractapi.TransactionContextInterface, recordID string) error {
+	return setRecordStatus(ctx, recordID, RecordActive, "RecordUnarchived")
+}
+
+// ListArchivedRecords 分页列出患者已归档的记录；患者本人返回完整记录，其他调用者只返回可读取记录的元数据
+func (s *SmartContract) ListArchivedRecords(ctx contractapi.TransactionContextInterface, patientID string, pageSize int32, bookmark string) (*RecordPage, error) {
+	if err := validateAddress(patientID); err != nil {
+		return nil, fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := callerQueryScope(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(archivedRecordIndex, []string{patientID}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s index: %w", archivedRecordIndex, err)
+	}
+	defer iterator.Close()
+
+	page := &RecordPage{Records: []*MedicalRecord{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate %s index: %w", archivedRecordIndex, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split %s index key: %w", archivedRecordIndex, err)
+		}
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil {
+			if record = scope.view(record); record == nil {
+				continue
+			}
+		} else if !isPatient {
+			allowed, err := s.callerAccess(ctx, record, callerID)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+			record = withoutContent(record)
+		}
+		page.Records = append(page.Records, record)
+	}
+	return page, nil
+}
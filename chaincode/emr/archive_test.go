@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func recordIDs(records []*MedicalRecord) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.RecordID)
+	}
+	return ids
+}
+
+func TestArchiveRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+
+	env.mustFail(doctor, "only the patient can change the archive status", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ArchiveRecord(ctx, "rec1")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ArchiveRecord(ctx, "rec1")
+	})
+	var event RecordStatusEvent
+	env.expectEvent("RecordArchived", &event)
+	if event.Status != RecordArchived || event.PatientID != patient.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(patient, "already archived", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ArchiveRecord(ctx, "rec1")
+	})
+	env.mustFail(doctor, "record rec1 is archived", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", "b")
+	})
+
+	// 默认列表排除归档记录，归档记录仍可按 ID 读取核验
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		records, err := env.cc.ListRecordsByPatient(ctx, patient.id, "")
+		if err != nil {
+			return err
+		}
+		if ids := recordIDs(records); len(ids) != 1 || ids[0] != "rec2" {
+			t.Fatalf("default list must exclude archived records, got %v", ids)
+		}
+		page, err := env.cc.ListRecordsByCreator(ctx, doctor.id, 10, "")
+		if err != nil {
+			return err
+		}
+		if ids := recordIDs(page.Records); len(ids) != 1 || ids[0] != "rec2" {
+			t.Fatalf("creator list must exclude archived records, got %v", ids)
+		}
+		page, err = env.cc.SearchRecords(ctx, "indexPatientTimestamp", `{"patientId":"patient1"}`, 10, "")
+		if err != nil {
+			return err
+		}
+		if ids := recordIDs(page.Records); len(ids) != 1 || ids[0] != "rec2" {
+			t.Fatalf("search without a status condition must exclude archived records, got %v", ids)
+		}
+		page, err = env.cc.SearchRecords(ctx, "indexStatusTimestamp", `{"status":"archived"}`, 10, "")
+		if err != nil {
+			return err
+		}
+		if ids := recordIDs(page.Records); len(ids) != 1 || ids[0] != "rec1" {
+			t.Fatalf("explicit status search must return archived records, got %v", ids)
+		}
+		return nil
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.ReadRecord(ctx, "rec1")
+		if err == nil && record.Status != RecordArchived {
+			t.Fatalf("unexpected status: %s", record.Status)
+		}
+		return err
+	})
+
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListArchivedRecords(ctx, patient.id, 10, "")
+		if err == nil && len(page.Records) != 0 {
+			t.Fatal("archived list must still be filtered by CheckAccess")
+		}
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListArchivedRecords(ctx, patient.id, 10, "")
+		if err == nil && len(page.Records) != 1 {
+			t.Fatalf("unexpected archived list: %v", recordIDs(page.Records))
+		}
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnarchiveRecord(ctx, "rec1")
+	})
+	env.expectEvent("RecordUnarchived", nil)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListArchivedRecords(ctx, patient.id, 10, "")
+		if err != nil {
+			return err
+		}
+		if len(page.Records) != 0 {
+			t.Fatal("unarchived record must leave the archive index")
+		}
+		records, err := env.cc.ListRecordsByPatient(ctx, patient.id, "")
+		if err == nil && len(records) != 2 {
+			t.Fatalf("unarchived record must return to the default list, got %v", recordIDs(records))
+		}
+		return err
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+type AttachmentEvent struct {
+	RecordID    string `json:"recordId"`
+	CID         string `json:"cid"`
+	ContentHash string `json:"contentHash"`
+	VersionHash string `json:"versionHash"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// attachableRecord 读取未归档记录并校验调用者的 write 级权限
+func (s *SmartContract) attachableRecord(ctx contractapi.TransactionContextInterface, recordID, callerID string) (*MedicalRecord, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == RecordArchived {
+		return nil, fmt.Errorf("record %s is archived", recordID)
+	}
+	allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s cannot change attachments of record %s", callerID, recordID)
+	}
+	return record, nil
+}
+
+func saveAttachmentChange(ctx contractapi.TransactionContextInterface, record *MedicalRecord, part RecordPart, eventName, callerID string) error {
+	if err := putJSON(ctx, recordKey(record.RecordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, AttachmentEvent{
+		RecordID:    record.RecordID,
+		CID:         part.CID,
+		ContentHash: part.ContentHash,
+		VersionHash: record.VersionHash,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   eventName,
+	})
+}
+
+// AddAttachment 在记录末尾追加一个附件部分，主内容哈希不变，版本哈希推进。
+// 单部分记录首次追加时主内容转为第一部分，其 mediaType 与 size 未知，留空
+func (s *SmartContract) AddAttachment(ctx contractapi.TransactionContextInterface, recordID, attachmentJson string) error {
+	var part RecordPart
+	if err := unmarshalArg(attachmentJson, &part); err != nil {
+		return fmt.Errorf("invalid attachment json: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.validatePart(part); err != nil {
+		return fmt.Errorf("invalid attachment: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := s.attachableRecord(ctx, recordID, callerID)
+	if err != nil {
+		return err
+	}
+	if len(record.Parts) == 0 {
+		record.Parts = []RecordPart{{CID: record.IPCSCID, ContentHash: record.ContentHash}}
+	}
+	if partIndex(record.Parts, part.CID) >= 0 {
+		return fmt.Errorf("cid %s is already part of record %s", part.CID, recordID)
+	}
+	if len(record.Parts) >= maxRecordParts {
+		return fmt.Errorf("a record can have at most %d parts", maxRecordParts)
+	}
+	record.Parts = append(record.Parts, part)
+	advanceVersion(record, "attach:"+part.ContentHash)
+	return saveAttachmentChange(ctx, record, part, "AttachmentAdded", callerID)
+}
+
+// RemoveAttachment 按 cid 移除附件，版本哈希推进；主内容（第一部分）不能移除
+func (s *SmartContract) RemoveAttachment(ctx contractapi.TransactionContextInterface, recordID, cid string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := s.attachableRecord(ctx, recordID, callerID)
+	if err != nil {
+		return err
+	}
+	index := partIndex(record.Parts, cid)
+	if index == 0 || (index < 0 && cid == record.IPCSCID) {
+		return fmt.Errorf("the primary content of record %s cannot be removed", recordID)
+	}
+	if index < 0 {
+		return fmt.Errorf("cid %s is not an attachment of record %s", cid, recordID)
+	}
+	part := record.Parts[index]
+	record.Parts = append(record.Parts[:index], record.Parts[index+1:]...)
+	advanceVersion(record, "detach:"+part.ContentHash)
+	return saveAttachmentChange(ctx, record, part, "AttachmentRemoved", callerID)
+}
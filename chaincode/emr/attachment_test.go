@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestAttachmentLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	labPart := `{"cid":"bafylab","contentHash":"` + strings.Repeat("e", 64) + `","mediaType":"application/pdf","size":4096}`
+	read := func() *MedicalRecord {
+		t.Helper()
+		var record *MedicalRecord
+		env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			record, err = env.cc.ReadRecord(ctx, "rec1")
+			return err
+		})
+		return record
+	}
+
+	env.mustFail(nurse, "cannot change attachments", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddAttachment(ctx, "rec1", labPart)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddAttachment(ctx, "rec1", labPart)
+	})
+	var added AttachmentEvent
+	env.expectEvent("AttachmentAdded", &added)
+	record := read()
+	if len(record.Parts) != 2 || record.Parts[0].CID != "bafyrec1" || record.Parts[1].CID != "bafylab" {
+		t.Fatalf("primary content must become the first part: %+v", record.Parts)
+	}
+	if record.ContentHash != strings.Repeat("a", 64) || record.VersionHash == "" || added.VersionHash != record.VersionHash {
+		t.Fatalf("attachments must advance the version chain only: %+v", record)
+	}
+	env.mustFail(doctor, "already part of record", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddAttachment(ctx, "rec1", labPart)
+	})
+
+	env.mustFail(doctor, "cannot be removed", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveAttachment(ctx, "rec1", "bafyrec1")
+	})
+	env.mustFail(doctor, "not an attachment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveAttachment(ctx, "rec1", "bafymissing")
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveAttachment(ctx, "rec1", "bafylab")
+	})
+	var removed AttachmentEvent
+	env.expectEvent("AttachmentRemoved", &removed)
+	if after := read(); len(after.Parts) != 1 || removed.VersionHash == added.VersionHash || after.VersionHash != removed.VersionHash {
+		t.Fatalf("unexpected record after removal: %+v", after)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	coderRole      = "coder"
+	qaReviewerRole = "qa-reviewer"
+)
+
+var attestationTypes = []string{"documentation-complete", "coding-accurate", "clinically-valid"}
+
+// RecordAttestation 编码员或质控审核人对记录某一版本的质量签署，VersionHash 为签署时的版本
+type RecordAttestation struct {
+	AttestationID   string `json:"attestationId"`
+	RecordID        string `json:"recordId"`
+	ReviewerID      string `json:"reviewerId"`
+	ReviewerMSP     string `json:"reviewerMsp"`
+	AttestationType string `json:"attestationType"`
+	VersionHash     string `json:"versionHash"`
+	AttestedAt      string `json:"attestedAt"`
+}
+
+type RecordAttestedEvent struct {
+	AttestationID   string `json:"attestationId"`
+	RecordID        string `json:"recordId"`
+	ReviewerID      string `json:"reviewerId"`
+	AttestationType string `json:"attestationType"`
+	VersionHash     string `json:"versionHash"`
+	Timestamp       string `json:"timestamp"`
+	EventType       string `json:"eventType"`
+}
+
+// attestRecordKey attest-record:{recordId}:{epoch}:{attestationId}，值为签署
+func attestRecordKey(recordID, epoch, attestationID string) string {
+	return attestRecordPrefix(recordID) + epoch + ":" + attestationID
+}
+
+func attestRecordPrefix(recordID string) string {
+	return "attest-record:" + keySegment(recordID) + ":"
+}
+
+// attestReviewerKey attest-reviewer:{reviewerId}:{epoch}:{attestationId}，值为签署
+func attestReviewerKey(reviewerID, epoch, attestationID string) string {
+	return attestReviewerPrefix(reviewerID) + epoch + ":" + attestationID
+}
+
+func attestReviewerPrefix(reviewerID string) string {
+	return "attest-reviewer:" + keySegment(reviewerID) + ":"
+}
+
+// AttestRecord 调用者须有 coder 或 qa-reviewer 角色，reviewerID 须为调用者本人；
+// 同一审核人可多次签署，按时间保留全部历史
+func (s *SmartContract) AttestRecord(ctx contractapi.TransactionContextInterface, recordID, reviewerID, attestationType string) error {
+	if !containsString(attestationTypes, attestationType) {
+		return fmt.Errorf("invalid attestationType %q: must be one of %v", attestationType, attestationTypes)
+	}
+	reviewer, err := hasAnyRole(ctx, coderRole, qaReviewerRole)
+	if err != nil {
+		return err
+	}
+	if !reviewer {
+		return fmt.Errorf("access denied: only a coder or qa-reviewer can attest records")
+	}
+	isReviewer, err := callerIs(ctx, reviewerID)
+	if err != nil {
+		return err
+	}
+	if !isReviewer {
+		return fmt.Errorf("access denied: reviewerID must be the caller")
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	attestation := RecordAttestation{
+		AttestationID:   ctx.GetStub().GetTxID(),
+		RecordID:        recordID,
+		ReviewerID:      reviewerID,
+		ReviewerMSP:     mspID,
+		AttestationType: attestationType,
+		VersionHash:     versionOf(record),
+		AttestedAt:      now.Format(time.RFC3339),
+	}
+	epoch := epochSegment(now)
+	if err := putJSON(ctx, attestRecordKey(recordID, epoch, attestation.AttestationID), attestation); err != nil {
+		return err
+	}
+	if err := putJSON(ctx, attestReviewerKey(reviewerID, epoch, attestation.AttestationID), attestation); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordAttested", RecordAttestedEvent{
+		AttestationID:   attestation.AttestationID,
+		RecordID:        recordID,
+		ReviewerID:      reviewerID,
+		AttestationType: attestationType,
+		VersionHash:     attestation.VersionHash,
+		Timestamp:       attestation.AttestedAt,
+		EventType:       "RecordAttested",
+	})
+}
+
+// ListAttestationsByRecord 按时间返回记录的签署历史；有记录访问权的身份、编码员、质控审核人与审计员可查询
+func (s *SmartContract) ListAttestationsByRecord(ctx contractapi.TransactionContextInterface, recordID string) ([]*RecordAttestation, error) {
+	allowed, err := hasAnyRole(ctx, coderRole, qaReviewerRole, auditorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if err := s.requireRecordAccess(ctx, recordID); err != nil {
+			return nil, err
+		}
+	}
+	return scanAttestations(ctx, attestRecordPrefix(recordID))
+}
+
+// ListAttestationsByReviewer 按时间返回审核人的签署历史；限审核人本人、质控审核人与审计员
+func (s *SmartContract) ListAttestationsByReviewer(ctx contractapi.TransactionContextInterface, reviewerID string) ([]*RecordAttestation, error) {
+	if err := validateAddress(reviewerID); err != nil {
+		return nil, fmt.Errorf("invalid reviewerID: %w", err)
+	}
+	allowed, err := hasAnyRole(ctx, qaReviewerRole, auditorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = callerIs(ctx, reviewerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only the reviewer, a qa-reviewer or an auditor can list attestations")
+	}
+	return scanAttestations(ctx, attestReviewerPrefix(reviewerID))
+}
+
+func scanAttestations(ctx contractapi.TransactionContextInterface, prefix string) ([]*RecordAttestation, error) {
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan attestations: %w", err)
+	}
+	defer iterator.Close()
+
+	attestations := []*RecordAttestation{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate attestations: %w", err)
+		}
+		var attestation RecordAttestation
+		if err := json.Unmarshal(kv.Value, &attestation); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attestation: %w", err)
+		}
+		attestations = append(attestations, &attestation)
+	}
+	return attestations, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	coder      = newIdentity("coder1", "Org1MSP", "role", coderRole)
+	qaReviewer = newIdentity("qa1", "Org1MSP", "role", qaReviewerRole)
+)
+
+func (e *testEnv) attest(identity *testIdentity, recordID, attestationType string) {
+	e.t.Helper()
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.AttestRecord(ctx, recordID, identity.id, attestationType)
+	})
+}
+
+func TestAttestRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustFail(doctor, "only a coder or qa-reviewer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AttestRecord(ctx, "rec1", doctor.id, "documentation-complete")
+	})
+	env.mustFail(coder, "reviewerID must be the caller", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AttestRecord(ctx, "rec1", qaReviewer.id, "documentation-complete")
+	})
+	env.mustFail(coder, "invalid attestationType", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AttestRecord(ctx, "rec1", coder.id, "looks-fine")
+	})
+
+	env.attest(coder, "rec1", "coding-accurate")
+	var event RecordAttestedEvent
+	env.expectEvent("RecordAttested", &event)
+	if event.ReviewerID != coder.id || event.VersionHash != versionOf(env.storedRecord("rec1")) {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", strings.Repeat("b", 64))
+	})
+	env.advance(time.Minute)
+	env.attest(qaReviewer, "rec1", "documentation-complete")
+	env.attest(coder, "rec2", "coding-accurate")
+
+	var byRecord []*RecordAttestation
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		byRecord, err = env.cc.ListAttestationsByRecord(ctx, "rec1")
+		return err
+	})
+	if len(byRecord) != 2 || byRecord[0].ReviewerID != coder.id || byRecord[1].ReviewerID != qaReviewer.id {
+		t.Fatalf("unexpected record history: %+v", byRecord)
+	}
+	// 签署针对当时的版本
+	if byRecord[0].VersionHash == byRecord[1].VersionHash {
+		t.Fatalf("attestations must pin the attested version: %+v", byRecord)
+	}
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListAttestationsByRecord(ctx, "rec1")
+		return err
+	})
+
+	var byReviewer []*RecordAttestation
+	env.mustInvoke(coder, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		byReviewer, err = env.cc.ListAttestationsByReviewer(ctx, coder.id)
+		return err
+	})
+	if len(byReviewer) != 2 || byReviewer[0].RecordID != "rec1" || byReviewer[1].RecordID != "rec2" {
+		t.Fatalf("unexpected reviewer history: %+v", byReviewer)
+	}
+	env.mustFail(doctor, "only the reviewer", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListAttestationsByReviewer(ctx, coder.id)
+		return err
+	})
+	env.mustInvoke(qaReviewer, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListAttestationsByReviewer(ctx, coder.id)
+		return err
+	})
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	DenialNoPermission  = "no active permission"
+	DenialRecordMissing = "record not found"
+)
+
+// auditorRole 内部审计：无需逐条授权即可查询任意记录的审计轨迹与披露报表，但不能读取记录内容
+const auditorRole = "auditor"
+
+// DeniedAccess 被拒访问的审计条目
+type DeniedAccess struct {
+	RecordID     string `json:"recordId"`
+	AccessorID   string `json:"accessorId"`
+	MspID        string `json:"mspId"`
+	Action       string `json:"action"`
+	Reason       string `json:"reason"`
+	PurposeOfUse string `json:"purposeOfUse,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	TxID         string `json:"txId"`
+}
+
+type DeniedAccessPage struct {
+	Entries  []*DeniedAccess `json:"entries"`
+	Bookmark string          `json:"bookmark"`
+}
+
+// Disclosure 患者以外的身份成功读取记录的条目，构成患者的披露报表
+type Disclosure struct {
+	RecordID     string `json:"recordId"`
+	PatientID    string `json:"patientId"`
+	AccessorID   string `json:"accessorId"`
+	MspID        string `json:"mspId"`
+	PurposeOfUse string `json:"purposeOfUse,omitempty"`
+	// StatutoryBasis 与 CaseRef 仅监管访问填写，见 regulator.go
+	StatutoryBasis string `json:"statutoryBasis,omitempty"`
+	CaseRef        string `json:"caseRef,omitempty"`
+	Timestamp      string `json:"timestamp"`
+	TxID           string `json:"txId"`
+}
+
+type DisclosurePage struct {
+	Entries  []*Disclosure `json:"entries"`
+	Bookmark string        `json:"bookmark"`
+}
+
+// AuditedRead ReadRecordAudited 的结果；被拒时 Record 为空
+type AuditedRead struct {
+	Allowed bool           `json:"allowed"`
+	Reason  string         `json:"reason,omitempty"`
+	Record  *MedicalRecord `json:"record,omitempty"`
+}
+
+// keySegment 转义简单键中的 ID：标识符允许 ':'，不转义时 "a" 的前缀范围会命中 "a:b"
+var keySegment = strings.NewReplacer("%", "%25", ":", "%3A").Replace
+
+// deniedRecordKey denied:{recordId}:{epoch}:{txId}
+func deniedRecordKey(recordID string, at time.Time, txID string) string {
+	return "denied:" + keySegment(recordID) + ":" + epochSegment(at) + ":" + txID
+}
+
+// deniedAccessorKey denied-by:{accessorId}:{epoch}:{txId}
+func deniedAccessorKey(accessorID string, at time.Time, txID string) string {
+	return "denied-by:" + keySegment(accessorID) + ":" + epochSegment(at) + ":" + txID
+}
+
+// auditLogKey audit:{recordId}:{epoch}:{txId}:{eventType}，值为事件摘要
+func auditLogKey(recordID string, at time.Time, txID, eventType string) string {
+	return "audit:" + keySegment(recordID) + ":" + epochSegment(at) + ":" + txID + ":" + eventType
+}
+
+// disclosureKey disclosure:{patientId}:{epoch}:{txId}:{recordId}
+func disclosureKey(patientID string, at time.Time, txID, recordID string) string {
+	return "disclosure:" + keySegment(patientID) + ":" + epochSegment(at) + ":" + txID + ":" + keySegment(recordID)
+}
+
+func isAuditor(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return hasRole(ctx, auditorRole)
+}
+
+// parseTimeRange 解析审计查询的 RFC3339 区间
+func parseTimeRange(fromTimestamp, toTimestamp string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.RFC3339, fromTimestamp)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid fromTimestamp: %w", err)
+	}
+	to, err := time.Parse(time.RFC3339, toTimestamp)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid toTimestamp: %w", err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("toTimestamp must not be before fromTimestamp")
+	}
+	return from, to, nil
+}
+
+// scanAuditRange 分页扫描 prefix 下 [from, to] 内的条目，返回下一页书签；结束键取 to 的下一秒，区间两端都包含
+func scanAuditRange(ctx contractapi.TransactionContextInterface, prefix string, from, to time.Time, pageSize int32, bookmark string, visit func(value []byte) error) (string, error) {
+	startKey := prefix + epochSegment(from)
+	endKey := prefix + epochSegment(to.Add(time.Second))
+	iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return "", fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed to iterate audit entries: %w", err)
+		}
+		if err := visit(kv.Value); err != nil {
+			return "", err
+		}
+	}
+	return metadata.GetBookmark(), nil
+}
+
+// recordDisclosure 写入披露条目，补齐访问者 MSP、交易时间与交易 ID；与读取同笔交易提交
+func recordDisclosure(ctx contractapi.TransactionContextInterface, entry Disclosure) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	entry.MspID = mspID
+	entry.Timestamp = now.Format(time.RFC3339)
+	entry.TxID = ctx.GetStub().GetTxID()
+	return putJSON(ctx, disclosureKey(entry.PatientID, now, entry.TxID, entry.RecordID), entry)
+}
+
+// requireSubjectOrAuditor 调用者须为 subjectID 本人或 auditor 角色
+func requireSubjectOrAuditor(ctx contractapi.TransactionContextInterface, subjectID, what string) error {
+	isSubject, err := callerIs(ctx, subjectID)
+	if err != nil || isSubject {
+		return err
+	}
+	auditor, err := isAuditor(ctx)
+	if err != nil {
+		return err
+	}
+	if !auditor {
+		return fmt.Errorf("access denied: only %s", what)
+	}
+	return nil
+}
+
+// recordDenial 两个键都存完整条目，按记录或按访问者查询都只需一次范围扫描
+func recordDenial(ctx contractapi.TransactionContextInterface, recordID, accessorID, action, reason, purpose string) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	txID := ctx.GetStub().GetTxID()
+	entry := DeniedAccess{
+		RecordID:     recordID,
+		AccessorID:   accessorID,
+		MspID:        mspID,
+		Action:       action,
+		Reason:       reason,
+		PurposeOfUse: purpose,
+		Timestamp:    now.Format(time.RFC3339),
+		TxID:         txID,
+	}
+	if err := putJSON(ctx, deniedRecordKey(recordID, now, txID), entry); err != nil {
+		return err
+	}
+	return putJSON(ctx, deniedAccessorKey(accessorID, now, txID), entry)
+}
+
+// ReadRecordAudited 与 ReadRecord 相同的访问检查，但被拒时不返回错误：
+// 出错的交易不会提交，只有正常返回 {allowed:false, reason} 才能让拒绝审计与事件上链
+func (s *SmartContract) ReadRecordAudited(ctx contractapi.TransactionContextInterface, recordID string) (*AuditedRead, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var reasonCode string
+	deny := func(reason, purpose string) (*AuditedRead, error) {
+		if err := recordDenial(ctx, recordID, callerID, "read", reason, purpose); err != nil {
+			return nil, err
+		}
+		if err := emitRecordAccessedEvent(ctx, recordID, callerID, purpose, reasonCode, false); err != nil {
+			return nil, err
+		}
+		return &AuditedRead{Reason: reason}, nil
+	}
+
+	purpose, err := purposeOfUse(ctx)
+	if err != nil {
+		return deny(err.Error(), "")
+	}
+	if reasonCode, err = readReasonCode(ctx); err != nil {
+		return deny(err.Error(), purpose)
+	}
+	exists, err := assetExists(ctx, recordKey(recordID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record: %w", err)
+	}
+	if !exists {
+		return deny(DenialRecordMissing, purpose)
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := s.callerAccess(ctx, record, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		regulated, err := regulatorRead(ctx, record, callerID, purpose)
+		if err != nil {
+			return nil, err
+		}
+		if regulated {
+			return &AuditedRead{Allowed: true, Record: record}, nil
+		}
+		return deny(DenialNoPermission, purpose)
+	}
+	cleared, err := researchCleared(ctx, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if !cleared {
+		return deny(DenialNoDPA, purpose)
+	}
+	if err := recordAllowedRead(ctx, record, callerID, purpose, reasonCode); err != nil {
+		return nil, err
+	}
+	return &AuditedRead{Allowed: true, Record: record}, nil
+}
+
+// QueryDeniedAccess 按记录或按访问者（二选一）分页查询 [fromTimestamp, toTimestamp] 内的拒绝记录；
+// 按记录查询限患者本人，按访问者查询限访问者本人，auditor 角色均可
+func (s *SmartContract) QueryDeniedAccess(ctx contractapi.TransactionContextInterface, recordID, accessorID, fromTimestamp, toTimestamp string, pageSize int32, bookmark string) (*DeniedAccessPage, error) {
+	if (recordID == "") == (accessorID == "") {
+		return nil, fmt.Errorf("exactly one of recordID or accessorID is required")
+	}
+	from, to, err := parseTimeRange(fromTimestamp, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+
+	prefix, subjectID := "denied-by:"+keySegment(accessorID)+":", accessorID
+	if recordID != "" {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return nil, err
+		}
+		prefix, subjectID = "denied:"+keySegment(recordID)+":", record.PatientID
+	}
+	if err := requireSubjectOrAuditor(ctx, subjectID, "the record's patient, the accessor or an auditor can query denied access"); err != nil {
+		return nil, err
+	}
+
+	page := &DeniedAccessPage{Entries: []*DeniedAccess{}}
+	page.Bookmark, err = scanAuditRange(ctx, prefix, from, to, pageSize, bookmark, func(value []byte) error {
+		var entry DeniedAccess
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("failed to unmarshal denied access: %w", err)
+		}
+		page.Entries = append(page.Entries, &entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// QueryAuditLog 分页返回记录在 [fromTimestamp, toTimestamp] 内的事件摘要（授权变更、读取、拒绝等），按交易时间排序；
+// 限患者本人或 auditor 角色。审计员查询不要求记录仍存在
+func (s *SmartContract) QueryAuditLog(ctx contractapi.TransactionContextInterface, recordID, fromTimestamp, toTimestamp string, pageSize int32, bookmark string) (*EventPage, error) {
+	from, to, err := parseTimeRange(fromTimestamp, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	auditor, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !auditor {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return nil, err
+		}
+		isPatient, err := callerIs(ctx, record.PatientID)
+		if err != nil {
+			return nil, err
+		}
+		if !isPatient {
+			return nil, fmt.Errorf("access denied: only the record's patient or an auditor can query the audit log")
+		}
+	}
+
+	page := &EventPage{Events: []*EventSummary{}}
+	page.Bookmark, err = scanAuditRange(ctx, "audit:"+keySegment(recordID)+":", from, to, pageSize, bookmark, func(value []byte) error {
+		var summary EventSummary
+		if err := json.Unmarshal(value, &summary); err != nil {
+			return fmt.Errorf("failed to unmarshal event summary: %w", err)
+		}
+		page.Events = append(page.Events, &summary)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetDisclosureReport 分页返回患者记录在 [fromTimestamp, toTimestamp] 内被他人读取的披露条目；限患者本人或 auditor 角色
+func (s *SmartContract) GetDisclosureReport(ctx contractapi.TransactionContextInterface, patientID, fromTimestamp, toTimestamp string, pageSize int32, bookmark string) (*DisclosurePage, error) {
+	if err := validateAddress(patientID); err != nil {
+		return nil, fmt.Errorf("invalid patientID: %w", err)
+	}
+	from, to, err := parseTimeRange(fromTimestamp, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	if err := requireSubjectOrAuditor(ctx, patientID, "the patient or an auditor can view the disclosure report"); err != nil {
+		return nil, err
+	}
+
+	page := &DisclosurePage{Entries: []*Disclosure{}}
+	page.Bookmark, err = scanAuditRange(ctx, "disclosure:"+keySegment(patientID)+":", from, to, pageSize, bookmark, func(value []byte) error {
+		var entry Disclosure
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("failed to unmarshal disclosure: %w", err)
+		}
+		page.Entries = append(page.Entries, &entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
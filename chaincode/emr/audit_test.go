@@ -0,0 +1,174 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) auditedRead(identity *testIdentity, recordID string) *AuditedRead {
+	e.t.Helper()
+	var result *AuditedRead
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = e.cc.ReadRecordAudited(ctx, recordID)
+		return err
+	})
+	return result
+}
+
+func TestReadRecordAuditedPersistsDenials(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	if result := env.auditedRead(nurse, "rec1"); result.Allowed || result.Reason != DenialNoPermission || result.Record != nil {
+		t.Fatalf("unexpected denial: %+v", result)
+	}
+	var event RecordAccessedEvent
+	env.expectEvent("RecordAccessed", &event)
+	if event.Allowed {
+		t.Fatal("denied read must be reported as not allowed")
+	}
+	env.advance(time.Minute)
+	if result := env.auditedRead(nurse, "missing"); result.Allowed || result.Reason != DenialRecordMissing {
+		t.Fatalf("unexpected denial: %+v", result)
+	}
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	if result := env.auditedRead(nurse, "rec1"); !result.Allowed || result.Record == nil || result.Record.RecordID != "rec1" {
+		t.Fatalf("granted read must return the record: %+v", result)
+	}
+	to := env.stub.now.Format(time.RFC3339)
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryDeniedAccess(ctx, "rec1", "", from, to, 10, "")
+		if err == nil && (len(page.Entries) != 1 || page.Entries[0].AccessorID != nurse.id || page.Entries[0].MspID != nurse.msp || page.Entries[0].Action != "read") {
+			t.Fatalf("unexpected record denials: %+v", page)
+		}
+		return err
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryDeniedAccess(ctx, "", nurse.id, from, to, 1, "")
+		if err != nil {
+			return err
+		}
+		if len(page.Entries) != 1 || page.Entries[0].RecordID != "rec1" || page.Bookmark == "" {
+			t.Fatalf("unexpected first page: %+v", page)
+		}
+		next, err := env.cc.QueryDeniedAccess(ctx, "", nurse.id, from, to, 1, page.Bookmark)
+		if err == nil && (len(next.Entries) != 1 || next.Entries[0].Reason != DenialRecordMissing) {
+			t.Fatalf("unexpected second page: %+v", next)
+		}
+		return err
+	})
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryDeniedAccess(ctx, "", nurse.id, from, from, 10, "")
+		if err == nil && len(page.Entries) != 1 {
+			t.Fatalf("time range must bound the scan: %+v", page)
+		}
+		return err
+	})
+
+	env.mustFail(doctor, "only the record's patient, the accessor or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.QueryDeniedAccess(ctx, "", nurse.id, from, to, 10, "")
+		return err
+	})
+	env.mustFail(auditor, "exactly one of", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.QueryDeniedAccess(ctx, "rec1", nurse.id, from, to, 10, "")
+		return err
+	})
+}
+
+func TestDeniedAccessKeysEscapeSeparators(t *testing.T) {
+	at := time.Unix(0, 0)
+	// "a" 的前缀范围不能命中 "a:b"
+	prefix := "denied:" + keySegment("a") + ":"
+	if key := deniedRecordKey("a:b", at, "tx1"); key >= prefix && key < "denied:"+keySegment("a")+";" {
+		t.Fatalf("%s must fall outside the range of record a", key)
+	}
+}
+
+func TestAuditLogAndDisclosureReport(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	for _, identity := range []*testIdentity{nurse, patient} {
+		env.advance(time.Minute)
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.ReadRecord(ctx, "rec1")
+			return err
+		})
+	}
+	to := env.stub.now.Format(time.RFC3339)
+
+	for _, identity := range []*testIdentity{patient, auditor} {
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.QueryAuditLog(ctx, "rec1", from, to, 10, "")
+			if err != nil {
+				return err
+			}
+			last := page.Events[len(page.Events)-1]
+			if len(page.Events) < 3 || last.EventType != "RecordAccessed" || last.RecordID != "rec1" {
+				t.Fatalf("unexpected audit log: %+v", page.Events)
+			}
+			report, err := env.cc.GetDisclosureReport(ctx, patient.id, from, to, 10, "")
+			if err == nil && (len(report.Entries) != 1 || report.Entries[0].AccessorID != nurse.id || report.Entries[0].MspID != nurse.msp) {
+				t.Fatalf("only reads by others are disclosures: %+v", report.Entries)
+			}
+			return err
+		})
+	}
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryAuditLog(ctx, "missing", from, to, 10, "")
+		if err == nil && len(page.Events) != 0 {
+			t.Fatalf("unexpected audit log: %+v", page.Events)
+		}
+		return err
+	})
+	env.mustFail(doctor, "only the record's patient or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.QueryAuditLog(ctx, "rec1", from, to, 10, "")
+		return err
+	})
+	env.mustFail(nurse, "only the patient or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDisclosureReport(ctx, patient.id, from, to, 10, "")
+		return err
+	})
+}
+
+func TestAuditorIsDeniedRecordContent(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	// 直接授权也不能让审计员读取内容
+	env.grant(patient, "rec1", auditor.id, "read", "")
+
+	env.mustFail(auditor, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	if result := env.auditedRead(auditor, "rec1"); result.Allowed || result.Record != nil {
+		t.Fatalf("auditor read must be denied: %+v", result)
+	}
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		metadata, err := env.cc.GetRecordMetadata(ctx, "rec1")
+		if err == nil && metadata.ContentHash == "" {
+			t.Fatalf("unexpected metadata: %+v", metadata)
+		}
+		return err
+	})
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		records, err := env.cc.ListRecordsByPatient(ctx, patient.id, "")
+		if err == nil && (len(records) != 1 || records[0].IPCSCID != "" || records[0].PatientID != patient.id) {
+			t.Fatalf("auditor lists must omit content: %+v", records)
+		}
+		return err
+	})
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListRecordsByCreator(ctx, doctor.id, 10, "")
+		if err == nil && (len(page.Records) != 1 || page.Records[0].IPCSCID != "") {
+			t.Fatalf("auditor lists must omit content: %+v", page.Records)
+		}
+		return err
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) verifyAuditChain(identity *testIdentity, recordID string) *AuditChainVerification {
+	e.t.Helper()
+	var result *AuditChainVerification
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = e.cc.VerifyAuditChain(ctx, recordID)
+		return err
+	})
+	return result
+}
+
+func TestAuditChainDetectsRemovedAndReorderedEntries(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.grant(patient, "rec1", specialist.id, "read", "")
+
+	result := env.verifyAuditChain(patient, "rec1")
+	if !result.Valid || result.Length != 4 {
+		t.Fatalf("unexpected verification: %+v", result)
+	}
+	// 每条记录各自成链
+	if result := env.verifyAuditChain(auditor, "rec2"); !result.Valid || result.Length != 1 {
+		t.Fatalf("unexpected verification: %+v", result)
+	}
+	var second EventSummary
+	if err := json.Unmarshal(env.stub.State[auditSeqKey("rec1", 2)], &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Seq != 2 || second.EventType != "AccessGranted" || second.PrevAuditHash == "" || second.EntryHash == "" {
+		t.Fatalf("unexpected audit entry: %+v", second)
+	}
+	env.mustFail(doctor, "only the record's patient or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyAuditChain(ctx, "rec1")
+		return err
+	})
+
+	// 调换两个条目
+	third := env.stub.State[auditSeqKey("rec1", 3)]
+	env.stub.State[auditSeqKey("rec1", 3)] = env.stub.State[auditSeqKey("rec1", 2)]
+	env.stub.State[auditSeqKey("rec1", 2)] = third
+	if result := env.verifyAuditChain(auditor, "rec1"); result.Valid || result.BrokenAt != 2 {
+		t.Fatalf("reordered entries must break the chain: %+v", result)
+	}
+	env.stub.State[auditSeqKey("rec1", 2)] = env.stub.State[auditSeqKey("rec1", 3)]
+	env.stub.State[auditSeqKey("rec1", 3)] = third
+
+	// 改写条目内容而不重算哈希
+	original := env.stub.State[auditSeqKey("rec1", 2)]
+	second.PayloadHash = strings.Repeat("0", 64)
+	env.stub.State[auditSeqKey("rec1", 2)], _ = json.Marshal(second)
+	if result := env.verifyAuditChain(auditor, "rec1"); result.Valid || result.BrokenAt != 2 {
+		t.Fatalf("altered entries must break the chain: %+v", result)
+	}
+	env.stub.State[auditSeqKey("rec1", 2)] = original
+
+	// 删除中间条目
+	env.stub.DelState(auditSeqKey("rec1", 2))
+	if result := env.verifyAuditChain(auditor, "rec1"); result.Valid || result.BrokenAt != 2 || result.Length != 4 {
+		t.Fatalf("removed entries must break the chain: %+v", result)
+	}
+}
+
+func TestAuditChainDetectsTruncatedTail(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.stub.DelState(auditSeqKey("rec1", 2))
+	if result := env.verifyAuditChain(patient, "rec1"); result.Valid || result.BrokenAt != 2 {
+		t.Fatalf("truncated chain must not match the audit head: %+v", result)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RecordUpdate 批量更新中的一条：新的存储位置与内容哈希
+type RecordUpdate struct {
+	RecordID    string `json:"recordId"`
+	IPFSCid     string `json:"ipfsCid"`
+	ContentHash string `json:"contentHash"`
+}
+
+type RecordsBatchUpdatedEvent struct {
+	RecordIDs []string `json:"recordIds"`
+	Count     int      `json:"count"`
+	Timestamp string   `json:"timestamp"`
+	CallerID  string   `json:"callerId"`
+	EventType string   `json:"eventType"`
+}
+
+// UpdateMedicalRecordsBatch 在一笔交易内更新多条记录（如存储迁移后统一替换 CID），逐条校验写权限，任一失败则整笔回滚
+func (s *SmartContract) UpdateMedicalRecordsBatch(ctx contractapi.TransactionContextInterface, updatesJson string) error {
+	var updates []RecordUpdate
+	if err := unmarshalArg(updatesJson, &updates); err != nil {
+		return fmt.Errorf("failed to unmarshal updates: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 || len(updates) > config.MaxBatchUpdates {
+		return fmt.Errorf("batch must contain between 1 and %d updates", config.MaxBatchUpdates)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	recordIDs := make([]string, 0, len(updates))
+	for _, update := range updates {
+		if containsString(recordIDs, update.RecordID) {
+			return fmt.Errorf("duplicate record in batch: %s", update.RecordID)
+		}
+		if _, err := s.updateRecord(ctx, callerID, update.RecordID, update.IPFSCid, update.ContentHash); err != nil {
+			return fmt.Errorf("record %s: %w", update.RecordID, err)
+		}
+		recordIDs = append(recordIDs, update.RecordID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordsBatchUpdated", RecordsBatchUpdatedEvent{
+		RecordIDs: recordIDs,
+		Count:     len(recordIDs),
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "RecordsBatchUpdated",
+	})
+}
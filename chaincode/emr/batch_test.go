@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestUpdateMedicalRecordsBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(specialist, "rec3", patient.id)
+	newHash := strings.Repeat("b", 64)
+	batch := func(ids ...string) string {
+		updates := make([]string, 0, len(ids))
+		for _, id := range ids {
+			updates = append(updates, `{"recordId":"`+id+`","ipfsCid":"bafynew`+id+`","contentHash":"`+newHash+`"}`)
+		}
+		return "[" + strings.Join(updates, ",") + "]"
+	}
+
+	// rec3 由其他医生创建，doctor 无写权限，整批回滚
+	env.mustFail(doctor, "record rec3: access denied", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, batch("rec1", "rec3"))
+	})
+	env.mustFail(doctor, "duplicate record", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, batch("rec1", "rec1"))
+	})
+	env.mustFail(doctor, "between 1 and 100", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, "[]")
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.GetRecord(ctx, "rec1")
+		if err == nil && record.IPCSCID != "bafyrec1" {
+			t.Fatalf("failed batch must not modify records: %+v", record)
+		}
+		return err
+	})
+
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, batch("rec1", "rec2"))
+	})
+	var event RecordsBatchUpdatedEvent
+	env.expectEvent("RecordsBatchUpdated", &event)
+	if event.Count != 2 || event.RecordIDs[1] != "rec2" || event.CallerID != doctor.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.GetRecord(ctx, "rec2")
+		if err == nil && (record.IPCSCID != "bafynewrec2" || record.ContentHash != newHash || record.VersionHash == "") {
+			t.Fatalf("record not updated: %+v", record)
+		}
+		return err
+	})
+}
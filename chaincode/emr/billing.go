@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const billingIndex = "billing"
+
+// BillingEvent 账单锚点；同一就诊重复锚定时追加新版本
+type BillingEvent struct {
+	PatientID   string `json:"patientId"`
+	EncounterID string `json:"encounterId"`
+	Version     int    `json:"version"`
+	InvoiceHash string `json:"invoiceHash"`
+	AnchoredAt  string `json:"anchoredAt"`
+	AnchoredBy  string `json:"anchoredBy"`
+	TxID        string `json:"txId"`
+}
+
+// InvoiceVerification 账单核验结果；Latest 表示命中的是该就诊最新一版锚点
+type InvoiceVerification struct {
+	Matched       bool   `json:"matched"`
+	Version       int    `json:"version,omitempty"`
+	Latest        bool   `json:"latest"`
+	LatestVersion int    `json:"latestVersion"`
+	AnchoredAt    string `json:"anchoredAt,omitempty"`
+}
+
+type BillingEventAnchoredEvent struct {
+	PatientID   string `json:"patientId"`
+	EncounterID string `json:"encounterId"`
+	Version     int    `json:"version"`
+	InvoiceHash string `json:"invoiceHash"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// billingVersionKey 版本号零填充，保证同一就诊下按字典序即版本顺序
+func billingVersionKey(ctx contractapi.TransactionContextInterface, patientID, encounterID string, version int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(billingIndex, []string{patientID, encounterID, fmt.Sprintf("%06d", version)})
+}
+
+func listBillingEvents(ctx contractapi.TransactionContextInterface, attrs ...string) ([]*BillingEvent, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(billingIndex, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query billing events: %w", err)
+	}
+	defer iterator.Close()
+
+	events := []*BillingEvent{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate billing events: %w", err)
+		}
+		var event BillingEvent
+		if err := unmarshalArg(string(kv.Value), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal billing event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func requireBillingReader(ctx contractapi.TransactionContextInterface, patientID string) error {
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil || isPatient {
+		return err
+	}
+	isBilling, err := hasRole(ctx, "billing")
+	if err != nil {
+		return err
+	}
+	if !isBilling {
+		return fmt.Errorf("access denied: only the patient or billing staff can view billing events")
+	}
+	return nil
+}
+
+// AnchorBillingEvent 锚定就诊账单哈希，仅 billing 角色可写
+func (s *SmartContract) AnchorBillingEvent(ctx contractapi.TransactionContextInterface, patientID, encounterID, invoiceHash string) (int, error) {
+	if err := validateAddress(patientID); err != nil {
+		return 0, fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validateAddress(encounterID); err != nil {
+		return 0, fmt.Errorf("invalid encounterID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(invoiceHash) {
+		return 0, fmt.Errorf("invalid invoiceHash: expected hex-encoded SHA-256")
+	}
+	isBilling, err := hasRole(ctx, "billing")
+	if err != nil {
+		return 0, err
+	}
+	if !isBilling {
+		return 0, fmt.Errorf("access denied: billing role required")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := listBillingEvents(ctx, patientID, encounterID)
+	if err != nil {
+		return 0, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+	event := BillingEvent{
+		PatientID:   patientID,
+		EncounterID: encounterID,
+		Version:     len(existing) + 1,
+		InvoiceHash: invoiceHash,
+		AnchoredAt:  now,
+		AnchoredBy:  callerID,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	key, err := billingVersionKey(ctx, patientID, encounterID, event.Version)
+	if err != nil {
+		return 0, err
+	}
+	if err := putJSON(ctx, key, event); err != nil {
+		return 0, err
+	}
+
+	return event.Version, emitEvent(ctx, "BillingEventAnchored", BillingEventAnchoredEvent{
+		PatientID:   patientID,
+		EncounterID: encounterID,
+		Version:     event.Version,
+		InvoiceHash: invoiceHash,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "BillingEventAnchored",
+	})
+}
+
+// GetBillingEvents 返回患者全部账单锚点（含历史版本），按就诊与版本排序
+func (s *SmartContract) GetBillingEvents(ctx contractapi.TransactionContextInterface, patientID string) ([]*BillingEvent, error) {
+	if err := requireBillingReader(ctx, patientID); err != nil {
+		return nil, err
+	}
+	return listBillingEvents(ctx, patientID)
+}
+
+// VerifyInvoice 将账单哈希与该就诊的全部锚定版本比对，返回命中的版本及其是否为最新版本
+func (s *SmartContract) VerifyInvoice(ctx contractapi.TransactionContextInterface, patientID, encounterID, invoiceHash string) (*InvoiceVerification, error) {
+	if err := requireBillingReader(ctx, patientID); err != nil {
+		return nil, err
+	}
+	events, err := listBillingEvents(ctx, patientID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no billing events anchored for encounter %s", encounterID)
+	}
+
+	result := &InvoiceVerification{LatestVersion: events[len(events)-1].Version}
+	// 同一哈希可能被重复锚定，取最新命中的版本
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].InvoiceHash == invoiceHash {
+			result.Matched = true
+			result.Version = events[i].Version
+			result.AnchoredAt = events[i].AnchoredAt
+			result.Latest = events[i].Version == result.LatestVersion
+			break
+		}
+	}
+	return result, nil
+}
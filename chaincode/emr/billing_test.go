@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var billingClerk = newIdentity("billing1", "Org1MSP", "role", "billing")
+
+func TestBillingAnchorsKeepEveryVersion(t *testing.T) {
+	env := newTestEnv(t)
+	first, second := strings.Repeat("1", 64), strings.Repeat("2", 64)
+
+	env.mustFail(doctor, "billing role required", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.AnchorBillingEvent(ctx, patient.id, "enc1", first)
+		return err
+	})
+	env.mustInvoke(billingClerk, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.AnchorBillingEvent(ctx, patient.id, "enc1", first)
+		return err
+	})
+	env.expectEvent("BillingEventAnchored", nil)
+	env.mustInvoke(billingClerk, func(ctx contractapi.TransactionContextInterface) error {
+		version, err := env.cc.AnchorBillingEvent(ctx, patient.id, "enc1", second)
+		if err == nil && version != 2 {
+			t.Fatalf("expected version 2, got %d", version)
+		}
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		events, err := env.cc.GetBillingEvents(ctx, patient.id)
+		if err == nil && (len(events) != 2 || events[0].InvoiceHash != first) {
+			t.Fatalf("original anchor must be preserved: %+v", events)
+		}
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		result, err := env.cc.VerifyInvoice(ctx, patient.id, "enc1", first)
+		if err == nil && (!result.Matched || result.Version != 1 || result.Latest) {
+			t.Fatalf("first invoice must match superseded version 1: %+v", result)
+		}
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		result, err := env.cc.VerifyInvoice(ctx, patient.id, "enc1", strings.Repeat("3", 64))
+		if err == nil && (result.Matched || result.LatestVersion != 2) {
+			t.Fatalf("unknown invoice must not match: %+v", result)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetBillingEvents(ctx, patient.id)
+		return err
+	})
+}
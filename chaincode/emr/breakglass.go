@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	BreakGlassPending   = "pending"
+	BreakGlassApproved  = "approved"
+	BreakGlassEscalated = "escalated"
+)
+
+// BreakGlassReview 紧急访问的事后复核条目，EventID 为紧急访问交易；Overdue 为查询时计算，不落库
+type BreakGlassReview struct {
+	EventID       string `json:"eventId"`
+	RecordID      string `json:"recordId"`
+	PatientID     string `json:"patientId"`
+	AccessorID    string `json:"accessorId"`
+	Code          string `json:"code"`
+	Justification string `json:"justification"`
+	AccessedAt    string `json:"accessedAt"`
+	Status        string `json:"status"`
+	ReviewedBy    string `json:"reviewedBy,omitempty"`
+	ReviewedAt    string `json:"reviewedAt,omitempty"`
+	NoteHash      string `json:"noteHash,omitempty"`
+	Overdue       bool   `json:"overdue,omitempty"`
+}
+
+// BreakGlassPage 复核队列分页结果；Bookmark 为空表示没有下一页
+type BreakGlassPage struct {
+	Reviews  []*BreakGlassReview `json:"reviews"`
+	Bookmark string              `json:"bookmark"`
+}
+
+type BreakGlassReviewedEvent struct {
+	EventID    string `json:"eventId"`
+	RecordID   string `json:"recordId"`
+	AccessorID string `json:"accessorId"`
+	Decision   string `json:"decision"`
+	NoteHash   string `json:"noteHash,omitempty"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+// breakGlassKey breakglass:{eventId}
+func breakGlassKey(eventID string) string {
+	return "breakglass:" + eventID
+}
+
+// breakGlassPendingKey breakglass-pending:{epoch}:{eventId}，值为 eventId；复核后删除
+func breakGlassPendingKey(accessedAt time.Time, eventID string) string {
+	return breakGlassPendingPrefix + epochSegment(accessedAt) + ":" + eventID
+}
+
+const breakGlassPendingPrefix = "breakglass-pending:"
+
+// queueBreakGlassReview 紧急访问写入待复核队列
+func queueBreakGlassReview(ctx contractapi.TransactionContextInterface, review *BreakGlassReview, accessedAt time.Time) error {
+	if err := putJSON(ctx, breakGlassKey(review.EventID), review); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(breakGlassPendingKey(accessedAt, review.EventID), []byte(review.EventID)); err != nil {
+		return fmt.Errorf("failed to queue break-glass review: %w", err)
+	}
+	return nil
+}
+
+func getBreakGlassReview(ctx contractapi.TransactionContextInterface, eventID string) (*BreakGlassReview, error) {
+	var review BreakGlassReview
+	found, err := getJSON(ctx, breakGlassKey(eventID), &review)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("break-glass event %s does not exist", eventID)
+	}
+	return &review, nil
+}
+
+// requireBreakGlassReader 复核队列限 privacy-officer 与审计员查看
+func requireBreakGlassReader(ctx contractapi.TransactionContextInterface) error {
+	allowed, err := hasAnyRole(ctx, privacyOfficerRole, auditorRole)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: only privacy officers or auditors can view break-glass reviews")
+	}
+	return nil
+}
+
+// listBreakGlass 分页扫描 [startKey, endKey) 内的待复核条目，并按 SLA 标记超时
+func listBreakGlass(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int32, bookmark string) (*BreakGlassPage, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	if err := requireBreakGlassReader(ctx); err != nil {
+		return nil, err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sla := time.Duration(config.BreakGlassReviewSLAHours) * time.Hour
+
+	page := &BreakGlassPage{Reviews: []*BreakGlassReview{}}
+	page.Bookmark, err = scanTimeIndex(ctx, startKey, endKey, pageSize, bookmark, func(eventID string) error {
+		review, err := getBreakGlassReview(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		accessedAt, err := time.Parse(time.RFC3339, review.AccessedAt)
+		review.Overdue = err == nil && now.Sub(accessedAt) >= sla
+		page.Reviews = append(page.Reviews, review)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListBreakGlassForReview 按访问时间分页列出待复核的紧急访问，超过复核时限的标记 overdue
+func (s *SmartContract) ListBreakGlassForReview(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*BreakGlassPage, error) {
+	return listBreakGlass(ctx, breakGlassPendingPrefix, breakGlassPendingPrefix[:len(breakGlassPendingPrefix)-1]+";", pageSize, bookmark)
+}
+
+// ListOverdueBreakGlass 只列出访问时间早于 breakGlassReviewSlaHours 仍未复核的紧急访问
+func (s *SmartContract) ListOverdueBreakGlass(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*BreakGlassPage, error) {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.Add(-time.Duration(config.BreakGlassReviewSLAHours) * time.Hour)
+	// 截止键取下一秒的前缀，恰好到达时限的条目也计入
+	endKey := breakGlassPendingPrefix + epochSegment(cutoff.Add(time.Second)) + ":"
+	return listBreakGlass(ctx, breakGlassPendingPrefix, endKey, pageSize, bookmark)
+}
+
+// ReviewBreakGlass privacy-officer 复核紧急访问：approve 确认合理，escalate 转交进一步调查且须附说明哈希。
+// 复核人不能是访问者本人；复核后移出待复核队列
+func (s *SmartContract) ReviewBreakGlass(ctx contractapi.TransactionContextInterface, eventID, decision, noteHash string) error {
+	isOfficer, err := hasRole(ctx, privacyOfficerRole)
+	if err != nil {
+		return err
+	}
+	if !isOfficer {
+		return fmt.Errorf("access denied: only privacy officers can review break-glass access")
+	}
+	var status string
+	switch decision {
+	case "approve":
+		status = BreakGlassApproved
+	case "escalate":
+		status = BreakGlassEscalated
+		if noteHash == "" {
+			return fmt.Errorf("noteHash is required to escalate")
+		}
+	default:
+		return fmt.Errorf("decision must be approve or escalate")
+	}
+	if noteHash != "" && !sha256HexPattern.MatchString(noteHash) {
+		return fmt.Errorf("noteHash must be a hex-encoded sha256 digest")
+	}
+	review, err := getBreakGlassReview(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if review.Status != BreakGlassPending {
+		return fmt.Errorf("break-glass event %s is already %s", eventID, review.Status)
+	}
+	isAccessor, err := callerIs(ctx, review.AccessorID)
+	if err != nil {
+		return err
+	}
+	if isAccessor {
+		return fmt.Errorf("access denied: break-glass access cannot be reviewed by the accessor")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	// 访问时间已在写入时校验
+	accessedAt, _ := time.Parse(time.RFC3339, review.AccessedAt)
+	if err := ctx.GetStub().DelState(breakGlassPendingKey(accessedAt, eventID)); err != nil {
+		return fmt.Errorf("failed to dequeue break-glass review: %w", err)
+	}
+	review.Status = status
+	review.ReviewedBy = callerID
+	review.ReviewedAt = now
+	review.NoteHash = noteHash
+	if err := putJSON(ctx, breakGlassKey(eventID), review); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "BreakGlassReviewed", BreakGlassReviewedEvent{
+		EventID:    eventID,
+		RecordID:   review.RecordID,
+		AccessorID: review.AccessorID,
+		Decision:   status,
+		NoteHash:   noteHash,
+		Timestamp:  now,
+		CallerID:   callerID,
+		EventType:  "BreakGlassReviewed",
+	})
+}
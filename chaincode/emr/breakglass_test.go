@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) breakGlassQueue(overdueOnly bool) []*BreakGlassReview {
+	e.t.Helper()
+	var page *BreakGlassPage
+	e.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		if overdueOnly {
+			page, err = e.cc.ListOverdueBreakGlass(ctx, 10, "")
+		} else {
+			page, err = e.cc.ListBreakGlassForReview(ctx, 10, "")
+		}
+		return err
+	})
+	return page.Reviews
+}
+
+func TestBreakGlassReviewQueue(t *testing.T) {
+	env := newTestEnv(t)
+	env.setFeature(FeatureBreakGlass, true)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(specialist, "rec2", patient.id)
+	breakGlass := func(recordID, code string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.BreakGlassAccess(ctx, recordID, code, "patient arrived unresponsive, history needed")
+		}
+	}
+	env.mustFail(nurse, "invalid break-glass code", breakGlass("rec1", "curiosity"))
+	env.mustInvoke(nurse, breakGlass("rec1", "unconscious-patient"))
+	var first BreakGlassAccessEvent
+	env.expectEvent("BreakGlassAccess", &first)
+	env.advance(48 * time.Hour)
+	env.mustInvoke(doctor, breakGlass("rec2", "life-threat"))
+	var second BreakGlassAccessEvent
+	env.expectEvent("BreakGlassAccess", &second)
+
+	env.mustFail(doctor, "only privacy officers or auditors", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListBreakGlassForReview(ctx, 10, "")
+		return err
+	})
+	if queue := env.breakGlassQueue(false); len(queue) != 2 || queue[0].EventID != first.EventID || queue[0].Code != "unconscious-patient" || queue[0].Overdue {
+		t.Fatalf("unexpected queue: %+v", queue)
+	}
+	if overdue := env.breakGlassQueue(true); len(overdue) != 0 {
+		t.Fatalf("nothing is overdue yet: %+v", overdue)
+	}
+
+	// 第一条超过默认 72 小时时限
+	env.advance(24 * time.Hour)
+	if overdue := env.breakGlassQueue(true); len(overdue) != 1 || overdue[0].EventID != first.EventID || !overdue[0].Overdue {
+		t.Fatalf("unexpected overdue entries: %+v", overdue)
+	}
+	env.mustFail(admin, "invalid code in breakGlassCodes", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"breakGlassCodes":["Life Threat"]}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"breakGlassReviewSlaHours":24}`)
+	})
+	if overdue := env.breakGlassQueue(true); len(overdue) != 2 {
+		t.Fatalf("a shorter SLA must surface both entries: %+v", overdue)
+	}
+
+	review := func(eventID, decision, noteHash string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.ReviewBreakGlass(ctx, eventID, decision, noteHash)
+		}
+	}
+	note := strings.Repeat("d", 64)
+	env.mustFail(nurse, "only privacy officers", review(first.EventID, "approve", ""))
+	env.mustFail(newIdentity(nurse.id, nurse.msp, "role", privacyOfficerRole), "cannot be reviewed by the accessor", review(first.EventID, "approve", ""))
+	env.mustFail(privacyOfficer, "decision must be", review(first.EventID, "ignore", ""))
+	env.mustFail(privacyOfficer, "noteHash is required", review(second.EventID, "escalate", ""))
+	env.mustInvoke(privacyOfficer, review(first.EventID, "approve", ""))
+	env.mustFail(privacyOfficer, "already approved", review(first.EventID, "approve", ""))
+	env.mustInvoke(privacyOfficer, review(second.EventID, "escalate", note))
+	var event BreakGlassReviewedEvent
+	env.expectEvent("BreakGlassReviewed", &event)
+	if event.Decision != BreakGlassEscalated || event.RecordID != "rec2" || event.AccessorID != doctor.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if queue := env.breakGlassQueue(false); len(queue) != 0 {
+		t.Fatalf("reviewed entries must leave the queue: %+v", queue)
+	}
+}
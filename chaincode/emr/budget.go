@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxEpsilon 单个数据集总预算与单次扣减的上限
+const maxEpsilon = 100
+
+// PrivacyBudget 研究数据集（一项研究的同意人群，datasetId 即 studyId）的查询预算。
+// 链码只计量 epsilon，不加噪声：链上没有提交者无法预知的随机源
+type PrivacyBudget struct {
+	DatasetID string  `json:"datasetId"`
+	Total     float64 `json:"total"`
+	Consumed  float64 `json:"consumed"`
+	UpdatedAt string  `json:"updatedAt"`
+	UpdatedBy string  `json:"updatedBy"`
+}
+
+type PrivacyBudgetConsumedEvent struct {
+	DatasetID   string  `json:"datasetId"`
+	EpsilonCost float64 `json:"epsilonCost"`
+	Remaining   float64 `json:"remaining"`
+	Timestamp   string  `json:"timestamp"`
+	CallerID    string  `json:"callerId"`
+	EventType   string  `json:"eventType"`
+}
+
+func privacyBudgetKey(datasetID string) string {
+	return "budget:" + keySegment(datasetID)
+}
+
+func validEpsilon(epsilon float64) bool {
+	return epsilon > 0 && epsilon <= maxEpsilon && !math.IsNaN(epsilon)
+}
+
+func getPrivacyBudget(ctx contractapi.TransactionContextInterface, datasetID string) (*PrivacyBudget, error) {
+	var budget PrivacyBudget
+	found, err := getJSON(ctx, privacyBudgetKey(datasetID), &budget)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no privacy budget for dataset %s", datasetID)
+	}
+	return &budget, nil
+}
+
+// consumeBudget 聚合查询在同一交易内扣减预算，不足时拒绝；查询因此须 submit，
+// 只 evaluate 的查询不写入状态，结果也不应被信任
+func consumeBudget(ctx contractapi.TransactionContextInterface, datasetID string, epsilonCost float64) (*PrivacyBudget, error) {
+	if !validEpsilon(epsilonCost) {
+		return nil, fmt.Errorf("epsilonCost must be greater than 0 and at most %d", maxEpsilon)
+	}
+	budget, err := getPrivacyBudget(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if budget.Consumed+epsilonCost > budget.Total {
+		return nil, fmt.Errorf("privacy budget exhausted for dataset %s: %g of %g consumed", datasetID, budget.Consumed, budget.Total)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budget.Consumed += epsilonCost
+	budget.UpdatedAt = now
+	budget.UpdatedBy = callerID
+	if err := putJSON(ctx, privacyBudgetKey(datasetID), budget); err != nil {
+		return nil, err
+	}
+	return budget, emitEvent(ctx, "PrivacyBudgetConsumed", PrivacyBudgetConsumedEvent{
+		DatasetID:   datasetID,
+		EpsilonCost: epsilonCost,
+		Remaining:   budget.Total - budget.Consumed,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "PrivacyBudgetConsumed",
+	})
+}
+
+// SetPrivacyBudget admin 设置数据集的总预算；已扣减的部分保留，总预算不能低于已扣减量
+func (s *SmartContract) SetPrivacyBudget(ctx contractapi.TransactionContextInterface, datasetID string, epsilon float64) error {
+	if err := validateAddress(datasetID); err != nil {
+		return fmt.Errorf("invalid datasetID: %w", err)
+	}
+	if !validEpsilon(epsilon) {
+		return fmt.Errorf("epsilon must be greater than 0 and at most %d", maxEpsilon)
+	}
+	callerID, _, err := requireAdminCaller(ctx, "set privacy budgets")
+	if err != nil {
+		return err
+	}
+	budget := PrivacyBudget{DatasetID: datasetID}
+	if _, err := getJSON(ctx, privacyBudgetKey(datasetID), &budget); err != nil {
+		return err
+	}
+	if epsilon < budget.Consumed {
+		return fmt.Errorf("epsilon must not be below the consumed budget %g", budget.Consumed)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	budget.Total = epsilon
+	budget.UpdatedAt = now
+	budget.UpdatedBy = callerID
+	return putJSON(ctx, privacyBudgetKey(datasetID), budget)
+}
+
+// GetPrivacyBudget 返回数据集的预算与已扣减量
+func (s *SmartContract) GetPrivacyBudget(ctx contractapi.TransactionContextInterface, datasetID string) (*PrivacyBudget, error) {
+	return getPrivacyBudget(ctx, datasetID)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestPrivacyBudgetMetersCohortQueries(t *testing.T) {
+	env := newTestEnv(t)
+	env.consentingPatients(3)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDPA(ctx, "Org3MSP", strings.Repeat("cd", 32), env.stub.now.AddDate(1, 0, 0).Format(time.RFC3339))
+	})
+	query := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetConsentedCohortSize(ctx, `{"studyId":"study1"}`, 0.5)
+		return err
+	}
+	env.mustFail(researcher, "no privacy budget", query)
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPrivacyBudget(ctx, "study1", 1)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPrivacyBudget(ctx, "study1", 1)
+	})
+	env.mustInvoke(researcher, query)
+	var event PrivacyBudgetConsumedEvent
+	env.expectEvent("PrivacyBudgetConsumed", &event)
+	if event.DatasetID != "study1" || event.EpsilonCost != 0.5 || event.Remaining != 0.5 || event.CallerID != researcher.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	// 结果不足阈值时同样扣减
+	if size := env.cohortSize(`{"studyId":"study1","conditionCodes":["I10"]}`); size.Result != CohortInsufficient || size.RemainingBudget != 0.4 {
+		t.Fatalf("unexpected cohort size: %+v", size)
+	}
+	env.mustFail(researcher, "privacy budget exhausted", query)
+	env.mustFail(researcher, "epsilonCost must be", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetConsentedCohortSize(ctx, `{"studyId":"study1"}`, 0)
+		return err
+	})
+
+	env.mustFail(admin, "below the consumed budget", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPrivacyBudget(ctx, "study1", 0.5)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetPrivacyBudget(ctx, "study1", 2)
+	})
+	env.mustInvoke(researcher, query)
+	env.mustInvoke(researcher, func(ctx contractapi.TransactionContextInterface) error {
+		budget, err := env.cc.GetPrivacyBudget(ctx, "study1")
+		if err == nil && (budget.Total != 2 || budget.Consumed != 1.1) {
+			t.Fatalf("unexpected budget: %+v", budget)
+		}
+		return err
+	})
+}
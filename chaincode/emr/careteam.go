@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	careTeamIndex = "careteam"
+
+	defaultCareTeamAction = "read"
+)
+
+// CareTeamMember 患者护理团队成员；成员按团队默认权限访问患者的记录
+type CareTeamMember struct {
+	PatientID string `json:"patientId"`
+	MemberID  string `json:"memberId"`
+	TeamRole  string `json:"teamRole"`
+	AddedBy   string `json:"addedBy"`
+	AddedAt   string `json:"addedAt"`
+}
+
+// CareTeamConfig 团队默认权限，取 read/share/write，不允许 admin
+type CareTeamConfig struct {
+	PatientID     string `json:"patientId"`
+	DefaultAction string `json:"defaultAction"`
+	UpdatedAt     string `json:"updatedAt"`
+}
+
+// CareTeam GetCareTeam 的返回值
+type CareTeam struct {
+	PatientID     string            `json:"patientId"`
+	DefaultAction string            `json:"defaultAction"`
+	Members       []*CareTeamMember `json:"members"`
+}
+
+type CareTeamEvent struct {
+	PatientID string `json:"patientId"`
+	MemberID  string `json:"memberId"`
+	TeamRole  string `json:"teamRole,omitempty"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func careTeamConfigKey(patientID string) string {
+	return "careteam-config:" + patientID
+}
+
+func careTeamMemberKey(ctx contractapi.TransactionContextInterface, patientID, memberID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(careTeamIndex, []string{patientID, memberID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create care team key: %w", err)
+	}
+	return key, nil
+}
+
+func getCareTeamDefaultAction(ctx contractapi.TransactionContextInterface, patientID string) (string, error) {
+	var config CareTeamConfig
+	found, err := getJSON(ctx, careTeamConfigKey(patientID), &config)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return defaultCareTeamAction, nil
+	}
+	return config.DefaultAction, nil
+}
+
+// careTeamAction 返回 userID 作为患者团队成员获得的权限；非成员返回空串
+func careTeamAction(ctx contractapi.TransactionContextInterface, patientID, userID string) (string, error) {
+	key, err := careTeamMemberKey(ctx, patientID, userID)
+	if err != nil {
+		return "", err
+	}
+	isMember, err := assetExists(ctx, key)
+	if err != nil || !isMember {
+		return "", err
+	}
+	return getCareTeamDefaultAction(ctx, patientID)
+}
+
+// careTeamGrantsAccess 团队成员对患者的记录具备默认权限
+func careTeamGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	action, err := careTeamAction(ctx, record.PatientID, userID)
+	return action != "", err
+}
+
+// AddCareTeamMember 患者（或持 consent 范围的代理人）将成员加入护理团队；重复添加时更新团队角色
+func (s *SmartContract) AddCareTeamMember(ctx contractapi.TransactionContextInterface, patientID, memberID, teamRole string) error {
+	if err := validateAddress(memberID); err != nil {
+		return fmt.Errorf("invalid memberID: %w", err)
+	}
+	if teamRole == "" {
+		return fmt.Errorf("teamRole is required")
+	}
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "manage the care team")
+	if err != nil {
+		return err
+	}
+	if memberID == patientID {
+		return fmt.Errorf("patient cannot be a care team member")
+	}
+	key, err := careTeamMemberKey(ctx, patientID, memberID)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	member := CareTeamMember{
+		PatientID: patientID,
+		MemberID:  memberID,
+		TeamRole:  teamRole,
+		AddedBy:   callerID,
+		AddedAt:   now,
+	}
+	if err := putJSON(ctx, key, member); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "CareTeamMemberAdded", CareTeamEvent{
+		PatientID: patientID,
+		MemberID:  memberID,
+		TeamRole:  teamRole,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "CareTeamMemberAdded",
+	})
+}
+
+// RemoveCareTeamMember 移出团队，成员经团队获得的权限随即失效
+func (s *SmartContract) RemoveCareTeamMember(ctx contractapi.TransactionContextInterface, patientID, memberID string) error {
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "manage the care team")
+	if err != nil {
+		return err
+	}
+	key, err := careTeamMemberKey(ctx, patientID, memberID)
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("care team member not found: %s", memberID)
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to remove care team member: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "CareTeamMemberRemoved", CareTeamEvent{
+		PatientID: patientID,
+		MemberID:  memberID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "CareTeamMemberRemoved",
+	})
+}
+
+// SetCareTeamDefaultAction 患者配置团队成员的默认权限
+func (s *SmartContract) SetCareTeamDefaultAction(ctx contractapi.TransactionContextInterface, patientID, action string) error {
+	if _, ok := permissionHierarchy[action]; !ok || action == "admin" {
+		return fmt.Errorf("invalid care team action: %s", action)
+	}
+	if _, err := requirePatientOrAgent(ctx, patientID, "consent", "configure the care team"); err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, careTeamConfigKey(patientID), CareTeamConfig{
+		PatientID:     patientID,
+		DefaultAction: action,
+		UpdatedAt:     now,
+	})
+}
+
+// GetCareTeam 患者本人与团队成员可查看团队
+func (s *SmartContract) GetCareTeam(ctx contractapi.TransactionContextInterface, patientID string) (*CareTeam, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	team := &CareTeam{PatientID: patientID, Members: []*CareTeamMember{}}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(careTeamIndex, []string{patientID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query care team: %w", err)
+	}
+	defer iterator.Close()
+
+	isMember := false
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate care team: %w", err)
+		}
+		var member CareTeamMember
+		if err := unmarshalArg(string(kv.Value), &member); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal care team member: %w", err)
+		}
+		isMember = isMember || member.MemberID == callerID
+		team.Members = append(team.Members, &member)
+	}
+	if callerID != patientID && !isMember {
+		return nil, fmt.Errorf("access denied: %s cannot view the care team of %s", callerID, patientID)
+	}
+	team.DefaultAction, err = getCareTeamDefaultAction(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestCareTeamDefaultPermission(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddCareTeamMember(ctx, patient.id, nurse.id, "home-care")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddCareTeamMember(ctx, patient.id, nurse.id, "home-care")
+	})
+	env.expectEvent("CareTeamMemberAdded", nil)
+
+	// 无需逐条授权即可访问患者全部记录
+	if !env.checkAccess("rec1", nurse.id) || !env.checkAccess("rec2", nurse.id) {
+		t.Fatal("care team member must access the patient's records")
+	}
+	level := func(action string) bool {
+		var ok bool
+		env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ok, err = env.cc.ValidatePermissionLevel(ctx, "rec1", nurse.id, action)
+			return err
+		})
+		return ok
+	}
+	if !level("read") || level("write") {
+		t.Fatal("default team permission must be read")
+	}
+
+	env.mustFail(patient, "invalid care team action", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetCareTeamDefaultAction(ctx, patient.id, "admin")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetCareTeamDefaultAction(ctx, patient.id, "write")
+	})
+	if !level("write") || level("admin") {
+		t.Fatal("configured team permission must be write")
+	}
+
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		team, err := env.cc.GetCareTeam(ctx, patient.id)
+		if err == nil && (len(team.Members) != 1 || team.DefaultAction != "write") {
+			t.Fatalf("unexpected care team: %+v", team)
+		}
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveCareTeamMember(ctx, patient.id, nurse.id)
+	})
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("removed member must lose access")
+	}
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetCareTeam(ctx, patient.id)
+		return err
+	})
+}
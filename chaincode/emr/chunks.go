@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxManifestChunks 单个清单的分块上限，按 1 MiB 分块约可覆盖 20 GB
+const maxManifestChunks = 20000
+
+// ChunkManifest 大文件分块 DAG 的分块清单：按序的分块哈希与总大小，供边下载边校验。
+// PartHash 为锚定时对应部分的 contentHash，部分内容被替换后清单随之失效
+type ChunkManifest struct {
+	RecordID     string   `json:"recordId"`
+	PartIndex    int      `json:"partIndex"`
+	PartHash     string   `json:"partHash"`
+	ChunkHashes  []string `json:"chunkHashes"`
+	ChunkSize    int64    `json:"chunkSize"`
+	TotalSize    int64    `json:"totalSize"`
+	ManifestHash string   `json:"manifestHash"`
+	AnchoredBy   string   `json:"anchoredBy"`
+	AnchoredAt   string   `json:"anchoredAt"`
+}
+
+type ChunkManifestAnchoredEvent struct {
+	RecordID     string `json:"recordId"`
+	PartIndex    int    `json:"partIndex"`
+	ChunkCount   int    `json:"chunkCount"`
+	ManifestHash string `json:"manifestHash"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+// chunkManifestKey chunks:{recordId}:{partIndex}
+func chunkManifestKey(recordID string, partIndex int) string {
+	return fmt.Sprintf("chunks:%s:%04d", keySegment(recordID), partIndex)
+}
+
+// validate 校验分块数与大小一致：除最后一块外都为 chunkSize
+func (manifest *ChunkManifest) validate(config *ContractConfig, part RecordPart) error {
+	count := int64(len(manifest.ChunkHashes))
+	if count == 0 || count > maxManifestChunks {
+		return fmt.Errorf("a manifest must list between 1 and %d chunks", maxManifestChunks)
+	}
+	if manifest.ChunkSize <= 0 || manifest.TotalSize <= 0 {
+		return fmt.Errorf("chunkSize and totalSize must be positive")
+	}
+	if (manifest.TotalSize+manifest.ChunkSize-1)/manifest.ChunkSize != count {
+		return fmt.Errorf("totalSize %d with chunkSize %d does not make %d chunks", manifest.TotalSize, manifest.ChunkSize, count)
+	}
+	if part.Size > 0 && part.Size != manifest.TotalSize {
+		return fmt.Errorf("totalSize %d does not match part size %d", manifest.TotalSize, part.Size)
+	}
+	for i, hash := range manifest.ChunkHashes {
+		if err := config.checkContentHash(hash); err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// AnchorChunkManifest 为记录的第 partIndex 部分锚定分块清单；manifestJson 为 {chunkHashes, chunkSize, totalSize}。
+// 调用者须持有 write 级权限；再次锚定覆盖原清单
+func (s *SmartContract) AnchorChunkManifest(ctx contractapi.TransactionContextInterface, recordID string, partIndex int, manifestJson string) error {
+	var manifest ChunkManifest
+	if err := unmarshalArg(manifestJson, &manifest); err != nil {
+		return fmt.Errorf("invalid manifest json: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: %s cannot anchor manifests for record %s", callerID, recordID)
+	}
+	part, err := recordPart(record, partIndex)
+	if err != nil {
+		return err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := manifest.validate(config, part); err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(strings.Join(manifest.ChunkHashes, "\n")))
+	manifest.RecordID = recordID
+	manifest.PartIndex = partIndex
+	manifest.PartHash = part.ContentHash
+	manifest.ManifestHash = hex.EncodeToString(digest[:])
+	manifest.AnchoredBy = callerID
+	manifest.AnchoredAt = now
+	if err := putJSON(ctx, chunkManifestKey(recordID, partIndex), manifest); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ChunkManifestAnchored", ChunkManifestAnchoredEvent{
+		RecordID:     recordID,
+		PartIndex:    partIndex,
+		ChunkCount:   len(manifest.ChunkHashes),
+		ManifestHash: manifest.ManifestHash,
+		Timestamp:    now,
+		CallerID:     callerID,
+		EventType:    "ChunkManifestAnchored",
+	})
+}
+
+// VerifyChunk 比较第 chunkIndex 块的哈希，调用者须能读取记录；部分内容在锚定后被替换时清单失效并报错
+func (s *SmartContract) VerifyChunk(ctx contractapi.TransactionContextInterface, recordID string, partIndex, chunkIndex int, chunkHash string) (bool, error) {
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return false, err
+	}
+	var manifest ChunkManifest
+	found, err := getJSON(ctx, chunkManifestKey(recordID, partIndex), &manifest)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, fmt.Errorf("no chunk manifest for part %d of record %s", partIndex, recordID)
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	part, err := recordPart(record, partIndex)
+	if err != nil {
+		return false, err
+	}
+	if part.ContentHash != manifest.PartHash {
+		return false, fmt.Errorf("chunk manifest for part %d of record %s is stale", partIndex, recordID)
+	}
+	if chunkIndex < 0 || chunkIndex >= len(manifest.ChunkHashes) {
+		return false, fmt.Errorf("manifest has no chunk %d", chunkIndex)
+	}
+	return manifest.ChunkHashes[chunkIndex] == chunkHash, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func chunkManifestJSON(totalSize int64, hashes ...string) string {
+	data, _ := json.Marshal(ChunkManifest{ChunkHashes: hashes, ChunkSize: 1 << 19, TotalSize: totalSize})
+	return string(data)
+}
+
+func TestChunkManifestVerification(t *testing.T) {
+	env := newTestEnv(t)
+	env.createMultipartRecord("rec1")
+	chunks := []string{strings.Repeat("1", 64), strings.Repeat("2", 64)}
+
+	// 影像部分 1 MiB，按 512 KiB 分块恰为两块
+	invalid := map[string]string{
+		"does not make":            chunkManifestJSON(3<<19, chunks...),
+		"does not match part size": chunkManifestJSON(1<<20-1, chunks...),
+	}
+	for contains, manifest := range invalid {
+		env.mustFail(doctor, contains, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AnchorChunkManifest(ctx, "rec1", 1, manifest)
+		})
+	}
+	env.mustFail(nurse, "cannot anchor manifests", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorChunkManifest(ctx, "rec1", 1, chunkManifestJSON(1<<20, chunks...))
+	})
+	env.mustFail(doctor, "has no part 2", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorChunkManifest(ctx, "rec1", 2, chunkManifestJSON(1<<20, chunks...))
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorChunkManifest(ctx, "rec1", 1, chunkManifestJSON(1<<20, chunks...))
+	})
+	var event ChunkManifestAnchoredEvent
+	env.expectEvent("ChunkManifestAnchored", &event)
+	if event.ChunkCount != 2 || event.ManifestHash == "" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		for i, want := range []bool{true, false} {
+			if ok, err := env.cc.VerifyChunk(ctx, "rec1", 1, i, chunks[1]); err != nil || ok == want {
+				t.Fatalf("VerifyChunk(%d) = %v, %v", i, ok, err)
+			}
+		}
+		_, err := env.cc.VerifyChunk(ctx, "rec1", 1, 2, chunks[0])
+		if err == nil || !strings.Contains(err.Error(), "no chunk 2") {
+			t.Fatalf("out-of-range chunk must fail: %v", err)
+		}
+		return nil
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyChunk(ctx, "rec1", 1, 0, chunks[0])
+		return err
+	})
+
+	// 主内容替换后，主内容的清单失效
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorChunkManifest(ctx, "rec1", 0, chunkManifestJSON(2048, chunks[0]))
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynote2", strings.Repeat("d", 64))
+	})
+	env.mustFail(patient, "is stale", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyChunk(ctx, "rec1", 0, 0, chunks[0])
+		return err
+	})
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ClaimsContract 保险理赔锚点合约
+type ClaimsContract struct {
+	contractapi.Contract
+}
+
+const (
+	ClaimSubmitted   = "submitted"
+	ClaimAdjudicated = "adjudicated"
+	ClaimPaid        = "paid"
+	ClaimDenied      = "denied"
+
+	claimPayerIndex  = "payer~claim"
+	claimRecordIndex = "claim~record"
+)
+
+// 理赔状态流转：submitted → adjudicated → paid，submitted|adjudicated → denied
+var claimTransitions = map[string][]string{
+	ClaimSubmitted:   {ClaimAdjudicated, ClaimDenied},
+	ClaimAdjudicated: {ClaimPaid, ClaimDenied},
+}
+
+var sha256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Claim 理赔锚点；金额只存哈希
+type Claim struct {
+	ClaimID      string   `json:"claimId"`
+	PayerID      string   `json:"payerId"`
+	PatientID    string   `json:"patientId"`
+	ProviderID   string   `json:"providerId"`
+	RecordIDs    []string `json:"recordIds"`
+	AmountHash   string   `json:"amountHash"`
+	Status       string   `json:"status"`
+	DecisionHash string   `json:"decisionHash,omitempty"`
+	ReasonCode   string   `json:"reasonCode,omitempty"`
+	SubmittedAt  string   `json:"submittedAt"`
+	UpdatedAt    string   `json:"updatedAt"`
+}
+
+type ClaimEvent struct {
+	ClaimID   string `json:"claimId"`
+	PayerID   string `json:"payerId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func claimKey(claimID string) string {
+	return "claim:" + claimID
+}
+
+func getClaim(ctx contractapi.TransactionContextInterface, claimID string) (*Claim, error) {
+	var claim Claim
+	found, err := getJSON(ctx, claimKey(claimID), &claim)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("claim not found: %s", claimID)
+	}
+	return &claim, nil
+}
+
+// claimGrantsAccess 经 claim~record 索引判断 userID 是否处于某理赔的审核窗口内
+func claimGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	allowed := false
+	err := scanIndex(ctx, claimRecordIndex, []string{recordID, userID}, func(attrs []string) error {
+		if allowed {
+			return nil
+		}
+		claim, err := getClaim(ctx, attrs[2])
+		if err != nil {
+			return err
+		}
+		allowed = claim.Status == ClaimSubmitted || claim.Status == ClaimAdjudicated
+		return nil
+	})
+	return allowed, err
+}
+
+// SubmitClaim 提交理赔锚点；提交者须对每条关联记录持有 share 及以上权限
+func (c *ClaimsContract) SubmitClaim(ctx contractapi.TransactionContextInterface, claimJson string) error {
+	var claim Claim
+	if err := unmarshalArg(claimJson, &claim); err != nil {
+		return fmt.Errorf("invalid claim json: %w", err)
+	}
+	if claim.ClaimID == "" || claim.PayerID == "" || claim.PatientID == "" || len(claim.RecordIDs) == 0 {
+		return fmt.Errorf("missing required fields: claimId, payerId, patientId and recordIds are required")
+	}
+	for _, id := range []string{claim.ClaimID, claim.PayerID, claim.PatientID} {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	if !sha256HexPattern.MatchString(claim.AmountHash) {
+		return fmt.Errorf("invalid amountHash: expected hex-encoded SHA-256")
+	}
+
+	exists, err := assetExists(ctx, claimKey(claim.ClaimID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("claim already exists: %s", claim.ClaimID)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	records := new(SmartContract)
+	seen := make(map[string]bool, len(claim.RecordIDs))
+	for _, recordID := range claim.RecordIDs {
+		if seen[recordID] {
+			return fmt.Errorf("duplicate recordId in claim: %s", recordID)
+		}
+		seen[recordID] = true
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if record.PatientID != claim.PatientID {
+			return fmt.Errorf("record %s does not belong to patient %s", recordID, claim.PatientID)
+		}
+		allowed, err := records.ValidatePermissionLevel(ctx, recordID, callerID, "share")
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("access denied: %s cannot share record %s", callerID, recordID)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	claim.ProviderID = callerID
+	claim.Status = ClaimSubmitted
+	claim.DecisionHash = ""
+	claim.ReasonCode = ""
+	claim.SubmittedAt = now
+	claim.UpdatedAt = now
+	if err := putJSON(ctx, claimKey(claim.ClaimID), claim); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, claimPayerIndex, claim.PayerID, claim.ClaimID); err != nil {
+		return err
+	}
+	for _, recordID := range claim.RecordIDs {
+		if err := putIndex(ctx, claimRecordIndex, recordID, claim.PayerID, claim.ClaimID); err != nil {
+			return err
+		}
+	}
+
+	return emitClaimEvent(ctx, "ClaimSubmitted", &claim, callerID)
+}
+
+// AdjudicateClaim payer 记录审核结论哈希
+func (c *ClaimsContract) AdjudicateClaim(ctx contractapi.TransactionContextInterface, claimID, decisionHash string) error {
+	if !sha256HexPattern.MatchString(decisionHash) {
+		return fmt.Errorf("invalid decisionHash: expected hex-encoded SHA-256")
+	}
+	return c.transition(ctx, claimID, ClaimAdjudicated, "ClaimAdjudicated", func(claim *Claim) {
+		claim.DecisionHash = decisionHash
+	})
+}
+
+// MarkClaimPaid payer 确认已支付
+func (c *ClaimsContract) MarkClaimPaid(ctx contractapi.TransactionContextInterface, claimID string) error {
+	return c.transition(ctx, claimID, ClaimPaid, "ClaimPaid", nil)
+}
+
+// DenyClaim payer 拒赔，须提供原因代码
+func (c *ClaimsContract) DenyClaim(ctx contractapi.TransactionContextInterface, claimID, reasonCode string) error {
+	if reasonCode == "" {
+		return fmt.Errorf("reasonCode is required")
+	}
+	return c.transition(ctx, claimID, ClaimDenied, "ClaimDenied", func(claim *Claim) {
+		claim.ReasonCode = reasonCode
+	})
+}
+
+func (c *ClaimsContract) transition(ctx contractapi.TransactionContextInterface, claimID, target, eventName string, apply func(*Claim)) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	claim, err := getClaim(ctx, claimID)
+	if err != nil {
+		return err
+	}
+	isPayer, err := callerIs(ctx, claim.PayerID)
+	if err != nil {
+		return err
+	}
+	if !isPayer {
+		return fmt.Errorf("access denied: only the payer can change claim status")
+	}
+	if !containsString(claimTransitions[claim.Status], target) {
+		return fmt.Errorf("invalid claim transition: %s -> %s", claim.Status, target)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	claim.Status = target
+	claim.UpdatedAt = now
+	if apply != nil {
+		apply(claim)
+	}
+	if err := putJSON(ctx, claimKey(claimID), claim); err != nil {
+		return err
+	}
+	return emitClaimEvent(ctx, eventName, claim, callerID)
+}
+
+// GetClaim 查询理赔；payer、患者与提交方可读
+func (c *ClaimsContract) GetClaim(ctx contractapi.TransactionContextInterface, claimID string) (*Claim, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claim, err := getClaim(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := callerIsAny(ctx, claim.PayerID, claim.PatientID, claim.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s cannot view claim %s", callerID, claimID)
+	}
+	return claim, nil
+}
+
+// ListClaimsByPayer 经 payer~claim 索引列出 payer 名下的理赔，仅 payer 本人可查询
+func (c *ClaimsContract) ListClaimsByPayer(ctx contractapi.TransactionContextInterface, payerID string) ([]*Claim, error) {
+	isPayer, err := callerIs(ctx, payerID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPayer {
+		return nil, fmt.Errorf("access denied: payers can only list their own claims")
+	}
+
+	claims := []*Claim{}
+	err = scanIndex(ctx, claimPayerIndex, []string{payerID}, func(attrs []string) error {
+		claim, err := getClaim(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		claims = append(claims, claim)
+		return nil
+	})
+	return claims, err
+}
+
+func emitClaimEvent(ctx contractapi.TransactionContextInterface, name string, claim *Claim, callerID string) error {
+	return emitEvent(ctx, name, ClaimEvent{
+		ClaimID:   claim.ClaimID,
+		PayerID:   claim.PayerID,
+		PatientID: claim.PatientID,
+		Status:    claim.Status,
+		Timestamp: claim.UpdatedAt,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var payer = newIdentity("payer1", "PayerMSP", "role", "payer")
+
+func submitTestClaim(env *testEnv, claims *ClaimsContract, claimID string, recordIDs ...string) {
+	env.t.Helper()
+	body := `{"claimId":"` + claimID + `","payerId":"payer1","patientId":"patient1","recordIds":["` +
+		strings.Join(recordIDs, `","`) + `"],"amountHash":"` + strings.Repeat("b", 64) + `"}`
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.SubmitClaim(ctx, body)
+	})
+}
+
+func TestClaimLifecycleGrantsPayerReadDuringAdjudication(t *testing.T) {
+	env := newTestEnv(t)
+	claims := new(ClaimsContract)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+
+	if env.checkAccess("rec1", payer.id) {
+		t.Fatal("payer must not have access before a claim exists")
+	}
+	submitTestClaim(env, claims, "claim1", "rec1")
+	env.expectEvent("ClaimSubmitted", nil)
+
+	if !env.checkAccess("rec1", payer.id) {
+		t.Fatal("payer must read linked records while the claim is submitted")
+	}
+	if env.checkAccess("rec2", payer.id) {
+		t.Fatal("payer must not read records outside the claim")
+	}
+
+	env.mustFail(doctor, "only the payer", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.MarkClaimPaid(ctx, "claim1")
+	})
+	env.mustFail(payer, "invalid claim transition", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.MarkClaimPaid(ctx, "claim1")
+	})
+	env.mustInvoke(payer, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.AdjudicateClaim(ctx, "claim1", strings.Repeat("c", 64))
+	})
+	if !env.checkAccess("rec1", payer.id) {
+		t.Fatal("payer must keep access while the claim is adjudicated")
+	}
+
+	env.mustInvoke(payer, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.MarkClaimPaid(ctx, "claim1")
+	})
+	env.expectEvent("ClaimPaid", nil)
+	if env.checkAccess("rec1", payer.id) {
+		t.Fatal("payer access must end once the claim is paid")
+	}
+}
+
+func TestClaimDenialEndsAccess(t *testing.T) {
+	env := newTestEnv(t)
+	claims := new(ClaimsContract)
+	env.createRecord(doctor, "rec1", patient.id)
+	submitTestClaim(env, claims, "claim1", "rec1")
+
+	env.mustFail(payer, "reasonCode is required", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.DenyClaim(ctx, "claim1", "")
+	})
+	env.mustInvoke(payer, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.DenyClaim(ctx, "claim1", "CO-50")
+	})
+	if env.checkAccess("rec1", payer.id) {
+		t.Fatal("payer access must end once the claim is denied")
+	}
+	env.mustFail(payer, "invalid claim transition", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.AdjudicateClaim(ctx, "claim1", strings.Repeat("c", 64))
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		claim, err := claims.GetClaim(ctx, "claim1")
+		if err == nil && (claim.Status != ClaimDenied || claim.ReasonCode != "CO-50") {
+			t.Fatalf("unexpected claim: %+v", claim)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := claims.GetClaim(ctx, "claim1")
+		return err
+	})
+}
+
+func TestSubmitClaimValidation(t *testing.T) {
+	env := newTestEnv(t)
+	claims := new(ClaimsContract)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec9", "patient9")
+
+	env.mustFail(other, "cannot share record", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.SubmitClaim(ctx, `{"claimId":"c1","payerId":"payer1","patientId":"patient1","recordIds":["rec1"],"amountHash":"`+strings.Repeat("b", 64)+`"}`)
+	})
+	env.mustFail(doctor, "does not belong to patient", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.SubmitClaim(ctx, `{"claimId":"c1","payerId":"payer1","patientId":"patient1","recordIds":["rec9"],"amountHash":"`+strings.Repeat("b", 64)+`"}`)
+	})
+	env.mustFail(doctor, "invalid amountHash", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.SubmitClaim(ctx, `{"claimId":"c1","payerId":"payer1","patientId":"patient1","recordIds":["rec1"],"amountHash":"123"}`)
+	})
+
+	submitTestClaim(env, claims, "claim1", "rec1")
+	env.mustInvoke(payer, func(ctx contractapi.TransactionContextInterface) error {
+		list, err := claims.ListClaimsByPayer(ctx, payer.id)
+		if err == nil && len(list) != 1 {
+			t.Fatalf("expected 1 claim, got %d", len(list))
+		}
+		return err
+	})
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	FindingExpiredActive = "expiredActive"
+	FindingFrozenGrantee = "frozenGrantee"
+	FindingOrphanGrant   = "orphanGrant"
+	FindingACLMismatch   = "aclMismatch"
+	FindingInactiveDUA   = "inactiveDua"
+)
+
+// ComplianceSnapshot 线下生成的周期合规报告的链上锚点：链上只存报告哈希、覆盖周期与汇总统计。
+// 同一周期可再次锚定更正版本，旧版本保留
+type ComplianceSnapshot struct {
+	ReportHash   string                 `json:"reportHash"`
+	PeriodStart  string                 `json:"periodStart"`
+	PeriodEnd    string                 `json:"periodEnd"`
+	SummaryStats map[string]interface{} `json:"summaryStats"`
+	AnchoredBy   string                 `json:"anchoredBy"`
+	AnchoredAt   string                 `json:"anchoredAt"`
+	TxID         string                 `json:"txId"`
+}
+
+type ComplianceSnapshotPage struct {
+	Snapshots []*ComplianceSnapshot `json:"snapshots"`
+	Bookmark  string                `json:"bookmark"`
+}
+
+type ComplianceSnapshotAnchoredEvent struct {
+	ReportHash  string `json:"reportHash"`
+	PeriodStart string `json:"periodStart"`
+	PeriodEnd   string `json:"periodEnd"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// complianceSnapshotKey compliance:{periodStart}:{anchoredAt}:{txId}，同一周期的版本按锚定时间排序
+func complianceSnapshotKey(periodStart, anchoredAt time.Time, txID string) string {
+	return "compliance:" + epochSegment(periodStart) + ":" + epochSegment(anchoredAt) + ":" + txID
+}
+
+// ComplianceFinding 扫描发现的单条异常，供线下整改
+type ComplianceFinding struct {
+	Type      string `json:"type"`
+	RecordID  string `json:"recordId"`
+	GranteeID string `json:"granteeId"`
+	Detail    string `json:"detail"`
+}
+
+// ComplianceReport Scanned 为本页检查的授权数；Bookmark 为空表示已扫描完全部授权
+type ComplianceReport struct {
+	Findings []*ComplianceFinding `json:"findings"`
+	Scanned  int                  `json:"scanned"`
+	Bookmark string               `json:"bookmark"`
+}
+
+// grantMatches 比较两份授权副本中决定访问结果的字段
+func grantMatches(a, b AccessPermission) bool {
+	return a.IsActive == b.IsActive && a.Action == b.Action && a.ExpiresAt == b.ExpiresAt &&
+		strings.Join(a.Sections, ",") == strings.Join(b.Sections, ",") && a.ViewID == b.ViewID && a.DUAHash == b.DUAHash
+}
+
+// checkGrant 检查一条单独权限，返回发现的异常
+func checkGrant(ctx contractapi.TransactionContextInterface, perm AccessPermission, now time.Time) ([]*ComplianceFinding, error) {
+	finding := func(findingType, detail string) *ComplianceFinding {
+		return &ComplianceFinding{Type: findingType, RecordID: perm.RecordID, GranteeID: perm.GranteeID, Detail: detail}
+	}
+	exists, err := assetExists(ctx, recordKey(perm.RecordID))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []*ComplianceFinding{finding(FindingOrphanGrant, "record no longer exists")}, nil
+	}
+
+	findings := []*ComplianceFinding{}
+	if perm.IsActive && !permissionActive(perm, now) {
+		findings = append(findings, finding(FindingExpiredActive, "expired at "+perm.ExpiresAt))
+	}
+	if permissionActive(perm, now) {
+		frozen, err := assetExists(ctx, frozenKey(perm.GranteeID))
+		if err != nil {
+			return nil, err
+		}
+		if frozen {
+			findings = append(findings, finding(FindingFrozenGrantee, "grantee identity is frozen"))
+		}
+		duaValid, err := grantDUAValid(ctx, perm, now)
+		if err != nil {
+			return nil, err
+		}
+		if !duaValid {
+			findings = append(findings, finding(FindingInactiveDUA, "data use agreement "+perm.DUAHash+" is revoked or expired"))
+		}
+	}
+
+	key, err := accessEntryKey(ctx, perm.RecordID, perm.GranteeID)
+	if err != nil {
+		return nil, err
+	}
+	var entry AccessPermission
+	found, err := getJSON(ctx, key, &entry)
+	if err != nil {
+		return nil, err
+	}
+	migratePermission(&entry)
+	switch {
+	case !found:
+		findings = append(findings, finding(FindingACLMismatch, "access entry missing"))
+	case !grantMatches(entry, perm):
+		findings = append(findings, finding(FindingACLMismatch, "access entry differs from permission"))
+	}
+	accessList, err := getAccessList(ctx, perm.RecordID)
+	if err != nil {
+		return nil, err
+	}
+	if accessList != nil {
+		if listed, ok := accessList.Permissions[perm.GranteeID]; ok && !grantMatches(listed, perm) {
+			findings = append(findings, finding(FindingACLMismatch, "access list entry differs from permission"))
+		}
+	}
+	return findings, nil
+}
+
+// RunComplianceScan 分页遍历单独权限（perm: 键），报告已过期仍有效、授予被冻结身份、引用失效 DUA、记录已不存在
+// 以及与快速路径条目或访问列表不一致的授权；只读，不做整改。仅 auditor/compliance 角色
+func (s *SmartContract) RunComplianceScan(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*ComplianceReport, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	allowed, err := hasAnyRole(ctx, auditorRole, "compliance")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only auditor or compliance roles can run compliance scans")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("perm:", "perm;", pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan permissions: %w", err)
+	}
+	defer iterator.Close()
+
+	report := &ComplianceReport{Findings: []*ComplianceFinding{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate permissions: %w", err)
+		}
+		var perm AccessPermission
+		if err := json.Unmarshal(kv.Value, &perm); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		migratePermission(&perm)
+		findings, err := checkGrant(ctx, perm, now)
+		if err != nil {
+			return nil, err
+		}
+		report.Scanned++
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+// AnchorComplianceSnapshot compliance 角色锚定已结束周期的合规报告；reportHash 为报告文件的 sha256，
+// summaryStatsJson 为汇总统计对象
+func (s *SmartContract) AnchorComplianceSnapshot(ctx contractapi.TransactionContextInterface, reportHash, periodStart, periodEnd, summaryStatsJson string) error {
+	if !sha256HexPattern.MatchString(reportHash) {
+		return fmt.Errorf("reportHash must be a lowercase hex sha256 digest")
+	}
+	start, end, err := parseTimeRange(periodStart, periodEnd)
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		return fmt.Errorf("periodEnd must be after periodStart")
+	}
+	var stats map[string]interface{}
+	if err := unmarshalArg(summaryStatsJson, &stats); err != nil || stats == nil {
+		return fmt.Errorf("summaryStatsJson must be a JSON object")
+	}
+	allowed, err := hasRole(ctx, "compliance")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: only the compliance role can anchor compliance snapshots")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if end.After(now) {
+		return fmt.Errorf("cannot anchor a report for a period that has not ended")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	snapshot := ComplianceSnapshot{
+		ReportHash:   reportHash,
+		PeriodStart:  start.UTC().Format(time.RFC3339),
+		PeriodEnd:    end.UTC().Format(time.RFC3339),
+		SummaryStats: stats,
+		AnchoredBy:   callerID,
+		AnchoredAt:   now.Format(time.RFC3339),
+		TxID:         txID,
+	}
+	if err := putJSON(ctx, complianceSnapshotKey(start, now, txID), snapshot); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ComplianceSnapshotAnchored", ComplianceSnapshotAnchoredEvent{
+		ReportHash:  reportHash,
+		PeriodStart: snapshot.PeriodStart,
+		PeriodEnd:   snapshot.PeriodEnd,
+		Timestamp:   snapshot.AnchoredAt,
+		CallerID:    callerID,
+		EventType:   "ComplianceSnapshotAnchored",
+	})
+}
+
+// GetComplianceSnapshots 分页返回周期起点在 [fromTimestamp, toTimestamp] 内的快照（含更正版本），按周期与锚定时间排序；
+// regulator、auditor 与 compliance 角色可查询
+func (s *SmartContract) GetComplianceSnapshots(ctx contractapi.TransactionContextInterface, fromTimestamp, toTimestamp string, pageSize int32, bookmark string) (*ComplianceSnapshotPage, error) {
+	from, to, err := parseTimeRange(fromTimestamp, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	allowed, err := hasAnyRole(ctx, regulatorRole, auditorRole, "compliance")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only regulator, auditor or compliance roles can read compliance snapshots")
+	}
+
+	page := &ComplianceSnapshotPage{Snapshots: []*ComplianceSnapshot{}}
+	page.Bookmark, err = scanAuditRange(ctx, "compliance:", from, to, pageSize, bookmark, func(value []byte) error {
+		var snapshot ComplianceSnapshot
+		if err := json.Unmarshal(value, &snapshot); err != nil {
+			return fmt.Errorf("failed to unmarshal compliance snapshot: %w", err)
+		}
+		page.Snapshots = append(page.Snapshots, &snapshot)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
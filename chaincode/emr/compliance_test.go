@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var complianceOfficer = newIdentity("compliance1", "Org1MSP", "role", "compliance")
+
+func TestRunComplianceScanReportsAnomalies(t *testing.T) {
+	env := newTestEnv(t)
+	for _, recordID := range []string{"rec1", "rec2", "rec3"} {
+		env.createRecord(doctor, recordID, patient.id)
+	}
+	env.grant(patient, "rec1", nurse.id, "read", env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	env.grant(patient, "rec1", specialist.id, "read", "")
+	env.grant(patient, "rec2", other.id, "read", "")
+	env.grant(patient, "rec3", other.id, "read", "")
+
+	scan := func(identity *testIdentity) []*ComplianceFinding {
+		t.Helper()
+		findings := []*ComplianceFinding{}
+		bookmark := ""
+		for {
+			var report *ComplianceReport
+			env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+				var err error
+				report, err = env.cc.RunComplianceScan(ctx, 2, bookmark)
+				return err
+			})
+			findings = append(findings, report.Findings...)
+			if bookmark = report.Bookmark; bookmark == "" {
+				return findings
+			}
+		}
+	}
+	if findings := scan(auditor); len(findings) != 0 {
+		t.Fatalf("consistent grants must not be reported: %+v", findings[0])
+	}
+
+	env.advance(2 * time.Hour)
+	env.mustInvoke(securityOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FreezeIdentity(ctx, specialist.id, strings.Repeat("ab", 32))
+	})
+	delete(env.stub.State, recordKey("rec2"))
+	key, _ := env.stub.CreateCompositeKey(accessEntryIndex, []string{"rec3", other.id})
+	var entry AccessPermission
+	_ = json.Unmarshal(env.stub.State[key], &entry)
+	entry.Action = "write"
+	env.stub.State[key], _ = json.Marshal(entry)
+
+	found := map[string]string{}
+	for _, finding := range scan(complianceOfficer) {
+		found[finding.Type] = finding.RecordID + "/" + finding.GranteeID
+	}
+	want := map[string]string{
+		FindingExpiredActive: "rec1/" + nurse.id,
+		FindingFrozenGrantee: "rec1/" + specialist.id,
+		FindingOrphanGrant:   "rec2/" + other.id,
+		FindingACLMismatch:   "rec3/" + other.id,
+	}
+	if len(found) != len(want) {
+		t.Fatalf("unexpected findings: %v", found)
+	}
+	for findingType, subject := range want {
+		if found[findingType] != subject {
+			t.Fatalf("%s: got %q, want %q", findingType, found[findingType], subject)
+		}
+	}
+
+	env.mustFail(doctor, "only auditor or compliance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.RunComplianceScan(ctx, 10, "")
+		return err
+	})
+}
+
+func TestComplianceSnapshotsKeepCorrections(t *testing.T) {
+	env := newTestEnv(t)
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := march.AddDate(0, 1, 0)
+	env.stub.now = april.Add(48 * time.Hour)
+	anchor := func(identity *testIdentity, hash string, start, end time.Time) error {
+		return env.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AnchorComplianceSnapshot(ctx, hash, start.Format(time.RFC3339), end.Format(time.RFC3339), `{"grantsScanned":120,"findings":3}`)
+		})
+	}
+	if err := anchor(auditor, strings.Repeat("a", 64), march, april); err == nil {
+		t.Fatal("only the compliance role can anchor snapshots")
+	}
+	if err := anchor(complianceOfficer, strings.Repeat("a", 64), april, april.AddDate(0, 1, 0)); err == nil {
+		t.Fatal("a period that has not ended must be rejected")
+	}
+	if err := anchor(complianceOfficer, strings.Repeat("a", 64), march, april); err != nil {
+		t.Fatal(err)
+	}
+	env.expectEvent("ComplianceSnapshotAnchored", nil)
+	env.advance(time.Hour)
+	if err := anchor(complianceOfficer, strings.Repeat("b", 64), march, april); err != nil {
+		t.Fatal(err)
+	}
+
+	env.mustInvoke(regulator, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetComplianceSnapshots(ctx, march.Format(time.RFC3339), march.Format(time.RFC3339), 10, "")
+		if err != nil {
+			return err
+		}
+		if len(page.Snapshots) != 2 || page.Snapshots[0].ReportHash != strings.Repeat("a", 64) || page.Snapshots[1].ReportHash != strings.Repeat("b", 64) {
+			t.Fatalf("both versions must be returned in anchoring order: %+v", page.Snapshots)
+		}
+		if page.Snapshots[1].SummaryStats["findings"] != float64(3) || page.Snapshots[1].PeriodEnd != april.Format(time.RFC3339) {
+			t.Fatalf("unexpected snapshot: %+v", page.Snapshots[1])
+		}
+		return nil
+	})
+	env.mustFail(doctor, "only regulator, auditor or compliance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetComplianceSnapshots(ctx, march.Format(time.RFC3339), april.Format(time.RFC3339), 10, "")
+		return err
+	})
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ConditionActive   = "active"
+	ConditionResolved = "resolved"
+)
+
+// snomedCodePattern SNOMED CT 概念 ID：6–18 位数字，不以 0 开头，末位为 Verhoeff 校验位
+var snomedCodePattern = regexp.MustCompile(`^[1-9][0-9]{5,17}$`)
+
+// Condition 问题清单条目；ConditionID 为登记交易
+type Condition struct {
+	ConditionID string `json:"conditionId"`
+	PatientID   string `json:"patientId"`
+	SNOMEDCode  string `json:"snomedCode"`
+	OnsetDate   string `json:"onsetDate"`
+	Status      string `json:"status"`
+	RecordedBy  string `json:"recordedBy"`
+	RecordedAt  string `json:"recordedAt"`
+	ResolvedBy  string `json:"resolvedBy,omitempty"`
+	ResolvedAt  string `json:"resolvedAt,omitempty"`
+}
+
+type ConditionEvent struct {
+	ConditionID string `json:"conditionId"`
+	PatientID   string `json:"patientId"`
+	SNOMEDCode  string `json:"snomedCode"`
+	Status      string `json:"status"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+var (
+	verhoeffMultiplication = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermutation = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// validateSNOMEDCode 校验格式与 Verhoeff 校验位，不校验概念是否存在
+func validateSNOMEDCode(code string) error {
+	if !snomedCodePattern.MatchString(code) {
+		return fmt.Errorf("invalid SNOMED CT code: %q", code)
+	}
+	check := 0
+	for i := 0; i < len(code); i++ {
+		digit := int(code[len(code)-1-i] - '0')
+		check = verhoeffMultiplication[check][verhoeffPermutation[i%8][digit]]
+	}
+	if check != 0 {
+		return fmt.Errorf("invalid SNOMED CT code: %q fails the check digit", code)
+	}
+	return nil
+}
+
+// conditionKey condition:{patientId}:{conditionId}
+func conditionKey(patientID, conditionID string) string {
+	return conditionPrefix(patientID) + keySegment(conditionID)
+}
+
+func conditionPrefix(patientID string) string {
+	return "condition:" + keySegment(patientID) + ":"
+}
+
+// listConditions 按前缀返回患者的问题清单条目，activeOnly 时只返回未解决的条目
+func listConditions(ctx contractapi.TransactionContextInterface, patientID string, activeOnly bool) ([]*Condition, error) {
+	prefix := conditionPrefix(patientID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan conditions: %w", err)
+	}
+	defer iterator.Close()
+
+	conditions := []*Condition{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate conditions: %w", err)
+		}
+		var condition Condition
+		if err := json.Unmarshal(kv.Value, &condition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal condition: %w", err)
+		}
+		if !activeOnly || condition.Status == ConditionActive {
+			conditions = append(conditions, &condition)
+		}
+	}
+	return conditions, nil
+}
+
+// hasActiveCondition 患者的问题清单中有 codes 任一代码的未解决条目
+func hasActiveCondition(ctx contractapi.TransactionContextInterface, patientID string, codes []string) (bool, error) {
+	conditions, err := listConditions(ctx, patientID, true)
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range conditions {
+		if containsString(codes, condition.SNOMEDCode) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// requireConditionWriter 问题清单由临床人员维护：调用者不是患者本人，且对患者任一记录持有 write 权限
+func (s *SmartContract) requireConditionWriter(ctx contractapi.TransactionContextInterface, patientID string) (string, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if callerID == patientID {
+		return "", fmt.Errorf("access denied: the problem list is maintained by clinicians")
+	}
+	allowed, err := anyPatientRecord(ctx, patientID, func(recordID string) (bool, error) {
+		return s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	})
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("access denied: write permission on a record of %s required", patientID)
+	}
+	return callerID, nil
+}
+
+// AddCondition 登记问题清单条目并返回 conditionId；onsetDate 为 YYYY-MM-DD，不晚于交易日期。
+// 同一代码已有未解决条目时拒绝
+func (s *SmartContract) AddCondition(ctx contractapi.TransactionContextInterface, patientID, snomedCode, onsetDate string) (string, error) {
+	if err := validateAddress(patientID); err != nil {
+		return "", fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validateSNOMEDCode(snomedCode); err != nil {
+		return "", err
+	}
+	onset, err := time.Parse("2006-01-02", onsetDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid onsetDate: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if onset.After(now) {
+		return "", fmt.Errorf("onsetDate must not be in the future")
+	}
+	callerID, err := s.requireConditionWriter(ctx, patientID)
+	if err != nil {
+		return "", err
+	}
+	active, err := hasActiveCondition(ctx, patientID, []string{snomedCode})
+	if err != nil {
+		return "", err
+	}
+	if active {
+		return "", fmt.Errorf("condition %s is already active for %s", snomedCode, patientID)
+	}
+
+	condition := Condition{
+		ConditionID: ctx.GetStub().GetTxID(),
+		PatientID:   patientID,
+		SNOMEDCode:  snomedCode,
+		OnsetDate:   onsetDate,
+		Status:      ConditionActive,
+		RecordedBy:  callerID,
+		RecordedAt:  now.Format(time.RFC3339),
+	}
+	if err := putJSON(ctx, conditionKey(patientID, condition.ConditionID), condition); err != nil {
+		return "", err
+	}
+	return condition.ConditionID, emitConditionEvent(ctx, "ConditionAdded", &condition, callerID, condition.RecordedAt)
+}
+
+// ResolveCondition 将条目标记为已解决；条目保留供追溯
+func (s *SmartContract) ResolveCondition(ctx contractapi.TransactionContextInterface, patientID, conditionID string) error {
+	callerID, err := s.requireConditionWriter(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	var condition Condition
+	found, err := getJSON(ctx, conditionKey(patientID, conditionID), &condition)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("condition not found: %s", conditionID)
+	}
+	if condition.Status != ConditionActive {
+		return fmt.Errorf("condition already resolved: %s", conditionID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	condition.Status = ConditionResolved
+	condition.ResolvedBy = callerID
+	condition.ResolvedAt = now
+	if err := putJSON(ctx, conditionKey(patientID, conditionID), condition); err != nil {
+		return err
+	}
+	return emitConditionEvent(ctx, "ConditionResolved", &condition, callerID, now)
+}
+
+// GetActiveConditions 返回未解决的条目；患者本人，或对其任一记录可访问的 doctor/nurse 可读
+func (s *SmartContract) GetActiveConditions(ctx contractapi.TransactionContextInterface, patientID string) ([]*Condition, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		allowed := false
+		if isClinician {
+			allowed, err = anyPatientRecord(ctx, patientID, func(recordID string) (bool, error) {
+				return s.CheckAccess(ctx, recordID, callerID)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("access denied: %s cannot view conditions of %s", callerID, patientID)
+		}
+	}
+	return listConditions(ctx, patientID, true)
+}
+
+func emitConditionEvent(ctx contractapi.TransactionContextInterface, name string, condition *Condition, callerID, now string) error {
+	return emitEvent(ctx, name, ConditionEvent{
+		ConditionID: condition.ConditionID,
+		PatientID:   condition.PatientID,
+		SNOMEDCode:  condition.SNOMEDCode,
+		Status:      condition.Status,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   name,
+	})
+}
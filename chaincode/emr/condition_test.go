@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestValidateSNOMEDCode(t *testing.T) {
+	for _, code := range []string{"363346000", "73211009", "38341003"} {
+		if err := validateSNOMEDCode(code); err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+	}
+	for _, code := range []string{"363346001", "12345", "0363346000", "C50.9"} {
+		if validateSNOMEDCode(code) == nil {
+			t.Fatalf("%s must be rejected", code)
+		}
+	}
+}
+
+func TestProblemListDrivesDepartmentPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	add := func(code, onset string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.AddCondition(ctx, patient.id, code, onset)
+			return err
+		}
+	}
+	env.mustFail(patient, "maintained by clinicians", add("363346000", "2025-12-01"))
+	env.mustFail(other, "write permission", add("363346000", "2025-12-01"))
+	env.mustFail(doctor, "check digit", add("363346001", "2025-12-01"))
+	env.mustFail(doctor, "in the future", add("363346000", "2026-02-01"))
+	var conditionID string
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		conditionID, err = env.cc.AddCondition(ctx, patient.id, "363346000", "2025-12-01")
+		return err
+	})
+	env.expectEvent("ConditionAdded", nil)
+	env.mustFail(doctor, "already active", add("363346000", "2025-12-15"))
+	env.mustInvoke(doctor, add("38341003", "2020-05-01"))
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		conditions, err := env.cc.GetActiveConditions(ctx, patient.id)
+		if err == nil && (len(conditions) != 2 || conditions[0].RecordedBy != doctor.id) {
+			t.Fatalf("unexpected conditions: %+v", conditions)
+		}
+		return err
+	})
+	env.mustFail(nurse, "cannot view conditions", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetActiveConditions(ctx, patient.id)
+		return err
+	})
+
+	// 肿瘤科策略只覆盖问题清单中有恶性肿瘤的患者
+	env.mustFail(admin, "invalid SNOMED CT code", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetDepartmentPolicy(ctx, "Org1MSP", "ed", `{"roles":["clinician"],"windowHours":72,"conditionCodes":["C50"]}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetDepartmentPolicy(ctx, "Org1MSP", "ed", `{"roles":["clinician"],"windowHours":72,"conditionCodes":["363346000"]}`)
+	})
+	env.createRecord(edDoctor, "ed1", patient.id)
+	env.createRecord(edDoctor, "ed2", "patient2")
+	if !env.canRead(edClinician, "ed1") || env.canRead(edClinician, "ed2") {
+		t.Fatal("the policy must follow the patient's problem list")
+	}
+
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResolveCondition(ctx, patient.id, conditionID)
+	})
+	env.expectEvent("ConditionResolved", nil)
+	env.mustFail(doctor, "already resolved", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResolveCondition(ctx, patient.id, conditionID)
+	})
+	if env.canRead(edClinician, "ed1") {
+		t.Fatal("resolved conditions must not satisfy the policy")
+	}
+}
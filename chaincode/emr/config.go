@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const contractConfigKey = "config:contract"
+
+// hashAlgorithmPatterns 可要求的内容哈希算法及其十六进制编码格式
+var hashAlgorithmPatterns = map[string]*regexp.Regexp{
+	"sha256": sha256HexPattern,
+	"sha384": regexp.MustCompile(`^[0-9a-f]{96}$`),
+	"sha512": regexp.MustCompile(`^[0-9a-f]{128}$`),
+}
+
+// ContractConfig 链上可调参数；未设置时使用 defaultConfig，与引入配置前的硬编码行为一致
+type ContractConfig struct {
+	// MaxGrantDurationDays 授权最长有效天数，0 表示不限（允许不过期授权）
+	MaxGrantDurationDays int `json:"maxGrantDurationDays"`
+	// AllowedActions 可授予的权限级别，须为 permissionHierarchy 的子集且包含 read
+	AllowedActions []string `json:"allowedActions"`
+	// HashAlgorithm 要求 contentHash 使用的算法，空串表示不校验
+	HashAlgorithm string `json:"hashAlgorithm"`
+	// MaxBatchUpdates 单笔 UpdateMedicalRecordsBatch 的记录上限，避免写集过大
+	MaxBatchUpdates int `json:"maxBatchUpdates"`
+	// MaxInitialGrants 单笔 CreateMedicalRecordWithGrants 可附带的授权上限
+	MaxInitialGrants int `json:"maxInitialGrants"`
+	// MaxPageSize 分页查询单页条数上限，见 validatePageSize
+	MaxPageSize int `json:"maxPageSize"`
+	// MaxGrantUses 限次授权的次数上限，见 GrantAccessWithUses
+	MaxGrantUses int `json:"maxGrantUses"`
+	// MaxEventBytes 单个事件负载上限，超过时分片，见 events.go
+	MaxEventBytes int `json:"maxEventBytes"`
+	// EventRetentionDays 事件摘要保留天数，0 表示不清理，见 PruneEvents
+	EventRetentionDays int `json:"eventRetentionDays"`
+	// MaxShareCodeTTLSeconds 分享码最长有效秒数，见 CreateShareCode
+	MaxShareCodeTTLSeconds int `json:"maxShareCodeTtlSeconds"`
+	// OutOfRegionMSPs 区域外机构，向其身份共享记录须经跨境传输审批，见 crossborder.go
+	OutOfRegionMSPs []string `json:"outOfRegionMsps,omitempty"`
+	// ExternalMSPs 外部机构，其身份读取记录须持引用有效数据使用协议的授权，见 dua.go
+	ExternalMSPs []string `json:"externalMsps,omitempty"`
+	// TwoPhaseRecordTypes 须经 ProposeRecordUpdate/CommitRecordUpdate 两阶段更新的记录类型，见 proposal.go
+	TwoPhaseRecordTypes []string `json:"twoPhaseRecordTypes,omitempty"`
+	// CosignRequiredTypes 须共同签署的记录类型及合格签署人须为 true 的证书属性（如 attending），见 cosign.go
+	CosignRequiredTypes map[string]string `json:"cosignRequiredTypes,omitempty"`
+	// BreakGlassCodes 紧急访问可用的理由代码，见 breakglass.go
+	BreakGlassCodes []string `json:"breakGlassCodes"`
+	// BreakGlassReviewSLAHours 紧急访问须在此时限内复核，超时条目由 ListOverdueBreakGlass 列出
+	BreakGlassReviewSLAHours int `json:"breakGlassReviewSlaHours"`
+	// ReasonCodes 读取原因代码集，见 readReasonCode
+	ReasonCodes []string `json:"reasonCodes"`
+	// JustificationDueHours 读取高敏记录后补交理由的时限，见 obligation.go
+	JustificationDueHours int `json:"justificationDueHours"`
+	// MinCohortSize 人群计数的最小披露阈值，低于时只返回 insufficient，见 research.go
+	MinCohortSize int `json:"minCohortSize"`
+	// Features 功能开关，只能经 EnableFeature/DisableFeature 修改
+	Features  map[string]bool `json:"features,omitempty"`
+	UpdatedAt string          `json:"updatedAt,omitempty"`
+	UpdatedBy string          `json:"updatedBy,omitempty"`
+}
+
+type ContractConfigUpdatedEvent struct {
+	Config    ContractConfig `json:"config"`
+	Timestamp string         `json:"timestamp"`
+	CallerID  string         `json:"callerId"`
+	EventType string         `json:"eventType"`
+}
+
+func defaultConfig() *ContractConfig {
+	return &ContractConfig{
+		AllowedActions:   []string{"read", "share", "write", "admin"},
+		MaxBatchUpdates:  100,
+		MaxInitialGrants: 50,
+		MaxPageSize:      100,
+		MaxGrantUses:     1000,
+		MaxEventBytes:    64 * 1024,
+
+		MaxShareCodeTTLSeconds: 15 * 60,
+		MinCohortSize:          10,
+
+		BreakGlassCodes:          []string{"unconscious-patient", "life-threat", "urgent-treatment", "public-health"},
+		BreakGlassReviewSLAHours: 72,
+		JustificationDueHours:    24,
+		ReasonCodes:              []string{"direct-care", "care-coordination", "billing", "quality-review", "legal-request", "patient-request"},
+	}
+}
+
+// loadConfig 读取链上配置，未设置时返回默认值
+func loadConfig(ctx contractapi.TransactionContextInterface) (*ContractConfig, error) {
+	config := defaultConfig()
+	if _, err := getJSON(ctx, contractConfigKey, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (config *ContractConfig) validate() error {
+	if config.MaxGrantDurationDays < 0 {
+		return fmt.Errorf("maxGrantDurationDays must not be negative")
+	}
+	if len(config.AllowedActions) == 0 || !containsString(config.AllowedActions, "read") {
+		return fmt.Errorf("allowedActions must include read")
+	}
+	for _, action := range config.AllowedActions {
+		if _, ok := permissionHierarchy[action]; !ok {
+			return fmt.Errorf("invalid action in allowedActions: %s", action)
+		}
+	}
+	if _, ok := hashAlgorithmPatterns[config.HashAlgorithm]; config.HashAlgorithm != "" && !ok {
+		return fmt.Errorf("unsupported hashAlgorithm: %s", config.HashAlgorithm)
+	}
+	if config.MaxBatchUpdates < 1 || config.MaxBatchUpdates > 1000 {
+		return fmt.Errorf("maxBatchUpdates must be between 1 and 1000")
+	}
+	if config.MaxInitialGrants < 1 || config.MaxInitialGrants > 1000 {
+		return fmt.Errorf("maxInitialGrants must be between 1 and 1000")
+	}
+	if config.MaxPageSize < 1 || config.MaxPageSize > 1000 {
+		return fmt.Errorf("maxPageSize must be between 1 and 1000")
+	}
+	if config.MaxGrantUses < 1 || config.MaxGrantUses > 100000 {
+		return fmt.Errorf("maxGrantUses must be between 1 and 100000")
+	}
+	if config.MaxEventBytes < 1024 || config.MaxEventBytes > 1024*1024 {
+		return fmt.Errorf("maxEventBytes must be between 1024 and 1048576")
+	}
+	if config.EventRetentionDays < 0 {
+		return fmt.Errorf("eventRetentionDays must not be negative")
+	}
+	if config.MaxShareCodeTTLSeconds < 60 || config.MaxShareCodeTTLSeconds > 24*60*60 {
+		return fmt.Errorf("maxShareCodeTtlSeconds must be between 60 and 86400")
+	}
+	if config.MinCohortSize < 1 {
+		return fmt.Errorf("minCohortSize must be at least 1")
+	}
+	if len(config.BreakGlassCodes) == 0 {
+		return fmt.Errorf("breakGlassCodes must not be empty")
+	}
+	for _, code := range config.BreakGlassCodes {
+		if !recordTypePattern.MatchString(code) {
+			return fmt.Errorf("invalid code in breakGlassCodes: %q", code)
+		}
+	}
+	if config.BreakGlassReviewSLAHours < 1 || config.BreakGlassReviewSLAHours > 24*30 {
+		return fmt.Errorf("breakGlassReviewSlaHours must be between 1 and 720")
+	}
+	if len(config.ReasonCodes) == 0 {
+		return fmt.Errorf("reasonCodes must not be empty")
+	}
+	for _, code := range config.ReasonCodes {
+		if !recordTypePattern.MatchString(code) {
+			return fmt.Errorf("invalid code in reasonCodes: %q", code)
+		}
+	}
+	if config.JustificationDueHours < 1 || config.JustificationDueHours > 24*7 {
+		return fmt.Errorf("justificationDueHours must be between 1 and 168")
+	}
+	for _, mspID := range config.OutOfRegionMSPs {
+		if mspID == "" {
+			return fmt.Errorf("outOfRegionMsps must not contain empty entries")
+		}
+	}
+	for _, recordType := range config.TwoPhaseRecordTypes {
+		if !recordTypePattern.MatchString(recordType) {
+			return fmt.Errorf("invalid recordType in twoPhaseRecordTypes: %q", recordType)
+		}
+	}
+	for recordType, attribute := range config.CosignRequiredTypes {
+		if !recordTypePattern.MatchString(recordType) {
+			return fmt.Errorf("invalid recordType in cosignRequiredTypes: %q", recordType)
+		}
+		if !recordTypePattern.MatchString(attribute) {
+			return fmt.Errorf("invalid cosigner attribute for %s: %q", recordType, attribute)
+		}
+	}
+	for _, mspID := range config.ExternalMSPs {
+		if mspID == "" {
+			return fmt.Errorf("externalMsps must not contain empty entries")
+		}
+	}
+	return nil
+}
+
+// checkAction 校验授权级别是否符合配置
+func (config *ContractConfig) checkAction(action string) error {
+	if _, ok := permissionHierarchy[action]; !ok || !containsString(config.AllowedActions, action) {
+		return fmt.Errorf("invalid action: %s", action)
+	}
+	return nil
+}
+
+// checkGrant 校验授权级别与有效期是否符合配置；now 为交易时间
+func (config *ContractConfig) checkGrant(action, expiresAt string, now time.Time) error {
+	if err := config.checkAction(action); err != nil {
+		return err
+	}
+	if expiresAt == "" {
+		if config.MaxGrantDurationDays > 0 {
+			return fmt.Errorf("expiresAt is required: grants are limited to %d days", config.MaxGrantDurationDays)
+		}
+		return nil
+	}
+	expires, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expiresAt: %w", err)
+	}
+	if config.MaxGrantDurationDays > 0 && expires.After(now.AddDate(0, 0, config.MaxGrantDurationDays)) {
+		return fmt.Errorf("expiresAt exceeds the maximum grant duration of %d days", config.MaxGrantDurationDays)
+	}
+	return nil
+}
+
+func (config *ContractConfig) checkContentHash(contentHash string) error {
+	if config.HashAlgorithm == "" || hashAlgorithmPatterns[config.HashAlgorithm].MatchString(contentHash) {
+		return nil
+	}
+	return fmt.Errorf("invalid contentHash: expected hex-encoded %s", config.HashAlgorithm)
+}
+
+// SetContractConfig 整体替换链上配置，功能开关保持不变；仅 admin
+func (s *SmartContract) SetContractConfig(ctx contractapi.TransactionContextInterface, configJson string) error {
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("access denied: only admin can change the contract configuration")
+	}
+	current, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	config := defaultConfig()
+	if err := unmarshalArg(configJson, config); err != nil {
+		return fmt.Errorf("invalid config json: %w", err)
+	}
+	if err := config.validate(); err != nil {
+		return err
+	}
+	config.Features = current.Features
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	config.UpdatedAt = now
+	config.UpdatedBy = callerID
+	if err := putJSON(ctx, contractConfigKey, config); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ContractConfigUpdated", ContractConfigUpdatedEvent{
+		Config:    *config,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "ContractConfigUpdated",
+	})
+}
+
+// GetContractConfig 返回当前生效的配置（未设置时为默认值）
+func (s *SmartContract) GetContractConfig(ctx contractapi.TransactionContextInterface) (*ContractConfig, error) {
+	return loadConfig(ctx)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestContractConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		config, err := env.cc.GetContractConfig(ctx)
+		if err == nil && (config.MaxBatchUpdates != 100 || len(config.AllowedActions) != 4 || config.HashAlgorithm != "") {
+			t.Fatalf("defaults must match the previous hard-coded behaviour: %+v", config)
+		}
+		return err
+	})
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{}`)
+	})
+	env.mustFail(admin, "must include read", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"allowedActions":["write"]}`)
+	})
+	env.mustFail(admin, "unsupported hashAlgorithm", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"hashAlgorithm":"md5"}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"maxGrantDurationDays":30,"allowedActions":["read","write"],"hashAlgorithm":"sha256","maxBatchUpdates":1}`)
+	})
+	var event ContractConfigUpdatedEvent
+	env.expectEvent("ContractConfigUpdated", &event)
+	if event.Config.MaxInitialGrants != 50 || event.Config.UpdatedBy != admin.id {
+		t.Fatalf("omitted fields must keep their defaults: %+v", event.Config)
+	}
+
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustFail(doctor, "expected hex-encoded sha256", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"rec3","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"abc"}`)
+		return err
+	})
+	env.mustFail(doctor, "expected hex-encoded sha256", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", "abc")
+	})
+	env.mustFail(doctor, "between 1 and 1 updates", func(ctx contractapi.TransactionContextInterface) error {
+		hash := strings.Repeat("b", 64)
+		return env.cc.UpdateMedicalRecordsBatch(ctx, `[{"recordId":"rec1","ipfsCid":"x","contentHash":"`+hash+`"},{"recordId":"rec2","ipfsCid":"x","contentHash":"`+hash+`"}]`)
+	})
+
+	env.mustFail(patient, "invalid action: share", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithExpiry(ctx, "rec1", nurse.id, "share", env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	})
+	env.mustFail(patient, "expiresAt is required", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	})
+	env.mustFail(patient, "maximum grant duration of 30 days", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithExpiry(ctx, "rec1", nurse.id, "read", env.stub.now.AddDate(0, 0, 31).Format(time.RFC3339))
+	})
+	env.grant(patient, "rec1", nurse.id, "read", env.stub.now.AddDate(0, 0, 30).Format(time.RFC3339))
+}
+
+func TestPageSizeAndGrantUsesFromConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	listPage := func(pageSize int32) error {
+		return env.invoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.ListRecordsByCreator(ctx, doctor.id, pageSize, "")
+			return err
+		})
+	}
+	if err := listPage(100); err != nil {
+		t.Fatalf("default maxPageSize must allow 100: %v", err)
+	}
+	if err := listPage(101); err == nil || !strings.Contains(err.Error(), "between 1 and 100") {
+		t.Fatalf("expected the default page size limit, got %v", err)
+	}
+
+	for config, want := range map[string]string{
+		`{"maxPageSize":0}`:       "maxPageSize must be between",
+		`{"maxPageSize":1001}`:    "maxPageSize must be between",
+		`{"maxGrantUses":0}`:      "maxGrantUses must be between",
+		`{"maxGrantUses":100001}`: "maxGrantUses must be between",
+	} {
+		env.mustFail(admin, want, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.SetContractConfig(ctx, config)
+		})
+	}
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"maxPageSize":500,"maxGrantUses":3}`)
+	})
+	if err := listPage(500); err != nil {
+		t.Fatalf("configured maxPageSize must apply: %v", err)
+	}
+	env.mustFail(patient, "maxUses must be between 1 and 3", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithUses(ctx, "rec1", specialist.id, "", 4)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithUses(ctx, "rec1", specialist.id, "", 3)
+	})
+}
@@ -0,0 +1,1612 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SmartContract EMR 链码合约：医疗记录锚点与访问控制
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// 医疗记录结构
+type MedicalRecord struct {
+	// SchemaVersion 存储结构版本，缺省为 1，见 schema.go
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// DocType 供 CouchDB 索引区分文档类型，固定为 medicalRecord
+	DocType     string `json:"docType,omitempty"`
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	CreatorID   string `json:"creatorId"`
+	IPCSCID     string `json:"ipfsCid"`
+	ContentHash string `json:"contentHash"`
+	VersionHash string `json:"versionHash,omitempty"`
+	Timestamp   string `json:"timestamp"`
+	// FHIR 资源类型与最小元数据，供下游 FHIR 网关映射
+	FhirResourceType string                 `json:"fhirResourceType,omitempty"`
+	FhirVersion      string                 `json:"fhirVersion,omitempty"`
+	FhirMetadata     map[string]interface{} `json:"fhirMetadata,omitempty"`
+	// 来源层级：clinician 或 patient-generated，由链码根据创建者推导
+	ProvenanceTier string `json:"provenanceTier,omitempty"`
+	// RecordType 业务分类（如 lab、imaging、note），可选
+	RecordType string `json:"recordType,omitempty"`
+	// Metadata 业务元数据；该 recordType 注册了模式时按模式校验
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Status 记录状态，创建时为 active
+	Status string `json:"status,omitempty"`
+	// Sensitivity normal 或 high；high 记录的授权须经会签，见 sensitive.go
+	Sensitivity string `json:"sensitivity,omitempty"`
+	// 历史导入：Migrated 标记由 ImportLegacyRecord 写入，与原生创建的锚点区分
+	Migrated       bool     `json:"migrated,omitempty"`
+	SourceSystem   string   `json:"sourceSystem,omitempty"`
+	OriginalHashes []string `json:"originalHashes,omitempty"`
+	ImportedAt     string   `json:"importedAt,omitempty"`
+	// Parts 多部分记录（病程记录加附件），有序；为空时记录只有 ipfsCid/contentHash 一部分，见 parts.go
+	Parts []RecordPart `json:"parts,omitempty"`
+	// Sections 可单独授权的分节清单，见 sections.go
+	Sections []RecordSection `json:"sections,omitempty"`
+	// FieldRoot 字段级哈希的 Merkle 根，供选择性披露验证，见 merkle.go
+	FieldRoot string `json:"fieldRoot,omitempty"`
+	// RequirePatientApproval 他人的更新须经患者批准，见 approval.go
+	RequirePatientApproval bool `json:"requirePatientApproval,omitempty"`
+	// SignStatus 须共同签署的记录类型为 unsigned 或 signed，其他记录为空，见 cosign.go
+	SignStatus string `json:"signStatus,omitempty"`
+	CoSignedBy string `json:"coSignedBy,omitempty"`
+	CoSignedAt string `json:"coSignedAt,omitempty"`
+	// CreatorMSP、Department 创建交易调用者的机构与证书 department 属性，见 department.go
+	CreatorMSP string `json:"creatorMsp,omitempty"`
+	Department string `json:"department,omitempty"`
+	// DocumentStatus 受状态转换规则约束的记录类型为 preliminary 或 final，其他记录为空，见 transition.go
+	DocumentStatus string `json:"documentStatus,omitempty"`
+	SignedOffBy    string `json:"signedOffBy,omitempty"`
+	SignedOffAt    string `json:"signedOffAt,omitempty"`
+}
+
+// 访问权限结构
+type AccessPermission struct {
+	SchemaVersion int    `json:"schemaVersion,omitempty"`
+	RecordID      string `json:"recordId"`
+	GranteeID     string `json:"granteeId"`
+	Action        string `json:"action"`
+	ExpiresAt     string `json:"expiresAt,omitempty"`
+	GrantedAt     string `json:"grantedAt"`
+	GrantedBy     string `json:"grantedBy"`
+	IsActive      bool   `json:"isActive"`
+	// ValidFrom 授权生效时间，临时人员的授权取其登记窗口起点，见 tempstaff.go
+	ValidFrom string `json:"validFrom,omitempty"`
+	// SupervisorID 实习人员的授权仅在督导人对同一记录也有有效授权时生效，见 supervision.go
+	SupervisorID string `json:"supervisorId,omitempty"`
+	// 暂停的授权 isActive 为 false，可经 ResumeAccess 恢复；撤销时清空，见 suspend.go
+	SuspendedAt   string `json:"suspendedAt,omitempty"`
+	SuspendReason string `json:"suspendReason,omitempty"`
+	// MaxUses 限次授权的总次数，0 表示不限；RemainingUses 由 ReadRecord 递减，见 uses.go
+	MaxUses       int `json:"maxUses,omitempty"`
+	RemainingUses int `json:"remainingUses,omitempty"`
+	// TxID 写入授权的交易，同意回执据此指向授权交易
+	TxID string `json:"txId,omitempty"`
+	// Sections 分节授权覆盖的分节，ViewID 视图授权指向的脱敏视图；均为空表示整条记录
+	Sections []string `json:"sections,omitempty"`
+	ViewID   string   `json:"viewId,omitempty"`
+	// DUAHash 授权引用的数据使用协议，协议撤销或过期后授权随即失效，见 dua.go
+	DUAHash string `json:"duaHash,omitempty"`
+	// RequiresAck 被授权人读取后须确认，见 ack.go
+	RequiresAck bool `json:"requiresAck,omitempty"`
+	// HandoverID 经交接班转交的授权为该次交接，grantedBy 为交班人，见 handover.go
+	HandoverID string `json:"handoverId,omitempty"`
+	// EncounterID 授权绑定的住院就诊，出院时撤销，见 discharge.go
+	EncounterID string `json:"encounterId,omitempty"`
+	// EpisodeID 授权引用的诊疗周期，周期关闭后失效，见 episode.go
+	EpisodeID string `json:"episodeId,omitempty"`
+}
+
+// 访问控制列表
+type AccessList struct {
+	SchemaVersion int                         `json:"schemaVersion,omitempty"`
+	RecordID      string                      `json:"recordId"`
+	Owner         string                      `json:"owner"`
+	Permissions   map[string]AccessPermission `json:"permissions"`
+	UpdatedAt     string                      `json:"updatedAt"`
+}
+
+// RecordMetadata 记录元数据（不含存储位置）
+type RecordMetadata struct {
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	CreatorID   string `json:"creatorId"`
+	ContentHash string `json:"contentHash"`
+	VersionHash string `json:"versionHash,omitempty"`
+	Timestamp   string `json:"timestamp"`
+
+	FhirResourceType string `json:"fhirResourceType,omitempty"`
+	FhirVersion      string `json:"fhirVersion,omitempty"`
+
+	Migrated     bool   `json:"migrated,omitempty"`
+	SourceSystem string `json:"sourceSystem,omitempty"`
+
+	// AccessCounters 成功读取与更新的累计次数，见 counters.go
+	AccessCounters RecordAccessCounters `json:"accessCounters"`
+
+	// Disputed 患者对记录内容有未解决的争议，OpenDisputes 为其争议 ID，见 dispute.go
+	Disputed     bool     `json:"disputed"`
+	OpenDisputes []string `json:"openDisputes,omitempty"`
+
+	// SignStatus 须共同签署的记录为 unsigned 或 signed，见 cosign.go
+	SignStatus string `json:"signStatus,omitempty"`
+	// DocumentStatus 受状态转换规则约束的记录为 preliminary 或 final，见 transition.go
+	DocumentStatus string `json:"documentStatus,omitempty"`
+}
+
+// 事件结构
+type RecordCreatedEvent struct {
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	CreatorID   string `json:"creatorId"`
+	IPFSCid     string `json:"ipfsCid"`
+	ContentHash string `json:"contentHash"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+type RecordUpdatedEvent struct {
+	RecordID    string `json:"recordId"`
+	ContentHash string `json:"contentHash"`
+	VersionHash string `json:"versionHash"`
+	// ProposalID 经两阶段更新提交时为所提交的提议，见 proposal.go
+	ProposalID string `json:"proposalId,omitempty"`
+	// CorrectionID 接受患者更正申请生成修正版本时为该申请，见 correction.go
+	CorrectionID string `json:"correctionId,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+type AccessGrantedEvent struct {
+	RecordID  string   `json:"recordId"`
+	GranteeID string   `json:"granteeId"`
+	Action    string   `json:"action"`
+	ExpiresAt string   `json:"expiresAt,omitempty"`
+	MaxUses   int      `json:"maxUses,omitempty"`
+	Sections  []string `json:"sections,omitempty"`
+	ViewID    string   `json:"viewId,omitempty"`
+	DUAHash   string   `json:"duaHash,omitempty"`
+	// RequiresAck 被授权人读取后须以 AcknowledgeAccess 确认
+	RequiresAck bool   `json:"requiresAck,omitempty"`
+	EncounterID string `json:"encounterId,omitempty"`
+	EpisodeID   string `json:"episodeId,omitempty"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	ActingFor   string `json:"actingFor,omitempty"`
+	EventType   string `json:"eventType"`
+}
+
+type AccessRevokedEvent struct {
+	RecordID  string `json:"recordId"`
+	GranteeID string `json:"granteeId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	ActingFor string `json:"actingFor,omitempty"`
+	EventType string `json:"eventType"`
+}
+
+type RecordAccessedEvent struct {
+	RecordID     string `json:"recordId"`
+	AccessorID   string `json:"accessorId"`
+	Allowed      bool   `json:"allowed"`
+	PurposeOfUse string `json:"purposeOfUse,omitempty"`
+	// ReasonCode 读取原因代码，经 transient 传入，见 readReasonCode
+	ReasonCode string `json:"reasonCode,omitempty"`
+	// SupervisorID 访问者为已关联督导人的实习人员时的督导人
+	SupervisorID string `json:"supervisorId,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	EventType    string `json:"eventType"`
+}
+
+// RecordMetadataAccessedEvent 带原因代码读取元数据时发出，使原因进入记录审计轨迹
+type RecordMetadataAccessedEvent struct {
+	RecordID   string `json:"recordId"`
+	AccessorID string `json:"accessorId"`
+	ReasonCode string `json:"reasonCode"`
+	Timestamp  string `json:"timestamp"`
+	EventType  string `json:"eventType"`
+}
+
+// 权限层级定义：admin > write > share > read
+var permissionHierarchy = map[string]int{
+	"read":  1,
+	"share": 2,
+	"write": 3,
+	"admin": 4,
+}
+
+var addressPattern = regexp.MustCompile(`^[A-Za-z0-9._:@/+=%-]{1,256}$`)
+
+var recordTypePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+const (
+	recordDocType = "medicalRecord"
+
+	RecordActive   = "active"
+	RecordArchived = "archived"
+)
+
+// patientRecordIndex 患者 → 记录索引，由 CreateMedicalRecord 写入
+const patientRecordIndex = "patient~record"
+
+// granteeRecordIndex 被授权人 → 记录索引，首次写入单独权限时建立；撤销后保留，权限以 isActive=false 列出
+const granteeRecordIndex = "grantee~record"
+
+// PermissionPage GetUserPermissions 的分页结果；Bookmark 为空表示没有下一页
+type PermissionPage struct {
+	Permissions []*AccessPermission `json:"permissions"`
+	Bookmark    string              `json:"bookmark"`
+}
+
+func recordKey(recordID string) string {
+	return "record:" + recordID
+}
+
+func accessListKey(recordID string) string {
+	return "access:" + recordID
+}
+
+func permKey(recordID, granteeID string) string {
+	return "perm:" + recordID + ":" + granteeID
+}
+
+// accessEntryIndex 访问判断快速路径：每个 (记录, 用户) 一条权限副本，所有者、创建者与授权同步写入，
+// CheckAccess 常见情况只需读取这一个键
+const accessEntryIndex = "access"
+
+func accessEntryKey(ctx contractapi.TransactionContextInterface, recordID, userID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(accessEntryIndex, []string{recordID, userID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create access entry key: %w", err)
+	}
+	return key, nil
+}
+
+func putAccessEntry(ctx contractapi.TransactionContextInterface, perm AccessPermission) error {
+	key, err := accessEntryKey(ctx, perm.RecordID, perm.GranteeID)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, key, perm); err != nil {
+		return fmt.Errorf("failed to store access entry: %w", err)
+	}
+	return nil
+}
+
+// getPermission 读取单独权限键，不存在时返回 nil
+func getPermission(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*AccessPermission, error) {
+	data, err := ctx.GetStub().GetState(permKey(recordID, granteeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var perm AccessPermission
+	if err := json.Unmarshal(data, &perm); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal permission: %w", err)
+	}
+	migratePermission(&perm)
+	return &perm, nil
+}
+
+// validateAddress 校验记录 ID 与身份 ID 的格式
+func validateAddress(value string) error {
+	if !addressPattern.MatchString(value) {
+		return fmt.Errorf("invalid identifier format: %q", value)
+	}
+	return nil
+}
+
+func assetExists(ctx contractapi.TransactionContextInterface, key string) (bool, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, err
+	}
+	return len(data) > 0, nil
+}
+
+func getRecord(ctx contractapi.TransactionContextInterface, recordID string) (*MedicalRecord, error) {
+	recordData, err := ctx.GetStub().GetState(recordKey(recordID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record: %w", err)
+	}
+	if len(recordData) == 0 {
+		return nil, fmt.Errorf("record not found: %s", recordID)
+	}
+	var record MedicalRecord
+	if err := json.Unmarshal(recordData, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+	migrateRecord(&record)
+	return &record, nil
+}
+
+func getAccessList(ctx contractapi.TransactionContextInterface, recordID string) (*AccessList, error) {
+	data, err := ctx.GetStub().GetState(accessListKey(recordID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access list: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var accessList AccessList
+	if err := json.Unmarshal(data, &accessList); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal access list: %w", err)
+	}
+	migrateAccessList(&accessList)
+	return &accessList, nil
+}
+
+// getCallerID 返回调用者的应用层 ID：证书已绑定时为绑定的用户 ID，否则为证书 ID
+func getCallerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	certID, err := callerCertID(ctx)
+	if err != nil {
+		return "", err
+	}
+	appUserID, err := boundUserID(ctx, certID)
+	if err != nil {
+		return "", err
+	}
+	if appUserID != "" {
+		return appUserID, nil
+	}
+	return certID, nil
+}
+
+// hasRole 判断调用者证书属性 role 是否包含指定角色（多个角色以逗号分隔）
+func hasRole(ctx contractapi.TransactionContextInterface, role string) (bool, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue("role")
+	if err != nil {
+		return false, fmt.Errorf("failed to read role attribute: %w", err)
+	}
+	if !found {
+		return false, nil
+	}
+	for _, r := range strings.Split(value, ",") {
+		if strings.TrimSpace(r) == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasAnyRole 判断调用者是否具备 roles 中任一角色
+func hasAnyRole(ctx contractapi.TransactionContextInterface, roles ...string) (bool, error) {
+	for _, role := range roles {
+		ok, err := hasRole(ctx, role)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// requirePatient 调用者须为患者本人（证书 ID 或其控制的 DID）
+func requirePatient(ctx contractapi.TransactionContextInterface, patientID, what string) (string, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return "", err
+	}
+	if !isPatient {
+		return "", fmt.Errorf("access denied: only the patient can %s", what)
+	}
+	return callerID, nil
+}
+
+// txTime 返回交易时间戳；同一交易在各背书节点上取值一致
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %w", err)
+	}
+	return ts.AsTime().UTC(), nil
+}
+
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	return now.Format(time.RFC3339), nil
+}
+
+func putJSON(ctx contractapi.TransactionContextInterface, key string, value interface{}) error {
+	data, err := json.Marshal(stampSchemaVersion(value))
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	return ctx.GetStub().PutState(key, data)
+}
+
+// getJSON 读取并反序列化状态；键不存在时返回 false
+func getJSON(ctx contractapi.TransactionContextInterface, key string, value interface{}) (bool, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func unmarshalArg(arg string, value interface{}) error {
+	return json.Unmarshal([]byte(arg), value)
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// emitEvent 序列化并发出事件，同时写入事件摘要索引；负载超过 maxEventBytes 时分片，见 events.go
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	eventBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", name, err)
+	}
+	if err := indexEvent(ctx, name, eventBytes); err != nil {
+		return err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if len(eventBytes) > config.MaxEventBytes {
+		eventBytes, err = setChunkedEvent(ctx, name, eventBytes, config.MaxEventBytes)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ctx.GetStub().SetEvent(name, eventBytes); err != nil {
+		return fmt.Errorf("failed to emit %s event: %w", name, err)
+	}
+	return nil
+}
+
+// emitRecordAccessedEvent 记录访问事件；被拒时的 ReadRecord 会返回错误、交易不提交，需留痕时用 ReadRecordAudited
+func emitRecordAccessedEvent(ctx contractapi.TransactionContextInterface, recordID, accessorID, purpose, reasonCode string, allowed bool) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	event := RecordAccessedEvent{
+		RecordID:     recordID,
+		AccessorID:   accessorID,
+		Allowed:      allowed,
+		PurposeOfUse: purpose,
+		ReasonCode:   reasonCode,
+		Timestamp:    now,
+		EventType:    "RecordAccessed",
+	}
+	supervision, err := getSupervision(ctx, accessorID)
+	if err != nil {
+		return err
+	}
+	if supervision != nil {
+		event.SupervisorID = supervision.SupervisorID
+	}
+	return emitEvent(ctx, "RecordAccessed", event)
+}
+
+// permissionActive 以交易时间 now 判断授权是否有效
+func permissionActive(perm AccessPermission, now time.Time) bool {
+	if !perm.IsActive {
+		return false
+	}
+	if perm.ValidFrom != "" {
+		validFrom, err := time.Parse(time.RFC3339, perm.ValidFrom)
+		if err != nil || now.Before(validFrom) {
+			return false
+		}
+	}
+	if perm.ExpiresAt == "" {
+		return true
+	}
+	expTime, err := time.Parse(time.RFC3339, perm.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return now.Before(expTime)
+}
+
+// CreateMedicalRecord 创建医疗记录锚点并初始化访问控制列表
+func (s *SmartContract) CreateMedicalRecord(ctx contractapi.TransactionContextInterface, recordJson string) (string, error) {
+	rec, callerID, err := createRecord(ctx, recordJson)
+	if err != nil {
+		return "", err
+	}
+	if err := emitEvent(ctx, "RecordCreated", recordCreatedEvent(rec, callerID)); err != nil {
+		return "", err
+	}
+	return rec.RecordID, nil
+}
+
+func recordCreatedEvent(rec *MedicalRecord, callerID string) RecordCreatedEvent {
+	return RecordCreatedEvent{
+		RecordID:    rec.RecordID,
+		PatientID:   rec.PatientID,
+		CreatorID:   rec.CreatorID,
+		IPFSCid:     rec.IPCSCID,
+		ContentHash: rec.ContentHash,
+		Timestamp:   rec.Timestamp,
+		CallerID:    callerID,
+		EventType:   "RecordCreated",
+	}
+}
+
+// createRecord 校验并写入记录、索引与初始访问列表，不发事件；返回记录与调用者 ID
+func createRecord(ctx contractapi.TransactionContextInterface, recordJson string) (*MedicalRecord, string, error) {
+	var rec MedicalRecord
+	if err := json.Unmarshal([]byte(recordJson), &rec); err != nil {
+		return nil, "", fmt.Errorf("invalid record json: %w", err)
+	}
+	recordedAt, err := prepareRecord(ctx, &rec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	isPatient, err := callerIs(ctx, rec.PatientID)
+	if err != nil {
+		return nil, "", err
+	}
+	isCreator, err := callerIs(ctx, rec.CreatorID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isPatient && !isCreator {
+		return nil, "", fmt.Errorf("access denied: caller must be patient or creator")
+	}
+	if rec.CreatorID != rec.PatientID {
+		if err := requireProviderCredential(ctx, rec.CreatorID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := storeNewRecord(ctx, &rec, recordedAt); err != nil {
+		return nil, "", err
+	}
+	return &rec, callerID, nil
+}
+
+// prepareRecord 校验新记录的字段并补全 docType、状态与 UTC 时间戳，返回记录时间
+func prepareRecord(ctx contractapi.TransactionContextInterface, rec *MedicalRecord) (time.Time, error) {
+	if err := alignPrimaryPart(rec); err != nil {
+		return time.Time{}, err
+	}
+	if rec.RecordID == "" || rec.PatientID == "" || rec.CreatorID == "" || rec.ContentHash == "" || rec.IPCSCID == "" {
+		return time.Time{}, fmt.Errorf("missing required fields: recordId, patientId, creatorId, ipfsCid, and contentHash are required")
+	}
+
+	if err := validateAddress(rec.RecordID); err != nil {
+		return time.Time{}, fmt.Errorf("invalid recordID: %w", err)
+	}
+	if err := validateAddress(rec.PatientID); err != nil {
+		return time.Time{}, fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validateAddress(rec.CreatorID); err != nil {
+		return time.Time{}, fmt.Errorf("invalid creatorID: %w", err)
+	}
+	if err := validateFhir(rec); err != nil {
+		return time.Time{}, err
+	}
+	if err := assignProvenanceTier(rec); err != nil {
+		return time.Time{}, err
+	}
+	if rec.RecordType != "" && !recordTypePattern.MatchString(rec.RecordType) {
+		return time.Time{}, fmt.Errorf("invalid recordType: %q", rec.RecordType)
+	}
+	if err := validateRecordMetadata(ctx, rec); err != nil {
+		return time.Time{}, err
+	}
+	if rec.Sensitivity != "" && rec.Sensitivity != SensitivityNormal && rec.Sensitivity != SensitivityHigh {
+		return time.Time{}, fmt.Errorf("invalid sensitivity: %q", rec.Sensitivity)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := config.checkContentHash(rec.ContentHash); err != nil {
+		return time.Time{}, err
+	}
+	if err := config.checkCid(rec.IPCSCID); err != nil {
+		return time.Time{}, err
+	}
+	if err := config.validateParts(rec.Parts); err != nil {
+		return time.Time{}, err
+	}
+	if err := config.validateSections(rec.Sections); err != nil {
+		return time.Time{}, err
+	}
+	if rec.FieldRoot != "" && !sha256HexPattern.MatchString(rec.FieldRoot) {
+		return time.Time{}, fmt.Errorf("fieldRoot must be a lowercase hex sha256 digest")
+	}
+	rec.DocType = recordDocType
+	rec.Status = RecordActive
+	rec.SignStatus, rec.CoSignedBy, rec.CoSignedAt = "", "", ""
+	if _, ok := config.CosignRequiredTypes[rec.RecordType]; ok && rec.RecordType != "" {
+		rec.SignStatus = SignUnsigned
+	}
+	rec.DocumentStatus, rec.SignedOffBy, rec.SignedOffAt = "", "", ""
+	if hasTransitionRules(rec.RecordType) {
+		rec.DocumentStatus = DocumentPreliminary
+	}
+	rec.CreatorMSP, err = ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get caller MSP: %w", err)
+	}
+	rec.Department, err = callerAttribute(ctx, departmentAttribute)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	exists, err := assetExists(ctx, recordKey(rec.RecordID))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to check record existence: %w", err)
+	}
+	if exists {
+		return time.Time{}, fmt.Errorf("record already exists: %s", rec.RecordID)
+	}
+
+	if rec.Timestamp == "" {
+		rec.Timestamp, err = txTimestamp(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	recordedAt, err := time.Parse(time.RFC3339, rec.Timestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	if recordedAt.Unix() < 0 {
+		return time.Time{}, fmt.Errorf("invalid timestamp: records before 1970 are not supported")
+	}
+	// 统一为 UTC，CouchDB 选择器按字符串比较时间
+	rec.Timestamp = recordedAt.UTC().Format(time.RFC3339)
+	return recordedAt, nil
+}
+
+// storeNewRecord 写入已校验的新记录及其索引、计数器、初始访问列表与快速路径条目
+func storeNewRecord(ctx contractapi.TransactionContextInterface, rec *MedicalRecord, recordedAt time.Time) error {
+	if err := putJSON(ctx, recordKey(rec.RecordID), rec); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	if err := putIndex(ctx, patientRecordIndex, rec.PatientID, rec.RecordID); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, creatorRecordIndex, rec.CreatorID, rec.RecordID); err != nil {
+		return err
+	}
+	if rec.SignStatus == SignUnsigned {
+		if err := putJSON(ctx, unsignedRecordKey(rec.PatientID, rec.RecordID), rec.RecordID); err != nil {
+			return err
+		}
+	}
+	if rec.DocumentStatus == DocumentPreliminary {
+		if err := putJSON(ctx, pendingSignOffKey(rec.CreatorMSP, rec.RecordID), rec.RecordID); err != nil {
+			return err
+		}
+	}
+	if err := countRecordCreated(ctx, rec.PatientID); err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get caller MSP: %w", err)
+	}
+	if err := ctx.GetStub().PutState(orgRecordKey(mspID, recordedAt, rec.RecordID), []byte(rec.RecordID)); err != nil {
+		return fmt.Errorf("failed to store organization index: %w", err)
+	}
+	if err := ctx.GetStub().PutState(patientTimeKey(rec.PatientID, recordedAt, rec.RecordID), []byte(rec.RecordID)); err != nil {
+		return fmt.Errorf("failed to store patient time index: %w", err)
+	}
+
+	initialAccessList := AccessList{
+		RecordID:    rec.RecordID,
+		Owner:       rec.PatientID,
+		Permissions: make(map[string]AccessPermission),
+		UpdatedAt:   rec.Timestamp,
+	}
+	if rec.CreatorID != rec.PatientID {
+		initialAccessList.Permissions[rec.CreatorID] = AccessPermission{
+			RecordID:  rec.RecordID,
+			GranteeID: rec.CreatorID,
+			Action:    "write",
+			GrantedAt: rec.Timestamp,
+			GrantedBy: rec.PatientID,
+			IsActive:  true,
+		}
+		// 患者锁定期间创建的记录不写创建者的快速路径条目，由完整判断拒绝
+		locked, err := patientLocked(ctx, rec.PatientID)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			if err := putAccessEntry(ctx, initialAccessList.Permissions[rec.CreatorID]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := putJSON(ctx, accessListKey(rec.RecordID), initialAccessList); err != nil {
+		return fmt.Errorf("failed to store access list: %w", err)
+	}
+	return putAccessEntry(ctx, AccessPermission{
+		RecordID:  rec.RecordID,
+		GranteeID: rec.PatientID,
+		Action:    "admin",
+		GrantedAt: rec.Timestamp,
+		GrantedBy: rec.PatientID,
+		IsActive:  true,
+	})
+}
+
+// ReadRecord 读取记录锚点，调用者须具备访问权限；transient 中的 purposeOfUse 随访问事件记录
+func (s *SmartContract) ReadRecord(ctx contractapi.TransactionContextInterface, recordID string) (*MedicalRecord, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	purpose, err := purposeOfUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reasonCode, err := readReasonCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.callerAccess(ctx, record, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		regulated, err := regulatorRead(ctx, record, callerID, purpose)
+		if err != nil {
+			return nil, err
+		}
+		if regulated {
+			return record, nil
+		}
+		if err := emitRecordAccessedEvent(ctx, recordID, callerID, purpose, reasonCode, false); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("access denied: %s cannot read record %s", callerID, recordID)
+	}
+	cleared, err := researchCleared(ctx, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if !cleared {
+		return nil, fmt.Errorf("access denied: research use requires a valid data processing agreement for the caller's organization")
+	}
+	if err := recordAllowedRead(ctx, record, callerID, purpose, reasonCode); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// requireRecordAccess 调用者须通过 CheckAccess 与跨境传输校验
+func (s *SmartContract) requireRecordAccess(ctx contractapi.TransactionContextInterface, recordID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.callerAccess(ctx, record, callerID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: %s cannot read record %s", callerID, recordID)
+	}
+	return nil
+}
+
+// GetRecord ReadRecord 的别名，兼容旧客户端
+func (s *SmartContract) GetRecord(ctx contractapi.TransactionContextInterface, recordID string) (*MedicalRecord, error) {
+	return s.ReadRecord(ctx, recordID)
+}
+
+// GetRecordMetadata 返回记录的哈希与时间信息，不包含 IPFS CID；transient 携带 reasonCode 时发出 RecordMetadataAccessed
+func (s *SmartContract) GetRecordMetadata(ctx contractapi.TransactionContextInterface, recordID string) (*RecordMetadata, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reasonCode, err := readReasonCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := getScopedQueryGrant(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		return nil, fmt.Errorf("access denied: %s is limited to scoped queries", callerID)
+	}
+
+	// 审计员可查看任意记录的元数据，元数据不含内容
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = s.CheckAccess(ctx, recordID, callerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		// 只持 records:metadata 的应用可看元数据而不能读取记录
+		allowed, err = appHasScope(ctx, record.PatientID, callerID, AppScopeRecordsMetadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s cannot read record %s", callerID, recordID)
+	}
+	counters, err := getAccessCounters(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	openDisputes, err := getOpenDisputes(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if reasonCode != "" {
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := emitEvent(ctx, "RecordMetadataAccessed", RecordMetadataAccessedEvent{
+			RecordID:   recordID,
+			AccessorID: callerID,
+			ReasonCode: reasonCode,
+			Timestamp:  now,
+			EventType:  "RecordMetadataAccessed",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RecordMetadata{
+		RecordID:    record.RecordID,
+		PatientID:   record.PatientID,
+		CreatorID:   record.CreatorID,
+		ContentHash: record.ContentHash,
+		VersionHash: record.VersionHash,
+		Timestamp:   record.Timestamp,
+
+		FhirResourceType: record.FhirResourceType,
+		FhirVersion:      record.FhirVersion,
+
+		Migrated:     record.Migrated,
+		SourceSystem: record.SourceSystem,
+
+		AccessCounters: *counters,
+
+		Disputed:     len(openDisputes) > 0,
+		OpenDisputes: openDisputes,
+
+		SignStatus:     record.SignStatus,
+		DocumentStatus: record.DocumentStatus,
+	}, nil
+}
+
+// UpdateMedicalRecord 更新记录的存储位置与内容哈希，并推进版本哈希
+func (s *SmartContract) UpdateMedicalRecord(ctx contractapi.TransactionContextInterface, recordID, ipfsCid, contentHash string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := s.checkRecordUpdate(ctx, callerID, recordID, ipfsCid, contentHash, true)
+	if err != nil {
+		return err
+	}
+	pending, err := needsPatientApproval(ctx, record)
+	if err != nil {
+		return err
+	}
+	if pending {
+		// 须患者批准：只生成提议，记录不变，见 approval.go
+		proposal, err := storeUpdateProposal(ctx, record, callerID, ipfsCid, contentHash, record.PatientID)
+		if err != nil {
+			return err
+		}
+		return emitProposalEvent(ctx, "UpdatePendingApproval", proposal, proposal.ProposedAt, callerID)
+	}
+	if err := applyRecordUpdate(ctx, record, ipfsCid, contentHash); err != nil {
+		return err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordUpdated", RecordUpdatedEvent{
+		RecordID:    recordID,
+		ContentHash: contentHash,
+		VersionHash: record.VersionHash,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "RecordUpdated",
+	})
+}
+
+// updateRecord 校验写权限后写入新的存储位置与内容哈希，不发事件；须两阶段更新或患者批准的记录被拒
+func (s *SmartContract) updateRecord(ctx contractapi.TransactionContextInterface, callerID, recordID, ipfsCid, contentHash string) (*MedicalRecord, error) {
+	record, err := s.checkRecordUpdate(ctx, callerID, recordID, ipfsCid, contentHash, true)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := needsPatientApproval(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, fmt.Errorf("record %s requires the patient's approval: use UpdateMedicalRecord", recordID)
+	}
+	return record, applyRecordUpdate(ctx, record, ipfsCid, contentHash)
+}
+
+// checkRecordUpdate 校验记录未归档、存储位置与哈希符合配置、调用者有写权限；
+// direct 为 true 时拒绝配置 twoPhaseRecordTypes 中的记录类型，见 proposal.go
+func (s *SmartContract) checkRecordUpdate(ctx contractapi.TransactionContextInterface, callerID, recordID, ipfsCid, contentHash string, direct bool) (*MedicalRecord, error) {
+	if ipfsCid == "" || contentHash == "" {
+		return nil, fmt.Errorf("invalid arguments: ipfsCid and contentHash are required")
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == RecordArchived {
+		return nil, fmt.Errorf("record %s is archived", recordID)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.checkContentHash(contentHash); err != nil {
+		return nil, err
+	}
+	if err := config.checkCid(ipfsCid); err != nil {
+		return nil, err
+	}
+	if direct && record.RecordType != "" && containsString(config.TwoPhaseRecordTypes, record.RecordType) {
+		return nil, fmt.Errorf("%s records require a reviewed update: use ProposeRecordUpdate", record.RecordType)
+	}
+
+	allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s cannot update record %s", callerID, recordID)
+	}
+	return record, nil
+}
+
+// applyRecordUpdate 写入新的存储位置与内容哈希并推进版本哈希，计入更新次数
+func applyRecordUpdate(ctx contractapi.TransactionContextInterface, record *MedicalRecord, ipfsCid, contentHash string) error {
+	if len(record.Parts) > 0 {
+		// 替换主内容；附件不变，mediaType 与 size 沿用原值
+		if index := partIndex(record.Parts, ipfsCid); index > 0 {
+			return fmt.Errorf("ipfsCid %s is already attachment %d", ipfsCid, index)
+		}
+		record.Parts[0].CID = ipfsCid
+		record.Parts[0].ContentHash = contentHash
+	}
+	advanceVersion(record, contentHash)
+	record.IPCSCID = ipfsCid
+	record.ContentHash = contentHash
+	// 字段根对应旧内容，须由写入者为新内容重新锚定
+	record.FieldRoot = ""
+
+	if err := putJSON(ctx, recordKey(record.RecordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	return countAccess(ctx, record.RecordID, true)
+}
+
+// advanceVersion 推进版本哈希：sha256(上一版本哈希 + step)，首个版本以内容哈希为起点
+func advanceVersion(record *MedicalRecord, step string) {
+	previous := record.VersionHash
+	if previous == "" {
+		previous = record.ContentHash
+	}
+	versionSum := sha256.Sum256([]byte(previous + step))
+	record.VersionHash = hex.EncodeToString(versionSum[:])
+}
+
+// storeGrant 写入单独权限键并同步访问控制列表
+func storeGrant(ctx contractapi.TransactionContextInterface, record *MedicalRecord, perm AccessPermission) error {
+	if err := requireUnlocked(ctx, record.PatientID); err != nil {
+		return err
+	}
+	if permissionHierarchy[perm.Action] >= permissionHierarchy["write"] {
+		if err := requireProviderCredential(ctx, perm.GranteeID); err != nil {
+			return err
+		}
+	}
+	if err := requireApprovedTransfer(ctx, perm.RecordID, perm.GranteeID); err != nil {
+		return err
+	}
+	if err := checkTransition(ctx, record, TransitionShare); err != nil {
+		return err
+	}
+	if err := capTemporaryGrant(ctx, &perm); err != nil {
+		return err
+	}
+	if err := superviseGrant(ctx, &perm); err != nil {
+		return err
+	}
+	previous, err := getPermission(ctx, perm.RecordID, perm.GranteeID)
+	if err != nil {
+		return err
+	}
+	perm.TxID = ctx.GetStub().GetTxID()
+	if err := putJSON(ctx, permKey(perm.RecordID, perm.GranteeID), perm); err != nil {
+		return fmt.Errorf("failed to store permission: %w", err)
+	}
+	if err := putAccessEntry(ctx, perm); err != nil {
+		return err
+	}
+	if err := trackGrantChange(ctx, previous, perm); err != nil {
+		return err
+	}
+	if previous == nil {
+		if err := putIndex(ctx, granteeRecordIndex, perm.GranteeID, perm.RecordID); err != nil {
+			return err
+		}
+	}
+
+	accessList, err := getAccessList(ctx, perm.RecordID)
+	if err != nil {
+		return err
+	}
+	if accessList == nil {
+		accessList = &AccessList{RecordID: perm.RecordID, Owner: record.PatientID, Permissions: make(map[string]AccessPermission)}
+	}
+	accessList.Permissions[perm.GranteeID] = perm
+	accessList.UpdatedAt = perm.GrantedAt
+	if err := putJSON(ctx, accessListKey(perm.RecordID), accessList); err != nil {
+		return fmt.Errorf("failed to store access list: %w", err)
+	}
+	return nil
+}
+
+// deactivateGrant 将单独权限与访问列表中的授权置为失效；撤销不可恢复，同时清除暂停标记
+func deactivateGrant(ctx contractapi.TransactionContextInterface, recordID, granteeID, now string) (bool, error) {
+	return updateGrant(ctx, recordID, granteeID, now, func(perm *AccessPermission) {
+		perm.IsActive = false
+		perm.SuspendedAt = ""
+		perm.SuspendReason = ""
+	})
+}
+
+// updateGrant 对单独权限与访问列表中的同一授权应用 change，并同步快速路径条目；两处都不存在时返回 false
+func updateGrant(ctx contractapi.TransactionContextInterface, recordID, granteeID, now string, change func(perm *AccessPermission)) (bool, error) {
+	permData, err := ctx.GetStub().GetState(permKey(recordID, granteeID))
+	if err != nil {
+		return false, fmt.Errorf("failed to read permission: %w", err)
+	}
+	accessList, err := getAccessList(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	inList := false
+	if accessList != nil {
+		_, inList = accessList.Permissions[granteeID]
+	}
+	if len(permData) == 0 && !inList {
+		return false, nil
+	}
+
+	if len(permData) > 0 {
+		var perm AccessPermission
+		if err := json.Unmarshal(permData, &perm); err != nil {
+			return false, fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		previous := perm
+		change(&perm)
+		if err := putJSON(ctx, permKey(recordID, granteeID), perm); err != nil {
+			return false, fmt.Errorf("failed to store permission: %w", err)
+		}
+		if err := putAccessEntry(ctx, perm); err != nil {
+			return false, err
+		}
+		if err := trackGrantChange(ctx, &previous, perm); err != nil {
+			return false, err
+		}
+	}
+	if inList {
+		perm := accessList.Permissions[granteeID]
+		change(&perm)
+		accessList.Permissions[granteeID] = perm
+		accessList.UpdatedAt = now
+		if err := putJSON(ctx, accessListKey(recordID), accessList); err != nil {
+			return false, fmt.Errorf("failed to store access list: %w", err)
+		}
+		if err := putAccessEntry(ctx, perm); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// GrantAccess 授予不过期的访问权限
+func (s *SmartContract) GrantAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, action string) error {
+	return s.GrantAccessWithExpiry(ctx, recordID, granteeID, action, "")
+}
+
+// GrantAccessWithExpiry 授予访问权限，expiresAt 为空表示不过期
+func (s *SmartContract) GrantAccessWithExpiry(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt string) error {
+	return grantAccess(ctx, recordID, granteeID, action, expiresAt, 0, grantScope{})
+}
+
+// grantAccess 授权的公共路径；maxUses 为 0 表示不限次数，scope 为零值表示整条记录
+func grantAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt string, maxUses int, scope grantScope) error {
+	if recordID == "" || granteeID == "" {
+		return fmt.Errorf("invalid arguments: recordID and granteeID are required")
+	}
+	if err := validateAddress(granteeID); err != nil {
+		return fmt.Errorf("invalid granteeID: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	txNow, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	// 引用诊疗周期且不设过期时间的授权以周期关闭为界
+	if expiresAt == "" && scope.episodeID != "" {
+		err = config.checkAction(action)
+	} else {
+		err = config.checkGrant(action, expiresAt, txNow)
+	}
+	if err != nil {
+		return err
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if err := requireSinglePartyGrant(record); err != nil {
+		return err
+	}
+	if err := scope.validate(ctx, record, action); err != nil {
+		return err
+	}
+	actingFor, err := ownerOrAgent(ctx, record.PatientID, callerID, "grant")
+	if err != nil {
+		return err
+	}
+
+	now := txNow.Format(time.RFC3339)
+	perm := AccessPermission{
+		RecordID:      recordID,
+		GranteeID:     granteeID,
+		Action:        action,
+		ExpiresAt:     expiresAt,
+		GrantedAt:     now,
+		GrantedBy:     callerID,
+		IsActive:      true,
+		MaxUses:       maxUses,
+		RemainingUses: maxUses,
+		Sections:      scope.sections,
+		ViewID:        scope.viewID,
+		DUAHash:       scope.duaHash,
+		RequiresAck:   scope.requiresAck,
+		EncounterID:   scope.encounterID,
+		EpisodeID:     scope.episodeID,
+	}
+	if err := storeGrant(ctx, record, perm); err != nil {
+		return err
+	}
+	if scope.encounterID != "" {
+		grant := DischargedGrant{RecordID: recordID, GranteeID: granteeID}
+		if err := putJSON(ctx, encounterGrantKey(record.PatientID, scope.encounterID, recordID, granteeID), grant); err != nil {
+			return err
+		}
+	}
+	if scope.requiresAck {
+		perm.TxID = ctx.GetStub().GetTxID()
+		if err := requestAck(ctx, perm); err != nil {
+			return err
+		}
+	}
+
+	return emitEvent(ctx, "AccessGranted", AccessGrantedEvent{
+		RecordID:    recordID,
+		GranteeID:   granteeID,
+		Action:      action,
+		ExpiresAt:   expiresAt,
+		MaxUses:     maxUses,
+		Sections:    scope.sections,
+		ViewID:      scope.viewID,
+		DUAHash:     scope.duaHash,
+		RequiresAck: scope.requiresAck,
+		EncounterID: scope.encounterID,
+		EpisodeID:   scope.episodeID,
+		Timestamp:   now,
+		CallerID:    callerID,
+		ActingFor:   actingFor,
+		EventType:   "AccessGranted",
+	})
+}
+
+// RevokeAccess 撤销访问权限（保留授权记录，置为失效）
+func (s *SmartContract) RevokeAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	actingFor, err := ownerOrAgent(ctx, record.PatientID, callerID, "revoke")
+	if err != nil {
+		return err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	found, err := deactivateGrant(ctx, recordID, granteeID, now)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("permission not found for %s on record %s", granteeID, recordID)
+	}
+
+	return emitEvent(ctx, "AccessRevoked", AccessRevokedEvent{
+		RecordID:  recordID,
+		GranteeID: granteeID,
+		Timestamp: now,
+		CallerID:  callerID,
+		ActingFor: actingFor,
+		EventType: "AccessRevoked",
+	})
+}
+
+// CheckAccess 检查用户是否可访问记录：被冻结的身份一律为 false；先读 access 条目，未命中再按 所有者 > 访问列表 > 单独权限 判断
+func (s *SmartContract) CheckAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	return checkAccess(ctx, recordID, userID, accessTarget{})
+}
+
+// checkAccess target 为零值时判断整条记录，否则判断分节或脱敏视图，分节与视图授权只在后者生效
+func checkAccess(ctx contractapi.TransactionContextInterface, recordID, userID string, target accessTarget) (bool, error) {
+	if recordID == "" || userID == "" {
+		return false, fmt.Errorf("invalid arguments: recordID and userID are required")
+	}
+	if err := validateAddress(recordID); err != nil {
+		return false, fmt.Errorf("invalid recordID: %w", err)
+	}
+	if err := validateAddress(userID); err != nil {
+		return false, fmt.Errorf("invalid userID: %w", err)
+	}
+
+	// 被冻结的身份不论授权一律拒绝，网关以服务身份代查时同样生效
+	frozen, err := assetExists(ctx, frozenKey(userID))
+	if err != nil || frozen {
+		return false, err
+	}
+
+	// 快速路径：所有者或有效的直接授权只需再读一次访问条目
+	key, err := accessEntryKey(ctx, recordID, userID)
+	if err != nil {
+		return false, err
+	}
+	var entry AccessPermission
+	found, err := getJSON(ctx, key, &entry)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		now, err := txTime(ctx)
+		if err != nil {
+			return false, err
+		}
+		usable, err := grantUsable(ctx, entry, now, target)
+		if err != nil || usable {
+			return usable, err
+		}
+	}
+
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	// userID 控制 DID 时，以 DID 为主体的所有权与授权同样生效
+	subjects, err := subjectIDs(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	// userID 本身已在入口判断；其绑定的应用用户或控制的 DID 被冻结时同样拒绝
+	for _, subject := range subjects[1:] {
+		frozen, err := assetExists(ctx, frozenKey(subject))
+		if err != nil || frozen {
+			return false, err
+		}
+	}
+	if !containsString(subjects, record.PatientID) {
+		// 患者锁定共享期间只有患者本人可访问；锁定时已撤销全部授权条目，快速路径不会放行
+		locked, err := patientLocked(ctx, record.PatientID)
+		if err != nil || locked {
+			return false, err
+		}
+	}
+	for _, subject := range subjects {
+		allowed, err := subjectAccess(ctx, record, subject, target)
+		if err != nil {
+			return false, err
+		}
+		if allowed {
+			// 护理团队、科室等派生规则不经 storeGrant，登记为区域外机构的主体在此按跨境传输拦截
+			return subjectCrossBorderCleared(ctx, record, subject)
+		}
+	}
+	return false, nil
+}
+
+// subjectAccess 依次判断所有者/创建者、访问列表、单独权限与派生规则
+func subjectAccess(ctx contractapi.TransactionContextInterface, record *MedicalRecord, userID string, target accessTarget) (bool, error) {
+	recordID := record.RecordID
+	if userID == record.PatientID || userID == record.CreatorID {
+		return true, nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	accessList, err := getAccessList(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	if accessList != nil {
+		if perm, exists := accessList.Permissions[userID]; exists {
+			usable, err := grantUsable(ctx, perm, now, target)
+			if err != nil || usable {
+				return usable, err
+			}
+		}
+	}
+
+	// 回退到单独权限检查（向后兼容）
+	permData, err := ctx.GetStub().GetState(permKey(recordID, userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check individual permission: %w", err)
+	}
+	if len(permData) > 0 {
+		var perm AccessPermission
+		if err := json.Unmarshal(permData, &perm); err != nil {
+			return false, fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		usable, err := grantUsable(ctx, perm, now, target)
+		if err != nil || usable {
+			return usable, err
+		}
+	}
+
+	return derivedAccess(ctx, recordID, userID)
+}
+
+// derivedAccessRules 由其他子系统派生的只读访问规则，直接授权未命中时依次评估
+var derivedAccessRules = []func(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error){
+	claimGrantsAccess,
+	priorAuthGrantsAccess,
+	careTeamGrantsAccess,
+	appGrantsAccess,
+	departmentGrantsAccess,
+	onCallGrantsAccess,
+	encounterGrantsAccess,
+}
+
+func derivedAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	for _, rule := range derivedAccessRules {
+		allowed, err := rule(ctx, recordID, userID)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return false, nil
+}
+
+// ValidatePermissionLevel 检查用户对记录的权限是否达到 requiredAction 级别
+func (s *SmartContract) ValidatePermissionLevel(ctx contractapi.TransactionContextInterface, recordID, userID, requiredAction string) (bool, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	if userID == record.PatientID || userID == record.CreatorID {
+		return true, nil
+	}
+
+	requiredLevel, requiredExists := permissionHierarchy[requiredAction]
+	if !requiredExists {
+		return false, fmt.Errorf("invalid permission level")
+	}
+
+	permData, err := ctx.GetStub().GetState(permKey(recordID, userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check permission: %w", err)
+	}
+	if len(permData) > 0 {
+		var perm AccessPermission
+		if err := json.Unmarshal(permData, &perm); err != nil {
+			return false, fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		now, err := txTime(ctx)
+		if err != nil {
+			return false, err
+		}
+		usable, err := grantUsable(ctx, perm, now, accessTarget{})
+		if err != nil {
+			return false, err
+		}
+		if usable {
+			userLevel, userExists := permissionHierarchy[perm.Action]
+			if !userExists {
+				return false, fmt.Errorf("invalid permission level")
+			}
+			if userLevel >= requiredLevel {
+				return true, nil
+			}
+		}
+	}
+
+	// 护理团队成员按患者配置的默认权限
+	teamAction, err := careTeamAction(ctx, record.PatientID, userID)
+	if err != nil {
+		return false, err
+	}
+	if teamAction != "" && permissionHierarchy[teamAction] >= requiredLevel {
+		return true, nil
+	}
+	// 值班身份在其时段内持科室记录的治疗级权限，见 roster.go
+	onCall, err := onCallForRecord(ctx, record, userID)
+	return onCall && permissionHierarchy[onCallAction] >= requiredLevel, err
+}
+
+// GetAccessList 返回记录的访问控制列表，仅所有者可查询
+func (s *SmartContract) GetAccessList(ctx contractapi.TransactionContextInterface, recordID string) (*AccessList, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	isPatient, err := callerIs(ctx, record.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPatient {
+		return nil, fmt.Errorf("access denied: only the patient can view the access list")
+	}
+	accessList, err := getAccessList(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if accessList == nil {
+		return nil, fmt.Errorf("access list not found: %s", recordID)
+	}
+	return accessList, nil
+}
+
+// GetUserPermissions 经 grantee~record 索引分页列出授予某用户的权限（含已撤销、已过期），仅本人可查询
+func (s *SmartContract) GetUserPermissions(ctx contractapi.TransactionContextInterface, userID string, pageSize int32, bookmark string) (*PermissionPage, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isSelf, err := callerIs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isSelf {
+		return nil, fmt.Errorf("access denied: callers can only list their own permissions")
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(granteeRecordIndex, []string{userID}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s index: %w", granteeRecordIndex, err)
+	}
+	defer iterator.Close()
+
+	page := &PermissionPage{Permissions: []*AccessPermission{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate %s index: %w", granteeRecordIndex, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split %s index key: %w", granteeRecordIndex, err)
+		}
+		perm, err := getPermission(ctx, attrs[1], userID)
+		if err != nil {
+			return nil, err
+		}
+		if perm != nil {
+			page.Permissions = append(page.Permissions, perm)
+		}
+	}
+	return page, nil
+}
+
+// BackfillGranteeIndex 为索引引入前写入的权限补建 grantee~record 索引，按 perm: 键分页执行，仅 admin；
+// 返回下一页书签，为空表示已完成
+func (s *SmartContract) BackfillGranteeIndex(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (string, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return "", err
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return "", err
+	}
+	if !isAdmin {
+		return "", fmt.Errorf("access denied: only admin can backfill indexes")
+	}
+	return scanRangeForUpdate(ctx, "perm:", "perm;", pageSize, bookmark, func(key string, value []byte) error {
+		var perm AccessPermission
+		if err := json.Unmarshal(value, &perm); err != nil {
+			return fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		return putIndex(ctx, granteeRecordIndex, perm.GranteeID, perm.RecordID)
+	})
+}
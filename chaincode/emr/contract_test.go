@@ -0,0 +1,254 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	patient = newIdentity("patient1", "Org1MSP")
+	doctor  = newIdentity("doctor1", "Org1MSP", "role", "doctor")
+	nurse   = newIdentity("nurse1", "Org2MSP", "role", "nurse")
+	other   = newIdentity("stranger1", "Org2MSP")
+)
+
+func TestCreateMedicalRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	var event RecordCreatedEvent
+	env.expectEvent("RecordCreated", &event)
+	if event.RecordID != "rec1" || event.CallerID != doctor.id {
+		t.Fatalf("unexpected event payload: %+v", event)
+	}
+
+	env.mustFail(doctor, "record already exists", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"rec1","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"aa"}`)
+		return err
+	})
+	env.mustFail(other, "caller must be patient or creator", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"rec2","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"aa"}`)
+		return err
+	})
+	env.mustFail(doctor, "missing required fields", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"rec3","patientId":"patient1"}`)
+		return err
+	})
+}
+
+func TestGrantRevokeAndCheckAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	if !env.checkAccess("rec1", patient.id) || !env.checkAccess("rec1", doctor.id) {
+		t.Fatal("patient and creator must have access")
+	}
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("nurse must not have access before grant")
+	}
+
+	env.mustFail(doctor, "only the patient can grant", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	})
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.expectEvent("AccessGranted", nil)
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("nurse must have access after grant")
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+	env.expectEvent("AccessRevoked", nil)
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("nurse must lose access after revoke")
+	}
+}
+
+func TestGrantExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	expiresAt := env.stub.now.Add(time.Hour).Format(time.RFC3339)
+	env.grant(patient, "rec1", nurse.id, "read", expiresAt)
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("grant must be active before expiry")
+	}
+
+	// 过期判断以交易时间为准
+	env.advance(2 * time.Hour)
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("expired grant must not allow access")
+	}
+}
+
+func TestValidatePermissionLevel(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "share", "")
+
+	cases := map[string]bool{"read": true, "share": true, "write": false, "admin": false}
+	for action, want := range cases {
+		var got bool
+		env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			got, err = env.cc.ValidatePermissionLevel(ctx, "rec1", nurse.id, action)
+			return err
+		})
+		if got != want {
+			t.Fatalf("action %s: got %v, want %v", action, got, want)
+		}
+	}
+}
+
+func TestReadAndUpdateRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		rec, err := env.cc.ReadRecord(ctx, "rec1")
+		if err == nil && rec.RecordID != "rec1" {
+			t.Fatalf("unexpected record: %+v", rec)
+		}
+		return err
+	})
+
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustFail(nurse, "cannot update", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", "bb")
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", "bb")
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		meta, err := env.cc.GetRecordMetadata(ctx, "rec1")
+		if err == nil && (meta.ContentHash != "bb" || meta.VersionHash == "") {
+			t.Fatalf("unexpected metadata: %+v", meta)
+		}
+		return err
+	})
+}
+
+func TestGetUserPermissions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.grant(patient, "rec2", nurse.id, "write", "")
+	env.grant(patient, "rec2", other.id, "read", "")
+
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetUserPermissions(ctx, nurse.id, 10, "")
+		if err == nil && len(page.Permissions) != 2 {
+			t.Fatalf("expected 2 permissions, got %d", len(page.Permissions))
+		}
+		return err
+	})
+	env.mustFail(other, "only list their own", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetUserPermissions(ctx, nurse.id, 10, "")
+		return err
+	})
+	// 只读取该用户自己的索引键，按页返回
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetUserPermissions(ctx, nurse.id, 1, "")
+		if err != nil {
+			return err
+		}
+		if len(page.Permissions) != 1 || page.Permissions[0].RecordID != "rec1" || page.Bookmark == "" {
+			t.Fatalf("unexpected first page: %+v", page)
+		}
+		next, err := env.cc.GetUserPermissions(ctx, nurse.id, 1, page.Bookmark)
+		if err == nil && (len(next.Permissions) != 1 || next.Permissions[0].RecordID != "rec2" || next.Bookmark != "") {
+			t.Fatalf("unexpected second page: %+v", next)
+		}
+		return err
+	})
+}
+
+func TestBackfillGranteeIndex(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	// 模拟索引引入前写入的权限
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return putJSON(ctx, permKey("rec1", nurse.id), AccessPermission{RecordID: "rec1", GranteeID: nurse.id, Action: "read", IsActive: true})
+	})
+	listed := func() int {
+		var n int
+		env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.GetUserPermissions(ctx, nurse.id, 10, "")
+			if page != nil {
+				n = len(page.Permissions)
+			}
+			return err
+		})
+		return n
+	}
+	if listed() != 0 {
+		t.Fatal("legacy permission must not be indexed yet")
+	}
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.BackfillGranteeIndex(ctx, 10, "")
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		bookmark, err := env.cc.BackfillGranteeIndex(ctx, 10, "")
+		if err == nil && bookmark != "" {
+			t.Fatalf("expected a single page, got bookmark %q", bookmark)
+		}
+		return err
+	})
+	if listed() != 1 {
+		t.Fatal("backfilled permission must be listed")
+	}
+}
+
+func TestBackfillGranteeIndexPages(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		for _, grantee := range []string{nurse.id, specialist.id, physician2.id} {
+			if err := putJSON(ctx, permKey("rec1", grantee), AccessPermission{RecordID: "rec1", GranteeID: grantee, Action: "read", IsActive: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	// 写交易内不能分页查询，书签为下一页起始键
+	pages, bookmark := 0, ""
+	for {
+		env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			bookmark, err = env.cc.BackfillGranteeIndex(ctx, 1, bookmark)
+			return err
+		})
+		pages++
+		if bookmark == "" {
+			break
+		}
+		if pages > 3 {
+			t.Fatal("backfill did not terminate")
+		}
+	}
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	for _, grantee := range []*testIdentity{nurse, specialist, physician2} {
+		env.mustInvoke(grantee, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.GetUserPermissions(ctx, grantee.id, 10, "")
+			if err == nil && len(page.Permissions) != 1 {
+				t.Fatalf("%s: backfilled permission must be listed, got %+v", grantee.id, page)
+			}
+			return err
+		})
+	}
+	env.mustFail(admin, "invalid bookmark", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.BackfillGranteeIndex(ctx, 1, "record:rec1")
+		return err
+	})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	CorrectionRequested = "requested"
+	CorrectionAccepted  = "accepted"
+	CorrectionRejected  = "rejected"
+)
+
+// CorrectionRequest 患者的更正申请（HIPAA 修正流程）：ProposedChangeHash 为患者线下提交的更正内容的 sha256，
+// Reason 为创建者书面答复的 sha256，接受或拒绝都必填。接受时 AmendmentOf 为更正前的版本哈希，
+// AmendmentVersionHash 为更正后的版本哈希；拒绝时记录不变，患者可据此 DisputeRecord
+type CorrectionRequest struct {
+	RequestID            string `json:"requestId"`
+	RecordID             string `json:"recordId"`
+	PatientID            string `json:"patientId"`
+	ProposedChangeHash   string `json:"proposedChangeHash"`
+	Status               string `json:"status"`
+	RequestedBy          string `json:"requestedBy"`
+	RequestedAt          string `json:"requestedAt"`
+	Reason               string `json:"reason,omitempty"`
+	DecidedBy            string `json:"decidedBy,omitempty"`
+	DecidedAt            string `json:"decidedAt,omitempty"`
+	AmendmentOf          string `json:"amendmentOf,omitempty"`
+	AmendmentVersionHash string `json:"amendmentVersionHash,omitempty"`
+}
+
+type CorrectionEvent struct {
+	RequestID string `json:"requestId"`
+	RecordID  string `json:"recordId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func correctionKey(requestID string) string {
+	return "correction:" + requestID
+}
+
+func getCorrectionRequest(ctx contractapi.TransactionContextInterface, requestID string) (*CorrectionRequest, error) {
+	var request CorrectionRequest
+	found, err := getJSON(ctx, correctionKey(requestID), &request)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("correction request not found: %s", requestID)
+	}
+	return &request, nil
+}
+
+func emitCorrectionEvent(ctx contractapi.TransactionContextInterface, name string, request *CorrectionRequest, timestamp, callerID string) error {
+	return emitEvent(ctx, name, CorrectionEvent{
+		RequestID: request.RequestID,
+		RecordID:  request.RecordID,
+		PatientID: request.PatientID,
+		Status:    request.Status,
+		Reason:    request.Reason,
+		Timestamp: timestamp,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
+
+// RequestCorrection 患者本人申请更正记录，返回申请 ID（本交易 ID）
+func (s *SmartContract) RequestCorrection(ctx contractapi.TransactionContextInterface, recordID, proposedChangeHash string) (string, error) {
+	if !sha256HexPattern.MatchString(proposedChangeHash) {
+		return "", fmt.Errorf("proposedChangeHash must be a lowercase hex sha256 digest")
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	if record.Status == RecordArchived {
+		return "", fmt.Errorf("record %s is archived", recordID)
+	}
+	isPatient, err := callerIs(ctx, record.PatientID)
+	if err != nil {
+		return "", err
+	}
+	if !isPatient {
+		return "", fmt.Errorf("access denied: only the patient can request a correction of record %s", recordID)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	request := &CorrectionRequest{
+		RequestID:          ctx.GetStub().GetTxID(),
+		RecordID:           recordID,
+		PatientID:          record.PatientID,
+		ProposedChangeHash: proposedChangeHash,
+		Status:             CorrectionRequested,
+		RequestedBy:        callerID,
+		RequestedAt:        now,
+	}
+	if err := putJSON(ctx, correctionKey(request.RequestID), request); err != nil {
+		return "", err
+	}
+	if err := emitCorrectionEvent(ctx, "CorrectionRequested", request, now, callerID); err != nil {
+		return "", err
+	}
+	return request.RequestID, nil
+}
+
+// decideCorrection 待处理的申请，调用者须为记录创建者，reason 必填
+func decideCorrection(ctx contractapi.TransactionContextInterface, requestID, reason string) (*CorrectionRequest, *MedicalRecord, string, error) {
+	if !sha256HexPattern.MatchString(reason) {
+		return nil, nil, "", fmt.Errorf("reason is required: the lowercase hex sha256 of the written response")
+	}
+	request, err := getCorrectionRequest(ctx, requestID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if request.Status != CorrectionRequested {
+		return nil, nil, "", fmt.Errorf("correction request %s is %s", requestID, request.Status)
+	}
+	record, err := getRecord(ctx, request.RecordID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	isCreator, err := callerIs(ctx, record.CreatorID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !isCreator {
+		return nil, nil, "", fmt.Errorf("access denied: only the record creator can decide a correction request")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	request.Reason = reason
+	request.DecidedBy = callerID
+	request.DecidedAt = now.Format(time.RFC3339)
+	return request, record, callerID, nil
+}
+
+// AcceptCorrection 创建者接受更正，以 newCid/newHash 生成修正版本，发出带 correctionId 的 RecordUpdated；
+// 修正由患者申请，不再经两阶段更新或患者批准
+func (s *SmartContract) AcceptCorrection(ctx contractapi.TransactionContextInterface, requestID, newCid, newHash, reason string) error {
+	request, record, callerID, err := decideCorrection(ctx, requestID, reason)
+	if err != nil {
+		return err
+	}
+	if newCid == "" || newHash == "" {
+		return fmt.Errorf("invalid arguments: newCid and newHash are required")
+	}
+	if record.Status == RecordArchived {
+		return fmt.Errorf("record %s is archived", record.RecordID)
+	}
+	if newHash == record.ContentHash {
+		return fmt.Errorf("newHash is unchanged")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.checkContentHash(newHash); err != nil {
+		return err
+	}
+	if err := config.checkCid(newCid); err != nil {
+		return err
+	}
+
+	request.AmendmentOf = versionOf(record)
+	if err := applyRecordUpdate(ctx, record, newCid, newHash); err != nil {
+		return err
+	}
+	request.Status = CorrectionAccepted
+	request.AmendmentVersionHash = record.VersionHash
+	if err := putJSON(ctx, correctionKey(requestID), request); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordUpdated", RecordUpdatedEvent{
+		RecordID:     record.RecordID,
+		ContentHash:  record.ContentHash,
+		VersionHash:  record.VersionHash,
+		CorrectionID: requestID,
+		Timestamp:    request.DecidedAt,
+		CallerID:     callerID,
+		EventType:    "RecordUpdated",
+	})
+}
+
+// RejectCorrection 创建者拒绝更正，记录不变；拒绝理由随申请保留，供患者发起争议
+func (s *SmartContract) RejectCorrection(ctx contractapi.TransactionContextInterface, requestID, reason string) error {
+	request, _, callerID, err := decideCorrection(ctx, requestID, reason)
+	if err != nil {
+		return err
+	}
+	request.Status = CorrectionRejected
+	if err := putJSON(ctx, correctionKey(requestID), request); err != nil {
+		return err
+	}
+	return emitCorrectionEvent(ctx, "CorrectionRejected", request, request.DecidedAt, callerID)
+}
+
+// GetCorrectionRequest 有记录访问权的身份可查询更正申请
+func (s *SmartContract) GetCorrectionRequest(ctx contractapi.TransactionContextInterface, requestID string) (*CorrectionRequest, error) {
+	request, err := getCorrectionRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRecordAccess(ctx, request.RecordID); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
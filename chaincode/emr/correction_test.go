@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) requestCorrection(identity *testIdentity, recordID string) string {
+	e.t.Helper()
+	var requestID string
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		requestID, err = e.cc.RequestCorrection(ctx, recordID, strings.Repeat("ab", 32))
+		return err
+	})
+	return requestID
+}
+
+func TestAcceptCorrection(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "write", "")
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.RequestCorrection(ctx, "rec1", strings.Repeat("ab", 32))
+		return err
+	})
+	requestID := env.requestCorrection(patient, "rec1")
+	var requested CorrectionEvent
+	env.expectEvent("CorrectionRequested", &requested)
+	if requested.RequestID != requestID || requested.Status != CorrectionRequested {
+		t.Fatalf("unexpected event: %+v", requested)
+	}
+
+	before := versionOf(env.storedRecord("rec1"))
+	newHash := strings.Repeat("b", 64)
+	reason := strings.Repeat("cd", 32)
+	accept := func(reason string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AcceptCorrection(ctx, requestID, "bafyamended", newHash, reason)
+		}
+	}
+	env.mustFail(doctor, "reason is required", accept(""))
+	// 有写权限但不是创建者
+	env.mustFail(nurse, "only the record creator", accept(reason))
+	env.mustInvoke(doctor, accept(reason))
+	var updated RecordUpdatedEvent
+	env.expectEvent("RecordUpdated", &updated)
+	if updated.CorrectionID != requestID || updated.ContentHash != newHash {
+		t.Fatalf("unexpected event: %+v", updated)
+	}
+	env.mustFail(doctor, "is accepted", accept(reason))
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		request, err := env.cc.GetCorrectionRequest(ctx, requestID)
+		if err == nil && (request.AmendmentOf != before || request.AmendmentVersionHash != env.storedRecord("rec1").VersionHash || request.Reason != reason) {
+			t.Fatalf("unexpected request: %+v", request)
+		}
+		return err
+	})
+}
+
+func TestRejectCorrection(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	original := env.storedRecord("rec1").ContentHash
+	requestID := env.requestCorrection(patient, "rec1")
+	reject := func(reason string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.RejectCorrection(ctx, requestID, reason)
+		}
+	}
+	env.mustFail(doctor, "reason is required", reject(""))
+	env.mustFail(patient, "only the record creator", reject(strings.Repeat("cd", 32)))
+	env.mustInvoke(doctor, reject(strings.Repeat("cd", 32)))
+	var rejected CorrectionEvent
+	env.expectEvent("CorrectionRejected", &rejected)
+	if rejected.Status != CorrectionRejected || rejected.Reason != strings.Repeat("cd", 32) {
+		t.Fatalf("unexpected event: %+v", rejected)
+	}
+	if record := env.storedRecord("rec1"); record.ContentHash != original {
+		t.Fatalf("a rejected correction must not change the record: %+v", record)
+	}
+	// 被拒后患者可发起争议
+	env.dispute(patient, "rec1", "inaccurate")
+	if metadata := env.metadata(patient, "rec1"); !metadata.Disputed {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	SignUnsigned = "unsigned"
+	SignSigned   = "signed"
+)
+
+type RecordCoSignedEvent struct {
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	CreatorID   string `json:"creatorId"`
+	VersionHash string `json:"versionHash"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// unsignedRecordKey unsigned:{patientId}:{recordId}，值为 recordId；共同签署后删除
+func unsignedRecordKey(patientID, recordID string) string {
+	return unsignedRecordPrefix(patientID) + keySegment(recordID)
+}
+
+func unsignedRecordPrefix(patientID string) string {
+	return "unsigned:" + keySegment(patientID) + ":"
+}
+
+// CoSignRecord 合格签署人共同签署 unsigned 记录（如住院医师病程由主治复签）；
+// 调用者证书须带配置的签署人属性且值为 true，须能读取该记录，且不能是创建者
+func (s *SmartContract) CoSignRecord(ctx contractapi.TransactionContextInterface, recordID string) error {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if record.SignStatus != SignUnsigned {
+		return fmt.Errorf("record %s does not await a co-signature", recordID)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	attribute, ok := config.CosignRequiredTypes[record.RecordType]
+	if !ok {
+		return fmt.Errorf("%s records no longer require a co-signature", record.RecordType)
+	}
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(attribute)
+	if err != nil {
+		return fmt.Errorf("failed to read %s attribute: %w", attribute, err)
+	}
+	if !found || value != "true" {
+		return fmt.Errorf("access denied: co-signing %s records requires %s=true", record.RecordType, attribute)
+	}
+	isCreator, err := callerIs(ctx, record.CreatorID)
+	if err != nil {
+		return err
+	}
+	if isCreator {
+		return fmt.Errorf("access denied: the creator cannot co-sign their own record")
+	}
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	record.SignStatus = SignSigned
+	record.CoSignedBy = callerID
+	record.CoSignedAt = now
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	if err := ctx.GetStub().DelState(unsignedRecordKey(record.PatientID, recordID)); err != nil {
+		return fmt.Errorf("failed to delete unsigned index: %w", err)
+	}
+	return emitEvent(ctx, "RecordCoSigned", RecordCoSignedEvent{
+		RecordID:    recordID,
+		PatientID:   record.PatientID,
+		CreatorID:   record.CreatorID,
+		VersionHash: versionOf(record),
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "RecordCoSigned",
+	})
+}
+
+// ListUnsignedRecords 列出患者名下待共同签署、调用者可访问的记录
+func (s *SmartContract) ListUnsignedRecords(ctx contractapi.TransactionContextInterface, patientID string) ([]*MedicalRecord, error) {
+	if err := validateAddress(patientID); err != nil {
+		return nil, fmt.Errorf("invalid patientID: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := unsignedRecordPrefix(patientID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan unsigned records: %w", err)
+	}
+	defer iterator.Close()
+
+	records := []*MedicalRecord{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate unsigned records: %w", err)
+		}
+		var recordID string
+		if err := json.Unmarshal(kv.Value, &recordID); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal unsigned record: %w", err)
+		}
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err := s.callerAccess(ctx, record, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
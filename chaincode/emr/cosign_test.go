@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var attending = newIdentity("attending1", "Org1MSP", "attending", "true")
+
+func (e *testEnv) unsignedRecords(identity *testIdentity, patientID string) []*MedicalRecord {
+	e.t.Helper()
+	var records []*MedicalRecord
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		records, err = e.cc.ListUnsignedRecords(ctx, patientID)
+		return err
+	})
+	return records
+}
+
+func TestCoSignRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustFail(admin, "invalid cosigner attribute", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"cosignRequiredTypes":{"resident-note":""}}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"cosignRequiredTypes":{"resident-note":"attending"}}`)
+	})
+	env.createTypedRecord("note1", "resident-note")
+	env.createTypedRecord("lab1", "lab")
+	if record := env.storedRecord("lab1"); record.SignStatus != "" {
+		t.Fatalf("other record types must not be flagged: %+v", record)
+	}
+	if metadata := env.metadata(patient, "note1"); metadata.SignStatus != SignUnsigned {
+		t.Fatalf("unsigned record must be flagged: %+v", metadata)
+	}
+	if records := env.unsignedRecords(patient, patient.id); len(records) != 1 || records[0].RecordID != "note1" {
+		t.Fatalf("unexpected unsigned records: %+v", records)
+	}
+	if records := env.unsignedRecords(other, patient.id); len(records) != 0 {
+		t.Fatalf("unsigned records must be filtered by access: %+v", records)
+	}
+
+	cosign := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CoSignRecord(ctx, "note1")
+	}
+	env.mustFail(doctor, "requires attending=true", cosign)
+	env.mustFail(attending, "access denied", cosign)
+	env.grant(patient, "note1", attending.id, "read", "")
+	env.mustInvoke(attending, cosign)
+	var event RecordCoSignedEvent
+	env.expectEvent("RecordCoSigned", &event)
+	if event.CallerID != attending.id || event.CreatorID != doctor.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if record := env.storedRecord("note1"); record.SignStatus != SignSigned || record.CoSignedBy != attending.id || record.CoSignedAt == "" {
+		t.Fatalf("co-signed record must be signed: %+v", record)
+	}
+	if records := env.unsignedRecords(patient, patient.id); len(records) != 0 {
+		t.Fatalf("signed records must leave the unsigned list: %+v", records)
+	}
+	env.mustFail(attending, "does not await a co-signature", cosign)
+	env.mustFail(attending, "does not await a co-signature", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CoSignRecord(ctx, "lab1")
+	})
+}
+
+func TestCreatorCannotCoSign(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"cosignRequiredTypes":{"resident-note":"attending"}}`)
+	})
+	var record MedicalRecord
+	_ = json.Unmarshal([]byte(recordJSON(doctor, "note1", patient.id)), &record)
+	record.RecordType = "resident-note"
+	record.SignStatus = SignSigned
+	record.CoSignedBy = attending.id
+	data, _ := json.Marshal(record)
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, string(data))
+		return err
+	})
+	// 客户端不能自行标记为已签署
+	if record := env.storedRecord("note1"); record.SignStatus != SignUnsigned {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+	// 创建者本人即使持合格属性也不能复签
+	attendingDoctor := newIdentity(doctor.id, doctor.msp, "attending", "true")
+	env.mustFail(attendingDoctor, "creator cannot co-sign", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CoSignRecord(ctx, "note1")
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RecordAccessCounters 记录的成功读取与内容更新次数，只增不减，与写审计同一交易递增；
+// 与审计链中读取（RecordAccessed、AccessExhausted、RegulatorAccessed）和更新（RecordUpdated、RecordsBatchUpdated）条目数比对，
+// 可发现缺失的审计写入
+type RecordAccessCounters struct {
+	Reads  int64 `json:"reads"`
+	Writes int64 `json:"writes"`
+}
+
+func accessCounterKey(recordID string) string {
+	return "counter:access:" + keySegment(recordID)
+}
+
+func getAccessCounters(ctx contractapi.TransactionContextInterface, recordID string) (*RecordAccessCounters, error) {
+	var counters RecordAccessCounters
+	if _, err := getJSON(ctx, accessCounterKey(recordID), &counters); err != nil {
+		return nil, err
+	}
+	return &counters, nil
+}
+
+// countAccess write 为 true 时计入更新，否则计入读取；同一记录的并发访问在计数键上冲突，由客户端重试
+func countAccess(ctx contractapi.TransactionContextInterface, recordID string, write bool) error {
+	counters, err := getAccessCounters(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if write {
+		counters.Writes++
+	} else {
+		counters.Reads++
+	}
+	return putJSON(ctx, accessCounterKey(recordID), counters)
+}
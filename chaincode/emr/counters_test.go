@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestAccessCountersMatchAuditChain(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	read := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	}
+	env.mustInvoke(nurse, read)
+	env.mustInvoke(patient, read)
+	// 被拒的读取不计数
+	env.mustFail(other, "access denied", read)
+	newHash := strings.Repeat("b", 64)
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", newHash)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, `[{"recordId":"rec1","ipfsCid":"bafybatch","contentHash":"`+newHash+`"},{"recordId":"rec2","ipfsCid":"bafybatch2","contentHash":"`+newHash+`"}]`)
+	})
+
+	var metadata *RecordMetadata
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		metadata, err = env.cc.GetRecordMetadata(ctx, "rec1")
+		return err
+	})
+	if metadata.AccessCounters != (RecordAccessCounters{Reads: 2, Writes: 2}) {
+		t.Fatalf("unexpected counters: %+v", metadata.AccessCounters)
+	}
+
+	// 批量更新为每条记录各写一份审计条目，计数与审计链中的读取、更新条目一致
+	counted := RecordAccessCounters{}
+	from, to := env.stub.now.Add(-time.Hour).Format(time.RFC3339), env.stub.now.Add(time.Hour).Format(time.RFC3339)
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryAuditLog(ctx, "rec1", from, to, 50, "")
+		for _, event := range page.Events {
+			switch event.EventType {
+			case "RecordAccessed":
+				counted.Reads++
+			case "RecordUpdated", "RecordsBatchUpdated":
+				counted.Writes++
+			}
+		}
+		return err
+	})
+	if counted != metadata.AccessCounters {
+		t.Fatalf("audit entries %+v do not match counters %+v", counted, metadata.AccessCounters)
+	}
+	var counters RecordAccessCounters
+	if err := json.Unmarshal(env.stub.State[accessCounterKey("rec2")], &counters); err != nil || counters.Writes != 1 {
+		t.Fatalf("unexpected rec2 counters: %+v", counters)
+	}
+	if result := env.verifyAuditChain(auditor, "rec2"); !result.Valid || result.Length != 2 {
+		t.Fatalf("unexpected rec2 audit chain: %+v", result)
+	}
+}
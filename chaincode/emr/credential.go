@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	CredentialValid   = "valid"
+	CredentialRevoked = "revoked"
+)
+
+// CredentialIssuer 签发执业资质凭证的机构身份（卫健委、医师协会等），由 admin 登记
+type CredentialIssuer struct {
+	IssuerID     string `json:"issuerId"`
+	Name         string `json:"name"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+// ProviderCredential 医护执业资质凭证锚点；链上只存凭证（VC/JWT）哈希，每名医护保留最新一份
+type ProviderCredential struct {
+	ProviderID     string `json:"providerId"`
+	CredentialHash string `json:"credentialHash"`
+	IssuerID       string `json:"issuerId"`
+	IssuedAt       string `json:"issuedAt"`
+	ExpiresAt      string `json:"expiresAt"`
+	Status         string `json:"status"`
+	RevokedAt      string `json:"revokedAt,omitempty"`
+}
+
+// CredentialRevocation 吊销登记，按凭证哈希存放，同一凭证不能再次提交
+type CredentialRevocation struct {
+	CredentialHash string `json:"credentialHash"`
+	ProviderID     string `json:"providerId"`
+	IssuerID       string `json:"issuerId"`
+	Reason         string `json:"reason,omitempty"`
+	RevokedAt      string `json:"revokedAt"`
+}
+
+type ProviderCredentialEvent struct {
+	ProviderID     string `json:"providerId"`
+	CredentialHash string `json:"credentialHash"`
+	IssuerID       string `json:"issuerId"`
+	ExpiresAt      string `json:"expiresAt"`
+	Status         string `json:"status"`
+	Timestamp      string `json:"timestamp"`
+	CallerID       string `json:"callerId"`
+	EventType      string `json:"eventType"`
+}
+
+func issuerKey(issuerID string) string {
+	return "issuer:" + issuerID
+}
+
+func credentialKey(providerID string) string {
+	return "credential:" + providerID
+}
+
+func credentialRevocationKey(credentialHash string) string {
+	return "credential-revocation:" + credentialHash
+}
+
+func getProviderCredential(ctx contractapi.TransactionContextInterface, providerID string) (*ProviderCredential, error) {
+	var credential ProviderCredential
+	found, err := getJSON(ctx, credentialKey(providerID), &credential)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// credentialValid 凭证存在、未吊销、签发机构仍在册且未到期（按交易时间）
+func credentialValid(ctx contractapi.TransactionContextInterface, providerID string) (bool, error) {
+	credential, err := getProviderCredential(ctx, providerID)
+	if err != nil || credential == nil {
+		return false, err
+	}
+	if credential.Status != CredentialValid {
+		return false, nil
+	}
+	revoked, err := assetExists(ctx, credentialRevocationKey(credential.CredentialHash))
+	if err != nil || revoked {
+		return false, err
+	}
+	issuerActive, err := assetExists(ctx, issuerKey(credential.IssuerID))
+	if err != nil || !issuerActive {
+		return false, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, credential.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	return now.Before(expiresAt), nil
+}
+
+// requireProviderCredential 医护创建记录或获得 write 及以上授权前须持有有效执业凭证
+func requireProviderCredential(ctx contractapi.TransactionContextInterface, providerID string) error {
+	valid, err := credentialValid(ctx, providerID)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("provider %s has no valid license credential", providerID)
+	}
+	return nil
+}
+
+// RegisterCredentialIssuer 登记资质签发机构身份，仅 admin 角色
+func (s *SmartContract) RegisterCredentialIssuer(ctx contractapi.TransactionContextInterface, issuerID, name string) error {
+	if err := validateAddress(issuerID); err != nil {
+		return fmt.Errorf("invalid issuerID: %w", err)
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("access denied: only admin can register credential issuers")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, issuerKey(issuerID), CredentialIssuer{
+		IssuerID:     issuerID,
+		Name:         name,
+		RegisteredBy: callerID,
+		RegisteredAt: now,
+	})
+}
+
+// RemoveCredentialIssuer 注销签发机构；其签发的凭证随即失效
+func (s *SmartContract) RemoveCredentialIssuer(ctx contractapi.TransactionContextInterface, issuerID string) error {
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("access denied: only admin can remove credential issuers")
+	}
+	exists, err := assetExists(ctx, issuerKey(issuerID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("credential issuer not found: %s", issuerID)
+	}
+	return ctx.GetStub().DelState(issuerKey(issuerID))
+}
+
+// SubmitProviderCredential 签发机构提交医护执业凭证哈希；调用者须为已登记的签发机构
+func (s *SmartContract) SubmitProviderCredential(ctx contractapi.TransactionContextInterface, providerID, credentialHash, expiresAt string) error {
+	if err := validateAddress(providerID); err != nil {
+		return fmt.Errorf("invalid providerID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(credentialHash) {
+		return fmt.Errorf("credentialHash must be a lowercase hex sha256 digest")
+	}
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expiresAt: %w", err)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	isIssuer, err := assetExists(ctx, issuerKey(callerID))
+	if err != nil {
+		return err
+	}
+	if !isIssuer {
+		return fmt.Errorf("access denied: %s is not a registered credential issuer", callerID)
+	}
+	revoked, err := assetExists(ctx, credentialRevocationKey(credentialHash))
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return fmt.Errorf("credential has been revoked: %s", credentialHash)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(expiry) {
+		return fmt.Errorf("credential already expired at %s", expiresAt)
+	}
+	credential := ProviderCredential{
+		ProviderID:     providerID,
+		CredentialHash: credentialHash,
+		IssuerID:       callerID,
+		IssuedAt:       now.UTC().Format(time.RFC3339),
+		ExpiresAt:      expiry.UTC().Format(time.RFC3339),
+		Status:         CredentialValid,
+	}
+	if err := putJSON(ctx, credentialKey(providerID), credential); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ProviderCredentialSubmitted", ProviderCredentialEvent{
+		ProviderID:     providerID,
+		CredentialHash: credentialHash,
+		IssuerID:       callerID,
+		ExpiresAt:      credential.ExpiresAt,
+		Status:         credential.Status,
+		Timestamp:      credential.IssuedAt,
+		CallerID:       callerID,
+		EventType:      "ProviderCredentialSubmitted",
+	})
+}
+
+// RevokeProviderCredential 原签发机构吊销凭证，并把凭证哈希写入吊销登记
+func (s *SmartContract) RevokeProviderCredential(ctx contractapi.TransactionContextInterface, providerID, reason string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	credential, err := getProviderCredential(ctx, providerID)
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return fmt.Errorf("credential not found for provider: %s", providerID)
+	}
+	if credential.IssuerID != callerID {
+		return fmt.Errorf("access denied: only the issuing authority can revoke this credential")
+	}
+	if credential.Status == CredentialRevoked {
+		return fmt.Errorf("credential already revoked for provider: %s", providerID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	credential.Status = CredentialRevoked
+	credential.RevokedAt = now
+	if err := putJSON(ctx, credentialKey(providerID), credential); err != nil {
+		return err
+	}
+	if err := putJSON(ctx, credentialRevocationKey(credential.CredentialHash), CredentialRevocation{
+		CredentialHash: credential.CredentialHash,
+		ProviderID:     providerID,
+		IssuerID:       callerID,
+		Reason:         reason,
+		RevokedAt:      now,
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ProviderCredentialRevoked", ProviderCredentialEvent{
+		ProviderID:     providerID,
+		CredentialHash: credential.CredentialHash,
+		IssuerID:       callerID,
+		ExpiresAt:      credential.ExpiresAt,
+		Status:         credential.Status,
+		Timestamp:      now,
+		CallerID:       callerID,
+		EventType:      "ProviderCredentialRevoked",
+	})
+}
+
+// VerifyProviderCredential 查询医护当前是否持有有效执业凭证
+func (s *SmartContract) VerifyProviderCredential(ctx contractapi.TransactionContextInterface, providerID string) (bool, error) {
+	return credentialValid(ctx, providerID)
+}
+
+// GetProviderCredential 返回凭证锚点，供前端展示到期与吊销状态
+func (s *SmartContract) GetProviderCredential(ctx contractapi.TransactionContextInterface, providerID string) (*ProviderCredential, error) {
+	credential, err := getProviderCredential(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, fmt.Errorf("credential not found for provider: %s", providerID)
+	}
+	return credential, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestCredentialIssuerRegistry(t *testing.T) {
+	env := newTestEnv(t)
+	locum := newIdentity("locum1", "Org2MSP", "role", "doctor")
+	hash := strings.Repeat("c", 64)
+	expiresAt := env.stub.now.Add(24 * time.Hour).Format(time.RFC3339)
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterCredentialIssuer(ctx, doctor.id, "self")
+	})
+	env.mustFail(doctor, "not a registered credential issuer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SubmitProviderCredential(ctx, locum.id, hash, expiresAt)
+	})
+	env.mustFail(medicalBoard, "already expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SubmitProviderCredential(ctx, locum.id, hash, env.stub.now.Format(time.RFC3339))
+	})
+	env.mustInvoke(medicalBoard, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SubmitProviderCredential(ctx, locum.id, hash, expiresAt)
+	})
+	env.expectEvent("ProviderCredentialSubmitted", nil)
+
+	// 签发机构注销后其凭证随即失效
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveCredentialIssuer(ctx, medicalBoard.id)
+	})
+	env.mustInvoke(locum, func(ctx contractapi.TransactionContextInterface) error {
+		valid, err := env.cc.VerifyProviderCredential(ctx, locum.id)
+		if err == nil && valid {
+			t.Fatal("credential from a removed issuer must not verify")
+		}
+		return err
+	})
+}
+
+func TestCredentialGatesRecordCreationAndWriteGrants(t *testing.T) {
+	env := newTestEnv(t)
+	locum := newIdentity("locum1", "Org2MSP", "role", "doctor")
+	record := `{"recordId":"rec1","patientId":"patient1","creatorId":"locum1","ipfsCid":"bafy","contentHash":"` + strings.Repeat("a", 64) + `"}`
+
+	env.mustFail(locum, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, record)
+		return err
+	})
+	env.createRecord(patient, "self1", patient.id)
+	env.mustFail(patient, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "self1", locum.id, "write")
+	})
+	env.grant(patient, "self1", locum.id, "read", "")
+
+	env.license(locum)
+	env.mustInvoke(locum, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, record)
+		return err
+	})
+	env.grant(patient, "self1", locum.id, "write", "")
+
+	// 到期按交易时间判断
+	env.advance(366 * 24 * time.Hour)
+	env.mustInvoke(locum, func(ctx contractapi.TransactionContextInterface) error {
+		valid, err := env.cc.VerifyProviderCredential(ctx, locum.id)
+		if err == nil && valid {
+			t.Fatal("expired credential must not verify")
+		}
+		return err
+	})
+}
+
+func TestRevokeProviderCredential(t *testing.T) {
+	env := newTestEnv(t)
+	locum := newIdentity("locum1", "Org2MSP", "role", "doctor")
+	env.license(locum)
+
+	env.mustFail(admin, "only the issuing authority", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeProviderCredential(ctx, locum.id, "board decision")
+	})
+	env.mustInvoke(medicalBoard, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeProviderCredential(ctx, locum.id, "board decision")
+	})
+	env.expectEvent("ProviderCredentialRevoked", nil)
+
+	var revoked *ProviderCredential
+	env.mustInvoke(locum, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		revoked, err = env.cc.GetProviderCredential(ctx, locum.id)
+		return err
+	})
+	if revoked.Status != CredentialRevoked {
+		t.Fatalf("unexpected credential: %+v", revoked)
+	}
+	env.mustFail(locum, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"rec1","patientId":"patient1","creatorId":"locum1","ipfsCid":"bafy","contentHash":"aa"}`)
+		return err
+	})
+	// 吊销登记按凭证哈希生效，同一凭证不能重新提交
+	env.mustFail(medicalBoard, "has been revoked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SubmitProviderCredential(ctx, locum.id, revoked.CredentialHash, env.stub.now.AddDate(1, 0, 0).Format(time.RFC3339))
+	})
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	TransferPending  = "pending"
+	TransferApproved = "approved"
+
+	LegalBasisAdequacy = "adequacy"
+	LegalBasisSCC      = "scc"
+	LegalBasisConsent  = "consent"
+)
+
+// CrossBorderTransfer 向区域外机构（配置 outOfRegionMsps）的身份共享记录前须经隐私官批准的传输对象，
+// 每个 (记录, 被授权人) 一条
+type CrossBorderTransfer struct {
+	RecordID    string `json:"recordId"`
+	GranteeID   string `json:"granteeId"`
+	GranteeMSP  string `json:"granteeMsp"`
+	LegalBasis  string `json:"legalBasis"`
+	SCCHash     string `json:"sccHash,omitempty"`
+	Status      string `json:"status"`
+	RequestedBy string `json:"requestedBy"`
+	RequestedAt string `json:"requestedAt"`
+	ApprovedBy  string `json:"approvedBy,omitempty"`
+	ApprovedAt  string `json:"approvedAt,omitempty"`
+}
+
+type CrossBorderTransferEvent struct {
+	RecordID   string `json:"recordId"`
+	GranteeID  string `json:"granteeId"`
+	GranteeMSP string `json:"granteeMsp"`
+	LegalBasis string `json:"legalBasis"`
+	Status     string `json:"status"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+// IdentityOrganization 身份所属机构登记；被授权人本人不在场时，storeGrant 与 checkAccess 据此识别区域外身份
+type IdentityOrganization struct {
+	UserID       string `json:"userId"`
+	MSPID        string `json:"mspId"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+type IdentityOrganizationEvent struct {
+	UserID    string `json:"userId"`
+	MSPID     string `json:"mspId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// transferKey transfer:{recordId}:{granteeId}
+func transferKey(recordID, granteeID string) string {
+	return "transfer:" + keySegment(recordID) + ":" + keySegment(granteeID)
+}
+
+func identityOrgKey(userID string) string {
+	return "identity-org:" + keySegment(userID)
+}
+
+// identityOutOfRegion 身份已登记所属机构且该机构在 outOfRegionMsps 中；未登记时只能在读取时按证书 MSP 判断
+func identityOutOfRegion(ctx contractapi.TransactionContextInterface, config *ContractConfig, userID string) (bool, error) {
+	if len(config.OutOfRegionMSPs) == 0 {
+		return false, nil
+	}
+	var org IdentityOrganization
+	found, err := getJSON(ctx, identityOrgKey(userID), &org)
+	if err != nil || !found {
+		return false, err
+	}
+	return containsString(config.OutOfRegionMSPs, org.MSPID), nil
+}
+
+func getTransfer(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*CrossBorderTransfer, error) {
+	var transfer CrossBorderTransfer
+	found, err := getJSON(ctx, transferKey(recordID, granteeID), &transfer)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// requireApprovedTransfer storeGrant 调用：已申报跨境传输或已登记为区域外机构的被授权人，须在传输批准后才能写入授权
+func requireApprovedTransfer(ctx contractapi.TransactionContextInterface, recordID, granteeID string) error {
+	transfer, err := getTransfer(ctx, recordID, granteeID)
+	if err != nil {
+		return err
+	}
+	if transfer == nil {
+		config, err := loadConfig(ctx)
+		if err != nil {
+			return err
+		}
+		outside, err := identityOutOfRegion(ctx, config, granteeID)
+		if err != nil || !outside {
+			return err
+		}
+	}
+	if transfer == nil || transfer.Status != TransferApproved {
+		return fmt.Errorf("grant to %s on record %s requires an approved cross-border transfer", granteeID, recordID)
+	}
+	return nil
+}
+
+// subjectCrossBorderCleared checkAccess 调用：登记为区域外机构的主体须有针对该记录的已批准传输；患者与创建者不受限。
+// 快速路径条目只由 storeGrant 等写入，写入时已校验，且主体持有有效授权时不能登记为区域外，因此只在完整判断中调用
+func subjectCrossBorderCleared(ctx contractapi.TransactionContextInterface, record *MedicalRecord, subject string) (bool, error) {
+	if subject == record.PatientID || subject == record.CreatorID {
+		return true, nil
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	outside, err := identityOutOfRegion(ctx, config, subject)
+	if err != nil || !outside {
+		return !outside, err
+	}
+	transfer, err := getTransfer(ctx, record.RecordID, subject)
+	if err != nil {
+		return false, err
+	}
+	return transfer != nil && transfer.Status == TransferApproved, nil
+}
+
+// crossBorderCleared 调用者来自区域外机构时，须有针对该记录与调用者的已批准传输对象；患者本人不受限。
+// 按调用者证书的 MSP 判断，覆盖未登记所属机构的身份；已登记的身份另由 checkAccess 按登记判断
+func crossBorderCleared(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string) (bool, error) {
+	if callerID == record.PatientID {
+		return true, nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !containsString(config.OutOfRegionMSPs, mspID) {
+		return true, nil
+	}
+	transfer, err := getTransfer(ctx, record.RecordID, callerID)
+	if err != nil {
+		return false, err
+	}
+	return transfer != nil && transfer.Status == TransferApproved, nil
+}
+
+// callerAccess 调用者读取自己可访问的记录：CheckAccess 之外再校验数据使用协议与跨境传输；持限定视图的身份一律拒绝
+func (s *SmartContract) callerAccess(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string) (bool, error) {
+	return s.callerTargetAccess(ctx, record, callerID, accessTarget{})
+}
+
+// callerTargetAccess 同 callerAccess，target 非零值时判断分节或脱敏视图
+func (s *SmartContract) callerTargetAccess(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string, target accessTarget) (bool, error) {
+	scope, err := getScopedQueryGrant(ctx, callerID)
+	if err != nil || scope != nil {
+		return false, err
+	}
+	// 审计员结构上不能读取记录内容，即使另有直接授权
+	auditor, err := isAuditor(ctx)
+	if err != nil || auditor {
+		return false, err
+	}
+	allowed, err := checkAccess(ctx, record.RecordID, callerID, target)
+	if err != nil || !allowed {
+		return false, err
+	}
+	cleared, err := duaCleared(ctx, record, callerID)
+	if err != nil || !cleared {
+		return false, err
+	}
+	return crossBorderCleared(ctx, record, callerID)
+}
+
+// RequestCrossBorderTransfer 患者或有 grant 范围的代理人申报向区域外身份共享记录的法律依据；
+// legalBasis 为 scc 时 sccHash 为标准合同条款文本的 sha256
+func (s *SmartContract) RequestCrossBorderTransfer(ctx contractapi.TransactionContextInterface, recordID, granteeID, granteeMSP, legalBasis, sccHash string) error {
+	if err := validateAddress(granteeID); err != nil {
+		return fmt.Errorf("invalid granteeID: %w", err)
+	}
+	switch legalBasis {
+	case LegalBasisAdequacy, LegalBasisConsent:
+		if sccHash != "" {
+			return fmt.Errorf("sccHash is only used with legalBasis scc")
+		}
+	case LegalBasisSCC:
+		if !sha256HexPattern.MatchString(sccHash) {
+			return fmt.Errorf("sccHash must be a lowercase hex sha256 digest")
+		}
+	default:
+		return fmt.Errorf("invalid legalBasis: %s", legalBasis)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !containsString(config.OutOfRegionMSPs, granteeMSP) {
+		return fmt.Errorf("%s is not an out-of-region organization", granteeMSP)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if _, err := ownerOrAgent(ctx, record.PatientID, callerID, "grant"); err != nil {
+		return err
+	}
+	var org IdentityOrganization
+	if found, err := getJSON(ctx, identityOrgKey(granteeID), &org); err != nil {
+		return err
+	} else if found && org.MSPID != granteeMSP {
+		return fmt.Errorf("%s is registered with %s, not %s", granteeID, org.MSPID, granteeMSP)
+	}
+	existing, err := getTransfer(ctx, recordID, granteeID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("cross-border transfer for %s on record %s is already %s", granteeID, recordID, existing.Status)
+	}
+	// 已有授权会绕过审批，须先撤销
+	if perm, err := getPermission(ctx, recordID, granteeID); err != nil {
+		return err
+	} else if perm != nil && perm.IsActive {
+		return fmt.Errorf("%s already has access to record %s: revoke it before requesting a transfer", granteeID, recordID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	transfer := CrossBorderTransfer{
+		RecordID:    recordID,
+		GranteeID:   granteeID,
+		GranteeMSP:  granteeMSP,
+		LegalBasis:  legalBasis,
+		SCCHash:     sccHash,
+		Status:      TransferPending,
+		RequestedBy: callerID,
+		RequestedAt: now,
+	}
+	if err := putJSON(ctx, transferKey(recordID, granteeID), transfer); err != nil {
+		return err
+	}
+	return emitTransferEvent(ctx, "CrossBorderTransferRequested", &transfer, now, callerID)
+}
+
+// ApproveCrossBorderTransfer 隐私官批准传输对象，之后才能为该被授权人写入授权；申请人不能自行批准
+func (s *SmartContract) ApproveCrossBorderTransfer(ctx contractapi.TransactionContextInterface, recordID, granteeID string) error {
+	isOfficer, err := hasRole(ctx, privacyOfficerRole)
+	if err != nil {
+		return err
+	}
+	if !isOfficer {
+		return fmt.Errorf("access denied: only a privacy officer can approve cross-border transfers")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	transfer, err := getTransfer(ctx, recordID, granteeID)
+	if err != nil {
+		return err
+	}
+	if transfer == nil {
+		return fmt.Errorf("cross-border transfer not found for %s on record %s", granteeID, recordID)
+	}
+	if transfer.Status != TransferPending {
+		return fmt.Errorf("cross-border transfer for %s on record %s is already %s", granteeID, recordID, transfer.Status)
+	}
+	if transfer.RequestedBy == callerID {
+		return fmt.Errorf("access denied: the requester cannot approve their own transfer")
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	transfer.Status = TransferApproved
+	transfer.ApprovedBy = callerID
+	transfer.ApprovedAt = now
+	if err := putJSON(ctx, transferKey(recordID, granteeID), transfer); err != nil {
+		return err
+	}
+	return emitTransferEvent(ctx, "CrossBorderTransferApproved", transfer, now, callerID)
+}
+
+// RegisterIdentityOrganization admin 或 registrar 线下核验后登记身份所属机构，可更正。
+// 登记为区域外机构时该身份不能仍持有有效授权（这些授权未经跨境审批），须先撤销
+func (s *SmartContract) RegisterIdentityOrganization(ctx contractapi.TransactionContextInterface, userID, mspID string) error {
+	if err := validateAddress(userID); err != nil {
+		return fmt.Errorf("invalid userID: %w", err)
+	}
+	if mspID == "" {
+		return fmt.Errorf("mspID is required")
+	}
+	allowed, err := hasAnyRole(ctx, "admin", "registrar")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: only admin or a registrar can register identity organizations")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if containsString(config.OutOfRegionMSPs, mspID) {
+		if err := requireNoActiveGrants(ctx, userID); err != nil {
+			return err
+		}
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, identityOrgKey(userID), IdentityOrganization{
+		UserID:       userID,
+		MSPID:        mspID,
+		RegisteredBy: callerID,
+		RegisteredAt: now,
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "IdentityOrganizationRegistered", IdentityOrganizationEvent{
+		UserID:    userID,
+		MSPID:     mspID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "IdentityOrganizationRegistered",
+	})
+}
+
+// requireNoActiveGrants 经 grantee~record 索引确认身份没有有效的单独授权
+func requireNoActiveGrants(ctx contractapi.TransactionContextInterface, userID string) error {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(granteeRecordIndex, []string{userID})
+	if err != nil {
+		return fmt.Errorf("failed to query %s index: %w", granteeRecordIndex, err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return fmt.Errorf("failed to iterate %s index: %w", granteeRecordIndex, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return fmt.Errorf("failed to split %s index key: %w", granteeRecordIndex, err)
+		}
+		perm, err := getPermission(ctx, attrs[1], userID)
+		if err != nil {
+			return err
+		}
+		if perm != nil && perm.IsActive {
+			return fmt.Errorf("%s still has access to record %s: revoke it before registering an out-of-region organization", userID, attrs[1])
+		}
+	}
+	return nil
+}
+
+// GetIdentityOrganization 返回身份所属机构登记
+func (s *SmartContract) GetIdentityOrganization(ctx contractapi.TransactionContextInterface, userID string) (*IdentityOrganization, error) {
+	var org IdentityOrganization
+	found, err := getJSON(ctx, identityOrgKey(userID), &org)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no organization registered for %s", userID)
+	}
+	return &org, nil
+}
+
+// GetCrossBorderTransfer 返回传输对象
+func (s *SmartContract) GetCrossBorderTransfer(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*CrossBorderTransfer, error) {
+	transfer, err := getTransfer(ctx, recordID, granteeID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, fmt.Errorf("cross-border transfer not found for %s on record %s", granteeID, recordID)
+	}
+	return transfer, nil
+}
+
+func emitTransferEvent(ctx contractapi.TransactionContextInterface, eventName string, transfer *CrossBorderTransfer, now, callerID string) error {
+	return emitEvent(ctx, eventName, CrossBorderTransferEvent{
+		RecordID:   transfer.RecordID,
+		GranteeID:  transfer.GranteeID,
+		GranteeMSP: transfer.GranteeMSP,
+		LegalBasis: transfer.LegalBasis,
+		Status:     transfer.Status,
+		Timestamp:  now,
+		CallerID:   callerID,
+		EventType:  eventName,
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var euDoctor = newIdentity("eudoc1", "EuMSP", "role", "doctor")
+
+func TestCrossBorderTransferGatesGrantsAndReads(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"outOfRegionMsps":["EuMSP"]}`)
+	})
+	env.createRecord(doctor, "rec1", patient.id)
+	sccHash := strings.Repeat("ab", 32)
+
+	// 未申报的区域外身份即便持有授权，读取时也按证书 MSP 拦截
+	env.grant(patient, "rec1", euDoctor.id, "read", "")
+	env.mustFail(euDoctor, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	if result := env.auditedRead(euDoctor, "rec1"); result.Allowed {
+		t.Fatalf("out-of-region read must be denied: %+v", result)
+	}
+	env.mustFail(patient, "revoke it before requesting", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "EuMSP", LegalBasisSCC, sccHash)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", euDoctor.id)
+	})
+
+	env.mustFail(patient, "not an out-of-region organization", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "Org2MSP", LegalBasisAdequacy, "")
+	})
+	env.mustFail(patient, "sccHash must be", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "EuMSP", LegalBasisSCC, "")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "EuMSP", LegalBasisSCC, sccHash)
+	})
+	env.expectEvent("CrossBorderTransferRequested", nil)
+	env.mustFail(patient, "requires an approved cross-border transfer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", euDoctor.id, "read")
+	})
+
+	env.mustFail(doctor, "only a privacy officer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveCrossBorderTransfer(ctx, "rec1", euDoctor.id)
+	})
+	env.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveCrossBorderTransfer(ctx, "rec1", euDoctor.id)
+	})
+	var event CrossBorderTransferEvent
+	env.expectEvent("CrossBorderTransferApproved", &event)
+	if event.Status != TransferApproved || event.LegalBasis != LegalBasisSCC {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	env.grant(patient, "rec1", euDoctor.id, "read", "")
+	env.mustInvoke(euDoctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	// 区域内身份不受影响
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+}
+
+func TestRegisteredOutOfRegionIdentityNeedsTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"outOfRegionMsps":["EuMSP","ApacMSP"]}`)
+	})
+	env.createRecord(doctor, "rec1", patient.id)
+	register := func(identity *testIdentity, userID, mspID string) error {
+		return env.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.RegisterIdentityOrganization(ctx, userID, mspID)
+		})
+	}
+
+	// 已持有未经审批的授权时不能登记为区域外机构
+	env.grant(patient, "rec1", euDoctor.id, "read", "")
+	if err := register(patient, euDoctor.id, "EuMSP"); err == nil || !strings.Contains(err.Error(), "only admin or a registrar") {
+		t.Fatalf("patient must not register organizations: %v", err)
+	}
+	if err := register(registrar, euDoctor.id, "EuMSP"); err == nil || !strings.Contains(err.Error(), "revoke it before") {
+		t.Fatalf("registration with active grants must fail: %v", err)
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", euDoctor.id)
+	})
+	if err := register(registrar, euDoctor.id, "EuMSP"); err != nil {
+		t.Fatal(err)
+	}
+	env.expectEvent("IdentityOrganizationRegistered", nil)
+
+	// 授权时即拒绝，不必等被授权人读取
+	env.mustFail(patient, "requires an approved cross-border transfer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", euDoctor.id, "read")
+	})
+	env.mustFail(patient, "registered with EuMSP", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "ApacMSP", LegalBasisConsent, "")
+	})
+
+	// 护理团队等派生访问不经 storeGrant，CheckAccess 同样拦截
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddCareTeamMember(ctx, patient.id, euDoctor.id, "consultant")
+	})
+	if env.checkAccess("rec1", euDoctor.id) {
+		t.Fatal("CheckAccess must deny a registered out-of-region identity without a transfer")
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RequestCrossBorderTransfer(ctx, "rec1", euDoctor.id, "EuMSP", LegalBasisConsent, "")
+	})
+	env.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveCrossBorderTransfer(ctx, "rec1", euDoctor.id)
+	})
+	if !env.checkAccess("rec1", euDoctor.id) {
+		t.Fatal("approved transfer must clear derived access")
+	}
+	env.grant(patient, "rec1", euDoctor.id, "read", "")
+}
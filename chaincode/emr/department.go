@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// departmentAttribute 证书中的科室属性；记录创建时取创建交易调用者的该属性
+const departmentAttribute = "department"
+
+// maxDepartmentWindowHours 科室策略的最长时间窗
+const maxDepartmentWindowHours = 24 * 30
+
+// maxPolicyConditionCodes 科室策略可引用的诊断代码上限
+const maxPolicyConditionCodes = 50
+
+// DepartmentPolicy 机构内科室的隐式读取策略：本机构 department 科室中持 roles 任一角色的身份，
+// 可读取本机构 createdInDepartment 科室在 windowHours 小时内创建的记录，无需逐条授权；
+// conditionCodes 非空时，患者的问题清单须有其中任一 SNOMED CT 代码的未解决条目
+type DepartmentPolicy struct {
+	MSPID               string   `json:"mspId"`
+	Department          string   `json:"department"`
+	Roles               []string `json:"roles"`
+	CreatedInDepartment string   `json:"createdInDepartment"`
+	WindowHours         int      `json:"windowHours"`
+	ConditionCodes      []string `json:"conditionCodes,omitempty"`
+	UpdatedAt           string   `json:"updatedAt"`
+	UpdatedBy           string   `json:"updatedBy"`
+}
+
+type DepartmentPolicyEvent struct {
+	MSPID      string `json:"mspId"`
+	Department string `json:"department"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+// departmentPolicyKey dept-policy:{mspId}:{department}
+func departmentPolicyKey(mspID, department string) string {
+	return departmentPolicyPrefix(mspID) + keySegment(department)
+}
+
+func departmentPolicyPrefix(mspID string) string {
+	return "dept-policy:" + keySegment(mspID) + ":"
+}
+
+// callerAttribute 调用者证书属性，未设置时为空串
+func callerAttribute(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	value, _, err := ctx.GetClientIdentity().GetAttributeValue(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s attribute: %w", name, err)
+	}
+	return value, nil
+}
+
+// requireOrgAdmin 调用者须为 mspID 机构的 admin
+func requireOrgAdmin(ctx contractapi.TransactionContextInterface, mspID, what string) (string, error) {
+	callerID, callerMSP, err := requireAdminCaller(ctx, what)
+	if err != nil {
+		return "", err
+	}
+	if callerMSP != mspID {
+		return "", fmt.Errorf("access denied: only admin of %s can %s", mspID, what)
+	}
+	return callerID, nil
+}
+
+func emitDepartmentPolicyEvent(ctx contractapi.TransactionContextInterface, name, mspID, department, callerID string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, name, DepartmentPolicyEvent{
+		MSPID:      mspID,
+		Department: department,
+		Timestamp:  now,
+		CallerID:   callerID,
+		EventType:  name,
+	})
+}
+
+// SetDepartmentPolicy 机构 admin 设置本机构科室的隐式读取策略，覆盖原有策略；
+// policyJson 为 {roles, createdInDepartment, windowHours, conditionCodes}，createdInDepartment 缺省为 department
+func (s *SmartContract) SetDepartmentPolicy(ctx contractapi.TransactionContextInterface, mspID, department, policyJson string) error {
+	if !recordTypePattern.MatchString(department) {
+		return fmt.Errorf("invalid department: %q", department)
+	}
+	callerID, err := requireOrgAdmin(ctx, mspID, "set department policies")
+	if err != nil {
+		return err
+	}
+	var policy DepartmentPolicy
+	if err := unmarshalArg(policyJson, &policy); err != nil {
+		return fmt.Errorf("invalid policy json: %w", err)
+	}
+	if len(policy.Roles) == 0 {
+		return fmt.Errorf("roles are required")
+	}
+	for _, role := range policy.Roles {
+		if role == "" || role == "admin" {
+			return fmt.Errorf("invalid role in department policy: %q", role)
+		}
+	}
+	if policy.CreatedInDepartment == "" {
+		policy.CreatedInDepartment = department
+	}
+	if !recordTypePattern.MatchString(policy.CreatedInDepartment) {
+		return fmt.Errorf("invalid createdInDepartment: %q", policy.CreatedInDepartment)
+	}
+	if policy.WindowHours < 1 || policy.WindowHours > maxDepartmentWindowHours {
+		return fmt.Errorf("windowHours must be between 1 and %d", maxDepartmentWindowHours)
+	}
+	if len(policy.ConditionCodes) > maxPolicyConditionCodes {
+		return fmt.Errorf("at most %d conditionCodes are allowed", maxPolicyConditionCodes)
+	}
+	for _, code := range policy.ConditionCodes {
+		if err := validateSNOMEDCode(code); err != nil {
+			return err
+		}
+	}
+	policy.MSPID = mspID
+	policy.Department = department
+	policy.UpdatedBy = callerID
+	policy.UpdatedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, departmentPolicyKey(mspID, department), policy); err != nil {
+		return err
+	}
+	return emitDepartmentPolicyEvent(ctx, "DepartmentPolicySet", mspID, department, callerID)
+}
+
+// RemoveDepartmentPolicy 机构 admin 删除科室策略
+func (s *SmartContract) RemoveDepartmentPolicy(ctx contractapi.TransactionContextInterface, mspID, department string) error {
+	callerID, err := requireOrgAdmin(ctx, mspID, "remove department policies")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, departmentPolicyKey(mspID, department))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no department policy for %s in %s", department, mspID)
+	}
+	if err := ctx.GetStub().DelState(departmentPolicyKey(mspID, department)); err != nil {
+		return fmt.Errorf("failed to delete department policy: %w", err)
+	}
+	return emitDepartmentPolicyEvent(ctx, "DepartmentPolicyRemoved", mspID, department, callerID)
+}
+
+// GetDepartmentPolicies 返回机构的全部科室策略；限本机构身份
+func (s *SmartContract) GetDepartmentPolicies(ctx contractapi.TransactionContextInterface, mspID string) ([]*DepartmentPolicy, error) {
+	callerMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	if callerMSP != mspID {
+		return nil, fmt.Errorf("access denied: only members of %s can view its department policies", mspID)
+	}
+	prefix := departmentPolicyPrefix(mspID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan department policies: %w", err)
+	}
+	defer iterator.Close()
+
+	policies := []*DepartmentPolicy{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate department policies: %w", err)
+		}
+		var policy DepartmentPolicy
+		if err := json.Unmarshal(kv.Value, &policy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal department policy: %w", err)
+		}
+		policies = append(policies, &policy)
+	}
+	return policies, nil
+}
+
+// departmentGrantsAccess 派生规则：按调用者的 MSP、department 与 role 属性评估科室策略，策略引用诊断时再查患者的问题清单。
+// 属性只能取自调用者证书，userID 不是调用者本人时不适用
+func departmentGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	isCaller, err := callerIs(ctx, userID)
+	if err != nil || !isCaller {
+		return false, err
+	}
+	department, err := callerAttribute(ctx, departmentAttribute)
+	if err != nil || department == "" {
+		return false, err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	var policy DepartmentPolicy
+	found, err := getJSON(ctx, departmentPolicyKey(mspID, department), &policy)
+	if err != nil || !found {
+		return false, err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	if record.CreatorMSP != mspID || record.Department != policy.CreatedInDepartment {
+		return false, nil
+	}
+	hasPolicyRole, err := hasAnyRole(ctx, policy.Roles...)
+	if err != nil || !hasPolicyRole {
+		return false, err
+	}
+	if len(policy.ConditionCodes) > 0 {
+		matches, err := hasActiveCondition(ctx, record.PatientID, policy.ConditionCodes)
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+	return withinWindow(ctx, record.Timestamp, time.Duration(policy.WindowHours)*time.Hour)
+}
+
+// withinWindow timestamp 不晚于交易时间且距今不足 window；记录时间可由客户端指定，晚于交易时间的不放行
+func withinWindow(ctx contractapi.TransactionContextInterface, timestamp string, window time.Duration) (bool, error) {
+	recordedAt, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false, nil
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !recordedAt.After(now) && now.Sub(recordedAt) < window, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	edDoctor    = newIdentity(doctor.id, doctor.msp, departmentAttribute, "ed")
+	edClinician = newIdentity("edclinician1", "Org1MSP", departmentAttribute, "ed", "role", "clinician")
+	edClerk     = newIdentity("edclerk1", "Org1MSP", departmentAttribute, "ed", "role", "clerk")
+	wardDoctor  = newIdentity("ward1", "Org1MSP", departmentAttribute, "ward", "role", "clinician")
+	org2Admin   = newIdentity("admin2", "Org2MSP", "role", "admin")
+)
+
+func TestDepartmentPolicyGrantsRecentReads(t *testing.T) {
+	env := newTestEnv(t)
+	policy := `{"roles":["clinician"],"windowHours":72}`
+	env.mustFail(org2Admin, "only admin of Org1MSP", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetDepartmentPolicy(ctx, "Org1MSP", "ed", policy)
+	})
+	env.mustFail(admin, "windowHours must be", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetDepartmentPolicy(ctx, "Org1MSP", "ed", `{"roles":["clinician"]}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetDepartmentPolicy(ctx, "Org1MSP", "ed", policy)
+	})
+	env.expectEvent("DepartmentPolicySet", nil)
+
+	env.createRecord(edDoctor, "ed1", patient.id)
+	env.createRecord(doctor, "other1", patient.id)
+	if record := env.storedRecord("ed1"); record.Department != "ed" || record.CreatorMSP != "Org1MSP" {
+		t.Fatalf("record must carry the creator's department: %+v", record)
+	}
+	if !env.canRead(edClinician, "ed1") {
+		t.Fatal("ED clinicians must read recent ED records")
+	}
+	// 角色、科室或记录来源不符均不放行
+	if env.canRead(edClerk, "ed1") || env.canRead(wardDoctor, "ed1") || env.canRead(edClinician, "other1") {
+		t.Fatal("department policy must match role, department and record origin")
+	}
+	// 属性取自调用者证书，代他人查询不适用
+	if env.checkAccess("ed1", edClinician.id) {
+		t.Fatal("department policy must only apply to the caller")
+	}
+
+	env.advance(72 * time.Hour)
+	if env.canRead(edClinician, "ed1") {
+		t.Fatal("department access must end after the window")
+	}
+
+	env.mustInvoke(edClinician, func(ctx contractapi.TransactionContextInterface) error {
+		policies, err := env.cc.GetDepartmentPolicies(ctx, "Org1MSP")
+		if err == nil && (len(policies) != 1 || policies[0].CreatedInDepartment != "ed" || policies[0].UpdatedBy != admin.id) {
+			t.Fatalf("unexpected policies: %+v", policies)
+		}
+		return err
+	})
+	env.mustFail(org2Admin, "only members of Org1MSP", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDepartmentPolicies(ctx, "Org1MSP")
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RemoveDepartmentPolicy(ctx, "Org1MSP", "ed")
+	})
+	env.createRecord(edDoctor, "ed2", patient.id)
+	if env.canRead(edClinician, "ed2") {
+		t.Fatal("removed policies must not grant access")
+	}
+}
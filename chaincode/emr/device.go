@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// deviceIDPattern 设备 ID 不含 ':'，保证 rollup 简单键的区间扫描不会串到其他设备
+var deviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+const rollupDayLayout = "20060102"
+
+// Device 患者名下登记的设备；CertID 为设备证书身份，提交批次时须与调用者一致
+type Device struct {
+	DeviceID     string `json:"deviceId"`
+	PatientID    string `json:"patientId"`
+	DeviceType   string `json:"deviceType"`
+	CertID       string `json:"certId"`
+	RegisteredAt string `json:"registeredAt"`
+	Active       bool   `json:"active"`
+}
+
+// DeviceRollup 一个采集周期的批次根
+type DeviceRollup struct {
+	DeviceID    string `json:"deviceId"`
+	PeriodStart string `json:"periodStart"`
+	MerkleRoot  string `json:"merkleRoot"`
+	Count       int    `json:"count"`
+	SubmittedAt string `json:"submittedAt"`
+	TxID        string `json:"txId"`
+}
+
+type DeviceEvent struct {
+	DeviceID  string `json:"deviceId"`
+	PatientID string `json:"patientId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func deviceKey(deviceID string) string {
+	return "device:" + deviceID
+}
+
+// rollupKey rollup:{deviceId}:{yyyymmdd}:{hhmmss}，各段定长，按字典序即时间顺序
+func rollupKey(deviceID string, periodStart time.Time) string {
+	return "rollup:" + deviceID + ":" + periodStart.Format(rollupDayLayout) + ":" + periodStart.Format("150405")
+}
+
+func getDevice(ctx contractapi.TransactionContextInterface, deviceID string) (*Device, error) {
+	var device Device
+	found, err := getJSON(ctx, deviceKey(deviceID), &device)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("device not found: %s", deviceID)
+	}
+	return &device, nil
+}
+
+// RegisterDevice 患者为本人登记设备
+func (s *SmartContract) RegisterDevice(ctx contractapi.TransactionContextInterface, patientID, deviceID, deviceType, certID string) error {
+	if !deviceIDPattern.MatchString(deviceID) {
+		return fmt.Errorf("invalid deviceId: %s", deviceID)
+	}
+	if deviceType == "" {
+		return fmt.Errorf("deviceType is required")
+	}
+	if err := validateAddress(certID); err != nil {
+		return fmt.Errorf("invalid certId: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != patientID {
+		return fmt.Errorf("access denied: only the patient can register devices")
+	}
+	exists, err := assetExists(ctx, deviceKey(deviceID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("device already registered: %s", deviceID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	device := Device{
+		DeviceID:     deviceID,
+		PatientID:    patientID,
+		DeviceType:   deviceType,
+		CertID:       certID,
+		RegisteredAt: now,
+		Active:       true,
+	}
+	if err := putJSON(ctx, deviceKey(deviceID), device); err != nil {
+		return err
+	}
+	return emitDeviceEvent(ctx, "DeviceRegistered", &device, callerID, now)
+}
+
+// DeactivateDevice 患者停用设备，之后该设备不能再提交批次
+func (s *SmartContract) DeactivateDevice(ctx contractapi.TransactionContextInterface, deviceID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	device, err := getDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if callerID != device.PatientID {
+		return fmt.Errorf("access denied: only the patient can deactivate devices")
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	device.Active = false
+	if err := putJSON(ctx, deviceKey(deviceID), device); err != nil {
+		return err
+	}
+	return emitDeviceEvent(ctx, "DeviceDeactivated", device, callerID, now)
+}
+
+// SubmitBatchRoot 设备提交一个周期的 Merkle 根；同一周期只能提交一次
+func (s *SmartContract) SubmitBatchRoot(ctx contractapi.TransactionContextInterface, deviceID, periodStart, merkleRoot string, count int) error {
+	start, err := time.Parse(time.RFC3339, periodStart)
+	if err != nil {
+		return fmt.Errorf("invalid periodStart: %w", err)
+	}
+	start = start.UTC()
+	if !sha256HexPattern.MatchString(merkleRoot) {
+		return fmt.Errorf("invalid merkleRoot: expected hex-encoded SHA-256")
+	}
+	if count <= 0 {
+		return fmt.Errorf("count must be positive")
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	device, err := getDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if !device.Active {
+		return fmt.Errorf("device is not active: %s", deviceID)
+	}
+	if callerID != device.CertID {
+		return fmt.Errorf("access denied: caller is not the registered device identity")
+	}
+
+	key := rollupKey(deviceID, start)
+	exists, err := assetExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("batch already submitted for %s at %s", deviceID, start.Format(time.RFC3339))
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, key, DeviceRollup{
+		DeviceID:    deviceID,
+		PeriodStart: start.Format(time.RFC3339),
+		MerkleRoot:  merkleRoot,
+		Count:       count,
+		SubmittedAt: now,
+		TxID:        ctx.GetStub().GetTxID(),
+	})
+}
+
+// ListDeviceRollups 返回 [fromDay, toDay]（YYYY-MM-DD，含两端）内的批次根；
+// 患者本人，或对患者任一记录通过 CheckAccess 的身份可查询
+func (s *SmartContract) ListDeviceRollups(ctx contractapi.TransactionContextInterface, deviceID, fromDay, toDay string) ([]*DeviceRollup, error) {
+	from, err := time.Parse("2006-01-02", fromDay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fromDay: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", toDay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid toDay: %w", err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("toDay must not be before fromDay")
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	device, err := getDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != device.PatientID {
+		allowed, err := anyPatientRecord(ctx, device.PatientID, func(recordID string) (bool, error) {
+			return s.CheckAccess(ctx, recordID, callerID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("access denied: %s cannot view device data of %s", callerID, device.PatientID)
+		}
+	}
+
+	// ';' 紧随 ':'，结束键覆盖 toDay 当天全部周期
+	startKey := "rollup:" + deviceID + ":" + from.Format(rollupDayLayout) + ":"
+	endKey := "rollup:" + deviceID + ":" + to.Format(rollupDayLayout) + ";"
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer iterator.Close()
+
+	rollups := []*DeviceRollup{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate rollups: %w", err)
+		}
+		var rollup DeviceRollup
+		if err := unmarshalArg(string(kv.Value), &rollup); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rollup: %w", err)
+		}
+		rollups = append(rollups, &rollup)
+	}
+	return rollups, nil
+}
+
+func emitDeviceEvent(ctx contractapi.TransactionContextInterface, name string, device *Device, callerID, now string) error {
+	return emitEvent(ctx, name, DeviceEvent{
+		DeviceID:  device.DeviceID,
+		PatientID: device.PatientID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
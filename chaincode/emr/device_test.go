@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var glucoseMeter = newIdentity("cgm-cert-1", "Org1MSP", "role", "device")
+
+func TestDeviceRollupsByDateRange(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDevice(ctx, patient.id, "cgm1", "glucose", glucoseMeter.id)
+	})
+	env.mustFail(patient, "invalid deviceId", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDevice(ctx, patient.id, "cgm:1", "glucose", glucoseMeter.id)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDevice(ctx, patient.id, "cgm1", "glucose", glucoseMeter.id)
+	})
+	env.expectEvent("DeviceRegistered", nil)
+
+	submit := func(id *testIdentity, periodStart string) error {
+		return env.invoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.SubmitBatchRoot(ctx, "cgm1", periodStart, strings.Repeat("e", 64), 12)
+		})
+	}
+	for _, p := range []string{"2026-01-01T23:00:00Z", "2026-01-02T00:00:00Z", "2026-01-02T13:00:00Z", "2026-01-03T00:00:00Z"} {
+		if err := submit(glucoseMeter, p); err != nil {
+			t.Fatalf("submit %s: %v", p, err)
+		}
+	}
+	if err := submit(glucoseMeter, "2026-01-02T00:00:00Z"); err == nil || !strings.Contains(err.Error(), "already submitted") {
+		t.Fatalf("duplicate period must be rejected, got %v", err)
+	}
+	if err := submit(patient, "2026-01-04T00:00:00Z"); err == nil {
+		t.Fatal("only the device identity may submit")
+	}
+
+	list := func(id *testIdentity, from, to string) []*DeviceRollup {
+		var rollups []*DeviceRollup
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			rollups, err = env.cc.ListDeviceRollups(ctx, "cgm1", from, to)
+			return err
+		})
+		return rollups
+	}
+	got := list(patient, "2026-01-02", "2026-01-02")
+	if len(got) != 2 || got[0].PeriodStart != "2026-01-02T00:00:00Z" || got[1].PeriodStart != "2026-01-02T13:00:00Z" {
+		t.Fatalf("unexpected rollups for one day: %+v", got)
+	}
+	if got := list(patient, "2026-01-01", "2026-01-03"); len(got) != 4 {
+		t.Fatalf("expected 4 rollups, got %d", len(got))
+	}
+
+	// 读取规则继承患者已有的记录授权
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListDeviceRollups(ctx, "cgm1", "2026-01-01", "2026-01-03")
+		return err
+	})
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	if got := list(nurse, "2026-01-03", "2026-01-03"); len(got) != 1 {
+		t.Fatalf("granted nurse must see rollups, got %d", len(got))
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.DeactivateDevice(ctx, "cgm1")
+	})
+	if err := submit(glucoseMeter, "2026-01-04T00:00:00Z"); err == nil || !strings.Contains(err.Error(), "not active") {
+		t.Fatalf("inactive device must be rejected, got %v", err)
+	}
+}
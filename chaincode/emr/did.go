@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// didPattern 支持的 DID 方法：did:fabric 与 did:web（method-specific-id 按 W3C DID Core 字符集）
+var didPattern = regexp.MustCompile(`^did:(fabric|web):[A-Za-z0-9._:%-]{1,200}$`)
+
+// DIDAnchor DID 文档锚点；链上只存文档哈希，ControllerID 为控制该 DID 的链上身份。
+// 登记只是声明，registrar 或 admin 核验控制权（线下以 DID 文档中的密钥签署挑战）后 Verified 才为 true
+type DIDAnchor struct {
+	DID          string `json:"did"`
+	DocHash      string `json:"docHash"`
+	ControllerID string `json:"controllerId"`
+	Verified     bool   `json:"verified"`
+	VerifiedBy   string `json:"verifiedBy,omitempty"`
+	VerifiedAt   string `json:"verifiedAt,omitempty"`
+	CreatedAt    string `json:"createdAt"`
+	UpdatedAt    string `json:"updatedAt"`
+}
+
+type DIDEvent struct {
+	DID          string `json:"did"`
+	DocHash      string `json:"docHash"`
+	ControllerID string `json:"controllerId"`
+	Timestamp    string `json:"timestamp"`
+	EventType    string `json:"eventType"`
+}
+
+func didKey(did string) string {
+	return "did:" + did
+}
+
+// didControllerKey 控制者 → DID 反查，只在核验通过后写入；每个身份只控制一个 DID
+func didControllerKey(controllerID string) string {
+	return "did-controller:" + controllerID
+}
+
+// controlledDID 返回 controllerID 经核验控制的 DID，没有时返回空串；未核验的声明不参与 subjectIDs
+func controlledDID(ctx contractapi.TransactionContextInterface, controllerID string) (string, error) {
+	data, err := ctx.GetStub().GetState(didControllerKey(controllerID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read DID controller: %w", err)
+	}
+	return string(data), nil
+}
+
+// RegisterDID 锚定 DID 文档；首次登记时调用者声明为控制者，之后只有控制者可更新文档哈希。
+// 声明在 VerifyDIDControl 之前不会让调用者被视为该 DID
+func (s *SmartContract) RegisterDID(ctx contractapi.TransactionContextInterface, did, docHash string) error {
+	if !didPattern.MatchString(did) {
+		return fmt.Errorf("unsupported DID: %s", did)
+	}
+	if !sha256HexPattern.MatchString(docHash) {
+		return fmt.Errorf("docHash must be a lowercase hex sha256 digest")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	var anchor DIDAnchor
+	found, err := getJSON(ctx, didKey(did), &anchor)
+	if err != nil {
+		return err
+	}
+	eventName := "DIDUpdated"
+	if found {
+		if anchor.ControllerID != callerID {
+			return fmt.Errorf("access denied: only the DID controller can update %s", did)
+		}
+	} else {
+		existing, err := controlledDID(ctx, callerID)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			return fmt.Errorf("identity already controls %s", existing)
+		}
+		anchor = DIDAnchor{DID: did, ControllerID: callerID, CreatedAt: now}
+		eventName = "DIDRegistered"
+	}
+	anchor.DocHash = docHash
+	anchor.UpdatedAt = now
+	if err := putJSON(ctx, didKey(did), anchor); err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, DIDEvent{
+		DID:          did,
+		DocHash:      docHash,
+		ControllerID: callerID,
+		Timestamp:    now,
+		EventType:    eventName,
+	})
+}
+
+// VerifyDIDControl registrar 或 admin 核验 controllerID 对 DID 的控制权后登记控制关系；
+// 未核验的声明可被改判给 controllerID，已核验的控制者不能被替换
+func (s *SmartContract) VerifyDIDControl(ctx contractapi.TransactionContextInterface, did, controllerID string) error {
+	allowed, err := hasAnyRole(ctx, "admin", "registrar")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: only admin or a registrar can verify DID control")
+	}
+	if err := validateAddress(controllerID); err != nil {
+		return fmt.Errorf("invalid controllerID: %w", err)
+	}
+	var anchor DIDAnchor
+	found, err := getJSON(ctx, didKey(did), &anchor)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("DID not found: %s", did)
+	}
+	if anchor.Verified {
+		return fmt.Errorf("control of %s is already verified for %s", did, anchor.ControllerID)
+	}
+	existing, err := controlledDID(ctx, controllerID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return fmt.Errorf("identity already controls %s", existing)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	anchor.ControllerID = controllerID
+	anchor.Verified = true
+	anchor.VerifiedBy = callerID
+	anchor.VerifiedAt = now
+	anchor.UpdatedAt = now
+	if err := putJSON(ctx, didKey(did), anchor); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(didControllerKey(controllerID), []byte(did)); err != nil {
+		return fmt.Errorf("failed to store DID controller: %w", err)
+	}
+	return emitEvent(ctx, "DIDVerified", DIDEvent{
+		DID:          did,
+		DocHash:      anchor.DocHash,
+		ControllerID: controllerID,
+		Timestamp:    now,
+		EventType:    "DIDVerified",
+	})
+}
+
+// ResolveDID 返回 DID 文档锚点
+func (s *SmartContract) ResolveDID(ctx contractapi.TransactionContextInterface, did string) (*DIDAnchor, error) {
+	var anchor DIDAnchor
+	found, err := getJSON(ctx, didKey(did), &anchor)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("DID not found: %s", did)
+	}
+	return &anchor, nil
+}
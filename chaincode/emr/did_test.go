@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestRegisterAndResolveDID(t *testing.T) {
+	env := newTestEnv(t)
+	docHash := strings.Repeat("d", 64)
+
+	env.mustFail(patient, "unsupported DID", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, "did:example:123", docHash)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, "did:web:partner.example:patients:42", docHash)
+	})
+	env.expectEvent("DIDRegistered", nil)
+
+	env.mustFail(other, "only the DID controller", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, "did:web:partner.example:patients:42", strings.Repeat("e", 64))
+	})
+	env.verifyDID("did:web:partner.example:patients:42", patient.id)
+	env.mustFail(patient, "already controls", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, "did:fabric:patient1-alt", docHash)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, "did:web:partner.example:patients:42", strings.Repeat("e", 64))
+	})
+	env.expectEvent("DIDUpdated", nil)
+
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		anchor, err := env.cc.ResolveDID(ctx, "did:web:partner.example:patients:42")
+		if err == nil && (anchor.ControllerID != patient.id || !anchor.Verified || anchor.DocHash != strings.Repeat("e", 64)) {
+			t.Fatalf("unexpected anchor: %+v", anchor)
+		}
+		return err
+	})
+}
+
+// verifyDID 由 registrar 核验 controllerID 对 did 的控制权
+func (e *testEnv) verifyDID(did, controllerID string) {
+	e.t.Helper()
+	e.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.VerifyDIDControl(ctx, did, controllerID)
+	})
+}
+
+func TestCheckAccessMatchesControlledDID(t *testing.T) {
+	env := newTestEnv(t)
+	patientDID := "did:web:partner.example:patients:42"
+	nurseDID := "did:fabric:org2:nurse1"
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, patientDID, strings.Repeat("d", 64))
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, nurseDID, strings.Repeat("d", 64))
+	})
+	env.verifyDID(patientDID, patient.id)
+	env.verifyDID(nurseDID, nurse.id)
+
+	// 合作网络以 DID 标识患者
+	env.createRecord(doctor, "rec1", patientDID)
+	if !env.checkAccess("rec1", patient.id) {
+		t.Fatal("DID controller must be treated as the patient")
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("nurse must not have access before grant")
+	}
+	env.grant(patient, "rec1", nurseDID, "read", "")
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("grant to a DID must apply to its controller")
+	}
+	if env.checkAccess("rec1", other.id) {
+		t.Fatal("unrelated identity must not have access")
+	}
+}
+
+func TestUnverifiedDIDClaimGrantsNothing(t *testing.T) {
+	env := newTestEnv(t)
+	patientDID := "did:web:partner.example:alice"
+	mallory := newIdentity("mallory1", "Org3MSP")
+	env.createRecord(doctor, "rec1", patientDID)
+
+	// 抢先登记只是声明，不能据此读取患者记录
+	env.mustInvoke(mallory, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDID(ctx, patientDID, strings.Repeat("d", 64))
+	})
+	if env.checkAccess("rec1", mallory.id) {
+		t.Fatal("an unverified DID claim must not count as the patient")
+	}
+	env.mustFail(mallory, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustFail(mallory, "only admin or a registrar", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.VerifyDIDControl(ctx, patientDID, mallory.id)
+	})
+
+	// registrar 核验后把声明改判给真正的控制者
+	env.verifyDID(patientDID, patient.id)
+	env.expectEvent("DIDVerified", nil)
+	if !env.checkAccess("rec1", patient.id) || env.checkAccess("rec1", mallory.id) {
+		t.Fatal("only the verified controller acts as the DID")
+	}
+	env.mustFail(registrar, "already verified", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.VerifyDIDControl(ctx, patientDID, mallory.id)
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const directiveIndex = "directive"
+
+var directiveTypes = []string{"dnr", "polst", "living-will", "healthcare-proxy"}
+
+// AdvanceDirective 预立医嘱登记；文档本身在链下，链上只存哈希
+type AdvanceDirective struct {
+	PatientID     string   `json:"patientId"`
+	DirectiveType string   `json:"directiveType"`
+	DocumentHash  string   `json:"documentHash"`
+	Witnesses     []string `json:"witnesses"`
+	RegisteredAt  string   `json:"registeredAt"`
+	RegisteredBy  string   `json:"registeredBy"`
+}
+
+// DirectiveStatus DirectiveExists 的返回值，不含文档哈希与见证人
+type DirectiveStatus struct {
+	PatientID     string `json:"patientId"`
+	DirectiveType string `json:"directiveType"`
+	Exists        bool   `json:"exists"`
+	RegisteredAt  string `json:"registeredAt,omitempty"`
+}
+
+type AdvanceDirectiveRegisteredEvent struct {
+	PatientID     string `json:"patientId"`
+	DirectiveType string `json:"directiveType"`
+	Timestamp     string `json:"timestamp"`
+	CallerID      string `json:"callerId"`
+	EventType     string `json:"eventType"`
+}
+
+func getAdvanceDirective(ctx contractapi.TransactionContextInterface, patientID, directiveType string) (*AdvanceDirective, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(directiveIndex, []string{patientID, directiveType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directive key: %w", err)
+	}
+	var directive AdvanceDirective
+	found, err := getJSON(ctx, key, &directive)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &directive, nil
+}
+
+// parseWitnesses 解析见证人列表：至少两名不同见证人，且不含患者本人
+func parseWitnesses(witnessesJson, patientID string) ([]string, error) {
+	var witnesses []string
+	if err := unmarshalArg(witnessesJson, &witnesses); err != nil {
+		return nil, fmt.Errorf("invalid witnesses json: %w", err)
+	}
+	seen := make(map[string]bool, len(witnesses))
+	for _, witness := range witnesses {
+		if err := validateAddress(witness); err != nil {
+			return nil, fmt.Errorf("invalid witness: %w", err)
+		}
+		if witness == patientID {
+			return nil, fmt.Errorf("patient cannot be their own witness")
+		}
+		seen[witness] = true
+	}
+	if len(seen) < 2 {
+		return nil, fmt.Errorf("at least two distinct witnesses are required")
+	}
+	return witnesses, nil
+}
+
+// RegisterAdvanceDirective 患者登记预立医嘱，至少两名见证人；同类型重新登记覆盖旧版本
+func (s *SmartContract) RegisterAdvanceDirective(ctx contractapi.TransactionContextInterface, patientID, directiveType, documentHash, witnessesJson string) error {
+	if !containsString(directiveTypes, directiveType) {
+		return fmt.Errorf("invalid directiveType: %s", directiveType)
+	}
+	if !sha256HexPattern.MatchString(documentHash) {
+		return fmt.Errorf("invalid documentHash: expected hex-encoded SHA-256")
+	}
+	witnesses, err := parseWitnesses(witnessesJson, patientID)
+	if err != nil {
+		return err
+	}
+
+	callerID, err := requirePatient(ctx, patientID, "register advance directives")
+	if err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(directiveIndex, []string{patientID, directiveType})
+	if err != nil {
+		return fmt.Errorf("failed to create directive key: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	directive := AdvanceDirective{
+		PatientID:     patientID,
+		DirectiveType: directiveType,
+		DocumentHash:  documentHash,
+		Witnesses:     witnesses,
+		RegisteredAt:  now,
+		RegisteredBy:  callerID,
+	}
+	if err := putJSON(ctx, key, directive); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AdvanceDirectiveRegistered", AdvanceDirectiveRegisteredEvent{
+		PatientID:     patientID,
+		DirectiveType: directiveType,
+		Timestamp:     now,
+		CallerID:      callerID,
+		EventType:     "AdvanceDirectiveRegistered",
+	})
+}
+
+// DirectiveExists 只返回是否存在与登记时间；患者本人与任何 doctor/nurse 角色可查，急救时无需授权
+func (s *SmartContract) DirectiveExists(ctx contractapi.TransactionContextInterface, patientID, directiveType string) (*DirectiveStatus, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		if !isClinician {
+			return nil, fmt.Errorf("access denied: clinician role required")
+		}
+	}
+	directive, err := getAdvanceDirective(ctx, patientID, directiveType)
+	if err != nil {
+		return nil, err
+	}
+	status := &DirectiveStatus{PatientID: patientID, DirectiveType: directiveType, Exists: directive != nil}
+	if directive != nil {
+		status.RegisteredAt = directive.RegisteredAt
+	}
+	return status, nil
+}
+
+// GetAdvanceDirective 返回文档哈希与见证人；患者本人，或对患者任一记录通过 CheckAccess 的身份可读
+func (s *SmartContract) GetAdvanceDirective(ctx contractapi.TransactionContextInterface, patientID, directiveType string) (*AdvanceDirective, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		allowed, err := anyPatientRecord(ctx, patientID, func(recordID string) (bool, error) {
+			return s.CheckAccess(ctx, recordID, callerID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("access denied: %s cannot view directives of %s", callerID, patientID)
+		}
+	}
+	directive, err := getAdvanceDirective(ctx, patientID, directiveType)
+	if err != nil {
+		return nil, err
+	}
+	if directive == nil {
+		return nil, fmt.Errorf("advance directive not found: %s", directiveType)
+	}
+	return directive, nil
+}
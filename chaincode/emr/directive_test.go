@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestAdvanceDirectiveExistenceAndDocumentAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	hash := strings.Repeat("f", 64)
+	paramedic := newIdentity("medic1", "EmsMSP", "role", "nurse")
+
+	env.mustFail(patient, "two distinct witnesses", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterAdvanceDirective(ctx, patient.id, "dnr", hash, `["w1","w1"]`)
+	})
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterAdvanceDirective(ctx, patient.id, "dnr", hash, `["w1","w2"]`)
+	})
+
+	exists := func(id *testIdentity) bool {
+		var status *DirectiveStatus
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			status, err = env.cc.DirectiveExists(ctx, patient.id, "dnr")
+			return err
+		})
+		return status.Exists
+	}
+	if exists(paramedic) {
+		t.Fatal("directive must not exist before registration")
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterAdvanceDirective(ctx, patient.id, "dnr", hash, `["w1","w2"]`)
+	})
+	env.expectEvent("AdvanceDirectiveRegistered", nil)
+
+	// 急救人员无授权也能确认 DNR 存在，但读不到文档
+	if !exists(paramedic) {
+		t.Fatal("clinician must see that the directive exists")
+	}
+	env.mustFail(paramedic, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAdvanceDirective(ctx, patient.id, "dnr")
+		return err
+	})
+	env.mustFail(other, "clinician role required", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.DirectiveExists(ctx, patient.id, "dnr")
+		return err
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		directive, err := env.cc.GetAdvanceDirective(ctx, patient.id, "dnr")
+		if err == nil && directive.DocumentHash != hash {
+			t.Fatalf("unexpected directive: %+v", directive)
+		}
+		return err
+	})
+}
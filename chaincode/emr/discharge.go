@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DischargedGrant 出院时撤销的一条就诊授权
+type DischargedGrant struct {
+	RecordID  string `json:"recordId"`
+	GranteeID string `json:"granteeId"`
+}
+
+// Discharge 出院登记；RevokedGrants 为本次撤销的就诊授权，TxID 为出院交易，供审计关联出院与撤销
+type Discharge struct {
+	PatientID     string            `json:"patientId"`
+	EncounterID   string            `json:"encounterId"`
+	DischargedAt  string            `json:"dischargedAt"`
+	DischargedBy  string            `json:"dischargedBy"`
+	TxID          string            `json:"txId"`
+	RevokedGrants []DischargedGrant `json:"revokedGrants"`
+}
+
+type DischargeRecordedEvent struct {
+	PatientID     string            `json:"patientId"`
+	EncounterID   string            `json:"encounterId"`
+	RevokedCount  int               `json:"revokedCount"`
+	RevokedGrants []DischargedGrant `json:"revokedGrants"`
+	Timestamp     string            `json:"timestamp"`
+	CallerID      string            `json:"callerId"`
+	EventType     string            `json:"eventType"`
+}
+
+// dischargeKey discharge:{patientId}:{encounterId}
+func dischargeKey(patientID, encounterID string) string {
+	return "discharge:" + keySegment(patientID) + ":" + keySegment(encounterID)
+}
+
+// encounterGrantKey encounter-grant:{patientId}:{encounterId}:{recordId}:{granteeId}，由 GrantEncounterAccess 写入，出院时删除
+func encounterGrantKey(patientID, encounterID, recordID, granteeID string) string {
+	return encounterGrantPrefix(patientID, encounterID) + keySegment(recordID) + ":" + keySegment(granteeID)
+}
+
+func encounterGrantPrefix(patientID, encounterID string) string {
+	return "encounter-grant:" + keySegment(patientID) + ":" + keySegment(encounterID) + ":"
+}
+
+// requireEncounterOpen 已出院的就诊不能再绑定授权
+func requireEncounterOpen(ctx contractapi.TransactionContextInterface, patientID, encounterID string) error {
+	if err := validateAddress(encounterID); err != nil {
+		return fmt.Errorf("invalid encounterID: %w", err)
+	}
+	discharged, err := assetExists(ctx, dischargeKey(patientID, encounterID))
+	if err != nil {
+		return err
+	}
+	if discharged {
+		return fmt.Errorf("encounter %s of patient %s is already discharged", encounterID, patientID)
+	}
+	return nil
+}
+
+// GrantEncounterAccess 与 GrantAccessWithExpiry 相同，但授权绑定患者的一次住院就诊，RecordDischarge 时自动撤销
+func (s *SmartContract) GrantEncounterAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt, encounterID string) error {
+	return grantAccess(ctx, recordID, granteeID, action, expiresAt, 0, grantScope{encounterID: encounterID})
+}
+
+// RecordDischarge 机构 admin 登记出院，撤销绑定该就诊且仍有效的全部授权；每次就诊只能登记一次
+func (s *SmartContract) RecordDischarge(ctx contractapi.TransactionContextInterface, patientID, encounterID string) (*Discharge, error) {
+	if err := validateAddress(patientID); err != nil {
+		return nil, fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := requireEncounterOpen(ctx, patientID, encounterID); err != nil {
+		return nil, err
+	}
+	callerID, _, err := requireAdminCaller(ctx, "record discharges")
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := now.Format(time.RFC3339)
+
+	prefix := encounterGrantPrefix(patientID, encounterID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan encounter grants: %w", err)
+	}
+	defer iterator.Close()
+
+	discharge := &Discharge{
+		PatientID:     patientID,
+		EncounterID:   encounterID,
+		DischargedAt:  timestamp,
+		DischargedBy:  callerID,
+		TxID:          ctx.GetStub().GetTxID(),
+		RevokedGrants: []DischargedGrant{},
+	}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate encounter grants: %w", err)
+		}
+		var grant DischargedGrant
+		if err := json.Unmarshal(kv.Value, &grant); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal encounter grant: %w", err)
+		}
+		if err := ctx.GetStub().DelState(kv.Key); err != nil {
+			return nil, fmt.Errorf("failed to delete encounter grant: %w", err)
+		}
+		// 之后被其他授权替换或已失效的不计入
+		perm, err := getPermission(ctx, grant.RecordID, grant.GranteeID)
+		if err != nil {
+			return nil, err
+		}
+		if perm == nil || perm.EncounterID != encounterID || !permissionActive(*perm, now) {
+			continue
+		}
+		if _, err := deactivateGrant(ctx, grant.RecordID, grant.GranteeID, timestamp); err != nil {
+			return nil, err
+		}
+		discharge.RevokedGrants = append(discharge.RevokedGrants, grant)
+	}
+	if err := putJSON(ctx, dischargeKey(patientID, encounterID), discharge); err != nil {
+		return nil, err
+	}
+	return discharge, emitEvent(ctx, "DischargeRecorded", DischargeRecordedEvent{
+		PatientID:     patientID,
+		EncounterID:   encounterID,
+		RevokedCount:  len(discharge.RevokedGrants),
+		RevokedGrants: discharge.RevokedGrants,
+		Timestamp:     timestamp,
+		CallerID:      callerID,
+		EventType:     "DischargeRecorded",
+	})
+}
+
+// GetDischarge 查询出院登记及其撤销的授权；限患者、有 consent 范围的代理人与审计员
+func (s *SmartContract) GetDischarge(ctx contractapi.TransactionContextInterface, patientID, encounterID string) (*Discharge, error) {
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if _, err := requirePatientOrAgent(ctx, patientID, "consent", "view discharges"); err != nil {
+			return nil, err
+		}
+	}
+	var discharge Discharge
+	found, err := getJSON(ctx, dischargeKey(patientID, encounterID), &discharge)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no discharge recorded for encounter %s of patient %s", encounterID, patientID)
+	}
+	return &discharge, nil
+}
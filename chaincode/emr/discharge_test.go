@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestDischargeRevokesEncounterGrants(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	grantEncounter := func(recordID, granteeID, encounterID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.GrantEncounterAccess(ctx, recordID, granteeID, "read", "", encounterID)
+		}
+	}
+	env.mustInvoke(patient, grantEncounter("rec1", nurse.id, "enc1"))
+	env.mustInvoke(patient, grantEncounter("rec2", nurse.id, "enc1"))
+	env.mustInvoke(patient, grantEncounter("rec1", specialist.id, "enc2"))
+	// 被普通授权替换的就诊授权不随出院撤销
+	env.mustInvoke(patient, grantEncounter("rec2", specialist.id, "enc1"))
+	env.grant(patient, "rec2", specialist.id, "read", "")
+
+	discharge := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.RecordDischarge(ctx, patient.id, "enc1")
+		return err
+	}
+	env.mustFail(doctor, "only admin", discharge)
+	env.mustInvoke(admin, discharge)
+	var event DischargeRecordedEvent
+	env.expectEvent("DischargeRecorded", &event)
+	if event.RevokedCount != 2 || event.EncounterID != "enc1" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if env.checkAccess("rec1", nurse.id) || env.checkAccess("rec2", nurse.id) {
+		t.Fatal("encounter grants must be revoked on discharge")
+	}
+	if !env.checkAccess("rec1", specialist.id) || !env.checkAccess("rec2", specialist.id) {
+		t.Fatal("grants of other encounters and replaced grants must survive")
+	}
+	env.mustFail(admin, "already discharged", discharge)
+	env.mustFail(patient, "already discharged", grantEncounter("rec1", other.id, "enc1"))
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.GetDischarge(ctx, patient.id, "enc1")
+		if err == nil && (len(record.RevokedGrants) != 2 || record.DischargedBy != admin.id || record.TxID == "") {
+			t.Fatalf("unexpected discharge: %+v", record)
+		}
+		return err
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDischarge(ctx, patient.id, "enc1")
+		return err
+	})
+}
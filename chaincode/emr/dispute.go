@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	DisputeOpen     = "open"
+	DisputeResolved = "resolved"
+)
+
+var (
+	disputeReasonCodes = []string{"inaccurate", "incomplete", "wrong-patient", "unauthorized-entry", "other"}
+	// disputeResolutions 创建者以 amended（已更正）或 upheld（维持原记录）解决，患者本人可 withdrawn 撤回
+	disputeResolutions = []string{"amended", "upheld", "withdrawn"}
+)
+
+// RecordDispute 患者对记录内容的正式异议；DetailsHash 为线下留存的异议说明的 sha256，
+// 争议未解决期间 GetRecordMetadata 的 disputed 为 true
+type RecordDispute struct {
+	DisputeID   string `json:"disputeId"`
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	ReasonCode  string `json:"reasonCode"`
+	DetailsHash string `json:"detailsHash"`
+	Status      string `json:"status"`
+	OpenedBy    string `json:"openedBy"`
+	OpenedAt    string `json:"openedAt"`
+	Resolution  string `json:"resolution,omitempty"`
+	NoteHash    string `json:"noteHash,omitempty"`
+	ResolvedBy  string `json:"resolvedBy,omitempty"`
+	ResolvedAt  string `json:"resolvedAt,omitempty"`
+}
+
+type RecordDisputeEvent struct {
+	DisputeID  string `json:"disputeId"`
+	RecordID   string `json:"recordId"`
+	PatientID  string `json:"patientId"`
+	ReasonCode string `json:"reasonCode"`
+	Status     string `json:"status"`
+	Resolution string `json:"resolution,omitempty"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func disputeKey(disputeID string) string {
+	return "dispute:" + disputeID
+}
+
+// openDisputesKey dispute-open:{recordId}，值为该记录未解决的争议 ID 列表
+func openDisputesKey(recordID string) string {
+	return "dispute-open:" + keySegment(recordID)
+}
+
+func getOpenDisputes(ctx contractapi.TransactionContextInterface, recordID string) ([]string, error) {
+	disputeIDs := []string{}
+	if _, err := getJSON(ctx, openDisputesKey(recordID), &disputeIDs); err != nil {
+		return nil, err
+	}
+	return disputeIDs, nil
+}
+
+func putOpenDisputes(ctx contractapi.TransactionContextInterface, recordID string, disputeIDs []string) error {
+	if len(disputeIDs) == 0 {
+		if err := ctx.GetStub().DelState(openDisputesKey(recordID)); err != nil {
+			return fmt.Errorf("failed to delete open disputes: %w", err)
+		}
+		return nil
+	}
+	return putJSON(ctx, openDisputesKey(recordID), disputeIDs)
+}
+
+func getDispute(ctx contractapi.TransactionContextInterface, disputeID string) (*RecordDispute, error) {
+	var dispute RecordDispute
+	found, err := getJSON(ctx, disputeKey(disputeID), &dispute)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("dispute not found: %s", disputeID)
+	}
+	return &dispute, nil
+}
+
+func emitDisputeEvent(ctx contractapi.TransactionContextInterface, name string, dispute *RecordDispute, timestamp, callerID string) error {
+	return emitEvent(ctx, name, RecordDisputeEvent{
+		DisputeID:  dispute.DisputeID,
+		RecordID:   dispute.RecordID,
+		PatientID:  dispute.PatientID,
+		ReasonCode: dispute.ReasonCode,
+		Status:     dispute.Status,
+		Resolution: dispute.Resolution,
+		Timestamp:  timestamp,
+		CallerID:   callerID,
+		EventType:  name,
+	})
+}
+
+// DisputeRecord 患者本人对记录内容提出异议，返回争议 ID（本交易 ID）；同一记录可有多项未解决的争议
+func (s *SmartContract) DisputeRecord(ctx contractapi.TransactionContextInterface, recordID, reasonCode, detailsHash string) (string, error) {
+	if !containsString(disputeReasonCodes, reasonCode) {
+		return "", fmt.Errorf("invalid reasonCode %q: must be one of %v", reasonCode, disputeReasonCodes)
+	}
+	if !sha256HexPattern.MatchString(detailsHash) {
+		return "", fmt.Errorf("detailsHash must be a lowercase hex sha256 digest")
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	isPatient, err := callerIs(ctx, record.PatientID)
+	if err != nil {
+		return "", err
+	}
+	if !isPatient {
+		return "", fmt.Errorf("access denied: only the patient can dispute record %s", recordID)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	dispute := &RecordDispute{
+		DisputeID:   ctx.GetStub().GetTxID(),
+		RecordID:    recordID,
+		PatientID:   record.PatientID,
+		ReasonCode:  reasonCode,
+		DetailsHash: detailsHash,
+		Status:      DisputeOpen,
+		OpenedBy:    callerID,
+		OpenedAt:    now,
+	}
+	if err := putJSON(ctx, disputeKey(dispute.DisputeID), dispute); err != nil {
+		return "", err
+	}
+	open, err := getOpenDisputes(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	if err := putOpenDisputes(ctx, recordID, append(open, dispute.DisputeID)); err != nil {
+		return "", err
+	}
+	if err := emitDisputeEvent(ctx, "RecordDisputed", dispute, now, callerID); err != nil {
+		return "", err
+	}
+	return dispute.DisputeID, nil
+}
+
+// ResolveDispute 记录创建者以 amended/upheld 解决争议，患者本人可以 withdrawn 撤回；
+// noteHash 为线下留存的处理说明的 sha256，创建者解决时必填
+func (s *SmartContract) ResolveDispute(ctx contractapi.TransactionContextInterface, disputeID, resolution, noteHash string) error {
+	if !containsString(disputeResolutions, resolution) {
+		return fmt.Errorf("invalid resolution %q: must be one of %v", resolution, disputeResolutions)
+	}
+	if noteHash != "" && !sha256HexPattern.MatchString(noteHash) {
+		return fmt.Errorf("noteHash must be a lowercase hex sha256 digest")
+	}
+	dispute, err := getDispute(ctx, disputeID)
+	if err != nil {
+		return err
+	}
+	if dispute.Status != DisputeOpen {
+		return fmt.Errorf("dispute %s is %s", disputeID, dispute.Status)
+	}
+	record, err := getRecord(ctx, dispute.RecordID)
+	if err != nil {
+		return err
+	}
+	if resolution == "withdrawn" {
+		isPatient, err := callerIs(ctx, record.PatientID)
+		if err != nil {
+			return err
+		}
+		if !isPatient {
+			return fmt.Errorf("access denied: only the patient can withdraw a dispute")
+		}
+	} else {
+		isCreator, err := callerIs(ctx, record.CreatorID)
+		if err != nil {
+			return err
+		}
+		if !isCreator {
+			return fmt.Errorf("access denied: only the record creator can resolve a dispute")
+		}
+		if noteHash == "" {
+			return fmt.Errorf("noteHash is required to resolve a dispute")
+		}
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	dispute.Status = DisputeResolved
+	dispute.Resolution = resolution
+	dispute.NoteHash = noteHash
+	dispute.ResolvedBy = callerID
+	dispute.ResolvedAt = now.Format(time.RFC3339)
+	if err := putJSON(ctx, disputeKey(disputeID), dispute); err != nil {
+		return err
+	}
+	open, err := getOpenDisputes(ctx, dispute.RecordID)
+	if err != nil {
+		return err
+	}
+	remaining := []string{}
+	for _, id := range open {
+		if id != disputeID {
+			remaining = append(remaining, id)
+		}
+	}
+	if err := putOpenDisputes(ctx, dispute.RecordID, remaining); err != nil {
+		return err
+	}
+	return emitDisputeEvent(ctx, "DisputeResolved", dispute, dispute.ResolvedAt, callerID)
+}
+
+// GetDispute 有记录访问权的身份可查询争议
+func (s *SmartContract) GetDispute(ctx contractapi.TransactionContextInterface, disputeID string) (*RecordDispute, error) {
+	dispute, err := getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRecordAccess(ctx, dispute.RecordID); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
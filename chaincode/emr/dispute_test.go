@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) metadata(identity *testIdentity, recordID string) *RecordMetadata {
+	e.t.Helper()
+	var metadata *RecordMetadata
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		metadata, err = e.cc.GetRecordMetadata(ctx, recordID)
+		return err
+	})
+	return metadata
+}
+
+func (e *testEnv) dispute(identity *testIdentity, recordID, reasonCode string) string {
+	e.t.Helper()
+	var disputeID string
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		disputeID, err = e.cc.DisputeRecord(ctx, recordID, reasonCode, strings.Repeat("ab", 32))
+		return err
+	})
+	return disputeID
+}
+
+func TestDisputeRecord(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	details := strings.Repeat("ab", 32)
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.DisputeRecord(ctx, "rec1", "inaccurate", details)
+		return err
+	})
+	env.mustFail(patient, "invalid reasonCode", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.DisputeRecord(ctx, "rec1", "dislike", details)
+		return err
+	})
+	if metadata := env.metadata(auditor, "rec1"); metadata.Disputed {
+		t.Fatalf("an undisputed record must not be flagged: %+v", metadata)
+	}
+
+	first := env.dispute(patient, "rec1", "inaccurate")
+	var disputed RecordDisputeEvent
+	env.expectEvent("RecordDisputed", &disputed)
+	if disputed.DisputeID != first || disputed.Status != DisputeOpen || disputed.ReasonCode != "inaccurate" {
+		t.Fatalf("unexpected event: %+v", disputed)
+	}
+	second := env.dispute(patient, "rec1", "incomplete")
+	if metadata := env.metadata(doctor, "rec1"); !metadata.Disputed || len(metadata.OpenDisputes) != 2 {
+		t.Fatalf("disputed record must be flagged: %+v", metadata)
+	}
+
+	note := strings.Repeat("cd", 32)
+	resolve := func(disputeID, resolution, noteHash string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.ResolveDispute(ctx, disputeID, resolution, noteHash)
+		}
+	}
+	env.mustFail(patient, "only the record creator", resolve(first, "upheld", note))
+	env.mustFail(doctor, "only the patient can withdraw", resolve(first, "withdrawn", ""))
+	env.mustFail(doctor, "noteHash is required", resolve(first, "upheld", ""))
+	env.mustInvoke(doctor, resolve(first, "amended", note))
+	var resolved RecordDisputeEvent
+	env.expectEvent("DisputeResolved", &resolved)
+	if resolved.Resolution != "amended" || resolved.Status != DisputeResolved {
+		t.Fatalf("unexpected event: %+v", resolved)
+	}
+	env.mustFail(doctor, "is resolved", resolve(first, "upheld", note))
+	if metadata := env.metadata(patient, "rec1"); !metadata.Disputed || len(metadata.OpenDisputes) != 1 || metadata.OpenDisputes[0] != second {
+		t.Fatalf("remaining dispute must keep the flag: %+v", metadata)
+	}
+
+	env.mustInvoke(patient, resolve(second, "withdrawn", ""))
+	if metadata := env.metadata(patient, "rec1"); metadata.Disputed || len(metadata.OpenDisputes) != 0 {
+		t.Fatalf("resolved disputes must clear the flag: %+v", metadata)
+	}
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		dispute, err := env.cc.GetDispute(ctx, first)
+		if err == nil && (dispute.NoteHash != note || dispute.ResolvedBy != doctor.id || dispute.DetailsHash != details) {
+			t.Fatalf("unexpected dispute: %+v", dispute)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDispute(ctx, first)
+		return err
+	})
+}
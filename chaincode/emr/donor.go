@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	transplantCoordinatorRole = "transplant-coordinator"
+
+	DonorRegistered = "donor"
+	DonorDeclined   = "non-donor"
+	DonorRevoked    = "revoked"
+)
+
+// donorScopes 可捐献的器官与组织；all 表示不限
+var donorScopes = []string{"all", "heart", "lungs", "liver", "kidneys", "pancreas", "intestines", "corneas", "tissue"}
+
+// DonorStatus 器官捐献登记的当前状态；每次变更同时写入历史
+type DonorStatus struct {
+	PatientID string   `json:"patientId"`
+	Status    string   `json:"status"`
+	Scope     []string `json:"scope,omitempty"`
+	Witnesses []string `json:"witnesses,omitempty"`
+	UpdatedAt string   `json:"updatedAt"`
+	UpdatedBy string   `json:"updatedBy"`
+	TxID      string   `json:"txId"`
+}
+
+// DonorStatusLookup GetDonorStatus 的返回值，不含见证人
+type DonorStatusLookup struct {
+	PatientID  string   `json:"patientId"`
+	Registered bool     `json:"registered"`
+	Status     string   `json:"status,omitempty"`
+	Scope      []string `json:"scope,omitempty"`
+	UpdatedAt  string   `json:"updatedAt,omitempty"`
+}
+
+type DonorStatusChangedEvent struct {
+	PatientID string   `json:"patientId"`
+	Status    string   `json:"status"`
+	Scope     []string `json:"scope,omitempty"`
+	Timestamp string   `json:"timestamp"`
+	CallerID  string   `json:"callerId"`
+	EventType string   `json:"eventType"`
+}
+
+func donorKey(patientID string) string {
+	return "donor:" + keySegment(patientID)
+}
+
+// donorHistoryKey donor-history:{patientId}:{epoch}:{txId}
+func donorHistoryKey(patientID string, at time.Time, txID string) string {
+	return donorHistoryPrefix(patientID) + epochSegment(at) + ":" + txID
+}
+
+func donorHistoryPrefix(patientID string) string {
+	return "donor-history:" + keySegment(patientID) + ":"
+}
+
+// putDonorStatus 写入当前状态与一条历史
+func putDonorStatus(ctx contractapi.TransactionContextInterface, status *DonorStatus, now time.Time) error {
+	if err := putJSON(ctx, donorKey(status.PatientID), status); err != nil {
+		return err
+	}
+	if err := putJSON(ctx, donorHistoryKey(status.PatientID, now, status.TxID), status); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "DonorStatusChanged", DonorStatusChangedEvent{
+		PatientID: status.PatientID,
+		Status:    status.Status,
+		Scope:     status.Scope,
+		Timestamp: status.UpdatedAt,
+		CallerID:  status.UpdatedBy,
+		EventType: "DonorStatusChanged",
+	})
+}
+
+// RegisterDonorStatus 患者登记捐献意愿，至少两名见证人；status 为 donor 或 non-donor，
+// donor 时 scopeJson 为器官列表，non-donor 时须为空。重新登记覆盖当前状态
+func (s *SmartContract) RegisterDonorStatus(ctx contractapi.TransactionContextInterface, patientID, status, scopeJson, witnessesJson string) error {
+	if status != DonorRegistered && status != DonorDeclined {
+		return fmt.Errorf("invalid status: %s", status)
+	}
+	var scope []string
+	if scopeJson != "" {
+		if err := unmarshalArg(scopeJson, &scope); err != nil {
+			return fmt.Errorf("invalid scope json: %w", err)
+		}
+	}
+	if status == DonorRegistered && len(scope) == 0 {
+		return fmt.Errorf("scope is required for donor registrations")
+	}
+	if status == DonorDeclined && len(scope) != 0 {
+		return fmt.Errorf("scope must be empty for non-donor registrations")
+	}
+	for _, organ := range scope {
+		if !containsString(donorScopes, organ) {
+			return fmt.Errorf("invalid scope: %s", organ)
+		}
+	}
+	witnesses, err := parseWitnesses(witnessesJson, patientID)
+	if err != nil {
+		return err
+	}
+	callerID, err := requirePatient(ctx, patientID, "register donor status")
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	return putDonorStatus(ctx, &DonorStatus{
+		PatientID: patientID,
+		Status:    status,
+		Scope:     scope,
+		Witnesses: witnesses,
+		UpdatedAt: now.Format(time.RFC3339),
+		UpdatedBy: callerID,
+		TxID:      ctx.GetStub().GetTxID(),
+	}, now)
+}
+
+// RevokeDonorStatus 患者撤销登记；撤销后查询返回 revoked，历史保留
+func (s *SmartContract) RevokeDonorStatus(ctx contractapi.TransactionContextInterface, patientID string) error {
+	callerID, err := requirePatient(ctx, patientID, "revoke donor status")
+	if err != nil {
+		return err
+	}
+	var current DonorStatus
+	found, err := getJSON(ctx, donorKey(patientID), &current)
+	if err != nil {
+		return err
+	}
+	if !found || current.Status == DonorRevoked {
+		return fmt.Errorf("no donor status registered for %s", patientID)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	return putDonorStatus(ctx, &DonorStatus{
+		PatientID: patientID,
+		Status:    DonorRevoked,
+		UpdatedAt: now.Format(time.RFC3339),
+		UpdatedBy: callerID,
+		TxID:      ctx.GetStub().GetTxID(),
+	}, now)
+}
+
+// requireDonorReader transplant-coordinator 角色或患者本人；先查证书角色，协调员查询不读身份绑定
+func requireDonorReader(ctx contractapi.TransactionContextInterface, patientID string) error {
+	isCoordinator, err := hasRole(ctx, transplantCoordinatorRole)
+	if err != nil || isCoordinator {
+		return err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if !isPatient {
+		return fmt.Errorf("access denied: %s role required", transplantCoordinatorRole)
+	}
+	return nil
+}
+
+// GetDonorStatus 单次读取当前状态，供移植协调员快速确认；未登记时 registered 为 false
+func (s *SmartContract) GetDonorStatus(ctx contractapi.TransactionContextInterface, patientID string) (*DonorStatusLookup, error) {
+	if err := requireDonorReader(ctx, patientID); err != nil {
+		return nil, err
+	}
+	var current DonorStatus
+	found, err := getJSON(ctx, donorKey(patientID), &current)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &DonorStatusLookup{PatientID: patientID, Registered: found}
+	if found {
+		lookup.Status = current.Status
+		lookup.Scope = current.Scope
+		lookup.UpdatedAt = current.UpdatedAt
+	}
+	return lookup, nil
+}
+
+// GetDonorStatusHistory 按时间顺序返回全部变更；患者本人、移植协调员与审计员可查
+func (s *SmartContract) GetDonorStatusHistory(ctx contractapi.TransactionContextInterface, patientID string) ([]*DonorStatus, error) {
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if err := requireDonorReader(ctx, patientID); err != nil {
+			return nil, err
+		}
+	}
+	prefix := donorHistoryPrefix(patientID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan donor history: %w", err)
+	}
+	defer iterator.Close()
+
+	history := []*DonorStatus{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate donor history: %w", err)
+		}
+		var entry DonorStatus
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal donor status: %w", err)
+		}
+		history = append(history, &entry)
+	}
+	return history, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var transplantCoordinator = newIdentity("coordinator1", "OpoMSP", "role", transplantCoordinatorRole)
+
+func TestDonorStatusRegistrationAndRevocation(t *testing.T) {
+	env := newTestEnv(t)
+	register := func(status, scope string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.RegisterDonorStatus(ctx, patient.id, status, scope, `["w1","w2"]`)
+		}
+	}
+	lookup := func(identity *testIdentity) *DonorStatusLookup {
+		var result *DonorStatusLookup
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.cc.GetDonorStatus(ctx, patient.id)
+			return err
+		})
+		return result
+	}
+
+	env.mustFail(doctor, "only the patient", register(DonorRegistered, `["all"]`))
+	env.mustFail(patient, "scope is required", register(DonorRegistered, ""))
+	env.mustFail(patient, "invalid scope", register(DonorRegistered, `["spleen"]`))
+	env.mustFail(patient, "scope must be empty", register(DonorDeclined, `["heart"]`))
+	env.mustFail(patient, "two distinct witnesses", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDonorStatus(ctx, patient.id, DonorRegistered, `["all"]`, `["w1"]`)
+	})
+	if lookup(transplantCoordinator).Registered {
+		t.Fatal("nothing registered yet")
+	}
+
+	env.mustInvoke(patient, register(DonorRegistered, `["kidneys","corneas"]`))
+	var event DonorStatusChangedEvent
+	env.expectEvent("DonorStatusChanged", &event)
+	if event.Status != DonorRegistered {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if result := lookup(transplantCoordinator); result.Status != DonorRegistered || len(result.Scope) != 2 {
+		t.Fatalf("unexpected lookup: %+v", result)
+	}
+	// 临床角色不等于移植协调员
+	env.mustFail(doctor, "transplant-coordinator role required", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDonorStatus(ctx, patient.id)
+		return err
+	})
+
+	env.advance(time.Hour)
+	env.mustFail(transplantCoordinator, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeDonorStatus(ctx, patient.id)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeDonorStatus(ctx, patient.id)
+	})
+	if result := lookup(patient); !result.Registered || result.Status != DonorRevoked {
+		t.Fatalf("revocation must be visible: %+v", result)
+	}
+	env.mustFail(patient, "no donor status registered", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeDonorStatus(ctx, patient.id)
+	})
+
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		history, err := env.cc.GetDonorStatusHistory(ctx, patient.id)
+		if err == nil && (len(history) != 2 || history[0].Status != DonorRegistered || history[1].Status != DonorRevoked) {
+			t.Fatalf("unexpected history: %+v", history)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDonorStatusHistory(ctx, patient.id)
+		return err
+	})
+}
+
+func TestDonorStatusLookupSingleRead(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDonorStatus(ctx, patient.id, DonorDeclined, "", `["w1","w2"]`)
+	})
+	before := env.stub.reads
+	env.mustInvoke(transplantCoordinator, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetDonorStatus(ctx, patient.id)
+		return err
+	})
+	if reads := env.stub.reads - before; reads != 1 {
+		t.Fatalf("donor lookup must be a single read, got %d", reads)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DenialNoDPA 以 research 目的读取时调用者机构没有有效 DPA
+const DenialNoDPA = "no valid data processing agreement"
+
+// DataProcessingAgreement 机构的数据处理协议登记；协议原文留在线下，链上只存哈希与有效期
+type DataProcessingAgreement struct {
+	MspID        string `json:"mspId"`
+	DPAHash      string `json:"dpaHash"`
+	ValidUntil   string `json:"validUntil"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+type DPARegisteredEvent struct {
+	MspID      string `json:"mspId"`
+	DPAHash    string `json:"dpaHash"`
+	ValidUntil string `json:"validUntil"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func dpaKey(mspID string) string {
+	return "dpa:" + mspID
+}
+
+// dpaValid 机构当前是否有有效 DPA
+func dpaValid(ctx contractapi.TransactionContextInterface, mspID string) (bool, error) {
+	var dpa DataProcessingAgreement
+	found, err := getJSON(ctx, dpaKey(mspID), &dpa)
+	if err != nil || !found {
+		return false, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	validUntil, err := time.Parse(time.RFC3339, dpa.ValidUntil)
+	if err != nil {
+		return false, fmt.Errorf("invalid stored validUntil: %w", err)
+	}
+	return now.Before(validUntil), nil
+}
+
+// researchCleared 以 research 目的读取时，调用者机构须有有效 DPA；其他目的不受限
+func researchCleared(ctx contractapi.TransactionContextInterface, purpose string) (bool, error) {
+	if purpose != "research" {
+		return true, nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	return dpaValid(ctx, mspID)
+}
+
+// RegisterDPA 登记或续签机构的 DPA，仅 admin；dpaHash 为协议文本的 sha256
+func (s *SmartContract) RegisterDPA(ctx contractapi.TransactionContextInterface, orgMSPID, dpaHash, validUntil string) error {
+	if err := validateAddress(orgMSPID); err != nil {
+		return fmt.Errorf("invalid orgMSPID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(dpaHash) {
+		return fmt.Errorf("dpaHash must be a lowercase hex sha256 digest")
+	}
+	until, err := time.Parse(time.RFC3339, validUntil)
+	if err != nil {
+		return fmt.Errorf("invalid validUntil: %w", err)
+	}
+	callerID, _, err := requireAdminCaller(ctx, "register data processing agreements")
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !until.After(now) {
+		return fmt.Errorf("validUntil must be in the future")
+	}
+	ts := now.Format(time.RFC3339)
+	dpa := DataProcessingAgreement{
+		MspID:        orgMSPID,
+		DPAHash:      dpaHash,
+		ValidUntil:   until.UTC().Format(time.RFC3339),
+		RegisteredBy: callerID,
+		RegisteredAt: ts,
+	}
+	if err := putJSON(ctx, dpaKey(orgMSPID), dpa); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "DPARegistered", DPARegisteredEvent{
+		MspID:      orgMSPID,
+		DPAHash:    dpaHash,
+		ValidUntil: dpa.ValidUntil,
+		Timestamp:  ts,
+		CallerID:   callerID,
+		EventType:  "DPARegistered",
+	})
+}
+
+// GetDPA 返回机构的 DPA 登记
+func (s *SmartContract) GetDPA(ctx contractapi.TransactionContextInterface, orgMSPID string) (*DataProcessingAgreement, error) {
+	var dpa DataProcessingAgreement
+	found, err := getJSON(ctx, dpaKey(orgMSPID), &dpa)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no data processing agreement registered for %s", orgMSPID)
+	}
+	return &dpa, nil
+}
+
+// ListExpiringDPAs 列出 withinDays 天内到期（含已过期）的 DPA，按到期时间排序；admin 或 auditor。
+// 登记按机构一条，数量有限，不分页
+func (s *SmartContract) ListExpiringDPAs(ctx contractapi.TransactionContextInterface, withinDays int) ([]*DataProcessingAgreement, error) {
+	if withinDays < 0 || withinDays > 366 {
+		return nil, fmt.Errorf("withinDays must be between 0 and 366")
+	}
+	allowed, err := hasAnyRole(ctx, "admin", auditorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only admin or auditor can list data processing agreements")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.AddDate(0, 0, withinDays)
+
+	iterator, err := ctx.GetStub().GetStateByRange("dpa:", "dpa;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan data processing agreements: %w", err)
+	}
+	defer iterator.Close()
+	expiring := []*DataProcessingAgreement{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate data processing agreements: %w", err)
+		}
+		var dpa DataProcessingAgreement
+		if err := json.Unmarshal(kv.Value, &dpa); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data processing agreement: %w", err)
+		}
+		validUntil, err := time.Parse(time.RFC3339, dpa.ValidUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored validUntil: %w", err)
+		}
+		if !validUntil.After(cutoff) {
+			expiring = append(expiring, &dpa)
+		}
+	}
+	sort.Slice(expiring, func(i, j int) bool { return expiring[i].ValidUntil < expiring[j].ValidUntil })
+	return expiring, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) listExpiringDPAs(withinDays int) []*DataProcessingAgreement {
+	e.t.Helper()
+	var dpas []*DataProcessingAgreement
+	e.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		dpas, err = e.cc.ListExpiringDPAs(ctx, withinDays)
+		return err
+	})
+	return dpas
+}
+
+func TestResearchReadsRequireValidDPA(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", specialist.id, "read", "")
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("research")}
+	defer func() { env.stub.TransientMap = nil }()
+	readErr := func() error {
+		return env.invoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.ReadRecord(ctx, "rec1")
+			return err
+		})
+	}
+
+	if err := readErr(); err == nil || !strings.Contains(err.Error(), "data processing agreement") {
+		t.Fatalf("expected DPA denial, got %v", err)
+	}
+	if result := env.auditedRead(specialist, "rec1"); result.Allowed || result.Reason != DenialNoDPA {
+		t.Fatalf("unexpected audited read: %+v", result)
+	}
+
+	dpaHash := strings.Repeat("cd", 32)
+	validUntil := env.stub.now.AddDate(0, 0, 30).Format(time.RFC3339)
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDPA(ctx, "Org2MSP", dpaHash, validUntil)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDPA(ctx, "Org2MSP", dpaHash, validUntil)
+	})
+	env.expectEvent("DPARegistered", nil)
+	if err := readErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	if dpas := env.listExpiringDPAs(10); len(dpas) != 0 {
+		t.Fatalf("DPA is not expiring within 10 days: %+v", dpas)
+	}
+	if dpas := env.listExpiringDPAs(60); len(dpas) != 1 || dpas[0].MspID != "Org2MSP" {
+		t.Fatalf("unexpected expiring DPAs: %+v", dpas)
+	}
+	env.advance(31 * 24 * time.Hour)
+	if err := readErr(); err == nil {
+		t.Fatal("expired DPA must block research reads")
+	}
+	// 其他目的不受 DPA 约束
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("treatment")}
+	if err := readErr(); err != nil {
+		t.Fatal(err)
+	}
+}
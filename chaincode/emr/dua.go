@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	DUAActive  = "active"
+	DUARevoked = "revoked"
+
+	// researcherRole 研究人员身份，证书属性 role=researcher；读取记录须持引用有效 DUA 的授权
+	researcherRole = "researcher"
+
+	maxDUAParties = 20
+)
+
+// DataUseAgreement 数据使用协议登记；协议原文留在线下，链上只存哈希、参与机构与有效期
+type DataUseAgreement struct {
+	DUAHash      string   `json:"duaHash"`
+	Parties      []string `json:"parties"`
+	ValidUntil   string   `json:"validUntil"`
+	Status       string   `json:"status"`
+	RegisteredBy string   `json:"registeredBy"`
+	RegisteredAt string   `json:"registeredAt"`
+	RevokedAt    string   `json:"revokedAt,omitempty"`
+}
+
+type DUAEvent struct {
+	DUAHash   string `json:"duaHash"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func duaKey(duaHash string) string {
+	return "dua:" + duaHash
+}
+
+func getDUA(ctx contractapi.TransactionContextInterface, duaHash string) (*DataUseAgreement, error) {
+	var dua DataUseAgreement
+	found, err := getJSON(ctx, duaKey(duaHash), &dua)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("data use agreement not found: %s", duaHash)
+	}
+	return &dua, nil
+}
+
+// active 未撤销且未过期
+func (dua *DataUseAgreement) active(now time.Time) bool {
+	if dua.Status != DUAActive {
+		return false
+	}
+	validUntil, err := time.Parse(time.RFC3339, dua.ValidUntil)
+	return err == nil && now.Before(validUntil)
+}
+
+// grantDUAValid 授权未引用 DUA，或引用的 DUA 仍有效；只在引用时多读一次
+func grantDUAValid(ctx contractapi.TransactionContextInterface, perm AccessPermission, now time.Time) (bool, error) {
+	if perm.DUAHash == "" {
+		return true, nil
+	}
+	var dua DataUseAgreement
+	found, err := getJSON(ctx, duaKey(perm.DUAHash), &dua)
+	if err != nil || !found {
+		return false, err
+	}
+	return dua.active(now), nil
+}
+
+// grantUsable 授权覆盖 target、满足督导约束，且引用的诊疗周期未关闭、DUA 仍有效
+func grantUsable(ctx contractapi.TransactionContextInterface, perm AccessPermission, now time.Time, target accessTarget) (bool, error) {
+	if !grantCovers(perm, now, target) {
+		return false, nil
+	}
+	supervised, err := supervisedGrantValid(ctx, perm, now)
+	if err != nil || !supervised {
+		return false, err
+	}
+	episodeOpen, err := grantEpisodeValid(ctx, perm)
+	if err != nil || !episodeOpen {
+		return false, err
+	}
+	return grantDUAValid(ctx, perm, now)
+}
+
+// requireActiveDUA 授权引用的 DUA 须存在且有效
+func requireActiveDUA(ctx contractapi.TransactionContextInterface, duaHash string) error {
+	dua, err := getDUA(ctx, duaHash)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !dua.active(now) {
+		return fmt.Errorf("data use agreement %s is %s or expired", duaHash, dua.Status)
+	}
+	return nil
+}
+
+// duaCleared researcher 角色或配置的外部机构（externalMsps）的调用者，须持引用有效 DUA 的单独授权，
+// 且 DUA 的参与机构包含调用者机构；患者与创建者不受限。与跨境校验一样只能按调用者证书判断
+func duaCleared(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string) (bool, error) {
+	if callerID == record.PatientID || callerID == record.CreatorID {
+		return true, nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	isResearcher, err := hasRole(ctx, researcherRole)
+	if err != nil {
+		return false, err
+	}
+	if !isResearcher && !containsString(config.ExternalMSPs, mspID) {
+		return true, nil
+	}
+	perm, err := getPermission(ctx, record.RecordID, callerID)
+	if err != nil || perm == nil || perm.DUAHash == "" {
+		return false, err
+	}
+	var dua DataUseAgreement
+	found, err := getJSON(ctx, duaKey(perm.DUAHash), &dua)
+	if err != nil || !found {
+		return false, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	return dua.active(now) && containsString(dua.Parties, mspID), nil
+}
+
+// RegisterDUA admin 登记数据使用协议；duaHash 为协议文本的 sha256，partiesJson 为参与机构 MSP ID 列表
+func (s *SmartContract) RegisterDUA(ctx contractapi.TransactionContextInterface, duaHash, partiesJson, validUntil string) error {
+	if !sha256HexPattern.MatchString(duaHash) {
+		return fmt.Errorf("duaHash must be a lowercase hex sha256 digest")
+	}
+	var parties []string
+	if err := unmarshalArg(partiesJson, &parties); err != nil {
+		return fmt.Errorf("failed to unmarshal parties: %w", err)
+	}
+	if len(parties) == 0 || len(parties) > maxDUAParties {
+		return fmt.Errorf("parties must contain between 1 and %d organizations", maxDUAParties)
+	}
+	for i, party := range parties {
+		if err := validateAddress(party); err != nil {
+			return fmt.Errorf("invalid party: %w", err)
+		}
+		if containsString(parties[:i], party) {
+			return fmt.Errorf("duplicate party: %s", party)
+		}
+	}
+	until, err := time.Parse(time.RFC3339, validUntil)
+	if err != nil {
+		return fmt.Errorf("invalid validUntil: %w", err)
+	}
+	callerID, _, err := requireAdminCaller(ctx, "register data use agreements")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, duaKey(duaHash))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("data use agreement already exists: %s", duaHash)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !until.After(now) {
+		return fmt.Errorf("validUntil must be in the future")
+	}
+
+	ts := now.Format(time.RFC3339)
+	if err := putJSON(ctx, duaKey(duaHash), DataUseAgreement{
+		DUAHash:      duaHash,
+		Parties:      parties,
+		ValidUntil:   until.UTC().Format(time.RFC3339),
+		Status:       DUAActive,
+		RegisteredBy: callerID,
+		RegisteredAt: ts,
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "DUARegistered", DUAEvent{
+		DUAHash:   duaHash,
+		Status:    DUAActive,
+		Timestamp: ts,
+		CallerID:  callerID,
+		EventType: "DUARegistered",
+	})
+}
+
+// RevokeDUA admin 撤销协议；引用它的授权随即失效，授权条目本身不改动
+func (s *SmartContract) RevokeDUA(ctx contractapi.TransactionContextInterface, duaHash string) error {
+	callerID, _, err := requireAdminCaller(ctx, "revoke data use agreements")
+	if err != nil {
+		return err
+	}
+	dua, err := getDUA(ctx, duaHash)
+	if err != nil {
+		return err
+	}
+	if dua.Status == DUARevoked {
+		return fmt.Errorf("data use agreement %s is already revoked", duaHash)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	dua.Status = DUARevoked
+	dua.RevokedAt = now
+	if err := putJSON(ctx, duaKey(duaHash), dua); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "DUARevoked", DUAEvent{
+		DUAHash:   duaHash,
+		Status:    DUARevoked,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "DUARevoked",
+	})
+}
+
+// GetDUA 返回数据使用协议登记
+func (s *SmartContract) GetDUA(ctx contractapi.TransactionContextInterface, duaHash string) (*DataUseAgreement, error) {
+	return getDUA(ctx, duaHash)
+}
+
+// GrantAccessWithDUA 授予引用数据使用协议的授权；向 researcher 角色或外部机构的身份授权须走此入口，
+// 否则被授权人读取时被拒绝
+func (s *SmartContract) GrantAccessWithDUA(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt, duaHash string) error {
+	if duaHash == "" {
+		return fmt.Errorf("duaHash is required")
+	}
+	return grantAccess(ctx, recordID, granteeID, action, expiresAt, 0, grantScope{duaHash: duaHash})
+}
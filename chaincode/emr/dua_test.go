@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var studyResearcher = newIdentity("researcher2", "Org1MSP", "role", researcherRole)
+
+func (e *testEnv) registerDUA(duaHash, partiesJson string, validFor time.Duration) {
+	e.t.Helper()
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.RegisterDUA(ctx, duaHash, partiesJson, e.stub.now.Add(validFor).Format(time.RFC3339))
+	})
+}
+
+func (e *testEnv) canRead(identity *testIdentity, recordID string) bool {
+	e.t.Helper()
+	return e.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.ReadRecord(ctx, recordID)
+		return err
+	}) == nil
+}
+
+func TestDUAGatesExternalAndResearchReads(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"externalMsps":["Org3MSP"]}`)
+	})
+	env.createRecord(doctor, "rec1", patient.id)
+	duaHash := strings.Repeat("ab", 32)
+
+	// 不引用 DUA 的普通授权对外部机构与研究人员无效
+	env.grant(patient, "rec1", researcher.id, "read", "")
+	env.grant(patient, "rec1", studyResearcher.id, "read", "")
+	if env.canRead(researcher, "rec1") || env.canRead(studyResearcher, "rec1") {
+		t.Fatal("grants without a DUA must not allow external or research reads")
+	}
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDUA(ctx, duaHash, `["Org3MSP"]`, env.stub.now.AddDate(1, 0, 0).Format(time.RFC3339))
+	})
+	env.mustFail(patient, "data use agreement not found", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithDUA(ctx, "rec1", researcher.id, "read", "", duaHash)
+	})
+	env.registerDUA(duaHash, `["Org3MSP"]`, 30*24*time.Hour)
+	env.expectEvent("DUARegistered", nil)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithDUA(ctx, "rec1", researcher.id, "read", "", duaHash)
+	})
+	var event AccessGrantedEvent
+	env.expectEvent("AccessGranted", &event)
+	if event.DUAHash != duaHash {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !env.canRead(researcher, "rec1") {
+		t.Fatal("grant referencing an active DUA must allow the read")
+	}
+	// DUA 的参与机构不含研究人员所在机构
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithDUA(ctx, "rec1", studyResearcher.id, "read", "", duaHash)
+	})
+	if env.canRead(studyResearcher, "rec1") {
+		t.Fatal("caller organization must be a party to the DUA")
+	}
+	// 机构内的普通身份不受影响
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	if !env.canRead(nurse, "rec1") {
+		t.Fatal("internal grants must not require a DUA")
+	}
+
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeDUA(ctx, duaHash)
+	})
+	env.expectEvent("DUARevoked", nil)
+	if env.canRead(researcher, "rec1") || env.checkAccess("rec1", researcher.id) {
+		t.Fatal("revoking the DUA must invalidate grants that reference it")
+	}
+	env.mustFail(patient, "is revoked or expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithDUA(ctx, "rec1", researcher.id, "read", "", duaHash)
+	})
+	env.mustFail(admin, "already revoked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeDUA(ctx, duaHash)
+	})
+}
+
+func TestDUAExpiryInvalidatesGrants(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	duaHash := strings.Repeat("cd", 32)
+	env.mustFail(admin, "duplicate party", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDUA(ctx, duaHash, `["Org1MSP","Org1MSP"]`, env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	})
+	env.mustFail(admin, "must be in the future", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDUA(ctx, duaHash, `["Org1MSP"]`, env.stub.now.Format(time.RFC3339))
+	})
+	env.registerDUA(duaHash, `["Org1MSP"]`, time.Hour)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithDUA(ctx, "rec1", studyResearcher.id, "read", "", duaHash)
+	})
+	if !env.canRead(studyResearcher, "rec1") {
+		t.Fatal("grant referencing an active DUA must allow the read")
+	}
+
+	env.advance(2 * time.Hour)
+	if env.checkAccess("rec1", studyResearcher.id) {
+		t.Fatal("an expired DUA must invalidate grants that reference it")
+	}
+	env.mustInvoke(studyResearcher, func(ctx contractapi.TransactionContextInterface) error {
+		dua, err := env.cc.GetDUA(ctx, duaHash)
+		if err == nil && (dua.Status != DUAActive || dua.RegisteredBy != admin.id) {
+			t.Fatalf("unexpected DUA: %+v", dua)
+		}
+		return err
+	})
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		report, err := env.cc.RunComplianceScan(ctx, 10, "")
+		if err == nil && (len(report.Findings) != 1 || report.Findings[0].Type != FindingInactiveDUA) {
+			t.Fatalf("unexpected findings: %+v", report.Findings)
+		}
+		return err
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// emergencySummaryDisclosure 紧急摘要读取在披露报表中的 recordId
+const emergencySummaryDisclosure = "emergency-summary"
+
+// EmergencySummary 紧急摘要锚点：血型、过敏、在用药物、DNR 状态等关键信息的链下文档，链上只存哈希与 CID
+type EmergencySummary struct {
+	PatientID   string `json:"patientId"`
+	SummaryHash string `json:"summaryHash"`
+	IPFSCid     string `json:"ipfsCid"`
+	UpdatedAt   string `json:"updatedAt"`
+	UpdatedBy   string `json:"updatedBy"`
+	TxID        string `json:"txId"`
+}
+
+type EmergencySummaryEvent struct {
+	PatientID    string `json:"patientId"`
+	SummaryHash  string `json:"summaryHash"`
+	PurposeOfUse string `json:"purposeOfUse,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+func emergencySummaryKey(patientID string) string {
+	return "emergency-summary:" + keySegment(patientID)
+}
+
+// SetEmergencySummary 写入或替换紧急摘要；对患者任一记录有 write 权限的临床人员、患者本人或有 consent 范围的代理人可写
+func (s *SmartContract) SetEmergencySummary(ctx contractapi.TransactionContextInterface, patientID, summaryHash, cid string) error {
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(summaryHash) {
+		return fmt.Errorf("invalid summaryHash: expected hex-encoded SHA-256")
+	}
+	if cid == "" {
+		return fmt.Errorf("cid is required")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.checkCid(cid); err != nil {
+		return err
+	}
+	callerID, err := s.requireConditionWriter(ctx, patientID)
+	if err != nil {
+		if callerID, err = requirePatientOrAgent(ctx, patientID, "consent", "set the emergency summary"); err != nil {
+			return err
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	summary := EmergencySummary{
+		PatientID:   patientID,
+		SummaryHash: summaryHash,
+		IPFSCid:     cid,
+		UpdatedAt:   now,
+		UpdatedBy:   callerID,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	if err := putJSON(ctx, emergencySummaryKey(patientID), summary); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "EmergencySummarySet", EmergencySummaryEvent{
+		PatientID:   patientID,
+		SummaryHash: summaryHash,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "EmergencySummarySet",
+	})
+}
+
+// GetEmergencySummary 任何 doctor/nurse 角色无需授权即可读取；患者以外的读取写入披露报表并发出 EmergencySummaryAccessed，
+// 因此须以 submit 调用。transient 未给出 purposeOfUse 时按 emergency 记录
+func (s *SmartContract) GetEmergencySummary(ctx contractapi.TransactionContextInterface, patientID string) (*EmergencySummary, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPatient {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		if !isClinician {
+			return nil, fmt.Errorf("access denied: clinician role required")
+		}
+	}
+	var summary EmergencySummary
+	found, err := getJSON(ctx, emergencySummaryKey(patientID), &summary)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no emergency summary for %s", patientID)
+	}
+	if isPatient {
+		return &summary, nil
+	}
+
+	purpose, err := purposeOfUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if purpose == "" {
+		purpose = "emergency"
+	}
+	if err := recordDisclosure(ctx, Disclosure{
+		RecordID:     emergencySummaryDisclosure,
+		PatientID:    patientID,
+		AccessorID:   callerID,
+		PurposeOfUse: purpose,
+	}); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, emitEvent(ctx, "EmergencySummaryAccessed", EmergencySummaryEvent{
+		PatientID:    patientID,
+		SummaryHash:  summary.SummaryHash,
+		PurposeOfUse: purpose,
+		Timestamp:    now,
+		CallerID:     callerID,
+		EventType:    "EmergencySummaryAccessed",
+	})
+}
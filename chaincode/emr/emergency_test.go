@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestEmergencySummaryReadWithoutGrantIsDisclosed(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+	hash := strings.Repeat("e", 64)
+	set := func(identity *testIdentity) error {
+		return env.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.SetEmergencySummary(ctx, patient.id, hash, "bafyemergency")
+		})
+	}
+	read := func(ctx contractapi.TransactionContextInterface) error {
+		summary, err := env.cc.GetEmergencySummary(ctx, patient.id)
+		if err == nil && summary.SummaryHash != hash {
+			t.Fatalf("unexpected summary: %+v", summary)
+		}
+		return err
+	}
+
+	env.mustFail(nurse, "no emergency summary", read)
+	if err := set(nurse); err == nil || !strings.Contains(err.Error(), "only the patient") {
+		t.Fatalf("clinicians without write access must not set the summary: %v", err)
+	}
+	if err := set(doctor); err != nil {
+		t.Fatal(err)
+	}
+	env.expectEvent("EmergencySummarySet", nil)
+
+	// 护士对 rec1 没有授权，仍可读取紧急摘要
+	env.advance(time.Minute)
+	env.mustInvoke(nurse, read)
+	var event EmergencySummaryEvent
+	env.expectEvent("EmergencySummaryAccessed", &event)
+	if event.CallerID != nurse.id || event.PurposeOfUse != "emergency" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("reading the summary must not grant record access")
+	}
+	env.mustFail(other, "clinician role required", read)
+	env.mustInvoke(patient, read)
+
+	to := env.stub.now.Format(time.RFC3339)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		report, err := env.cc.GetDisclosureReport(ctx, patient.id, from, to, 10, "")
+		if err == nil && (len(report.Entries) != 1 || report.Entries[0].RecordID != emergencySummaryDisclosure || report.Entries[0].AccessorID != nurse.id) {
+			t.Fatalf("only the nurse's read is a disclosure: %+v", report.Entries)
+		}
+		return err
+	})
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxEncounterRecords 单次就诊可分组的记录上限
+const maxEncounterRecords = 100
+
+// Encounter 一次就诊，把就诊期间创建的多条记录分为一组，可按组共享
+type Encounter struct {
+	EncounterID   string `json:"encounterId"`
+	PatientID     string `json:"patientId"`
+	EncounterType string `json:"encounterType,omitempty"`
+	Description   string `json:"description,omitempty"`
+	StartedAt     string `json:"startedAt"`
+	RecordCount   int    `json:"recordCount"`
+	CreatedBy     string `json:"createdBy"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+// EncounterAccess 就诊级 read 授权，覆盖就诊中的全部记录（含之后加入的），高敏记录除外
+type EncounterAccess struct {
+	EncounterID string `json:"encounterId"`
+	GranteeID   string `json:"granteeId"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
+	GrantedAt   string `json:"grantedAt"`
+	GrantedBy   string `json:"grantedBy"`
+	IsActive    bool   `json:"isActive"`
+}
+
+type EncounterEvent struct {
+	EncounterID string `json:"encounterId"`
+	PatientID   string `json:"patientId"`
+	RecordID    string `json:"recordId,omitempty"`
+	GranteeID   string `json:"granteeId,omitempty"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+func encounterKey(encounterID string) string {
+	return "encounter:" + encounterID
+}
+
+// encounterRecordKey encounter-record:{encounterId}:{recordId}，值为 recordId
+func encounterRecordKey(encounterID, recordID string) string {
+	return encounterRecordPrefix(encounterID) + keySegment(recordID)
+}
+
+func encounterRecordPrefix(encounterID string) string {
+	return "encounter-record:" + keySegment(encounterID) + ":"
+}
+
+// recordEncounterKey record-encounter:{recordId}，值为记录所属的 encounterId；一条记录只属于一次就诊
+func recordEncounterKey(recordID string) string {
+	return "record-encounter:" + keySegment(recordID)
+}
+
+// encounterAccessKey encounter-access:{encounterId}:{granteeId}
+func encounterAccessKey(encounterID, granteeID string) string {
+	return "encounter-access:" + keySegment(encounterID) + ":" + keySegment(granteeID)
+}
+
+func getEncounter(ctx contractapi.TransactionContextInterface, encounterID string) (*Encounter, error) {
+	var encounter Encounter
+	found, err := getJSON(ctx, encounterKey(encounterID), &encounter)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("encounter not found: %s", encounterID)
+	}
+	return &encounter, nil
+}
+
+// encounterGrantsAccess 派生规则：记录所属就诊上有被授权人有效的就诊级授权；高敏记录须单独会签授权
+func encounterGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	encounterID, err := ctx.GetStub().GetState(recordEncounterKey(recordID))
+	if err != nil {
+		return false, fmt.Errorf("failed to read record encounter: %w", err)
+	}
+	if len(encounterID) == 0 {
+		return false, nil
+	}
+	var access EncounterAccess
+	found, err := getJSON(ctx, encounterAccessKey(string(encounterID), userID), &access)
+	if err != nil || !found {
+		return false, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !permissionActive(AccessPermission{IsActive: access.IsActive, ExpiresAt: access.ExpiresAt}, now) {
+		return false, nil
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	return record.Sensitivity != SensitivityHigh, nil
+}
+
+// CreateEncounter 持有效执业凭证的医护登记一次就诊；encounterJson 为 {encounterId, encounterType, description, startedAt}
+func (s *SmartContract) CreateEncounter(ctx contractapi.TransactionContextInterface, patientID, encounterJson string) error {
+	var encounter Encounter
+	if err := unmarshalArg(encounterJson, &encounter); err != nil {
+		return fmt.Errorf("invalid encounter json: %w", err)
+	}
+	if err := validateAddress(encounter.EncounterID); err != nil {
+		return fmt.Errorf("invalid encounterId: %w", err)
+	}
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	if encounter.EncounterType != "" && !recordTypePattern.MatchString(encounter.EncounterType) {
+		return fmt.Errorf("invalid encounterType: %q", encounter.EncounterType)
+	}
+	if _, err := time.Parse(time.RFC3339, encounter.StartedAt); err != nil {
+		return fmt.Errorf("invalid startedAt: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID == patientID {
+		return fmt.Errorf("patients cannot create their own encounters")
+	}
+	if err := requireProviderCredential(ctx, callerID); err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, encounterKey(encounter.EncounterID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("encounter already exists: %s", encounter.EncounterID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	encounter.PatientID = patientID
+	encounter.RecordCount = 0
+	encounter.CreatedBy = callerID
+	encounter.CreatedAt = now
+	if err := putJSON(ctx, encounterKey(encounter.EncounterID), encounter); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "EncounterCreated", EncounterEvent{
+		EncounterID: encounter.EncounterID,
+		PatientID:   patientID,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "EncounterCreated",
+	})
+}
+
+// AddRecordToEncounter 就诊登记人或对记录有 write 权限的身份把患者的记录加入就诊
+func (s *SmartContract) AddRecordToEncounter(ctx contractapi.TransactionContextInterface, encounterID, recordID string) error {
+	encounter, err := getEncounter(ctx, encounterID)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if record.PatientID != encounter.PatientID {
+		return fmt.Errorf("record %s does not belong to patient %s", recordID, encounter.PatientID)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != encounter.CreatedBy {
+		allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("access denied: %s cannot add record %s to encounter %s", callerID, recordID, encounterID)
+		}
+	}
+	current, err := ctx.GetStub().GetState(recordEncounterKey(recordID))
+	if err != nil {
+		return fmt.Errorf("failed to read record encounter: %w", err)
+	}
+	if len(current) > 0 {
+		return fmt.Errorf("record %s already belongs to encounter %s", recordID, current)
+	}
+	if encounter.RecordCount >= maxEncounterRecords {
+		return fmt.Errorf("an encounter can group at most %d records", maxEncounterRecords)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(recordEncounterKey(recordID), []byte(encounterID)); err != nil {
+		return fmt.Errorf("failed to link record to encounter: %w", err)
+	}
+	if err := ctx.GetStub().PutState(encounterRecordKey(encounterID, recordID), []byte(recordID)); err != nil {
+		return fmt.Errorf("failed to index encounter record: %w", err)
+	}
+	encounter.RecordCount++
+	if err := putJSON(ctx, encounterKey(encounterID), encounter); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordAddedToEncounter", EncounterEvent{
+		EncounterID: encounterID,
+		PatientID:   encounter.PatientID,
+		RecordID:    recordID,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "RecordAddedToEncounter",
+	})
+}
+
+// ListEncounterRecords 列出就诊中调用者可访问的未归档记录
+func (s *SmartContract) ListEncounterRecords(ctx contractapi.TransactionContextInterface, encounterID string) ([]*MedicalRecord, error) {
+	if _, err := getEncounter(ctx, encounterID); err != nil {
+		return nil, err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := encounterRecordPrefix(encounterID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan encounter records: %w", err)
+	}
+	defer iterator.Close()
+
+	records := []*MedicalRecord{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate encounter records: %w", err)
+		}
+		record, err := getRecord(ctx, string(kv.Value))
+		if err != nil {
+			return nil, err
+		}
+		if !listable(record) {
+			continue
+		}
+		allowed, err := s.CheckAccess(ctx, record.RecordID, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// ShareEncounter 患者或有 grant 范围的代理人授予就诊级 read 权限，一次覆盖就诊中的全部记录；重复授予时更新到期时间
+func (s *SmartContract) ShareEncounter(ctx contractapi.TransactionContextInterface, encounterID, granteeID, expiresAt string) error {
+	if err := validateAddress(granteeID); err != nil {
+		return fmt.Errorf("invalid granteeID: %w", err)
+	}
+	encounter, err := getEncounter(ctx, encounterID)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := ownerOrAgent(ctx, encounter.PatientID, callerID, "grant"); err != nil {
+		return err
+	}
+	if granteeID == encounter.PatientID {
+		return fmt.Errorf("patient already has access to the encounter")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	txNow, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.checkGrant("read", expiresAt, txNow); err != nil {
+		return err
+	}
+	now := txNow.Format(time.RFC3339)
+	access := EncounterAccess{
+		EncounterID: encounterID,
+		GranteeID:   granteeID,
+		ExpiresAt:   expiresAt,
+		GrantedAt:   now,
+		GrantedBy:   callerID,
+		IsActive:    true,
+	}
+	if err := putJSON(ctx, encounterAccessKey(encounterID, granteeID), access); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "EncounterAccessGranted", EncounterEvent{
+		EncounterID: encounterID,
+		PatientID:   encounter.PatientID,
+		GranteeID:   granteeID,
+		ExpiresAt:   expiresAt,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "EncounterAccessGranted",
+	})
+}
+
+// RevokeEncounterAccess 患者或有 revoke 范围的代理人撤销就诊级授权（保留授权记录，置为失效）
+func (s *SmartContract) RevokeEncounterAccess(ctx contractapi.TransactionContextInterface, encounterID, granteeID string) error {
+	encounter, err := getEncounter(ctx, encounterID)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := ownerOrAgent(ctx, encounter.PatientID, callerID, "revoke"); err != nil {
+		return err
+	}
+	var access EncounterAccess
+	found, err := getJSON(ctx, encounterAccessKey(encounterID, granteeID), &access)
+	if err != nil {
+		return err
+	}
+	if !found || !access.IsActive {
+		return fmt.Errorf("no active encounter access for %s on encounter %s", granteeID, encounterID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	access.IsActive = false
+	if err := putJSON(ctx, encounterAccessKey(encounterID, granteeID), access); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "EncounterAccessRevoked", EncounterEvent{
+		EncounterID: encounterID,
+		PatientID:   encounter.PatientID,
+		GranteeID:   granteeID,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "EncounterAccessRevoked",
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestEncounterGroupsAndSharesRecords(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(doctor, "rec3", "patient2")
+	env.createSensitiveRecord(doctor, "rec4", patient.id)
+	create := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateEncounter(ctx, patient.id, `{"encounterId":"enc1","encounterType":"emergency","startedAt":"2026-03-02T08:00:00Z"}`)
+	}
+	env.mustFail(patient, "patients cannot create", create)
+	env.mustFail(other, "no valid license credential", create)
+	env.mustInvoke(doctor, create)
+	env.expectEvent("EncounterCreated", nil)
+	env.mustFail(doctor, "already exists", create)
+
+	add := func(recordID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AddRecordToEncounter(ctx, "enc1", recordID)
+		}
+	}
+	env.mustInvoke(doctor, add("rec1"))
+	env.mustInvoke(doctor, add("rec4"))
+	env.mustFail(doctor, "already belongs to encounter enc1", add("rec1"))
+	env.mustFail(doctor, "does not belong to patient", add("rec3"))
+	env.mustFail(other, "cannot add record", add("rec2"))
+
+	share := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ShareEncounter(ctx, "enc1", nurse.id, "")
+	}
+	env.mustFail(nurse, "access denied", share)
+	env.mustInvoke(patient, share)
+	env.expectEvent("EncounterAccessGranted", nil)
+	if !env.canRead(nurse, "rec1") || env.checkAccess("rec2", nurse.id) {
+		t.Fatal("encounter access must cover exactly the grouped records")
+	}
+	if env.checkAccess("rec4", nurse.id) {
+		t.Fatal("high-sensitivity records need their own countersigned grant")
+	}
+	env.mustInvoke(doctor, add("rec2"))
+	if !env.checkAccess("rec2", nurse.id) {
+		t.Fatal("records added later must be covered")
+	}
+
+	list := func(identity *testIdentity) []*MedicalRecord {
+		var records []*MedicalRecord
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			records, err = env.cc.ListEncounterRecords(ctx, "enc1")
+			return err
+		})
+		return records
+	}
+	if got := list(nurse); len(got) != 2 {
+		t.Fatalf("nurse should list two records, got %d", len(got))
+	}
+	if got := list(patient); len(got) != 3 {
+		t.Fatalf("patient should list three records, got %d", len(got))
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeEncounterAccess(ctx, "enc1", nurse.id)
+	})
+	if env.checkAccess("rec1", nurse.id) || len(list(nurse)) != 0 {
+		t.Fatal("revoking encounter access must cover every record")
+	}
+}
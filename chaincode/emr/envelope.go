@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TxContext 产生应答的交易。只有提交并上链的交易才能据此在区块中核对；
+// 仅 evaluate 的查询不进入任何区块，txId 查不到，信封只说明背书节点当时的应答
+type TxContext struct {
+	TxID        string `json:"txId"`
+	ChannelID   string `json:"channelId"`
+	TxTimestamp string `json:"txTimestamp"`
+}
+
+type AccessCheckResponse struct {
+	Tx      *TxContext `json:"tx"`
+	Allowed bool       `json:"allowed"`
+}
+
+type RecordResponse struct {
+	Tx     *TxContext     `json:"tx"`
+	Record *MedicalRecord `json:"record"`
+}
+
+type RecordMetadataResponse struct {
+	Tx       *TxContext      `json:"tx"`
+	Metadata *RecordMetadata `json:"metadata"`
+}
+
+type RecordListResponse struct {
+	Tx      *TxContext       `json:"tx"`
+	Records []*MedicalRecord `json:"records"`
+}
+
+// AccessAttestation 已提交的访问判断：与应答同笔交易写入状态，之后凭 txId 以 GetAccessAttestation 取回
+type AccessAttestation struct {
+	RecordID    string `json:"recordId"`
+	UserID      string `json:"userId"`
+	Allowed     bool   `json:"allowed"`
+	RequestedBy string `json:"requestedBy"`
+	TxID        string `json:"txId"`
+	ChannelID   string `json:"channelId"`
+	TxTimestamp string `json:"txTimestamp"`
+}
+
+type AccessCheckAttestedEvent struct {
+	RecordID    string `json:"recordId"`
+	UserID      string `json:"userId"`
+	Allowed     bool   `json:"allowed"`
+	RequestedBy string `json:"requestedBy"`
+	Timestamp   string `json:"timestamp"`
+	EventType   string `json:"eventType"`
+}
+
+func attestationKey(txID string) string {
+	return "attest:" + txID
+}
+
+func txContext(ctx contractapi.TransactionContextInterface) (*TxContext, error) {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stub := ctx.GetStub()
+	return &TxContext{TxID: stub.GetTxID(), ChannelID: stub.GetChannelID(), TxTimestamp: now}, nil
+}
+
+// CheckAccessWithTxContext 与 CheckAccess 相同，应答附带交易上下文；原函数返回值不变，兼容后端 BlockchainService
+func (s *SmartContract) CheckAccessWithTxContext(ctx contractapi.TransactionContextInterface, recordID, userID string) (*AccessCheckResponse, error) {
+	allowed, err := s.CheckAccess(ctx, recordID, userID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessCheckResponse{Tx: tx, Allowed: allowed}, nil
+}
+
+// ReadRecordWithTxContext 与 ReadRecord 相同，应答附带交易上下文；提交时读取事件与披露条目随之上链
+func (s *SmartContract) ReadRecordWithTxContext(ctx contractapi.TransactionContextInterface, recordID string) (*RecordResponse, error) {
+	record, err := s.ReadRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordResponse{Tx: tx, Record: record}, nil
+}
+
+// GetRecordMetadataWithTxContext 与 GetRecordMetadata 相同，应答附带交易上下文
+func (s *SmartContract) GetRecordMetadataWithTxContext(ctx contractapi.TransactionContextInterface, recordID string) (*RecordMetadataResponse, error) {
+	metadata, err := s.GetRecordMetadata(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordMetadataResponse{Tx: tx, Metadata: metadata}, nil
+}
+
+// ListRecordsByPatientWithTxContext 与 ListRecordsByPatient 相同，应答附带交易上下文。
+// 分页查询（GetUserPermissions、ListRecordsByPatientAndTimeRange、SearchRecords 等）不提供信封：
+// Fabric 只允许在只读交易中分页，这些应答的 txId 不会上链
+func (s *SmartContract) ListRecordsByPatientWithTxContext(ctx contractapi.TransactionContextInterface, patientID, provenanceTier string) (*RecordListResponse, error) {
+	records, err := s.ListRecordsByPatient(ctx, patientID, provenanceTier)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordListResponse{Tx: tx, Records: records}, nil
+}
+
+// AttestAccessCheck 把 CheckAccess 的结果写入状态并发出事件，须以提交交易调用；
+// 交易上链后，结果、时间与 txId 可由被判断的身份、患者或审计员以 GetAccessAttestation 核对
+func (s *SmartContract) AttestAccessCheck(ctx contractapi.TransactionContextInterface, recordID, userID string) (*AccessCheckResponse, error) {
+	allowed, err := s.CheckAccess(ctx, recordID, userID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := putJSON(ctx, attestationKey(tx.TxID), AccessAttestation{
+		RecordID:    recordID,
+		UserID:      userID,
+		Allowed:     allowed,
+		RequestedBy: callerID,
+		TxID:        tx.TxID,
+		ChannelID:   tx.ChannelID,
+		TxTimestamp: tx.TxTimestamp,
+	}); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, "AccessCheckAttested", AccessCheckAttestedEvent{
+		RecordID:    recordID,
+		UserID:      userID,
+		Allowed:     allowed,
+		RequestedBy: callerID,
+		Timestamp:   tx.TxTimestamp,
+		EventType:   "AccessCheckAttested",
+	}); err != nil {
+		return nil, err
+	}
+	return &AccessCheckResponse{Tx: tx, Allowed: allowed}, nil
+}
+
+// GetAccessAttestation 返回已提交的访问判断；判断揭示了谁能访问哪条记录，仅被判断的身份、记录的患者与审计员可取回
+func (s *SmartContract) GetAccessAttestation(ctx contractapi.TransactionContextInterface, txID string) (*AccessAttestation, error) {
+	var attestation AccessAttestation
+	found, err := getJSON(ctx, attestationKey(txID), &attestation)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("access attestation not found: %s", txID)
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = callerIs(ctx, attestation.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		record, err := getRecord(ctx, attestation.RecordID)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err = callerIs(ctx, record.PatientID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only the subject, the patient or an auditor can view this attestation")
+	}
+	return &attestation, nil
+}
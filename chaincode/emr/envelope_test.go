@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestTxContextResponses(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		response, err := env.cc.ReadRecordWithTxContext(ctx, "rec1")
+		if err == nil && (response.Record.RecordID != "rec1" || response.Tx.TxID != ctx.GetStub().GetTxID() || response.Tx.TxTimestamp != env.stub.now.Format(time.RFC3339)) {
+			t.Fatalf("unexpected response: %+v", response)
+		}
+		return err
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		response, err := env.cc.GetRecordMetadataWithTxContext(ctx, "rec1")
+		if err == nil && (response.Metadata.RecordID != "rec1" || response.Tx.TxID != ctx.GetStub().GetTxID()) {
+			t.Fatalf("unexpected response: %+v", response)
+		}
+		return err
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		response, err := env.cc.ListRecordsByPatientWithTxContext(ctx, patient.id, "")
+		if err == nil && (len(response.Records) != 1 || response.Records[0].IPCSCID != "" || response.Tx.TxID != ctx.GetStub().GetTxID()) {
+			t.Fatalf("unexpected response: %+v", response)
+		}
+		return err
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		response, err := env.cc.CheckAccessWithTxContext(ctx, "rec1", other.id)
+		if err == nil && (response.Allowed || response.Tx.TxID == "") {
+			t.Fatalf("unexpected response: %+v", response)
+		}
+		return err
+	})
+}
+
+func TestAttestAccessCheckIsRetrievable(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+
+	var response *AccessCheckResponse
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		response, err = env.cc.AttestAccessCheck(ctx, "rec1", nurse.id)
+		return err
+	})
+	var event AccessCheckAttestedEvent
+	env.expectEvent("AccessCheckAttested", &event)
+	if !response.Allowed || !event.Allowed || event.RequestedBy != doctor.id {
+		t.Fatalf("unexpected attestation: %+v %+v", response, event)
+	}
+
+	for _, identity := range []*testIdentity{nurse, patient, auditor} {
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			attestation, err := env.cc.GetAccessAttestation(ctx, response.Tx.TxID)
+			if err == nil && (!attestation.Allowed || attestation.UserID != nurse.id || attestation.TxTimestamp != response.Tx.TxTimestamp) {
+				t.Fatalf("unexpected attestation: %+v", attestation)
+			}
+			return err
+		})
+	}
+	env.mustFail(other, "only the subject, the patient or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAccessAttestation(ctx, response.Tx.TxID)
+		return err
+	})
+	env.mustFail(other, "not found", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAccessAttestation(ctx, "missing")
+		return err
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	EpisodeOpen   = "open"
+	EpisodeClosed = "closed"
+)
+
+// Episode 诊疗周期；引用周期的授权在周期关闭后失效，可不设过期时间
+type Episode struct {
+	EpisodeID   string `json:"episodeId"`
+	PatientID   string `json:"patientId"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+	OpenedAt    string `json:"openedAt"`
+	OpenedBy    string `json:"openedBy"`
+	ClosedAt    string `json:"closedAt,omitempty"`
+	ClosedBy    string `json:"closedBy,omitempty"`
+}
+
+type EpisodeEvent struct {
+	EpisodeID string `json:"episodeId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func episodeKey(episodeID string) string {
+	return "episode:" + episodeID
+}
+
+func getEpisode(ctx contractapi.TransactionContextInterface, episodeID string) (*Episode, error) {
+	var episode Episode
+	found, err := getJSON(ctx, episodeKey(episodeID), &episode)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("episode not found: %s", episodeID)
+	}
+	return &episode, nil
+}
+
+// requireOpenEpisode 授权引用的周期须属于记录的患者且未关闭
+func requireOpenEpisode(ctx contractapi.TransactionContextInterface, record *MedicalRecord, episodeID string) error {
+	episode, err := getEpisode(ctx, episodeID)
+	if err != nil {
+		return err
+	}
+	if episode.PatientID != record.PatientID {
+		return fmt.Errorf("episode %s does not belong to patient %s", episodeID, record.PatientID)
+	}
+	if episode.Status != EpisodeOpen {
+		return fmt.Errorf("episode %s is %s", episodeID, episode.Status)
+	}
+	return nil
+}
+
+// grantEpisodeValid 授权未引用周期，或引用的周期仍未关闭；只在引用时多读一次
+func grantEpisodeValid(ctx contractapi.TransactionContextInterface, perm AccessPermission) (bool, error) {
+	if perm.EpisodeID == "" {
+		return true, nil
+	}
+	var episode Episode
+	found, err := getJSON(ctx, episodeKey(perm.EpisodeID), &episode)
+	if err != nil || !found {
+		return false, err
+	}
+	return episode.Status == EpisodeOpen, nil
+}
+
+// OpenEpisode 患者、有 grant 范围的代理人或持有效执业凭证的医护为患者开启诊疗周期；episodeJson 为 {episodeId, description}
+func (s *SmartContract) OpenEpisode(ctx contractapi.TransactionContextInterface, patientID, episodeJson string) error {
+	var episode Episode
+	if err := unmarshalArg(episodeJson, &episode); err != nil {
+		return fmt.Errorf("invalid episode json: %w", err)
+	}
+	if err := validateAddress(episode.EpisodeID); err != nil {
+		return fmt.Errorf("invalid episodeId: %w", err)
+	}
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	licensed, err := credentialValid(ctx, callerID)
+	if err != nil {
+		return err
+	}
+	if !licensed {
+		if _, err := requirePatientOrAgent(ctx, patientID, "grant", "open episodes without a provider credential"); err != nil {
+			return err
+		}
+	}
+	exists, err := assetExists(ctx, episodeKey(episode.EpisodeID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("episode already exists: %s", episode.EpisodeID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	episode.PatientID = patientID
+	episode.Status = EpisodeOpen
+	episode.OpenedAt = now
+	episode.OpenedBy = callerID
+	episode.ClosedAt = ""
+	episode.ClosedBy = ""
+	if err := putJSON(ctx, episodeKey(episode.EpisodeID), episode); err != nil {
+		return err
+	}
+	return emitEpisodeEvent(ctx, "EpisodeOpened", &episode, callerID, now)
+}
+
+// CloseEpisode 开启人、患者或有 grant 范围的代理人关闭周期，引用该周期的授权随即失效
+func (s *SmartContract) CloseEpisode(ctx contractapi.TransactionContextInterface, episodeID string) error {
+	episode, err := getEpisode(ctx, episodeID)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != episode.OpenedBy {
+		if _, err := requirePatientOrAgent(ctx, episode.PatientID, "grant", "close this episode"); err != nil {
+			return err
+		}
+	}
+	if episode.Status != EpisodeOpen {
+		return fmt.Errorf("episode %s is already %s", episodeID, episode.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	episode.Status = EpisodeClosed
+	episode.ClosedAt = now.Format(time.RFC3339)
+	episode.ClosedBy = callerID
+	if err := putJSON(ctx, episodeKey(episodeID), episode); err != nil {
+		return err
+	}
+	return emitEpisodeEvent(ctx, "EpisodeClosed", episode, callerID, episode.ClosedAt)
+}
+
+// GetEpisode 查询诊疗周期；限开启人、患者、有 consent 范围的代理人与审计员
+func (s *SmartContract) GetEpisode(ctx contractapi.TransactionContextInterface, episodeID string) (*Episode, error) {
+	episode, err := getEpisode(ctx, episodeID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = callerIs(ctx, episode.OpenedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		if _, err := requirePatientOrAgent(ctx, episode.PatientID, "consent", "view this episode"); err != nil {
+			return nil, err
+		}
+	}
+	return episode, nil
+}
+
+// GrantEpisodeAccess 授予引用诊疗周期的授权，周期关闭即失效；expiresAt 可为空，此时不受最长授权期限约束
+func (s *SmartContract) GrantEpisodeAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt, episodeID string) error {
+	if episodeID == "" {
+		return fmt.Errorf("episodeID is required")
+	}
+	return grantAccess(ctx, recordID, granteeID, action, expiresAt, 0, grantScope{episodeID: episodeID})
+}
+
+func emitEpisodeEvent(ctx contractapi.TransactionContextInterface, name string, episode *Episode, callerID, now string) error {
+	return emitEvent(ctx, name, EpisodeEvent{
+		EpisodeID: episode.EpisodeID,
+		PatientID: episode.PatientID,
+		Status:    episode.Status,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestEpisodeBoundsGrants(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", "patient2")
+	open := func(patientID, episodeID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.OpenEpisode(ctx, patientID, `{"episodeId":"`+episodeID+`","description":"knee replacement"}`)
+		}
+	}
+	env.mustFail(other, "only the patient", open(patient.id, "ep1"))
+	env.mustInvoke(doctor, open(patient.id, "ep1"))
+	env.expectEvent("EpisodeOpened", nil)
+	env.mustFail(patient, "already exists", open(patient.id, "ep1"))
+	env.mustInvoke(patient, open(patient.id, "ep2"))
+
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"maxGrantDurationDays":30}`)
+	})
+	grantEpisode := func(recordID, episodeID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.GrantEpisodeAccess(ctx, recordID, nurse.id, "read", "", episodeID)
+		}
+	}
+	env.mustFail(patient, "expiresAt is required", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	})
+	env.mustInvoke(patient, grantEpisode("rec1", "ep1"))
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("episode grants need no expiry")
+	}
+	env.mustFail(newIdentity("patient2", "Org1MSP"), "does not belong to patient", grantEpisode("rec2", "ep1"))
+
+	closeEpisode := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CloseEpisode(ctx, "ep1")
+	}
+	env.mustFail(nurse, "access denied", closeEpisode)
+	env.mustInvoke(doctor, closeEpisode)
+	env.expectEvent("EpisodeClosed", nil)
+	if env.checkAccess("rec1", nurse.id) || env.canRead(nurse, "rec1") {
+		t.Fatal("grants on a closed episode must be inactive")
+	}
+	env.mustFail(patient, "already closed", closeEpisode)
+	env.mustFail(patient, "episode ep1 is closed", grantEpisode("rec1", "ep1"))
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		episode, err := env.cc.GetEpisode(ctx, "ep1")
+		if err == nil && (episode.Status != EpisodeClosed || episode.ClosedBy != doctor.id || episode.OpenedBy != doctor.id) {
+			t.Fatalf("unexpected episode: %+v", episode)
+		}
+		return err
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetEpisode(ctx, "ep1")
+		return err
+	})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// eventPartIndex 超限事件的分片，event-part~{eventId}~{part}
+const eventPartIndex = "event-part"
+
+// eventEnvelopeOverhead 为信封字段预留的字节数
+const eventEnvelopeOverhead = 256
+
+// eventTypePattern 事件名为 PascalCase，不含键分隔符
+var eventTypePattern = regexp.MustCompile(`^[A-Z][A-Za-z]{0,63}$`)
+
+// EventPart 分片信封：超过 maxEventBytes 的事件负载按字节切分，Chunk 以 base64 编码；
+// 事件本身携带第 1 片，其余分片写入状态，监听方以 GetEventPart 取回后按序拼接得到原始 JSON
+type EventPart struct {
+	EventID   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	Part      int    `json:"part"`
+	Total     int    `json:"total"`
+	Chunk     []byte `json:"chunk"`
+}
+
+func eventPartKey(ctx contractapi.TransactionContextInterface, eventID string, part int) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(eventPartIndex, []string{eventID, fmt.Sprintf("%04d", part)})
+	if err != nil {
+		return "", fmt.Errorf("failed to create event part key: %w", err)
+	}
+	return key, nil
+}
+
+// chunkEvent 按 maxEventBytes 切分负载，base64 膨胀后连同信封不超过上限
+func chunkEvent(eventID, name string, payload []byte, maxEventBytes int) []EventPart {
+	chunkSize := (maxEventBytes - eventEnvelopeOverhead) * 3 / 4
+	total := (len(payload) + chunkSize - 1) / chunkSize
+	parts := make([]EventPart, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		parts = append(parts, EventPart{EventID: eventID, EventType: name, Part: i + 1, Total: total, Chunk: payload[i*chunkSize : end]})
+	}
+	return parts
+}
+
+// setChunkedEvent 负载超限时把第 2 片起写入状态，事件只发第 1 片
+func setChunkedEvent(ctx contractapi.TransactionContextInterface, name string, payload []byte, maxEventBytes int) ([]byte, error) {
+	parts := chunkEvent(ctx.GetStub().GetTxID(), name, payload, maxEventBytes)
+	for _, part := range parts[1:] {
+		key, err := eventPartKey(ctx, part.EventID, part.Part)
+		if err != nil {
+			return nil, err
+		}
+		if err := putJSON(ctx, key, part); err != nil {
+			return nil, fmt.Errorf("failed to store event part: %w", err)
+		}
+	}
+	envelope, err := json.Marshal(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event envelope: %w", name, err)
+	}
+	return envelope, nil
+}
+
+// GetEventPart 返回分片事件的第 part 片（1 开始）；第 1 片即事件负载本身
+func (s *SmartContract) GetEventPart(ctx contractapi.TransactionContextInterface, eventID string, part int) (*EventPart, error) {
+	if part < 2 {
+		return nil, fmt.Errorf("part %d is carried by the event itself", part)
+	}
+	key, err := eventPartKey(ctx, eventID, part)
+	if err != nil {
+		return nil, err
+	}
+	var eventPart EventPart
+	found, err := getJSON(ctx, key, &eventPart)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("event part not found: %s/%d", eventID, part)
+	}
+	return &eventPart, nil
+}
+
+// EventSummary 持久化的事件摘要，供监听方停机后补采；完整负载仍以区块中的事件为准，可按 payloadHash 核对
+type EventSummary struct {
+	EventType string `json:"eventType"`
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	RecordID  string `json:"recordId,omitempty"`
+	PatientID string `json:"patientId,omitempty"`
+	// ReasonCode 读取类事件负载中的读取原因代码
+	ReasonCode  string `json:"reasonCode,omitempty"`
+	PayloadHash string `json:"payloadHash"`
+	Size        int    `json:"size"`
+	// Seq、PrevAuditHash、EntryHash 只在记录审计轨迹中填写，构成每条记录的审计哈希链，见 auditchain.go
+	Seq           int    `json:"seq,omitempty"`
+	PrevAuditHash string `json:"prevAuditHash,omitempty"`
+	EntryHash     string `json:"entryHash,omitempty"`
+}
+
+type EventPage struct {
+	Events   []*EventSummary `json:"events"`
+	Bookmark string          `json:"bookmark"`
+}
+
+// EventPruneResult More 为 true 表示仍有过期摘要，需再次调用
+type EventPruneResult struct {
+	Pruned int  `json:"pruned"`
+	More   bool `json:"more"`
+}
+
+// eventIndexKey evt:{eventType}:{epoch}:{txId}，同一类型内按交易时间排序
+func eventIndexKey(eventType string, at time.Time, txID string) string {
+	return "evt:" + eventType + ":" + epochSegment(at) + ":" + txID
+}
+
+// indexEvent 随事件写入摘要；recordId/patientId 取自负载的同名字段（若有），
+// 负载只有 recordIds（如批量更新）时为其中每条记录各写一份审计轨迹
+func indexEvent(ctx contractapi.TransactionContextInterface, name string, payload []byte) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	var subject struct {
+		RecordID   string   `json:"recordId"`
+		RecordIDs  []string `json:"recordIds"`
+		PatientID  string   `json:"patientId"`
+		ReasonCode string   `json:"reasonCode"`
+	}
+	_ = json.Unmarshal(payload, &subject)
+	digest := sha256.Sum256(payload)
+	txID := ctx.GetStub().GetTxID()
+	summary := EventSummary{
+		EventType:   name,
+		TxID:        txID,
+		Timestamp:   now.Format(time.RFC3339),
+		RecordID:    subject.RecordID,
+		PatientID:   subject.PatientID,
+		ReasonCode:  subject.ReasonCode,
+		PayloadHash: hex.EncodeToString(digest[:]),
+		Size:        len(payload),
+	}
+	if err := putJSON(ctx, eventIndexKey(name, now, txID), summary); err != nil {
+		return err
+	}
+	// 涉及记录的事件另写一份按记录排列的审计轨迹，不随 PruneEvents 删除
+	recordIDs := subject.RecordIDs
+	if subject.RecordID != "" {
+		recordIDs = []string{subject.RecordID}
+	}
+	for i, recordID := range recordIDs {
+		if recordID == "" || containsString(recordIDs[:i], recordID) {
+			continue
+		}
+		entry := summary
+		entry.RecordID = recordID
+		if err := chainAuditEntry(ctx, &entry); err != nil {
+			return err
+		}
+		if err := putJSON(ctx, auditLogKey(recordID, now, txID, name), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEventsSince 按交易时间顺序分页返回 fromTimestamp（含）之后的某类事件摘要，仅 admin/auditor 角色
+func (s *SmartContract) GetEventsSince(ctx contractapi.TransactionContextInterface, eventType, fromTimestamp string, pageSize int32, bookmark string) (*EventPage, error) {
+	if !eventTypePattern.MatchString(eventType) {
+		return nil, fmt.Errorf("invalid eventType: %q", eventType)
+	}
+	from, err := time.Parse(time.RFC3339, fromTimestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fromTimestamp: %w", err)
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	allowed, err := hasAnyRole(ctx, "admin", auditorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only admin or auditor roles can read the event index")
+	}
+
+	startKey := "evt:" + eventType + ":" + epochSegment(from)
+	iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination(startKey, "evt:"+eventType+";", pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event index: %w", err)
+	}
+	defer iterator.Close()
+
+	page := &EventPage{Events: []*EventSummary{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate event index: %w", err)
+		}
+		var summary EventSummary
+		if err := json.Unmarshal(kv.Value, &summary); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event summary: %w", err)
+		}
+		page.Events = append(page.Events, &summary)
+	}
+	return page, nil
+}
+
+// PruneEvents 删除早于保留期（配置 eventRetentionDays）的某类事件摘要，每次最多 pageSize 条；仅 admin
+func (s *SmartContract) PruneEvents(ctx contractapi.TransactionContextInterface, eventType string, pageSize int32) (*EventPruneResult, error) {
+	if !eventTypePattern.MatchString(eventType) {
+		return nil, fmt.Errorf("invalid eventType: %q", eventType)
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, fmt.Errorf("access denied: only admin can prune the event index")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.EventRetentionDays == 0 {
+		return nil, fmt.Errorf("event retention is not configured")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 结束键为截止时刻所在秒，范围内的摘要均已过期
+	prefix := "evt:" + eventType + ":"
+	cutoff := prefix + epochSegment(now.AddDate(0, 0, -config.EventRetentionDays))
+	result := &EventPruneResult{}
+	next, err := scanRangeForUpdate(ctx, prefix, cutoff, pageSize, "", func(key string, _ []byte) error {
+		result.Pruned++
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to prune event summary: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.More = next != ""
+	return result, nil
+}
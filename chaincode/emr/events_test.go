@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestLargeEventIsChunked(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"maxEventBytes":1024}`)
+	})
+	updates := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		recordID := fmt.Sprintf("migrated-record-%02d-%s", i, strings.Repeat("x", 24))
+		env.createRecord(doctor, recordID, patient.id)
+		updates = append(updates, `{"recordId":"`+recordID+`","ipfsCid":"bafynew","contentHash":"`+strings.Repeat("b", 64)+`"}`)
+	}
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecordsBatch(ctx, "["+strings.Join(updates, ",")+"]")
+	})
+
+	event := env.lastEvent()
+	if event.EventName != "RecordsBatchUpdated" || len(event.Payload) > 1024 {
+		t.Fatalf("event must keep its name and fit the limit: %s, %d bytes", event.EventName, len(event.Payload))
+	}
+	var first EventPart
+	if err := json.Unmarshal(event.Payload, &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Part != 1 || first.Total < 2 || first.EventType != "RecordsBatchUpdated" {
+		t.Fatalf("unexpected envelope: %+v", first)
+	}
+
+	// 监听方取回其余分片并拼接
+	payload := bytes.NewBuffer(first.Chunk)
+	for part := 2; part <= first.Total; part++ {
+		env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+			eventPart, err := env.cc.GetEventPart(ctx, first.EventID, part)
+			if err == nil {
+				payload.Write(eventPart.Chunk)
+			}
+			return err
+		})
+	}
+	var batch RecordsBatchUpdatedEvent
+	if err := json.Unmarshal(payload.Bytes(), &batch); err != nil {
+		t.Fatalf("reassembled payload must be the original JSON: %v", err)
+	}
+	if batch.Count != 40 || len(batch.RecordIDs) != 40 {
+		t.Fatalf("unexpected reassembled event: %+v", batch)
+	}
+	env.mustFail(other, "carried by the event itself", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetEventPart(ctx, first.EventID, 1)
+		return err
+	})
+
+	// 未超限的事件保持原始负载
+	env.createRecord(doctor, "small", patient.id)
+	env.expectEvent("RecordCreated", &RecordCreatedEvent{})
+	if bytes.Contains(env.lastEvent().Payload, []byte(`"chunk"`)) {
+		t.Fatal("small events must not be wrapped")
+	}
+}
+
+func TestEventIndexReplay(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.advance(time.Hour)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+
+	env.mustFail(doctor, "only admin or auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetEventsSince(ctx, "RecordCreated", from, 10, "")
+		return err
+	})
+	env.mustFail(auditor, "invalid eventType", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetEventsSince(ctx, "Record:Created", from, 10, "")
+		return err
+	})
+	var first *EventPage
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		first, err = env.cc.GetEventsSince(ctx, "RecordCreated", from, 1, "")
+		return err
+	})
+	if len(first.Events) != 1 || first.Events[0].RecordID != "rec1" || first.Events[0].PatientID != patient.id || first.Bookmark == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	if len(first.Events[0].PayloadHash) != 64 || first.Events[0].Size == 0 {
+		t.Fatalf("summary must carry the payload digest: %+v", first.Events[0])
+	}
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		next, err := env.cc.GetEventsSince(ctx, "RecordCreated", from, 1, first.Bookmark)
+		if err == nil && (len(next.Events) != 1 || next.Events[0].RecordID != "rec2") {
+			t.Fatalf("unexpected second page: %+v", next)
+		}
+		return err
+	})
+
+	// 起始时间之后只剩 rec2，其他类型不混入
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetEventsSince(ctx, "RecordCreated", env.stub.now.Add(-time.Minute).Format(time.RFC3339), 10, "")
+		if err == nil && (len(page.Events) != 1 || page.Events[0].RecordID != "rec2") {
+			t.Fatalf("fromTimestamp must bound the scan: %+v", page)
+		}
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetEventsSince(ctx, "AccessGranted", from, 10, "")
+		if err == nil && (len(page.Events) != 1 || page.Events[0].EventType != "AccessGranted") {
+			t.Fatalf("unexpected AccessGranted page: %+v", page)
+		}
+		return err
+	})
+}
+
+func TestPruneEvents(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustFail(admin, "retention is not configured", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.PruneEvents(ctx, "RecordCreated", 10)
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"eventRetentionDays":30}`)
+	})
+	env.advance(31 * 24 * time.Hour)
+	env.createRecord(doctor, "rec3", patient.id)
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.PruneEvents(ctx, "RecordCreated", 10)
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		result, err := env.cc.PruneEvents(ctx, "RecordCreated", 1)
+		if err == nil && (result.Pruned != 1 || !result.More) {
+			t.Fatalf("unexpected first prune: %+v", result)
+		}
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		result, err := env.cc.PruneEvents(ctx, "RecordCreated", 10)
+		if err == nil && (result.Pruned != 1 || result.More) {
+			t.Fatalf("unexpected second prune: %+v", result)
+		}
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetEventsSince(ctx, "RecordCreated", from, 10, "")
+		if err == nil && (len(page.Events) != 1 || page.Events[0].RecordID != "rec3") {
+			t.Fatalf("only the retained summary must remain: %+v", page)
+		}
+		return err
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RecordAuditSummary 按记录汇总的授权轨迹；逐次读取只以事件形式存在，不在世界状态中，故不计入
+type RecordAuditSummary struct {
+	RecordID         string `json:"recordId"`
+	ActiveGrants     int    `json:"activeGrants"`
+	InactiveGrants   int    `json:"inactiveGrants"`
+	LastAccessChange string `json:"lastAccessChange,omitempty"`
+}
+
+// BundleContent 可携导出的全部内容；BundleHash 为其 JSON 编码的 SHA-256
+type BundleContent struct {
+	PatientID    string                `json:"patientId"`
+	Records      []*MedicalRecord      `json:"records"`
+	AccessLists  []*AccessList         `json:"accessLists"`
+	Directives   []*AdvanceDirective   `json:"directives"`
+	AuditSummary []*RecordAuditSummary `json:"auditSummary"`
+}
+
+// BundleManifest 链上留存的导出清单，导入方以 VerifyPatientBundle 核对 bundleHash
+type BundleManifest struct {
+	ExportID    string `json:"exportId"`
+	PatientID   string `json:"patientId"`
+	RecordCount int    `json:"recordCount"`
+	BundleHash  string `json:"bundleHash"`
+	GeneratedAt string `json:"generatedAt"`
+	GeneratedBy string `json:"generatedBy"`
+}
+
+type PatientBundle struct {
+	Manifest *BundleManifest `json:"manifest"`
+	Content  *BundleContent  `json:"content"`
+}
+
+type BundleExportedEvent struct {
+	ExportID   string `json:"exportId"`
+	PatientID  string `json:"patientId"`
+	BundleHash string `json:"bundleHash"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func exportKey(exportID string) string {
+	return "export:" + exportID
+}
+
+func auditSummary(accessList *AccessList, recordID string, now time.Time) *RecordAuditSummary {
+	summary := &RecordAuditSummary{RecordID: recordID}
+	if accessList == nil {
+		return summary
+	}
+	for _, perm := range accessList.Permissions {
+		if permissionActive(perm, now) {
+			summary.ActiveGrants++
+		} else {
+			summary.InactiveGrants++
+		}
+	}
+	summary.LastAccessChange = accessList.UpdatedAt
+	return summary
+}
+
+// ExportPatientBundle 汇总患者全部记录锚点（含已归档）、访问列表、预立医嘱与授权审计摘要，
+// 以内容哈希生成清单并写入链上，供另一网络导入时核对；仅患者本人
+func (s *SmartContract) ExportPatientBundle(ctx contractapi.TransactionContextInterface, patientID string) (*PatientBundle, error) {
+	callerID, err := requirePatient(ctx, patientID, "export their data")
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &BundleContent{
+		PatientID:    patientID,
+		Records:      []*MedicalRecord{},
+		AccessLists:  []*AccessList{},
+		Directives:   []*AdvanceDirective{},
+		AuditSummary: []*RecordAuditSummary{},
+	}
+	err = scanIndex(ctx, patientRecordIndex, []string{patientID}, func(attrs []string) error {
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		accessList, err := getAccessList(ctx, record.RecordID)
+		if err != nil {
+			return err
+		}
+		content.Records = append(content.Records, record)
+		if accessList != nil {
+			content.AccessLists = append(content.AccessLists, accessList)
+		}
+		content.AuditSummary = append(content.AuditSummary, auditSummary(accessList, record.RecordID, now))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, directiveType := range directiveTypes {
+		directive, err := getAdvanceDirective(ctx, patientID, directiveType)
+		if err != nil {
+			return nil, err
+		}
+		if directive != nil {
+			content.Directives = append(content.Directives, directive)
+		}
+	}
+
+	// 结构体字段顺序固定、map 键按字典序编码，各背书节点得到相同哈希
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	manifest := &BundleManifest{
+		ExportID:    ctx.GetStub().GetTxID(),
+		PatientID:   patientID,
+		RecordCount: len(content.Records),
+		BundleHash:  hex.EncodeToString(sum[:]),
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		GeneratedBy: callerID,
+	}
+	if err := putJSON(ctx, exportKey(manifest.ExportID), manifest); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, "BundleExported", BundleExportedEvent{
+		ExportID:   manifest.ExportID,
+		PatientID:  patientID,
+		BundleHash: manifest.BundleHash,
+		Timestamp:  manifest.GeneratedAt,
+		CallerID:   callerID,
+		EventType:  "BundleExported",
+	}); err != nil {
+		return nil, err
+	}
+	return &PatientBundle{Manifest: manifest, Content: content}, nil
+}
+
+// VerifyPatientBundle 核对导出包内容哈希与链上清单是否一致
+func (s *SmartContract) VerifyPatientBundle(ctx contractapi.TransactionContextInterface, exportID, bundleHash string) (bool, error) {
+	var manifest BundleManifest
+	found, err := getJSON(ctx, exportKey(exportID), &manifest)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, fmt.Errorf("export not found: %s", exportID)
+	}
+	return manifest.BundleHash == bundleHash, nil
+}
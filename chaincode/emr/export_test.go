@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestExportPatientBundle(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(doctor, "rec3", "patient2")
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ArchiveRecord(ctx, "rec2")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterAdvanceDirective(ctx, patient.id, "dnr", strings.Repeat("c", 64), `["witness1","witness2"]`)
+	})
+
+	env.mustFail(doctor, "only the patient can export", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ExportPatientBundle(ctx, patient.id)
+		return err
+	})
+	var bundle *PatientBundle
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		bundle, err = env.cc.ExportPatientBundle(ctx, patient.id)
+		return err
+	})
+	env.expectEvent("BundleExported", nil)
+
+	content := bundle.Content
+	if len(content.Records) != 2 || len(content.AccessLists) != 2 || len(content.Directives) != 1 {
+		t.Fatalf("bundle must include archived records and directives: %+v", content)
+	}
+	for _, summary := range content.AuditSummary {
+		if summary.RecordID == "rec1" && summary.ActiveGrants != 2 {
+			t.Fatalf("rec1 must report the creator and nurse grants: %+v", summary)
+		}
+	}
+
+	// 导入方重新计算内容哈希并与链上清单核对
+	data, _ := json.Marshal(content)
+	sum := sha256.Sum256(data)
+	if bundle.Manifest.BundleHash != hex.EncodeToString(sum[:]) || bundle.Manifest.RecordCount != 2 {
+		t.Fatalf("unexpected manifest: %+v", bundle.Manifest)
+	}
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		ok, err := env.cc.VerifyPatientBundle(ctx, bundle.Manifest.ExportID, bundle.Manifest.BundleHash)
+		if err == nil && !ok {
+			t.Fatal("manifest hash must verify")
+		}
+		return err
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		ok, err := env.cc.VerifyPatientBundle(ctx, bundle.Manifest.ExportID, strings.Repeat("0", 64))
+		if err == nil && ok {
+			t.Fatal("tampered bundle must not verify")
+		}
+		return err
+	})
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// FeatureStrictCidValidation 记录的 ipfsCid 须为合法的 CIDv0 或 base32 CIDv1
+	FeatureStrictCidValidation = "strictCidValidation"
+	// FeatureMandatoryPurposeOfUse ReadRecord 须在 transient 中携带 purposeOfUse
+	FeatureMandatoryPurposeOfUse = "mandatoryPurposeOfUse"
+	// FeatureBreakGlass 允许持证临床人员以 BreakGlassAccess 紧急取得只读权限
+	FeatureBreakGlass = "breakGlass"
+	// FeaturePostAccessJustification 读取高敏记录后须在时限内补交理由，见 obligation.go
+	FeaturePostAccessJustification = "postAccessJustification"
+	// FeatureMandatoryReasonCode 读取记录与元数据须在 transient 中携带 reasonCode
+	FeatureMandatoryReasonCode = "mandatoryReasonCode"
+	// FeatureRequireTreatmentRelationship 治疗用途的读取须有有效治疗关系，见 treatment.go
+	FeatureRequireTreatmentRelationship = "requireTreatmentRelationship"
+)
+
+var knownFeatures = []string{
+	FeatureStrictCidValidation, FeatureMandatoryPurposeOfUse, FeatureBreakGlass,
+	FeaturePostAccessJustification, FeatureMandatoryReasonCode, FeatureRequireTreatmentRelationship,
+}
+
+var (
+	cidV0Pattern = regexp.MustCompile(`^Qm[1-9A-HJ-NP-Za-km-z]{44}$`)
+	cidV1Pattern = regexp.MustCompile(`^b[a-z2-7]{58,}$`)
+)
+
+// purposeOfUseCodes 读取目的代码，取自 HL7 PurposeOfUse 的常用子集
+var purposeOfUseCodes = []string{"treatment", "payment", "operations", "research", "public-health", "emergency"}
+
+// purposeOfUseTransientKey 读取目的经 transient 传入，不进入交易提案的公开参数
+const purposeOfUseTransientKey = "purposeOfUse"
+
+// reasonCodeTransientKey 读取原因代码同样经 transient 传入，取值见配置 reasonCodes
+const reasonCodeTransientKey = "reasonCode"
+
+// breakGlassDuration 紧急访问授权的有效期
+const breakGlassDuration = 4 * time.Hour
+
+type FeatureToggledEvent struct {
+	Feature   string `json:"feature"`
+	Enabled   bool   `json:"enabled"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// BreakGlassAccessEvent 紧急访问事件，负载含理由供事后复核；EventID 为复核队列条目
+type BreakGlassAccessEvent struct {
+	EventID       string `json:"eventId"`
+	RecordID      string `json:"recordId"`
+	PatientID     string `json:"patientId"`
+	GranteeID     string `json:"granteeId"`
+	Code          string `json:"code"`
+	Justification string `json:"justification"`
+	ExpiresAt     string `json:"expiresAt"`
+	Timestamp     string `json:"timestamp"`
+	EventType     string `json:"eventType"`
+}
+
+func featureEnabled(config *ContractConfig, name string) bool {
+	return config.Features[name]
+}
+
+func requireFeature(ctx contractapi.TransactionContextInterface, name string) error {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !featureEnabled(config, name) {
+		return fmt.Errorf("feature %s is not enabled", name)
+	}
+	return nil
+}
+
+// checkCid 启用 strictCidValidation 时校验 CID 格式
+func (config *ContractConfig) checkCid(cid string) error {
+	if !featureEnabled(config, FeatureStrictCidValidation) || cidV0Pattern.MatchString(cid) || cidV1Pattern.MatchString(cid) {
+		return nil
+	}
+	return fmt.Errorf("invalid ipfsCid: expected a CIDv0 or base32 CIDv1")
+}
+
+// purposeOfUse 读取 transient 中的读取目的；启用 mandatoryPurposeOfUse 时必填
+func purposeOfUse(ctx contractapi.TransactionContextInterface) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient data: %w", err)
+	}
+	purpose := string(transient[purposeOfUseTransientKey])
+	if purpose != "" && !containsString(purposeOfUseCodes, purpose) {
+		return "", fmt.Errorf("invalid purposeOfUse: %s", purpose)
+	}
+	if purpose == "" {
+		config, err := loadConfig(ctx)
+		if err != nil {
+			return "", err
+		}
+		if featureEnabled(config, FeatureMandatoryPurposeOfUse) {
+			return "", fmt.Errorf("purposeOfUse is required in transient data")
+		}
+	}
+	return purpose, nil
+}
+
+// readReasonCode 读取 transient 中的读取原因代码，须在配置的 reasonCodes 中；启用 mandatoryReasonCode 时必填
+func readReasonCode(ctx contractapi.TransactionContextInterface) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient data: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	reasonCode := string(transient[reasonCodeTransientKey])
+	if reasonCode == "" && featureEnabled(config, FeatureMandatoryReasonCode) {
+		return "", fmt.Errorf("reasonCode is required in transient data")
+	}
+	if reasonCode != "" && !containsString(config.ReasonCodes, reasonCode) {
+		return "", fmt.Errorf("invalid reasonCode: %s", reasonCode)
+	}
+	return reasonCode, nil
+}
+
+func setFeature(ctx contractapi.TransactionContextInterface, name string, enabled bool) error {
+	if !containsString(knownFeatures, name) {
+		return fmt.Errorf("unknown feature: %s", name)
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("access denied: only admin can toggle features")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Features == nil {
+		config.Features = map[string]bool{}
+	}
+	config.Features[name] = enabled
+	config.UpdatedAt = now
+	config.UpdatedBy = callerID
+	if err := putJSON(ctx, contractConfigKey, config); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "FeatureToggled", FeatureToggledEvent{
+		Feature:   name,
+		Enabled:   enabled,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "FeatureToggled",
+	})
+}
+
+// EnableFeature 开启功能开关；仅 admin
+func (s *SmartContract) EnableFeature(ctx contractapi.TransactionContextInterface, name string) error {
+	return setFeature(ctx, name, true)
+}
+
+// DisableFeature 关闭功能开关；仅 admin
+func (s *SmartContract) DisableFeature(ctx contractapi.TransactionContextInterface, name string) error {
+	return setFeature(ctx, name, false)
+}
+
+// ListFeatures 返回全部已知开关的当前状态
+func (s *SmartContract) ListFeatures(ctx contractapi.TransactionContextInterface) (map[string]bool, error) {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	features := make(map[string]bool, len(knownFeatures))
+	for _, name := range knownFeatures {
+		features[name] = featureEnabled(config, name)
+	}
+	return features, nil
+}
+
+// BreakGlassAccess 紧急情况下持证临床人员（doctor/nurse 角色）自行取得 4 小时只读权限；
+// code 须为配置的 breakGlassCodes 之一，理由随事件上链并进入待复核队列，见 breakglass.go。需开启 breakGlass 开关
+func (s *SmartContract) BreakGlassAccess(ctx contractapi.TransactionContextInterface, recordID, code, justification string) error {
+	if err := requireFeature(ctx, FeatureBreakGlass); err != nil {
+		return err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !containsString(config.BreakGlassCodes, code) {
+		return fmt.Errorf("invalid break-glass code: %q", code)
+	}
+	if len(justification) < 10 || len(justification) > 500 {
+		return fmt.Errorf("justification must be between 10 and 500 characters")
+	}
+	clinician, err := hasAnyRole(ctx, "doctor", "nurse")
+	if err != nil {
+		return err
+	}
+	if !clinician {
+		return fmt.Errorf("access denied: only doctor or nurse roles can break glass")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireProviderCredential(ctx, callerID); err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	perm := AccessPermission{
+		RecordID:  recordID,
+		GranteeID: callerID,
+		Action:    "read",
+		ExpiresAt: now.Add(breakGlassDuration).UTC().Format(time.RFC3339),
+		GrantedAt: now.UTC().Format(time.RFC3339),
+		GrantedBy: callerID,
+		IsActive:  true,
+	}
+	if err := storeGrant(ctx, record, perm); err != nil {
+		return err
+	}
+	eventID := ctx.GetStub().GetTxID()
+	if err := queueBreakGlassReview(ctx, &BreakGlassReview{
+		EventID:       eventID,
+		RecordID:      recordID,
+		PatientID:     record.PatientID,
+		AccessorID:    callerID,
+		Code:          code,
+		Justification: justification,
+		AccessedAt:    perm.GrantedAt,
+		Status:        BreakGlassPending,
+	}, now); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "BreakGlassAccess", BreakGlassAccessEvent{
+		EventID:       eventID,
+		RecordID:      recordID,
+		PatientID:     record.PatientID,
+		GranteeID:     callerID,
+		Code:          code,
+		Justification: justification,
+		ExpiresAt:     perm.ExpiresAt,
+		Timestamp:     perm.GrantedAt,
+		EventType:     "BreakGlassAccess",
+	})
+}
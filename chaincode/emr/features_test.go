@@ -0,0 +1,197 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) setFeature(name string, enabled bool) {
+	e.t.Helper()
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		if enabled {
+			return e.cc.EnableFeature(ctx, name)
+		}
+		return e.cc.DisableFeature(ctx, name)
+	})
+}
+
+func TestFeatureToggles(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EnableFeature(ctx, FeatureBreakGlass)
+	})
+	env.mustFail(admin, "unknown feature", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EnableFeature(ctx, "teleport")
+	})
+	env.setFeature(FeatureStrictCidValidation, true)
+	var event FeatureToggledEvent
+	env.expectEvent("FeatureToggled", &event)
+	if event.Feature != FeatureStrictCidValidation || !event.Enabled {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	// 替换配置不影响开关
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"maxBatchUpdates":10}`)
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		features, err := env.cc.ListFeatures(ctx)
+		if err == nil && (!features[FeatureStrictCidValidation] || features[FeatureBreakGlass] || len(features) != len(knownFeatures)) {
+			t.Fatalf("unexpected features: %v", features)
+		}
+		return err
+	})
+
+	create := func(recordID, cid string) error {
+		return env.invoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"`+recordID+`","patientId":"patient1","creatorId":"doctor1","ipfsCid":"`+cid+`","contentHash":"`+strings.Repeat("a", 64)+`"}`)
+			return err
+		})
+	}
+	if err := create("rec1", "bafy-not-a-cid"); err == nil || !strings.Contains(err.Error(), "invalid ipfsCid") {
+		t.Fatalf("strict CID validation must reject malformed CIDs, got %v", err)
+	}
+	if err := create("rec1", "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"); err != nil {
+		t.Fatalf("CIDv0 rejected: %v", err)
+	}
+	if err := create("rec2", "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"); err != nil {
+		t.Fatalf("CIDv1 rejected: %v", err)
+	}
+	env.setFeature(FeatureStrictCidValidation, false)
+	if err := create("rec3", "bafy-not-a-cid"); err != nil {
+		t.Fatalf("disabled feature must restore the previous behaviour: %v", err)
+	}
+}
+
+func TestMandatoryPurposeOfUse(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	read := func() error {
+		return env.invoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.ReadRecord(ctx, "rec1")
+			return err
+		})
+	}
+	if err := read(); err != nil {
+		t.Fatalf("purpose must be optional while the feature is off: %v", err)
+	}
+	env.setFeature(FeatureMandatoryPurposeOfUse, true)
+	if err := read(); err == nil || !strings.Contains(err.Error(), "purposeOfUse is required") {
+		t.Fatalf("expected missing purpose error, got %v", err)
+	}
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("marketing")}
+	if err := read(); err == nil || !strings.Contains(err.Error(), "invalid purposeOfUse") {
+		t.Fatalf("expected invalid purpose error, got %v", err)
+	}
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("treatment")}
+	defer func() { env.stub.TransientMap = nil }()
+	if err := read(); err != nil {
+		t.Fatal(err)
+	}
+	var event RecordAccessedEvent
+	env.expectEvent("RecordAccessed", &event)
+	if event.PurposeOfUse != "treatment" {
+		t.Fatalf("purpose must be recorded on the access event: %+v", event)
+	}
+}
+
+func TestBreakGlassAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	breakGlass := func(identity *testIdentity) error {
+		return env.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.BreakGlassAccess(ctx, "rec1", "unconscious-patient", "unconscious patient in ED, allergy history needed")
+		})
+	}
+	if err := breakGlass(nurse); err == nil || !strings.Contains(err.Error(), "feature breakGlass is not enabled") {
+		t.Fatalf("break-glass must be gated by its feature flag, got %v", err)
+	}
+	env.setFeature(FeatureBreakGlass, true)
+	if err := breakGlass(other); err == nil || !strings.Contains(err.Error(), "only doctor or nurse") {
+		t.Fatalf("expected role check, got %v", err)
+	}
+	if err := breakGlass(nurse); err != nil {
+		t.Fatal(err)
+	}
+	var event BreakGlassAccessEvent
+	env.expectEvent("BreakGlassAccess", &event)
+	if event.GranteeID != nurse.id || event.PatientID != patient.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("break-glass must grant temporary read access")
+	}
+	env.advance(5 * time.Hour)
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("break-glass access must expire")
+	}
+}
+
+func TestReasonCodeOnReads(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	from := env.stub.now.Format(time.RFC3339)
+	defer func() { env.stub.TransientMap = nil }()
+	read := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	}
+	metadata := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetRecordMetadata(ctx, "rec1")
+		return err
+	}
+	withReason := func(code string) {
+		env.stub.TransientMap = map[string][]byte{reasonCodeTransientKey: []byte(code)}
+	}
+
+	env.mustInvoke(doctor, read)
+	withReason("curiosity")
+	env.mustFail(doctor, "invalid reasonCode", read)
+	env.stub.TransientMap = nil
+	env.setFeature(FeatureMandatoryReasonCode, true)
+	env.mustFail(doctor, "reasonCode is required", read)
+	env.mustFail(doctor, "reasonCode is required", metadata)
+
+	withReason("direct-care")
+	env.advance(time.Minute)
+	env.mustInvoke(doctor, read)
+	var event RecordAccessedEvent
+	env.expectEvent("RecordAccessed", &event)
+	if event.ReasonCode != "direct-care" {
+		t.Fatalf("reason code must be recorded on the access event: %+v", event)
+	}
+	withReason("billing")
+	env.advance(time.Minute)
+	env.mustInvoke(doctor, metadata)
+	var metadataEvent RecordMetadataAccessedEvent
+	env.expectEvent("RecordMetadataAccessed", &metadataEvent)
+	if metadataEvent.ReasonCode != "billing" || metadataEvent.AccessorID != doctor.id {
+		t.Fatalf("unexpected event: %+v", metadataEvent)
+	}
+
+	env.stub.TransientMap = nil
+	to := env.stub.now.Format(time.RFC3339)
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.QueryAuditLog(ctx, "rec1", from, to, 10, "")
+		if err != nil {
+			return err
+		}
+		n := len(page.Events)
+		if n < 2 || page.Events[n-2].ReasonCode != "direct-care" || page.Events[n-1].ReasonCode != "billing" {
+			t.Fatalf("audit entries must carry the reason code: %+v", page.Events)
+		}
+		return nil
+	})
+
+	// 代码集可经配置替换
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"reasonCodes":["research-review"]}`)
+	})
+	withReason("direct-care")
+	env.mustFail(doctor, "invalid reasonCode", read)
+	withReason("research-review")
+	env.mustInvoke(doctor, read)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var fhirVersions = []string{"R4", "R5"}
+
+// fhirRequiredElements 各资源类型元数据的最小必填元素；未列出的资源类型拒绝
+var fhirRequiredElements = map[string][]string{
+	"DocumentReference": {"status", "content"},
+	"DiagnosticReport":  {"status", "code"},
+	"Observation":       {"status", "code"},
+	"ImagingStudy":      {"status", "subject"},
+	"MedicationRequest": {"status", "intent", "subject"},
+}
+
+func emptyFhirElement(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// validateFhir 校验记录声明的 FHIR 类型与元数据；未声明类型的记录保持原有行为
+func validateFhir(rec *MedicalRecord) error {
+	if rec.FhirResourceType == "" {
+		if rec.FhirVersion != "" || len(rec.FhirMetadata) > 0 {
+			return fmt.Errorf("fhirResourceType is required when fhirVersion or fhirMetadata is set")
+		}
+		return nil
+	}
+	required, ok := fhirRequiredElements[rec.FhirResourceType]
+	if !ok {
+		return fmt.Errorf("unsupported FHIR resource type: %s", rec.FhirResourceType)
+	}
+	if !containsString(fhirVersions, rec.FhirVersion) {
+		return fmt.Errorf("unsupported FHIR version: %q", rec.FhirVersion)
+	}
+	if resourceType, ok := rec.FhirMetadata["resourceType"]; ok && resourceType != rec.FhirResourceType {
+		return fmt.Errorf("fhirMetadata resourceType %v does not match %s", resourceType, rec.FhirResourceType)
+	}
+
+	var missing []string
+	for _, element := range required {
+		if emptyFhirElement(rec.FhirMetadata[element]) {
+			missing = append(missing, element)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing FHIR elements for %s: %s", rec.FhirResourceType, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func fhirRecord(recordID, fhirFields string) string {
+	return `{"recordId":"` + recordID + `","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"` +
+		strings.Repeat("a", 64) + `"` + fhirFields + `}`
+}
+
+func TestFhirResourceValidation(t *testing.T) {
+	env := newTestEnv(t)
+	cases := []struct {
+		fields   string
+		contains string
+	}{
+		{`,"fhirResourceType":"DiagnosticReport","fhirVersion":"R4","fhirMetadata":{"status":"final"}`, "missing FHIR elements for DiagnosticReport: code"},
+		{`,"fhirResourceType":"Patient","fhirVersion":"R4"`, "unsupported FHIR resource type"},
+		{`,"fhirResourceType":"Observation","fhirVersion":"STU3","fhirMetadata":{"status":"final","code":{"text":"x"}}`, "unsupported FHIR version"},
+		{`,"fhirVersion":"R4"`, "fhirResourceType is required"},
+		{`,"fhirResourceType":"Observation","fhirVersion":"R4","fhirMetadata":{"resourceType":"DiagnosticReport","status":"final","code":{"text":"x"}}`, "does not match"},
+	}
+	for _, c := range cases {
+		env.mustFail(doctor, c.contains, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, fhirRecord("rec1", c.fields))
+			return err
+		})
+	}
+
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, fhirRecord("rec1",
+			`,"fhirResourceType":"DocumentReference","fhirVersion":"R5","fhirMetadata":{"status":"current","content":[{"attachment":{"contentType":"application/pdf"}}]}`))
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		meta, err := env.cc.GetRecordMetadata(ctx, "rec1")
+		if err == nil && (meta.FhirResourceType != "DocumentReference" || meta.FhirVersion != "R5") {
+			t.Fatalf("metadata must expose FHIR typing: %+v", meta)
+		}
+		return err
+	})
+
+	// 未声明类型的记录保持原有行为
+	env.createRecord(doctor, "rec2", patient.id)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// securityOfficerRole 事件响应角色，可冻结疑似泄露的身份
+const securityOfficerRole = "security-officer"
+
+// IdentityFreeze 冻结期间该身份发起的任何交易（读取、写入、授权）都在入口处被拒绝，不论已有授权；
+// 以该身份为对象的 CheckAccess 同样返回 false
+type IdentityFreeze struct {
+	UserID     string `json:"userId"`
+	ReasonHash string `json:"reasonHash"`
+	FrozenBy   string `json:"frozenBy"`
+	FrozenAt   string `json:"frozenAt"`
+}
+
+type IdentityFreezeEvent struct {
+	UserID    string `json:"userId"`
+	Frozen    bool   `json:"frozen"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func frozenKey(userID string) string {
+	return "frozen:" + userID
+}
+
+// assertNotFrozen 各合约的 BeforeTransaction：证书本身或其绑定的应用用户被冻结时拒绝交易
+func assertNotFrozen(ctx contractapi.TransactionContextInterface) error {
+	certID, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	ids := []string{certID}
+	appUserID, err := boundUserID(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if appUserID != "" && appUserID != certID {
+		ids = append(ids, appUserID)
+	}
+	for _, id := range ids {
+		frozen, err := assetExists(ctx, frozenKey(id))
+		if err != nil {
+			return err
+		}
+		if frozen {
+			return fmt.Errorf("access denied: identity %s is frozen", id)
+		}
+	}
+	return nil
+}
+
+func requireSecurityOfficer(ctx contractapi.TransactionContextInterface) (string, error) {
+	isOfficer, err := hasRole(ctx, securityOfficerRole)
+	if err != nil {
+		return "", err
+	}
+	if !isOfficer {
+		return "", fmt.Errorf("access denied: only a security officer can freeze identities")
+	}
+	return getCallerID(ctx)
+}
+
+func emitFreezeEvent(ctx contractapi.TransactionContextInterface, eventName, userID, callerID string, frozen bool) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, IdentityFreezeEvent{
+		UserID:    userID,
+		Frozen:    frozen,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: eventName,
+	})
+}
+
+// FreezeIdentity 冻结证书 ID 或应用用户 ID；reasonHash 为事件工单的 sha256，原文留在线下
+func (s *SmartContract) FreezeIdentity(ctx contractapi.TransactionContextInterface, userID, reasonHash string) error {
+	if err := validateAddress(userID); err != nil {
+		return fmt.Errorf("invalid userID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(reasonHash) {
+		return fmt.Errorf("reasonHash must be a lowercase hex sha256 digest")
+	}
+	callerID, err := requireSecurityOfficer(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID == userID {
+		return fmt.Errorf("cannot freeze your own identity")
+	}
+	exists, err := assetExists(ctx, frozenKey(userID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("identity %s is already frozen", userID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, frozenKey(userID), IdentityFreeze{UserID: userID, ReasonHash: reasonHash, FrozenBy: callerID, FrozenAt: now}); err != nil {
+		return err
+	}
+	return emitFreezeEvent(ctx, "IdentityFrozen", userID, callerID, true)
+}
+
+// UnfreezeIdentity 解除冻结，已有授权随之恢复生效
+func (s *SmartContract) UnfreezeIdentity(ctx contractapi.TransactionContextInterface, userID string) error {
+	callerID, err := requireSecurityOfficer(ctx)
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, frozenKey(userID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("identity %s is not frozen", userID)
+	}
+	if err := ctx.GetStub().DelState(frozenKey(userID)); err != nil {
+		return fmt.Errorf("failed to delete freeze: %w", err)
+	}
+	return emitFreezeEvent(ctx, "IdentityUnfrozen", userID, callerID, false)
+}
+
+// GetIdentityFreeze 返回冻结条目；CheckAccess 对被冻结的 userId 已返回 false，网关无须另行确认
+func (s *SmartContract) GetIdentityFreeze(ctx contractapi.TransactionContextInterface, userID string) (*IdentityFreeze, error) {
+	var freeze IdentityFreeze
+	found, err := getJSON(ctx, frozenKey(userID), &freeze)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("identity %s is not frozen", userID)
+	}
+	return &freeze, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var securityOfficer = newIdentity("secops1", "Org1MSP", "role", "security-officer")
+
+func TestFreezeIdentityBlocksEveryContract(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	reasonHash := strings.Repeat("f", 64)
+
+	env.mustFail(doctor, "only a security officer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FreezeIdentity(ctx, nurse.id, reasonHash)
+	})
+	env.mustFail(securityOfficer, "your own identity", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FreezeIdentity(ctx, securityOfficer.id, reasonHash)
+	})
+	env.mustInvoke(securityOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FreezeIdentity(ctx, nurse.id, reasonHash)
+	})
+	env.expectEvent("IdentityFrozen", nil)
+	// 网关代查冻结身份同样被拒，授权本身不变
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("CheckAccess must deny a frozen identity")
+	}
+
+	contracts := newContracts()
+	if len(contracts) != 3 {
+		t.Fatalf("expected 3 contracts, got %d", len(contracts))
+	}
+	for _, contract := range contracts {
+		hook, ok := contract.GetBeforeTransaction().(func(contractapi.TransactionContextInterface) error)
+		if !ok {
+			t.Fatalf("%T must run assertNotFrozen before every transaction", contract)
+		}
+		env.mustFail(nurse, "is frozen", hook)
+		env.mustInvoke(doctor, hook)
+	}
+
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		freeze, err := env.cc.GetIdentityFreeze(ctx, nurse.id)
+		if err == nil && (freeze.FrozenBy != securityOfficer.id || freeze.ReasonHash != reasonHash) {
+			t.Fatalf("unexpected freeze: %+v", freeze)
+		}
+		return err
+	})
+	env.mustInvoke(securityOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnfreezeIdentity(ctx, nurse.id)
+	})
+	env.expectEvent("IdentityUnfrozen", nil)
+	env.mustInvoke(nurse, assertNotFrozen)
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("existing grants must survive a freeze")
+	}
+}
+
+func TestFreezeAppliesToBoundUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	env.mustInvoke(securityOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FreezeIdentity(ctx, "patient-alice", strings.Repeat("f", 64))
+	})
+	env.mustFail(aliceCert, "identity patient-alice is frozen", assertNotFrozen)
+
+	// 以证书 ID 查询时按其绑定的应用用户判断
+	env.createRecord(doctor, "rec1", "patient-alice")
+	if env.checkAccess("rec1", "patient-alice") || env.checkAccess("rec1", aliceCert.id) {
+		t.Fatal("CheckAccess must deny a frozen bound user")
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// maxHandoverShift 交接授权最长有效期
+	maxHandoverShift = 24 * time.Hour
+	// maxHandoverRecords 单次交接可转交的记录上限，避免写集过大
+	maxHandoverRecords = 200
+)
+
+// AccessHandover 交接班授权委托：交班人将其有效的治疗授权转交接班人至 shiftEnd；
+// Skipped 为范围内未转交的记录（非治疗授权、高敏记录或接班人已有授权）
+type AccessHandover struct {
+	HandoverID string   `json:"handoverId"`
+	FromID     string   `json:"fromId"`
+	ToID       string   `json:"toId"`
+	RecordIDs  []string `json:"recordIds"`
+	Skipped    []string `json:"skipped,omitempty"`
+	ShiftEnd   string   `json:"shiftEnd"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type AccessHandedOverEvent struct {
+	HandoverID string   `json:"handoverId"`
+	FromID     string   `json:"fromId"`
+	ToID       string   `json:"toId"`
+	RecordIDs  []string `json:"recordIds"`
+	ShiftEnd   string   `json:"shiftEnd"`
+	Timestamp  string   `json:"timestamp"`
+	EventType  string   `json:"eventType"`
+}
+
+// handoverKey handover:{fromId}:{epoch}:{handoverId}
+func handoverKey(fromID string, createdAt time.Time, handoverID string) string {
+	return handoverPrefix(fromID) + epochSegment(createdAt) + ":" + handoverID
+}
+
+func handoverPrefix(fromID string) string {
+	return "handover:" + keySegment(fromID) + ":"
+}
+
+// treatmentGrant 可交接的治疗授权：有效、整条记录的 read/write 授权，不限次且不引用数据使用协议
+func treatmentGrant(ctx contractapi.TransactionContextInterface, perm *AccessPermission, now time.Time) (bool, error) {
+	if perm == nil || (perm.Action != "read" && perm.Action != "write") {
+		return false, nil
+	}
+	if perm.MaxUses > 0 || perm.DUAHash != "" {
+		return false, nil
+	}
+	return grantUsable(ctx, *perm, now, accessTarget{})
+}
+
+// capExpiresAt 到期时间不晚于 limit；未设到期时取 limit。比较按时间而非字符串，授权时间可带任意时区
+func capExpiresAt(expiresAt string, limit time.Time) string {
+	if expires, err := time.Parse(time.RFC3339, expiresAt); err == nil && expires.Before(limit) {
+		return expiresAt
+	}
+	return limit.UTC().Format(time.RFC3339)
+}
+
+// HandoverAccess 交班人把 scopeJson 范围内自己有效的治疗授权转交接班人，新授权在 shiftEnd 与原到期时间中较早者失效，
+// 并标记 handoverId 以区别于普通授权。scopeJson 为 {recordIds} 或 {patientIds}：
+// 按记录转交时每条记录都须是治疗授权；按患者转交时只转交其中的治疗授权
+func (s *SmartContract) HandoverAccess(ctx contractapi.TransactionContextInterface, fromID, toID, scopeJson, shiftEnd string) (*AccessHandover, error) {
+	var scope struct {
+		RecordIDs  []string `json:"recordIds"`
+		PatientIDs []string `json:"patientIds"`
+	}
+	if err := unmarshalArg(scopeJson, &scope); err != nil {
+		return nil, fmt.Errorf("invalid scope json: %w", err)
+	}
+	if (len(scope.RecordIDs) == 0) == (len(scope.PatientIDs) == 0) {
+		return nil, fmt.Errorf("scope must list either recordIds or patientIds")
+	}
+	if err := validateAddress(toID); err != nil {
+		return nil, fmt.Errorf("invalid toID: %w", err)
+	}
+	if toID == fromID {
+		return nil, fmt.Errorf("cannot hand over access to yourself")
+	}
+	isFrom, err := callerIs(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	if !isFrom {
+		return nil, fmt.Errorf("access denied: only %s can hand over their grants", fromID)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(time.RFC3339, shiftEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shiftEnd: %w", err)
+	}
+	if !end.After(now) || end.Sub(now) > maxHandoverShift {
+		return nil, fmt.Errorf("shiftEnd must be within %s of now", maxHandoverShift)
+	}
+
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIDs := scope.RecordIDs
+	if len(scope.PatientIDs) > 0 {
+		recordIDs = []string{}
+		err = scanIndex(ctx, granteeRecordIndex, []string{fromID}, func(attrs []string) error {
+			record, err := getRecord(ctx, attrs[1])
+			if err != nil {
+				return err
+			}
+			if containsString(scope.PatientIDs, record.PatientID) {
+				recordIDs = append(recordIDs, record.RecordID)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(recordIDs) > maxHandoverRecords {
+		return nil, fmt.Errorf("handover must not exceed %d records", maxHandoverRecords)
+	}
+
+	handover := &AccessHandover{
+		HandoverID: ctx.GetStub().GetTxID(),
+		FromID:     fromID,
+		ToID:       toID,
+		RecordIDs:  []string{},
+		ShiftEnd:   end.UTC().Format(time.RFC3339),
+		CreatedAt:  now.Format(time.RFC3339),
+	}
+	for _, recordID := range recordIDs {
+		handed, err := handOver(ctx, config, handover, recordID, now, len(scope.RecordIDs) > 0)
+		if err != nil {
+			return nil, err
+		}
+		if handed {
+			handover.RecordIDs = append(handover.RecordIDs, recordID)
+		} else {
+			handover.Skipped = append(handover.Skipped, recordID)
+		}
+	}
+	if len(handover.RecordIDs) == 0 {
+		return nil, fmt.Errorf("no treatment grants of %s to hand over", fromID)
+	}
+	if err := putJSON(ctx, handoverKey(fromID, now, handover.HandoverID), handover); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, "AccessHandedOver", AccessHandedOverEvent{
+		HandoverID: handover.HandoverID,
+		FromID:     fromID,
+		ToID:       toID,
+		RecordIDs:  handover.RecordIDs,
+		ShiftEnd:   handover.ShiftEnd,
+		Timestamp:  handover.CreatedAt,
+		EventType:  "AccessHandedOver",
+	}); err != nil {
+		return nil, err
+	}
+	return handover, nil
+}
+
+// handOver 转交单条记录的授权；strict 为 true 时交班人不持治疗授权即报错，否则跳过
+func handOver(ctx contractapi.TransactionContextInterface, config *ContractConfig, handover *AccessHandover, recordID string, now time.Time, strict bool) (bool, error) {
+	perm, err := getPermission(ctx, recordID, handover.FromID)
+	if err != nil {
+		return false, err
+	}
+	treatment, err := treatmentGrant(ctx, perm, now)
+	if err != nil {
+		return false, err
+	}
+	if !treatment {
+		if strict {
+			return false, fmt.Errorf("%s holds no treatment grant on record %s", handover.FromID, recordID)
+		}
+		return false, nil
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	// 高敏记录的授权须会签；接班人已有授权时不覆盖，以免缩短原授权
+	if record.Sensitivity == SensitivityHigh {
+		return false, nil
+	}
+	existing, err := getPermission(ctx, recordID, handover.ToID)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && permissionActive(*existing, now) {
+		return false, nil
+	}
+
+	shiftEnd, _ := time.Parse(time.RFC3339, handover.ShiftEnd)
+	expiresAt := capExpiresAt(perm.ExpiresAt, shiftEnd)
+	if err := config.checkGrant(perm.Action, expiresAt, now); err != nil {
+		return false, err
+	}
+	return true, storeGrant(ctx, record, AccessPermission{
+		RecordID:   recordID,
+		GranteeID:  handover.ToID,
+		Action:     perm.Action,
+		ExpiresAt:  expiresAt,
+		GrantedAt:  handover.CreatedAt,
+		GrantedBy:  handover.FromID,
+		IsActive:   true,
+		HandoverID: handover.HandoverID,
+	})
+}
+
+// ListHandovers 按时间返回 fromID 发起的交接；限交班人本人与审计员
+func (s *SmartContract) ListHandovers(ctx contractapi.TransactionContextInterface, fromID string) ([]*AccessHandover, error) {
+	if err := validateAddress(fromID); err != nil {
+		return nil, fmt.Errorf("invalid fromID: %w", err)
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = callerIs(ctx, fromID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only %s or an auditor can list handovers", fromID)
+	}
+
+	prefix := handoverPrefix(fromID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan handovers: %w", err)
+	}
+	defer iterator.Close()
+
+	handovers := []*AccessHandover{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate handovers: %w", err)
+		}
+		var handover AccessHandover
+		if err := json.Unmarshal(kv.Value, &handover); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal handover: %w", err)
+		}
+		handovers = append(handovers, &handover)
+	}
+	return handovers, nil
+}
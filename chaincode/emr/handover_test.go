@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestHandoverTransfersTreatmentGrantsUntilShiftEnd(t *testing.T) {
+	env := newTestEnv(t)
+	for _, id := range []string{"rec1", "rec2", "rec3"} {
+		env.createRecord(doctor, id, patient.id)
+	}
+	env.createRecord(doctor, "rec4", other.id)
+	env.grant(patient, "rec1", nurse.id, "write", env.stub.now.Add(48*time.Hour).Format(time.RFC3339))
+	env.grant(patient, "rec2", nurse.id, "read", env.stub.now.Add(2*time.Hour).Format(time.RFC3339))
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithUses(ctx, "rec3", nurse.id, "", 3)
+	})
+	env.grant(other, "rec4", nurse.id, "read", "")
+
+	shiftEnd := env.stub.now.Add(8 * time.Hour).Format(time.RFC3339)
+	handover := func(scope, end string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.HandoverAccess(ctx, nurse.id, specialist.id, scope, end)
+			return err
+		}
+	}
+	env.mustFail(specialist, "only "+nurse.id, handover(`{"recordIds":["rec1"]}`, shiftEnd))
+	env.mustFail(nurse, "within", handover(`{"recordIds":["rec1"]}`, env.stub.now.Add(25*time.Hour).Format(time.RFC3339)))
+	env.mustFail(nurse, "either recordIds or patientIds", handover(`{"recordIds":["rec1"],"patientIds":["`+patient.id+`"]}`, shiftEnd))
+	// 限次授权不是治疗授权，按记录转交时报错
+	env.mustFail(nurse, "no treatment grant on record rec3", handover(`{"recordIds":["rec1","rec3"]}`, shiftEnd))
+
+	var result *AccessHandover
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = env.cc.HandoverAccess(ctx, nurse.id, specialist.id, `{"patientIds":["`+patient.id+`"]}`, shiftEnd)
+		return err
+	})
+	if len(result.RecordIDs) != 2 || len(result.Skipped) != 1 || result.Skipped[0] != "rec3" {
+		t.Fatalf("unexpected handover: %+v", result)
+	}
+	var event AccessHandedOverEvent
+	env.expectEvent("AccessHandedOver", &event)
+	if event.HandoverID != result.HandoverID || event.ToID != specialist.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	perm := storedPermission(env, "rec1", specialist.id)
+	if perm.HandoverID != result.HandoverID || perm.GrantedBy != nurse.id || perm.Action != "write" || perm.ExpiresAt != shiftEnd {
+		t.Fatalf("handed-over grant must be marked and end with the shift: %+v", perm)
+	}
+	// 原授权更早到期时沿用原到期时间
+	if perm := storedPermission(env, "rec2", specialist.id); perm.ExpiresAt != env.stub.now.Add(2*time.Hour).Format(time.RFC3339) {
+		t.Fatalf("handed-over grant must not outlive the original: %+v", perm)
+	}
+	if !env.checkAccess("rec1", specialist.id) || env.checkAccess("rec4", specialist.id) {
+		t.Fatal("handover must cover exactly the patient's treatment grants")
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("the outgoing identity keeps its own grants")
+	}
+
+	env.advance(8 * time.Hour)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("handed-over access must end with the shift")
+	}
+
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		handovers, err := env.cc.ListHandovers(ctx, nurse.id)
+		if err == nil && (len(handovers) != 1 || handovers[0].HandoverID != result.HandoverID) {
+			t.Fatalf("unexpected handovers: %+v", handovers)
+		}
+		return err
+	})
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListHandovers(ctx, nurse.id)
+		return err
+	})
+}
+
+func storedPermission(env *testEnv, recordID, granteeID string) AccessPermission {
+	env.t.Helper()
+	var perm AccessPermission
+	if err := json.Unmarshal(env.stub.State[permKey(recordID, granteeID)], &perm); err != nil {
+		env.t.Fatalf("permission %s/%s: %v", recordID, granteeID, err)
+	}
+	return perm
+}
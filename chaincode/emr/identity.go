@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const userCertIndex = "user~cert"
+
+// IdentityBinding 证书 ID → 应用层用户 ID（patientId/creatorId 使用的 ID）
+type IdentityBinding struct {
+	AppUserID string `json:"appUserId"`
+	CertID    string `json:"certId"`
+	BoundBy   string `json:"boundBy"`
+	BoundAt   string `json:"boundAt"`
+}
+
+type IdentityBindingEvent struct {
+	AppUserID string `json:"appUserId"`
+	CertID    string `json:"certId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func bindingKey(certID string) string {
+	return "bind:" + certID
+}
+
+// callerCertID 返回调用者证书的原始 ID，绑定管理只认证书本身；身份恢复后停用的旧证书一律拒绝
+func callerCertID(ctx contractapi.TransactionContextInterface) (string, error) {
+	certID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	retired, err := assetExists(ctx, retiredCertKey(certID))
+	if err != nil {
+		return "", err
+	}
+	if retired {
+		return "", fmt.Errorf("access denied: certificate was retired by identity recovery")
+	}
+	return certID, nil
+}
+
+// boundUserID 返回证书绑定的应用用户 ID，未绑定时返回空串
+func boundUserID(ctx contractapi.TransactionContextInterface, certID string) (string, error) {
+	var binding IdentityBinding
+	found, err := getJSON(ctx, bindingKey(certID), &binding)
+	if err != nil || !found {
+		return "", err
+	}
+	return binding.AppUserID, nil
+}
+
+// subjectIDs 返回 userID 在访问判断中代表的全部主体：自身、证书绑定的应用用户 ID，以及它们控制的 DID
+func subjectIDs(ctx contractapi.TransactionContextInterface, userID string) ([]string, error) {
+	subjects := []string{userID}
+	appUserID, err := boundUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if appUserID != "" && appUserID != userID {
+		subjects = append(subjects, appUserID)
+	}
+	for _, id := range subjects {
+		did, err := controlledDID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if did != "" {
+			subjects = append(subjects, did)
+		}
+	}
+	return subjects, nil
+}
+
+// callerIs 调用者证书本身、其绑定的应用用户 ID 或控制的 DID 等于 subjectID
+func callerIs(ctx contractapi.TransactionContextInterface, subjectID string) (bool, error) {
+	certID, err := callerCertID(ctx)
+	if err != nil {
+		return false, err
+	}
+	subjects, err := subjectIDs(ctx, certID)
+	if err != nil {
+		return false, err
+	}
+	return containsString(subjects, subjectID), nil
+}
+
+// callerIsAny 调用者是否为 ids 中任一主体；空串不匹配
+func callerIsAny(ctx contractapi.TransactionContextInterface, ids ...string) (bool, error) {
+	certID, err := callerCertID(ctx)
+	if err != nil {
+		return false, err
+	}
+	subjects, err := subjectIDs(ctx, certID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id != "" && containsString(subjects, id) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// userHasCertificate 应用用户是否已绑定任一证书
+func userHasCertificate(ctx contractapi.TransactionContextInterface, appUserID string) (bool, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(userCertIndex, []string{appUserID})
+	if err != nil {
+		return false, fmt.Errorf("failed to query %s index: %w", userCertIndex, err)
+	}
+	defer iterator.Close()
+	return iterator.HasNext(), nil
+}
+
+func writeBinding(ctx contractapi.TransactionContextInterface, appUserID, certID, boundBy string) error {
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, bindingKey(certID), IdentityBinding{
+		AppUserID: appUserID,
+		CertID:    certID,
+		BoundBy:   boundBy,
+		BoundAt:   now,
+	}); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, userCertIndex, appUserID, certID); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "IdentityBound", IdentityBindingEvent{
+		AppUserID: appUserID,
+		CertID:    certID,
+		Timestamp: now,
+		CallerID:  boundBy,
+		EventType: "IdentityBound",
+	})
+}
+
+// removeBinding 删除绑定及反向索引；证书未绑定时报错
+func removeBinding(ctx contractapi.TransactionContextInterface, certID, callerID string) (string, error) {
+	appUserID, err := boundUserID(ctx, certID)
+	if err != nil {
+		return "", err
+	}
+	if appUserID == "" {
+		return "", fmt.Errorf("certificate is not bound: %s", certID)
+	}
+	if err := ctx.GetStub().DelState(bindingKey(certID)); err != nil {
+		return "", fmt.Errorf("failed to delete binding: %w", err)
+	}
+	if err := delIndex(ctx, userCertIndex, appUserID, certID); err != nil {
+		return "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	return appUserID, emitEvent(ctx, "IdentityUnbound", IdentityBindingEvent{
+		AppUserID: appUserID,
+		CertID:    certID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "IdentityUnbound",
+	})
+}
+
+// BindIdentity 将证书绑定到应用用户 ID，仅 admin 或 registrar（线下核验身份后）可绑定；不接受本人自证，
+// 否则任何证书都能认领尚未绑定证书的 patientId。registrar 不能向已绑定证书的用户追加证书，须走 LinkCertificate
+func (s *SmartContract) BindIdentity(ctx contractapi.TransactionContextInterface, appUserID, certID string) error {
+	if err := validateAddress(appUserID); err != nil {
+		return fmt.Errorf("invalid appUserID: %w", err)
+	}
+	if err := validateAddress(certID); err != nil {
+		return fmt.Errorf("invalid certID: %w", err)
+	}
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		isRegistrar, err := hasRole(ctx, "registrar")
+		if err != nil {
+			return err
+		}
+		if !isRegistrar {
+			return fmt.Errorf("access denied: only admin or a registrar can bind certificates")
+		}
+		taken, err := userHasCertificate(ctx, appUserID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("access denied: %s is already bound; link further certificates with LinkCertificate", appUserID)
+		}
+	}
+	existing, err := boundUserID(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return fmt.Errorf("certificate already bound to %s", existing)
+	}
+	return writeBinding(ctx, appUserID, certID, callerCert)
+}
+
+// UnbindIdentity 解除证书绑定，admin 或证书本人
+func (s *SmartContract) UnbindIdentity(ctx contractapi.TransactionContextInterface, appUserID, certID string) error {
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin && callerCert != certID {
+		return fmt.Errorf("access denied: only admin or the certificate holder can unbind")
+	}
+	existing, err := boundUserID(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if existing != appUserID {
+		return fmt.Errorf("certificate %s is not bound to %s", certID, appUserID)
+	}
+	_, err = removeBinding(ctx, certID, callerCert)
+	return err
+}
+
+// ResolveIdentity 返回证书绑定的应用用户 ID
+func (s *SmartContract) ResolveIdentity(ctx contractapi.TransactionContextInterface, certID string) (string, error) {
+	appUserID, err := boundUserID(ctx, certID)
+	if err != nil {
+		return "", err
+	}
+	if appUserID == "" {
+		return "", fmt.Errorf("certificate is not bound: %s", certID)
+	}
+	return appUserID, nil
+}
+
+const (
+	CertLinkPending   = "pending"
+	CertLinkConfirmed = "confirmed"
+
+	// certLinkTTL 待确认的关联请求有效期
+	certLinkTTL = 24 * time.Hour
+)
+
+// CertificateLink 新证书发起的关联请求，须由同一用户已关联的另一张证书确认
+type CertificateLink struct {
+	LinkID      string `json:"linkId"`
+	AppUserID   string `json:"appUserId"`
+	NewCertID   string `json:"newCertId"`
+	Status      string `json:"status"`
+	RequestedAt string `json:"requestedAt"`
+	ExpiresAt   string `json:"expiresAt"`
+	ConfirmedBy string `json:"confirmedBy,omitempty"`
+}
+
+func certLinkKey(linkID string) string {
+	return "certlink:" + linkID
+}
+
+// LinkCertificate 由新证书发起，把自己关联到已有的应用用户；返回待确认的 linkId
+func (s *SmartContract) LinkCertificate(ctx contractapi.TransactionContextInterface, appUserID, newCertID string) (string, error) {
+	if err := validateAddress(appUserID); err != nil {
+		return "", fmt.Errorf("invalid appUserID: %w", err)
+	}
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if callerCert != newCertID {
+		return "", fmt.Errorf("access denied: a link must be requested from the new certificate")
+	}
+	existing, err := boundUserID(ctx, newCertID)
+	if err != nil {
+		return "", err
+	}
+	if existing != "" {
+		return "", fmt.Errorf("certificate already bound to %s", existing)
+	}
+	hasCert, err := userHasCertificate(ctx, appUserID)
+	if err != nil {
+		return "", err
+	}
+	if !hasCert {
+		return "", fmt.Errorf("%s has no linked certificate to confirm the request; use BindIdentity", appUserID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	link := CertificateLink{
+		LinkID:      ctx.GetStub().GetTxID(),
+		AppUserID:   appUserID,
+		NewCertID:   newCertID,
+		Status:      CertLinkPending,
+		RequestedAt: now.UTC().Format(time.RFC3339),
+		ExpiresAt:   now.Add(certLinkTTL).UTC().Format(time.RFC3339),
+	}
+	if err := putJSON(ctx, certLinkKey(link.LinkID), link); err != nil {
+		return "", err
+	}
+	// 通知已关联终端有待确认的请求
+	return link.LinkID, emitEvent(ctx, "CertificateLinkRequested", IdentityBindingEvent{
+		AppUserID: appUserID,
+		CertID:    newCertID,
+		Timestamp: link.RequestedAt,
+		CallerID:  callerCert,
+		EventType: "CertificateLinkRequested",
+	})
+}
+
+// ConfirmCertificateLink 同一用户已关联的证书确认请求后写入绑定
+func (s *SmartContract) ConfirmCertificateLink(ctx contractapi.TransactionContextInterface, linkID string) error {
+	var link CertificateLink
+	found, err := getJSON(ctx, certLinkKey(linkID), &link)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("certificate link not found: %s", linkID)
+	}
+	if link.Status != CertLinkPending {
+		return fmt.Errorf("certificate link %s is %s", linkID, link.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, link.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("certificate link %s has expired", linkID)
+	}
+
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	callerUser, err := boundUserID(ctx, callerCert)
+	if err != nil {
+		return err
+	}
+	if callerCert == link.NewCertID || callerUser != link.AppUserID {
+		return fmt.Errorf("access denied: only a certificate already linked to %s can confirm", link.AppUserID)
+	}
+	// 请求发出后新证书可能已被管理员绑定到别处
+	existing, err := boundUserID(ctx, link.NewCertID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return fmt.Errorf("certificate already bound to %s", existing)
+	}
+
+	link.Status = CertLinkConfirmed
+	link.ConfirmedBy = callerCert
+	if err := putJSON(ctx, certLinkKey(linkID), link); err != nil {
+		return err
+	}
+	return writeBinding(ctx, link.AppUserID, link.NewCertID, callerCert)
+}
+
+// UnlinkCertificate 由同一用户的另一张已关联证书解除关联，不能解除最后一张
+func (s *SmartContract) UnlinkCertificate(ctx contractapi.TransactionContextInterface, appUserID, certID string) error {
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	callerUser, err := boundUserID(ctx, callerCert)
+	if err != nil {
+		return err
+	}
+	if callerCert == certID || callerUser != appUserID {
+		return fmt.Errorf("access denied: unlink must come from another certificate linked to %s", appUserID)
+	}
+	existing, err := boundUserID(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if existing != appUserID {
+		return fmt.Errorf("certificate %s is not bound to %s", certID, appUserID)
+	}
+	// 调用者证书本身仍关联在该用户下，因此解除后至少保留一张
+	_, err = removeBinding(ctx, certID, callerCert)
+	return err
+}
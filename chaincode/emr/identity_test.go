@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// 证书 ID 与 cid.GetID 一致，为 base64 编码的 x509::subject::issuer
+var aliceCert = newIdentity("eDUwOTo6Q049YWxpY2UsT1U9Y2xpZW50OjpDTj1jYS5vcmcx", "Org1MSP")
+
+func TestBindIdentityRequiresRegistrar(t *testing.T) {
+	env := newTestEnv(t)
+	mallory := newIdentity("eDUwOTo6Q049bWFsbG9yeSxPVT1jbGllbnQ6OkNOPWNhLm9yZzM=", "Org3MSP")
+	env.createRecord(doctor, "rec-p1", patient.id)
+
+	// 本人自证不再被接受：既不能认领他人的 patientId，也不能认领尚未绑定证书的应用用户 ID
+	for _, appUserID := range []string{patient.id, "patient-alice"} {
+		env.mustFail(mallory, "only admin or a registrar", func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.BindIdentity(ctx, appUserID, mallory.id)
+		})
+	}
+	env.mustFail(mallory, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec-p1")
+		return err
+	})
+	env.mustFail(mallory, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec-p1", "eve", "read")
+	})
+
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	env.expectEvent("IdentityBound", nil)
+	env.mustFail(registrar, "link further certificates", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", mallory.id)
+	})
+	env.mustFail(registrar, "certificate already bound", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice2", aliceCert.id)
+	})
+
+	// 记录 JSON 使用应用层 ID，不再需要嵌入证书主题串
+	env.createRecord(doctor, "rec1", "patient-alice")
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.grant(aliceCert, "rec1", nurse.id, "read", "")
+	var event AccessGrantedEvent
+	env.expectEvent("AccessGranted", &event)
+	if event.CallerID != "patient-alice" {
+		t.Fatalf("events must carry the application user ID, got %s", event.CallerID)
+	}
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetAccessList(ctx, "rec1")
+		return err
+	})
+	if !env.checkAccess("rec1", aliceCert.id) {
+		t.Fatal("CheckAccess must resolve the certificate binding")
+	}
+}
+
+func TestAdminBindAndUnbind(t *testing.T) {
+	env := newTestEnv(t)
+	kiosk := newIdentity("eDUwOTo6Q049a2lvc2stNyxPVT1jbGllbnQ6OkNOPWNhLm9yZzE=", "Org1MSP")
+
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", kiosk.id)
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		appUserID, err := env.cc.ResolveIdentity(ctx, kiosk.id)
+		if err == nil && appUserID != "patient-alice" {
+			t.Fatalf("unexpected binding: %s", appUserID)
+		}
+		return err
+	})
+	env.createRecord(doctor, "rec1", "patient-alice")
+	if !env.checkAccess("rec1", kiosk.id) {
+		t.Fatal("bound certificate must act as the patient")
+	}
+
+	env.mustFail(other, "only admin or the certificate holder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnbindIdentity(ctx, "patient-alice", kiosk.id)
+	})
+	env.mustInvoke(kiosk, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnbindIdentity(ctx, "patient-alice", kiosk.id)
+	})
+	env.expectEvent("IdentityUnbound", nil)
+	if env.checkAccess("rec1", kiosk.id) {
+		t.Fatal("unbound certificate must lose patient access")
+	}
+}
+
+func TestLinkCertificateAcrossDevices(t *testing.T) {
+	env := newTestEnv(t)
+	phone := newIdentity("eDUwOTo6Q049YWxpY2UtcGhvbmUsT1U9Y2xpZW50OjpDTj1jYS5vcmcx", "Org1MSP")
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	env.createRecord(doctor, "rec1", "patient-alice")
+
+	env.mustFail(aliceCert, "requested from the new certificate", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.LinkCertificate(ctx, "patient-alice", phone.id)
+		return err
+	})
+	var linkID string
+	env.mustInvoke(phone, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		linkID, err = env.cc.LinkCertificate(ctx, "patient-alice", phone.id)
+		return err
+	})
+	env.expectEvent("CertificateLinkRequested", nil)
+	if env.checkAccess("rec1", phone.id) {
+		t.Fatal("pending link must not grant access")
+	}
+
+	env.mustFail(phone, "only a certificate already linked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ConfirmCertificateLink(ctx, linkID)
+	})
+	env.mustFail(other, "only a certificate already linked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ConfirmCertificateLink(ctx, linkID)
+	})
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ConfirmCertificateLink(ctx, linkID)
+	})
+	if !env.checkAccess("rec1", phone.id) {
+		t.Fatal("linked certificate must act as the patient")
+	}
+	env.grant(phone, "rec1", nurse.id, "read", "")
+
+	// 证书不能解除自己，须由另一张已关联证书发起
+	env.mustFail(aliceCert, "another certificate", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlinkCertificate(ctx, "patient-alice", aliceCert.id)
+	})
+	env.mustInvoke(phone, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlinkCertificate(ctx, "patient-alice", aliceCert.id)
+	})
+	if env.checkAccess("rec1", aliceCert.id) || !env.checkAccess("rec1", phone.id) {
+		t.Fatal("unlink must only affect the removed certificate")
+	}
+}
+
+func TestCertificateLinkExpires(t *testing.T) {
+	env := newTestEnv(t)
+	phone := newIdentity("eDUwOTo6Q049YWxpY2UtcGhvbmUsT1U9Y2xpZW50OjpDTj1jYS5vcmcx", "Org1MSP")
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	var linkID string
+	env.mustInvoke(phone, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		linkID, err = env.cc.LinkCertificate(ctx, "patient-alice", phone.id)
+		return err
+	})
+	env.advance(25 * time.Hour)
+	env.mustFail(aliceCert, "has expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ConfirmCertificateLink(ctx, linkID)
+	})
+}
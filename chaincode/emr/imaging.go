@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// dicomUIDPattern DICOM UID：以点分隔的数字分量，分量不以 0 开头（0 本身除外），总长不超过 64
+var dicomUIDPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))*$`)
+
+// ImagingSeries 单个序列的锚点
+type ImagingSeries struct {
+	SeriesInstanceUID string `json:"seriesInstanceUid"`
+	ContentHash       string `json:"contentHash"`
+	InstanceCount     int    `json:"instanceCount"`
+}
+
+// ImagingManifest 影像检查清单，挂在 recordId 对应的记录锚点上
+type ImagingManifest struct {
+	RecordID         string          `json:"recordId"`
+	StudyInstanceUID string          `json:"studyInstanceUid"`
+	Modality         string          `json:"modality"`
+	Series           []ImagingSeries `json:"series"`
+}
+
+func imagingKey(recordID string) string {
+	return "imaging:" + recordID
+}
+
+func studyKey(studyInstanceUID string) string {
+	return "study:" + studyInstanceUID
+}
+
+func validDicomUID(uid string) bool {
+	return len(uid) <= 64 && dicomUIDPattern.MatchString(uid)
+}
+
+func getImagingManifest(ctx contractapi.TransactionContextInterface, recordID string) (*ImagingManifest, error) {
+	var manifest ImagingManifest
+	found, err := getJSON(ctx, imagingKey(recordID), &manifest)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("imaging manifest not found for record: %s", recordID)
+	}
+	return &manifest, nil
+}
+
+// CreateImagingStudy 创建影像记录锚点并写入按序列的清单
+func (s *SmartContract) CreateImagingStudy(ctx contractapi.TransactionContextInterface, recordJson, manifestJson string) (string, error) {
+	var manifest ImagingManifest
+	if err := unmarshalArg(manifestJson, &manifest); err != nil {
+		return "", fmt.Errorf("invalid manifest json: %w", err)
+	}
+	if !validDicomUID(manifest.StudyInstanceUID) {
+		return "", fmt.Errorf("invalid StudyInstanceUID: %s", manifest.StudyInstanceUID)
+	}
+	if manifest.Modality == "" || len(manifest.Series) == 0 {
+		return "", fmt.Errorf("missing required fields: modality and series are required")
+	}
+	seen := make(map[string]bool, len(manifest.Series))
+	for _, series := range manifest.Series {
+		if !validDicomUID(series.SeriesInstanceUID) {
+			return "", fmt.Errorf("invalid SeriesInstanceUID: %s", series.SeriesInstanceUID)
+		}
+		if seen[series.SeriesInstanceUID] {
+			return "", fmt.Errorf("duplicate SeriesInstanceUID: %s", series.SeriesInstanceUID)
+		}
+		seen[series.SeriesInstanceUID] = true
+		if !sha256HexPattern.MatchString(series.ContentHash) {
+			return "", fmt.Errorf("invalid contentHash for series %s: expected hex-encoded SHA-256", series.SeriesInstanceUID)
+		}
+		if series.InstanceCount <= 0 {
+			return "", fmt.Errorf("instanceCount must be positive for series %s", series.SeriesInstanceUID)
+		}
+	}
+	exists, err := assetExists(ctx, studyKey(manifest.StudyInstanceUID))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("study already anchored: %s", manifest.StudyInstanceUID)
+	}
+
+	recordID, err := s.CreateMedicalRecord(ctx, recordJson)
+	if err != nil {
+		return "", err
+	}
+	manifest.RecordID = recordID
+	if err := putJSON(ctx, imagingKey(recordID), manifest); err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(studyKey(manifest.StudyInstanceUID), []byte(recordID)); err != nil {
+		return "", fmt.Errorf("failed to store study index: %w", err)
+	}
+	return recordID, nil
+}
+
+// GetImagingManifest 读取影像清单，调用者须对记录持有访问权限
+func (s *SmartContract) GetImagingManifest(ctx contractapi.TransactionContextInterface, recordID string) (*ImagingManifest, error) {
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return nil, err
+	}
+	return getImagingManifest(ctx, recordID)
+}
+
+// ValidateImagingIntegrity 比对序列哈希与锚定清单，仅返回是否一致
+func (s *SmartContract) ValidateImagingIntegrity(ctx contractapi.TransactionContextInterface, recordID, seriesInstanceUID, seriesHash string) (bool, error) {
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return false, err
+	}
+	manifest, err := getImagingManifest(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	for _, series := range manifest.Series {
+		if series.SeriesInstanceUID == seriesInstanceUID {
+			return series.ContentHash == seriesHash, nil
+		}
+	}
+	return false, fmt.Errorf("series %s not found in study %s", seriesInstanceUID, manifest.StudyInstanceUID)
+}
+
+// GetRecordIDByStudyUID 按 StudyInstanceUID 反查记录 ID，调用者须对记录持有访问权限
+func (s *SmartContract) GetRecordIDByStudyUID(ctx contractapi.TransactionContextInterface, studyInstanceUID string) (string, error) {
+	data, err := ctx.GetStub().GetState(studyKey(studyInstanceUID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read study index: %w", err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("study not found: %s", studyInstanceUID)
+	}
+	recordID := string(data)
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return "", err
+	}
+	return recordID, nil
+}
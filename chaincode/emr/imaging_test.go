@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testStudyUID = "1.2.840.113619.2.55.3"
+
+func TestImagingIntegrity(t *testing.T) {
+	env := newTestEnv(t)
+	record := `{"recordId":"img1","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafyimg1","contentHash":"` + strings.Repeat("a", 64) + `"}`
+	manifest := func(seriesUID string) string {
+		return `{"studyInstanceUid":"` + testStudyUID + `","modality":"CT","series":[` +
+			`{"seriesInstanceUid":"` + testStudyUID + `.1","contentHash":"` + strings.Repeat("1", 64) + `","instanceCount":120},` +
+			`{"seriesInstanceUid":"` + seriesUID + `","contentHash":"` + strings.Repeat("2", 64) + `","instanceCount":80}]}`
+	}
+
+	env.mustFail(doctor, "duplicate SeriesInstanceUID", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateImagingStudy(ctx, record, manifest(testStudyUID+".1"))
+		return err
+	})
+	env.mustFail(doctor, "invalid SeriesInstanceUID", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateImagingStudy(ctx, record, manifest("1.02.3"))
+		return err
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateImagingStudy(ctx, record, manifest(testStudyUID+".2"))
+		return err
+	})
+
+	validate := func(id *testIdentity, hash string) bool {
+		var ok bool
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ok, err = env.cc.ValidateImagingIntegrity(ctx, "img1", testStudyUID+".2", hash)
+			return err
+		})
+		return ok
+	}
+	if !validate(patient, strings.Repeat("2", 64)) {
+		t.Fatal("anchored series hash must validate")
+	}
+	if validate(doctor, strings.Repeat("3", 64)) {
+		t.Fatal("altered series hash must not validate")
+	}
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ValidateImagingIntegrity(ctx, "img1", testStudyUID+".2", strings.Repeat("2", 64))
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		recordID, err := env.cc.GetRecordIDByStudyUID(ctx, testStudyUID)
+		if err == nil && recordID != "img1" {
+			t.Fatalf("unexpected record for study: %s", recordID)
+		}
+		return err
+	})
+}
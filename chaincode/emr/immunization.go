@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const immunizationIndex = "imm"
+
+// Immunization 接种锚点；详细记录仍在 recordId 指向的病历中，受原有访问控制约束
+type Immunization struct {
+	ImmunizationID string `json:"immunizationId"`
+	PatientID      string `json:"patientId"`
+	VaccineCode    string `json:"vaccineCode"`
+	LotNumber      string `json:"lotNumber"`
+	AdministeredAt string `json:"administeredAt"`
+	AdministeredBy string `json:"administeredBy"`
+	RecordID       string `json:"recordId,omitempty"`
+	ContentHash    string `json:"contentHash"`
+}
+
+// VaccinationProofItem 证明只携带摘要字段，不含记录 ID 或 IPFS 地址
+type VaccinationProofItem struct {
+	VaccineCode    string `json:"vaccineCode"`
+	AdministeredAt string `json:"administeredAt"`
+	ContentHash    string `json:"contentHash"`
+}
+
+// VaccinationProof 接种摘要；Digest 为除 Digest 外各字段 JSON 的 SHA-256
+type VaccinationProof struct {
+	ProofID   string                 `json:"proofId"`
+	PatientID string                 `json:"patientId"`
+	Items     []VaccinationProofItem `json:"items"`
+	IssuedAt  string                 `json:"issuedAt"`
+	Digest    string                 `json:"digest"`
+}
+
+type ImmunizationAddedEvent struct {
+	ImmunizationID string `json:"immunizationId"`
+	PatientID      string `json:"patientId"`
+	VaccineCode    string `json:"vaccineCode"`
+	Timestamp      string `json:"timestamp"`
+	CallerID       string `json:"callerId"`
+	EventType      string `json:"eventType"`
+}
+
+func vaccinationProofKey(proofID string) string {
+	return "vaxproof:" + proofID
+}
+
+// vaccinationProofDigest 对证明内容（不含 Digest）做 SHA-256，第三方可据同一规则复算
+func vaccinationProofDigest(proof VaccinationProof) (string, error) {
+	proof.Digest = ""
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal vaccination proof: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func listImmunizations(ctx contractapi.TransactionContextInterface, patientID string) ([]*Immunization, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(immunizationIndex, []string{patientID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query immunizations: %w", err)
+	}
+	defer iterator.Close()
+
+	history := []*Immunization{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate immunizations: %w", err)
+		}
+		var imm Immunization
+		if err := unmarshalArg(string(kv.Value), &imm); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal immunization: %w", err)
+		}
+		history = append(history, &imm)
+	}
+	return history, nil
+}
+
+// AddImmunization 登记接种；调用者须为 doctor 或 nurse 角色
+func (s *SmartContract) AddImmunization(ctx contractapi.TransactionContextInterface, immJson string) error {
+	var imm Immunization
+	if err := unmarshalArg(immJson, &imm); err != nil {
+		return fmt.Errorf("invalid immunization json: %w", err)
+	}
+	if imm.ImmunizationID == "" || imm.PatientID == "" || imm.VaccineCode == "" || imm.AdministeredAt == "" {
+		return fmt.Errorf("missing required fields: immunizationId, patientId, vaccineCode and administeredAt are required")
+	}
+	for _, id := range []string{imm.ImmunizationID, imm.PatientID} {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, imm.AdministeredAt); err != nil {
+		return fmt.Errorf("invalid administeredAt: %w", err)
+	}
+	if !sha256HexPattern.MatchString(imm.ContentHash) {
+		return fmt.Errorf("invalid contentHash: expected hex-encoded SHA-256")
+	}
+
+	isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+	if err != nil {
+		return err
+	}
+	if !isClinician {
+		return fmt.Errorf("access denied: doctor or nurse role required")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if imm.RecordID != "" {
+		record, err := getRecord(ctx, imm.RecordID)
+		if err != nil {
+			return err
+		}
+		if record.PatientID != imm.PatientID {
+			return fmt.Errorf("record %s does not belong to patient %s", imm.RecordID, imm.PatientID)
+		}
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(immunizationIndex, []string{imm.PatientID, imm.ImmunizationID})
+	if err != nil {
+		return fmt.Errorf("failed to create immunization key: %w", err)
+	}
+	exists, err := assetExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("immunization already exists: %s", imm.ImmunizationID)
+	}
+	imm.AdministeredBy = callerID
+	if err := putJSON(ctx, key, imm); err != nil {
+		return err
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ImmunizationAdded", ImmunizationAddedEvent{
+		ImmunizationID: imm.ImmunizationID,
+		PatientID:      imm.PatientID,
+		VaccineCode:    imm.VaccineCode,
+		Timestamp:      now,
+		CallerID:       callerID,
+		EventType:      "ImmunizationAdded",
+	})
+}
+
+// GetImmunizationHistory 患者本人或 doctor/nurse 角色可查询接种史
+func (s *SmartContract) GetImmunizationHistory(ctx contractapi.TransactionContextInterface, patientID string) ([]*Immunization, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		if !isClinician {
+			return nil, fmt.Errorf("access denied: %s cannot view immunizations of %s", callerID, patientID)
+		}
+	}
+	return listImmunizations(ctx, patientID)
+}
+
+// GenerateVaccinationProof 患者生成接种摘要并写入 vaxproof:{txId}；
+// 只有作为交易提交并上链后，第三方才能用 VerifyVaccinationProof 核验
+func (s *SmartContract) GenerateVaccinationProof(ctx contractapi.TransactionContextInterface, patientID, vaccineCodesJson string) (*VaccinationProof, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		return nil, fmt.Errorf("access denied: only the patient can generate a vaccination proof")
+	}
+	var vaccineCodes []string
+	if vaccineCodesJson != "" {
+		if err := unmarshalArg(vaccineCodesJson, &vaccineCodes); err != nil {
+			return nil, fmt.Errorf("invalid vaccineCodes json: %w", err)
+		}
+	}
+
+	history, err := listImmunizations(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proof := VaccinationProof{
+		ProofID:   ctx.GetStub().GetTxID(),
+		PatientID: patientID,
+		Items:     []VaccinationProofItem{},
+		IssuedAt:  now,
+	}
+	for _, imm := range history {
+		if len(vaccineCodes) > 0 && !containsString(vaccineCodes, imm.VaccineCode) {
+			continue
+		}
+		proof.Items = append(proof.Items, VaccinationProofItem{
+			VaccineCode:    imm.VaccineCode,
+			AdministeredAt: imm.AdministeredAt,
+			ContentHash:    imm.ContentHash,
+		})
+	}
+	if len(proof.Items) == 0 {
+		return nil, fmt.Errorf("no matching immunizations for patient %s", patientID)
+	}
+	proof.Digest, err = vaccinationProofDigest(proof)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(vaccinationProofKey(proof.ProofID), []byte(proof.Digest)); err != nil {
+		return nil, fmt.Errorf("failed to store vaccination proof: %w", err)
+	}
+	return &proof, nil
+}
+
+// VerifyVaccinationProof 核验第三方复算的摘要是否与链上记录一致；不要求访问权限，只返回是否匹配
+func (s *SmartContract) VerifyVaccinationProof(ctx contractapi.TransactionContextInterface, proofID, digest string) (bool, error) {
+	stored, err := ctx.GetStub().GetState(vaccinationProofKey(proofID))
+	if err != nil {
+		return false, fmt.Errorf("failed to read vaccination proof: %w", err)
+	}
+	return len(stored) > 0 && string(stored) == digest, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func addTestImmunization(env *testEnv, by *testIdentity, immID, vaccineCode string) {
+	env.t.Helper()
+	body := fmt.Sprintf(`{"immunizationId":%q,"patientId":"patient1","vaccineCode":%q,"lotNumber":"L1","administeredAt":"2025-12-01T10:00:00Z","contentHash":%q}`,
+		immID, vaccineCode, strings.Repeat("d", 64))
+	env.mustInvoke(by, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddImmunization(ctx, body)
+	})
+}
+
+func TestVaccinationProofVerifiesOnlyCommittedDigest(t *testing.T) {
+	env := newTestEnv(t)
+	addTestImmunization(env, nurse, "imm1", "CVX-208")
+	env.expectEvent("ImmunizationAdded", nil)
+	addTestImmunization(env, doctor, "imm2", "CVX-141")
+
+	env.mustFail(other, "doctor or nurse role required", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddImmunization(ctx, `{"immunizationId":"imm3","patientId":"patient1","vaccineCode":"CVX-1","administeredAt":"2025-12-01T10:00:00Z","contentHash":"`+strings.Repeat("d", 64)+`"}`)
+	})
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GenerateVaccinationProof(ctx, patient.id, "")
+		return err
+	})
+
+	var proof *VaccinationProof
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		proof, err = env.cc.GenerateVaccinationProof(ctx, patient.id, `["CVX-208"]`)
+		return err
+	})
+	if len(proof.Items) != 1 || proof.Items[0].VaccineCode != "CVX-208" {
+		t.Fatalf("proof must only include requested vaccines: %+v", proof)
+	}
+
+	// 第三方按同一规则复算摘要后核验
+	digest, err := vaccinationProofDigest(*proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := func(d string) bool {
+		var ok bool
+		env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ok, err = env.cc.VerifyVaccinationProof(ctx, proof.ProofID, d)
+			return err
+		})
+		return ok
+	}
+	if !verify(digest) {
+		t.Fatal("recomputed digest must verify")
+	}
+	proof.Items[0].AdministeredAt = "2024-01-01T00:00:00Z"
+	tampered, _ := vaccinationProofDigest(*proof)
+	if verify(tampered) {
+		t.Fatal("tampered proof must not verify")
+	}
+
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetImmunizationHistory(ctx, patient.id)
+		return err
+	})
+}
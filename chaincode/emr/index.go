@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// 复合键索引的值固定为单字节，Fabric 不允许写入空值
+var indexValue = []byte{0x00}
+
+func putIndex(ctx contractapi.TransactionContextInterface, objectType string, attrs ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attrs)
+	if err != nil {
+		return fmt.Errorf("failed to create %s index key: %w", objectType, err)
+	}
+	if err := ctx.GetStub().PutState(key, indexValue); err != nil {
+		return fmt.Errorf("failed to store %s index: %w", objectType, err)
+	}
+	return nil
+}
+
+func delIndex(ctx contractapi.TransactionContextInterface, objectType string, attrs ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attrs)
+	if err != nil {
+		return fmt.Errorf("failed to create %s index key: %w", objectType, err)
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete %s index: %w", objectType, err)
+	}
+	return nil
+}
+
+// scanIndex 按前缀遍历复合键索引，visit 收到完整的属性列表
+func scanIndex(ctx contractapi.TransactionContextInterface, objectType string, prefix []string, visit func(attrs []string) error) error {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to query %s index: %w", objectType, err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return fmt.Errorf("failed to iterate %s index: %w", objectType, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return fmt.Errorf("failed to split %s index key: %w", objectType, err)
+		}
+		if err := visit(attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanRangeForUpdate 写交易中 Fabric 不允许分页查询，改为从 bookmark（下一页起始键）起手动计数，
+// 每次最多访问 limit 条；返回下一页起始键，空串表示已扫描完毕
+func scanRangeForUpdate(ctx contractapi.TransactionContextInterface, startKey, endKey string, limit int32, bookmark string, visit func(key string, value []byte) error) (string, error) {
+	if bookmark != "" {
+		if bookmark < startKey || bookmark >= endKey {
+			return "", fmt.Errorf("invalid bookmark")
+		}
+		startKey = bookmark
+	}
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan %s: %w", startKey, err)
+	}
+	defer iterator.Close()
+
+	var visited int32
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed to iterate %s: %w", startKey, err)
+		}
+		if visited == limit {
+			return kv.Key, nil
+		}
+		if err := visit(kv.Key, kv.Value); err != nil {
+			return "", err
+		}
+		visited++
+	}
+	return "", nil
+}
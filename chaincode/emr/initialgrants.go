@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// InitialGrant 创建记录时一并授予的权限
+type InitialGrant struct {
+	GranteeID string `json:"granteeId"`
+	Action    string `json:"action"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// RecordCreatedWithGrantsEvent 记录创建事件附带本次授予的全部权限；同一交易只保留最后一个事件，故合并发出
+type RecordCreatedWithGrantsEvent struct {
+	RecordCreatedEvent
+	Grants    []AccessPermission `json:"grants"`
+	ActingFor string             `json:"actingFor,omitempty"`
+}
+
+// initialGrantCeiling 返回调用者可向 granteeID 授予的最高权限：患者或其代理人（patientApproved）不受限；
+// 创建者（非患者）只能授予患者已列入护理团队的成员，且不高于团队默认权限，团队默认权限至多 write
+func initialGrantCeiling(ctx contractapi.TransactionContextInterface, rec *MedicalRecord, patientApproved bool, granteeID string) (string, error) {
+	if patientApproved {
+		return "admin", nil
+	}
+	action, err := careTeamAction(ctx, rec.PatientID, granteeID)
+	if err != nil {
+		return "", err
+	}
+	if action == "" {
+		return "", fmt.Errorf("access denied: the record creator can only grant access to members of the patient's care team, %s is not one", granteeID)
+	}
+	return action, nil
+}
+
+// initialGranter 调用者须为患者、其有 grant 范围的代理人或记录创建者；第一个返回值表示是否代表患者授权
+func initialGranter(ctx contractapi.TransactionContextInterface, rec *MedicalRecord, callerID string) (bool, string, error) {
+	actingFor, err := ownerOrAgent(ctx, rec.PatientID, callerID, "grant")
+	if err == nil {
+		return true, actingFor, nil
+	}
+	isCreator, creatorErr := callerIs(ctx, rec.CreatorID)
+	if creatorErr != nil {
+		return false, "", creatorErr
+	}
+	if !isCreator {
+		return false, "", err
+	}
+	return false, "", nil
+}
+
+// CreateMedicalRecordWithGrants 在同一交易内创建记录并授予初始权限，避免记录已存在而护理团队尚无权限的窗口期；
+// 创建者代为授权时只能授予患者护理团队中的成员，见 initialGrantCeiling
+func (s *SmartContract) CreateMedicalRecordWithGrants(ctx contractapi.TransactionContextInterface, recordJson, grantsJson string) (string, error) {
+	var grants []InitialGrant
+	if err := unmarshalArg(grantsJson, &grants); err != nil {
+		return "", fmt.Errorf("failed to unmarshal grants: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(grants) > config.MaxInitialGrants {
+		return "", fmt.Errorf("at most %d grants can be attached to a new record", config.MaxInitialGrants)
+	}
+
+	rec, callerID, err := createRecord(ctx, recordJson)
+	if err != nil {
+		return "", err
+	}
+	if len(grants) > 0 {
+		if err := requireSinglePartyGrant(rec); err != nil {
+			return "", err
+		}
+	}
+	patientApproved, actingFor, err := initialGranter(ctx, rec, callerID)
+	if err != nil {
+		return "", err
+	}
+	txNow, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	now := txNow.Format(time.RFC3339)
+
+	granted := make([]AccessPermission, 0, len(grants))
+	seen := make(map[string]bool, len(grants))
+	for _, grant := range grants {
+		if err := validateAddress(grant.GranteeID); err != nil {
+			return "", fmt.Errorf("invalid granteeID: %w", err)
+		}
+		if grant.GranteeID == rec.PatientID || grant.GranteeID == rec.CreatorID {
+			return "", fmt.Errorf("%s already has access to the record", grant.GranteeID)
+		}
+		if seen[grant.GranteeID] {
+			return "", fmt.Errorf("duplicate grantee: %s", grant.GranteeID)
+		}
+		seen[grant.GranteeID] = true
+		if err := config.checkGrant(grant.Action, grant.ExpiresAt, txNow); err != nil {
+			return "", err
+		}
+		ceiling, err := initialGrantCeiling(ctx, rec, patientApproved, grant.GranteeID)
+		if err != nil {
+			return "", err
+		}
+		if permissionHierarchy[grant.Action] > permissionHierarchy[ceiling] {
+			return "", fmt.Errorf("access denied: the record creator can grant at most %s access to %s", ceiling, grant.GranteeID)
+		}
+
+		perm := AccessPermission{
+			RecordID:  rec.RecordID,
+			GranteeID: grant.GranteeID,
+			Action:    grant.Action,
+			ExpiresAt: grant.ExpiresAt,
+			GrantedAt: now,
+			GrantedBy: callerID,
+			IsActive:  true,
+		}
+		if err := storeGrant(ctx, rec, perm); err != nil {
+			return "", err
+		}
+		granted = append(granted, perm)
+	}
+
+	event := RecordCreatedWithGrantsEvent{
+		RecordCreatedEvent: recordCreatedEvent(rec, callerID),
+		Grants:             granted,
+		ActingFor:          actingFor,
+	}
+	event.EventType = "RecordCreatedWithGrants"
+	if err := emitEvent(ctx, event.EventType, event); err != nil {
+		return "", err
+	}
+	return rec.RecordID, nil
+}
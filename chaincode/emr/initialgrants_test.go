@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestCreateMedicalRecordWithGrantsByPatient(t *testing.T) {
+	env := newTestEnv(t)
+	selfRecord := recordJSON(patient, "rec1", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, selfRecord,
+			`[{"granteeId":"`+nurse.id+`","action":"read"},{"granteeId":"`+doctor.id+`","action":"admin"}]`)
+		return err
+	})
+	var event RecordCreatedWithGrantsEvent
+	env.expectEvent("RecordCreatedWithGrants", &event)
+	if event.RecordID != "rec1" || len(event.Grants) != 2 || event.Grants[1].Action != "admin" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !env.checkAccess("rec1", nurse.id) || !env.checkAccess("rec1", doctor.id) {
+		t.Fatal("initial grants must be active in the creating transaction")
+	}
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetUserPermissions(ctx, nurse.id, 10, "")
+		if err == nil && len(page.Permissions) != 1 {
+			t.Fatalf("grant must be indexed for the grantee: %+v", page)
+		}
+		return err
+	})
+}
+
+func TestCreateMedicalRecordWithGrantsByCreator(t *testing.T) {
+	env := newTestEnv(t)
+	careTeam := `[{"granteeId":"` + nurse.id + `","action":"read"},{"granteeId":"` + specialist.id + `","action":"write"}]`
+
+	// 创建者只能授予患者认可的护理团队成员
+	env.mustFail(doctor, "not one", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec1", patient.id), careTeam)
+		return err
+	})
+	for _, member := range []*testIdentity{nurse, specialist, other} {
+		env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AddCareTeamMember(ctx, patient.id, member.id, "inpatient")
+		})
+	}
+	// 团队默认 read，不能超出患者认可的级别
+	env.mustFail(doctor, "can grant at most read access", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec1", patient.id), careTeam)
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetCareTeamDefaultAction(ctx, patient.id, "write")
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec1", patient.id), careTeam)
+		return err
+	})
+	if !env.checkAccess("rec1", nurse.id) || !env.checkAccess("rec1", specialist.id) {
+		t.Fatal("creator must be able to share the new record with the care team")
+	}
+
+	// 创建者不能授予高于自身 write 的权限，失败时记录本身也不落账
+	env.mustFail(doctor, "can grant at most write", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec2", patient.id),
+			`[{"granteeId":"`+nurse.id+`","action":"admin"}]`)
+		return err
+	})
+	env.mustFail(doctor, "record not found", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetRecord(ctx, "rec2")
+		return err
+	})
+	env.mustFail(doctor, "duplicate grantee", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec2", patient.id),
+			`[{"granteeId":"`+nurse.id+`","action":"read"},{"granteeId":"`+nurse.id+`","action":"write"}]`)
+		return err
+	})
+	env.mustFail(doctor, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec2", patient.id),
+			`[{"granteeId":"`+other.id+`","action":"write"}]`)
+		return err
+	})
+	env.mustFail(other, "caller must be patient or creator", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, recordJSON(doctor, "rec2", patient.id), careTeam)
+		return err
+	})
+}
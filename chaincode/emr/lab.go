@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	labIndex = "lab"
+
+	// collectionLabResults 编码摘要的私有数据集合，需在 collections_config.json 中定义
+	collectionLabResults = "collectionLabResults"
+)
+
+var (
+	loincPattern = regexp.MustCompile(`^(\d{1,5})-(\d)$`)
+	// ucumPattern 只做 UCUM 大小写敏感语法的字符校验，不校验单位原子表
+	ucumPattern = regexp.MustCompile(`^[A-Za-z0-9.%/\[\]{}*^'+-]{1,64}$`)
+)
+
+// LabResultInput CreateLabResult 的参数：记录锚点字段加编码摘要
+type LabResultInput struct {
+	MedicalRecord
+	LoincCode  string          `json:"loincCode"`
+	Value      float64         `json:"value"`
+	Unit       string          `json:"unit"`
+	Reference  *ReferenceRange `json:"referenceRange,omitempty"`
+	ObservedAt string          `json:"observedAt"`
+	Private    bool            `json:"private"`
+}
+
+// ReferenceRange 参考区间，单位与结果相同
+type ReferenceRange struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// LabSummary 检验结果编码摘要；完整报告在 IPFS，由 recordId 对应的锚点校验
+type LabSummary struct {
+	RecordID   string          `json:"recordId"`
+	PatientID  string          `json:"patientId"`
+	LoincCode  string          `json:"loincCode"`
+	Value      float64         `json:"value"`
+	Unit       string          `json:"unit"`
+	Reference  *ReferenceRange `json:"referenceRange,omitempty"`
+	Flag       string          `json:"flag,omitempty"`
+	ObservedAt string          `json:"observedAt"`
+}
+
+// validLoinc 校验 LOINC 格式与 mod 10 校验位
+func validLoinc(code string) bool {
+	m := loincPattern.FindStringSubmatch(code)
+	if m == nil {
+		return false
+	}
+	sum := 0
+	digits := m[1]
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// 自右向左第 1、3、5 位乘 2
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+		}
+		sum += d/10 + d%10
+	}
+	check, _ := strconv.Atoi(m[2])
+	return (10-sum%10)%10 == check
+}
+
+func labFlag(value float64, reference *ReferenceRange) string {
+	switch {
+	case reference == nil:
+		return ""
+	case value < reference.Low:
+		return "L"
+	case value > reference.High:
+		return "H"
+	default:
+		return "N"
+	}
+}
+
+// CreateLabResult 创建检验记录锚点，并按 private 写入公开状态或私有集合的编码摘要
+func (s *SmartContract) CreateLabResult(ctx contractapi.TransactionContextInterface, labJson string) (string, error) {
+	var input LabResultInput
+	if err := unmarshalArg(labJson, &input); err != nil {
+		return "", fmt.Errorf("invalid lab result json: %w", err)
+	}
+	if !validLoinc(input.LoincCode) {
+		return "", fmt.Errorf("invalid LOINC code: %s", input.LoincCode)
+	}
+	if !ucumPattern.MatchString(input.Unit) {
+		return "", fmt.Errorf("invalid UCUM unit: %s", input.Unit)
+	}
+	if input.Reference != nil && input.Reference.Low > input.Reference.High {
+		return "", fmt.Errorf("invalid referenceRange: low must not exceed high")
+	}
+	observedAt, err := time.Parse(time.RFC3339, input.ObservedAt)
+	if err != nil {
+		return "", fmt.Errorf("invalid observedAt: %w", err)
+	}
+
+	recordJSON, err := json.Marshal(input.MedicalRecord)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal record: %w", err)
+	}
+	recordID, err := s.CreateMedicalRecord(ctx, string(recordJSON))
+	if err != nil {
+		return "", err
+	}
+
+	summary := LabSummary{
+		RecordID:   recordID,
+		PatientID:  input.PatientID,
+		LoincCode:  input.LoincCode,
+		Value:      input.Value,
+		Unit:       input.Unit,
+		Reference:  input.Reference,
+		Flag:       labFlag(input.Value, input.Reference),
+		ObservedAt: observedAt.UTC().Format(time.RFC3339),
+	}
+	// 时间取 UTC RFC3339，同一患者同一代码下按键序即时间升序
+	key, err := ctx.GetStub().CreateCompositeKey(labIndex, []string{summary.PatientID, summary.LoincCode, summary.ObservedAt, recordID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create lab index key: %w", err)
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lab summary: %w", err)
+	}
+	if input.Private {
+		err = ctx.GetStub().PutPrivateData(collectionLabResults, key, data)
+	} else {
+		err = ctx.GetStub().PutState(key, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store lab summary: %w", err)
+	}
+	return recordID, nil
+}
+
+// GetResultsByLoincCode 按时间升序返回公开状态中的检验摘要，只包含调用者可访问的记录
+func (s *SmartContract) GetResultsByLoincCode(ctx contractapi.TransactionContextInterface, patientID, loincCode string) ([]*LabSummary, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(labIndex, []string{patientID, loincCode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lab results: %w", err)
+	}
+	return s.collectLabSummaries(ctx, iterator)
+}
+
+// GetPrivateResultsByLoincCode 同 GetResultsByLoincCode，读取私有集合；须在集合成员组织的节点上调用
+func (s *SmartContract) GetPrivateResultsByLoincCode(ctx contractapi.TransactionContextInterface, patientID, loincCode string) ([]*LabSummary, error) {
+	iterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(collectionLabResults, labIndex, []string{patientID, loincCode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query private lab results: %w", err)
+	}
+	return s.collectLabSummaries(ctx, iterator)
+}
+
+func (s *SmartContract) collectLabSummaries(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface) ([]*LabSummary, error) {
+	defer iterator.Close()
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*LabSummary{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate lab results: %w", err)
+		}
+		var summary LabSummary
+		if err := json.Unmarshal(kv.Value, &summary); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal lab summary: %w", err)
+		}
+		allowed, err := s.CheckAccess(ctx, summary.RecordID, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			results = append(results, &summary)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func labBody(recordID, loinc string, value float64, observedAt string, private bool) string {
+	return fmt.Sprintf(`{"recordId":%q,"patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy%s","contentHash":%q,`+
+		`"loincCode":%q,"value":%g,"unit":"mg/dL","referenceRange":{"low":70,"high":99},"observedAt":%q,"private":%t}`,
+		recordID, recordID, strings.Repeat("a", 64), loinc, value, observedAt, private)
+}
+
+func TestValidLoinc(t *testing.T) {
+	for code, want := range map[string]bool{"2345-7": true, "718-7": true, "2345-6": false, "123456-1": false, "abc": false} {
+		if got := validLoinc(code); got != want {
+			t.Errorf("validLoinc(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestLabResultTrendQuery(t *testing.T) {
+	env := newTestEnv(t)
+	create := func(recordID string, value float64, observedAt string, private bool) {
+		env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateLabResult(ctx, labBody(recordID, "2345-7", value, observedAt, private))
+			return err
+		})
+	}
+	create("lab2", 130, "2026-01-03T08:00:00Z", false)
+	create("lab1", 95, "2026-01-01T08:00:00Z", false)
+	create("lab3", 60, "2026-01-04T08:00:00Z", true)
+
+	env.mustFail(doctor, "invalid LOINC code", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateLabResult(ctx, labBody("lab4", "2345-6", 1, "2026-01-04T08:00:00Z", false))
+		return err
+	})
+	env.mustFail(doctor, "low must not exceed high", func(ctx contractapi.TransactionContextInterface) error {
+		body := strings.Replace(labBody("lab4", "2345-7", 1, "2026-01-04T08:00:00Z", false), `"low":70`, `"low":120`, 1)
+		_, err := env.cc.CreateLabResult(ctx, body)
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		results, err := env.cc.GetResultsByLoincCode(ctx, patient.id, "2345-7")
+		if err == nil && (len(results) != 2 || results[0].RecordID != "lab1" || results[1].Flag != "H") {
+			t.Fatalf("expected public results in time order: %+v", results)
+		}
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		results, err := env.cc.GetPrivateResultsByLoincCode(ctx, patient.id, "2345-7")
+		if err == nil && (len(results) != 1 || results[0].Flag != "L") {
+			t.Fatalf("expected private result: %+v", results)
+		}
+		return err
+	})
+
+	// 只返回调用者可访问的记录
+	env.grant(patient, "lab2", nurse.id, "read", "")
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		results, err := env.cc.GetResultsByLoincCode(ctx, patient.id, "2345-7")
+		if err == nil && (len(results) != 1 || results[0].RecordID != "lab2") {
+			t.Fatalf("nurse must only see granted results: %+v", results)
+		}
+		return err
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// sourceSystemPattern 外部来源系统标识，如 his-2009、pacs.legacy
+var sourceSystemPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// maxOriginalHashes 每条导入记录可附带的原始文档哈希上限
+const maxOriginalHashes = 20
+
+type LegacyRecordImportedEvent struct {
+	RecordID     string `json:"recordId"`
+	PatientID    string `json:"patientId"`
+	SourceSystem string `json:"sourceSystem"`
+	Timestamp    string `json:"timestamp"`
+	ImportedAt   string `json:"importedAt"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+// ImportLegacyRecord 由 registrar/admin 迁入外部系统的历史记录：timestamp 为原始记录时间，须早于本交易且不早于 1970；
+// originalHashesJson 为原系统文档的 SHA-256 列表。迁入记录标记 migrated，创建者凭证不再校验（可能已离职），由导入人担保
+func (s *SmartContract) ImportLegacyRecord(ctx contractapi.TransactionContextInterface, recordJson, sourceSystem, originalHashesJson string) (string, error) {
+	allowed, err := hasAnyRole(ctx, "registrar", "admin")
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("access denied: only registrar or admin roles can import legacy records")
+	}
+	if !sourceSystemPattern.MatchString(sourceSystem) {
+		return "", fmt.Errorf("invalid sourceSystem: %q", sourceSystem)
+	}
+	var originalHashes []string
+	if err := unmarshalArg(originalHashesJson, &originalHashes); err != nil {
+		return "", fmt.Errorf("invalid originalHashes json: %w", err)
+	}
+	if len(originalHashes) == 0 || len(originalHashes) > maxOriginalHashes {
+		return "", fmt.Errorf("between 1 and %d original hashes are required", maxOriginalHashes)
+	}
+	for _, hash := range originalHashes {
+		if !sha256HexPattern.MatchString(hash) {
+			return "", fmt.Errorf("invalid original hash: expected hex-encoded SHA-256")
+		}
+	}
+
+	var rec MedicalRecord
+	if err := json.Unmarshal([]byte(recordJson), &rec); err != nil {
+		return "", fmt.Errorf("invalid record json: %w", err)
+	}
+	if rec.Timestamp == "" {
+		return "", fmt.Errorf("legacy records must carry their original timestamp")
+	}
+	recordedAt, err := prepareRecord(ctx, &rec)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !recordedAt.Before(now) {
+		return "", fmt.Errorf("invalid timestamp: legacy records must predate the import")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	rec.Migrated = true
+	rec.SourceSystem = sourceSystem
+	rec.OriginalHashes = originalHashes
+	rec.ImportedAt = now.UTC().Format(time.RFC3339)
+	if err := storeNewRecord(ctx, &rec, recordedAt); err != nil {
+		return "", err
+	}
+	return rec.RecordID, emitEvent(ctx, "LegacyRecordImported", LegacyRecordImportedEvent{
+		RecordID:     rec.RecordID,
+		PatientID:    rec.PatientID,
+		SourceSystem: sourceSystem,
+		Timestamp:    rec.Timestamp,
+		ImportedAt:   rec.ImportedAt,
+		CallerID:     callerID,
+		EventType:    "LegacyRecordImported",
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestImportLegacyRecord(t *testing.T) {
+	env := newTestEnv(t)
+	legacy := func(recordID, timestamp string) string {
+		return `{"recordId":"` + recordID + `","patientId":"patient1","creatorId":"retired-doctor","ipfsCid":"bafy` + recordID +
+			`","contentHash":"` + strings.Repeat("a", 64) + `","timestamp":"` + timestamp + `"}`
+	}
+	hashes := `["` + strings.Repeat("f", 64) + `"]`
+
+	env.mustFail(doctor, "only registrar or admin", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ImportLegacyRecord(ctx, legacy("old1", "2004-03-01T10:00:00Z"), "his-2004", hashes)
+		return err
+	})
+	env.mustFail(registrar, "before 1970", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ImportLegacyRecord(ctx, legacy("old1", "1965-03-01T10:00:00Z"), "his-2004", hashes)
+		return err
+	})
+	env.mustFail(registrar, "must predate the import", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ImportLegacyRecord(ctx, legacy("old1", "2030-03-01T10:00:00Z"), "his-2004", hashes)
+		return err
+	})
+	env.mustFail(registrar, "original hash", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ImportLegacyRecord(ctx, legacy("old1", "2004-03-01T10:00:00Z"), "his-2004", `["abc"]`)
+		return err
+	})
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ImportLegacyRecord(ctx, legacy("old1", "2004-03-01T12:00:00+02:00"), "his-2004", hashes)
+		return err
+	})
+	var event LegacyRecordImportedEvent
+	env.expectEvent("LegacyRecordImported", &event)
+	if event.Timestamp != "2004-03-01T10:00:00Z" || event.ImportedAt == event.Timestamp {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		meta, err := env.cc.GetRecordMetadata(ctx, "old1")
+		if err != nil {
+			return err
+		}
+		if !meta.Migrated || meta.SourceSystem != "his-2004" {
+			t.Fatalf("imported record must be marked migrated: %+v", meta)
+		}
+		meta, err = env.cc.GetRecordMetadata(ctx, "rec1")
+		if err == nil && meta.Migrated {
+			t.Fatal("natively created records must not be marked migrated")
+		}
+		page, err := env.cc.ListRecordsByPatientAndTimeRange(ctx, patient.id, "2004-01-01T00:00:00Z", "2004-12-31T00:00:00Z", 10, "")
+		if err == nil && (len(page.Records) != 1 || page.Records[0].OriginalHashes[0] != strings.Repeat("f", 64)) {
+			t.Fatalf("back-dated record must be indexed at its original time: %v", recordIDs(page.Records))
+		}
+		return err
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// PatientLock 患者锁定共享期间，除患者本人外任何人都不能访问其记录，也不能写入新授权
+type PatientLock struct {
+	PatientID string `json:"patientId"`
+	LockedBy  string `json:"lockedBy"`
+	LockedAt  string `json:"lockedAt"`
+}
+
+type AllAccessRevokedEvent struct {
+	PatientID   string `json:"patientId"`
+	RecordCount int    `json:"recordCount"`
+	GrantCount  int    `json:"grantCount"`
+	Locked      bool   `json:"locked"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+type RecordsUnlockedEvent struct {
+	PatientID string `json:"patientId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func patientLockKey(patientID string) string {
+	return "lock:" + patientID
+}
+
+func patientLocked(ctx contractapi.TransactionContextInterface, patientID string) (bool, error) {
+	return assetExists(ctx, patientLockKey(patientID))
+}
+
+// requireUnlocked 锁定期间拒绝写入新授权
+func requireUnlocked(ctx contractapi.TransactionContextInterface, patientID string) error {
+	locked, err := patientLocked(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("records of %s are locked: the patient must call UnlockRecords before sharing", patientID)
+	}
+	return nil
+}
+
+// activeGrantees 列出记录上仍有效或暂停中的被授权人（访问列表与单独权限的并集），按 ID 排序；
+// 暂停的授权一并撤销，避免之后被 ResumeAccess 恢复
+func activeGrantees(ctx contractapi.TransactionContextInterface, record *MedicalRecord) ([]string, error) {
+	active := make(map[string]bool)
+	accessList, err := getAccessList(ctx, record.RecordID)
+	if err != nil {
+		return nil, err
+	}
+	if accessList != nil {
+		for granteeID, perm := range accessList.Permissions {
+			if perm.IsActive || perm.SuspendedAt != "" {
+				active[granteeID] = true
+			}
+		}
+	}
+	iterator, err := ctx.GetStub().GetStateByRange("perm:"+record.RecordID+":", "perm:"+record.RecordID+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan permissions: %w", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate permissions: %w", err)
+		}
+		var perm AccessPermission
+		if err := json.Unmarshal(kv.Value, &perm); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal permission: %w", err)
+		}
+		// recordId 允许 ':'，前缀范围可能命中其他记录的权限
+		if perm.RecordID == record.RecordID && (perm.IsActive || perm.SuspendedAt != "") {
+			active[perm.GranteeID] = true
+		}
+	}
+	delete(active, record.PatientID)
+
+	grantees := make([]string, 0, len(active))
+	for granteeID := range active {
+		grantees = append(grantees, granteeID)
+	}
+	sort.Strings(grantees)
+	return grantees, nil
+}
+
+// RevokeAllAccess 一次撤销患者全部记录上的授权（含创建者的访问列表条目）；lock 为 true 时同时锁定共享，
+// 由患者本人或有 revoke 范围的代理人调用。护理团队等派生访问不在授权之列，需另行调整
+func (s *SmartContract) RevokeAllAccess(ctx contractapi.TransactionContextInterface, patientID string, lock bool) error {
+	callerID, err := requirePatientOrAgent(ctx, patientID, "revoke", "revoke all access")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	recordCount, grantCount := 0, 0
+	err = scanIndex(ctx, patientRecordIndex, []string{patientID}, func(attrs []string) error {
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		grantees, err := activeGrantees(ctx, record)
+		if err != nil {
+			return err
+		}
+		for _, granteeID := range grantees {
+			if _, err := deactivateGrant(ctx, record.RecordID, granteeID, now); err != nil {
+				return err
+			}
+		}
+		recordCount++
+		grantCount += len(grantees)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if lock {
+		if err := putJSON(ctx, patientLockKey(patientID), PatientLock{PatientID: patientID, LockedBy: callerID, LockedAt: now}); err != nil {
+			return err
+		}
+	}
+
+	return emitEvent(ctx, "AllAccessRevoked", AllAccessRevokedEvent{
+		PatientID:   patientID,
+		RecordCount: recordCount,
+		GrantCount:  grantCount,
+		Locked:      lock,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "AllAccessRevoked",
+	})
+}
+
+// UnlockRecords 解除锁定；已撤销的授权不会恢复，创建者的访问随之恢复
+func (s *SmartContract) UnlockRecords(ctx contractapi.TransactionContextInterface, patientID string) error {
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "unlock records")
+	if err != nil {
+		return err
+	}
+	locked, err := patientLocked(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return fmt.Errorf("records of %s are not locked", patientID)
+	}
+	if err := ctx.GetStub().DelState(patientLockKey(patientID)); err != nil {
+		return fmt.Errorf("failed to delete lock: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordsUnlocked", RecordsUnlockedEvent{
+		PatientID: patientID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "RecordsUnlocked",
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestRevokeAllAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(doctor, "rec3", "patient2")
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.grant(patient, "rec2", nurse.id, "read", "")
+	env.grant(patient, "rec2", specialist.id, "read", "")
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAllAccess(ctx, patient.id, false)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAllAccess(ctx, patient.id, false)
+	})
+	var event AllAccessRevokedEvent
+	env.expectEvent("AllAccessRevoked", &event)
+	// 每条记录另有创建者的访问列表条目
+	if event.RecordCount != 2 || event.GrantCount != 5 || event.Locked {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	for _, recordID := range []string{"rec1", "rec2"} {
+		if env.checkAccess(recordID, nurse.id) || env.checkAccess(recordID, specialist.id) {
+			t.Fatalf("%s: grants must be revoked", recordID)
+		}
+		if !env.checkAccess(recordID, patient.id) || !env.checkAccess(recordID, doctor.id) {
+			t.Fatalf("%s: patient and creator keep access without a lock", recordID)
+		}
+	}
+	if !env.checkAccess("rec3", doctor.id) {
+		t.Fatal("other patients' records must be untouched")
+	}
+	env.grant(patient, "rec1", nurse.id, "read", "")
+}
+
+func TestRevokeAllAccessWithLock(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAllAccess(ctx, patient.id, true)
+	})
+
+	if env.checkAccess("rec1", doctor.id) || env.checkAccess("rec1", nurse.id) {
+		t.Fatal("locked records must only be readable by the patient")
+	}
+	if !env.checkAccess("rec1", patient.id) {
+		t.Fatal("the patient keeps access while locked")
+	}
+	env.mustFail(patient, "are locked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	})
+	// 锁定期间新建的记录同样只对患者开放
+	env.createRecord(doctor, "rec2", patient.id)
+	if env.checkAccess("rec2", doctor.id) {
+		t.Fatal("records created while locked must be locked too")
+	}
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlockRecords(ctx, patient.id)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlockRecords(ctx, patient.id)
+	})
+	env.expectEvent("RecordsUnlocked", nil)
+	if !env.checkAccess("rec1", doctor.id) || !env.checkAccess("rec2", doctor.id) {
+		t.Fatal("creators regain access after unlock")
+	}
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("unlock must not restore revoked grants")
+	}
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustFail(patient, "not locked", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlockRecords(ctx, patient.id)
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// newContracts 链码中的全部合约，第一个为默认合约；每笔交易先经 beforeTransaction 拦截被冻结的身份与越权的应用
+func newContracts() []contractapi.ContractInterface {
+	return []contractapi.ContractInterface{
+		&SmartContract{Contract: contractapi.Contract{BeforeTransaction: beforeTransaction}},
+		&ClaimsContract{Contract: contractapi.Contract{BeforeTransaction: beforeTransaction}},
+		&PrescriptionContract{Contract: contractapi.Contract{BeforeTransaction: beforeTransaction}},
+	}
+}
+
+func newChaincode() (*contractapi.ContractChaincode, error) {
+	return contractapi.NewChaincode(newContracts()...)
+}
+
+func main() {
+	chaincode, err := newChaincode()
+	if err != nil {
+		log.Panicf("Error creating EMR chaincode: %v", err)
+	}
+
+	if err := chaincode.Start(); err != nil {
+		log.Panicf("Error starting EMR chaincode: %v", err)
+	}
+}
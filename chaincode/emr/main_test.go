@@ -0,0 +1,10 @@
+package main
+
+import "testing"
+
+// 合约方法签名不合规时 NewChaincode 才会报错，这里提前暴露
+func TestChaincodeRegistration(t *testing.T) {
+	if _, err := newChaincode(); err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	recordTypeMedReconciliation = "med-reconciliation"
+
+	// maxReconciledMedications 重整前后清单各自的条目上限
+	maxReconciledMedications = 100
+)
+
+// drugCodePattern RxNorm、ATC 等药品编码，只校验字符集与长度
+var drugCodePattern = regexp.MustCompile(`^[A-Za-z0-9.:-]{1,64}$`)
+
+// MedicationEntry 用药清单中的一条
+type MedicationEntry struct {
+	DrugCode string `json:"drugCode"`
+	Dosage   string `json:"dosage"`
+}
+
+// MedicationReconciliation 用药重整：记录锚点之外的结构化前后清单。
+// 与处方相同，重整交易由重整医生的证书签名，ReconciledBy 与 TxID 即其签名；ListHash 为两份清单的 sha256，供比对线下文档
+type MedicationReconciliation struct {
+	RecordID     string            `json:"recordId"`
+	PatientID    string            `json:"patientId"`
+	BeforeMeds   []MedicationEntry `json:"beforeMeds"`
+	AfterMeds    []MedicationEntry `json:"afterMeds"`
+	ListHash     string            `json:"listHash"`
+	ReconciledBy string            `json:"reconciledBy"`
+	ReconciledAt string            `json:"reconciledAt"`
+	TxID         string            `json:"txId"`
+}
+
+func medRecKey(recordID string) string {
+	return "medrec:" + recordID
+}
+
+// latestMedRecKey medrec-latest:{patientId}，值为患者最近一次重整的 recordId
+func latestMedRecKey(patientID string) string {
+	return "medrec-latest:" + keySegment(patientID)
+}
+
+func validateMedicationList(name string, entries []MedicationEntry) error {
+	if len(entries) > maxReconciledMedications {
+		return fmt.Errorf("%s can list at most %d medications", name, maxReconciledMedications)
+	}
+	for i, entry := range entries {
+		if !drugCodePattern.MatchString(entry.DrugCode) {
+			return fmt.Errorf("%s[%d]: invalid drugCode: %q", name, i, entry.DrugCode)
+		}
+		if entry.Dosage == "" {
+			return fmt.Errorf("%s[%d]: dosage is required", name, i)
+		}
+	}
+	return nil
+}
+
+// medicationListHash 两份清单按字段顺序固定编码后的 sha256
+func medicationListHash(before, after []MedicationEntry) (string, error) {
+	data, err := json.Marshal(struct {
+		BeforeMeds []MedicationEntry `json:"beforeMeds"`
+		AfterMeds  []MedicationEntry `json:"afterMeds"`
+	}{before, after})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal medication lists: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CreateMedicationReconciliation 持有效执业凭证的医护创建 med-reconciliation 记录锚点并写入重整前后清单；
+// reconciliationJson 为 {beforeMeds, afterMeds}，recordType 固定为 med-reconciliation
+func (s *SmartContract) CreateMedicationReconciliation(ctx contractapi.TransactionContextInterface, recordJson, reconciliationJson string) (string, error) {
+	var reconciliation MedicationReconciliation
+	if err := unmarshalArg(reconciliationJson, &reconciliation); err != nil {
+		return "", fmt.Errorf("invalid reconciliation json: %w", err)
+	}
+	if len(reconciliation.BeforeMeds) == 0 && len(reconciliation.AfterMeds) == 0 {
+		return "", fmt.Errorf("beforeMeds or afterMeds is required")
+	}
+	if err := validateMedicationList("beforeMeds", reconciliation.BeforeMeds); err != nil {
+		return "", err
+	}
+	if err := validateMedicationList("afterMeds", reconciliation.AfterMeds); err != nil {
+		return "", err
+	}
+	var record MedicalRecord
+	if err := unmarshalArg(recordJson, &record); err != nil {
+		return "", fmt.Errorf("invalid record json: %w", err)
+	}
+	if record.RecordType != "" && record.RecordType != recordTypeMedReconciliation {
+		return "", fmt.Errorf("recordType must be %s", recordTypeMedReconciliation)
+	}
+	record.RecordType = recordTypeMedReconciliation
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := requireProviderCredential(ctx, callerID); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal record: %w", err)
+	}
+	recordID, err := s.CreateMedicalRecord(ctx, string(data))
+	if err != nil {
+		return "", err
+	}
+	listHash, err := medicationListHash(reconciliation.BeforeMeds, reconciliation.AfterMeds)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	reconciliation.RecordID = recordID
+	reconciliation.PatientID = record.PatientID
+	reconciliation.ListHash = listHash
+	reconciliation.ReconciledBy = callerID
+	reconciliation.ReconciledAt = now
+	reconciliation.TxID = ctx.GetStub().GetTxID()
+	if err := putJSON(ctx, medRecKey(recordID), reconciliation); err != nil {
+		return "", err
+	}
+	// 交易时间单调递增，最后写入的即最新一次
+	if err := ctx.GetStub().PutState(latestMedRecKey(record.PatientID), []byte(recordID)); err != nil {
+		return "", fmt.Errorf("failed to index latest reconciliation: %w", err)
+	}
+	return recordID, nil
+}
+
+// GetMedicationReconciliation 读取重整清单；调用者须对记录持有访问权限，审计员不受限
+func (s *SmartContract) GetMedicationReconciliation(ctx contractapi.TransactionContextInterface, recordID string) (*MedicationReconciliation, error) {
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if err := s.requireRecordAccess(ctx, recordID); err != nil {
+			return nil, err
+		}
+	}
+	var reconciliation MedicationReconciliation
+	found, err := getJSON(ctx, medRecKey(recordID), &reconciliation)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("medication reconciliation not found for record: %s", recordID)
+	}
+	return &reconciliation, nil
+}
+
+// GetLatestReconciliation 返回患者最近一次用药重整，权限同 GetMedicationReconciliation
+func (s *SmartContract) GetLatestReconciliation(ctx contractapi.TransactionContextInterface, patientID string) (*MedicationReconciliation, error) {
+	recordID, err := ctx.GetStub().GetState(latestMedRecKey(patientID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest reconciliation: %w", err)
+	}
+	if len(recordID) == 0 {
+		return nil, fmt.Errorf("no medication reconciliation recorded for %s", patientID)
+	}
+	return s.GetMedicationReconciliation(ctx, string(recordID))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestLatestMedicationReconciliation(t *testing.T) {
+	env := newTestEnv(t)
+	lists := `{"beforeMeds":[{"drugCode":"197361","dosage":"5 mg daily"}],"afterMeds":[{"drugCode":"197361","dosage":"10 mg daily"},{"drugCode":"310965","dosage":"200 mg as needed"}]}`
+	reconcile := func(recordID, lists string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicationReconciliation(ctx, recordJSON(doctor, recordID, patient.id), lists)
+			return err
+		}
+	}
+	env.mustFail(doctor, "beforeMeds or afterMeds is required", reconcile("medrec1", `{}`))
+	env.mustFail(doctor, "invalid drugCode", reconcile("medrec1", `{"afterMeds":[{"drugCode":"bad code","dosage":"1"}]}`))
+	env.mustFail(doctor, "recordType must be", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicationReconciliation(ctx, `{"recordId":"medrec1","patientId":"patient1","creatorId":"doctor1","recordType":"note"}`, lists)
+		return err
+	})
+	env.mustInvoke(doctor, reconcile("medrec1", `{"beforeMeds":[{"drugCode":"197361","dosage":"5 mg daily"}]}`))
+	env.advance(time.Hour)
+	env.mustInvoke(doctor, reconcile("medrec2", lists))
+	if record := env.storedRecord("medrec2"); record.RecordType != recordTypeMedReconciliation {
+		t.Fatalf("unexpected record type: %s", record.RecordType)
+	}
+
+	latest := func(ctx contractapi.TransactionContextInterface) error {
+		reconciliation, err := env.cc.GetLatestReconciliation(ctx, patient.id)
+		if err != nil {
+			return err
+		}
+		hash, _ := medicationListHash(reconciliation.BeforeMeds, reconciliation.AfterMeds)
+		if reconciliation.RecordID != "medrec2" || reconciliation.ReconciledBy != doctor.id || len(reconciliation.AfterMeds) != 2 || reconciliation.ListHash != hash {
+			t.Fatalf("unexpected reconciliation: %+v", reconciliation)
+		}
+		return nil
+	}
+	env.mustInvoke(patient, latest)
+	env.mustInvoke(auditor, latest)
+	env.mustFail(nurse, "access denied", latest)
+	env.mustFail(patient, "no medication reconciliation", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetLatestReconciliation(ctx, "patient2")
+		return err
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// maxFieldProofDepth 证明路径长度上限，足够覆盖 2^64 个字段
+	maxFieldProofDepth = 64
+	maxFieldPathLength = 256
+)
+
+// FieldProofStep Merkle 证明中的一个兄弟节点；Position 为兄弟节点在左还是在右
+type FieldProofStep struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"`
+}
+
+type FieldRootAnchoredEvent struct {
+	RecordID  string `json:"recordId"`
+	FieldRoot string `json:"fieldRoot"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// fieldLeaf 叶子节点 sha256(0x00 || fieldPath || 0x00 || fieldHash)。fieldHash 由患者在链下以 sha256(salt || value) 计算，
+// 叶子同时绑定字段路径，证明不能挪用到别的字段
+func fieldLeaf(fieldPath string, fieldHash []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{0}, fieldPath+"\x00"...), fieldHash...))
+	return sum[:]
+}
+
+// merkleNode 内部节点 sha256(0x01 || left || right)，前缀与叶子区分，防止以内部节点冒充叶子
+func merkleNode(left, right []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{1}, left...), right...))
+	return sum[:]
+}
+
+// AnchorFieldRoot 写入记录字段级哈希的 Merkle 根，需 write 权限；更新记录内容会清空字段根，须重新锚定
+func (s *SmartContract) AnchorFieldRoot(ctx contractapi.TransactionContextInterface, recordID, fieldRoot string) error {
+	if !sha256HexPattern.MatchString(fieldRoot) {
+		return fmt.Errorf("fieldRoot must be a lowercase hex sha256 digest")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if record.Status == RecordArchived {
+		return fmt.Errorf("record %s is archived", recordID)
+	}
+	allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "write")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: %s cannot update record %s", callerID, recordID)
+	}
+
+	record.FieldRoot = fieldRoot
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "FieldRootAnchored", FieldRootAnchoredEvent{
+		RecordID:  recordID,
+		FieldRoot: fieldRoot,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "FieldRootAnchored",
+	})
+}
+
+// VerifyFieldDisclosure 验证 fieldPath 上哈希为 fieldHash 的字段属于记录的字段根。proofJson 为自叶子向上的兄弟节点列表
+// [{hash, position: left|right}]。只返回验证结果，不授予记录访问，调用者无需授权
+func (s *SmartContract) VerifyFieldDisclosure(ctx contractapi.TransactionContextInterface, recordID, fieldPath, fieldHash, proofJson string) (bool, error) {
+	if fieldPath == "" || len(fieldPath) > maxFieldPathLength {
+		return false, fmt.Errorf("fieldPath must be 1 to %d bytes", maxFieldPathLength)
+	}
+	if !sha256HexPattern.MatchString(fieldHash) {
+		return false, fmt.Errorf("fieldHash must be a lowercase hex sha256 digest")
+	}
+	var proof []FieldProofStep
+	if err := unmarshalArg(proofJson, &proof); err != nil {
+		return false, fmt.Errorf("failed to unmarshal proof: %w", err)
+	}
+	if len(proof) > maxFieldProofDepth {
+		return false, fmt.Errorf("proof can have at most %d steps", maxFieldProofDepth)
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	if record.FieldRoot == "" {
+		return false, fmt.Errorf("record %s has no field root", recordID)
+	}
+
+	leafHash, _ := hex.DecodeString(fieldHash)
+	node := fieldLeaf(fieldPath, leafHash)
+	for i, step := range proof {
+		if !sha256HexPattern.MatchString(step.Hash) {
+			return false, fmt.Errorf("proof step %d: hash must be a lowercase hex sha256 digest", i)
+		}
+		sibling, _ := hex.DecodeString(step.Hash)
+		switch step.Position {
+		case "left":
+			node = merkleNode(sibling, node)
+		case "right":
+			node = merkleNode(node, sibling)
+		default:
+			return false, fmt.Errorf("proof step %d: position must be left or right", i)
+		}
+	}
+	return hex.EncodeToString(node) == record.FieldRoot, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// fieldTree 四个字段的 Merkle 树：返回根与 bloodType 叶子（下标 1）的证明
+func fieldTree(bloodType string) (string, string, string) {
+	fieldHash := func(salt, value string) []byte {
+		sum := sha256.Sum256([]byte(salt + value))
+		return sum[:]
+	}
+	bloodHash := fieldHash("s1", bloodType)
+	leaves := [][]byte{
+		fieldLeaf("patient.name", fieldHash("s0", "Nguyen Van A")),
+		fieldLeaf("patient.bloodType", bloodHash),
+		fieldLeaf("patient.allergies", fieldHash("s2", "penicillin")),
+		fieldLeaf("patient.birthDate", fieldHash("s3", "1990-01-01")),
+	}
+	left := merkleNode(leaves[0], leaves[1])
+	right := merkleNode(leaves[2], leaves[3])
+	root := merkleNode(left, right)
+	proof, _ := json.Marshal([]FieldProofStep{
+		{Hash: hex.EncodeToString(leaves[0]), Position: "left"},
+		{Hash: hex.EncodeToString(right), Position: "right"},
+	})
+	return hex.EncodeToString(root), hex.EncodeToString(bloodHash), string(proof)
+}
+
+func (e *testEnv) verifyField(recordID, fieldPath, fieldHash, proof string) bool {
+	e.t.Helper()
+	var valid bool
+	e.mustInvoke(newIdentity("verifier", "Org3MSP"), func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		valid, err = e.cc.VerifyFieldDisclosure(ctx, recordID, fieldPath, fieldHash, proof)
+		return err
+	})
+	return valid
+}
+
+func TestVerifyFieldDisclosure(t *testing.T) {
+	env := newTestEnv(t)
+	root, bloodHash, proof := fieldTree("O+")
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustFail(specialist, "has no field root", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyFieldDisclosure(ctx, "rec1", "patient.bloodType", bloodHash, proof)
+		return err
+	})
+	env.mustFail(specialist, "cannot update", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorFieldRoot(ctx, "rec1", root)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AnchorFieldRoot(ctx, "rec1", root)
+	})
+	env.expectEvent("FieldRootAnchored", nil)
+
+	// 验证者无需任何授权
+	if !env.verifyField("rec1", "patient.bloodType", bloodHash, proof) {
+		t.Fatal("valid disclosure should verify")
+	}
+	_, otherHash, _ := fieldTree("A-")
+	if env.verifyField("rec1", "patient.bloodType", otherHash, proof) {
+		t.Fatal("a different value must not verify")
+	}
+	if env.verifyField("rec1", "patient.allergies", bloodHash, proof) {
+		t.Fatal("the proof must not verify under another field path")
+	}
+	if env.verifyField("rec1", "patient.bloodType", bloodHash, strings.Replace(proof, `"left"`, `"right"`, 1)) {
+		t.Fatal("a proof with swapped positions must not verify")
+	}
+	env.mustFail(specialist, "position must be left or right", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyFieldDisclosure(ctx, "rec1", "patient.bloodType", bloodHash, `[{"hash":"`+bloodHash+`","position":"up"}]`)
+		return err
+	})
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+
+	// 内容更新后字段根作废
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafyrec1v2", strings.Repeat("f", 64))
+	})
+	env.mustFail(specialist, "has no field root", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyFieldDisclosure(ctx, "rec1", "patient.bloodType", bloodHash, proof)
+		return err
+	})
+}
+
+func TestFieldRootAtCreation(t *testing.T) {
+	env := newTestEnv(t)
+	root, bloodHash, proof := fieldTree("O+")
+	record := strings.Replace(recordJSON(doctor, "rec1", patient.id), `"recordId"`, `"fieldRoot":"`+root+`","recordId"`, 1)
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, record)
+		return err
+	})
+	if !env.verifyField("rec1", "patient.bloodType", bloodHash, proof) {
+		t.Fatal("field root set at creation should verify")
+	}
+	env.mustFail(doctor, "fieldRoot must be", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, strings.Replace(recordJSON(doctor, "rec2", patient.id), `"recordId"`, `"fieldRoot":"abc","recordId"`, 1))
+		return err
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Notarization 辅助文档（同意书 PDF、出院签字单、律师函等）的存证，不建立 MedicalRecord；
+// 文档本身留在链下，链上只存哈希与可选的关联记录
+type Notarization struct {
+	DocumentHash   string `json:"documentHash"`
+	DocType        string `json:"docType"`
+	LinkedRecordID string `json:"linkedRecordId,omitempty"`
+	NotarizedBy    string `json:"notarizedBy"`
+	NotarizedAt    string `json:"notarizedAt"`
+	TxID           string `json:"txId"`
+}
+
+// DocumentNotarizedEvent RecordID 为关联记录，随事件进入该记录的审计轨迹
+type DocumentNotarizedEvent struct {
+	DocumentHash string `json:"documentHash"`
+	DocType      string `json:"docType"`
+	RecordID     string `json:"recordId,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+func notaryKey(documentHash string) string {
+	return "notary:" + documentHash
+}
+
+// NotarizeDocument 存证文档哈希；docType 与 recordType 同格式。linkedRecordID 可为空，
+// 非空时调用者须对该记录持有 write 级权限。同一哈希只能存证一次
+func (s *SmartContract) NotarizeDocument(ctx contractapi.TransactionContextInterface, documentHash, docType, linkedRecordID string) error {
+	if err := validateAddress(documentHash); err != nil {
+		return fmt.Errorf("invalid documentHash: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.checkContentHash(documentHash); err != nil {
+		return err
+	}
+	if !recordTypePattern.MatchString(docType) {
+		return fmt.Errorf("invalid docType: %q", docType)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if linkedRecordID != "" {
+		allowed, err := s.ValidatePermissionLevel(ctx, linkedRecordID, callerID, "write")
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("access denied: %s cannot link documents to record %s", callerID, linkedRecordID)
+		}
+	}
+	exists, err := assetExists(ctx, notaryKey(documentHash))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("document %s is already notarized", documentHash)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, notaryKey(documentHash), Notarization{
+		DocumentHash:   documentHash,
+		DocType:        docType,
+		LinkedRecordID: linkedRecordID,
+		NotarizedBy:    callerID,
+		NotarizedAt:    now,
+		TxID:           ctx.GetStub().GetTxID(),
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "DocumentNotarized", DocumentNotarizedEvent{
+		DocumentHash: documentHash,
+		DocType:      docType,
+		RecordID:     linkedRecordID,
+		Timestamp:    now,
+		CallerID:     callerID,
+		EventType:    "DocumentNotarized",
+	})
+}
+
+// GetNotarization 返回存证条目
+func (s *SmartContract) GetNotarization(ctx contractapi.TransactionContextInterface, documentHash string) (*Notarization, error) {
+	var notarization Notarization
+	found, err := getJSON(ctx, notaryKey(documentHash), &notarization)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("document %s is not notarized", documentHash)
+	}
+	return &notarization, nil
+}
+
+// VerifyNotarization 文档哈希是否已存证；供只需是/否答案的校验方调用
+func (s *SmartContract) VerifyNotarization(ctx contractapi.TransactionContextInterface, documentHash string) (bool, error) {
+	return assetExists(ctx, notaryKey(documentHash))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestNotarizeDocument(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	consentPDF := strings.Repeat("c", 64)
+
+	env.mustFail(nurse, "cannot link documents", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.NotarizeDocument(ctx, consentPDF, "consent-form", "rec1")
+	})
+	env.mustFail(doctor, "invalid docType", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.NotarizeDocument(ctx, consentPDF, "Consent Form", "")
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.NotarizeDocument(ctx, consentPDF, "consent-form", "rec1")
+	})
+	var event DocumentNotarizedEvent
+	env.expectEvent("DocumentNotarized", &event)
+	if event.RecordID != "rec1" || event.DocType != "consent-form" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(patient, "already notarized", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.NotarizeDocument(ctx, consentPDF, "consent-form", "")
+	})
+	// 不关联记录时任何身份都可存证
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.NotarizeDocument(ctx, strings.Repeat("d", 64), "legal-letter", "")
+	})
+
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		notarization, err := env.cc.GetNotarization(ctx, consentPDF)
+		if err == nil && (notarization.NotarizedBy != doctor.id || notarization.LinkedRecordID != "rec1" || notarization.TxID == "") {
+			t.Fatalf("unexpected notarization: %+v", notarization)
+		}
+		return err
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		for hash, want := range map[string]bool{consentPDF: true, strings.Repeat("e", 64): false} {
+			if ok, err := env.cc.VerifyNotarization(ctx, hash); err != nil || ok != want {
+				t.Fatalf("VerifyNotarization(%s) = %v, %v", hash, ok, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// publicHealthRole 公共卫生机构身份，证书属性 role=public-health
+	publicHealthRole = "public-health"
+
+	// collectionPublicHealth 报告患者身份的私有数据集合，成员仅公共卫生 MSP（报告医院的节点背书写入但不保存），需在 collections_config.json 中定义
+	collectionPublicHealth = "collectionPublicHealth"
+
+	// notifiablePatientTransientKey 患者身份经 transient 传入，不进入交易提案的公开参数
+	notifiablePatientTransientKey = "patientId"
+)
+
+// jurisdictionPattern ISO 3166-1 国家代码，可带 ISO 3166-2 细分
+var jurisdictionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// NotifiableReport 法定传染病报告的公开锚点，不含患者身份；ReportID 为报告交易
+type NotifiableReport struct {
+	ReportID      string `json:"reportId"`
+	ConditionCode string `json:"conditionCode"`
+	Jurisdiction  string `json:"jurisdiction"`
+	CaseHash      string `json:"caseHash"`
+	ReportedBy    string `json:"reportedBy"`
+	ReportedByMsp string `json:"reportedByMsp"`
+	ReportedAt    string `json:"reportedAt"`
+}
+
+// NotifiableCase 私有集合中的病例身份，按 caseHash 与公开锚点对应
+type NotifiableCase struct {
+	ReportID  string `json:"reportId"`
+	CaseHash  string `json:"caseHash"`
+	PatientID string `json:"patientId"`
+}
+
+type NotifiableReportPage struct {
+	Entries  []*NotifiableReport `json:"entries"`
+	Bookmark string              `json:"bookmark"`
+}
+
+type NotifiableConditionReportedEvent struct {
+	ReportID      string `json:"reportId"`
+	ConditionCode string `json:"conditionCode"`
+	Jurisdiction  string `json:"jurisdiction"`
+	CaseHash      string `json:"caseHash"`
+	ReportedByMsp string `json:"reportedByMsp"`
+	Timestamp     string `json:"timestamp"`
+	EventType     string `json:"eventType"`
+}
+
+// notifiableJurisdictionKey notifiable:{jurisdiction}:{epoch}:{reportId}
+func notifiableJurisdictionKey(jurisdiction string, at time.Time, reportID string) string {
+	return "notifiable:" + keySegment(jurisdiction) + ":" + epochSegment(at) + ":" + reportID
+}
+
+// notifiableMspKey notifiable-by:{mspId}:{epoch}:{reportId}
+func notifiableMspKey(mspID string, at time.Time, reportID string) string {
+	return "notifiable-by:" + keySegment(mspID) + ":" + epochSegment(at) + ":" + reportID
+}
+
+// notifiableCaseKey notifiable-case:{caseHash}；公开状态存报告，私有集合存病例身份
+func notifiableCaseKey(caseHash string) string {
+	return "notifiable-case:" + caseHash
+}
+
+func publicHealthOrAuditor(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return hasAnyRole(ctx, publicHealthRole, auditorRole)
+}
+
+// ReportNotifiableCondition 持有效执业凭证的医护提交法定传染病报告并返回 reportId。
+// 患者身份经 transient 的 patientId 传入，只写入 collectionPublicHealth；同一 caseHash 只能报告一次
+func (s *SmartContract) ReportNotifiableCondition(ctx contractapi.TransactionContextInterface, conditionCode, jurisdiction, caseHash string) (string, error) {
+	if !conditionCodePattern.MatchString(conditionCode) {
+		return "", fmt.Errorf("invalid conditionCode: %q", conditionCode)
+	}
+	if !jurisdictionPattern.MatchString(jurisdiction) {
+		return "", fmt.Errorf("invalid jurisdiction: %q", jurisdiction)
+	}
+	if !sha256HexPattern.MatchString(caseHash) {
+		return "", fmt.Errorf("invalid caseHash: expected hex-encoded SHA-256")
+	}
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient data: %w", err)
+	}
+	patientID := string(transient[notifiablePatientTransientKey])
+	if err := validateAddress(patientID); err != nil {
+		return "", fmt.Errorf("invalid patientId in transient data: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := requireProviderCredential(ctx, callerID); err != nil {
+		return "", err
+	}
+	exists, err := assetExists(ctx, notifiableCaseKey(caseHash))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("case already reported: %s", caseHash)
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	report := NotifiableReport{
+		ReportID:      ctx.GetStub().GetTxID(),
+		ConditionCode: conditionCode,
+		Jurisdiction:  jurisdiction,
+		CaseHash:      caseHash,
+		ReportedBy:    callerID,
+		ReportedByMsp: mspID,
+		ReportedAt:    now.Format(time.RFC3339),
+	}
+	// 两个索引与按 caseHash 的键都存完整报告，对账查询只需一次范围扫描或一次读取
+	for _, key := range []string{
+		notifiableJurisdictionKey(jurisdiction, now, report.ReportID),
+		notifiableMspKey(mspID, now, report.ReportID),
+		notifiableCaseKey(caseHash),
+	} {
+		if err := putJSON(ctx, key, report); err != nil {
+			return "", err
+		}
+	}
+	data, err := json.Marshal(NotifiableCase{ReportID: report.ReportID, CaseHash: caseHash, PatientID: patientID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal notifiable case: %w", err)
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionPublicHealth, notifiableCaseKey(caseHash), data); err != nil {
+		return "", fmt.Errorf("failed to store notifiable case: %w", err)
+	}
+	return report.ReportID, emitEvent(ctx, "NotifiableConditionReported", NotifiableConditionReportedEvent{
+		ReportID:      report.ReportID,
+		ConditionCode: conditionCode,
+		Jurisdiction:  jurisdiction,
+		CaseHash:      caseHash,
+		ReportedByMsp: mspID,
+		Timestamp:     report.ReportedAt,
+		EventType:     "NotifiableConditionReported",
+	})
+}
+
+// ListNotifiableReports 按辖区或报告 MSP（二选一）分页列出 [fromTimestamp, toTimestamp] 内的报告锚点，供医院与卫生部门对账；
+// 按辖区查询限 public-health 与 auditor 角色，按 MSP 查询另对该 MSP 的成员开放
+func (s *SmartContract) ListNotifiableReports(ctx contractapi.TransactionContextInterface, jurisdiction, reporterMsp, fromTimestamp, toTimestamp string, pageSize int32, bookmark string) (*NotifiableReportPage, error) {
+	if (jurisdiction == "") == (reporterMsp == "") {
+		return nil, fmt.Errorf("exactly one of jurisdiction or reporterMsp is required")
+	}
+	from, to, err := parseTimeRange(fromTimestamp, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	allowed, err := publicHealthOrAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed && reporterMsp != "" {
+		mspID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+		}
+		allowed = mspID == reporterMsp
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only %s, an auditor or the reporting organization can list notifiable reports", publicHealthRole)
+	}
+
+	prefix := "notifiable-by:" + keySegment(reporterMsp) + ":"
+	if jurisdiction != "" {
+		prefix = "notifiable:" + keySegment(jurisdiction) + ":"
+	}
+	page := &NotifiableReportPage{Entries: []*NotifiableReport{}}
+	page.Bookmark, err = scanAuditRange(ctx, prefix, from, to, pageSize, bookmark, func(value []byte) error {
+		var report NotifiableReport
+		if err := json.Unmarshal(value, &report); err != nil {
+			return fmt.Errorf("failed to unmarshal notifiable report: %w", err)
+		}
+		page.Entries = append(page.Entries, &report)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetNotifiableReport 按 caseHash 读取公开锚点，卫生部门核对收到的病例是否已上链；权限同按 MSP 查询
+func (s *SmartContract) GetNotifiableReport(ctx contractapi.TransactionContextInterface, caseHash string) (*NotifiableReport, error) {
+	var report NotifiableReport
+	found, err := getJSON(ctx, notifiableCaseKey(caseHash), &report)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("notifiable report not found: %s", caseHash)
+	}
+	allowed, err := publicHealthOrAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		mspID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+		}
+		if mspID != report.ReportedByMsp {
+			return nil, fmt.Errorf("access denied: only %s, an auditor or the reporting organization can view this report", publicHealthRole)
+		}
+	}
+	return &report, nil
+}
+
+// GetNotifiableCase 读取私有集合中的病例身份，限 public-health 角色；须在集合成员组织的节点上调用
+func (s *SmartContract) GetNotifiableCase(ctx contractapi.TransactionContextInterface, caseHash string) (*NotifiableCase, error) {
+	allowed, err := hasRole(ctx, publicHealthRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s role required", publicHealthRole)
+	}
+	data, err := ctx.GetStub().GetPrivateData(collectionPublicHealth, notifiableCaseKey(caseHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifiable case: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("notifiable case not found: %s", caseHash)
+	}
+	var notifiableCase NotifiableCase
+	if err := json.Unmarshal(data, &notifiableCase); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notifiable case: %w", err)
+	}
+	return &notifiableCase, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var healthAuthority = newIdentity("cdc1", "PublicHealthMSP", "role", publicHealthRole)
+
+func TestNotifiableConditionReportKeepsIdentityPrivate(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	caseHash := strings.Repeat("c", 64)
+	report := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReportNotifiableCondition(ctx, "A15.0", "US-CA", caseHash)
+		return err
+	}
+
+	env.mustFail(doctor, "invalid patientId in transient data", report)
+	env.stub.TransientMap = map[string][]byte{notifiablePatientTransientKey: []byte(patient.id)}
+	defer func() { env.stub.TransientMap = nil }()
+	env.mustFail(doctor, "invalid jurisdiction", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReportNotifiableCondition(ctx, "A15.0", "california", caseHash)
+		return err
+	})
+	env.mustFail(other, "credential", report)
+	env.mustInvoke(doctor, report)
+	var event NotifiableConditionReportedEvent
+	env.expectEvent("NotifiableConditionReported", &event)
+	if event.Jurisdiction != "US-CA" || event.ReportedByMsp != doctor.msp {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(doctor, "already reported", report)
+
+	// 公开状态中不出现患者身份
+	for key, value := range env.stub.State {
+		if strings.HasPrefix(key, "notifiable") && strings.Contains(string(value), patient.id) {
+			t.Fatalf("public state %s leaks the patient identity", key)
+		}
+	}
+
+	to := env.stub.now.Format(time.RFC3339)
+	list := func(jurisdiction, msp string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.ListNotifiableReports(ctx, jurisdiction, msp, from, to, 10, "")
+			if err == nil && (len(page.Entries) != 1 || page.Entries[0].CaseHash != caseHash) {
+				t.Fatalf("unexpected reports: %+v", page.Entries)
+			}
+			return err
+		}
+	}
+	env.mustInvoke(healthAuthority, list("US-CA", ""))
+	env.mustInvoke(doctor, list("", doctor.msp))
+	env.mustInvoke(auditor, list("", doctor.msp))
+	env.mustFail(doctor, "access denied", list("US-CA", ""))
+	env.mustFail(nurse, "access denied", list("", doctor.msp))
+	env.mustInvoke(healthAuthority, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListNotifiableReports(ctx, "US", "", from, to, 10, "")
+		if err == nil && len(page.Entries) != 0 {
+			t.Fatalf("jurisdiction prefixes must not match subdivisions: %+v", page.Entries)
+		}
+		return err
+	})
+
+	env.mustInvoke(healthAuthority, func(ctx contractapi.TransactionContextInterface) error {
+		anchor, err := env.cc.GetNotifiableReport(ctx, caseHash)
+		if err != nil {
+			return err
+		}
+		notifiableCase, err := env.cc.GetNotifiableCase(ctx, caseHash)
+		if err == nil && (notifiableCase.PatientID != patient.id || notifiableCase.ReportID != anchor.ReportID) {
+			t.Fatalf("private case does not reconcile with the anchor: %+v %+v", notifiableCase, anchor)
+		}
+		return err
+	})
+	env.mustFail(auditor, "public-health role required", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetNotifiableCase(ctx, caseHash)
+		return err
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetNotifiableReport(ctx, caseHash)
+		return err
+	})
+}
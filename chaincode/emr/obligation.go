@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ObligationPending   = "pending"
+	ObligationSubmitted = "submitted"
+)
+
+// AccessObligation 读取高敏记录后须在 DueAt 前补交的理由；ObligationID 为读取交易
+type AccessObligation struct {
+	ObligationID      string `json:"obligationId"`
+	RecordID          string `json:"recordId"`
+	AccessorID        string `json:"accessorId"`
+	ReadAt            string `json:"readAt"`
+	DueAt             string `json:"dueAt"`
+	Status            string `json:"status"`
+	JustificationHash string `json:"justificationHash,omitempty"`
+	SubmittedAt       string `json:"submittedAt,omitempty"`
+}
+
+type AccessJustifiedEvent struct {
+	ObligationID      string `json:"obligationId"`
+	RecordID          string `json:"recordId"`
+	AccessorID        string `json:"accessorId"`
+	JustificationHash string `json:"justificationHash"`
+	Overdue           bool   `json:"overdue"`
+	Timestamp         string `json:"timestamp"`
+	EventType         string `json:"eventType"`
+}
+
+// obligationKey obligation:{obligationId}
+func obligationKey(obligationID string) string {
+	return "obligation:" + obligationID
+}
+
+// openObligationKey obligation-open:{accessorId}:{epoch(dueAt)}:{obligationId}，值为 obligationId；提交后删除
+func openObligationKey(accessorID string, dueAt time.Time, obligationID string) string {
+	return openObligationPrefix(accessorID) + epochSegment(dueAt) + ":" + obligationID
+}
+
+func openObligationPrefix(accessorID string) string {
+	return "obligation-open:" + keySegment(accessorID) + ":"
+}
+
+// requireNoOverdueObligations 调用者存在已到期未提交的理由时拒绝读取；按到期时间排序，只需看区间内第一条
+func requireNoOverdueObligations(ctx contractapi.TransactionContextInterface, accessorID string) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	prefix := openObligationPrefix(accessorID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix+epochSegment(now.Add(time.Second)))
+	if err != nil {
+		return fmt.Errorf("failed to scan access obligations: %w", err)
+	}
+	defer iterator.Close()
+	if !iterator.HasNext() {
+		return nil
+	}
+	kv, err := iterator.Next()
+	if err != nil {
+		return fmt.Errorf("failed to iterate access obligations: %w", err)
+	}
+	return fmt.Errorf("access denied: %s has an overdue access justification (obligation %s)", accessorID, kv.Value)
+}
+
+// createAccessObligation 启用 postAccessJustification 时，患者与创建者以外的身份读取高敏记录须事后补交理由
+func createAccessObligation(ctx contractapi.TransactionContextInterface, config *ContractConfig, record *MedicalRecord, callerID string) error {
+	if !featureEnabled(config, FeaturePostAccessJustification) || record.Sensitivity != SensitivityHigh || callerID == record.CreatorID {
+		return nil
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	dueAt := now.Add(time.Duration(config.JustificationDueHours) * time.Hour)
+	obligation := AccessObligation{
+		ObligationID: ctx.GetStub().GetTxID(),
+		RecordID:     record.RecordID,
+		AccessorID:   callerID,
+		ReadAt:       now.Format(time.RFC3339),
+		DueAt:        dueAt.Format(time.RFC3339),
+		Status:       ObligationPending,
+	}
+	if err := putJSON(ctx, obligationKey(obligation.ObligationID), obligation); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(openObligationKey(callerID, dueAt, obligation.ObligationID), []byte(obligation.ObligationID)); err != nil {
+		return fmt.Errorf("failed to index access obligation: %w", err)
+	}
+	return nil
+}
+
+// SubmitAccessJustification 访问者补交理由哈希；逾期仍可提交，提交后解除阻断
+func (s *SmartContract) SubmitAccessJustification(ctx contractapi.TransactionContextInterface, obligationID, justificationHash string) error {
+	if !sha256HexPattern.MatchString(justificationHash) {
+		return fmt.Errorf("justificationHash must be a hex-encoded sha256 digest")
+	}
+	var obligation AccessObligation
+	found, err := getJSON(ctx, obligationKey(obligationID), &obligation)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("access obligation %s does not exist", obligationID)
+	}
+	isAccessor, err := callerIs(ctx, obligation.AccessorID)
+	if err != nil {
+		return err
+	}
+	if !isAccessor {
+		return fmt.Errorf("access denied: only %s can justify this access", obligation.AccessorID)
+	}
+	if obligation.Status != ObligationPending {
+		return fmt.Errorf("access obligation %s is already %s", obligationID, obligation.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	// 到期时间已在写入时校验
+	dueAt, _ := time.Parse(time.RFC3339, obligation.DueAt)
+	if err := ctx.GetStub().DelState(openObligationKey(obligation.AccessorID, dueAt, obligationID)); err != nil {
+		return fmt.Errorf("failed to close access obligation: %w", err)
+	}
+	obligation.Status = ObligationSubmitted
+	obligation.JustificationHash = justificationHash
+	obligation.SubmittedAt = now.Format(time.RFC3339)
+	if err := putJSON(ctx, obligationKey(obligationID), obligation); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AccessJustified", AccessJustifiedEvent{
+		ObligationID:      obligationID,
+		RecordID:          obligation.RecordID,
+		AccessorID:        obligation.AccessorID,
+		JustificationHash: justificationHash,
+		Overdue:           !now.Before(dueAt),
+		Timestamp:         obligation.SubmittedAt,
+		EventType:         "AccessJustified",
+	})
+}
+
+// ListAccessObligations 按到期时间列出访问者未提交的理由；限本人与审计员
+func (s *SmartContract) ListAccessObligations(ctx contractapi.TransactionContextInterface, accessorID string) ([]*AccessObligation, error) {
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		allowed, err = callerIs(ctx, accessorID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only %s or an auditor can list its access obligations", accessorID)
+	}
+
+	prefix := openObligationPrefix(accessorID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan access obligations: %w", err)
+	}
+	defer iterator.Close()
+
+	obligations := []*AccessObligation{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate access obligations: %w", err)
+		}
+		data, err := ctx.GetStub().GetState(obligationKey(string(kv.Value)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read access obligation: %w", err)
+		}
+		var obligation AccessObligation
+		if err := json.Unmarshal(data, &obligation); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal access obligation: %w", err)
+		}
+		obligations = append(obligations, &obligation)
+	}
+	return obligations, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) accessObligations(accessorID string) []*AccessObligation {
+	e.t.Helper()
+	var obligations []*AccessObligation
+	e.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		obligations, err = e.cc.ListAccessObligations(ctx, accessorID)
+		return err
+	})
+	return obligations
+}
+
+func TestSensitiveReadsRequireLaterJustification(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSensitiveRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	proposalID := env.proposeSensitiveGrant(patient, "rec1", nurse.id, "read")
+	env.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+	env.grant(patient, "rec2", nurse.id, "read", "")
+
+	if !env.canRead(nurse, "rec1") || len(env.accessObligations(nurse.id)) != 0 {
+		t.Fatal("obligations must only be created with postAccessJustification enabled")
+	}
+	env.setFeature(FeaturePostAccessJustification, true)
+	if !env.canRead(nurse, "rec1") || !env.canRead(doctor, "rec1") || !env.canRead(patient, "rec1") || !env.canRead(nurse, "rec2") {
+		t.Fatal("reads must still succeed")
+	}
+	obligations := env.accessObligations(nurse.id)
+	if len(obligations) != 1 || obligations[0].RecordID != "rec1" || obligations[0].DueAt != env.stub.now.Add(24*time.Hour).Format(time.RFC3339) {
+		t.Fatalf("one obligation for the sensitive read expected: %+v", obligations)
+	}
+	if len(env.accessObligations(doctor.id)) != 0 || len(env.accessObligations(patient.id)) != 0 {
+		t.Fatal("patient and creator reads need no justification")
+	}
+
+	env.advance(23 * time.Hour)
+	if !env.canRead(nurse, "rec2") {
+		t.Fatal("pending obligations must not block reads before they are due")
+	}
+	env.advance(time.Hour)
+	env.mustFail(nurse, "overdue access justification", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec2")
+		return err
+	})
+
+	submit := func(hash string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.SubmitAccessJustification(ctx, obligations[0].ObligationID, hash)
+		}
+	}
+	justification := strings.Repeat("e", 64)
+	env.mustFail(other, "only "+nurse.id, submit(justification))
+	env.mustFail(nurse, "sha256", submit("because"))
+	env.mustInvoke(nurse, submit(justification))
+	var event AccessJustifiedEvent
+	env.expectEvent("AccessJustified", &event)
+	if !event.Overdue || event.RecordID != "rec1" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(nurse, "already submitted", submit(justification))
+	if !env.canRead(nurse, "rec2") || len(env.accessObligations(nurse.id)) != 0 {
+		t.Fatal("submitting the justification must lift the block")
+	}
+}
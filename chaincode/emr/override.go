@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	OverridePending  = "pending"
+	OverrideApproved = "approved"
+
+	// overrideTTL 提议未在此期限内审批即失效
+	overrideTTL = 48 * time.Hour
+)
+
+// RecordOverride 双人管理员强制解锁：所有者身份丢失且无法走身份恢复时，把记录转交给新的所有者。
+// 条目本身即审计记录，保留双方身份、机构与理由哈希
+type RecordOverride struct {
+	OverrideID        string `json:"overrideId"`
+	RecordID          string `json:"recordId"`
+	PreviousOwnerID   string `json:"previousOwnerId"`
+	NewOwnerID        string `json:"newOwnerId"`
+	JustificationHash string `json:"justificationHash"`
+	ProposedBy        string `json:"proposedBy"`
+	ProposerMSP       string `json:"proposerMsp"`
+	ApprovedBy        string `json:"approvedBy,omitempty"`
+	ApproverMSP       string `json:"approverMsp,omitempty"`
+	Status            string `json:"status"`
+	CreatedAt         string `json:"createdAt"`
+	ExpiresAt         string `json:"expiresAt"`
+	UpdatedAt         string `json:"updatedAt"`
+}
+
+// RecordOverrideEvent Notify 为 true 时通知服务须告知原所有者与新所有者
+type RecordOverrideEvent struct {
+	OverrideID      string `json:"overrideId"`
+	RecordID        string `json:"recordId"`
+	PatientID       string `json:"patientId"`
+	PreviousOwnerID string `json:"previousOwnerId"`
+	Status          string `json:"status"`
+	Notify          bool   `json:"notify"`
+	Timestamp       string `json:"timestamp"`
+	CallerID        string `json:"callerId"`
+	EventType       string `json:"eventType"`
+}
+
+func overrideKey(overrideID string) string {
+	return "override:" + overrideID
+}
+
+// requireAdminCaller 返回调用者 ID 与 MSP，调用者须为 admin
+func requireAdminCaller(ctx contractapi.TransactionContextInterface, what string) (string, string, error) {
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return "", "", err
+	}
+	if !isAdmin {
+		return "", "", fmt.Errorf("access denied: only admin can %s", what)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	return callerID, mspID, nil
+}
+
+func saveOverride(ctx contractapi.TransactionContextInterface, override *RecordOverride, eventName, callerID string) error {
+	if err := putJSON(ctx, overrideKey(override.OverrideID), override); err != nil {
+		return err
+	}
+	patientID := override.PreviousOwnerID
+	if override.Status == OverrideApproved {
+		patientID = override.NewOwnerID
+	}
+	return emitEvent(ctx, eventName, RecordOverrideEvent{
+		OverrideID:      override.OverrideID,
+		RecordID:        override.RecordID,
+		PatientID:       patientID,
+		PreviousOwnerID: override.PreviousOwnerID,
+		Status:          override.Status,
+		Notify:          true,
+		Timestamp:       override.UpdatedAt,
+		CallerID:        callerID,
+		EventType:       eventName,
+	})
+}
+
+// transferRecordOwner 改写记录患者并迁移按患者维护的索引、计数与访问条目
+func transferRecordOwner(ctx contractapi.TransactionContextInterface, record *MedicalRecord, newOwnerID, grantedBy, now string) error {
+	previousOwnerID := record.PatientID
+	recordedAt, err := time.Parse(time.RFC3339, record.Timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid stored timestamp: %w", err)
+	}
+	if err := delIndex(ctx, patientRecordIndex, previousOwnerID, record.RecordID); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, patientRecordIndex, newOwnerID, record.RecordID); err != nil {
+		return err
+	}
+	if record.Status == RecordArchived {
+		if err := delIndex(ctx, archivedRecordIndex, previousOwnerID, record.RecordID); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, archivedRecordIndex, newOwnerID, record.RecordID); err != nil {
+			return err
+		}
+	}
+	if err := ctx.GetStub().DelState(patientTimeKey(previousOwnerID, recordedAt, record.RecordID)); err != nil {
+		return fmt.Errorf("failed to delete patient time index: %w", err)
+	}
+	if err := ctx.GetStub().PutState(patientTimeKey(newOwnerID, recordedAt, record.RecordID), []byte(record.RecordID)); err != nil {
+		return fmt.Errorf("failed to store patient time index: %w", err)
+	}
+	if err := addCounter(ctx, patientRecordCounterKey(previousOwnerID), -1); err != nil {
+		return err
+	}
+	if err := addCounter(ctx, patientRecordCounterKey(newOwnerID), 1); err != nil {
+		return err
+	}
+
+	record.PatientID = newOwnerID
+	if err := putJSON(ctx, recordKey(record.RecordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	accessList, err := getAccessList(ctx, record.RecordID)
+	if err != nil {
+		return err
+	}
+	if accessList != nil {
+		accessList.Owner = newOwnerID
+		accessList.UpdatedAt = now
+		if err := putJSON(ctx, accessListKey(record.RecordID), accessList); err != nil {
+			return fmt.Errorf("failed to store access list: %w", err)
+		}
+	}
+	if previousOwnerID != record.CreatorID {
+		key, err := accessEntryKey(ctx, record.RecordID, previousOwnerID)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to delete access entry: %w", err)
+		}
+	}
+	return putAccessEntry(ctx, AccessPermission{
+		RecordID:  record.RecordID,
+		GranteeID: newOwnerID,
+		Action:    "admin",
+		GrantedAt: now,
+		GrantedBy: grantedBy,
+		IsActive:  true,
+	})
+}
+
+// ProposeOverride admin 提议把记录转交给 newOwnerID，返回提议 ID；justificationHash 为线下核验材料的 sha256
+func (s *SmartContract) ProposeOverride(ctx contractapi.TransactionContextInterface, recordID, newOwnerID, justificationHash string) (string, error) {
+	if err := validateAddress(newOwnerID); err != nil {
+		return "", fmt.Errorf("invalid newOwnerID: %w", err)
+	}
+	if !sha256HexPattern.MatchString(justificationHash) {
+		return "", fmt.Errorf("justificationHash must be a lowercase hex sha256 digest")
+	}
+	callerID, mspID, err := requireAdminCaller(ctx, "propose an override")
+	if err != nil {
+		return "", err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	if record.PatientID == newOwnerID {
+		return "", fmt.Errorf("%s already owns record %s", newOwnerID, recordID)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	ts := now.Format(time.RFC3339)
+	override := &RecordOverride{
+		OverrideID:        ctx.GetStub().GetTxID(),
+		RecordID:          recordID,
+		PreviousOwnerID:   record.PatientID,
+		NewOwnerID:        newOwnerID,
+		JustificationHash: justificationHash,
+		ProposedBy:        callerID,
+		ProposerMSP:       mspID,
+		Status:            OverridePending,
+		CreatedAt:         ts,
+		ExpiresAt:         now.Add(overrideTTL).Format(time.RFC3339),
+		UpdatedAt:         ts,
+	}
+	return override.OverrideID, saveOverride(ctx, override, "OverrideProposed", callerID)
+}
+
+// ApproveOverride 另一机构的 admin 审批后执行转交
+func (s *SmartContract) ApproveOverride(ctx contractapi.TransactionContextInterface, overrideID string) error {
+	var override RecordOverride
+	found, err := getJSON(ctx, overrideKey(overrideID), &override)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("override not found: %s", overrideID)
+	}
+	if override.Status != OverridePending {
+		return fmt.Errorf("override %s is %s", overrideID, override.Status)
+	}
+	callerID, mspID, err := requireAdminCaller(ctx, "approve an override")
+	if err != nil {
+		return err
+	}
+	if callerID == override.ProposedBy || mspID == override.ProposerMSP {
+		return fmt.Errorf("access denied: the approver must be an admin from an organization other than %s", override.ProposerMSP)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, override.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("override %s expired at %s", overrideID, override.ExpiresAt)
+	}
+	record, err := getRecord(ctx, override.RecordID)
+	if err != nil {
+		return err
+	}
+	if record.PatientID != override.PreviousOwnerID {
+		return fmt.Errorf("record %s changed owner since the override was proposed", override.RecordID)
+	}
+
+	ts := now.Format(time.RFC3339)
+	if err := transferRecordOwner(ctx, record, override.NewOwnerID, overrideKey(overrideID), ts); err != nil {
+		return err
+	}
+	override.Status = OverrideApproved
+	override.ApprovedBy = callerID
+	override.ApproverMSP = mspID
+	override.UpdatedAt = ts
+	return saveOverride(ctx, &override, "OverrideApproved", callerID)
+}
+
+// GetOverride 返回强制解锁的审计条目
+func (s *SmartContract) GetOverride(ctx contractapi.TransactionContextInterface, overrideID string) (*RecordOverride, error) {
+	var override RecordOverride
+	found, err := getJSON(ctx, overrideKey(overrideID), &override)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("override not found: %s", overrideID)
+	}
+	return &override, nil
+}
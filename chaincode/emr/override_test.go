@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var admin2 = newIdentity("admin2", "Org2MSP", "role", "admin")
+
+func (e *testEnv) proposeOverride(recordID, newOwnerID string) string {
+	e.t.Helper()
+	var overrideID string
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		overrideID, err = e.cc.ProposeOverride(ctx, recordID, newOwnerID, strings.Repeat("c", 64))
+		return err
+	})
+	return overrideID
+}
+
+func TestDualControlOverride(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ProposeOverride(ctx, "rec1", agent.id, strings.Repeat("c", 64))
+		return err
+	})
+	overrideID := env.proposeOverride("rec1", agent.id)
+	var proposed RecordOverrideEvent
+	env.expectEvent("OverrideProposed", &proposed)
+	if !proposed.Notify || proposed.PatientID != patient.id {
+		t.Fatalf("proposal must notify the current owner: %+v", proposed)
+	}
+	if env.checkAccess("rec1", agent.id) {
+		t.Fatal("pending override must not change ownership")
+	}
+
+	env.mustFail(admin, "organization other than", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveOverride(ctx, overrideID)
+	})
+	env.mustFail(nurse, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveOverride(ctx, overrideID)
+	})
+	env.mustInvoke(admin2, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveOverride(ctx, overrideID)
+	})
+	var approved RecordOverrideEvent
+	env.expectEvent("OverrideApproved", &approved)
+	if !approved.Notify || approved.PatientID != agent.id || approved.PreviousOwnerID != patient.id {
+		t.Fatalf("unexpected notification: %+v", approved)
+	}
+
+	if !env.checkAccess("rec1", agent.id) || env.checkAccess("rec1", patient.id) {
+		t.Fatal("ownership must move to the new owner")
+	}
+	env.grant(agent, "rec1", nurse.id, "read", "")
+	env.mustInvoke(agent, func(ctx contractapi.TransactionContextInterface) error {
+		records, err := env.cc.ListRecordsByPatient(ctx, agent.id, "")
+		if err == nil && len(records) != 1 {
+			t.Fatalf("patient index must follow the new owner: %+v", records)
+		}
+		return err
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		override, err := env.cc.GetOverride(ctx, overrideID)
+		if err == nil && (override.ApprovedBy != admin2.id || override.ApproverMSP != "Org2MSP" || override.JustificationHash != strings.Repeat("c", 64)) {
+			t.Fatalf("override must keep the audit trail: %+v", override)
+		}
+		return err
+	})
+	env.mustFail(admin2, "is approved", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveOverride(ctx, overrideID)
+	})
+}
+
+func TestOverrideExpires(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	overrideID := env.proposeOverride("rec1", agent.id)
+	env.advance(49 * time.Hour)
+	env.mustFail(admin2, "expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveOverride(ctx, overrideID)
+	})
+}
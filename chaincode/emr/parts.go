@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxRecordParts 单条记录的部分上限（主内容加附件）
+const maxRecordParts = 32
+
+// mediaTypePattern type/subtype，不校验参数部分
+var mediaTypePattern = regexp.MustCompile(`^[a-z]+/[A-Za-z0-9.+-]{1,100}$`)
+
+// RecordPart 多部分记录中的一部分；第一部分即主内容，与记录的 ipfsCid/contentHash 一致
+type RecordPart struct {
+	CID         string `json:"cid"`
+	ContentHash string `json:"contentHash"`
+	MediaType   string `json:"mediaType"`
+	Size        int64  `json:"size"`
+}
+
+// validatePart 按链上配置校验单个部分的 CID 与哈希格式
+func (config *ContractConfig) validatePart(part RecordPart) error {
+	if part.CID == "" || part.ContentHash == "" {
+		return fmt.Errorf("cid and contentHash are required")
+	}
+	if err := config.checkCid(part.CID); err != nil {
+		return err
+	}
+	if err := config.checkContentHash(part.ContentHash); err != nil {
+		return err
+	}
+	if !mediaTypePattern.MatchString(part.MediaType) {
+		return fmt.Errorf("invalid mediaType: %q", part.MediaType)
+	}
+	if part.Size <= 0 {
+		return fmt.Errorf("size must be positive")
+	}
+	return nil
+}
+
+// validateParts 逐部分校验并拒绝重复 CID
+func (config *ContractConfig) validateParts(parts []RecordPart) error {
+	if len(parts) > maxRecordParts {
+		return fmt.Errorf("a record can have at most %d parts", maxRecordParts)
+	}
+	for i, part := range parts {
+		if err := config.validatePart(part); err != nil {
+			return fmt.Errorf("part %d: %w", i, err)
+		}
+		if partIndex(parts[:i], part.CID) >= 0 {
+			return fmt.Errorf("part %d: duplicate cid %s", i, part.CID)
+		}
+	}
+	return nil
+}
+
+// partIndex 返回 cid 所在的部分下标，不存在时为 -1
+func partIndex(parts []RecordPart, cid string) int {
+	for i, part := range parts {
+		if part.CID == cid {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordPart 返回第 index 部分；单部分记录只有下标 0，即主内容
+func recordPart(record *MedicalRecord, index int) (RecordPart, error) {
+	if len(record.Parts) == 0 && index == 0 {
+		return RecordPart{CID: record.IPCSCID, ContentHash: record.ContentHash}, nil
+	}
+	if index < 0 || index >= len(record.Parts) {
+		return RecordPart{}, fmt.Errorf("record %s has no part %d", record.RecordID, index)
+	}
+	return record.Parts[index], nil
+}
+
+// alignPrimaryPart 有 parts 时以第一部分回填 ipfsCid/contentHash；两者已填写时须与第一部分一致
+func alignPrimaryPart(rec *MedicalRecord) error {
+	if len(rec.Parts) == 0 {
+		return nil
+	}
+	primary := rec.Parts[0]
+	if (rec.IPCSCID != "" && rec.IPCSCID != primary.CID) || (rec.ContentHash != "" && rec.ContentHash != primary.ContentHash) {
+		return fmt.Errorf("ipfsCid and contentHash must match the first part")
+	}
+	rec.IPCSCID = primary.CID
+	rec.ContentHash = primary.ContentHash
+	return nil
+}
+
+// VerifyRecordPart 比较第 index 部分（0 为主内容）的哈希，供分部分下载时逐一校验；调用者须能读取记录
+func (s *SmartContract) VerifyRecordPart(ctx contractapi.TransactionContextInterface, recordID string, index int, contentHash string) (bool, error) {
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return false, err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	part, err := recordPart(record, index)
+	if err != nil {
+		return false, err
+	}
+	return part.ContentHash == contentHash, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// multipartJSON 病程记录加一份影像附件
+func multipartJSON(recordID string, parts ...RecordPart) string {
+	data, _ := json.Marshal(MedicalRecord{
+		RecordID:  recordID,
+		PatientID: patient.id,
+		CreatorID: doctor.id,
+		Parts:     parts,
+	})
+	return string(data)
+}
+
+var (
+	notePart  = RecordPart{CID: "bafynote", ContentHash: strings.Repeat("a", 64), MediaType: "text/plain", Size: 2048}
+	imagePart = RecordPart{CID: "bafyimage", ContentHash: strings.Repeat("b", 64), MediaType: "application/dicom", Size: 1 << 20}
+)
+
+func (e *testEnv) createMultipartRecord(recordID string) {
+	e.t.Helper()
+	e.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateMedicalRecord(ctx, multipartJSON(recordID, notePart, imagePart))
+		return err
+	})
+}
+
+func TestMultipartRecordBackfillsPrimary(t *testing.T) {
+	env := newTestEnv(t)
+	env.createMultipartRecord("rec1")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.ReadRecord(ctx, "rec1")
+		if err == nil && (record.IPCSCID != notePart.CID || record.ContentHash != notePart.ContentHash || len(record.Parts) != 2) {
+			t.Fatalf("unexpected record: %+v", record)
+		}
+		return err
+	})
+
+	invalid := map[string]string{
+		"duplicate cid":        multipartJSON("bad1", notePart, notePart),
+		"size must be":         multipartJSON("bad2", notePart, RecordPart{CID: "bafyx", ContentHash: strings.Repeat("c", 64), MediaType: "image/png"}),
+		"invalid mediaType":    multipartJSON("bad3", RecordPart{CID: "bafyx", ContentHash: strings.Repeat("c", 64), MediaType: "png", Size: 1}),
+		"must match the first": strings.Replace(multipartJSON("bad4", notePart), `"ipfsCid":""`, `"ipfsCid":"bafyother"`, 1),
+	}
+	for contains, recordJSON := range invalid {
+		env.mustFail(doctor, contains, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, recordJSON)
+			return err
+		})
+	}
+}
+
+func TestVerifyRecordPart(t *testing.T) {
+	env := newTestEnv(t)
+	env.createMultipartRecord("rec1")
+	env.createRecord(doctor, "rec2", patient.id)
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		cases := []struct {
+			recordID string
+			index    int
+			hash     string
+			want     bool
+		}{
+			{"rec1", 1, imagePart.ContentHash, true},
+			{"rec1", 1, notePart.ContentHash, false},
+			{"rec2", 0, strings.Repeat("a", 64), true},
+		}
+		for _, c := range cases {
+			if ok, err := env.cc.VerifyRecordPart(ctx, c.recordID, c.index, c.hash); err != nil || ok != c.want {
+				t.Fatalf("VerifyRecordPart(%s, %d) = %v, %v", c.recordID, c.index, ok, err)
+			}
+		}
+		_, err := env.cc.VerifyRecordPart(ctx, "rec1", 2, imagePart.ContentHash)
+		if err == nil || !strings.Contains(err.Error(), "has no part 2") {
+			t.Fatalf("out-of-range part must fail: %v", err)
+		}
+		return nil
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyRecordPart(ctx, "rec1", 0, notePart.ContentHash)
+		return err
+	})
+
+	// 更新主内容只替换第一部分
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynote2", strings.Repeat("d", 64))
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.ReadRecord(ctx, "rec1")
+		if err == nil && (record.Parts[0].CID != "bafynote2" || record.Parts[1] != imagePart) {
+			t.Fatalf("unexpected parts: %+v", record.Parts)
+		}
+		return err
+	})
+}
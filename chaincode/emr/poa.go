@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	POARegistered = "registered"
+	POAActive     = "active"
+	POARevoked    = "revoked"
+
+	poaIndex = "poa"
+
+	maxPOAAttestations = 5
+)
+
+var poaScopes = []string{"grant", "revoke", "consent"}
+
+// POAConditions 生效条件：RequiredAttestations 名不同医生提交失能证明后生效，0 表示登记即生效
+type POAConditions struct {
+	RequiredAttestations int `json:"requiredAttestations"`
+}
+
+type POAAttestation struct {
+	PhysicianID     string `json:"physicianId"`
+	AttestationHash string `json:"attestationHash"`
+	AttestedAt      string `json:"attestedAt"`
+}
+
+// HealthcarePOA 医疗委托代理；每个患者对同一代理人只有一份委托
+type HealthcarePOA struct {
+	PatientID    string           `json:"patientId"`
+	AgentID      string           `json:"agentId"`
+	Scope        []string         `json:"scope"`
+	Conditions   POAConditions    `json:"conditions"`
+	Attestations []POAAttestation `json:"attestations"`
+	Status       string           `json:"status"`
+	RegisteredAt string           `json:"registeredAt"`
+	UpdatedAt    string           `json:"updatedAt"`
+}
+
+type POAEvent struct {
+	PatientID string `json:"patientId"`
+	AgentID   string `json:"agentId"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func poaKey(ctx contractapi.TransactionContextInterface, patientID, agentID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(poaIndex, []string{patientID, agentID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create poa key: %w", err)
+	}
+	return key, nil
+}
+
+func getPOA(ctx contractapi.TransactionContextInterface, patientID, agentID string) (*HealthcarePOA, error) {
+	key, err := poaKey(ctx, patientID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	var poa HealthcarePOA
+	found, err := getJSON(ctx, key, &poa)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &poa, nil
+}
+
+// actsForPatient 调用者是否为患者的 active 代理且委托范围覆盖 scope，或为持 consents:manage 的应用
+func actsForPatient(ctx contractapi.TransactionContextInterface, patientID, callerID, scope string) (bool, error) {
+	poa, err := getPOA(ctx, patientID, callerID)
+	if err != nil {
+		return false, err
+	}
+	if poa != nil && poa.Status == POAActive && containsString(poa.Scope, scope) {
+		return true, nil
+	}
+	// 持 consents:manage 的应用在全部委托范围内代患者操作
+	return appHasScope(ctx, patientID, callerID, AppScopeConsentsManage)
+}
+
+// ownerOrAgent 所有者校验：患者本人返回空串，代理人返回其代理的患者 ID
+func ownerOrAgent(ctx contractapi.TransactionContextInterface, patientID, callerID, scope string) (string, error) {
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil || isPatient {
+		return "", err
+	}
+	isAgent, err := actsForPatient(ctx, patientID, callerID, scope)
+	if err != nil {
+		return "", err
+	}
+	if !isAgent {
+		return "", fmt.Errorf("access denied: only the patient can %s access", scope)
+	}
+	return patientID, nil
+}
+
+// requirePatientOrAgent 患者本人，或委托范围覆盖 scope 的 active 代理人
+func requirePatientOrAgent(ctx contractapi.TransactionContextInterface, patientID, scope, what string) (string, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return "", err
+	}
+	if isPatient {
+		return callerID, nil
+	}
+	isAgent, err := actsForPatient(ctx, patientID, callerID, scope)
+	if err != nil {
+		return "", err
+	}
+	if !isAgent {
+		return "", fmt.Errorf("access denied: only the patient can %s", what)
+	}
+	return callerID, nil
+}
+
+func savePOA(ctx contractapi.TransactionContextInterface, poa *HealthcarePOA, eventName, callerID string) error {
+	key, err := poaKey(ctx, poa.PatientID, poa.AgentID)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, key, poa); err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, POAEvent{
+		PatientID: poa.PatientID,
+		AgentID:   poa.AgentID,
+		Status:    poa.Status,
+		Timestamp: poa.UpdatedAt,
+		CallerID:  callerID,
+		EventType: eventName,
+	})
+}
+
+// RegisterHealthcarePOA 患者指定代理人；scopeJson 为 grant/revoke/consent 数组，
+// effectiveConditionsJson 形如 {"requiredAttestations":2}
+func (s *SmartContract) RegisterHealthcarePOA(ctx contractapi.TransactionContextInterface, patientID, agentID, scopeJson, effectiveConditionsJson string) error {
+	if err := validateAddress(agentID); err != nil {
+		return fmt.Errorf("invalid agentID: %w", err)
+	}
+	var scope []string
+	if err := unmarshalArg(scopeJson, &scope); err != nil {
+		return fmt.Errorf("invalid scope json: %w", err)
+	}
+	if len(scope) == 0 {
+		return fmt.Errorf("scope must not be empty")
+	}
+	for _, sc := range scope {
+		if !containsString(poaScopes, sc) {
+			return fmt.Errorf("invalid scope: %s", sc)
+		}
+	}
+	var conditions POAConditions
+	if err := unmarshalArg(effectiveConditionsJson, &conditions); err != nil {
+		return fmt.Errorf("invalid effectiveConditions json: %w", err)
+	}
+	if conditions.RequiredAttestations < 0 || conditions.RequiredAttestations > maxPOAAttestations {
+		return fmt.Errorf("requiredAttestations must be between 0 and %d", maxPOAAttestations)
+	}
+
+	callerID, err := requirePatient(ctx, patientID, "register a healthcare POA")
+	if err != nil {
+		return err
+	}
+	if agentID == patientID {
+		return fmt.Errorf("patient cannot be their own agent")
+	}
+	existing, err := getPOA(ctx, patientID, agentID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status != POARevoked {
+		return fmt.Errorf("healthcare POA already registered for agent %s", agentID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	poa := &HealthcarePOA{
+		PatientID:    patientID,
+		AgentID:      agentID,
+		Scope:        scope,
+		Conditions:   conditions,
+		Attestations: []POAAttestation{},
+		Status:       POARegistered,
+		RegisteredAt: now,
+		UpdatedAt:    now,
+	}
+	eventName := "POARegistered"
+	if conditions.RequiredAttestations == 0 {
+		poa.Status = POAActive
+		eventName = "POAActivated"
+	}
+	return savePOA(ctx, poa, eventName, callerID)
+}
+
+// AttestIncapacity 医生提交失能证明；达到所需人数后委托转为 active
+func (s *SmartContract) AttestIncapacity(ctx contractapi.TransactionContextInterface, patientID, agentID, attestationHash string) error {
+	if !sha256HexPattern.MatchString(attestationHash) {
+		return fmt.Errorf("invalid attestationHash: expected hex-encoded SHA-256")
+	}
+	isDoctor, err := hasRole(ctx, "doctor")
+	if err != nil {
+		return err
+	}
+	if !isDoctor {
+		return fmt.Errorf("access denied: doctor role required to attest incapacity")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID == agentID || callerID == patientID {
+		return fmt.Errorf("agent and patient cannot attest incapacity")
+	}
+	poa, err := getPOA(ctx, patientID, agentID)
+	if err != nil {
+		return err
+	}
+	if poa == nil {
+		return fmt.Errorf("healthcare POA not found for agent %s", agentID)
+	}
+	if poa.Status != POARegistered {
+		return fmt.Errorf("healthcare POA is not awaiting attestation: %s", poa.Status)
+	}
+	for _, a := range poa.Attestations {
+		if a.PhysicianID == callerID {
+			return fmt.Errorf("physician %s has already attested", callerID)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	poa.Attestations = append(poa.Attestations, POAAttestation{
+		PhysicianID:     callerID,
+		AttestationHash: attestationHash,
+		AttestedAt:      now,
+	})
+	poa.UpdatedAt = now
+	eventName := "IncapacityAttested"
+	if len(poa.Attestations) >= poa.Conditions.RequiredAttestations {
+		poa.Status = POAActive
+		eventName = "POAActivated"
+	}
+	return savePOA(ctx, poa, eventName, callerID)
+}
+
+// RevokeHealthcarePOA 患者撤销委托
+func (s *SmartContract) RevokeHealthcarePOA(ctx contractapi.TransactionContextInterface, patientID, agentID string) error {
+	callerID, err := requirePatient(ctx, patientID, "revoke a healthcare POA")
+	if err != nil {
+		return err
+	}
+	poa, err := getPOA(ctx, patientID, agentID)
+	if err != nil {
+		return err
+	}
+	if poa == nil || poa.Status == POARevoked {
+		return fmt.Errorf("healthcare POA not found for agent %s", agentID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	poa.Status = POARevoked
+	poa.UpdatedAt = now
+	return savePOA(ctx, poa, "POARevoked", callerID)
+}
+
+// GetHealthcarePOA 患者与代理人可查看委托
+func (s *SmartContract) GetHealthcarePOA(ctx contractapi.TransactionContextInterface, patientID, agentID string) (*HealthcarePOA, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID && callerID != agentID {
+		return nil, fmt.Errorf("access denied: %s cannot view this healthcare POA", callerID)
+	}
+	poa, err := getPOA(ctx, patientID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if poa == nil {
+		return nil, fmt.Errorf("healthcare POA not found for agent %s", agentID)
+	}
+	return poa, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	agent      = newIdentity("agent1", "Org1MSP")
+	physician2 = newIdentity("doctor2", "Org2MSP", "role", "doctor")
+)
+
+func TestHealthcarePOAActivation(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	attest := func(id *testIdentity) error {
+		return env.invoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AttestIncapacity(ctx, patient.id, agent.id, strings.Repeat("9", 64))
+		})
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterHealthcarePOA(ctx, patient.id, agent.id, `["grant","revoke"]`, `{"requiredAttestations":2}`)
+	})
+	env.expectEvent("POARegistered", nil)
+
+	grantAsAgent := func() error {
+		return env.invoke(agent, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+		})
+	}
+	if err := grantAsAgent(); err == nil {
+		t.Fatal("agent must not act before activation")
+	}
+
+	if err := attest(doctor); err != nil {
+		t.Fatal(err)
+	}
+	if err := attest(doctor); err == nil || !strings.Contains(err.Error(), "already attested") {
+		t.Fatalf("same physician must not attest twice, got %v", err)
+	}
+	if err := attest(nurse); err == nil {
+		t.Fatal("attestation requires doctor role")
+	}
+	if err := attest(physician2); err != nil {
+		t.Fatal(err)
+	}
+	env.expectEvent("POAActivated", nil)
+
+	if err := grantAsAgent(); err != nil {
+		t.Fatalf("active agent must grant: %v", err)
+	}
+	var event AccessGrantedEvent
+	env.expectEvent("AccessGranted", &event)
+	if event.ActingFor != patient.id || event.CallerID != agent.id {
+		t.Fatalf("grant event must record acting-for: %+v", event)
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("grant by agent must take effect")
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeHealthcarePOA(ctx, patient.id, agent.id)
+	})
+	env.mustFail(agent, "only the patient can revoke", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+}
+
+func TestHealthcarePOAScope(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterHealthcarePOA(ctx, patient.id, agent.id, `["revoke"]`, `{"requiredAttestations":0}`)
+	})
+	env.mustFail(agent, "only the patient can grant", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", other.id, "read")
+	})
+	env.mustInvoke(agent, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("revoke by agent must take effect")
+	}
+	env.mustFail(agent, "only the patient can manage the care team", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddCareTeamMember(ctx, patient.id, nurse.id, "home-care")
+	})
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// PrescriptionContract 电子处方合约，与 EMR 锚点部署在同一链码
+type PrescriptionContract struct {
+	contractapi.Contract
+}
+
+const (
+	RxActive    = "active"
+	RxCompleted = "completed"
+	RxCancelled = "cancelled"
+
+	rxPatientIndex = "rx~patient"
+	dispenseIndex  = "dispense"
+)
+
+// Prescription 处方；RefillsAllowed 为首次配药之外的续配次数
+type Prescription struct {
+	RxID           string `json:"rxId"`
+	PatientID      string `json:"patientId"`
+	PrescriberID   string `json:"prescriberId"`
+	DrugCode       string `json:"drugCode"`
+	Dosage         string `json:"dosage"`
+	RefillsAllowed int    `json:"refillsAllowed"`
+	FillsUsed      int    `json:"fillsUsed"`
+	ExpiresAt      string `json:"expiresAt"`
+	Status         string `json:"status"`
+	CancelReason   string `json:"cancelReason,omitempty"`
+	CreatedAt      string `json:"createdAt"`
+	UpdatedAt      string `json:"updatedAt"`
+}
+
+// DispenseRecord 单次配药记录；Seq 与处方的 FillsUsed 对应
+type DispenseRecord struct {
+	RxID        string `json:"rxId"`
+	Seq         int    `json:"seq"`
+	PharmacyID  string `json:"pharmacyId"`
+	Quantity    int    `json:"quantity"`
+	DispensedAt string `json:"dispensedAt"`
+	TxID        string `json:"txId"`
+}
+
+type PrescriptionEvent struct {
+	RxID      string `json:"rxId"`
+	PatientID string `json:"patientId"`
+	Status    string `json:"status"`
+	FillsUsed int    `json:"fillsUsed"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func rxKey(rxID string) string {
+	return "rx:" + rxID
+}
+
+func getPrescription(ctx contractapi.TransactionContextInterface, rxID string) (*Prescription, error) {
+	var rx Prescription
+	found, err := getJSON(ctx, rxKey(rxID), &rx)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("prescription not found: %s", rxID)
+	}
+	return &rx, nil
+}
+
+// CreatePrescription 开具处方；调用者须为 doctor 角色，处方以其交易签名作为开方人签名
+func (p *PrescriptionContract) CreatePrescription(ctx contractapi.TransactionContextInterface, rxJson string) error {
+	var rx Prescription
+	if err := unmarshalArg(rxJson, &rx); err != nil {
+		return fmt.Errorf("invalid prescription json: %w", err)
+	}
+	if rx.RxID == "" || rx.PatientID == "" || rx.DrugCode == "" || rx.Dosage == "" || rx.ExpiresAt == "" {
+		return fmt.Errorf("missing required fields: rxId, patientId, drugCode, dosage and expiresAt are required")
+	}
+	for _, id := range []string{rx.RxID, rx.PatientID} {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	if rx.RefillsAllowed < 0 {
+		return fmt.Errorf("refillsAllowed must not be negative")
+	}
+
+	isDoctor, err := hasRole(ctx, "doctor")
+	if err != nil {
+		return err
+	}
+	if !isDoctor {
+		return fmt.Errorf("access denied: doctor role required to prescribe")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if rx.PrescriberID != "" {
+		isPrescriber, err := callerIs(ctx, rx.PrescriberID)
+		if err != nil {
+			return err
+		}
+		if !isPrescriber {
+			return fmt.Errorf("prescriberId must match the caller")
+		}
+	} else {
+		rx.PrescriberID = callerID
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, rx.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expiresAt: %w", err)
+	}
+	if !expiresAt.After(now) {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+
+	exists, err := assetExists(ctx, rxKey(rx.RxID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("prescription already exists: %s", rx.RxID)
+	}
+
+	rx.FillsUsed = 0
+	rx.Status = RxActive
+	rx.CancelReason = ""
+	rx.CreatedAt = now.Format(time.RFC3339)
+	rx.UpdatedAt = rx.CreatedAt
+	if err := putJSON(ctx, rxKey(rx.RxID), rx); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, rxPatientIndex, rx.PatientID, rx.RxID); err != nil {
+		return err
+	}
+	return emitPrescriptionEvent(ctx, "PrescriptionCreated", &rx, callerID)
+}
+
+// canDispense 要求 pharmacist 角色且证书属性 dispensing=true
+func canDispense(ctx contractapi.TransactionContextInterface) (bool, error) {
+	isPharmacist, err := hasRole(ctx, "pharmacist")
+	if err != nil || !isPharmacist {
+		return false, err
+	}
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue("dispensing")
+	if err != nil {
+		return false, fmt.Errorf("failed to read dispensing attribute: %w", err)
+	}
+	return found && value == "true", nil
+}
+
+// DispensePrescription 药房配药并写入配药记录；超出续配次数或已过期时拒绝
+func (p *PrescriptionContract) DispensePrescription(ctx contractapi.TransactionContextInterface, rxID string, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be positive")
+	}
+	allowed, err := canDispense(ctx)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: dispensing pharmacist required")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	rx, err := getPrescription(ctx, rxID)
+	if err != nil {
+		return err
+	}
+	if rx.Status != RxActive {
+		return fmt.Errorf("prescription is not active: %s", rx.Status)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, rx.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expiresAt on prescription: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("prescription expired at %s", rx.ExpiresAt)
+	}
+	if rx.FillsUsed > rx.RefillsAllowed {
+		return fmt.Errorf("no refills remaining")
+	}
+
+	rx.FillsUsed++
+	if rx.FillsUsed > rx.RefillsAllowed {
+		rx.Status = RxCompleted
+	}
+	rx.UpdatedAt = now.Format(time.RFC3339)
+	if err := putJSON(ctx, rxKey(rxID), rx); err != nil {
+		return err
+	}
+
+	dispense := DispenseRecord{
+		RxID:        rxID,
+		Seq:         rx.FillsUsed,
+		PharmacyID:  callerID,
+		Quantity:    quantity,
+		DispensedAt: rx.UpdatedAt,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	dispenseKey, err := ctx.GetStub().CreateCompositeKey(dispenseIndex, []string{rxID, fmt.Sprintf("%04d", dispense.Seq)})
+	if err != nil {
+		return fmt.Errorf("failed to create dispense key: %w", err)
+	}
+	if err := putJSON(ctx, dispenseKey, dispense); err != nil {
+		return err
+	}
+	return emitPrescriptionEvent(ctx, "PrescriptionDispensed", rx, callerID)
+}
+
+// CancelPrescription 开方人或患者取消处方，须给出原因
+func (p *PrescriptionContract) CancelPrescription(ctx contractapi.TransactionContextInterface, rxID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	rx, err := getPrescription(ctx, rxID)
+	if err != nil {
+		return err
+	}
+	allowed, err := callerIsAny(ctx, rx.PrescriberID, rx.PatientID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: only the prescriber or patient can cancel")
+	}
+	if rx.Status != RxActive {
+		return fmt.Errorf("prescription is not active: %s", rx.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	rx.Status = RxCancelled
+	rx.CancelReason = reason
+	rx.UpdatedAt = now
+	if err := putJSON(ctx, rxKey(rxID), rx); err != nil {
+		return err
+	}
+	return emitPrescriptionEvent(ctx, "PrescriptionCancelled", rx, callerID)
+}
+
+// readPrescription 读取处方并校验读取权限：患者、开方人与 pharmacist 角色可读
+func readPrescription(ctx contractapi.TransactionContextInterface, rxID string) (*Prescription, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rx, err := getPrescription(ctx, rxID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := callerIsAny(ctx, rx.PatientID, rx.PrescriberID)
+	if err != nil || allowed {
+		return rx, err
+	}
+	isPharmacist, err := hasRole(ctx, "pharmacist")
+	if err != nil {
+		return nil, err
+	}
+	if !isPharmacist {
+		return nil, fmt.Errorf("access denied: %s cannot view prescription %s", callerID, rxID)
+	}
+	return rx, nil
+}
+
+// GetPrescription 查询处方
+func (p *PrescriptionContract) GetPrescription(ctx contractapi.TransactionContextInterface, rxID string) (*Prescription, error) {
+	return readPrescription(ctx, rxID)
+}
+
+// GetDispenseHistory 按配药顺序返回处方的配药记录，读取规则同 GetPrescription
+func (p *PrescriptionContract) GetDispenseHistory(ctx contractapi.TransactionContextInterface, rxID string) ([]*DispenseRecord, error) {
+	if _, err := readPrescription(ctx, rxID); err != nil {
+		return nil, err
+	}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(dispenseIndex, []string{rxID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dispense records: %w", err)
+	}
+	defer iterator.Close()
+
+	records := []*DispenseRecord{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate dispense records: %w", err)
+		}
+		var record DispenseRecord
+		if err := unmarshalArg(string(kv.Value), &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dispense record: %w", err)
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// dispensedBy 调用者是否为该处方配过药
+func dispensedBy(ctx contractapi.TransactionContextInterface, rxID string) (bool, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(dispenseIndex, []string{rxID})
+	if err != nil {
+		return false, fmt.Errorf("failed to query dispense records: %w", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return false, fmt.Errorf("failed to iterate dispense records: %w", err)
+		}
+		var record DispenseRecord
+		if err := unmarshalArg(string(kv.Value), &record); err != nil {
+			return false, fmt.Errorf("failed to unmarshal dispense record: %w", err)
+		}
+		isPharmacy, err := callerIs(ctx, record.PharmacyID)
+		if err != nil || isPharmacy {
+			return isPharmacy, err
+		}
+	}
+	return false, nil
+}
+
+// medicationVisible 非患者、非护理团队的 doctor/pharmacist 只能看到自己开具或配过药的处方
+func medicationVisible(ctx contractapi.TransactionContextInterface, rx *Prescription) (bool, error) {
+	isPrescriber, err := callerIs(ctx, rx.PrescriberID)
+	if err != nil || isPrescriber {
+		return isPrescriber, err
+	}
+	return dispensedBy(ctx, rx.RxID)
+}
+
+// GetActiveMedications 经 rx~patient 索引返回患者未过期、未取消且仍可配药的处方；
+// 患者本人与护理团队中的 doctor/pharmacist 可查询全部，其他 doctor/pharmacist 只返回其开具或配过药的处方
+func (p *PrescriptionContract) GetActiveMedications(ctx contractapi.TransactionContextInterface, patientID string) ([]*Prescription, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seeAll, err := callerIs(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if !seeAll {
+		allowed, err := hasAnyRole(ctx, "doctor", "pharmacist")
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("access denied: %s cannot view medications of %s", callerID, patientID)
+		}
+		teamAction, err := careTeamAction(ctx, patientID, callerID)
+		if err != nil {
+			return nil, err
+		}
+		seeAll = teamAction != ""
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := []*Prescription{}
+	err = scanIndex(ctx, rxPatientIndex, []string{patientID}, func(attrs []string) error {
+		rx, err := getPrescription(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		if rx.Status != RxActive {
+			return nil
+		}
+		expiresAt, err := time.Parse(time.RFC3339, rx.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid expiresAt on prescription %s: %w", rx.RxID, err)
+		}
+		if !now.Before(expiresAt) {
+			return nil
+		}
+		if !seeAll {
+			visible, err := medicationVisible(ctx, rx)
+			if err != nil || !visible {
+				return err
+			}
+		}
+		active = append(active, rx)
+		return nil
+	})
+	return active, err
+}
+
+func emitPrescriptionEvent(ctx contractapi.TransactionContextInterface, name string, rx *Prescription, callerID string) error {
+	return emitEvent(ctx, name, PrescriptionEvent{
+		RxID:      rx.RxID,
+		PatientID: rx.PatientID,
+		Status:    rx.Status,
+		FillsUsed: rx.FillsUsed,
+		Timestamp: rx.UpdatedAt,
+		CallerID:  callerID,
+		EventType: name,
+	})
+}
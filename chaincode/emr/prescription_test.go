@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var pharmacist = newIdentity("pharmacy1", "PharmacyMSP", "role", "pharmacist", "dispensing", "true")
+
+func createTestPrescription(env *testEnv, rxs *PrescriptionContract, rxID string, refills int, validFor time.Duration) {
+	env.t.Helper()
+	body := fmt.Sprintf(`{"rxId":%q,"patientId":"patient1","drugCode":"RX-123","dosage":"10mg daily","refillsAllowed":%d,"expiresAt":%q}`,
+		rxID, refills, env.stub.now.Add(validFor).Format(time.RFC3339))
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CreatePrescription(ctx, body)
+	})
+}
+
+func TestPrescriptionRefillLimit(t *testing.T) {
+	env := newTestEnv(t)
+	rxs := new(PrescriptionContract)
+
+	env.mustFail(nurse, "doctor role required", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CreatePrescription(ctx, `{"rxId":"rx1","patientId":"patient1","drugCode":"RX-123","dosage":"10mg","expiresAt":"2027-01-01T00:00:00Z"}`)
+	})
+	createTestPrescription(env, rxs, "rx1", 1, 30*24*time.Hour)
+	env.expectEvent("PrescriptionCreated", nil)
+
+	env.mustFail(doctor, "dispensing pharmacist required", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+	for i := 0; i < 2; i++ {
+		env.mustInvoke(pharmacist, func(ctx contractapi.TransactionContextInterface) error {
+			return rxs.DispensePrescription(ctx, "rx1", 30)
+		})
+		env.expectEvent("PrescriptionDispensed", nil)
+	}
+	env.mustFail(pharmacist, "not active", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		rx, err := rxs.GetPrescription(ctx, "rx1")
+		if err == nil && (rx.Status != RxCompleted || rx.FillsUsed != 2) {
+			t.Fatalf("unexpected prescription state: %+v", rx)
+		}
+		return err
+	})
+}
+
+func TestPrescriptionExpiryAndCancel(t *testing.T) {
+	env := newTestEnv(t)
+	rxs := new(PrescriptionContract)
+	createTestPrescription(env, rxs, "rx1", 3, time.Hour)
+	createTestPrescription(env, rxs, "rx2", 3, 24*time.Hour)
+
+	env.advance(2 * time.Hour)
+	env.mustFail(pharmacist, "expired", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+
+	env.mustFail(other, "only the prescriber or patient", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CancelPrescription(ctx, "rx2", "duplicate")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CancelPrescription(ctx, "rx2", "duplicate")
+	})
+	env.expectEvent("PrescriptionCancelled", nil)
+	env.mustFail(pharmacist, "not active", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx2", 30)
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := rxs.GetPrescription(ctx, "rx2")
+		return err
+	})
+}
+
+func TestDispenseRecordsAndActiveMedications(t *testing.T) {
+	env := newTestEnv(t)
+	rxs := new(PrescriptionContract)
+	createTestPrescription(env, rxs, "rx1", 2, 30*24*time.Hour)
+	createTestPrescription(env, rxs, "rx2", 0, 24*time.Hour)
+	createTestPrescription(env, rxs, "rx3", 0, time.Hour)
+
+	readOnly := newIdentity("pharmacy2", "PharmacyMSP", "role", "pharmacist")
+	env.mustFail(readOnly, "dispensing pharmacist required", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+	env.mustFail(pharmacist, "quantity must be positive", func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 0)
+	})
+	env.mustInvoke(pharmacist, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+	env.advance(time.Hour)
+	env.mustInvoke(pharmacist, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 15)
+	})
+	env.mustInvoke(pharmacist, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx2", 10)
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		history, err := rxs.GetDispenseHistory(ctx, "rx1")
+		if err == nil && (len(history) != 2 || history[0].Quantity != 30 || history[1].Seq != 2 || history[1].PharmacyID != pharmacist.id) {
+			t.Fatalf("unexpected dispense history: %+v", history)
+		}
+		return err
+	})
+
+	// rx2 已用尽，rx3 已过期
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		active, err := rxs.GetActiveMedications(ctx, patient.id)
+		if err == nil && (len(active) != 1 || active[0].RxID != "rx1") {
+			t.Fatalf("expected only rx1 active, got %+v", active)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := rxs.GetActiveMedications(ctx, patient.id)
+		return err
+	})
+}
+
+func TestActiveMedicationsLimitedToTreatingProviders(t *testing.T) {
+	env := newTestEnv(t)
+	rxs := new(PrescriptionContract)
+	createTestPrescription(env, rxs, "rx1", 2, 30*24*time.Hour)
+	createTestPrescription(env, rxs, "rx2", 2, 30*24*time.Hour)
+	env.mustInvoke(pharmacist, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.DispensePrescription(ctx, "rx1", 30)
+	})
+
+	activeFor := func(identity *testIdentity) []string {
+		ids := []string{}
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			active, err := rxs.GetActiveMedications(ctx, patient.id)
+			for _, rx := range active {
+				ids = append(ids, rx.RxID)
+			}
+			return err
+		})
+		return ids
+	}
+
+	// 与患者无关的医生与药师看不到任何处方
+	unrelatedPharmacy := newIdentity("pharmacy2", "PharmacyMSP", "role", "pharmacist", "dispensing", "true")
+	for _, identity := range []*testIdentity{specialist, unrelatedPharmacy} {
+		if ids := activeFor(identity); len(ids) != 0 {
+			t.Fatalf("%s must not see unrelated prescriptions: %v", identity.id, ids)
+		}
+	}
+	if ids := activeFor(pharmacist); len(ids) != 1 || ids[0] != "rx1" {
+		t.Fatalf("the dispensing pharmacy sees only what it filled: %v", ids)
+	}
+	if ids := activeFor(doctor); len(ids) != 2 {
+		t.Fatalf("the prescriber sees both prescriptions: %v", ids)
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AddCareTeamMember(ctx, patient.id, specialist.id, "consultant")
+	})
+	if ids := activeFor(specialist); len(ids) != 2 {
+		t.Fatalf("care team members see the full medication list: %v", ids)
+	}
+}
+
+func TestBoundPatientManagesOwnPrescriptions(t *testing.T) {
+	env := newTestEnv(t)
+	rxs := new(PrescriptionContract)
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	body := fmt.Sprintf(`{"rxId":"rx1","patientId":"patient-alice","drugCode":"RX-123","dosage":"10mg daily","expiresAt":%q}`,
+		env.stub.now.Add(24*time.Hour).Format(time.RFC3339))
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CreatePrescription(ctx, body)
+	})
+
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		active, err := rxs.GetActiveMedications(ctx, "patient-alice")
+		if err == nil && len(active) != 1 {
+			t.Fatalf("expected the bound patient to see rx1, got %+v", active)
+		}
+		return err
+	})
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		return rxs.CancelPrescription(ctx, "rx1", "switched medication")
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	PriorAuthPending  = "pending"
+	PriorAuthApproved = "approved"
+	PriorAuthDenied   = "denied"
+
+	priorAuthRecordIndex = "priorauth~record"
+)
+
+// PriorAuth 事前授权请求；待决期间审核人可读取所引用的记录
+type PriorAuth struct {
+	RequestID      string   `json:"requestId"`
+	PatientID      string   `json:"patientId"`
+	PayerID        string   `json:"payerId"`
+	ReviewerID     string   `json:"reviewerId"`
+	RecordIDs      []string `json:"recordIds"`
+	ProcedureCodes []string `json:"procedureCodes"`
+	Status         string   `json:"status"`
+	DecisionHash   string   `json:"decisionHash,omitempty"`
+	RequestedBy    string   `json:"requestedBy"`
+	RequestedAt    string   `json:"requestedAt"`
+	DecidedAt      string   `json:"decidedAt,omitempty"`
+}
+
+type PriorAuthEvent struct {
+	RequestID  string `json:"requestId"`
+	PatientID  string `json:"patientId"`
+	ReviewerID string `json:"reviewerId"`
+	Status     string `json:"status"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func priorAuthKey(requestID string) string {
+	return "priorauth:" + requestID
+}
+
+func getPriorAuth(ctx contractapi.TransactionContextInterface, requestID string) (*PriorAuth, error) {
+	var request PriorAuth
+	found, err := getJSON(ctx, priorAuthKey(requestID), &request)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("prior authorization request not found: %s", requestID)
+	}
+	return &request, nil
+}
+
+// priorAuthGrantsAccess 经 priorauth~record 索引判断审核人是否有待决请求引用该记录
+func priorAuthGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	allowed := false
+	err := scanIndex(ctx, priorAuthRecordIndex, []string{recordID, userID}, func(attrs []string) error {
+		if allowed {
+			return nil
+		}
+		request, err := getPriorAuth(ctx, attrs[2])
+		if err != nil {
+			return err
+		}
+		allowed = request.Status == PriorAuthPending
+		return nil
+	})
+	return allowed, err
+}
+
+// RequestPriorAuth 发起事前授权；发起方须对每条引用记录持有 share 及以上权限
+func (c *ClaimsContract) RequestPriorAuth(ctx contractapi.TransactionContextInterface, requestJson string) error {
+	var request PriorAuth
+	if err := unmarshalArg(requestJson, &request); err != nil {
+		return fmt.Errorf("invalid prior authorization json: %w", err)
+	}
+	if request.RequestID == "" || request.PatientID == "" || request.PayerID == "" || request.ReviewerID == "" {
+		return fmt.Errorf("missing required fields: requestId, patientId, payerId and reviewerId are required")
+	}
+	if len(request.RecordIDs) == 0 || len(request.ProcedureCodes) == 0 {
+		return fmt.Errorf("recordIds and procedureCodes must not be empty")
+	}
+	for _, id := range []string{request.RequestID, request.PatientID, request.PayerID, request.ReviewerID} {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	exists, err := assetExists(ctx, priorAuthKey(request.RequestID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("prior authorization request already exists: %s", request.RequestID)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	records := new(SmartContract)
+	for _, recordID := range request.RecordIDs {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if record.PatientID != request.PatientID {
+			return fmt.Errorf("record %s does not belong to patient %s", recordID, request.PatientID)
+		}
+		allowed, err := records.ValidatePermissionLevel(ctx, recordID, callerID, "share")
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("access denied: %s cannot share record %s", callerID, recordID)
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	request.Status = PriorAuthPending
+	request.DecisionHash = ""
+	request.DecidedAt = ""
+	request.RequestedBy = callerID
+	request.RequestedAt = now
+	if err := putJSON(ctx, priorAuthKey(request.RequestID), request); err != nil {
+		return err
+	}
+	for _, recordID := range request.RecordIDs {
+		if err := putIndex(ctx, priorAuthRecordIndex, recordID, request.ReviewerID, request.RequestID); err != nil {
+			return err
+		}
+	}
+
+	return emitPriorAuthEvent(ctx, "PriorAuthRequested", &request, callerID, now)
+}
+
+// DecidePriorAuth 审核人给出 approved/denied 结论；决定后审核人的读取权随即失效
+func (c *ClaimsContract) DecidePriorAuth(ctx contractapi.TransactionContextInterface, requestID, decision, decisionHash string) error {
+	if decision != PriorAuthApproved && decision != PriorAuthDenied {
+		return fmt.Errorf("invalid decision: %s", decision)
+	}
+	if !sha256HexPattern.MatchString(decisionHash) {
+		return fmt.Errorf("invalid decisionHash: expected hex-encoded SHA-256")
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	request, err := getPriorAuth(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if callerID != request.ReviewerID {
+		return fmt.Errorf("access denied: only the assigned reviewer can decide")
+	}
+	if request.Status != PriorAuthPending {
+		return fmt.Errorf("prior authorization already decided: %s", request.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	request.Status = decision
+	request.DecisionHash = decisionHash
+	request.DecidedAt = now
+	if err := putJSON(ctx, priorAuthKey(requestID), request); err != nil {
+		return err
+	}
+	return emitPriorAuthEvent(ctx, "PriorAuthDecided", request, callerID, now)
+}
+
+// GetPriorAuthStatus 查询事前授权；患者、发起方、审核人与 payer 可读
+func (c *ClaimsContract) GetPriorAuthStatus(ctx contractapi.TransactionContextInterface, requestID string) (*PriorAuth, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request, err := getPriorAuth(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString([]string{request.PatientID, request.RequestedBy, request.ReviewerID, request.PayerID}, callerID) {
+		return nil, fmt.Errorf("access denied: %s cannot view prior authorization %s", callerID, requestID)
+	}
+	return request, nil
+}
+
+func emitPriorAuthEvent(ctx contractapi.TransactionContextInterface, name string, request *PriorAuth, callerID, now string) error {
+	return emitEvent(ctx, name, PriorAuthEvent{
+		RequestID:  request.RequestID,
+		PatientID:  request.PatientID,
+		ReviewerID: request.ReviewerID,
+		Status:     request.Status,
+		Timestamp:  now,
+		CallerID:   callerID,
+		EventType:  name,
+	})
+}
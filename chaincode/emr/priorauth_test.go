@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var reviewer = newIdentity("reviewer1", "PayerMSP", "role", "payer-reviewer")
+
+func TestPriorAuthGrantsReviewerAccessWhilePending(t *testing.T) {
+	env := newTestEnv(t)
+	claims := new(ClaimsContract)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.RequestPriorAuth(ctx, `{"requestId":"pa1","patientId":"patient1","payerId":"payer1","reviewerId":"reviewer1","recordIds":["rec1"],"procedureCodes":["27447"]}`)
+	})
+	env.expectEvent("PriorAuthRequested", nil)
+
+	if !env.checkAccess("rec1", reviewer.id) {
+		t.Fatal("reviewer must read cited records while pending")
+	}
+	if env.checkAccess("rec2", reviewer.id) {
+		t.Fatal("reviewer must not read records that were not cited")
+	}
+
+	env.mustFail(payer, "only the assigned reviewer", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.DecidePriorAuth(ctx, "pa1", PriorAuthApproved, strings.Repeat("d", 64))
+	})
+	env.mustInvoke(reviewer, func(ctx contractapi.TransactionContextInterface) error {
+		return claims.DecidePriorAuth(ctx, "pa1", PriorAuthApproved, strings.Repeat("d", 64))
+	})
+	env.expectEvent("PriorAuthDecided", nil)
+	if env.checkAccess("rec1", reviewer.id) {
+		t.Fatal("reviewer access must end once decided")
+	}
+
+	env.mustFail(reviewer, "already decided", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.DecidePriorAuth(ctx, "pa1", PriorAuthDenied, strings.Repeat("d", 64))
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		request, err := claims.GetPriorAuthStatus(ctx, "pa1")
+		if err == nil && request.Status != PriorAuthApproved {
+			t.Fatalf("unexpected status: %s", request.Status)
+		}
+		return err
+	})
+	env.mustFail(other, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := claims.GetPriorAuthStatus(ctx, "pa1")
+		return err
+	})
+}
+
+func TestRequestPriorAuthRequiresShareOnCitedRecords(t *testing.T) {
+	env := newTestEnv(t)
+	claims := new(ClaimsContract)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(other, "cannot share record", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.RequestPriorAuth(ctx, `{"requestId":"pa1","patientId":"patient1","payerId":"payer1","reviewerId":"reviewer1","recordIds":["rec1"],"procedureCodes":["27447"]}`)
+	})
+	env.mustFail(doctor, "must not be empty", func(ctx contractapi.TransactionContextInterface) error {
+		return claims.RequestPriorAuth(ctx, `{"requestId":"pa1","patientId":"patient1","payerId":"payer1","reviewerId":"reviewer1","recordIds":["rec1"]}`)
+	})
+}
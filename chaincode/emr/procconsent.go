@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ProcedureConsentActive    = "active"
+	ProcedureConsentWithdrawn = "withdrawn"
+
+	// maxProcedureConsentDays 手术/操作同意的最长有效期
+	maxProcedureConsentDays = 180
+)
+
+// procedureCodePattern CPT、ICD-10-PCS、SNOMED CT 等操作编码，只校验字符集与长度
+var procedureCodePattern = regexp.MustCompile(`^[A-Za-z0-9.-]{1,20}$`)
+
+// ProcedureConsent 手术/操作知情同意锚点；同意书在链下，链上只存哈希。ConsentID 为登记交易
+type ProcedureConsent struct {
+	ConsentID           string `json:"consentId"`
+	PatientID           string `json:"patientId"`
+	ProcedureCode       string `json:"procedureCode"`
+	ConsentDocumentHash string `json:"consentDocumentHash"`
+	ValidFrom           string `json:"validFrom"`
+	ValidTo             string `json:"validTo"`
+	Status              string `json:"status"`
+	RecordedBy          string `json:"recordedBy"`
+	RecordedAt          string `json:"recordedAt"`
+	WithdrawnBy         string `json:"withdrawnBy,omitempty"`
+	WithdrawnAt         string `json:"withdrawnAt,omitempty"`
+}
+
+// ProcedureConsentVerification VerifyProcedureConsent 的结果；Valid 为 false 时不含同意详情
+type ProcedureConsentVerification struct {
+	PatientID           string `json:"patientId"`
+	ProcedureCode       string `json:"procedureCode"`
+	Valid               bool   `json:"valid"`
+	ConsentID           string `json:"consentId,omitempty"`
+	ConsentDocumentHash string `json:"consentDocumentHash,omitempty"`
+	ValidFrom           string `json:"validFrom,omitempty"`
+	ValidTo             string `json:"validTo,omitempty"`
+}
+
+type ProcedureConsentEvent struct {
+	ConsentID     string `json:"consentId"`
+	PatientID     string `json:"patientId"`
+	ProcedureCode string `json:"procedureCode"`
+	Status        string `json:"status"`
+	Timestamp     string `json:"timestamp"`
+	CallerID      string `json:"callerId"`
+	EventType     string `json:"eventType"`
+}
+
+func procedureConsentKey(consentID string) string {
+	return "proc-consent:" + consentID
+}
+
+// procedureConsentIndexKey proc-consent-index:{patientId}:{procedureCode}:{epoch(recordedAt)}:{consentId}，值为 consentId
+func procedureConsentIndexKey(patientID, procedureCode string, recordedAt time.Time, consentID string) string {
+	return procedureConsentIndexPrefix(patientID, procedureCode) + epochSegment(recordedAt) + ":" + consentID
+}
+
+func procedureConsentIndexPrefix(patientID, procedureCode string) string {
+	return "proc-consent-index:" + keySegment(patientID) + ":" + keySegment(procedureCode) + ":"
+}
+
+// requireConsentRecorder 患者本人、有 consent 范围的代理人，或取得同意的持证医护
+func requireConsentRecorder(ctx contractapi.TransactionContextInterface, patientID, what string) (string, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	licensed, err := credentialValid(ctx, callerID)
+	if err != nil {
+		return "", err
+	}
+	if licensed && callerID != patientID {
+		return callerID, nil
+	}
+	return requirePatientOrAgent(ctx, patientID, "consent", what)
+}
+
+// RecordProcedureConsent 锚定一份手术/操作同意书；validFrom 至 validTo 为同意的有效期，至多 180 天。
+// 同一操作可多次登记，核验取有效期内最新登记的一份
+func (s *SmartContract) RecordProcedureConsent(ctx contractapi.TransactionContextInterface, patientID, procedureCode, consentDocumentHash, validFrom, validTo string) (string, error) {
+	if err := validateAddress(patientID); err != nil {
+		return "", fmt.Errorf("invalid patientID: %w", err)
+	}
+	if !procedureCodePattern.MatchString(procedureCode) {
+		return "", fmt.Errorf("invalid procedureCode: %q", procedureCode)
+	}
+	if !sha256HexPattern.MatchString(consentDocumentHash) {
+		return "", fmt.Errorf("invalid consentDocumentHash: expected hex-encoded SHA-256")
+	}
+	from, err := time.Parse(time.RFC3339, validFrom)
+	if err != nil {
+		return "", fmt.Errorf("invalid validFrom: %w", err)
+	}
+	to, err := time.Parse(time.RFC3339, validTo)
+	if err != nil {
+		return "", fmt.Errorf("invalid validTo: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !to.After(from) || !to.After(now) {
+		return "", fmt.Errorf("validTo must be after validFrom and in the future")
+	}
+	if to.After(from.AddDate(0, 0, maxProcedureConsentDays)) {
+		return "", fmt.Errorf("a procedure consent is valid for at most %d days", maxProcedureConsentDays)
+	}
+	callerID, err := requireConsentRecorder(ctx, patientID, "record procedure consents")
+	if err != nil {
+		return "", err
+	}
+
+	consent := ProcedureConsent{
+		ConsentID:           ctx.GetStub().GetTxID(),
+		PatientID:           patientID,
+		ProcedureCode:       procedureCode,
+		ConsentDocumentHash: consentDocumentHash,
+		ValidFrom:           from.UTC().Format(time.RFC3339),
+		ValidTo:             to.UTC().Format(time.RFC3339),
+		Status:              ProcedureConsentActive,
+		RecordedBy:          callerID,
+		RecordedAt:          now.Format(time.RFC3339),
+	}
+	if err := putJSON(ctx, procedureConsentKey(consent.ConsentID), consent); err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(procedureConsentIndexKey(patientID, procedureCode, now, consent.ConsentID), []byte(consent.ConsentID)); err != nil {
+		return "", fmt.Errorf("failed to index procedure consent: %w", err)
+	}
+	return consent.ConsentID, emitProcedureConsentEvent(ctx, "ProcedureConsentRecorded", &consent, callerID, consent.RecordedAt)
+}
+
+// WithdrawProcedureConsent 患者或有 consent 范围的代理人撤回同意，撤回后核验不再返回该同意
+func (s *SmartContract) WithdrawProcedureConsent(ctx contractapi.TransactionContextInterface, consentID string) error {
+	var consent ProcedureConsent
+	found, err := getJSON(ctx, procedureConsentKey(consentID), &consent)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("procedure consent not found: %s", consentID)
+	}
+	callerID, err := requirePatientOrAgent(ctx, consent.PatientID, "consent", "withdraw procedure consents")
+	if err != nil {
+		return err
+	}
+	if consent.Status != ProcedureConsentActive {
+		return fmt.Errorf("procedure consent %s is already %s", consentID, consent.Status)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	consent.Status = ProcedureConsentWithdrawn
+	consent.WithdrawnBy = callerID
+	consent.WithdrawnAt = now
+	if err := putJSON(ctx, procedureConsentKey(consentID), consent); err != nil {
+		return err
+	}
+	return emitProcedureConsentEvent(ctx, "ProcedureConsentWithdrawn", &consent, callerID, now)
+}
+
+// VerifyProcedureConsent 返回交易时间处于有效期内、未撤回且最新登记的同意及其文档哈希，供手术室系统术前核对；
+// 患者本人与任何 doctor/nurse 角色可查，无需记录授权
+func (s *SmartContract) VerifyProcedureConsent(ctx contractapi.TransactionContextInterface, patientID, procedureCode string) (*ProcedureConsentVerification, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != patientID {
+		isClinician, err := hasAnyRole(ctx, "doctor", "nurse")
+		if err != nil {
+			return nil, err
+		}
+		if !isClinician {
+			return nil, fmt.Errorf("access denied: clinician role required")
+		}
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := procedureConsentIndexPrefix(patientID, procedureCode)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan procedure consents: %w", err)
+	}
+	defer iterator.Close()
+
+	result := &ProcedureConsentVerification{PatientID: patientID, ProcedureCode: procedureCode}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate procedure consents: %w", err)
+		}
+		var consent ProcedureConsent
+		found, err := getJSON(ctx, procedureConsentKey(string(kv.Value)), &consent)
+		if err != nil {
+			return nil, err
+		}
+		if !found || consent.Status != ProcedureConsentActive {
+			continue
+		}
+		// 有效期已在登记时校验
+		from, _ := time.Parse(time.RFC3339, consent.ValidFrom)
+		to, _ := time.Parse(time.RFC3339, consent.ValidTo)
+		if now.Before(from) || !now.Before(to) {
+			continue
+		}
+		// 按登记时间升序，后命中的覆盖先命中的
+		result.Valid = true
+		result.ConsentID = consent.ConsentID
+		result.ConsentDocumentHash = consent.ConsentDocumentHash
+		result.ValidFrom = consent.ValidFrom
+		result.ValidTo = consent.ValidTo
+	}
+	return result, nil
+}
+
+func emitProcedureConsentEvent(ctx contractapi.TransactionContextInterface, name string, consent *ProcedureConsent, callerID, now string) error {
+	return emitEvent(ctx, name, ProcedureConsentEvent{
+		ConsentID:     consent.ConsentID,
+		PatientID:     consent.PatientID,
+		ProcedureCode: consent.ProcedureCode,
+		Status:        consent.Status,
+		Timestamp:     now,
+		CallerID:      callerID,
+		EventType:     name,
+	})
+}
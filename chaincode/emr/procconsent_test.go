@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestProcedureConsentVerification(t *testing.T) {
+	env := newTestEnv(t)
+	day := func(days int) string {
+		return env.stub.now.AddDate(0, 0, days).Format(time.RFC3339)
+	}
+	first, second := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	record := func(hash, from, to string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.RecordProcedureConsent(ctx, patient.id, "27447", hash, from, to)
+			return err
+		}
+	}
+	verify := func(identity *testIdentity) *ProcedureConsentVerification {
+		var result *ProcedureConsentVerification
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.cc.VerifyProcedureConsent(ctx, patient.id, "27447")
+			return err
+		})
+		return result
+	}
+
+	env.mustFail(other, "only the patient", record(first, day(0), day(30)))
+	env.mustFail(doctor, "invalid consentDocumentHash", record("signed", day(0), day(30)))
+	env.mustFail(doctor, "at most 180 days", record(first, day(0), day(200)))
+	env.mustFail(doctor, "validTo must be after validFrom", record(first, day(30), day(1)))
+	if verify(doctor).Valid {
+		t.Fatal("no consent recorded yet")
+	}
+	env.mustInvoke(doctor, record(first, day(0), day(30)))
+	env.expectEvent("ProcedureConsentRecorded", nil)
+	env.advance(time.Hour)
+	// 尚未生效的新同意不影响核验
+	env.mustInvoke(patient, record(second, day(7), day(60)))
+	if result := verify(nurse); !result.Valid || result.ConsentDocumentHash != first {
+		t.Fatalf("unexpected verification: %+v", result)
+	}
+	env.advance(8 * 24 * time.Hour)
+	result := verify(patient)
+	if !result.Valid || result.ConsentDocumentHash != second {
+		t.Fatalf("the latest consent in force must win: %+v", result)
+	}
+	env.mustFail(other, "clinician role required", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyProcedureConsent(ctx, patient.id, "27447")
+		return err
+	})
+
+	withdraw := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawProcedureConsent(ctx, result.ConsentID)
+	}
+	env.mustFail(doctor, "only the patient", withdraw)
+	env.mustInvoke(patient, withdraw)
+	env.mustFail(patient, "already withdrawn", withdraw)
+	if result := verify(doctor); !result.Valid || result.ConsentDocumentHash != first {
+		t.Fatalf("withdrawn consents must be skipped: %+v", result)
+	}
+	env.advance(30 * 24 * time.Hour)
+	if verify(doctor).Valid {
+		t.Fatal("expired consents must not verify")
+	}
+}
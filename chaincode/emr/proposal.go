@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ProposalPending   = "pending"
+	ProposalCommitted = "committed"
+	ProposalWithdrawn = "withdrawn"
+	ProposalExpired   = "expired"
+	ProposalApproved  = "approved"
+	ProposalRejected  = "rejected"
+
+	// updateProposalTTL 提议未在此期限内提交即失效
+	updateProposalTTL = 72 * time.Hour
+)
+
+// RecordUpdateProposal 两阶段更新的提议：由第二名有写权限的临床人员或患者本人提交后才写入记录。
+// BaseVersionHash 为提议时记录的版本哈希，记录此后另有更新则提议作废，须重新提议。
+// Approver 非空的提议只能由该患者以 ApproveUpdate/RejectUpdate 处理，见 approval.go
+type RecordUpdateProposal struct {
+	ProposalID      string `json:"proposalId"`
+	RecordID        string `json:"recordId"`
+	PatientID       string `json:"patientId"`
+	IPFSCid         string `json:"ipfsCid"`
+	ContentHash     string `json:"contentHash"`
+	BaseVersionHash string `json:"baseVersionHash"`
+	ProposedBy      string `json:"proposedBy"`
+	ProposedAt      string `json:"proposedAt"`
+	ExpiresAt       string `json:"expiresAt"`
+	Status          string `json:"status"`
+	Approver        string `json:"approver,omitempty"`
+	ResolvedBy      string `json:"resolvedBy,omitempty"`
+	ResolvedAt      string `json:"resolvedAt,omitempty"`
+	CommentHash     string `json:"commentHash,omitempty"`
+}
+
+type RecordUpdateProposalEvent struct {
+	ProposalID string `json:"proposalId"`
+	RecordID   string `json:"recordId"`
+	PatientID  string `json:"patientId"`
+	Status     string `json:"status"`
+	ExpiresAt  string `json:"expiresAt"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func updateProposalKey(proposalID string) string {
+	return "update-proposal:" + proposalID
+}
+
+// versionOf 记录当前版本：从未更新的记录以内容哈希为版本
+func versionOf(record *MedicalRecord) string {
+	if record.VersionHash != "" {
+		return record.VersionHash
+	}
+	return record.ContentHash
+}
+
+func getUpdateProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*RecordUpdateProposal, error) {
+	var proposal RecordUpdateProposal
+	found, err := getJSON(ctx, updateProposalKey(proposalID), &proposal)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("update proposal not found: %s", proposalID)
+	}
+	return &proposal, nil
+}
+
+// pendingProposal 提议须待提交且未过期
+func pendingProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*RecordUpdateProposal, time.Time, error) {
+	proposal, err := getUpdateProposal(ctx, proposalID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if proposal.Status != ProposalPending {
+		return nil, time.Time{}, fmt.Errorf("update proposal %s is %s", proposalID, proposal.Status)
+	}
+	if proposal.expired(now) {
+		return nil, time.Time{}, fmt.Errorf("update proposal %s has expired", proposalID)
+	}
+	return proposal, now, nil
+}
+
+func (proposal *RecordUpdateProposal) expired(now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, proposal.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+func emitProposalEvent(ctx contractapi.TransactionContextInterface, name string, proposal *RecordUpdateProposal, timestamp, callerID string) error {
+	return emitEvent(ctx, name, RecordUpdateProposalEvent{
+		ProposalID: proposal.ProposalID,
+		RecordID:   proposal.RecordID,
+		PatientID:  proposal.PatientID,
+		Status:     proposal.Status,
+		ExpiresAt:  proposal.ExpiresAt,
+		Timestamp:  timestamp,
+		CallerID:   callerID,
+		EventType:  name,
+	})
+}
+
+// ProposeRecordUpdate 提议更新记录的存储位置与内容哈希，updateJson 为 {ipfsCid, contentHash}；
+// 提议人须有写权限，校验与 UpdateMedicalRecord 相同。返回提议 ID（本交易 ID）
+func (s *SmartContract) ProposeRecordUpdate(ctx contractapi.TransactionContextInterface, recordID, updateJson string) (string, error) {
+	var update struct {
+		IPFSCid     string `json:"ipfsCid"`
+		ContentHash string `json:"contentHash"`
+	}
+	if err := unmarshalArg(updateJson, &update); err != nil {
+		return "", fmt.Errorf("failed to unmarshal update: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	record, err := s.checkRecordUpdate(ctx, callerID, recordID, update.IPFSCid, update.ContentHash, false)
+	if err != nil {
+		return "", err
+	}
+	proposal, err := storeUpdateProposal(ctx, record, callerID, update.IPFSCid, update.ContentHash, "")
+	if err != nil {
+		return "", err
+	}
+	if err := emitProposalEvent(ctx, "RecordUpdateProposed", proposal, proposal.ProposedAt, callerID); err != nil {
+		return "", err
+	}
+	return proposal.ProposalID, nil
+}
+
+// storeUpdateProposal 写入待处理的提议，提议 ID 为本交易 ID；approver 为空表示由第二名写权限持有人提交
+func storeUpdateProposal(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID, ipfsCid, contentHash, approver string) (*RecordUpdateProposal, error) {
+	if contentHash == record.ContentHash {
+		return nil, fmt.Errorf("contentHash is unchanged")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposal := &RecordUpdateProposal{
+		ProposalID:      ctx.GetStub().GetTxID(),
+		RecordID:        record.RecordID,
+		PatientID:       record.PatientID,
+		IPFSCid:         ipfsCid,
+		ContentHash:     contentHash,
+		BaseVersionHash: versionOf(record),
+		ProposedBy:      callerID,
+		ProposedAt:      now.Format(time.RFC3339),
+		ExpiresAt:       now.Add(updateProposalTTL).Format(time.RFC3339),
+		Status:          ProposalPending,
+		Approver:        approver,
+	}
+	if err := putJSON(ctx, updateProposalKey(proposal.ProposalID), proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// resolveProposal 检查记录未归档且自提议后未变化，写入提议内容并记下处理结果，返回更新后的记录
+func resolveProposal(ctx contractapi.TransactionContextInterface, proposal *RecordUpdateProposal, status, callerID string, now time.Time) (*MedicalRecord, error) {
+	record, err := getRecord(ctx, proposal.RecordID)
+	if err != nil {
+		return nil, err
+	}
+	if status == ProposalCommitted || status == ProposalApproved {
+		if record.Status == RecordArchived {
+			return nil, fmt.Errorf("record %s is archived", record.RecordID)
+		}
+		if versionOf(record) != proposal.BaseVersionHash {
+			return nil, fmt.Errorf("record %s changed after the proposal: propose the update again", record.RecordID)
+		}
+		if err := applyRecordUpdate(ctx, record, proposal.IPFSCid, proposal.ContentHash); err != nil {
+			return nil, err
+		}
+	}
+	proposal.Status = status
+	proposal.ResolvedBy = callerID
+	proposal.ResolvedAt = now.Format(time.RFC3339)
+	return record, putJSON(ctx, updateProposalKey(proposal.ProposalID), proposal)
+}
+
+// emitProposalApplied 已写入记录的提议发出带 proposalId 的 RecordUpdated，已有的更新监听无需改动
+func emitProposalApplied(ctx contractapi.TransactionContextInterface, proposal *RecordUpdateProposal, record *MedicalRecord) error {
+	return emitEvent(ctx, "RecordUpdated", RecordUpdatedEvent{
+		RecordID:    record.RecordID,
+		ContentHash: record.ContentHash,
+		VersionHash: record.VersionHash,
+		ProposalID:  proposal.ProposalID,
+		Timestamp:   proposal.ResolvedAt,
+		CallerID:    proposal.ResolvedBy,
+		EventType:   "RecordUpdated",
+	})
+}
+
+// CommitRecordUpdate 提交提议并写入记录，发出带 proposalId 的 RecordUpdated；
+// 提交者须为患者本人或另一名有写权限的身份，不能是提议人
+func (s *SmartContract) CommitRecordUpdate(ctx contractapi.TransactionContextInterface, proposalID string) error {
+	proposal, now, err := pendingProposal(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if proposal.Approver != "" {
+		return fmt.Errorf("update proposal %s awaits the patient's approval: use ApproveUpdate", proposalID)
+	}
+	if callerID == proposal.ProposedBy {
+		return fmt.Errorf("access denied: the proposer cannot commit their own update")
+	}
+	isPatient, err := callerIs(ctx, proposal.PatientID)
+	if err != nil {
+		return err
+	}
+	if !isPatient {
+		record, err := s.checkRecordUpdate(ctx, callerID, proposal.RecordID, proposal.IPFSCid, proposal.ContentHash, false)
+		if err != nil {
+			return err
+		}
+		pending, err := needsPatientApproval(ctx, record)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return fmt.Errorf("record %s requires the patient's approval: only the patient can commit", proposal.RecordID)
+		}
+	}
+	record, err := resolveProposal(ctx, proposal, ProposalCommitted, callerID, now)
+	if err != nil {
+		return err
+	}
+	return emitProposalApplied(ctx, proposal, record)
+}
+
+// WithdrawRecordUpdate 提议人撤回待提交的提议
+func (s *SmartContract) WithdrawRecordUpdate(ctx contractapi.TransactionContextInterface, proposalID string) error {
+	proposal, now, err := pendingProposal(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != proposal.ProposedBy {
+		return fmt.Errorf("access denied: only the proposer can withdraw an update proposal")
+	}
+	if _, err := resolveProposal(ctx, proposal, ProposalWithdrawn, callerID, now); err != nil {
+		return err
+	}
+	return emitProposalEvent(ctx, "RecordUpdateWithdrawn", proposal, proposal.ResolvedAt, callerID)
+}
+
+// GetRecordUpdateProposal 有记录访问权的身份可查询；过期未提交的提议状态显示为 expired
+func (s *SmartContract) GetRecordUpdateProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*RecordUpdateProposal, error) {
+	proposal, err := getUpdateProposal(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRecordAccess(ctx, proposal.RecordID); err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status == ProposalPending && proposal.expired(now) {
+		proposal.Status = ProposalExpired
+	}
+	return proposal, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) proposeUpdate(identity *testIdentity, recordID, contentHash string) string {
+	e.t.Helper()
+	var proposalID string
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		proposalID, err = e.cc.ProposeRecordUpdate(ctx, recordID, `{"ipfsCid":"bafy`+contentHash[:8]+`","contentHash":"`+contentHash+`"}`)
+		return err
+	})
+	return proposalID
+}
+
+func TestTwoPhaseRecordUpdate(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetContractConfig(ctx, `{"twoPhaseRecordTypes":["surgical-note"]}`)
+	})
+	env.createTypedRecord("rec1", "surgical-note")
+	env.grant(patient, "rec1", specialist.id, "write", "")
+	newHash := strings.Repeat("b", 64)
+
+	env.mustFail(doctor, "use ProposeRecordUpdate", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", newHash)
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ProposeRecordUpdate(ctx, "rec1", `{"ipfsCid":"bafynew","contentHash":"`+newHash+`"}`)
+		return err
+	})
+	proposalID := env.proposeUpdate(doctor, "rec1", newHash)
+	var proposed RecordUpdateProposalEvent
+	env.expectEvent("RecordUpdateProposed", &proposed)
+	if proposed.ProposalID != proposalID || proposed.Status != ProposalPending {
+		t.Fatalf("unexpected event: %+v", proposed)
+	}
+	// 提议不改变记录
+	if record := env.storedRecord("rec1"); record.ContentHash == newHash {
+		t.Fatal("a proposal must not change the record")
+	}
+	commit := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, proposalID)
+	}
+	env.mustFail(doctor, "proposer cannot commit", commit)
+	env.mustFail(nurse, "access denied", commit)
+	env.mustInvoke(specialist, commit)
+	var updated RecordUpdatedEvent
+	env.expectEvent("RecordUpdated", &updated)
+	if updated.ProposalID != proposalID || updated.ContentHash != newHash || updated.CallerID != specialist.id {
+		t.Fatalf("unexpected event: %+v", updated)
+	}
+	if record := env.storedRecord("rec1"); record.ContentHash != newHash {
+		t.Fatalf("committed update must be applied: %+v", record)
+	}
+	env.mustFail(patient, "is committed", commit)
+
+	// 记录在提议后另有更新时提议作废
+	first := env.proposeUpdate(doctor, "rec1", strings.Repeat("c", 64))
+	second := env.proposeUpdate(specialist, "rec1", strings.Repeat("d", 64))
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, first)
+	})
+	env.mustFail(doctor, "changed after the proposal", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, second)
+	})
+	env.mustFail(doctor, "only the proposer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawRecordUpdate(ctx, second)
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawRecordUpdate(ctx, second)
+	})
+	env.expectEvent("RecordUpdateWithdrawn", nil)
+}
+
+func TestUpdateProposalsExpire(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	proposalID := env.proposeUpdate(doctor, "rec1", strings.Repeat("b", 64))
+	env.advance(updateProposalTTL + time.Minute)
+	env.mustFail(patient, "has expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CommitRecordUpdate(ctx, proposalID)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		proposal, err := env.cc.GetRecordUpdateProposal(ctx, proposalID)
+		if err == nil && proposal.Status != ProposalExpired {
+			t.Fatalf("unexpected proposal: %+v", proposal)
+		}
+		return err
+	})
+	// 未配置两阶段的记录类型仍可直接更新
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafynew", strings.Repeat("c", 64))
+	})
+}
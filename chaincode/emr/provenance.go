@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ProvenanceClinician        = "clinician"
+	ProvenancePatientGenerated = "patient-generated"
+)
+
+// clinicianOnlyFhirTypes 诊断依据类资源，患者自产记录不能声明
+var clinicianOnlyFhirTypes = []string{"DiagnosticReport", "ImagingStudy", "MedicationRequest"}
+
+// assignProvenanceTier 创建者即患者本人的记录为 patient-generated，其余为 clinician；不接受客户端自报层级
+func assignProvenanceTier(rec *MedicalRecord) error {
+	tier := ProvenanceClinician
+	if rec.CreatorID == rec.PatientID {
+		tier = ProvenancePatientGenerated
+	}
+	if rec.ProvenanceTier != "" && rec.ProvenanceTier != tier {
+		return fmt.Errorf("provenanceTier %s does not match the record creator", rec.ProvenanceTier)
+	}
+	if tier == ProvenancePatientGenerated && containsString(clinicianOnlyFhirTypes, rec.FhirResourceType) {
+		return fmt.Errorf("patient-generated records cannot declare %s", rec.FhirResourceType)
+	}
+	rec.ProvenanceTier = tier
+	return nil
+}
+
+// CreatePatientGeneratedRecord 患者提交自产数据（症状日记、居家测量等），creatorId 强制为患者本人
+func (s *SmartContract) CreatePatientGeneratedRecord(ctx contractapi.TransactionContextInterface, recordJson string) (string, error) {
+	var rec MedicalRecord
+	if err := unmarshalArg(recordJson, &rec); err != nil {
+		return "", fmt.Errorf("invalid record json: %w", err)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if callerID != rec.PatientID {
+		return "", fmt.Errorf("access denied: only the patient can submit patient-generated records")
+	}
+	rec.CreatorID = callerID
+	rec.ProvenanceTier = ProvenancePatientGenerated
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal record: %w", err)
+	}
+	return s.CreateMedicalRecord(ctx, string(data))
+}
+
+// ListRecordsByPatient 经 patient~record 索引列出调用者可访问的未归档患者记录，患者本人以外只返回元数据；provenanceTier 为空时不过滤
+func (s *SmartContract) ListRecordsByPatient(ctx contractapi.TransactionContextInterface, patientID, provenanceTier string) ([]*MedicalRecord, error) {
+	if provenanceTier != "" && provenanceTier != ProvenanceClinician && provenanceTier != ProvenancePatientGenerated {
+		return nil, fmt.Errorf("invalid provenanceTier: %s", provenanceTier)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := callerQueryScope(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	records := []*MedicalRecord{}
+	err = scanIndex(ctx, patientRecordIndex, []string{patientID}, func(attrs []string) error {
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		if !listable(record) || (provenanceTier != "" && recordTier(record) != provenanceTier) {
+			return nil
+		}
+		if scope != nil {
+			if limited := scope.view(record); limited != nil {
+				records = append(records, limited)
+			}
+			return nil
+		}
+		if isPatient {
+			records = append(records, record)
+			return nil
+		}
+		allowed, err := s.callerAccess(ctx, record, callerID)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			records = append(records, withoutContent(record))
+		}
+		return nil
+	})
+	return records, err
+}
+
+// recordTier 早于来源层级字段创建的记录按 clinician 处理
+func recordTier(record *MedicalRecord) string {
+	if record.ProvenanceTier == "" {
+		return ProvenanceClinician
+	}
+	return record.ProvenanceTier
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestPatientGeneratedRecords(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	recordJSON := func(recordID, extra string) string {
+		return `{"recordId":"` + recordID + `","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"` +
+			strings.Repeat("a", 64) + `"` + extra + `}`
+	}
+	diary := recordJSON("diary1", "")
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreatePatientGeneratedRecord(ctx, diary)
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreatePatientGeneratedRecord(ctx, diary)
+		return err
+	})
+	env.mustFail(patient, "cannot declare DiagnosticReport", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreatePatientGeneratedRecord(ctx, recordJSON("diary2",
+			`,"fhirResourceType":"DiagnosticReport","fhirVersion":"R4","fhirMetadata":{"status":"final","code":{"text":"x"}}`))
+		return err
+	})
+	env.mustFail(doctor, "does not match the record creator", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, recordJSON("rec9", `,"provenanceTier":"patient-generated"`))
+		return err
+	})
+
+	list := func(id *testIdentity, tier string) []*MedicalRecord {
+		var records []*MedicalRecord
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			records, err = env.cc.ListRecordsByPatient(ctx, patient.id, tier)
+			return err
+		})
+		return records
+	}
+	if got := list(patient, ""); len(got) != 2 {
+		t.Fatalf("expected both records, got %d", len(got))
+	}
+	if got := list(patient, ProvenanceClinician); len(got) != 1 || got[0].RecordID != "rec1" {
+		t.Fatalf("clinician filter must exclude patient data: %+v", got)
+	}
+	if got := list(patient, ProvenancePatientGenerated); len(got) != 1 || got[0].CreatorID != patient.id {
+		t.Fatalf("patient-generated filter must return the diary: %+v", got)
+	}
+	// 列表只包含调用者可访问的记录
+	if got := list(doctor, ""); len(got) != 1 {
+		t.Fatalf("doctor must only see authored record, got %d", len(got))
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// creatorRecordIndex 创建者 → 记录索引，由 CreateMedicalRecord 写入
+const creatorRecordIndex = "creator~record"
+
+// orgDateLayout ListRecordsByOrganization 的日期参数格式，区间两端均包含
+const orgDateLayout = "2006-01-02"
+
+// RecordPage 分页查询结果；Bookmark 为空表示没有下一页
+type RecordPage struct {
+	Records  []*MedicalRecord `json:"records"`
+	Bookmark string           `json:"bookmark"`
+}
+
+// withoutContent 列表与检索向患者以外的调用者只返回元数据：去掉 CID、分部与分节，
+// 内容须经 ReadRecord 读取，才会扣减限次授权并计入披露报表
+func withoutContent(record *MedicalRecord) *MedicalRecord {
+	listed := *record
+	listed.IPCSCID = ""
+	listed.Parts = nil
+	listed.Sections = nil
+	return &listed
+}
+
+// validatePageSize 单页条数须在 1 到配置的 maxPageSize 之间
+func validatePageSize(ctx contractapi.TransactionContextInterface, pageSize int32) error {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if pageSize < 1 || int(pageSize) > config.MaxPageSize {
+		return fmt.Errorf("pageSize must be between 1 and %d", config.MaxPageSize)
+	}
+	return nil
+}
+
+// ListRecordsByCreator 分页列出创建者跨患者创建的未归档记录，仅创建者本人或 auditor 角色；审计员只得到元数据
+func (s *SmartContract) ListRecordsByCreator(ctx contractapi.TransactionContextInterface, creatorID string, pageSize int32, bookmark string) (*RecordPage, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isCreator, err := callerIs(ctx, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isCreator {
+		auditor, err := isAuditor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !auditor {
+			return nil, fmt.Errorf("access denied: only the creator or an auditor can list records by creator")
+		}
+	}
+	scope, err := callerScopeByCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(creatorRecordIndex, []string{creatorID}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s index: %w", creatorRecordIndex, err)
+	}
+	defer iterator.Close()
+
+	page := &RecordPage{Records: []*MedicalRecord{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate %s index: %w", creatorRecordIndex, err)
+		}
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split %s index key: %w", creatorRecordIndex, err)
+		}
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return nil, err
+		}
+		if listable(record) {
+			if scope != nil {
+				if record = scope.view(record); record == nil {
+					continue
+				}
+			}
+			if !isCreator {
+				record = withoutContent(record)
+			}
+			page.Records = append(page.Records, record)
+		}
+	}
+	return page, nil
+}
+
+// epochSegment 12 位零填充的 Unix 秒，定长保证简单键按字典序即时间顺序
+func epochSegment(t time.Time) string {
+	return fmt.Sprintf("%012d", t.Unix())
+}
+
+// orgRecordKey orgtime:{mspId}:{epoch}:{recordId}，值为 recordId
+func orgRecordKey(mspID string, recordedAt time.Time, recordID string) string {
+	return "orgtime:" + mspID + ":" + epochSegment(recordedAt) + ":" + recordID
+}
+
+// patientTimeKey ptime:{patientId}:{epoch}:{recordId}，值为 recordId
+func patientTimeKey(patientID string, recordedAt time.Time, recordID string) string {
+	return "ptime:" + patientID + ":" + epochSegment(recordedAt) + ":" + recordID
+}
+
+// scanTimeIndex 在 [startKey, endKey) 上分页扫描时间索引简单键，visit 收到索引值中的 recordId
+func scanTimeIndex(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int32, bookmark string, visit func(recordID string) error) (string, error) {
+	iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return "", fmt.Errorf("failed to query time index: %w", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed to iterate time index: %w", err)
+		}
+		if err := visit(string(kv.Value)); err != nil {
+			return "", err
+		}
+	}
+	return metadata.GetBookmark(), nil
+}
+
+// ListRecordsByOrganization 按创建交易所属机构与记录日期区间分页列出记录元数据，仅 auditor/compliance 角色
+func (s *SmartContract) ListRecordsByOrganization(ctx contractapi.TransactionContextInterface, mspID, fromDate, toDate string, pageSize int32, bookmark string) (*RecordPage, error) {
+	if mspID == "" || strings.Contains(mspID, ":") {
+		return nil, fmt.Errorf("invalid mspID: %q", mspID)
+	}
+	from, err := time.Parse(orgDateLayout, fromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fromDate: %w", err)
+	}
+	to, err := time.Parse(orgDateLayout, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid toDate: %w", err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("toDate must not be before fromDate")
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	allowed, err := hasAnyRole(ctx, auditorRole, "compliance")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only auditor or compliance roles can list records by organization")
+	}
+	scope, err := callerScopeByCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// toDate 当天全部包含：结束键取次日零点
+	startKey := "orgtime:" + mspID + ":" + epochSegment(from)
+	endKey := "orgtime:" + mspID + ":" + epochSegment(to.AddDate(0, 0, 1))
+	page := &RecordPage{Records: []*MedicalRecord{}}
+	page.Bookmark, err = scanTimeIndex(ctx, startKey, endKey, pageSize, bookmark, func(recordID string) error {
+		record, err := getRecord(ctx, recordID)
+		if err != nil || !listable(record) {
+			return err
+		}
+		if scope != nil {
+			if record = scope.view(record); record == nil {
+				return nil
+			}
+		}
+		page.Records = append(page.Records, withoutContent(record))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListRecordsByPatientAndTimeRange 按记录时间区间（RFC3339，两端包含）分页列出患者记录；
+// 患者本人返回完整记录，其他调用者只返回可读取（同 ReadRecord 的访问判断）记录的元数据
+func (s *SmartContract) ListRecordsByPatientAndTimeRange(ctx contractapi.TransactionContextInterface, patientID, from, to string, pageSize int32, bookmark string) (*RecordPage, error) {
+	if err := validateAddress(patientID); err != nil {
+		return nil, fmt.Errorf("invalid patientID: %w", err)
+	}
+	fromTime, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	toTime, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	if toTime.Before(fromTime) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+	if fromTime.Unix() < 0 {
+		fromTime = time.Unix(0, 0)
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isPatient, err := callerIs(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := callerQueryScope(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+
+	startKey := "ptime:" + patientID + ":" + epochSegment(fromTime)
+	endKey := "ptime:" + patientID + ":" + epochSegment(toTime.Add(time.Second))
+	page := &RecordPage{Records: []*MedicalRecord{}}
+	page.Bookmark, err = scanTimeIndex(ctx, startKey, endKey, pageSize, bookmark, func(recordID string) error {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		// patientId 可含 ':'（DID），前缀相邻的其他患者键可能落入区间，以记录本身为准
+		if record.PatientID != patientID || !listable(record) {
+			return nil
+		}
+		if scope != nil {
+			if limited := scope.view(record); limited != nil {
+				page.Records = append(page.Records, limited)
+			}
+			return nil
+		}
+		if !isPatient {
+			allowed, err := s.callerAccess(ctx, record, callerID)
+			if err != nil || !allowed {
+				return err
+			}
+			record = withoutContent(record)
+		}
+		page.Records = append(page.Records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
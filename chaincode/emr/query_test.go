@@ -0,0 +1,172 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var auditor = newIdentity("auditor1", "AuditMSP", "role", "auditor")
+
+func TestListRecordsByCreatorPaginates(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", "patient2")
+	env.createRecord(doctor, "rec3", "patient3")
+	env.createRecord(nurse, "rec4", patient.id)
+
+	var seen []string
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		var page *RecordPage
+		env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			page, err = env.cc.ListRecordsByCreator(ctx, doctor.id, 2, bookmark)
+			return err
+		})
+		for _, rec := range page.Records {
+			seen = append(seen, rec.RecordID)
+		}
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if len(seen) != 3 || seen[0] != "rec1" || seen[2] != "rec3" {
+		t.Fatalf("unexpected records: %v", seen)
+	}
+
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.ListRecordsByCreator(ctx, nurse.id, 10, "")
+		if err == nil && (len(page.Records) != 1 || page.Records[0].RecordID != "rec4" || page.Records[0].IPCSCID != "") {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+		return err
+	})
+	env.mustFail(nurse, "only the creator or an auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListRecordsByCreator(ctx, doctor.id, 10, "")
+		return err
+	})
+	env.mustFail(doctor, "pageSize", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListRecordsByCreator(ctx, doctor.id, 0, "")
+		return err
+	})
+}
+
+func TestListRecordsByOrganizationDateRange(t *testing.T) {
+	env := newTestEnv(t)
+	compliance := newIdentity("compliance1", "AuditMSP", "role", "compliance")
+	env.createRecord(doctor, "rec1", patient.id) // Org1MSP 2026-01-05
+	env.createRecord(nurse, "rec2", patient.id)  // Org2MSP 2026-01-05
+	env.advance(48 * time.Hour)
+	env.createRecord(doctor, "rec3", "patient2") // Org1MSP 2026-01-07
+	env.advance(48 * time.Hour)
+	env.createRecord(doctor, "rec4", "patient3") // Org1MSP 2026-01-09
+
+	list := func(identity *testIdentity, from, to string) []string {
+		var ids []string
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.ListRecordsByOrganization(ctx, "Org1MSP", from, to, 10, "")
+			if err != nil {
+				return err
+			}
+			for _, rec := range page.Records {
+				ids = append(ids, rec.RecordID)
+			}
+			return nil
+		})
+		return ids
+	}
+	if got := list(auditor, "2026-01-05", "2026-01-07"); len(got) != 2 || got[0] != "rec1" || got[1] != "rec3" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	if got := list(compliance, "2026-01-08", "2026-01-31"); len(got) != 1 || got[0] != "rec4" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+
+	env.mustFail(doctor, "only auditor or compliance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListRecordsByOrganization(ctx, "Org1MSP", "2026-01-01", "2026-01-31", 10, "")
+		return err
+	})
+	env.mustFail(auditor, "toDate must not be before", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListRecordsByOrganization(ctx, "Org1MSP", "2026-01-31", "2026-01-01", 10, "")
+		return err
+	})
+}
+
+func TestListRecordsByPatientAndTimeRange(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "before", patient.id)
+	env.advance(24 * time.Hour)
+	admitted := env.stub.now
+	env.createRecord(doctor, "admission", patient.id)
+	env.advance(2 * time.Hour)
+	env.createRecord(nurse, "vitals", patient.id)
+	env.createRecord(doctor, "neighbour", "patient10")
+	env.advance(72 * time.Hour)
+	discharged := env.stub.now
+	env.createRecord(doctor, "discharge", patient.id)
+	env.advance(24 * time.Hour)
+	env.createRecord(doctor, "after", patient.id)
+
+	list := func(identity *testIdentity) []string {
+		var ids []string
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.ListRecordsByPatientAndTimeRange(ctx, patient.id, admitted.Format(time.RFC3339), discharged.Format(time.RFC3339), 10, "")
+			if err != nil {
+				return err
+			}
+			for _, rec := range page.Records {
+				ids = append(ids, rec.RecordID)
+			}
+			return nil
+		})
+		return ids
+	}
+	if got := list(patient); len(got) != 3 || got[0] != "admission" || got[1] != "vitals" || got[2] != "discharge" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	// 护士只看到自己创建（有访问权）的记录
+	if got := list(nurse); len(got) != 1 || got[0] != "vitals" {
+		t.Fatalf("unexpected records for nurse: %v", got)
+	}
+	if got := list(other); len(got) != 0 {
+		t.Fatalf("stranger must see nothing, got %v", got)
+	}
+}
+
+func TestTimeRangeListingReturnsMetadataOnly(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.grant(patient, "rec1", studyResearcher.id, "read", "")
+
+	list := func(identity *testIdentity) []*MedicalRecord {
+		var records []*MedicalRecord
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.ListRecordsByPatientAndTimeRange(ctx, patient.id, "2026-01-01T00:00:00Z", "2026-12-31T00:00:00Z", 10, "")
+			if err == nil {
+				records = page.Records
+			}
+			return err
+		})
+		return records
+	}
+	if got := list(patient); len(got) != 1 || got[0].IPCSCID == "" {
+		t.Fatalf("patient must get the full record: %+v", got)
+	}
+	got := list(nurse)
+	if len(got) != 1 || got[0].IPCSCID != "" || got[0].ContentHash == "" {
+		t.Fatalf("grantee must get metadata without the content pointer: %+v", got)
+	}
+	// 与 ReadRecord 同一判断：研究人员的授权未引用 DUA，CheckAccess 通过也不列出
+	if got := list(studyResearcher); len(got) != 0 {
+		t.Fatalf("researcher without a DUA must see nothing: %+v", got)
+	}
+	if got := list(auditor); len(got) != 1 || got[0].IPCSCID != "" {
+		t.Fatalf("auditor must get metadata only: %+v", got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// consentReceiptVersion 字段命名参照 Kantara Consent Receipt 规范
+const consentReceiptVersion = "KI-CR-v1.1.0"
+
+// ConsentReceipt 同意回执：被授权人留存，作为处理该记录的合法依据。
+// 回执本身不带私钥签名，其效力来自生成回执的交易被背书并写入账本：须以 submit 调用，
+// 核验方用 GetConsentReceipt 读回并比对 receiptHash
+type ConsentReceipt struct {
+	Version     string `json:"version"`
+	ReceiptID   string `json:"receiptId"`
+	ConsentedBy string `json:"consentedBy"`
+	OnBehalfOf  string `json:"onBehalfOf,omitempty"`
+	Grantee     string `json:"grantee"`
+	RecordID    string `json:"recordId"`
+	Scope       string `json:"scope"`
+	GrantedAt   string `json:"grantedAt"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
+	GrantTxID   string `json:"grantTxId,omitempty"`
+	IssuedAt    string `json:"issuedAt"`
+	IssuedBy    string `json:"issuedBy"`
+	ReceiptHash string `json:"receiptHash"`
+}
+
+type ConsentReceiptEvent struct {
+	ReceiptID string `json:"receiptId"`
+	RecordID  string `json:"recordId"`
+	Grantee   string `json:"grantee"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func receiptKey(receiptID string) string {
+	return "receipt:" + receiptID
+}
+
+// hash 对 receiptHash 置空后的 JSON 取 sha256；结构体字段顺序固定，序列化结果确定
+func (receipt ConsentReceipt) hash() (string, error) {
+	receipt.ReceiptHash = ""
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal receipt: %w", err)
+	}
+	return sha256Hex(string(data)), nil
+}
+
+// GenerateConsentReceipt 为有效授权生成回执，由患者、有 consent 范围的代理人或被授权人调用；
+// 回执 ID 为本交易 ID
+func (s *SmartContract) GenerateConsentReceipt(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*ConsentReceipt, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != granteeID {
+		if _, err := ownerOrAgent(ctx, record.PatientID, callerID, "consent"); err != nil {
+			return nil, err
+		}
+	}
+	perm, err := currentGrant(ctx, recordID, granteeID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !permissionActive(*perm, now) {
+		return nil, fmt.Errorf("permission for %s on record %s is not active", granteeID, recordID)
+	}
+
+	receipt := &ConsentReceipt{
+		Version:     consentReceiptVersion,
+		ReceiptID:   ctx.GetStub().GetTxID(),
+		ConsentedBy: perm.GrantedBy,
+		Grantee:     granteeID,
+		RecordID:    recordID,
+		Scope:       perm.Action,
+		GrantedAt:   perm.GrantedAt,
+		ExpiresAt:   perm.ExpiresAt,
+		GrantTxID:   perm.TxID,
+		IssuedAt:    now.Format(time.RFC3339),
+		IssuedBy:    callerID,
+	}
+	if perm.GrantedBy != record.PatientID {
+		receipt.OnBehalfOf = record.PatientID
+	}
+	receipt.ReceiptHash, err = receipt.hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := putJSON(ctx, receiptKey(receipt.ReceiptID), receipt); err != nil {
+		return nil, err
+	}
+	return receipt, emitEvent(ctx, "ConsentReceiptIssued", ConsentReceiptEvent{
+		ReceiptID: receipt.ReceiptID,
+		RecordID:  recordID,
+		Grantee:   granteeID,
+		Timestamp: receipt.IssuedAt,
+		CallerID:  callerID,
+		EventType: "ConsentReceiptIssued",
+	})
+}
+
+// GetConsentReceipt 读回链上回执并重新计算哈希，哈希不符时报错
+func (s *SmartContract) GetConsentReceipt(ctx contractapi.TransactionContextInterface, receiptID string) (*ConsentReceipt, error) {
+	var receipt ConsentReceipt
+	found, err := getJSON(ctx, receiptKey(receiptID), &receipt)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("consent receipt not found: %s", receiptID)
+	}
+	expected, err := receipt.hash()
+	if err != nil {
+		return nil, err
+	}
+	if expected != receipt.ReceiptHash {
+		return nil, fmt.Errorf("consent receipt %s does not match its hash", receiptID)
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestGenerateConsentReceipt(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	var grantTxID string
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		perm, err := getPermission(ctx, "rec1", nurse.id)
+		if err == nil {
+			grantTxID = perm.TxID
+		}
+		return err
+	})
+	if grantTxID == "" {
+		t.Fatal("grant must record its transaction ID")
+	}
+
+	env.mustFail(other, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GenerateConsentReceipt(ctx, "rec1", nurse.id)
+		return err
+	})
+	var receipt *ConsentReceipt
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		receipt, err = env.cc.GenerateConsentReceipt(ctx, "rec1", nurse.id)
+		return err
+	})
+	env.expectEvent("ConsentReceiptIssued", nil)
+	if receipt.ConsentedBy != patient.id || receipt.Scope != "read" || receipt.GrantTxID != grantTxID || receipt.OnBehalfOf != "" {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		stored, err := env.cc.GetConsentReceipt(ctx, receipt.ReceiptID)
+		if err == nil && *stored != *receipt {
+			t.Fatalf("stored receipt differs: %+v", stored)
+		}
+		return err
+	})
+	tampered := *receipt
+	tampered.Scope = "write"
+	data, _ := json.Marshal(tampered)
+	env.putRaw(receiptKey(receipt.ReceiptID), string(data))
+	env.mustFail(other, "does not match its hash", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetConsentReceipt(ctx, receipt.ReceiptID)
+		return err
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+	env.mustFail(nurse, "is not active", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GenerateConsentReceipt(ctx, "rec1", nurse.id)
+		return err
+	})
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// jsonSchema 支持的 JSON Schema 子集：type、required、properties、items、enum、pattern；
+// 出现其他关键字时拒绝注册，避免调用方误以为约束已生效
+type jsonSchema struct {
+	Type       string                 `json:"type,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+	Enum       []interface{}          `json:"enum,omitempty"`
+	Pattern    string                 `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+var jsonSchemaTypes = []string{"object", "array", "string", "number", "integer", "boolean"}
+
+// RecordSchema recordType 当前生效的元数据模式；重新注册时版本递增
+type RecordSchema struct {
+	RecordType   string `json:"recordType"`
+	Schema       string `json:"schema"`
+	Version      int    `json:"version"`
+	RegisteredAt string `json:"registeredAt"`
+	RegisteredBy string `json:"registeredBy"`
+}
+
+type RecordSchemaRegisteredEvent struct {
+	RecordType string `json:"recordType"`
+	Version    int    `json:"version"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func recordSchemaKey(recordType string) string {
+	return "schema:" + recordType
+}
+
+// parseJSONSchema 严格解析并预编译模式
+func parseJSONSchema(schemaJson string) (*jsonSchema, error) {
+	decoder := json.NewDecoder(strings.NewReader(schemaJson))
+	decoder.DisallowUnknownFields()
+	var schema jsonSchema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.compile("metadata"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (schema *jsonSchema) compile(path string) error {
+	if schema.Type != "" && !containsString(jsonSchemaTypes, schema.Type) {
+		return fmt.Errorf("invalid schema at %s: unsupported type %q", path, schema.Type)
+	}
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %s: %w", path, err)
+		}
+		schema.pattern = pattern
+	}
+	for name, property := range schema.Properties {
+		if property == nil {
+			return fmt.Errorf("invalid schema at %s.%s: property schema is null", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if schema.Items != nil {
+		return schema.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+func jsonTypeMatches(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+// validate 收集 path 下的全部字段级错误，格式为 "metadata.code: required"
+func (schema *jsonSchema) validate(value interface{}, path string, errs *[]string) {
+	if schema.Type != "" && !jsonTypeMatches(schema.Type, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s", path, schema.Type))
+		return
+	}
+	if len(schema.Enum) > 0 {
+		matched := false
+		for _, allowed := range schema.Enum {
+			if reflect.DeepEqual(allowed, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: not one of the allowed values", path))
+		}
+	}
+	if text, ok := value.(string); ok && schema.pattern != nil && !schema.pattern.MatchString(text) {
+		*errs = append(*errs, fmt.Sprintf("%s: does not match pattern %s", path, schema.Pattern))
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, name := range schema.Required {
+			if _, present := object[name]; !present {
+				*errs = append(*errs, fmt.Sprintf("%s.%s: required", path, name))
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, present := object[name]; present {
+				schema.Properties[name].validate(property, path+"."+name, errs)
+			}
+		}
+	}
+	if items, ok := value.([]interface{}); ok && schema.Items != nil {
+		for i, item := range items {
+			schema.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func getRecordSchema(ctx contractapi.TransactionContextInterface, recordType string) (*RecordSchema, error) {
+	var schema RecordSchema
+	found, err := getJSON(ctx, recordSchemaKey(recordType), &schema)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// validateRecordMetadata 记录类型注册了模式时校验 metadata；未注册的类型不校验
+func validateRecordMetadata(ctx contractapi.TransactionContextInterface, rec *MedicalRecord) error {
+	if rec.RecordType == "" {
+		return nil
+	}
+	registered, err := getRecordSchema(ctx, rec.RecordType)
+	if err != nil || registered == nil {
+		return err
+	}
+	schema, err := parseJSONSchema(registered.Schema)
+	if err != nil {
+		return err
+	}
+	var metadata interface{} = rec.Metadata
+	if rec.Metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	var errs []string
+	schema.validate(metadata, "metadata", &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("metadata does not match %s schema v%d: %s", rec.RecordType, registered.Version, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// RegisterRecordSchema 注册或替换 recordType 的元数据模式；仅 admin
+func (s *SmartContract) RegisterRecordSchema(ctx contractapi.TransactionContextInterface, recordType, schemaJson string) error {
+	if !recordTypePattern.MatchString(recordType) {
+		return fmt.Errorf("invalid recordType: %q", recordType)
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("access denied: only admin can register record schemas")
+	}
+	if _, err := parseJSONSchema(schemaJson); err != nil {
+		return err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(schemaJson)); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	previous, err := getRecordSchema(ctx, recordType)
+	if err != nil {
+		return err
+	}
+	schema := RecordSchema{RecordType: recordType, Schema: compact.String(), Version: 1, RegisteredAt: now, RegisteredBy: callerID}
+	if previous != nil {
+		schema.Version = previous.Version + 1
+	}
+	if err := putJSON(ctx, recordSchemaKey(recordType), schema); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RecordSchemaRegistered", RecordSchemaRegisteredEvent{
+		RecordType: recordType,
+		Version:    schema.Version,
+		Timestamp:  now,
+		CallerID:   callerID,
+		EventType:  "RecordSchemaRegistered",
+	})
+}
+
+// GetRecordSchema 返回 recordType 当前生效的模式
+func (s *SmartContract) GetRecordSchema(ctx contractapi.TransactionContextInterface, recordType string) (*RecordSchema, error) {
+	schema, err := getRecordSchema(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("no schema registered for record type %s", recordType)
+	}
+	return schema, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const labSchema = `{
+	"type": "object",
+	"required": ["code", "status"],
+	"properties": {
+		"code": {"type": "string", "pattern": "^[0-9]{1,5}-[0-9]$"},
+		"status": {"enum": ["preliminary", "final"]},
+		"values": {"type": "array", "items": {"type": "number"}}
+	}
+}`
+
+func TestRecordSchemaValidation(t *testing.T) {
+	env := newTestEnv(t)
+	create := func(recordID, metadata string) error {
+		return env.invoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"`+recordID+`","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy",`+
+				`"contentHash":"`+strings.Repeat("a", 64)+`","recordType":"lab","metadata":`+metadata+`}`)
+			return err
+		})
+	}
+
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRecordSchema(ctx, "lab", labSchema)
+	})
+	env.mustFail(admin, "unknown field", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRecordSchema(ctx, "lab", `{"type":"object","minProperties":1}`)
+	})
+	env.mustFail(admin, "unsupported type", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRecordSchema(ctx, "lab", `{"type":"date"}`)
+	})
+	// 注册前不校验
+	if err := create("lab0", `{"anything":true}`); err != nil {
+		t.Fatalf("unregistered record types must not be validated: %v", err)
+	}
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRecordSchema(ctx, "lab", labSchema)
+	})
+	env.expectEvent("RecordSchemaRegistered", nil)
+
+	err := create("lab1", `{"code":"bad","values":[1,"x"]}`)
+	if err == nil {
+		t.Fatal("invalid metadata must be rejected")
+	}
+	for _, want := range []string{"metadata.status: required", "metadata.code: does not match pattern", "metadata.values[1]: expected number"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected field-level error %q in %v", want, err)
+		}
+	}
+	if err := create("lab1", `{"code":"2345-7","status":"draft"}`); err == nil || !strings.Contains(err.Error(), "metadata.status: not one of the allowed values") {
+		t.Fatalf("enum must be enforced, got %v", err)
+	}
+	if err := create("lab1", `{"code":"2345-7","status":"final","values":[5.4]}`); err != nil {
+		t.Fatalf("valid metadata rejected: %v", err)
+	}
+
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRecordSchema(ctx, "lab", `{"type":"object"}`)
+	})
+	env.mustInvoke(other, func(ctx contractapi.TransactionContextInterface) error {
+		schema, err := env.cc.GetRecordSchema(ctx, "lab")
+		if err == nil && (schema.Version != 2 || schema.Schema != `{"type":"object"}`) {
+			t.Fatalf("unexpected schema: %+v", schema)
+		}
+		return err
+	})
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	RecoveryPending   = "pending"
+	RecoveryCompleted = "completed"
+	RecoveryCancelled = "cancelled"
+
+	RecoveryApprovalRegistrar = "registrar"
+	RecoveryApprovalOldKey    = "old-key"
+
+	// recoveryWaitingPeriod 发起后的冷静期，期间旧证书可取消恢复
+	recoveryWaitingPeriod = 72 * time.Hour
+	// recoveryRegistrarQuorum 无旧证书签名时所需的不同登记人员人数
+	recoveryRegistrarQuorum = 2
+)
+
+type RecoveryApproval struct {
+	ApproverID   string `json:"approverId"`
+	ApprovalType string `json:"approvalType"`
+	ApprovedAt   string `json:"approvedAt"`
+}
+
+// IdentityRecovery 丢失私钥后把应用用户重新绑定到新证书；授权以应用用户 ID 为主体，完成后无需逐条迁移
+type IdentityRecovery struct {
+	RecoveryID   string             `json:"recoveryId"`
+	AppUserID    string             `json:"appUserId"`
+	NewCertID    string             `json:"newCertId"`
+	Approvals    []RecoveryApproval `json:"approvals"`
+	Status       string             `json:"status"`
+	InitiatedAt  string             `json:"initiatedAt"`
+	NotBefore    string             `json:"notBefore"`
+	UpdatedAt    string             `json:"updatedAt"`
+	RetiredCerts []string           `json:"retiredCerts,omitempty"`
+}
+
+type IdentityRecoveryEvent struct {
+	RecoveryID string `json:"recoveryId"`
+	AppUserID  string `json:"appUserId"`
+	NewCertID  string `json:"newCertId"`
+	Status     string `json:"status"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func recoveryKey(recoveryID string) string {
+	return "recovery:" + recoveryID
+}
+
+// retiredCertKey 恢复完成后旧证书的停用标记，停用证书不能再发起任何交易
+func retiredCertKey(certID string) string {
+	return "retired-cert:" + certID
+}
+
+func getRecovery(ctx contractapi.TransactionContextInterface, recoveryID string) (*IdentityRecovery, error) {
+	var recovery IdentityRecovery
+	found, err := getJSON(ctx, recoveryKey(recoveryID), &recovery)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("identity recovery not found: %s", recoveryID)
+	}
+	return &recovery, nil
+}
+
+func saveRecovery(ctx contractapi.TransactionContextInterface, recovery *IdentityRecovery, eventName, callerID string) error {
+	if err := putJSON(ctx, recoveryKey(recovery.RecoveryID), recovery); err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, IdentityRecoveryEvent{
+		RecoveryID: recovery.RecoveryID,
+		AppUserID:  recovery.AppUserID,
+		NewCertID:  recovery.NewCertID,
+		Status:     recovery.Status,
+		Timestamp:  recovery.UpdatedAt,
+		CallerID:   callerID,
+		EventType:  eventName,
+	})
+}
+
+// linkedCertificates 列出应用用户当前绑定的全部证书
+func linkedCertificates(ctx contractapi.TransactionContextInterface, appUserID string) ([]string, error) {
+	certs := []string{}
+	err := scanIndex(ctx, userCertIndex, []string{appUserID}, func(attrs []string) error {
+		certs = append(certs, attrs[1])
+		return nil
+	})
+	return certs, err
+}
+
+// recoveryApproved 登记人员审批之外，须有旧证书签名或足够人数的登记人员多方审批
+func recoveryApproved(recovery *IdentityRecovery) bool {
+	registrars, oldKey := 0, false
+	for _, approval := range recovery.Approvals {
+		switch approval.ApprovalType {
+		case RecoveryApprovalRegistrar:
+			registrars++
+		case RecoveryApprovalOldKey:
+			oldKey = true
+		}
+	}
+	return registrars >= 1 && (oldKey || registrars >= recoveryRegistrarQuorum)
+}
+
+func requirePendingRecovery(recovery *IdentityRecovery) error {
+	if recovery.Status != RecoveryPending {
+		return fmt.Errorf("identity recovery %s is %s", recovery.RecoveryID, recovery.Status)
+	}
+	return nil
+}
+
+// InitiateIdentityRecovery 登记人员完成线下身份核验后发起恢复，发起即计一份登记人员审批
+func (s *SmartContract) InitiateIdentityRecovery(ctx contractapi.TransactionContextInterface, appUserID, newCertID string) (string, error) {
+	if err := validateAddress(appUserID); err != nil {
+		return "", fmt.Errorf("invalid appUserID: %w", err)
+	}
+	if err := validateAddress(newCertID); err != nil {
+		return "", fmt.Errorf("invalid newCertID: %w", err)
+	}
+	isRegistrar, err := hasRole(ctx, "registrar")
+	if err != nil {
+		return "", err
+	}
+	if !isRegistrar {
+		return "", fmt.Errorf("access denied: only a registrar can initiate identity recovery")
+	}
+	existing, err := boundUserID(ctx, newCertID)
+	if err != nil {
+		return "", err
+	}
+	if existing != "" {
+		return "", fmt.Errorf("certificate already bound to %s", existing)
+	}
+
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	ts := now.UTC().Format(time.RFC3339)
+	recovery := &IdentityRecovery{
+		RecoveryID:  ctx.GetStub().GetTxID(),
+		AppUserID:   appUserID,
+		NewCertID:   newCertID,
+		Approvals:   []RecoveryApproval{{ApproverID: callerCert, ApprovalType: RecoveryApprovalRegistrar, ApprovedAt: ts}},
+		Status:      RecoveryPending,
+		InitiatedAt: ts,
+		NotBefore:   now.Add(recoveryWaitingPeriod).UTC().Format(time.RFC3339),
+		UpdatedAt:   ts,
+	}
+	return recovery.RecoveryID, saveRecovery(ctx, recovery, "IdentityRecoveryInitiated", callerCert)
+}
+
+// ApproveIdentityRecovery 追加审批：registrar 角色计为登记人员审批，该用户仍持有的旧证书计为旧密钥签名
+func (s *SmartContract) ApproveIdentityRecovery(ctx contractapi.TransactionContextInterface, recoveryID string) error {
+	recovery, err := getRecovery(ctx, recoveryID)
+	if err != nil {
+		return err
+	}
+	if err := requirePendingRecovery(recovery); err != nil {
+		return err
+	}
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	for _, approval := range recovery.Approvals {
+		if approval.ApproverID == callerCert {
+			return fmt.Errorf("%s has already approved this recovery", callerCert)
+		}
+	}
+
+	approvalType := ""
+	callerUser, err := boundUserID(ctx, callerCert)
+	if err != nil {
+		return err
+	}
+	if callerUser == recovery.AppUserID || callerCert == recovery.AppUserID {
+		approvalType = RecoveryApprovalOldKey
+	} else {
+		isRegistrar, err := hasRole(ctx, "registrar")
+		if err != nil {
+			return err
+		}
+		if isRegistrar {
+			approvalType = RecoveryApprovalRegistrar
+		}
+	}
+	if approvalType == "" {
+		return fmt.Errorf("access denied: only a registrar or the user's existing certificate can approve recovery")
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	recovery.Approvals = append(recovery.Approvals, RecoveryApproval{ApproverID: callerCert, ApprovalType: approvalType, ApprovedAt: now})
+	recovery.UpdatedAt = now
+	return saveRecovery(ctx, recovery, "IdentityRecoveryApproved", callerCert)
+}
+
+// CancelIdentityRecovery 冷静期内由该用户仍持有的证书取消恢复（例如并非本人发起）
+func (s *SmartContract) CancelIdentityRecovery(ctx contractapi.TransactionContextInterface, recoveryID string) error {
+	recovery, err := getRecovery(ctx, recoveryID)
+	if err != nil {
+		return err
+	}
+	if err := requirePendingRecovery(recovery); err != nil {
+		return err
+	}
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	callerUser, err := boundUserID(ctx, callerCert)
+	if err != nil {
+		return err
+	}
+	if callerUser != recovery.AppUserID && callerCert != recovery.AppUserID {
+		return fmt.Errorf("access denied: only the user's existing certificate can cancel recovery")
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	recovery.Status = RecoveryCancelled
+	recovery.UpdatedAt = now
+	return saveRecovery(ctx, recovery, "IdentityRecoveryCancelled", callerCert)
+}
+
+// FinalizeIdentityRecovery 冷静期满且审批齐全后，解除旧证书绑定并停用旧证书，把新证书绑定到该用户
+func (s *SmartContract) FinalizeIdentityRecovery(ctx contractapi.TransactionContextInterface, recoveryID string) error {
+	recovery, err := getRecovery(ctx, recoveryID)
+	if err != nil {
+		return err
+	}
+	if err := requirePendingRecovery(recovery); err != nil {
+		return err
+	}
+	callerCert, err := callerCertID(ctx)
+	if err != nil {
+		return err
+	}
+	isRegistrar, err := hasRole(ctx, "registrar")
+	if err != nil {
+		return err
+	}
+	if callerCert != recovery.NewCertID && !isRegistrar {
+		return fmt.Errorf("access denied: only the new certificate or a registrar can finalize recovery")
+	}
+	if !recoveryApproved(recovery) {
+		return fmt.Errorf("identity recovery %s requires an old-key approval or %d registrar approvals", recoveryID, recoveryRegistrarQuorum)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	notBefore, err := time.Parse(time.RFC3339, recovery.NotBefore)
+	if err != nil {
+		return fmt.Errorf("invalid stored notBefore: %w", err)
+	}
+	if now.Before(notBefore) {
+		return fmt.Errorf("identity recovery %s is in its waiting period until %s", recoveryID, recovery.NotBefore)
+	}
+	existing, err := boundUserID(ctx, recovery.NewCertID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return fmt.Errorf("certificate already bound to %s", existing)
+	}
+
+	oldCerts, err := linkedCertificates(ctx, recovery.AppUserID)
+	if err != nil {
+		return err
+	}
+	for _, certID := range oldCerts {
+		if _, err := removeBinding(ctx, certID, callerCert); err != nil {
+			return err
+		}
+	}
+	// 未做绑定的旧用户直接以证书 ID 作为 appUserId，该证书同样停用
+	retired := append(oldCerts, recovery.AppUserID)
+	for _, certID := range retired {
+		if err := ctx.GetStub().PutState(retiredCertKey(certID), []byte(recovery.RecoveryID)); err != nil {
+			return fmt.Errorf("failed to retire certificate: %w", err)
+		}
+	}
+	if err := writeBinding(ctx, recovery.AppUserID, recovery.NewCertID, callerCert); err != nil {
+		return err
+	}
+
+	recovery.Status = RecoveryCompleted
+	recovery.RetiredCerts = retired
+	recovery.UpdatedAt = now.UTC().Format(time.RFC3339)
+	return saveRecovery(ctx, recovery, "IdentityRecovered", callerCert)
+}
+
+// GetIdentityRecovery 返回恢复流程及审批轨迹
+func (s *SmartContract) GetIdentityRecovery(ctx contractapi.TransactionContextInterface, recoveryID string) (*IdentityRecovery, error) {
+	return getRecovery(ctx, recoveryID)
+}
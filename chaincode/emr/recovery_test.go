@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	registrar  = newIdentity("registrar1", "Org1MSP", "role", "registrar")
+	registrar2 = newIdentity("registrar2", "Org2MSP", "role", "registrar")
+	aliceNew   = newIdentity("eDUwOTo6Q049YWxpY2UtbmV3LE9VPWNsaWVudDo6Q049Y2Eub3JnMQ==", "Org1MSP")
+)
+
+func setupRecovery(t *testing.T) (*testEnv, string) {
+	env := newTestEnv(t)
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.BindIdentity(ctx, "patient-alice", aliceCert.id)
+	})
+	env.createRecord(doctor, "rec1", "patient-alice")
+	env.grant(aliceCert, "rec1", nurse.id, "read", "")
+
+	env.mustFail(aliceNew, "only a registrar", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.InitiateIdentityRecovery(ctx, "patient-alice", aliceNew.id)
+		return err
+	})
+	var recoveryID string
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		recoveryID, err = env.cc.InitiateIdentityRecovery(ctx, "patient-alice", aliceNew.id)
+		return err
+	})
+	env.expectEvent("IdentityRecoveryInitiated", nil)
+	return env, recoveryID
+}
+
+func finalizeRecovery(env *testEnv, recoveryID string) error {
+	return env.invoke(aliceNew, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FinalizeIdentityRecovery(ctx, recoveryID)
+	})
+}
+
+func TestIdentityRecoveryWithRegistrarQuorum(t *testing.T) {
+	env, recoveryID := setupRecovery(t)
+
+	env.advance(73 * time.Hour)
+	env.mustFail(aliceNew, "requires an old-key approval", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FinalizeIdentityRecovery(ctx, recoveryID)
+	})
+	env.mustFail(registrar, "already approved", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveIdentityRecovery(ctx, recoveryID)
+	})
+	env.mustFail(other, "only a registrar or the user's existing certificate", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveIdentityRecovery(ctx, recoveryID)
+	})
+	env.mustInvoke(registrar2, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveIdentityRecovery(ctx, recoveryID)
+	})
+	if err := finalizeRecovery(env, recoveryID); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	env.expectEvent("IdentityRecovered", nil)
+
+	// 授权以应用用户 ID 为主体，新证书直接继承记录所有权
+	if !env.checkAccess("rec1", aliceNew.id) || !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("new certificate and existing grants must carry over")
+	}
+	env.mustFail(aliceCert, "retired by identity recovery", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustInvoke(registrar, func(ctx contractapi.TransactionContextInterface) error {
+		recovery, err := env.cc.GetIdentityRecovery(ctx, recoveryID)
+		if err == nil && (recovery.Status != RecoveryCompleted || len(recovery.Approvals) != 2) {
+			t.Fatalf("unexpected recovery: %+v", recovery)
+		}
+		return err
+	})
+}
+
+func TestIdentityRecoveryWaitingPeriodAndOldKey(t *testing.T) {
+	env, recoveryID := setupRecovery(t)
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ApproveIdentityRecovery(ctx, recoveryID)
+	})
+	if err := finalizeRecovery(env, recoveryID); err == nil {
+		t.Fatal("recovery must not complete during the waiting period")
+	}
+	env.advance(72 * time.Hour)
+	if err := finalizeRecovery(env, recoveryID); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+}
+
+func TestCancelIdentityRecovery(t *testing.T) {
+	env, recoveryID := setupRecovery(t)
+	env.mustFail(registrar2, "only the user's existing certificate", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelIdentityRecovery(ctx, recoveryID)
+	})
+	env.mustInvoke(aliceCert, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelIdentityRecovery(ctx, recoveryID)
+	})
+	env.expectEvent("IdentityRecoveryCancelled", nil)
+	env.advance(73 * time.Hour)
+	env.mustFail(aliceNew, "is cancelled", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FinalizeIdentityRecovery(ctx, recoveryID)
+	})
+	if !env.checkAccess("rec1", aliceCert.id) {
+		t.Fatal("cancelled recovery must leave the original certificate intact")
+	}
+}
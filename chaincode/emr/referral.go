@@ -0,0 +1,421 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ReferralCreated   = "created"
+	ReferralAccepted  = "accepted"
+	ReferralCompleted = "completed"
+	ReferralExpired   = "expired"
+	ReferralCancelled = "cancelled"
+)
+
+// maxReferralGrantHours 授权包单条授权的最长时长
+const maxReferralGrantHours = 720
+
+// referralPatientIndex 患者 → 转诊索引，供患者汇总列出待处理的转诊
+const referralPatientIndex = "referral~patient"
+
+// ReferralGrantBundle 授权包：接受时按记录类型选出患者记录授权接收方，时长自接受起计且不超过转诊过期时间
+type ReferralGrantBundle struct {
+	RecordTypes   []string `json:"recordTypes"`
+	DurationHours int      `json:"durationHours"`
+}
+
+// Referral 转诊；接受后接收方获得被引用记录与授权包记录的限时 read 授权，转诊关闭时撤销
+type Referral struct {
+	ReferralID       string               `json:"referralId"`
+	PatientID        string               `json:"patientId"`
+	FromProviderID   string               `json:"fromProviderId"`
+	ToProviderID     string               `json:"toProviderId"`
+	RecordIDs        []string             `json:"recordIds,omitempty"`
+	GrantBundle      *ReferralGrantBundle `json:"grantBundle,omitempty"`
+	Reason           string               `json:"reason,omitempty"`
+	Status           string               `json:"status"`
+	ExpiresAt        string               `json:"expiresAt"`
+	GrantedRecordIDs []string             `json:"grantedRecordIds,omitempty"`
+	CreatedAt        string               `json:"createdAt"`
+	UpdatedAt        string               `json:"updatedAt"`
+}
+
+type ReferralEvent struct {
+	ReferralID     string `json:"referralId"`
+	PatientID      string `json:"patientId"`
+	FromProviderID string `json:"fromProviderId"`
+	ToProviderID   string `json:"toProviderId"`
+	Status         string `json:"status"`
+	Timestamp      string `json:"timestamp"`
+	CallerID       string `json:"callerId"`
+	EventType      string `json:"eventType"`
+}
+
+func referralKey(referralID string) string {
+	return "referral:" + referralID
+}
+
+// referralGrantor 转诊授权的 grantedBy 标记，撤销时只处理带此标记的授权
+func referralGrantor(referralID string) string {
+	return "referral:" + referralID
+}
+
+func getReferral(ctx contractapi.TransactionContextInterface, referralID string) (*Referral, error) {
+	var referral Referral
+	found, err := getJSON(ctx, referralKey(referralID), &referral)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("referral not found: %s", referralID)
+	}
+	return &referral, nil
+}
+
+// referralExpired 以交易时间判断转诊是否过期
+func referralExpired(referral *Referral, now time.Time) (bool, error) {
+	expiresAt, err := time.Parse(time.RFC3339, referral.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("invalid expiresAt on referral: %w", err)
+	}
+	return !now.Before(expiresAt), nil
+}
+
+func validateGrantBundle(bundle *ReferralGrantBundle) error {
+	if len(bundle.RecordTypes) == 0 {
+		return fmt.Errorf("grantBundle.recordTypes must not be empty")
+	}
+	for _, recordType := range bundle.RecordTypes {
+		if !recordTypePattern.MatchString(recordType) {
+			return fmt.Errorf("invalid recordType in grantBundle: %q", recordType)
+		}
+	}
+	if bundle.DurationHours < 1 || bundle.DurationHours > maxReferralGrantHours {
+		return fmt.Errorf("grantBundle.durationHours must be between 1 and %d", maxReferralGrantHours)
+	}
+	return nil
+}
+
+// CreateReferral 转出方发起转诊；须对每条引用记录持有 share 及以上权限。
+// 授权包的记录在接受时选出，只包含转出方届时仍可 share 的记录
+func (s *SmartContract) CreateReferral(ctx contractapi.TransactionContextInterface, referralJson string) error {
+	var referral Referral
+	if err := unmarshalArg(referralJson, &referral); err != nil {
+		return fmt.Errorf("invalid referral json: %w", err)
+	}
+	if referral.ReferralID == "" || referral.PatientID == "" || referral.ToProviderID == "" || referral.ExpiresAt == "" || (len(referral.RecordIDs) == 0 && referral.GrantBundle == nil) {
+		return fmt.Errorf("missing required fields: referralId, patientId, toProviderId, expiresAt and recordIds or grantBundle are required")
+	}
+	if referral.GrantBundle != nil {
+		if err := validateGrantBundle(referral.GrantBundle); err != nil {
+			return err
+		}
+	}
+	for _, id := range []string{referral.ReferralID, referral.PatientID, referral.ToProviderID} {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expired, err := referralExpired(&referral, now)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if referral.FromProviderID != "" && referral.FromProviderID != callerID {
+		return fmt.Errorf("fromProviderId must match the caller")
+	}
+	if referral.ToProviderID == callerID {
+		return fmt.Errorf("cannot refer to yourself")
+	}
+	for _, recordID := range referral.RecordIDs {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if record.PatientID != referral.PatientID {
+			return fmt.Errorf("record %s does not belong to patient %s", recordID, referral.PatientID)
+		}
+		if err := requireSinglePartyGrant(record); err != nil {
+			return err
+		}
+		allowed, err := s.ValidatePermissionLevel(ctx, recordID, callerID, "share")
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("access denied: %s cannot share record %s", callerID, recordID)
+		}
+	}
+	exists, err := assetExists(ctx, referralKey(referral.ReferralID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("referral already exists: %s", referral.ReferralID)
+	}
+
+	referral.FromProviderID = callerID
+	referral.Status = ReferralCreated
+	referral.GrantedRecordIDs = nil
+	referral.CreatedAt = now.Format(time.RFC3339)
+	referral.UpdatedAt = referral.CreatedAt
+	if err := putJSON(ctx, referralKey(referral.ReferralID), referral); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, referralPatientIndex, referral.PatientID, referral.ReferralID); err != nil {
+		return err
+	}
+	return emitReferralEvent(ctx, "ReferralCreated", &referral, callerID)
+}
+
+// grantReferralRecord 为接收方写入一条转诊 read 授权；接收方已持有其他有效授权的记录保持不变
+func grantReferralRecord(ctx contractapi.TransactionContextInterface, referral *Referral, record *MedicalRecord, expiresAt string, now time.Time) error {
+	existing, err := getPermission(ctx, record.RecordID, referral.ToProviderID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && grantCovers(*existing, now, accessTarget{}) {
+		return nil
+	}
+	perm := AccessPermission{
+		RecordID:  record.RecordID,
+		GranteeID: referral.ToProviderID,
+		Action:    "read",
+		ExpiresAt: expiresAt,
+		GrantedAt: now.Format(time.RFC3339),
+		GrantedBy: referralGrantor(referral.ReferralID),
+		IsActive:  true,
+	}
+	if err := storeGrant(ctx, record, perm); err != nil {
+		return err
+	}
+	referral.GrantedRecordIDs = append(referral.GrantedRecordIDs, record.RecordID)
+	return nil
+}
+
+// grantBundleRecords 经 patient~record 索引选出授权包类型的患者记录；
+// 跳过已引用、已归档、高敏以及转出方无法 share 的记录
+func (s *SmartContract) grantBundleRecords(ctx contractapi.TransactionContextInterface, referral *Referral) ([]*MedicalRecord, error) {
+	records := []*MedicalRecord{}
+	err := scanIndex(ctx, patientRecordIndex, []string{referral.PatientID}, func(attrs []string) error {
+		if containsString(referral.RecordIDs, attrs[1]) {
+			return nil
+		}
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		if !listable(record) || record.Sensitivity == SensitivityHigh || !containsString(referral.GrantBundle.RecordTypes, record.RecordType) {
+			return nil
+		}
+		allowed, err := s.ValidatePermissionLevel(ctx, record.RecordID, referral.FromProviderID, "share")
+		if err != nil {
+			return err
+		}
+		if allowed {
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// AcceptReferral 接收方接受转诊，为其写入引用记录的 read 授权，过期时间取转诊过期时间；
+// 授权包记录的授权在 durationHours 与转诊过期时间中较早者失效
+func (s *SmartContract) AcceptReferral(ctx contractapi.TransactionContextInterface, referralID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	referral, err := getReferral(ctx, referralID)
+	if err != nil {
+		return err
+	}
+	if callerID != referral.ToProviderID {
+		return fmt.Errorf("access denied: only the receiving provider can accept")
+	}
+	if referral.Status != ReferralCreated {
+		return fmt.Errorf("referral is not awaiting acceptance: %s", referral.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expired, err := referralExpired(referral, now)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return fmt.Errorf("referral expired at %s", referral.ExpiresAt)
+	}
+	// 过期时间已在 referralExpired 中校验
+	referralExpiry, _ := time.Parse(time.RFC3339, referral.ExpiresAt)
+
+	for _, recordID := range referral.RecordIDs {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if err := grantReferralRecord(ctx, referral, record, referral.ExpiresAt, now); err != nil {
+			return err
+		}
+	}
+	if referral.GrantBundle != nil {
+		records, err := s.grantBundleRecords(ctx, referral)
+		if err != nil {
+			return err
+		}
+		expiresAt := capExpiresAt(now.Add(time.Duration(referral.GrantBundle.DurationHours)*time.Hour).Format(time.RFC3339), referralExpiry)
+		for _, record := range records {
+			if err := grantReferralRecord(ctx, referral, record, expiresAt, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	referral.Status = ReferralAccepted
+	referral.UpdatedAt = now.Format(time.RFC3339)
+	if err := putJSON(ctx, referralKey(referralID), referral); err != nil {
+		return err
+	}
+	return emitReferralEvent(ctx, "ReferralAccepted", referral, callerID)
+}
+
+// revokeReferralGrants 撤销本转诊写入且未被替换的授权
+func revokeReferralGrants(ctx contractapi.TransactionContextInterface, referral *Referral, now string) error {
+	for _, recordID := range referral.GrantedRecordIDs {
+		perm, err := getPermission(ctx, recordID, referral.ToProviderID)
+		if err != nil {
+			return err
+		}
+		if perm == nil || perm.GrantedBy != referralGrantor(referral.ReferralID) {
+			continue
+		}
+		if _, err := deactivateGrant(ctx, recordID, referral.ToProviderID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompleteReferral 接收方完成转诊，撤销本转诊写入且未被替换的授权
+func (s *SmartContract) CompleteReferral(ctx contractapi.TransactionContextInterface, referralID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	referral, err := getReferral(ctx, referralID)
+	if err != nil {
+		return err
+	}
+	if callerID != referral.ToProviderID {
+		return fmt.Errorf("access denied: only the receiving provider can complete")
+	}
+	if referral.Status != ReferralAccepted {
+		return fmt.Errorf("referral is not accepted: %s", referral.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := revokeReferralGrants(ctx, referral, now); err != nil {
+		return err
+	}
+
+	referral.Status = ReferralCompleted
+	referral.UpdatedAt = now
+	if err := putJSON(ctx, referralKey(referralID), referral); err != nil {
+		return err
+	}
+	return emitReferralEvent(ctx, "ReferralCompleted", referral, callerID)
+}
+
+// CancelReferral 转出方或患者取消未完成的转诊，已接受的转诊同时撤销其授权
+func (s *SmartContract) CancelReferral(ctx contractapi.TransactionContextInterface, referralID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	referral, err := getReferral(ctx, referralID)
+	if err != nil {
+		return err
+	}
+	if callerID != referral.FromProviderID && callerID != referral.PatientID {
+		return fmt.Errorf("access denied: only the referring provider or the patient can cancel")
+	}
+	if referral.Status != ReferralCreated && referral.Status != ReferralAccepted {
+		return fmt.Errorf("referral is already closed: %s", referral.Status)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := revokeReferralGrants(ctx, referral, now); err != nil {
+		return err
+	}
+
+	referral.Status = ReferralCancelled
+	referral.UpdatedAt = now
+	if err := putJSON(ctx, referralKey(referralID), referral); err != nil {
+		return err
+	}
+	return emitReferralEvent(ctx, "ReferralCancelled", referral, callerID)
+}
+
+// GetReferral 查询转诊；患者与双方机构可读，过期未完成的转诊以 expired 返回
+func (s *SmartContract) GetReferral(ctx contractapi.TransactionContextInterface, referralID string) (*Referral, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referral, err := getReferral(ctx, referralID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString([]string{referral.PatientID, referral.FromProviderID, referral.ToProviderID}, callerID) {
+		return nil, fmt.Errorf("access denied: %s cannot view referral %s", callerID, referralID)
+	}
+	if referral.Status == ReferralCreated || referral.Status == ReferralAccepted {
+		now, err := txTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+		expired, err := referralExpired(referral, now)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			referral.Status = ReferralExpired
+		}
+	}
+	return referral, nil
+}
+
+func emitReferralEvent(ctx contractapi.TransactionContextInterface, name string, referral *Referral, callerID string) error {
+	return emitEvent(ctx, name, ReferralEvent{
+		ReferralID:     referral.ReferralID,
+		PatientID:      referral.PatientID,
+		FromProviderID: referral.FromProviderID,
+		ToProviderID:   referral.ToProviderID,
+		Status:         referral.Status,
+		Timestamp:      referral.UpdatedAt,
+		CallerID:       callerID,
+		EventType:      name,
+	})
+}
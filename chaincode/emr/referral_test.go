@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var specialist = newIdentity("specialist1", "Org2MSP", "role", "doctor")
+
+func createTestReferral(env *testEnv, referralID string, validFor time.Duration, recordIDs string) {
+	env.t.Helper()
+	body := fmt.Sprintf(`{"referralId":%q,"patientId":"patient1","toProviderId":"specialist1","recordIds":%s,"expiresAt":%q}`,
+		referralID, recordIDs, env.stub.now.Add(validFor).Format(time.RFC3339))
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateReferral(ctx, body)
+	})
+}
+
+func TestReferralGrantsAndRevokesAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	// 患者已直接授予 rec2，转诊完成后不应被撤销
+	env.grant(patient, "rec2", specialist.id, "write", "")
+
+	createTestReferral(env, "ref1", 7*24*time.Hour, `["rec1","rec2"]`)
+	env.expectEvent("ReferralCreated", nil)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("receiver must not have access before acceptance")
+	}
+
+	env.mustFail(doctor, "only the receiving provider", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AcceptReferral(ctx, "ref1")
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AcceptReferral(ctx, "ref1")
+	})
+	env.expectEvent("ReferralAccepted", nil)
+	if !env.checkAccess("rec1", specialist.id) {
+		t.Fatal("receiver must read cited records after acceptance")
+	}
+
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CompleteReferral(ctx, "ref1")
+	})
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("referral grant must be revoked on completion")
+	}
+	if !env.checkAccess("rec2", specialist.id) {
+		t.Fatal("pre-existing direct grant must survive completion")
+	}
+}
+
+func TestReferralExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	createTestReferral(env, "ref1", 24*time.Hour, `["rec1"]`)
+	createTestReferral(env, "ref2", 48*time.Hour, `["rec1"]`)
+
+	env.mustFail(other, "cannot share record", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateReferral(ctx, `{"referralId":"ref3","patientId":"patient1","toProviderId":"specialist1","recordIds":["rec1"],"expiresAt":"2027-01-01T00:00:00Z"}`)
+	})
+
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AcceptReferral(ctx, "ref2")
+	})
+	env.advance(30 * time.Hour)
+	env.mustFail(specialist, "expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.AcceptReferral(ctx, "ref1")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		referral, err := env.cc.GetReferral(ctx, "ref1")
+		if err == nil && referral.Status != ReferralExpired {
+			t.Fatalf("expected expired status, got %s", referral.Status)
+		}
+		return err
+	})
+
+	// 授权随转诊过期自动失效
+	env.advance(24 * time.Hour)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("referral grant must lapse at referral expiry")
+	}
+}
+
+func TestReferralGrantBundle(t *testing.T) {
+	env := newTestEnv(t)
+	env.createTypedRecord("lab1", "lab")
+	env.createTypedRecord("lab2", "lab")
+	env.createTypedRecord("note1", "note")
+	create := func(referralID, bundle string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.CreateReferral(ctx, fmt.Sprintf(`{"referralId":%q,"patientId":"patient1","toProviderId":"specialist1","grantBundle":%s,"expiresAt":%q}`,
+				referralID, bundle, env.stub.now.Add(7*24*time.Hour).Format(time.RFC3339)))
+		}
+	}
+	accept := func(referralID string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.AcceptReferral(ctx, referralID)
+		}
+	}
+	env.mustFail(doctor, "durationHours must be between", create("ref1", `{"recordTypes":["lab"],"durationHours":0}`))
+	env.mustFail(doctor, "recordTypes must not be empty", create("ref1", `{"recordTypes":[],"durationHours":24}`))
+	env.mustInvoke(doctor, create("ref1", `{"recordTypes":["lab"],"durationHours":48}`))
+	env.mustInvoke(doctor, create("ref2", `{"recordTypes":["lab"],"durationHours":24}`))
+
+	env.mustInvoke(specialist, accept("ref1"))
+	if !env.checkAccess("lab1", specialist.id) || !env.checkAccess("lab2", specialist.id) || env.checkAccess("note1", specialist.id) {
+		t.Fatal("acceptance must grant exactly the bundled record types")
+	}
+	if perm := storedPermission(env, "lab1", specialist.id); perm.GrantedBy != referralGrantor("ref1") || perm.ExpiresAt != env.stub.now.Add(48*time.Hour).Format(time.RFC3339) {
+		t.Fatalf("unexpected bundle grant: %+v", perm)
+	}
+
+	cancel := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelReferral(ctx, "ref1")
+	}
+	env.mustFail(specialist, "only the referring provider or the patient", cancel)
+	env.mustInvoke(patient, cancel)
+	env.expectEvent("ReferralCancelled", nil)
+	env.mustFail(patient, "already closed", cancel)
+	if env.checkAccess("lab1", specialist.id) || env.checkAccess("lab2", specialist.id) {
+		t.Fatal("cancelling the referral must revoke its grants")
+	}
+
+	// 授权包时长短于转诊有效期时按时长失效
+	env.mustInvoke(specialist, accept("ref2"))
+	env.advance(25 * time.Hour)
+	if env.checkAccess("lab1", specialist.id) {
+		t.Fatal("bundle grants must lapse after durationHours")
+	}
+}
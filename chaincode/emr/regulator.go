@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// regulatorRole 卫生主管部门等监管身份，证书属性 role=regulator
+	regulatorRole = "regulator"
+
+	maxRegulatorHours   = 72
+	maxRegulatorRecords = 50
+)
+
+// RegulatorAccess 监管检查的限时访问：不是授权条目，不能转授、不进入访问列表，
+// 只在 ReadRecord/ReadRecordAudited 中由持有者本人使用，每次使用都写入带法律依据的披露条目并通知患者
+type RegulatorAccess struct {
+	RecordID       string `json:"recordId"`
+	PatientID      string `json:"patientId"`
+	RegulatorID    string `json:"regulatorId"`
+	MspID          string `json:"mspId"`
+	StatutoryBasis string `json:"statutoryBasis"`
+	CaseRef        string `json:"caseRef"`
+	GrantedAt      string `json:"grantedAt"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+type RegulatorAccessOpenedEvent struct {
+	RegulatorID    string   `json:"regulatorId"`
+	RecordIDs      []string `json:"recordIds"`
+	PatientIDs     []string `json:"patientIds"`
+	StatutoryBasis string   `json:"statutoryBasis"`
+	CaseRef        string   `json:"caseRef"`
+	ExpiresAt      string   `json:"expiresAt"`
+	Notify         bool     `json:"notify"`
+	Timestamp      string   `json:"timestamp"`
+	EventType      string   `json:"eventType"`
+}
+
+// RegulatorAccessedEvent Notify 为 true，通知服务须告知患者
+type RegulatorAccessedEvent struct {
+	RecordID       string `json:"recordId"`
+	PatientID      string `json:"patientId"`
+	RegulatorID    string `json:"regulatorId"`
+	StatutoryBasis string `json:"statutoryBasis"`
+	CaseRef        string `json:"caseRef"`
+	PurposeOfUse   string `json:"purposeOfUse,omitempty"`
+	Notify         bool   `json:"notify"`
+	Timestamp      string `json:"timestamp"`
+	EventType      string `json:"eventType"`
+}
+
+// regulatorRead 调用者持有效监管访问时写入增强审计并返回 true；普通访问检查未通过后才调用
+func regulatorRead(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID, purpose string) (bool, error) {
+	access, err := activeRegulatorAccess(ctx, record.RecordID, callerID)
+	if err != nil || access == nil {
+		return false, err
+	}
+	return true, recordRegulatorRead(ctx, record, access, purpose)
+}
+
+// regulatorAccessKey regaccess:{recordId}:{regulatorId}
+func regulatorAccessKey(recordID, regulatorID string) string {
+	return "regaccess:" + keySegment(recordID) + ":" + keySegment(regulatorID)
+}
+
+// activeRegulatorAccess 调用者仍持 regulator 角色且对记录有未过期的监管访问时返回该条目
+func activeRegulatorAccess(ctx contractapi.TransactionContextInterface, recordID, callerID string) (*RegulatorAccess, error) {
+	isRegulator, err := hasRole(ctx, regulatorRole)
+	if err != nil || !isRegulator {
+		return nil, err
+	}
+	var access RegulatorAccess
+	found, err := getJSON(ctx, regulatorAccessKey(recordID, callerID), &access)
+	if err != nil || !found {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, access.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return nil, nil
+	}
+	return &access, nil
+}
+
+// recordRegulatorRead 监管读取的增强审计：披露条目带法律依据与案件号，并以 RegulatorAccessed 替代 RecordAccessed
+func recordRegulatorRead(ctx contractapi.TransactionContextInterface, record *MedicalRecord, access *RegulatorAccess, purpose string) error {
+	if err := countAccess(ctx, record.RecordID, false); err != nil {
+		return err
+	}
+	if err := recordDisclosure(ctx, Disclosure{
+		RecordID:       record.RecordID,
+		PatientID:      record.PatientID,
+		AccessorID:     access.RegulatorID,
+		PurposeOfUse:   purpose,
+		StatutoryBasis: access.StatutoryBasis,
+		CaseRef:        access.CaseRef,
+	}); err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RegulatorAccessed", RegulatorAccessedEvent{
+		RecordID:       record.RecordID,
+		PatientID:      record.PatientID,
+		RegulatorID:    access.RegulatorID,
+		StatutoryBasis: access.StatutoryBasis,
+		CaseRef:        access.CaseRef,
+		PurposeOfUse:   purpose,
+		Notify:         true,
+		Timestamp:      now,
+		EventType:      "RegulatorAccessed",
+	})
+}
+
+// RegulatorAccess 监管身份凭法律依据与案件号开启对 recordIdsJson 中记录的限时访问（1–72 小时）；
+// 再次调用覆盖原条目，有效期从本次交易起算
+func (s *SmartContract) RegulatorAccess(ctx contractapi.TransactionContextInterface, recordIdsJson, statutoryBasis, caseRef string, durationHours int) error {
+	if statutoryBasis == "" || caseRef == "" {
+		return fmt.Errorf("statutoryBasis and caseRef are required")
+	}
+	if durationHours < 1 || durationHours > maxRegulatorHours {
+		return fmt.Errorf("durationHours must be between 1 and %d", maxRegulatorHours)
+	}
+	var recordIDs []string
+	if err := unmarshalArg(recordIdsJson, &recordIDs); err != nil {
+		return fmt.Errorf("failed to unmarshal record IDs: %w", err)
+	}
+	if len(recordIDs) == 0 || len(recordIDs) > maxRegulatorRecords {
+		return fmt.Errorf("recordIDs must contain between 1 and %d records", maxRegulatorRecords)
+	}
+	isRegulator, err := hasRole(ctx, regulatorRole)
+	if err != nil {
+		return err
+	}
+	if !isRegulator {
+		return fmt.Errorf("access denied: only a regulator can open regulator access")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	grantedAt := now.Format(time.RFC3339)
+	expiresAt := now.Add(time.Duration(durationHours) * time.Hour).Format(time.RFC3339)
+
+	patientIDs := []string{}
+	for i, recordID := range recordIDs {
+		if containsString(recordIDs[:i], recordID) {
+			return fmt.Errorf("duplicate record: %s", recordID)
+		}
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if err := putJSON(ctx, regulatorAccessKey(recordID, callerID), RegulatorAccess{
+			RecordID:       recordID,
+			PatientID:      record.PatientID,
+			RegulatorID:    callerID,
+			MspID:          mspID,
+			StatutoryBasis: statutoryBasis,
+			CaseRef:        caseRef,
+			GrantedAt:      grantedAt,
+			ExpiresAt:      expiresAt,
+		}); err != nil {
+			return err
+		}
+		if !containsString(patientIDs, record.PatientID) {
+			patientIDs = append(patientIDs, record.PatientID)
+		}
+	}
+	return emitEvent(ctx, "RegulatorAccessOpened", RegulatorAccessOpenedEvent{
+		RegulatorID:    callerID,
+		RecordIDs:      recordIDs,
+		PatientIDs:     patientIDs,
+		StatutoryBasis: statutoryBasis,
+		CaseRef:        caseRef,
+		ExpiresAt:      expiresAt,
+		Notify:         true,
+		Timestamp:      grantedAt,
+		EventType:      "RegulatorAccessOpened",
+	})
+}
+
+// GetRegulatorAccess 返回监管访问条目（含已过期的），供患者与审计核对
+func (s *SmartContract) GetRegulatorAccess(ctx contractapi.TransactionContextInterface, recordID, regulatorID string) (*RegulatorAccess, error) {
+	var access RegulatorAccess
+	found, err := getJSON(ctx, regulatorAccessKey(recordID, regulatorID), &access)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no regulator access for %s on record %s", regulatorID, recordID)
+	}
+	return &access, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var regulator = newIdentity("inspector1", "HealthAuthorityMSP", "role", "regulator")
+
+func TestRegulatorAccessIsTimeBoxedAndAudited(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.stub.now.Format(time.RFC3339)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+
+	open := func(identity *testIdentity, recordIDs string, hours int) error {
+		return env.invoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.RegulatorAccess(ctx, recordIDs, "Public Health Act s.12", "CASE-2026-041", hours)
+		})
+	}
+	if err := open(doctor, `["rec1"]`, 24); err == nil {
+		t.Fatal("only regulators can open regulator access")
+	}
+	if err := open(regulator, `["rec1"]`, maxRegulatorHours+1); err == nil {
+		t.Fatal("duration must be capped")
+	}
+	if err := open(regulator, `["rec1","rec1"]`, 24); err == nil {
+		t.Fatal("duplicate records must be rejected")
+	}
+	env.mustFail(regulator, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+
+	if err := open(regulator, `["rec1","rec2"]`, 24); err != nil {
+		t.Fatal(err)
+	}
+	var opened RegulatorAccessOpenedEvent
+	env.expectEvent("RegulatorAccessOpened", &opened)
+	if len(opened.RecordIDs) != 2 || len(opened.PatientIDs) != 1 || !opened.Notify {
+		t.Fatalf("unexpected event: %+v", opened)
+	}
+	if env.checkAccess("rec1", regulator.id) {
+		t.Fatal("regulator access must not appear as a grant")
+	}
+
+	env.mustInvoke(regulator, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	var accessed RegulatorAccessedEvent
+	env.expectEvent("RegulatorAccessed", &accessed)
+	if accessed.PatientID != patient.id || accessed.CaseRef != "CASE-2026-041" || !accessed.Notify {
+		t.Fatalf("unexpected event: %+v", accessed)
+	}
+	if result := env.auditedRead(regulator, "rec2"); !result.Allowed {
+		t.Fatalf("regulator audited read must be allowed: %+v", result)
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		report, err := env.cc.GetDisclosureReport(ctx, patient.id, from, env.stub.now.Format(time.RFC3339), 10, "")
+		if err == nil && (len(report.Entries) != 2 || report.Entries[0].StatutoryBasis != "Public Health Act s.12" || report.Entries[0].MspID != regulator.msp) {
+			t.Fatalf("regulator reads must be enriched disclosures: %+v", report.Entries)
+		}
+		return err
+	})
+
+	// 不可转授：监管访问不是授权条目
+	env.mustFail(regulator, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithExpiry(ctx, "rec1", other.id, "read", "")
+	})
+
+	env.advance(24 * time.Hour)
+	env.mustFail(regulator, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		access, err := env.cc.GetRegulatorAccess(ctx, "rec1", regulator.id)
+		if err == nil && access.CaseRef != "CASE-2026-041" {
+			t.Fatalf("unexpected entry: %+v", access)
+		}
+		return err
+	})
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	CohortSufficient   = "sufficient"
+	CohortInsufficient = "insufficient"
+
+	maxConsentCodes = 50
+)
+
+// conditionCodePattern ICD-10、SNOMED CT 等诊断编码，只校验字符集与长度
+var conditionCodePattern = regexp.MustCompile(`^[A-Za-z0-9.-]{1,20}$`)
+
+// ResearchConsent 患者对一项研究的同意：RecordTypes 为空表示全部类型；ConditionCodes 为患者同意纳入的诊断，
+// 按诊断筛选的计数只统计列出该诊断的同意
+type ResearchConsent struct {
+	PatientID      string   `json:"patientId"`
+	StudyID        string   `json:"studyId"`
+	RecordTypes    []string `json:"recordTypes,omitempty"`
+	ConditionCodes []string `json:"conditionCodes,omitempty"`
+	ExpiresAt      string   `json:"expiresAt,omitempty"`
+	GivenBy        string   `json:"givenBy"`
+	GivenAt        string   `json:"givenAt"`
+	WithdrawnAt    string   `json:"withdrawnAt,omitempty"`
+}
+
+// CohortCriteria 计数条件：recordTypes 须全部在同意范围内，conditionCodes 命中任一即可
+type CohortCriteria struct {
+	StudyID        string   `json:"studyId"`
+	RecordTypes    []string `json:"recordTypes,omitempty"`
+	ConditionCodes []string `json:"conditionCodes,omitempty"`
+}
+
+// CohortSize 结果为 insufficient 时不返回数量；RemainingBudget 为本次扣减后研究数据集的剩余预算
+type CohortSize struct {
+	StudyID         string  `json:"studyId"`
+	Result          string  `json:"result"`
+	Count           int     `json:"count,omitempty"`
+	RemainingBudget float64 `json:"remainingBudget"`
+}
+
+type ResearchConsentEvent struct {
+	PatientID string `json:"patientId"`
+	StudyID   string `json:"studyId"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+// researchConsentKey research-consent:{studyId}:{patientId}，按研究前缀扫描计数
+func researchConsentKey(studyID, patientID string) string {
+	return researchConsentPrefix(studyID) + keySegment(patientID)
+}
+
+func researchConsentPrefix(studyID string) string {
+	return "research-consent:" + keySegment(studyID) + ":"
+}
+
+// validateConsentCodes 校验记录类型与诊断编码列表
+func validateConsentCodes(recordTypes, conditionCodes []string) error {
+	if len(recordTypes) > maxConsentCodes || len(conditionCodes) > maxConsentCodes {
+		return fmt.Errorf("recordTypes and conditionCodes can have at most %d entries", maxConsentCodes)
+	}
+	for _, recordType := range recordTypes {
+		if !recordTypePattern.MatchString(recordType) {
+			return fmt.Errorf("invalid recordType: %q", recordType)
+		}
+	}
+	for _, code := range conditionCodes {
+		if !conditionCodePattern.MatchString(code) {
+			return fmt.Errorf("invalid condition code: %q", code)
+		}
+	}
+	return nil
+}
+
+// active 未撤回且未过期
+func (consent *ResearchConsent) active(now time.Time) bool {
+	if consent.WithdrawnAt != "" {
+		return false
+	}
+	if consent.ExpiresAt == "" {
+		return true
+	}
+	expiresAt, err := time.Parse(time.RFC3339, consent.ExpiresAt)
+	return err == nil && now.Before(expiresAt)
+}
+
+func (consent *ResearchConsent) matches(criteria *CohortCriteria) bool {
+	for _, recordType := range criteria.RecordTypes {
+		if len(consent.RecordTypes) > 0 && !containsString(consent.RecordTypes, recordType) {
+			return false
+		}
+	}
+	if len(criteria.ConditionCodes) == 0 {
+		return true
+	}
+	for _, code := range criteria.ConditionCodes {
+		if containsString(consent.ConditionCodes, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// GiveResearchConsent 患者或有 consent 范围的代理人同意参加研究；scopeJson 为 {recordTypes, conditionCodes, expiresAt}。
+// 再次调用覆盖原同意
+func (s *SmartContract) GiveResearchConsent(ctx contractapi.TransactionContextInterface, patientID, studyID, scopeJson string) error {
+	if err := validateAddress(studyID); err != nil {
+		return fmt.Errorf("invalid studyID: %w", err)
+	}
+	var scope struct {
+		RecordTypes    []string `json:"recordTypes"`
+		ConditionCodes []string `json:"conditionCodes"`
+		ExpiresAt      string   `json:"expiresAt"`
+	}
+	if err := unmarshalArg(scopeJson, &scope); err != nil {
+		return fmt.Errorf("failed to unmarshal consent scope: %w", err)
+	}
+	if err := validateConsentCodes(scope.RecordTypes, scope.ConditionCodes); err != nil {
+		return err
+	}
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "give research consent")
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, scope.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid expiresAt: %w", err)
+		}
+		if !expiresAt.After(now) {
+			return fmt.Errorf("expiresAt must be in the future")
+		}
+	}
+
+	timestamp := now.Format(time.RFC3339)
+	if err := putJSON(ctx, researchConsentKey(studyID, patientID), ResearchConsent{
+		PatientID:      patientID,
+		StudyID:        studyID,
+		RecordTypes:    scope.RecordTypes,
+		ConditionCodes: scope.ConditionCodes,
+		ExpiresAt:      scope.ExpiresAt,
+		GivenBy:        callerID,
+		GivenAt:        timestamp,
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ResearchConsentGiven", ResearchConsentEvent{
+		PatientID: patientID,
+		StudyID:   studyID,
+		Timestamp: timestamp,
+		CallerID:  callerID,
+		EventType: "ResearchConsentGiven",
+	})
+}
+
+// WithdrawResearchConsent 撤回同意；条目保留撤回时间，之后不再计入人群
+func (s *SmartContract) WithdrawResearchConsent(ctx contractapi.TransactionContextInterface, patientID, studyID string) error {
+	callerID, err := requirePatientOrAgent(ctx, patientID, "consent", "withdraw research consent")
+	if err != nil {
+		return err
+	}
+	consent, err := getResearchConsent(ctx, patientID, studyID)
+	if err != nil {
+		return err
+	}
+	if consent.WithdrawnAt != "" {
+		return fmt.Errorf("research consent for %s was already withdrawn", studyID)
+	}
+	consent.WithdrawnAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, researchConsentKey(studyID, patientID), consent); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ResearchConsentWithdrawn", ResearchConsentEvent{
+		PatientID: patientID,
+		StudyID:   studyID,
+		Timestamp: consent.WithdrawnAt,
+		CallerID:  callerID,
+		EventType: "ResearchConsentWithdrawn",
+	})
+}
+
+func getResearchConsent(ctx contractapi.TransactionContextInterface, patientID, studyID string) (*ResearchConsent, error) {
+	var consent ResearchConsent
+	found, err := getJSON(ctx, researchConsentKey(studyID, patientID), &consent)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no research consent from %s for study %s", patientID, studyID)
+	}
+	return &consent, nil
+}
+
+// GetResearchConsent 患者本人或有 consent 范围的代理人查询
+func (s *SmartContract) GetResearchConsent(ctx contractapi.TransactionContextInterface, patientID, studyID string) (*ResearchConsent, error) {
+	if _, err := requirePatientOrAgent(ctx, patientID, "consent", "view research consent"); err != nil {
+		return nil, err
+	}
+	return getResearchConsent(ctx, patientID, studyID)
+}
+
+// GetConsentedCohortSize 统计研究中有效同意符合 criteriaJson 的患者数，永不返回名单；
+// 数量低于配置的 minCohortSize 时只返回 insufficient。调用者机构须有有效 DPA，
+// 每次查询从研究数据集的隐私预算扣减 epsilonCost（见 budget.go），须以提交交易调用
+func (s *SmartContract) GetConsentedCohortSize(ctx contractapi.TransactionContextInterface, criteriaJson string, epsilonCost float64) (*CohortSize, error) {
+	var criteria CohortCriteria
+	if err := unmarshalArg(criteriaJson, &criteria); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal criteria: %w", err)
+	}
+	if err := validateAddress(criteria.StudyID); err != nil {
+		return nil, fmt.Errorf("invalid studyId: %w", err)
+	}
+	if err := validateConsentCodes(criteria.RecordTypes, criteria.ConditionCodes); err != nil {
+		return nil, err
+	}
+	cleared, err := researchCleared(ctx, "research")
+	if err != nil {
+		return nil, err
+	}
+	if !cleared {
+		return nil, fmt.Errorf("access denied: cohort counts require a valid data processing agreement for the caller's organization")
+	}
+	budget, err := consumeBudget(ctx, criteria.StudyID, epsilonCost)
+	if err != nil {
+		return nil, err
+	}
+	remaining := budget.Total - budget.Consumed
+	count, err := countCohort(ctx, &criteria)
+	if err != nil {
+		return nil, err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count < config.MinCohortSize {
+		return &CohortSize{StudyID: criteria.StudyID, Result: CohortInsufficient, RemainingBudget: remaining}, nil
+	}
+	return &CohortSize{StudyID: criteria.StudyID, Result: CohortSufficient, Count: count, RemainingBudget: remaining}, nil
+}
+
+// countCohort 扫描研究的全部同意条目并计数
+func countCohort(ctx contractapi.TransactionContextInterface, criteria *CohortCriteria) (int, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	prefix := researchConsentPrefix(criteria.StudyID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan research consents: %w", err)
+	}
+	defer iterator.Close()
+
+	count := 0
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate research consents: %w", err)
+		}
+		var consent ResearchConsent
+		if err := json.Unmarshal(kv.Value, &consent); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal research consent: %w", err)
+		}
+		if consent.active(now) && consent.matches(criteria) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var researcher = newIdentity("researcher1", "Org3MSP")
+
+func (e *testEnv) giveResearchConsent(identity *testIdentity, studyID, scopeJson string) {
+	e.t.Helper()
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.GiveResearchConsent(ctx, identity.id, studyID, scopeJson)
+	})
+}
+
+func (e *testEnv) cohortSize(criteriaJson string) *CohortSize {
+	e.t.Helper()
+	var size *CohortSize
+	e.mustInvoke(researcher, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		size, err = e.cc.GetConsentedCohortSize(ctx, criteriaJson, 0.1)
+		return err
+	})
+	return size
+}
+
+// consentingPatients n 名患者同意 study1，诊断均为 E11；前两名另同意 I10 与影像
+func (e *testEnv) consentingPatients(n int) []*testIdentity {
+	patients := make([]*testIdentity, n)
+	for i := range patients {
+		patients[i] = newIdentity(fmt.Sprintf("cohort%d", i), "Org1MSP")
+		scope := `{"recordTypes":["lab"],"conditionCodes":["E11"]}`
+		if i < 2 {
+			scope = `{"conditionCodes":["E11","I10"]}`
+		}
+		e.giveResearchConsent(patients[i], "study1", scope)
+	}
+	return patients
+}
+
+func TestConsentedCohortSize(t *testing.T) {
+	env := newTestEnv(t)
+	patients := env.consentingPatients(4)
+	env.mustFail(researcher, "data processing agreement", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetConsentedCohortSize(ctx, `{"studyId":"study1"}`, 0.1)
+		return err
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		if err := env.cc.RegisterDPA(ctx, "Org3MSP", strings.Repeat("cd", 32), env.stub.now.AddDate(1, 0, 0).Format(time.RFC3339)); err != nil {
+			return err
+		}
+		for _, studyID := range []string{"study1", "study2"} {
+			if err := env.cc.SetPrivacyBudget(ctx, studyID, 10); err != nil {
+				return err
+			}
+		}
+		return env.cc.SetContractConfig(ctx, `{"minCohortSize":3}`)
+	})
+
+	if size := env.cohortSize(`{"studyId":"study1","conditionCodes":["E11"]}`); size.Result != CohortSufficient || size.Count != 4 {
+		t.Fatalf("unexpected cohort size: %+v", size)
+	}
+	if size := env.cohortSize(`{"studyId":"study1","recordTypes":["lab"]}`); size.Count != 4 {
+		t.Fatalf("consents without recordTypes cover every type: %+v", size)
+	}
+	// 低于阈值只返回 insufficient，不泄露数量
+	size := env.cohortSize(`{"studyId":"study1","conditionCodes":["I10"]}`)
+	if size.Result != CohortInsufficient || size.Count != 0 {
+		t.Fatalf("unexpected cohort size: %+v", size)
+	}
+	if size := env.cohortSize(`{"studyId":"study1","recordTypes":["imaging"],"conditionCodes":["E11"]}`); size.Result != CohortInsufficient {
+		t.Fatalf("unexpected cohort size: %+v", size)
+	}
+
+	env.mustInvoke(patients[3], func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawResearchConsent(ctx, patients[3].id, "study1")
+	})
+	env.expectEvent("ResearchConsentWithdrawn", nil)
+	if size := env.cohortSize(`{"studyId":"study1"}`); size.Count != 3 {
+		t.Fatalf("withdrawn consent must not be counted: %+v", size)
+	}
+	if size := env.cohortSize(`{"studyId":"study2"}`); size.Result != CohortInsufficient {
+		t.Fatalf("unexpected cohort size: %+v", size)
+	}
+}
+
+func TestResearchConsentLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	expiresAt := env.stub.now.Add(24 * time.Hour).Format(time.RFC3339)
+	env.giveResearchConsent(patient, "study1", `{"recordTypes":["lab"],"expiresAt":"`+expiresAt+`"}`)
+	env.expectEvent("ResearchConsentGiven", nil)
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GiveResearchConsent(ctx, patient.id, "study1", `{}`)
+	})
+	env.mustFail(patient, "invalid condition code", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GiveResearchConsent(ctx, patient.id, "study1", `{"conditionCodes":["E11; DROP"]}`)
+	})
+	env.mustFail(patient, "must be in the future", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GiveResearchConsent(ctx, patient.id, "study1", `{"expiresAt":"2000-01-01T00:00:00Z"}`)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		consent, err := env.cc.GetResearchConsent(ctx, patient.id, "study1")
+		if err == nil && (consent.ExpiresAt != expiresAt || consent.GivenBy != patient.id) {
+			t.Fatalf("unexpected consent: %+v", consent)
+		}
+		return err
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawResearchConsent(ctx, patient.id, "study1")
+	})
+	env.mustFail(patient, "already withdrawn", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.WithdrawResearchConsent(ctx, patient.id, "study1")
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// onCallAction 值班身份在时段内对科室记录的治疗级权限
+	onCallAction = "write"
+
+	maxRosterSlots     = 500
+	maxRosterSlotHours = 7 * 24
+)
+
+// RosterSlot 值班时段 [from, to)，时间为 RFC3339
+type RosterSlot struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Identities []string `json:"identities"`
+}
+
+// OnCallRoster 机构科室的值班表：时段内列出的身份对该机构该科室创建的记录持治疗级权限
+type OnCallRoster struct {
+	MSPID      string       `json:"mspId"`
+	Department string       `json:"department"`
+	Slots      []RosterSlot `json:"slots"`
+	UpdatedAt  string       `json:"updatedAt"`
+	UpdatedBy  string       `json:"updatedBy"`
+}
+
+// rosterKey roster:{mspId}:{department}
+func rosterKey(mspID, department string) string {
+	return "roster:" + keySegment(mspID) + ":" + keySegment(department)
+}
+
+// SetOnCallRoster 机构 admin 设置科室值班表，覆盖原有值班表；rosterJson 为 [{from, to, identities}]，
+// 空数组表示清空
+func (s *SmartContract) SetOnCallRoster(ctx contractapi.TransactionContextInterface, mspID, department, rosterJson string) error {
+	if !recordTypePattern.MatchString(department) {
+		return fmt.Errorf("invalid department: %q", department)
+	}
+	callerID, err := requireOrgAdmin(ctx, mspID, "set on-call rosters")
+	if err != nil {
+		return err
+	}
+	var slots []RosterSlot
+	if err := unmarshalArg(rosterJson, &slots); err != nil {
+		return fmt.Errorf("invalid roster json: %w", err)
+	}
+	if len(slots) > maxRosterSlots {
+		return fmt.Errorf("roster must not exceed %d slots", maxRosterSlots)
+	}
+	for i, slot := range slots {
+		from, err := time.Parse(time.RFC3339, slot.From)
+		if err != nil {
+			return fmt.Errorf("invalid from in slot %d: %w", i, err)
+		}
+		to, err := time.Parse(time.RFC3339, slot.To)
+		if err != nil {
+			return fmt.Errorf("invalid to in slot %d: %w", i, err)
+		}
+		if !to.After(from) || to.Sub(from) > maxRosterSlotHours*time.Hour {
+			return fmt.Errorf("slot %d must end after it starts and last at most %d hours", i, maxRosterSlotHours)
+		}
+		if len(slot.Identities) == 0 {
+			return fmt.Errorf("slot %d has no identities", i)
+		}
+		for _, identity := range slot.Identities {
+			if err := validateAddress(identity); err != nil {
+				return fmt.Errorf("invalid identity in slot %d: %w", i, err)
+			}
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, rosterKey(mspID, department), OnCallRoster{
+		MSPID:      mspID,
+		Department: department,
+		Slots:      slots,
+		UpdatedAt:  now,
+		UpdatedBy:  callerID,
+	}); err != nil {
+		return err
+	}
+	return emitDepartmentPolicyEvent(ctx, "OnCallRosterSet", mspID, department, callerID)
+}
+
+// GetOnCallRoster 返回科室值班表；限本机构身份
+func (s *SmartContract) GetOnCallRoster(ctx contractapi.TransactionContextInterface, mspID, department string) (*OnCallRoster, error) {
+	callerMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	if callerMSP != mspID {
+		return nil, fmt.Errorf("access denied: only members of %s can view its rosters", mspID)
+	}
+	var roster OnCallRoster
+	found, err := getJSON(ctx, rosterKey(mspID, department), &roster)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no on-call roster for %s in %s", department, mspID)
+	}
+	return &roster, nil
+}
+
+// onCallForRecord userID 在当前交易时间所在的时段内值班于记录的创建机构与科室
+func onCallForRecord(ctx contractapi.TransactionContextInterface, record *MedicalRecord, userID string) (bool, error) {
+	if record.CreatorMSP == "" || record.Department == "" {
+		return false, nil
+	}
+	var roster OnCallRoster
+	found, err := getJSON(ctx, rosterKey(record.CreatorMSP, record.Department), &roster)
+	if err != nil || !found {
+		return false, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, slot := range roster.Slots {
+		if !containsString(slot.Identities, userID) {
+			continue
+		}
+		// 时间已在写入时校验
+		from, _ := time.Parse(time.RFC3339, slot.From)
+		to, _ := time.Parse(time.RFC3339, slot.To)
+		if !now.Before(from) && now.Before(to) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// onCallGrantsAccess 派生规则：值班身份在其时段内可读取科室记录
+func onCallGrantsAccess(ctx contractapi.TransactionContextInterface, recordID, userID string) (bool, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	return onCallForRecord(ctx, record, userID)
+}
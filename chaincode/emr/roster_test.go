@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var onCallDoctor = newIdentity("oncall1", "Org1MSP")
+
+func TestOnCallRosterGrantsSlotAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(edDoctor, "ed1", patient.id)
+	slot := func(from, to time.Duration, identities string) string {
+		return `{"from":"` + env.stub.now.Add(from).Format(time.RFC3339) + `","to":"` + env.stub.now.Add(to).Format(time.RFC3339) + `","identities":[` + identities + `]}`
+	}
+	roster := `[` + slot(time.Hour, 9*time.Hour, `"oncall1"`) + `,` + slot(9*time.Hour, 17*time.Hour, `"`+other.id+`"`) + `]`
+	env.mustFail(org2Admin, "only admin of Org1MSP", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetOnCallRoster(ctx, "Org1MSP", "ed", roster)
+	})
+	env.mustFail(admin, "must end after it starts", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetOnCallRoster(ctx, "Org1MSP", "ed", `[`+slot(time.Hour, 0, `"oncall1"`)+`]`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SetOnCallRoster(ctx, "Org1MSP", "ed", roster)
+	})
+	env.expectEvent("OnCallRosterSet", nil)
+
+	update := func(contentHash string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.UpdateMedicalRecord(ctx, "ed1", "bafy"+contentHash[:8], contentHash)
+		}
+	}
+	// 时段开始前
+	if env.checkAccess("ed1", onCallDoctor.id) {
+		t.Fatal("roster access must not start before the slot")
+	}
+	env.advance(2 * time.Hour)
+	if !env.checkAccess("ed1", onCallDoctor.id) || !env.canRead(onCallDoctor, "ed1") {
+		t.Fatal("on-call identity must read department records during the slot")
+	}
+	if env.checkAccess("ed1", other.id) {
+		t.Fatal("identities of later slots must wait for their slot")
+	}
+	env.mustInvoke(onCallDoctor, update(strings.Repeat("b", 64)))
+
+	env.advance(8 * time.Hour)
+	if env.checkAccess("ed1", onCallDoctor.id) {
+		t.Fatal("roster access must end with the slot")
+	}
+	env.mustFail(onCallDoctor, "access denied", update(strings.Repeat("c", 64)))
+	if !env.checkAccess("ed1", other.id) {
+		t.Fatal("next slot must take over")
+	}
+	// 其他科室的记录不受该值班表影响
+	env.createRecord(doctor, "rec1", patient.id)
+	if env.checkAccess("rec1", other.id) {
+		t.Fatal("roster must only cover its department")
+	}
+
+	env.mustInvoke(edClinician, func(ctx contractapi.TransactionContextInterface) error {
+		roster, err := env.cc.GetOnCallRoster(ctx, "Org1MSP", "ed")
+		if err == nil && (len(roster.Slots) != 2 || roster.UpdatedBy != admin.id) {
+			t.Fatalf("unexpected roster: %+v", roster)
+		}
+		return err
+	})
+}
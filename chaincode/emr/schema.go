@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// currentSchemaVersion MedicalRecord、AccessList、AccessPermission 的当前存储结构版本；
+// 未带 schemaVersion 的旧状态视为版本 1，读取时就地升级，写入时总是写出当前版本
+const currentSchemaVersion = 2
+
+// MigrationResult MigrateState 一页的处理结果；Bookmark 为空表示已扫描完毕
+type MigrationResult struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	Bookmark string `json:"bookmark"`
+}
+
+// migrateRecord 升级记录结构，已是当前版本时返回 false。
+// v1→v2：补齐 docType、status 与 provenanceTier，使旧记录可被 CouchDB 索引与归档过滤命中
+func migrateRecord(record *MedicalRecord) bool {
+	if record.SchemaVersion >= currentSchemaVersion {
+		return false
+	}
+	record.DocType = recordDocType
+	if record.Status == "" {
+		record.Status = RecordActive
+	}
+	record.ProvenanceTier = recordTier(record)
+	record.SchemaVersion = currentSchemaVersion
+	return true
+}
+
+// migrateAccessList v1→v2：补齐 permissions 映射及各条目缺失的 recordId/granteeId
+func migrateAccessList(accessList *AccessList) bool {
+	if accessList.SchemaVersion >= currentSchemaVersion {
+		return false
+	}
+	if accessList.Permissions == nil {
+		accessList.Permissions = make(map[string]AccessPermission)
+	}
+	for granteeID, perm := range accessList.Permissions {
+		if perm.RecordID == "" {
+			perm.RecordID = accessList.RecordID
+		}
+		if perm.GranteeID == "" {
+			perm.GranteeID = granteeID
+		}
+		accessList.Permissions[granteeID] = perm
+	}
+	accessList.SchemaVersion = currentSchemaVersion
+	return true
+}
+
+// migratePermission v1→v2：expiresAt 统一为 UTC RFC3339，与其余时间字段一致
+func migratePermission(perm *AccessPermission) bool {
+	if perm.SchemaVersion >= currentSchemaVersion {
+		return false
+	}
+	if perm.ExpiresAt != "" {
+		if expiresAt, err := time.Parse(time.RFC3339, perm.ExpiresAt); err == nil {
+			perm.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+		}
+	}
+	perm.SchemaVersion = currentSchemaVersion
+	return true
+}
+
+// stampSchemaVersion 为 putJSON 写出的版本化结构标记当前版本
+func stampSchemaVersion(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *MedicalRecord:
+		v.SchemaVersion = currentSchemaVersion
+	case MedicalRecord:
+		v.SchemaVersion = currentSchemaVersion
+		return v
+	case *AccessList:
+		v.SchemaVersion = currentSchemaVersion
+	case AccessList:
+		v.SchemaVersion = currentSchemaVersion
+		return v
+	case *AccessPermission:
+		v.SchemaVersion = currentSchemaVersion
+	case AccessPermission:
+		v.SchemaVersion = currentSchemaVersion
+		return v
+	}
+	return value
+}
+
+// migrators 可批量迁移的键前缀及其升级函数；返回 nil 表示已是当前版本
+var migrators = map[string]func(data []byte) (interface{}, error){
+	"record:": func(data []byte) (interface{}, error) {
+		var record MedicalRecord
+		if err := json.Unmarshal(data, &record); err != nil || !migrateRecord(&record) {
+			return nil, err
+		}
+		return &record, nil
+	},
+	"access:": func(data []byte) (interface{}, error) {
+		var accessList AccessList
+		if err := json.Unmarshal(data, &accessList); err != nil || !migrateAccessList(&accessList) {
+			return nil, err
+		}
+		return &accessList, nil
+	},
+	"perm:": func(data []byte) (interface{}, error) {
+		var perm AccessPermission
+		if err := json.Unmarshal(data, &perm); err != nil || !migratePermission(&perm) {
+			return nil, err
+		}
+		return &perm, nil
+	},
+}
+
+// MigrateState 分页扫描 keyPrefix（record:、access:、perm:）下的状态，回写升级后的结构；仅 admin
+func (s *SmartContract) MigrateState(ctx contractapi.TransactionContextInterface, keyPrefix string, pageSize int32, bookmark string) (*MigrationResult, error) {
+	migrate, ok := migrators[keyPrefix]
+	if !ok {
+		return nil, fmt.Errorf("unsupported keyPrefix: %s", keyPrefix)
+	}
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	isAdmin, err := hasRole(ctx, "admin")
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, fmt.Errorf("access denied: only admin can migrate state")
+	}
+
+	// 前缀均以 ':' 结尾，结束键取 ';' 覆盖整个前缀
+	endKey := keyPrefix[:len(keyPrefix)-1] + ";"
+	result := &MigrationResult{}
+	next, err := scanRangeForUpdate(ctx, keyPrefix, endKey, pageSize, bookmark, func(key string, value []byte) error {
+		result.Scanned++
+		upgraded, err := migrate(value)
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", key, err)
+		}
+		if upgraded == nil {
+			return nil
+		}
+		result.Migrated++
+		return putJSON(ctx, key, upgraded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Bookmark = next
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// putRaw 直接写入状态，模拟升级前留下的旧格式数据
+func (e *testEnv) putRaw(key, value string) {
+	e.t.Helper()
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState(key, []byte(value))
+	})
+}
+
+func TestSchemaMigration(t *testing.T) {
+	env := newTestEnv(t)
+	env.putRaw(recordKey("old1"), `{"recordId":"old1","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafyold1","contentHash":"aa","timestamp":"2020-01-01T00:00:00Z"}`)
+	env.putRaw(accessListKey("old1"), `{"recordId":"old1","owner":"patient1","permissions":{"nurse1":{"action":"read","grantedAt":"2020-01-01T00:00:00Z","isActive":true}}}`)
+	env.putRaw(permKey("old1", "nurse1"), `{"recordId":"old1","granteeId":"nurse1","action":"read","expiresAt":"2099-01-01T08:00:00+08:00","isActive":true}`)
+
+	// 读取时升级，不回写
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.ReadRecord(ctx, "old1")
+		if err != nil {
+			return err
+		}
+		if record.SchemaVersion != currentSchemaVersion || record.Status != RecordActive || record.DocType != recordDocType || record.ProvenanceTier != ProvenanceClinician {
+			t.Fatalf("record not upgraded on read: %+v", record)
+		}
+		accessList, err := env.cc.GetAccessList(ctx, "old1")
+		if err == nil && accessList.Permissions["nurse1"].GranteeID != "nurse1" {
+			t.Fatalf("access list not upgraded on read: %+v", accessList)
+		}
+		return err
+	})
+	if rawVersion(env.stub.State[recordKey("old1")]) != 0 {
+		t.Fatal("read path must not write back")
+	}
+
+	env.mustFail(doctor, "only admin can migrate", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.MigrateState(ctx, "record:", 10, "")
+		return err
+	})
+	env.mustFail(admin, "unsupported keyPrefix", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.MigrateState(ctx, "did:", 10, "")
+		return err
+	})
+	env.createRecord(doctor, "rec1", patient.id)
+	for _, prefix := range []string{"record:", "access:", "perm:"} {
+		var result *MigrationResult
+		env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.cc.MigrateState(ctx, prefix, 10, "")
+			return err
+		})
+		if result.Migrated != 1 || result.Scanned < 1 {
+			t.Fatalf("%s: only the legacy entry must be rewritten: %+v", prefix, result)
+		}
+	}
+	for _, key := range []string{recordKey("old1"), accessListKey("old1"), permKey("old1", "nurse1"), recordKey("rec1")} {
+		if rawVersion(env.stub.State[key]) != currentSchemaVersion {
+			t.Fatalf("%s must be stored at the current schema version", key)
+		}
+	}
+	var perm AccessPermission
+	_ = json.Unmarshal(env.stub.State[permKey("old1", "nurse1")], &perm)
+	if perm.ExpiresAt != "2099-01-01T00:00:00Z" {
+		t.Fatalf("expiresAt must be normalized to UTC, got %s", perm.ExpiresAt)
+	}
+}
+
+func TestMigrateStatePages(t *testing.T) {
+	env := newTestEnv(t)
+	for _, recordID := range []string{"old1", "old2", "old3"} {
+		env.putRaw(permKey(recordID, "nurse1"), `{"recordId":"`+recordID+`","granteeId":"nurse1","action":"read","isActive":true}`)
+	}
+	total, pages, bookmark := 0, 0, ""
+	for {
+		var result *MigrationResult
+		env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.cc.MigrateState(ctx, "perm:", 2, bookmark)
+			return err
+		})
+		total += result.Migrated
+		pages++
+		if bookmark = result.Bookmark; bookmark == "" || pages > 2 {
+			break
+		}
+	}
+	if pages != 2 || total != 3 {
+		t.Fatalf("expected 3 entries over 2 pages, got %d over %d", total, pages)
+	}
+}
+
+func rawVersion(data []byte) int {
+	var versioned struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	_ = json.Unmarshal(data, &versioned)
+	return versioned.SchemaVersion
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ScopedQueryGrant 研究门户等身份的限定视图：列表与检索函数只返回匹配类型与时间区间的记录，
+// 且一律去掉 ipfsCid 与业务元数据；持有者不能读取单条记录
+type ScopedQueryGrant struct {
+	GranteeID        string   `json:"granteeId"`
+	RecordTypes      []string `json:"recordTypes,omitempty"`
+	From             string   `json:"from,omitempty"`
+	To               string   `json:"to,omitempty"`
+	DeidentifiedOnly bool     `json:"deidentifiedOnly"`
+	CreatedBy        string   `json:"createdBy"`
+	CreatedAt        string   `json:"createdAt"`
+}
+
+type ScopedQueryGrantEvent struct {
+	GranteeID string `json:"granteeId"`
+	Active    bool   `json:"active"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func scopedQueryKey(granteeID string) string {
+	return "scoped-query:" + granteeID
+}
+
+func getScopedQueryGrant(ctx contractapi.TransactionContextInterface, granteeID string) (*ScopedQueryGrant, error) {
+	var grant ScopedQueryGrant
+	found, err := getJSON(ctx, scopedQueryKey(granteeID), &grant)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// callerQueryScope 返回调用者的限定视图，没有时返回 nil；限定视图属研究数据访问，调用者机构须有有效 DPA。
+// 审计员没有登记的视图时按空范围处理：可列出全部记录，但内容字段一律去掉
+func callerQueryScope(ctx contractapi.TransactionContextInterface, callerID string) (*ScopedQueryGrant, error) {
+	grant, err := getScopedQueryGrant(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		auditor, err := isAuditor(ctx)
+		if err != nil || !auditor {
+			return nil, err
+		}
+		return &ScopedQueryGrant{GranteeID: callerID}, nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	valid, err := dpaValid(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, fmt.Errorf("access denied: scoped queries require a valid data processing agreement for %s", mspID)
+	}
+	return grant, nil
+}
+
+// callerScopeByCert 角色放行的列表函数同样套用限定视图，防止门户身份被误配审计角色
+func callerScopeByCert(ctx contractapi.TransactionContextInterface) (*ScopedQueryGrant, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return callerQueryScope(ctx, callerID)
+}
+
+// view 返回记录在限定视图下的副本，不在范围内时返回 nil
+func (grant *ScopedQueryGrant) view(record *MedicalRecord) *MedicalRecord {
+	if len(grant.RecordTypes) > 0 && !containsString(grant.RecordTypes, record.RecordType) {
+		return nil
+	}
+	// 范围与记录时间均为 RFC3339 UTC，按字符串比较即时间顺序
+	if (grant.From != "" && record.Timestamp < grant.From) || (grant.To != "" && record.Timestamp > grant.To) {
+		return nil
+	}
+	limited := *record
+	limited.IPCSCID = ""
+	limited.Metadata = nil
+	limited.FhirMetadata = nil
+	limited.OriginalHashes = nil
+	limited.Parts = nil
+	limited.Sections = nil
+	if grant.DeidentifiedOnly {
+		limited.PatientID = ""
+		limited.CreatorID = ""
+		limited.SourceSystem = ""
+		limited.CoSignedBy = ""
+		limited.SignedOffBy = ""
+	}
+	return &limited
+}
+
+// CreateScopedQueryGrant admin 为身份设置限定视图，覆盖原有设置；
+// scopeJson 为 {recordTypes, from, to, deidentifiedOnly}，时间为 RFC3339
+func (s *SmartContract) CreateScopedQueryGrant(ctx contractapi.TransactionContextInterface, granteeID, scopeJson string) error {
+	if err := validateAddress(granteeID); err != nil {
+		return fmt.Errorf("invalid granteeID: %w", err)
+	}
+	var grant ScopedQueryGrant
+	if err := unmarshalArg(scopeJson, &grant); err != nil {
+		return fmt.Errorf("invalid scope json: %w", err)
+	}
+	for _, bound := range []*string{&grant.From, &grant.To} {
+		if *bound == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *bound)
+		if err != nil {
+			return fmt.Errorf("invalid scope time: %w", err)
+		}
+		*bound = t.UTC().Format(time.RFC3339)
+	}
+	if grant.From != "" && grant.To != "" && grant.To < grant.From {
+		return fmt.Errorf("scope to must not be before from")
+	}
+	callerID, _, err := requireAdminCaller(ctx, "create scoped query grants")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	grant.GranteeID = granteeID
+	grant.CreatedBy = callerID
+	grant.CreatedAt = now
+	if err := putJSON(ctx, scopedQueryKey(granteeID), grant); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ScopedQueryGrantCreated", ScopedQueryGrantEvent{
+		GranteeID: granteeID,
+		Active:    true,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "ScopedQueryGrantCreated",
+	})
+}
+
+// RevokeScopedQueryGrant 删除限定视图，身份恢复为普通访问判断
+func (s *SmartContract) RevokeScopedQueryGrant(ctx contractapi.TransactionContextInterface, granteeID string) error {
+	callerID, _, err := requireAdminCaller(ctx, "revoke scoped query grants")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, scopedQueryKey(granteeID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no scoped query grant for %s", granteeID)
+	}
+	if err := ctx.GetStub().DelState(scopedQueryKey(granteeID)); err != nil {
+		return fmt.Errorf("failed to delete scoped query grant: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "ScopedQueryGrantRevoked", ScopedQueryGrantEvent{
+		GranteeID: granteeID,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "ScopedQueryGrantRevoked",
+	})
+}
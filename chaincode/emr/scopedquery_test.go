@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var researchPortal = newIdentity("portal1", "ResearchMSP")
+
+func (e *testEnv) createTypedRecord(recordID, recordType string) {
+	e.t.Helper()
+	var record MedicalRecord
+	_ = json.Unmarshal([]byte(recordJSON(doctor, recordID, patient.id)), &record)
+	record.RecordType = recordType
+	record.Metadata = map[string]interface{}{"note": "clinical detail"}
+	data, _ := json.Marshal(record)
+	e.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateMedicalRecord(ctx, string(data))
+		return err
+	})
+}
+
+func TestScopedQueryGrantLimitsListings(t *testing.T) {
+	env := newTestEnv(t)
+	env.createTypedRecord("lab1", "lab")
+	env.createTypedRecord("note1", "note")
+	env.advance(48 * time.Hour)
+	env.createTypedRecord("lab2", "lab")
+	// 误配：门户同时被直接授权
+	env.grant(patient, "lab1", researchPortal.id, "read", "")
+
+	to := env.stub.now.Add(-24 * time.Hour).Format(time.RFC3339)
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateScopedQueryGrant(ctx, researchPortal.id, `{}`)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateScopedQueryGrant(ctx, researchPortal.id, `{"recordTypes":["lab"],"to":"`+to+`","deidentifiedOnly":true}`)
+	})
+	env.expectEvent("ScopedQueryGrantCreated", nil)
+	list := func() ([]*MedicalRecord, error) {
+		var records []*MedicalRecord
+		err := env.invoke(researchPortal, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			records, err = env.cc.ListRecordsByPatient(ctx, patient.id, "")
+			return err
+		})
+		return records, err
+	}
+
+	if _, err := list(); err == nil || !strings.Contains(err.Error(), "data processing agreement") {
+		t.Fatalf("scoped queries must require a DPA, got %v", err)
+	}
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterDPA(ctx, "ResearchMSP", strings.Repeat("ef", 32), env.stub.now.AddDate(1, 0, 0).Format(time.RFC3339))
+	})
+	records, err := list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].RecordID != "lab1" {
+		t.Fatalf("scope must filter by type and time: %+v", records)
+	}
+	if r := records[0]; r.IPCSCID != "" || r.PatientID != "" || r.CreatorID != "" || r.Metadata != nil || r.ContentHash == "" {
+		t.Fatalf("scoped view must be de-identified metadata only: %+v", r)
+	}
+
+	env.mustFail(researchPortal, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "lab1")
+		return err
+	})
+	env.mustFail(researchPortal, "limited to scoped queries", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetRecordMetadata(ctx, "lab1")
+		return err
+	})
+
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeScopedQueryGrant(ctx, researchPortal.id)
+	})
+	env.mustInvoke(researchPortal, func(ctx contractapi.TransactionContextInterface) error {
+		record, err := env.cc.ReadRecord(ctx, "lab1")
+		if err == nil && record.IPCSCID == "" {
+			t.Fatal("revoking the scope restores the direct grant")
+		}
+		return err
+	})
+}
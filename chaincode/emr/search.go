@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// recordSearchIndexes 与 META-INF/statedb/couchdb/indexes 下随链码打包的索引一一对应；
+// 第二个字段为必须以等值条件命中的前导字段
+var recordSearchIndexes = map[string][]string{
+	"indexPatientTimestamp":    {"docType", "patientId", "timestamp"},
+	"indexCreatorTimestamp":    {"docType", "creatorId", "timestamp"},
+	"indexRecordTypeTimestamp": {"docType", "recordType", "timestamp"},
+	"indexStatusTimestamp":     {"docType", "status", "timestamp"},
+}
+
+// buildIndexedQuery 校验选择器能命中 indexName 并组装 CouchDB 查询；docType 由链码固定注入。
+// 第二个返回值表示选择器是否显式约束了 status
+func buildIndexedQuery(indexName, selectorJson string) (string, bool, error) {
+	fields, ok := recordSearchIndexes[indexName]
+	if !ok {
+		return "", false, fmt.Errorf("unknown index: %s", indexName)
+	}
+	var selector map[string]interface{}
+	if err := unmarshalArg(selectorJson, &selector); err != nil {
+		return "", false, fmt.Errorf("invalid selector json: %w", err)
+	}
+	if selector == nil {
+		return "", false, fmt.Errorf("selector must be a JSON object")
+	}
+	for field := range selector {
+		// 顶层 $or/$nor 等组合条件会让 CouchDB 放弃索引退化为全表扫描
+		if strings.HasPrefix(field, "$") {
+			return "", false, fmt.Errorf("top-level operator %s is not allowed", field)
+		}
+	}
+	leading := fields[1]
+	if _, ok := selector[leading].(string); !ok {
+		return "", false, fmt.Errorf("selector must match %s with an equality condition to use %s", leading, indexName)
+	}
+	selector["docType"] = recordDocType
+
+	query, err := json.Marshal(map[string]interface{}{
+		"selector":  selector,
+		"use_index": []string{"_design/" + indexName + "Doc", indexName},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal query: %w", err)
+	}
+	_, hasStatus := selector["status"]
+	return string(query), hasStatus, nil
+}
+
+// SearchRecords 按随链码打包的 CouchDB 索引检索记录元数据并分页返回，结果逐条按 ReadRecord 的访问判断过滤，
+// 患者本人的记录以外只返回元数据；
+// 富查询不参与提交时的读集校验，仅用于查询（evaluate）
+func (s *SmartContract) SearchRecords(ctx contractapi.TransactionContextInterface, indexName, selectorJson string, pageSize int32, bookmark string) (*RecordPage, error) {
+	if err := validatePageSize(ctx, pageSize); err != nil {
+		return nil, err
+	}
+	// 选择器未约束 status 时按默认列表处理，排除已归档记录
+	query, explicitStatus, err := buildIndexedQuery(indexName, selectorJson)
+	if err != nil {
+		return nil, err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := callerQueryScope(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	certID, err := callerCertID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subjects, err := subjectIDs(ctx, certID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer iterator.Close()
+
+	page := &RecordPage{Records: []*MedicalRecord{}, Bookmark: metadata.GetBookmark()}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate records: %w", err)
+		}
+		var record MedicalRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record: %w", err)
+		}
+		migrateRecord(&record)
+		if !listable(&record) && !explicitStatus {
+			continue
+		}
+		if scope != nil {
+			if limited := scope.view(&record); limited != nil {
+				page.Records = append(page.Records, limited)
+			}
+			continue
+		}
+		if containsString(subjects, record.PatientID) {
+			page.Records = append(page.Records, &record)
+			continue
+		}
+		allowed, err := s.callerAccess(ctx, &record, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			page.Records = append(page.Records, withoutContent(&record))
+		}
+	}
+	return page, nil
+}
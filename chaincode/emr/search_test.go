@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// 打包的索引定义须与 recordSearchIndexes 保持一致，否则 use_index 会指向不存在的索引
+func TestShippedCouchDBIndexes(t *testing.T) {
+	files, err := filepath.Glob("META-INF/statedb/couchdb/indexes/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(recordSearchIndexes) {
+		t.Fatalf("expected %d index files, found %d", len(recordSearchIndexes), len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var def struct {
+			Index struct {
+				Fields []string `json:"fields"`
+			} `json:"index"`
+			Ddoc string `json:"ddoc"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &def); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if def.Name+".json" != filepath.Base(file) || def.Ddoc != def.Name+"Doc" || def.Type != "json" {
+			t.Fatalf("%s: unexpected definition %+v", file, def)
+		}
+		if !reflect.DeepEqual(recordSearchIndexes[def.Name], def.Index.Fields) {
+			t.Fatalf("%s: fields %v do not match %v", file, def.Index.Fields, recordSearchIndexes[def.Name])
+		}
+	}
+}
+
+func TestSearchRecords(t *testing.T) {
+	env := newTestEnv(t)
+	create := func(creator *testIdentity, recordID, recordType string) {
+		env.mustInvoke(creator, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"`+recordID+`","patientId":"patient1","creatorId":"`+creator.id+
+				`","ipfsCid":"bafy","contentHash":"`+strings.Repeat("a", 64)+`","recordType":"`+recordType+`"}`)
+			return err
+		})
+	}
+	create(doctor, "lab1", "lab")
+	create(nurse, "note1", "note")
+	env.advance(48 * time.Hour)
+	create(doctor, "lab2", "lab")
+
+	search := func(identity *testIdentity, index, selector string) []string {
+		var ids []string
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.SearchRecords(ctx, index, selector, 10, "")
+			if err != nil {
+				return err
+			}
+			for _, rec := range page.Records {
+				ids = append(ids, rec.RecordID)
+			}
+			return nil
+		})
+		return ids
+	}
+	if got := search(patient, "indexPatientTimestamp", `{"patientId":"patient1"}`); len(got) != 3 {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	if got := search(patient, "indexRecordTypeTimestamp", `{"recordType":"lab","timestamp":{"$gte":"2026-01-06T00:00:00Z"}}`); len(got) != 1 || got[0] != "lab2" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	// 结果按 callerAccess 过滤
+	if got := search(nurse, "indexStatusTimestamp", `{"status":"active"}`); len(got) != 1 || got[0] != "note1" {
+		t.Fatalf("unexpected records for nurse: %v", got)
+	}
+	env.grant(patient, "lab1", studyResearcher.id, "read", "")
+	if got := search(studyResearcher, "indexPatientTimestamp", `{"patientId":"patient1"}`); len(got) != 0 {
+		t.Fatalf("researcher without a DUA must see nothing: %v", got)
+	}
+	// 患者本人的结果带内容指针，其他调用者只得到元数据
+	cids := func(identity *testIdentity) []string {
+		var out []string
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.cc.SearchRecords(ctx, "indexRecordTypeTimestamp", `{"recordType":"note"}`, 10, "")
+			if err != nil {
+				return err
+			}
+			for _, rec := range page.Records {
+				out = append(out, rec.IPCSCID)
+			}
+			return nil
+		})
+		return out
+	}
+	if got := cids(patient); len(got) != 1 || got[0] != "bafy" {
+		t.Fatalf("patient must get the content pointer: %v", got)
+	}
+	if got := cids(nurse); len(got) != 1 || got[0] != "" {
+		t.Fatalf("grantee must not get the content pointer: %v", got)
+	}
+
+	for selector, want := range map[string]string{
+		`{"timestamp":{"$gte":"2026-01-01T00:00:00Z"}}`:  "equality condition",
+		`{"creatorId":{"$gt":""}}`:                       "equality condition",
+		`{"creatorId":"doctor1","$or":[{"status":"a"}]}`: "top-level operator",
+	} {
+		env.mustFail(patient, want, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.SearchRecords(ctx, "indexCreatorTimestamp", selector, 10, "")
+			return err
+		})
+	}
+	env.mustFail(patient, "unknown index", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.SearchRecords(ctx, "_all_docs", `{"patientId":"patient1"}`, 10, "")
+		return err
+	})
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxRecordSections 单条记录的分节上限
+const maxRecordSections = 32
+
+// RecordSection 记录中可单独授权的一节（如 medications、psychotherapy-notes），内容单独加密存储；
+// 分节清单即合法分节名
+type RecordSection struct {
+	Name        string `json:"name"`
+	CID         string `json:"cid"`
+	ContentHash string `json:"contentHash"`
+}
+
+// validateSections 校验分节名（与 recordType 同格式）、CID 与哈希，拒绝重名
+func (config *ContractConfig) validateSections(sections []RecordSection) error {
+	if len(sections) > maxRecordSections {
+		return fmt.Errorf("a record can have at most %d sections", maxRecordSections)
+	}
+	for i, section := range sections {
+		if !recordTypePattern.MatchString(section.Name) {
+			return fmt.Errorf("section %d: invalid name: %q", i, section.Name)
+		}
+		if recordSection(sections[:i], section.Name) != nil {
+			return fmt.Errorf("section %d: duplicate name %s", i, section.Name)
+		}
+		if section.CID == "" || section.ContentHash == "" {
+			return fmt.Errorf("section %s: cid and contentHash are required", section.Name)
+		}
+		if err := config.checkCid(section.CID); err != nil {
+			return fmt.Errorf("section %s: %w", section.Name, err)
+		}
+		if err := config.checkContentHash(section.ContentHash); err != nil {
+			return fmt.Errorf("section %s: %w", section.Name, err)
+		}
+	}
+	return nil
+}
+
+// recordSection 按名称查找分节，不存在时返回 nil
+func recordSection(sections []RecordSection, name string) *RecordSection {
+	for i := range sections {
+		if sections[i].Name == name {
+			return &sections[i]
+		}
+	}
+	return nil
+}
+
+// accessTarget 访问检查的对象：零值为整条记录，否则为一节或一个脱敏视图（见 views.go）
+type accessTarget struct {
+	section string
+	viewID  string
+}
+
+func (target accessTarget) String() string {
+	if target.viewID != "" {
+		return "view " + target.viewID
+	}
+	return "section " + target.section
+}
+
+// grantScope 授权的范围：零值为整条记录，否则为若干分节或一个脱敏视图，二者不并用；
+// duaHash 为授权引用的数据使用协议（见 dua.go），requiresAck 要求被授权人读取后确认（见 ack.go），
+// encounterID 为授权绑定的住院就诊（见 discharge.go），episodeID 为授权引用的诊疗周期（见 episode.go），均与范围无关
+type grantScope struct {
+	sections    []string
+	viewID      string
+	duaHash     string
+	requiresAck bool
+	encounterID string
+	episodeID   string
+}
+
+// validate 分节须在清单中且不重复，视图须已登记，引用的 DUA 须有效；限定范围的授权只能是 read
+func (scope grantScope) validate(ctx contractapi.TransactionContextInterface, record *MedicalRecord, action string) error {
+	if scope.duaHash != "" {
+		if err := requireActiveDUA(ctx, scope.duaHash); err != nil {
+			return err
+		}
+	}
+	if scope.encounterID != "" {
+		if err := requireEncounterOpen(ctx, record.PatientID, scope.encounterID); err != nil {
+			return err
+		}
+	}
+	if scope.episodeID != "" {
+		if err := requireOpenEpisode(ctx, record, scope.episodeID); err != nil {
+			return err
+		}
+	}
+	if len(scope.sections) == 0 && scope.viewID == "" {
+		return nil
+	}
+	if action != "read" {
+		return fmt.Errorf("section and view grants are read-only")
+	}
+	if scope.viewID != "" {
+		_, err := getRedactedView(ctx, record.RecordID, scope.viewID)
+		return err
+	}
+	for i, section := range scope.sections {
+		if err := requireSection(record, section); err != nil {
+			return err
+		}
+		if containsString(scope.sections[:i], section) {
+			return fmt.Errorf("duplicate section: %s", section)
+		}
+	}
+	return nil
+}
+
+// grantCovers 授权有效且覆盖 target。不限范围的授权覆盖整条记录及其全部分节与视图；
+// 分节授权只覆盖所列分节，视图授权只覆盖该视图
+func grantCovers(perm AccessPermission, now time.Time, target accessTarget) bool {
+	if !permissionActive(perm, now) {
+		return false
+	}
+	switch {
+	case perm.ViewID != "":
+		return target.viewID == perm.ViewID
+	case len(perm.Sections) > 0:
+		return target.section != "" && containsString(perm.Sections, target.section)
+	default:
+		return true
+	}
+}
+
+// requireSection section 须在记录的分节清单中
+func requireSection(record *MedicalRecord, section string) error {
+	if recordSection(record.Sections, section) == nil {
+		return fmt.Errorf("record %s has no section %q", record.RecordID, section)
+	}
+	return nil
+}
+
+// GrantSectionAccess 授予只读的分节授权，被授权人只能读取 sectionsJson 列出的分节，不能读取整条记录；
+// expiresAt 为空表示不过期。再次授权覆盖原授权
+func (s *SmartContract) GrantSectionAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, sectionsJson, expiresAt string) error {
+	var sections []string
+	if err := unmarshalArg(sectionsJson, &sections); err != nil {
+		return fmt.Errorf("failed to unmarshal sections: %w", err)
+	}
+	if len(sections) == 0 {
+		return fmt.Errorf("sections must not be empty: use GrantAccess for the whole record")
+	}
+	return grantAccess(ctx, recordID, granteeID, "read", expiresAt, 0, grantScope{sections: sections})
+}
+
+// CheckSectionAccess 与 CheckAccess 相同，但判断的是记录中的一节：整条记录的访问覆盖全部分节，
+// 分节授权只覆盖所列分节
+func (s *SmartContract) CheckSectionAccess(ctx contractapi.TransactionContextInterface, recordID, userID, section string) (bool, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return false, err
+	}
+	if err := requireSection(record, section); err != nil {
+		return false, err
+	}
+	return checkAccess(ctx, recordID, userID, accessTarget{section: section})
+}
+
+// ReadRecordSection 返回一节的存储位置与内容哈希；与 ReadRecord 一样计入披露、扣减限次授权并发出 RecordAccessed
+func (s *SmartContract) ReadRecordSection(ctx contractapi.TransactionContextInterface, recordID, section string) (*RecordSection, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireSection(record, section); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTargetRead(ctx, record, accessTarget{section: section}); err != nil {
+		return nil, err
+	}
+	return recordSection(record.Sections, section), nil
+}
+
+// authorizeTargetRead 读取分节或视图的公共路径：拒绝时发出 RecordAccessed(false)，
+// 允许时按 ReadRecord 的规则校验研究用途并记录读取
+func (s *SmartContract) authorizeTargetRead(ctx contractapi.TransactionContextInterface, record *MedicalRecord, target accessTarget) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	purpose, err := purposeOfUse(ctx)
+	if err != nil {
+		return err
+	}
+	reasonCode, err := readReasonCode(ctx)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.callerTargetAccess(ctx, record, callerID, target)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		if err := emitRecordAccessedEvent(ctx, record.RecordID, callerID, purpose, reasonCode, false); err != nil {
+			return err
+		}
+		return fmt.Errorf("access denied: %s cannot read %s of record %s", callerID, target, record.RecordID)
+	}
+	cleared, err := researchCleared(ctx, purpose)
+	if err != nil {
+		return err
+	}
+	if !cleared {
+		return fmt.Errorf("access denied: research use requires a valid data processing agreement for the caller's organization")
+	}
+	return recordAllowedRead(ctx, record, callerID, purpose, reasonCode)
+}
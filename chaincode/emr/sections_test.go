@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	medicationsSection = RecordSection{Name: "medications", CID: "bafymeds", ContentHash: strings.Repeat("c", 64)}
+	therapySection     = RecordSection{Name: "psychotherapy-notes", CID: "bafytherapy", ContentHash: strings.Repeat("d", 64)}
+)
+
+func sectionedJSON(recordID string, sections ...RecordSection) string {
+	data, _ := json.Marshal(MedicalRecord{
+		RecordID:    recordID,
+		PatientID:   patient.id,
+		CreatorID:   doctor.id,
+		IPCSCID:     "bafy" + recordID,
+		ContentHash: strings.Repeat("a", 64),
+		Sections:    sections,
+	})
+	return string(data)
+}
+
+func (e *testEnv) createSectionedRecord(recordID string) {
+	e.t.Helper()
+	e.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateMedicalRecord(ctx, sectionedJSON(recordID, medicationsSection, therapySection))
+		return err
+	})
+}
+
+func (e *testEnv) checkSectionAccess(recordID, userID, section string) bool {
+	e.t.Helper()
+	var allowed bool
+	e.mustInvoke(newIdentity("query", "Org1MSP"), func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		allowed, err = e.cc.CheckSectionAccess(ctx, recordID, userID, section)
+		return err
+	})
+	return allowed
+}
+
+func TestSectionManifestValidation(t *testing.T) {
+	env := newTestEnv(t)
+	invalid := map[string]string{
+		"duplicate name":      sectionedJSON("bad1", medicationsSection, medicationsSection),
+		"invalid name":        sectionedJSON("bad2", RecordSection{Name: "Meds", CID: "bafyx", ContentHash: strings.Repeat("c", 64)}),
+		"cid and contentHash": sectionedJSON("bad3", RecordSection{Name: "meds", CID: "bafyx"}),
+	}
+	for contains, recordJSON := range invalid {
+		env.mustFail(doctor, contains, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, recordJSON)
+			return err
+		})
+	}
+}
+
+func TestSectionGrantCoversOnlyListedSections(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSectionedRecord("rec1")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantSectionAccess(ctx, "rec1", specialist.id, `["medications"]`, "")
+	})
+	var event AccessGrantedEvent
+	env.expectEvent("AccessGranted", &event)
+	if len(event.Sections) != 1 || event.Sections[0] != "medications" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	if !env.checkSectionAccess("rec1", specialist.id, "medications") {
+		t.Fatal("section grant should cover medications")
+	}
+	if env.checkSectionAccess("rec1", specialist.id, "psychotherapy-notes") {
+		t.Fatal("section grant must not cover other sections")
+	}
+	// 分节授权不满足整条记录的检查，快速路径与回退路径都不放行
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("section grant must not grant the whole record")
+	}
+	env.dropAccessEntries("rec1", specialist.id)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("section grant must not grant the whole record via the access list")
+	}
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		section, err := env.cc.ReadRecordSection(ctx, "rec1", "medications")
+		if err == nil && *section != medicationsSection {
+			t.Fatalf("unexpected section: %+v", section)
+		}
+		return err
+	})
+	env.mustFail(specialist, "cannot read section", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecordSection(ctx, "rec1", "psychotherapy-notes")
+		return err
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		allowed, err := env.cc.ValidatePermissionLevel(ctx, "rec1", specialist.id, "read")
+		if err == nil && allowed {
+			t.Fatal("section grant must not satisfy a record-level permission check")
+		}
+		return err
+	})
+}
+
+func TestWholeRecordAccessCoversSections(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSectionedRecord("rec1")
+	env.grant(patient, "rec1", specialist.id, "read", "")
+	for _, section := range []string{"medications", "psychotherapy-notes"} {
+		if !env.checkSectionAccess("rec1", specialist.id, section) {
+			t.Fatalf("whole-record grant should cover %s", section)
+		}
+		if !env.checkSectionAccess("rec1", patient.id, section) {
+			t.Fatalf("patient should read %s", section)
+		}
+	}
+	env.mustFail(patient, "has no section", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CheckSectionAccess(ctx, "rec1", specialist.id, "labs")
+		return err
+	})
+}
+
+func TestGrantSectionAccessValidation(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSectionedRecord("rec1")
+	cases := map[string]string{
+		"has no section":      `["labs"]`,
+		"duplicate section":   `["medications","medications"]`,
+		"must not be empty":   `[]`,
+		"failed to unmarshal": `"medications"`,
+	}
+	for contains, sections := range cases {
+		env.mustFail(patient, contains, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.GrantSectionAccess(ctx, "rec1", specialist.id, sections, "")
+		})
+	}
+	env.mustFail(specialist, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantSectionAccess(ctx, "rec1", specialist.id, `["medications"]`, "")
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	SensitivityNormal = "normal"
+	SensitivityHigh   = "high"
+
+	GrantProposalPending   = "pending"
+	GrantProposalActivated = "activated"
+	GrantProposalRejected  = "rejected"
+
+	// privacyOfficerRole 会签人角色，须来自与提议人不同的机构
+	privacyOfficerRole = "privacy-officer"
+)
+
+// GrantProposal 高敏感记录的两阶段授权：所有者提议，另一机构的隐私官会签后才写入权限
+type GrantProposal struct {
+	ProposalID       string `json:"proposalId"`
+	RecordID         string `json:"recordId"`
+	GranteeID        string `json:"granteeId"`
+	Action           string `json:"action"`
+	ExpiresAt        string `json:"expiresAt,omitempty"`
+	ProposedBy       string `json:"proposedBy"`
+	ProposerMSP      string `json:"proposerMsp"`
+	ActingFor        string `json:"actingFor,omitempty"`
+	Status           string `json:"status"`
+	CountersignedBy  string `json:"countersignedBy,omitempty"`
+	CountersignerMSP string `json:"countersignerMsp,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+}
+
+type GrantProposalEvent struct {
+	ProposalID string `json:"proposalId"`
+	RecordID   string `json:"recordId"`
+	GranteeID  string `json:"granteeId"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+	Timestamp  string `json:"timestamp"`
+	CallerID   string `json:"callerId"`
+	EventType  string `json:"eventType"`
+}
+
+func grantProposalKey(proposalID string) string {
+	return "grant-proposal:" + proposalID
+}
+
+// requireSinglePartyGrant 高敏感记录不接受单方授权
+func requireSinglePartyGrant(record *MedicalRecord) error {
+	if record.Sensitivity == SensitivityHigh {
+		return fmt.Errorf("record %s is high-sensitivity: grants require ProposeSensitiveGrant and a countersignature", record.RecordID)
+	}
+	return nil
+}
+
+func getGrantProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*GrantProposal, error) {
+	var proposal GrantProposal
+	found, err := getJSON(ctx, grantProposalKey(proposalID), &proposal)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("grant proposal not found: %s", proposalID)
+	}
+	if proposal.Status != GrantProposalPending {
+		return nil, fmt.Errorf("grant proposal %s is %s", proposalID, proposal.Status)
+	}
+	return &proposal, nil
+}
+
+func saveGrantProposal(ctx contractapi.TransactionContextInterface, proposal *GrantProposal, eventName, callerID string) error {
+	if err := putJSON(ctx, grantProposalKey(proposal.ProposalID), proposal); err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, GrantProposalEvent{
+		ProposalID: proposal.ProposalID,
+		RecordID:   proposal.RecordID,
+		GranteeID:  proposal.GranteeID,
+		Action:     proposal.Action,
+		Status:     proposal.Status,
+		Timestamp:  proposal.UpdatedAt,
+		CallerID:   callerID,
+		EventType:  eventName,
+	})
+}
+
+// requireCountersigner 调用者须为隐私官，且与提议人分属不同机构
+func requireCountersigner(ctx contractapi.TransactionContextInterface, proposal *GrantProposal) (string, string, error) {
+	isOfficer, err := hasRole(ctx, privacyOfficerRole)
+	if err != nil {
+		return "", "", err
+	}
+	if !isOfficer {
+		return "", "", fmt.Errorf("access denied: only a privacy officer can countersign sensitive grants")
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	if mspID == proposal.ProposerMSP {
+		return "", "", fmt.Errorf("access denied: the countersigner must belong to a different organization than %s", proposal.ProposerMSP)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return callerID, mspID, nil
+}
+
+// ProposeSensitiveGrant 所有者或有授权范围的代理人提议高敏感记录授权，返回提议 ID
+func (s *SmartContract) ProposeSensitiveGrant(ctx contractapi.TransactionContextInterface, recordID, granteeID, action, expiresAt string) (string, error) {
+	if err := validateAddress(granteeID); err != nil {
+		return "", fmt.Errorf("invalid granteeID: %w", err)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	txNow, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := config.checkGrant(action, expiresAt, txNow); err != nil {
+		return "", err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	if record.Sensitivity != SensitivityHigh {
+		return "", fmt.Errorf("record %s is not high-sensitivity: use GrantAccess", recordID)
+	}
+	actingFor, err := ownerOrAgent(ctx, record.PatientID, callerID, "grant")
+	if err != nil {
+		return "", err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+
+	now := txNow.Format(time.RFC3339)
+	proposal := &GrantProposal{
+		ProposalID:  ctx.GetStub().GetTxID(),
+		RecordID:    recordID,
+		GranteeID:   granteeID,
+		Action:      action,
+		ExpiresAt:   expiresAt,
+		ProposedBy:  callerID,
+		ProposerMSP: mspID,
+		ActingFor:   actingFor,
+		Status:      GrantProposalPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	return proposal.ProposalID, saveGrantProposal(ctx, proposal, "SensitiveGrantProposed", callerID)
+}
+
+// CountersignGrant 隐私官会签，权限自此生效；授予人记为提议人
+func (s *SmartContract) CountersignGrant(ctx contractapi.TransactionContextInterface, proposalID string) error {
+	proposal, err := getGrantProposal(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+	callerID, mspID, err := requireCountersigner(ctx, proposal)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, proposal.RecordID)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	perm := AccessPermission{
+		RecordID:  proposal.RecordID,
+		GranteeID: proposal.GranteeID,
+		Action:    proposal.Action,
+		ExpiresAt: proposal.ExpiresAt,
+		GrantedAt: now,
+		GrantedBy: proposal.ProposedBy,
+		IsActive:  true,
+	}
+	if err := storeGrant(ctx, record, perm); err != nil {
+		return err
+	}
+	proposal.Status = GrantProposalActivated
+	proposal.CountersignedBy = callerID
+	proposal.CountersignerMSP = mspID
+	proposal.UpdatedAt = now
+	return saveGrantProposal(ctx, proposal, "SensitiveGrantActivated", callerID)
+}
+
+// RejectSensitiveGrant 隐私官拒绝提议
+func (s *SmartContract) RejectSensitiveGrant(ctx contractapi.TransactionContextInterface, proposalID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	proposal, err := getGrantProposal(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+	callerID, mspID, err := requireCountersigner(ctx, proposal)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	proposal.Status = GrantProposalRejected
+	proposal.CountersignedBy = callerID
+	proposal.CountersignerMSP = mspID
+	proposal.Reason = reason
+	proposal.UpdatedAt = now
+	return saveGrantProposal(ctx, proposal, "SensitiveGrantRejected", callerID)
+}
+
+// GetGrantProposal 返回会签提议，供双方核对
+func (s *SmartContract) GetGrantProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*GrantProposal, error) {
+	var proposal GrantProposal
+	found, err := getJSON(ctx, grantProposalKey(proposalID), &proposal)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("grant proposal not found: %s", proposalID)
+	}
+	return &proposal, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	privacyOfficer     = newIdentity("privacy1", "Org2MSP", "role", "privacy-officer")
+	privacyOfficerOrg1 = newIdentity("privacy2", "Org1MSP", "role", "privacy-officer")
+)
+
+func (e *testEnv) createSensitiveRecord(creator *testIdentity, recordID, patientID string) {
+	e.t.Helper()
+	var rec map[string]interface{}
+	_ = json.Unmarshal([]byte(recordJSON(creator, recordID, patientID)), &rec)
+	rec["sensitivity"] = SensitivityHigh
+	data, _ := json.Marshal(rec)
+	e.mustInvoke(creator, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateMedicalRecord(ctx, string(data))
+		return err
+	})
+}
+
+func (e *testEnv) proposeSensitiveGrant(identity *testIdentity, recordID, granteeID, action string) string {
+	e.t.Helper()
+	var proposalID string
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		proposalID, err = e.cc.ProposeSensitiveGrant(ctx, recordID, granteeID, action, "")
+		return err
+	})
+	return proposalID
+}
+
+func TestSensitiveGrantRequiresCountersignature(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSensitiveRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(patient, "high-sensitivity", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", nurse.id, "read")
+	})
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ProposeSensitiveGrant(ctx, "rec1", nurse.id, "read", "")
+		return err
+	})
+	proposalID := env.proposeSensitiveGrant(patient, "rec1", nurse.id, "read")
+	env.expectEvent("SensitiveGrantProposed", nil)
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("proposal alone must not grant access")
+	}
+
+	env.mustFail(doctor, "only a privacy officer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+	env.mustFail(privacyOfficerOrg1, "different organization", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+	env.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+	var event GrantProposalEvent
+	env.expectEvent("SensitiveGrantActivated", &event)
+	if event.Status != GrantProposalActivated || event.GranteeID != nurse.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("countersigned grant must be active")
+	}
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		proposal, err := env.cc.GetGrantProposal(ctx, proposalID)
+		if err == nil && (proposal.CountersignedBy != privacyOfficer.id || proposal.CountersignerMSP != "Org2MSP") {
+			t.Fatalf("unexpected proposal: %+v", proposal)
+		}
+		return err
+	})
+	env.mustFail(privacyOfficer, "is activated", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+}
+
+func TestRejectSensitiveGrant(t *testing.T) {
+	env := newTestEnv(t)
+	env.createSensitiveRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustFail(patient, "not high-sensitivity", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ProposeSensitiveGrant(ctx, "rec2", nurse.id, "read", "")
+		return err
+	})
+
+	proposalID := env.proposeSensitiveGrant(patient, "rec1", nurse.id, "read")
+	env.mustFail(privacyOfficer, "reason is required", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RejectSensitiveGrant(ctx, proposalID, "")
+	})
+	env.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RejectSensitiveGrant(ctx, proposalID, "no treatment relationship")
+	})
+	env.expectEvent("SensitiveGrantRejected", nil)
+	env.mustFail(privacyOfficer, "is rejected", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CountersignGrant(ctx, proposalID)
+	})
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("rejected proposal must not grant access")
+	}
+}
+
+func TestSensitiveRecordBlocksOtherGrantPaths(t *testing.T) {
+	env := newTestEnv(t)
+	var rec map[string]interface{}
+	_ = json.Unmarshal([]byte(recordJSON(doctor, "rec1", patient.id)), &rec)
+	rec["sensitivity"] = "secret"
+	invalid, _ := json.Marshal(rec)
+	env.mustFail(doctor, "invalid sensitivity", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecord(ctx, string(invalid))
+		return err
+	})
+
+	rec["sensitivity"] = SensitivityHigh
+	high, _ := json.Marshal(rec)
+	env.mustFail(doctor, "high-sensitivity", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, string(high), `[{"granteeId":"`+nurse.id+`","action":"read"}]`)
+		return err
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateMedicalRecordWithGrants(ctx, string(high), `[]`)
+		return err
+	})
+	env.mustFail(patient, "high-sensitivity", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateReferral(ctx, `{"referralId":"ref1","patientId":"patient1","toProviderId":"specialist1","recordIds":["rec1"],"expiresAt":"2027-01-01T00:00:00Z"}`)
+	})
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ShareCodePending   = "pending"
+	ShareCodeClaimed   = "claimed"
+	ShareCodeCancelled = "cancelled"
+
+	// maxShareCodeRecords 单个分享码可覆盖的记录上限
+	maxShareCodeRecords = 20
+)
+
+// ShareCode 前台出示的二维码授权：覆盖同一患者的多条记录，在短时间内由第一个认领者绑定。
+// 与一次性令牌共用原文规则与兑换承诺（CommitTokenRedemption），授权按 GrantExpiresAt 过期而非按次数
+type ShareCode struct {
+	CodeHash       string   `json:"codeHash"`
+	RecordIDs      []string `json:"recordIds"`
+	PatientID      string   `json:"patientId"`
+	Action         string   `json:"action"`
+	GrantExpiresAt string   `json:"grantExpiresAt,omitempty"`
+	CreatedBy      string   `json:"createdBy"`
+	CreatedAt      string   `json:"createdAt"`
+	ExpiresAt      string   `json:"expiresAt"`
+	Status         string   `json:"status"`
+	ClaimedBy      string   `json:"claimedBy,omitempty"`
+	UpdatedAt      string   `json:"updatedAt"`
+}
+
+type ShareCodeEvent struct {
+	CodeHash  string   `json:"codeHash"`
+	PatientID string   `json:"patientId"`
+	RecordIDs []string `json:"recordIds"`
+	Status    string   `json:"status"`
+	ClaimedBy string   `json:"claimedBy,omitempty"`
+	Timestamp string   `json:"timestamp"`
+	CallerID  string   `json:"callerId"`
+	EventType string   `json:"eventType"`
+}
+
+func shareCodeKey(codeHash string) string {
+	return "sharecode:" + codeHash
+}
+
+func getShareCode(ctx contractapi.TransactionContextInterface, codeHash string) (*ShareCode, error) {
+	var code ShareCode
+	found, err := getJSON(ctx, shareCodeKey(codeHash), &code)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("share code not found")
+	}
+	return &code, nil
+}
+
+func saveShareCode(ctx contractapi.TransactionContextInterface, code *ShareCode, eventName, callerID string) error {
+	if err := putJSON(ctx, shareCodeKey(code.CodeHash), code); err != nil {
+		return err
+	}
+	return emitEvent(ctx, eventName, ShareCodeEvent{
+		CodeHash:  code.CodeHash,
+		PatientID: code.PatientID,
+		RecordIDs: code.RecordIDs,
+		Status:    code.Status,
+		ClaimedBy: code.ClaimedBy,
+		Timestamp: code.UpdatedAt,
+		CallerID:  callerID,
+		EventType: eventName,
+	})
+}
+
+// CreateShareCode 患者（或有 grant 范围的代理人）为同一患者的若干记录登记分享码，返回 codeHash。
+// ttlSeconds 为认领窗口，上限取配置 maxShareCodeTtlSeconds；grantExpiresAt 为认领后授权的到期时间
+func (s *SmartContract) CreateShareCode(ctx contractapi.TransactionContextInterface, recordIDsJson, action, grantExpiresAt string, ttlSeconds int, codeHash string) (string, error) {
+	if !sha256HexPattern.MatchString(codeHash) {
+		return "", fmt.Errorf("codeHash must be a lowercase hex sha256 digest")
+	}
+	var recordIDs []string
+	if err := unmarshalArg(recordIDsJson, &recordIDs); err != nil {
+		return "", fmt.Errorf("invalid recordIds json: %w", err)
+	}
+	if len(recordIDs) == 0 || len(recordIDs) > maxShareCodeRecords {
+		return "", fmt.Errorf("a share code must cover between 1 and %d records", maxShareCodeRecords)
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ttlSeconds < 1 || ttlSeconds > config.MaxShareCodeTTLSeconds {
+		return "", fmt.Errorf("ttlSeconds must be between 1 and %d", config.MaxShareCodeTTLSeconds)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := config.checkGrant(action, grantExpiresAt, now); err != nil {
+		return "", err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	patientID := ""
+	seen := make(map[string]bool, len(recordIDs))
+	for _, recordID := range recordIDs {
+		if seen[recordID] {
+			return "", fmt.Errorf("duplicate record in share code: %s", recordID)
+		}
+		seen[recordID] = true
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return "", err
+		}
+		if patientID == "" {
+			patientID = record.PatientID
+		} else if record.PatientID != patientID {
+			return "", fmt.Errorf("all records in a share code must belong to the same patient")
+		}
+		if err := requireSinglePartyGrant(record); err != nil {
+			return "", err
+		}
+	}
+	if _, err := ownerOrAgent(ctx, patientID, callerID, "grant"); err != nil {
+		return "", err
+	}
+	if err := requireUnlocked(ctx, patientID); err != nil {
+		return "", err
+	}
+	exists, err := assetExists(ctx, shareCodeKey(codeHash))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("share code already exists")
+	}
+
+	ts := now.Format(time.RFC3339)
+	code := &ShareCode{
+		CodeHash:       codeHash,
+		RecordIDs:      recordIDs,
+		PatientID:      patientID,
+		Action:         action,
+		GrantExpiresAt: grantExpiresAt,
+		CreatedBy:      callerID,
+		CreatedAt:      ts,
+		ExpiresAt:      now.Add(time.Duration(ttlSeconds) * time.Second).Format(time.RFC3339),
+		Status:         ShareCodePending,
+		UpdatedAt:      ts,
+	}
+	return codeHash, saveShareCode(ctx, code, "ShareCodeCreated", callerID)
+}
+
+// ClaimShareCode 认领分享码：调用者须已用 CommitTokenRedemption 提交 sha256(code + ":" + 调用者 ID)，
+// 随后为其写入分享码覆盖的全部授权，分享码随即失效
+func (s *SmartContract) ClaimShareCode(ctx contractapi.TransactionContextInterface, code string) error {
+	if len(code) < minTokenSecretLength {
+		return fmt.Errorf("invalid share code")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	codeHash := sha256Hex(code)
+	var share ShareCode
+	found, err := getJSON(ctx, shareCodeKey(codeHash), &share)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("invalid share code")
+	}
+	if share.Status != ShareCodePending {
+		return fmt.Errorf("share code is %s", share.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, share.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("share code expired at %s", share.ExpiresAt)
+	}
+	if callerID == share.PatientID {
+		return fmt.Errorf("the patient cannot claim their own share code")
+	}
+	commitment := tokenCommitment(code, callerID)
+	var commit TokenCommitment
+	found, err = getJSON(ctx, tokenCommitmentKey(commitment), &commit)
+	if err != nil {
+		return err
+	}
+	if !found || commit.RedeemerID != callerID {
+		return fmt.Errorf("no redemption commitment from %s: call CommitTokenRedemption first", callerID)
+	}
+
+	ts := now.Format(time.RFC3339)
+	for _, recordID := range share.RecordIDs {
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		if record.PatientID != share.PatientID {
+			return fmt.Errorf("record %s changed owner since the share code was created", recordID)
+		}
+		if err := requireSinglePartyGrant(record); err != nil {
+			return err
+		}
+		if err := storeGrant(ctx, record, AccessPermission{
+			RecordID:  recordID,
+			GranteeID: callerID,
+			Action:    share.Action,
+			ExpiresAt: share.GrantExpiresAt,
+			GrantedAt: ts,
+			GrantedBy: share.CreatedBy,
+			IsActive:  true,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := ctx.GetStub().DelState(tokenCommitmentKey(commitment)); err != nil {
+		return fmt.Errorf("failed to delete commitment: %w", err)
+	}
+	share.Status = ShareCodeClaimed
+	share.ClaimedBy = callerID
+	share.UpdatedAt = ts
+	return saveShareCode(ctx, &share, "ShareCodeClaimed", callerID)
+}
+
+// CancelShareCode 作废尚未认领的分享码
+func (s *SmartContract) CancelShareCode(ctx contractapi.TransactionContextInterface, codeHash string) error {
+	code, err := getShareCode(ctx, codeHash)
+	if err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := ownerOrAgent(ctx, code.PatientID, callerID, "revoke"); err != nil {
+		return err
+	}
+	if code.Status != ShareCodePending {
+		return fmt.Errorf("share code is %s", code.Status)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	code.Status = ShareCodeCancelled
+	code.UpdatedAt = now
+	return saveShareCode(ctx, code, "ShareCodeCancelled", callerID)
+}
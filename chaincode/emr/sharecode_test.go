@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testShareCode = "4b6d8f0a2c4e6b8d0f2a4c6e8b0d2f4a"
+
+func (e *testEnv) createShareCode(recordIDsJson string, ttlSeconds int) {
+	e.t.Helper()
+	e.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateShareCode(ctx, recordIDsJson, "read", "", ttlSeconds, sha256Hex(testShareCode))
+		return err
+	})
+}
+
+func TestClaimShareCodeGrantsAllRecords(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(doctor, "rec3", other.id)
+
+	env.mustFail(patient, "same patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateShareCode(ctx, `["rec1","rec3"]`, "read", "", 300, sha256Hex(testShareCode))
+		return err
+	})
+	env.mustFail(patient, "ttlSeconds must be between", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateShareCode(ctx, `["rec1"]`, "read", "", 3600, sha256Hex(testShareCode))
+		return err
+	})
+	env.createShareCode(`["rec1","rec2"]`, 300)
+	env.expectEvent("ShareCodeCreated", nil)
+
+	env.mustFail(specialist, "call CommitTokenRedemption first", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ClaimShareCode(ctx, testShareCode)
+	})
+	env.commitRedemption(specialist, testShareCode)
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ClaimShareCode(ctx, testShareCode)
+	})
+	var event ShareCodeEvent
+	env.expectEvent("ShareCodeClaimed", &event)
+	if event.ClaimedBy != specialist.id || len(event.RecordIDs) != 2 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !env.checkAccess("rec1", specialist.id) || !env.checkAccess("rec2", specialist.id) {
+		t.Fatal("claimed share code must grant every record")
+	}
+
+	env.commitRedemption(other, testShareCode)
+	env.mustFail(other, "share code is claimed", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ClaimShareCode(ctx, testShareCode)
+	})
+	env.mustFail(patient, "share code is claimed", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelShareCode(ctx, sha256Hex(testShareCode))
+	})
+}
+
+func TestShareCodeExpiresAndCancels(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createShareCode(`["rec1"]`, 60)
+	env.commitRedemption(specialist, testShareCode)
+	env.advance(2 * time.Minute)
+	env.mustFail(specialist, "expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ClaimShareCode(ctx, testShareCode)
+	})
+
+	env.mustFail(nurse, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelShareCode(ctx, sha256Hex(testShareCode))
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CancelShareCode(ctx, sha256Hex(testShareCode))
+	})
+	env.expectEvent("ShareCodeCancelled", nil)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("unclaimed share code must not grant access")
+	}
+}
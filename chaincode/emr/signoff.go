@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// attendingAttribute 签核人证书须带 attending=true
+const attendingAttribute = "attending"
+
+// PendingSignOff 待签核的记录，不含存储位置
+type PendingSignOff struct {
+	RecordID   string `json:"recordId"`
+	PatientID  string `json:"patientId"`
+	CreatorID  string `json:"creatorId"`
+	RecordType string `json:"recordType"`
+	Timestamp  string `json:"timestamp"`
+}
+
+type RecordSignedOffEvent struct {
+	RecordID    string `json:"recordId"`
+	PatientID   string `json:"patientId"`
+	CreatorMSP  string `json:"creatorMsp"`
+	VersionHash string `json:"versionHash"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// pendingSignOffKey signoff-pending:{mspId}:{recordId}，值为 recordId；签核后删除
+func pendingSignOffKey(mspID, recordID string) string {
+	return pendingSignOffPrefix(mspID) + keySegment(recordID)
+}
+
+func pendingSignOffPrefix(mspID string) string {
+	return "signoff-pending:" + keySegment(mspID) + ":"
+}
+
+// requireSignOff 转换规则：记录须已由主治签核
+func requireSignOff(ctx contractapi.TransactionContextInterface, record *MedicalRecord) error {
+	if record.SignedOffBy == "" {
+		return fmt.Errorf("%s record %s requires an attending sign-off", record.RecordType, record.RecordID)
+	}
+	return nil
+}
+
+// SignOffRecord 与创建机构相同、证书带 attending=true 的身份签核待签核的记录；
+// 签核前记录不能授权给他人，主治无需先取得读取授权
+func (s *SmartContract) SignOffRecord(ctx contractapi.TransactionContextInterface, recordID string) error {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if record.DocumentStatus == "" {
+		return fmt.Errorf("record %s does not require a sign-off", recordID)
+	}
+	if record.SignedOffBy != "" {
+		return fmt.Errorf("record %s was already signed off", recordID)
+	}
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(attendingAttribute)
+	if err != nil {
+		return fmt.Errorf("failed to read %s attribute: %w", attendingAttribute, err)
+	}
+	if !found || value != "true" {
+		return fmt.Errorf("access denied: sign-off requires %s=true", attendingAttribute)
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	if mspID != record.CreatorMSP {
+		return fmt.Errorf("access denied: sign-off must come from %s", record.CreatorMSP)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	record.SignedOffBy = callerID
+	record.SignedOffAt = now
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	if err := ctx.GetStub().DelState(pendingSignOffKey(record.CreatorMSP, recordID)); err != nil {
+		return fmt.Errorf("failed to delete pending sign-off: %w", err)
+	}
+	return emitEvent(ctx, "RecordSignedOff", RecordSignedOffEvent{
+		RecordID:    recordID,
+		PatientID:   record.PatientID,
+		CreatorMSP:  record.CreatorMSP,
+		VersionHash: versionOf(record),
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   "RecordSignedOff",
+	})
+}
+
+// ListPendingSignOffs 列出 mspID 创建的待签核记录；限该机构带 attending=true 的身份与审计员
+func (s *SmartContract) ListPendingSignOffs(ctx contractapi.TransactionContextInterface, mspID string) ([]*PendingSignOff, error) {
+	if mspID == "" {
+		return nil, fmt.Errorf("mspID is required")
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		callerMSP, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MSP ID: %w", err)
+		}
+		value, found, err := ctx.GetClientIdentity().GetAttributeValue(attendingAttribute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s attribute: %w", attendingAttribute, err)
+		}
+		allowed = callerMSP == mspID && found && value == "true"
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only attendings of %s or an auditor can list pending sign-offs", mspID)
+	}
+
+	prefix := pendingSignOffPrefix(mspID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pending sign-offs: %w", err)
+	}
+	defer iterator.Close()
+
+	pending := []*PendingSignOff{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate pending sign-offs: %w", err)
+		}
+		var recordID string
+		if err := json.Unmarshal(kv.Value, &recordID); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending sign-off: %w", err)
+		}
+		record, err := getRecord(ctx, recordID)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, &PendingSignOff{
+			RecordID:   record.RecordID,
+			PatientID:  record.PatientID,
+			CreatorID:  record.CreatorID,
+			RecordType: record.RecordType,
+			Timestamp:  record.Timestamp,
+		})
+	}
+	return pending, nil
+}
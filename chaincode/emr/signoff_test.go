@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (e *testEnv) pendingSignOffs(identity *testIdentity, mspID string) []*PendingSignOff {
+	e.t.Helper()
+	var pending []*PendingSignOff
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		pending, err = e.cc.ListPendingSignOffs(ctx, mspID)
+		return err
+	})
+	return pending
+}
+
+func TestDischargeSummaryRequiresSignOff(t *testing.T) {
+	env := newTestEnv(t)
+	env.createTypedRecord("dc1", dischargeSummaryType)
+	env.createTypedRecord("lab1", "lab")
+	record := env.storedRecord("dc1")
+	if record.DocumentStatus != DocumentPreliminary || record.CreatorMSP != doctor.msp {
+		t.Fatalf("discharge summaries must start preliminary: %+v", record)
+	}
+	if record := env.storedRecord("lab1"); record.DocumentStatus != "" {
+		t.Fatalf("other record types must not be gated: %+v", record)
+	}
+	if pending := env.pendingSignOffs(attending, doctor.msp); len(pending) != 1 || pending[0].RecordID != "dc1" {
+		t.Fatalf("unexpected pending sign-offs: %+v", pending)
+	}
+	env.mustFail(doctor, "only attendings", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListPendingSignOffs(ctx, doctor.msp)
+		return err
+	})
+
+	// 签核前不能定稿，也不能授权他人
+	finalize := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.FinalizeRecord(ctx, "dc1")
+	}
+	env.mustFail(doctor, "requires an attending sign-off", finalize)
+	env.mustFail(patient, "requires an attending sign-off", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "dc1", specialist.id, "read")
+	})
+	env.grant(patient, "lab1", specialist.id, "read", "")
+
+	signOff := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SignOffRecord(ctx, "dc1")
+	}
+	env.mustFail(doctor, "requires attending=true", signOff)
+	otherOrgAttending := newIdentity("attending2", "Org2MSP", "attending", "true")
+	env.mustFail(otherOrgAttending, "must come from "+doctor.msp, signOff)
+	env.mustInvoke(attending, signOff)
+	var event RecordSignedOffEvent
+	env.expectEvent("RecordSignedOff", &event)
+	if event.CallerID != attending.id || event.CreatorMSP != doctor.msp {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustFail(attending, "already signed off", signOff)
+	if pending := env.pendingSignOffs(auditor, doctor.msp); len(pending) != 0 {
+		t.Fatalf("signed-off records must leave the pending list: %+v", pending)
+	}
+
+	env.mustFail(patient, "only the record creator", finalize)
+	env.mustInvoke(doctor, finalize)
+	env.expectEvent("RecordFinalized", nil)
+	if metadata := env.metadata(patient, "dc1"); metadata.DocumentStatus != DocumentFinal {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+	env.mustFail(doctor, "is not preliminary", finalize)
+	env.grant(patient, "dc1", specialist.id, "read", "")
+}
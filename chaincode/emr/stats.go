@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// counterShards 全局计数的分桶数；同一键的并发写入会 MVCC 冲突，按交易 ID 分散到不同桶
+const counterShards = 16
+
+const (
+	totalRecordsCounter = "counter:total-records"
+	totalGrantsCounter  = "counter:total-grants"
+)
+
+// ContractStats 仪表盘统计；计数自引入计数器之后开始累计
+type ContractStats struct {
+	TotalRecords           int64 `json:"totalRecords"`
+	TotalGrants            int64 `json:"totalGrants"`
+	GrantsExpiringThisWeek int64 `json:"grantsExpiringThisWeek"`
+}
+
+func patientRecordCounterKey(patientID string) string {
+	return "counter:patient-records:" + patientID
+}
+
+func recordGrantCounterKey(recordID string) string {
+	return "counter:record-grants:" + recordID
+}
+
+// grantExpiryKey grantexp:{yyyymmdd}:{recordId}:{granteeId}，日期定长，按日区间扫描
+func grantExpiryKey(expiresAt time.Time, recordID, granteeID string) string {
+	return "grantexp:" + expiresAt.UTC().Format(rollupDayLayout) + ":" + recordID + ":" + granteeID
+}
+
+func readCounter(ctx contractapi.TransactionContextInterface, key string) (int64, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read counter %s: %w", key, err)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid counter %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func addCounter(ctx contractapi.TransactionContextInterface, key string, delta int64) error {
+	value, err := readCounter(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, []byte(strconv.FormatInt(value+delta, 10))); err != nil {
+		return fmt.Errorf("failed to store counter %s: %w", key, err)
+	}
+	return nil
+}
+
+// addShardedCounter 按交易 ID 哈希选桶写入 {base}:{n}
+func addShardedCounter(ctx contractapi.TransactionContextInterface, base string, delta int64) error {
+	h := fnv.New32a()
+	h.Write([]byte(ctx.GetStub().GetTxID()))
+	return addCounter(ctx, base+":"+strconv.Itoa(int(h.Sum32()%counterShards)), delta)
+}
+
+func readShardedCounter(ctx contractapi.TransactionContextInterface, base string) (int64, error) {
+	var total int64
+	for n := 0; n < counterShards; n++ {
+		value, err := readCounter(ctx, base+":"+strconv.Itoa(n))
+		if err != nil {
+			return 0, err
+		}
+		total += value
+	}
+	return total, nil
+}
+
+// countRecordCreated 由 CreateMedicalRecord 调用
+func countRecordCreated(ctx contractapi.TransactionContextInterface, patientID string) error {
+	if err := addShardedCounter(ctx, totalRecordsCounter, 1); err != nil {
+		return err
+	}
+	return addCounter(ctx, patientRecordCounterKey(patientID), 1)
+}
+
+// trackGrantChange 维护授权计数与到期索引；previous 为写入前的单独权限（可为 nil）
+func trackGrantChange(ctx contractapi.TransactionContextInterface, previous *AccessPermission, next AccessPermission) error {
+	wasActive := previous != nil && previous.IsActive
+	if wasActive && previous.ExpiresAt != "" {
+		if expiresAt, err := time.Parse(time.RFC3339, previous.ExpiresAt); err == nil {
+			if err := ctx.GetStub().DelState(grantExpiryKey(expiresAt, previous.RecordID, previous.GranteeID)); err != nil {
+				return fmt.Errorf("failed to delete grant expiry index: %w", err)
+			}
+		}
+	}
+	if next.IsActive && next.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, next.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid expiresAt: %w", err)
+		}
+		if err := ctx.GetStub().PutState(grantExpiryKey(expiresAt, next.RecordID, next.GranteeID), indexValue); err != nil {
+			return fmt.Errorf("failed to store grant expiry index: %w", err)
+		}
+	}
+
+	delta := int64(0)
+	if next.IsActive && !wasActive {
+		delta = 1
+	} else if !next.IsActive && wasActive {
+		delta = -1
+	}
+	if delta == 0 {
+		return nil
+	}
+	if err := addShardedCounter(ctx, totalGrantsCounter, delta); err != nil {
+		return err
+	}
+	return addCounter(ctx, recordGrantCounterKey(next.RecordID), delta)
+}
+
+// GetPatientRecordCount 患者记录数，患者本人、有效代理人或 auditor 角色可查询
+func (s *SmartContract) GetPatientRecordCount(ctx contractapi.TransactionContextInterface, patientID string) (int64, error) {
+	if _, err := requirePatientOrAgent(ctx, patientID, "consent", "view the record count"); err != nil {
+		auditor, roleErr := isAuditor(ctx)
+		if roleErr != nil {
+			return 0, roleErr
+		}
+		if !auditor {
+			return 0, err
+		}
+	}
+	return readCounter(ctx, patientRecordCounterKey(patientID))
+}
+
+// GetAccessGrantCount 记录上未撤销的授权数（不含创建者的初始权限），须具备记录访问权限
+func (s *SmartContract) GetAccessGrantCount(ctx contractapi.TransactionContextInterface, recordID string) (int64, error) {
+	if err := s.requireRecordAccess(ctx, recordID); err != nil {
+		return 0, err
+	}
+	return readCounter(ctx, recordGrantCounterKey(recordID))
+}
+
+// GetContractStats 全局统计，仅 admin/auditor 角色；到期数扫描交易时间起 7 天内的到期索引
+func (s *SmartContract) GetContractStats(ctx contractapi.TransactionContextInterface) (*ContractStats, error) {
+	allowed, err := hasAnyRole(ctx, "admin", auditorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only admin or auditor roles can view contract stats")
+	}
+	stats := &ContractStats{}
+	if stats.TotalRecords, err = readShardedCounter(ctx, totalRecordsCounter); err != nil {
+		return nil, err
+	}
+	if stats.TotalGrants, err = readShardedCounter(ctx, totalGrantsCounter); err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	startKey := "grantexp:" + now.UTC().Format(rollupDayLayout) + ":"
+	endKey := "grantexp:" + now.UTC().AddDate(0, 0, 7).Format(rollupDayLayout) + ":"
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grant expiry index: %w", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		if _, err := iterator.Next(); err != nil {
+			return nil, fmt.Errorf("failed to iterate grant expiry index: %w", err)
+		}
+		stats.GrantsExpiringThisWeek++
+	}
+	return stats, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestCountersFollowRecordsAndGrants(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.createRecord(doctor, "rec3", "patient2")
+
+	env.grant(patient, "rec1", nurse.id, "read", env.stub.now.Add(3*24*time.Hour).Format(time.RFC3339))
+	env.grant(patient, "rec1", other.id, "read", env.stub.now.Add(30*24*time.Hour).Format(time.RFC3339))
+	env.grant(patient, "rec2", nurse.id, "read", "")
+	// 重复授权只更新到期时间，不重复计数
+	env.grant(patient, "rec2", nurse.id, "read", env.stub.now.Add(5*24*time.Hour).Format(time.RFC3339))
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", other.id)
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		count, err := env.cc.GetPatientRecordCount(ctx, patient.id)
+		if err == nil && count != 2 {
+			t.Fatalf("expected 2 records, got %d", count)
+		}
+		return err
+	})
+	env.mustFail(nurse, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetPatientRecordCount(ctx, patient.id)
+		return err
+	})
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		count, err := env.cc.GetAccessGrantCount(ctx, "rec1")
+		if err == nil && count != 1 {
+			t.Fatalf("expected 1 grant on rec1, got %d", count)
+		}
+		return err
+	})
+
+	var stats *ContractStats
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		stats, err = env.cc.GetContractStats(ctx)
+		return err
+	})
+	if stats.TotalRecords != 3 || stats.TotalGrants != 2 || stats.GrantsExpiringThisWeek != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	env.mustFail(doctor, "only admin or auditor", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetContractStats(ctx)
+		return err
+	})
+}
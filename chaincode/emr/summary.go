@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// summaryRecentAccessLimit 汇总中最近授权变动的条数
+const summaryRecentAccessLimit = 10
+
+// PatientSummary 移动端首页所需的患者汇总，一次查询返回
+type PatientSummary struct {
+	PatientID             string                    `json:"patientId"`
+	RecordCountsByType    map[string]int            `json:"recordCountsByType"`
+	LatestByType          map[string]*MedicalRecord `json:"latestByType"`
+	ActiveGrants          int                       `json:"activeGrants"`
+	PendingAccessRequests []*Referral               `json:"pendingAccessRequests"`
+	RecentAccessChanges   []AccessPermission        `json:"recentAccessChanges"`
+}
+
+// summaryType 汇总分组：recordType，其次 FHIR 资源类型
+func summaryType(record *MedicalRecord) string {
+	switch {
+	case record.RecordType != "":
+		return record.RecordType
+	case record.FhirResourceType != "":
+		return record.FhirResourceType
+	default:
+		return "unspecified"
+	}
+}
+
+// GetPatientSummary 遍历一次 patient~record 索引汇总记录与授权，并列出尚未接受且未过期的转诊；
+// 仅患者本人或有效的医疗委托代理人
+func (s *SmartContract) GetPatientSummary(ctx contractapi.TransactionContextInterface, patientID string) (*PatientSummary, error) {
+	if _, err := requirePatientOrAgent(ctx, patientID, "consent", "view the patient summary"); err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PatientSummary{
+		PatientID:             patientID,
+		RecordCountsByType:    map[string]int{},
+		LatestByType:          map[string]*MedicalRecord{},
+		PendingAccessRequests: []*Referral{},
+		RecentAccessChanges:   []AccessPermission{},
+	}
+	err = scanIndex(ctx, patientRecordIndex, []string{patientID}, func(attrs []string) error {
+		record, err := getRecord(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		recordType := summaryType(record)
+		summary.RecordCountsByType[recordType]++
+		// 时间戳均为 UTC RFC3339，可直接按字符串比较
+		if latest := summary.LatestByType[recordType]; latest == nil || record.Timestamp > latest.Timestamp {
+			summary.LatestByType[recordType] = record
+		}
+
+		accessList, err := getAccessList(ctx, record.RecordID)
+		if err != nil || accessList == nil {
+			return err
+		}
+		for _, perm := range accessList.Permissions {
+			if permissionActive(perm, now) {
+				summary.ActiveGrants++
+			}
+			summary.RecentAccessChanges = append(summary.RecentAccessChanges, perm)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(summary.RecentAccessChanges, func(i, j int) bool {
+		a, b := summary.RecentAccessChanges[i], summary.RecentAccessChanges[j]
+		if a.GrantedAt != b.GrantedAt {
+			return a.GrantedAt > b.GrantedAt
+		}
+		return a.RecordID+a.GranteeID < b.RecordID+b.GranteeID
+	})
+	if len(summary.RecentAccessChanges) > summaryRecentAccessLimit {
+		summary.RecentAccessChanges = summary.RecentAccessChanges[:summaryRecentAccessLimit]
+	}
+
+	err = scanIndex(ctx, referralPatientIndex, []string{patientID}, func(attrs []string) error {
+		referral, err := getReferral(ctx, attrs[1])
+		if err != nil {
+			return err
+		}
+		if referral.Status != ReferralCreated {
+			return nil
+		}
+		expired, err := referralExpired(referral, now)
+		if err != nil || expired {
+			return err
+		}
+		summary.PendingAccessRequests = append(summary.PendingAccessRequests, referral)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect pending referrals: %w", err)
+	}
+	return summary, nil
+}
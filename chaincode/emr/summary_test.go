@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestGetPatientSummary(t *testing.T) {
+	env := newTestEnv(t)
+	create := func(recordID, recordType string) {
+		env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.CreateMedicalRecord(ctx, `{"recordId":"`+recordID+`","patientId":"patient1","creatorId":"doctor1","ipfsCid":"bafy","contentHash":"`+
+				strings.Repeat("a", 64)+`","recordType":"`+recordType+`"}`)
+			return err
+		})
+		env.advance(time.Hour)
+	}
+	create("lab1", "lab")
+	create("note1", "note")
+	create("lab2", "lab")
+	env.grant(patient, "lab1", nurse.id, "read", "")
+	env.advance(time.Minute)
+	env.grant(patient, "note1", other.id, "read", env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	createTestReferral(env, "ref1", 7*24*time.Hour, `["lab2"]`)
+	createTestReferral(env, "ref2", time.Hour, `["lab1"]`)
+	env.advance(2 * time.Hour)
+
+	var summary *PatientSummary
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		summary, err = env.cc.GetPatientSummary(ctx, patient.id)
+		return err
+	})
+	if summary.RecordCountsByType["lab"] != 2 || summary.RecordCountsByType["note"] != 1 {
+		t.Fatalf("unexpected counts: %v", summary.RecordCountsByType)
+	}
+	if summary.LatestByType["lab"].RecordID != "lab2" {
+		t.Fatalf("unexpected latest lab: %+v", summary.LatestByType["lab"])
+	}
+	// 3 条创建者权限 + 护士授权；other 的授权已过期
+	if summary.ActiveGrants != 4 {
+		t.Fatalf("expected 4 active grants, got %d", summary.ActiveGrants)
+	}
+	if len(summary.PendingAccessRequests) != 1 || summary.PendingAccessRequests[0].ReferralID != "ref1" {
+		t.Fatalf("unexpected pending requests: %+v", summary.PendingAccessRequests)
+	}
+	if len(summary.RecentAccessChanges) != 5 || summary.RecentAccessChanges[0].GranteeID != other.id {
+		t.Fatalf("unexpected recent access changes: %+v", summary.RecentAccessChanges)
+	}
+
+	env.mustFail(doctor, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetPatientSummary(ctx, patient.id)
+		return err
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// traineeAttribute 证书属性 trainee=true 标识实习人员；未关联督导人时其授权一律不生效
+const traineeAttribute = "trainee"
+
+// Supervision 实习人员与督导人的关联：实习人员的授权仅在督导人对同一记录也持有有效授权时生效
+type Supervision struct {
+	TraineeID    string `json:"traineeId"`
+	SupervisorID string `json:"supervisorId"`
+	MSPID        string `json:"mspId"`
+	LinkedAt     string `json:"linkedAt"`
+	LinkedBy     string `json:"linkedBy"`
+}
+
+type SupervisionEvent struct {
+	TraineeID    string `json:"traineeId"`
+	SupervisorID string `json:"supervisorId"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+// supervisionKey supervision:{traineeId}
+func supervisionKey(traineeID string) string {
+	return "supervision:" + keySegment(traineeID)
+}
+
+func getSupervision(ctx contractapi.TransactionContextInterface, traineeID string) (*Supervision, error) {
+	var supervision Supervision
+	found, err := getJSON(ctx, supervisionKey(traineeID), &supervision)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &supervision, nil
+}
+
+// superviseGrant 授权给已关联督导人的实习人员时在授权上记下督导人
+func superviseGrant(ctx contractapi.TransactionContextInterface, perm *AccessPermission) error {
+	supervision, err := getSupervision(ctx, perm.GranteeID)
+	if err != nil || supervision == nil {
+		return err
+	}
+	perm.SupervisorID = supervision.SupervisorID
+	return nil
+}
+
+// setGrantSupervisor 同步实习人员全部授权上的督导人；supervisorID 为空表示解除
+func setGrantSupervisor(ctx contractapi.TransactionContextInterface, traineeID, supervisorID, now string) error {
+	return scanIndex(ctx, granteeRecordIndex, []string{traineeID}, func(attrs []string) error {
+		_, err := updateGrant(ctx, attrs[1], traineeID, now, func(perm *AccessPermission) {
+			perm.SupervisorID = supervisorID
+		})
+		return err
+	})
+}
+
+// supervisedGrantValid 授权未记督导人时，被授权人不得是持 trainee 属性的调用者；记有督导人时，
+// 督导人须是记录创建者或对同一记录持有有效授权。只在实习人员的授权上多读
+func supervisedGrantValid(ctx contractapi.TransactionContextInterface, perm AccessPermission, now time.Time) (bool, error) {
+	if perm.SupervisorID == "" {
+		trainee, err := callerAttribute(ctx, traineeAttribute)
+		if err != nil || trainee != "true" {
+			return err == nil, err
+		}
+		isGrantee, err := callerIs(ctx, perm.GranteeID)
+		return !isGrantee, err
+	}
+	supervisorPerm, err := getPermission(ctx, perm.RecordID, perm.SupervisorID)
+	if err != nil {
+		return false, err
+	}
+	if supervisorPerm != nil && permissionActive(*supervisorPerm, now) {
+		return grantDUAValid(ctx, *supervisorPerm, now)
+	}
+	record, err := getRecord(ctx, perm.RecordID)
+	if err != nil {
+		return false, err
+	}
+	return record.CreatorID == perm.SupervisorID, nil
+}
+
+// LinkSupervisor 机构 admin 为实习人员关联督导人，覆盖原有关联；督导人须持有效执业凭证。
+// 实习人员已有的授权一并改记新督导人
+func (s *SmartContract) LinkSupervisor(ctx contractapi.TransactionContextInterface, traineeID, supervisorID string) error {
+	if err := validateAddress(traineeID); err != nil {
+		return fmt.Errorf("invalid traineeID: %w", err)
+	}
+	if err := validateAddress(supervisorID); err != nil {
+		return fmt.Errorf("invalid supervisorID: %w", err)
+	}
+	if traineeID == supervisorID {
+		return fmt.Errorf("trainee cannot supervise themselves")
+	}
+	callerID, mspID, err := requireAdminCaller(ctx, "link supervisors")
+	if err != nil {
+		return err
+	}
+	existing, err := getSupervision(ctx, traineeID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.MSPID != mspID {
+		return fmt.Errorf("access denied: only admin of %s can change the supervisor of %s", existing.MSPID, traineeID)
+	}
+	supervisorIsTrainee, err := assetExists(ctx, supervisionKey(supervisorID))
+	if err != nil {
+		return err
+	}
+	if supervisorIsTrainee {
+		return fmt.Errorf("supervisor %s is a trainee", supervisorID)
+	}
+	if err := requireProviderCredential(ctx, supervisorID); err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, supervisionKey(traineeID), Supervision{
+		TraineeID:    traineeID,
+		SupervisorID: supervisorID,
+		MSPID:        mspID,
+		LinkedAt:     now,
+		LinkedBy:     callerID,
+	}); err != nil {
+		return err
+	}
+	if err := setGrantSupervisor(ctx, traineeID, supervisorID, now); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "SupervisorLinked", SupervisionEvent{
+		TraineeID:    traineeID,
+		SupervisorID: supervisorID,
+		Timestamp:    now,
+		CallerID:     callerID,
+		EventType:    "SupervisorLinked",
+	})
+}
+
+// UnlinkSupervisor 关联机构的 admin 解除督导关联，实习人员的授权不再受督导人约束；
+// 证书仍带 trainee 属性的身份在解除后无法使用其授权
+func (s *SmartContract) UnlinkSupervisor(ctx contractapi.TransactionContextInterface, traineeID string) error {
+	supervision, err := getSupervision(ctx, traineeID)
+	if err != nil {
+		return err
+	}
+	if supervision == nil {
+		return fmt.Errorf("no supervisor linked to %s", traineeID)
+	}
+	callerID, err := requireOrgAdmin(ctx, supervision.MSPID, "unlink supervisors")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(supervisionKey(traineeID)); err != nil {
+		return fmt.Errorf("failed to delete supervision: %w", err)
+	}
+	if err := setGrantSupervisor(ctx, traineeID, "", now); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "SupervisorUnlinked", SupervisionEvent{
+		TraineeID:    traineeID,
+		SupervisorID: supervision.SupervisorID,
+		Timestamp:    now,
+		CallerID:     callerID,
+		EventType:    "SupervisorUnlinked",
+	})
+}
+
+// GetSupervision 返回实习人员的督导关联；限实习人员本人、督导人、关联机构 admin 与审计员
+func (s *SmartContract) GetSupervision(ctx contractapi.TransactionContextInterface, traineeID string) (*Supervision, error) {
+	supervision, err := getSupervision(ctx, traineeID)
+	if err != nil {
+		return nil, err
+	}
+	if supervision == nil {
+		return nil, fmt.Errorf("no supervisor linked to %s", traineeID)
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		_, err := requireOrgAdmin(ctx, supervision.MSPID, "view supervision")
+		allowed = err == nil
+	}
+	for _, subjectID := range []string{traineeID, supervision.SupervisorID} {
+		if allowed {
+			break
+		}
+		if allowed, err = callerIs(ctx, subjectID); err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only the trainee, the supervisor, an admin of %s or an auditor can view the supervision", supervision.MSPID)
+	}
+	return supervision, nil
+}
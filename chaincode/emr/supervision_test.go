@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var trainee = newIdentity("trainee1", "Org1MSP", traineeAttribute, "true")
+
+func TestTraineeGrantsRequireSupervisorGrant(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(nurse, "rec1", patient.id)
+	env.createRecord(specialist, "rec2", patient.id)
+	env.grant(patient, "rec1", trainee.id, "read", "")
+
+	// 未关联督导人的实习人员不能使用授权
+	if env.canRead(trainee, "rec1") {
+		t.Fatal("unsupervised trainee grants must not apply")
+	}
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.LinkSupervisor(ctx, trainee.id, specialist.id)
+	})
+	env.mustFail(admin, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.LinkSupervisor(ctx, trainee.id, other.id)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.LinkSupervisor(ctx, trainee.id, specialist.id)
+	})
+	env.expectEvent("SupervisorLinked", nil)
+	if perm := storedPermission(env, "rec1", trainee.id); perm.SupervisorID != specialist.id {
+		t.Fatalf("existing grants must record the supervisor: %+v", perm)
+	}
+
+	if env.canRead(trainee, "rec1") || env.checkAccess("rec1", trainee.id) {
+		t.Fatal("trainee grants must wait for the supervisor's grant")
+	}
+	env.grant(patient, "rec1", specialist.id, "read", "")
+	if !env.checkAccess("rec1", trainee.id) {
+		t.Fatal("trainee grants must apply while the supervisor holds a grant")
+	}
+	env.mustInvoke(trainee, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	var event RecordAccessedEvent
+	env.expectEvent("RecordAccessed", &event)
+	if event.SupervisorID != specialist.id {
+		t.Fatalf("trainee access events must carry the supervisor: %+v", event)
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", specialist.id)
+	})
+	if env.checkAccess("rec1", trainee.id) {
+		t.Fatal("trainee grants must lapse with the supervisor's grant")
+	}
+
+	// 督导人是记录创建者时同样满足
+	env.grant(patient, "rec2", trainee.id, "read", "")
+	if perm := storedPermission(env, "rec2", trainee.id); perm.SupervisorID != specialist.id {
+		t.Fatalf("new grants must record the supervisor: %+v", perm)
+	}
+	if !env.canRead(trainee, "rec2") {
+		t.Fatal("supervisor's own records must count as held grants")
+	}
+
+	env.mustFail(org2Admin, "only admin of Org1MSP", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlinkSupervisor(ctx, trainee.id)
+	})
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UnlinkSupervisor(ctx, trainee.id)
+	})
+	if perm := storedPermission(env, "rec1", trainee.id); perm.SupervisorID != "" {
+		t.Fatalf("unlinking must clear the supervisor: %+v", perm)
+	}
+	if env.canRead(trainee, "rec2") {
+		t.Fatal("identities still holding the trainee attribute need a supervisor")
+	}
+}
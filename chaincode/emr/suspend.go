@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+type AccessSuspensionEvent struct {
+	RecordID  string `json:"recordId"`
+	GranteeID string `json:"granteeId"`
+	Reason    string `json:"reason,omitempty"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	ActingFor string `json:"actingFor,omitempty"`
+	EventType string `json:"eventType"`
+}
+
+// currentGrant 返回授权当前状态，优先取单独权限，其次取访问列表条目
+func currentGrant(ctx contractapi.TransactionContextInterface, recordID, granteeID string) (*AccessPermission, error) {
+	perm, err := getPermission(ctx, recordID, granteeID)
+	if err != nil || perm != nil {
+		return perm, err
+	}
+	accessList, err := getAccessList(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if accessList != nil {
+		if entry, ok := accessList.Permissions[granteeID]; ok {
+			return &entry, nil
+		}
+	}
+	return nil, fmt.Errorf("permission not found for %s on record %s", granteeID, recordID)
+}
+
+// SuspendAccess 暂停授权：只把 isActive 置为 false 并记录暂停时间与原因，授权内容保持不变
+func (s *SmartContract) SuspendAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	actingFor, err := ownerOrAgent(ctx, record.PatientID, callerID, "revoke")
+	if err != nil {
+		return err
+	}
+	perm, err := currentGrant(ctx, recordID, granteeID)
+	if err != nil {
+		return err
+	}
+	if !perm.IsActive {
+		return fmt.Errorf("permission for %s on record %s is not active", granteeID, recordID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := updateGrant(ctx, recordID, granteeID, now, func(perm *AccessPermission) {
+		perm.IsActive = false
+		perm.SuspendedAt = now
+		perm.SuspendReason = reason
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AccessSuspended", AccessSuspensionEvent{
+		RecordID:  recordID,
+		GranteeID: granteeID,
+		Reason:    reason,
+		Timestamp: now,
+		CallerID:  callerID,
+		ActingFor: actingFor,
+		EventType: "AccessSuspended",
+	})
+}
+
+// ResumeAccess 恢复暂停的授权，原有效期不顺延；已撤销的授权不能恢复
+func (s *SmartContract) ResumeAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID string) error {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	actingFor, err := ownerOrAgent(ctx, record.PatientID, callerID, "grant")
+	if err != nil {
+		return err
+	}
+	if err := requireUnlocked(ctx, record.PatientID); err != nil {
+		return err
+	}
+	perm, err := currentGrant(ctx, recordID, granteeID)
+	if err != nil {
+		return err
+	}
+	if perm.SuspendedAt == "" {
+		return fmt.Errorf("permission for %s on record %s is not suspended", granteeID, recordID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := updateGrant(ctx, recordID, granteeID, now, func(perm *AccessPermission) {
+		perm.IsActive = true
+		perm.SuspendedAt = ""
+		perm.SuspendReason = ""
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AccessResumed", AccessSuspensionEvent{
+		RecordID:  recordID,
+		GranteeID: granteeID,
+		Timestamp: now,
+		CallerID:  callerID,
+		ActingFor: actingFor,
+		EventType: "AccessResumed",
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestSuspendAndResumeAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	expiresAt := env.stub.now.Add(48 * time.Hour).Format(time.RFC3339)
+	env.grant(patient, "rec1", nurse.id, "read", expiresAt)
+
+	env.mustFail(nurse, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SuspendAccess(ctx, "rec1", nurse.id, "leave of absence")
+	})
+	env.mustFail(patient, "not suspended", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResumeAccess(ctx, "rec1", nurse.id)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SuspendAccess(ctx, "rec1", nurse.id, "leave of absence")
+	})
+	var event AccessSuspensionEvent
+	env.expectEvent("AccessSuspended", &event)
+	if event.Reason != "leave of absence" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("suspended grant must not allow access")
+	}
+	env.mustFail(patient, "is not active", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SuspendAccess(ctx, "rec1", nurse.id, "again")
+	})
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResumeAccess(ctx, "rec1", nurse.id)
+	})
+	env.expectEvent("AccessResumed", nil)
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("resumed grant must allow access")
+	}
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetUserPermissions(ctx, nurse.id, 10, "")
+		if err == nil && (len(page.Permissions) != 1 || page.Permissions[0].ExpiresAt != expiresAt || page.Permissions[0].SuspendedAt != "") {
+			t.Fatalf("resume must keep the original grant: %+v", page.Permissions)
+		}
+		return err
+	})
+	// 有效期不因暂停顺延
+	env.advance(49 * time.Hour)
+	if env.checkAccess("rec1", nurse.id) {
+		t.Fatal("resumed grant must still expire at its original time")
+	}
+}
+
+func TestRevokedGrantCannotBeResumed(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.SuspendAccess(ctx, "rec1", nurse.id, "investigation")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeAccess(ctx, "rec1", nurse.id)
+	})
+	env.mustFail(patient, "not suspended", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ResumeAccess(ctx, "rec1", nurse.id)
+	})
+}
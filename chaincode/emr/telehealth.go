@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const telehealthPatientIndex = "session~patient"
+
+// TelehealthSession 远程诊疗会话锚点；录像只存哈希
+type TelehealthSession struct {
+	SessionID         string   `json:"sessionId"`
+	PatientID         string   `json:"patientId"`
+	ClinicianID       string   `json:"clinicianId"`
+	Participants      []string `json:"participants,omitempty"`
+	StartedAt         string   `json:"startedAt"`
+	EndedAt           string   `json:"endedAt"`
+	RecordingHash     string   `json:"recordingHash,omitempty"`
+	EncounterRecordID string   `json:"encounterRecordId"`
+	CreatedAt         string   `json:"createdAt"`
+}
+
+type TelehealthSessionCreatedEvent struct {
+	SessionID         string `json:"sessionId"`
+	PatientID         string `json:"patientId"`
+	ClinicianID       string `json:"clinicianId"`
+	EncounterRecordID string `json:"encounterRecordId"`
+	Timestamp         string `json:"timestamp"`
+	CallerID          string `json:"callerId"`
+	EventType         string `json:"eventType"`
+}
+
+func telehealthKey(sessionID string) string {
+	return "telehealth:" + sessionID
+}
+
+func getTelehealthSession(ctx contractapi.TransactionContextInterface, sessionID string) (*TelehealthSession, error) {
+	var session TelehealthSession
+	found, err := getJSON(ctx, telehealthKey(sessionID), &session)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("telehealth session not found: %s", sessionID)
+	}
+	return &session, nil
+}
+
+// canViewSession 患者与主诊医生自动可读；其他身份须对关联的就诊记录通过 CheckAccess
+func (s *SmartContract) canViewSession(ctx contractapi.TransactionContextInterface, session *TelehealthSession, callerID string) (bool, error) {
+	if callerID == session.PatientID || callerID == session.ClinicianID {
+		return true, nil
+	}
+	return s.CheckAccess(ctx, session.EncounterRecordID, callerID)
+}
+
+// CreateTelehealthSession 主诊医生锚定会话；调用者须为 clinicianId 且对就诊记录持有 write
+func (s *SmartContract) CreateTelehealthSession(ctx contractapi.TransactionContextInterface, sessionJson string) error {
+	var session TelehealthSession
+	if err := unmarshalArg(sessionJson, &session); err != nil {
+		return fmt.Errorf("invalid session json: %w", err)
+	}
+	if session.SessionID == "" || session.PatientID == "" || session.ClinicianID == "" || session.EncounterRecordID == "" {
+		return fmt.Errorf("missing required fields: sessionId, patientId, clinicianId and encounterRecordId are required")
+	}
+	for _, id := range append([]string{session.SessionID, session.PatientID, session.ClinicianID}, session.Participants...) {
+		if err := validateAddress(id); err != nil {
+			return err
+		}
+	}
+	startedAt, err := time.Parse(time.RFC3339, session.StartedAt)
+	if err != nil {
+		return fmt.Errorf("invalid startedAt: %w", err)
+	}
+	endedAt, err := time.Parse(time.RFC3339, session.EndedAt)
+	if err != nil {
+		return fmt.Errorf("invalid endedAt: %w", err)
+	}
+	if endedAt.Before(startedAt) {
+		return fmt.Errorf("endedAt must not be before startedAt")
+	}
+	if session.RecordingHash != "" && !sha256HexPattern.MatchString(session.RecordingHash) {
+		return fmt.Errorf("invalid recordingHash: expected hex-encoded SHA-256")
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != session.ClinicianID {
+		return fmt.Errorf("access denied: only the clinician can anchor the session")
+	}
+	record, err := getRecord(ctx, session.EncounterRecordID)
+	if err != nil {
+		return err
+	}
+	if record.PatientID != session.PatientID {
+		return fmt.Errorf("record %s does not belong to patient %s", session.EncounterRecordID, session.PatientID)
+	}
+	allowed, err := s.ValidatePermissionLevel(ctx, session.EncounterRecordID, callerID, "write")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("access denied: %s cannot write record %s", callerID, session.EncounterRecordID)
+	}
+	exists, err := assetExists(ctx, telehealthKey(session.SessionID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("telehealth session already exists: %s", session.SessionID)
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	session.StartedAt = startedAt.UTC().Format(time.RFC3339)
+	session.EndedAt = endedAt.UTC().Format(time.RFC3339)
+	session.CreatedAt = now
+	if err := putJSON(ctx, telehealthKey(session.SessionID), session); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, telehealthPatientIndex, session.PatientID, session.StartedAt, session.SessionID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, "TelehealthSessionCreated", TelehealthSessionCreatedEvent{
+		SessionID:         session.SessionID,
+		PatientID:         session.PatientID,
+		ClinicianID:       session.ClinicianID,
+		EncounterRecordID: session.EncounterRecordID,
+		Timestamp:         now,
+		CallerID:          callerID,
+		EventType:         "TelehealthSessionCreated",
+	})
+}
+
+// GetTelehealthSession 读取会话锚点
+func (s *SmartContract) GetTelehealthSession(ctx contractapi.TransactionContextInterface, sessionID string) (*TelehealthSession, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := getTelehealthSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := s.canViewSession(ctx, session, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: %s cannot view session %s", callerID, sessionID)
+	}
+	return session, nil
+}
+
+// ListTelehealthSessions 按开始时间升序返回患者的会话，只包含调用者可读的会话
+func (s *SmartContract) ListTelehealthSessions(ctx contractapi.TransactionContextInterface, patientID string) ([]*TelehealthSession, error) {
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []*TelehealthSession{}
+	err = scanIndex(ctx, telehealthPatientIndex, []string{patientID}, func(attrs []string) error {
+		session, err := getTelehealthSession(ctx, attrs[2])
+		if err != nil {
+			return err
+		}
+		allowed, err := s.canViewSession(ctx, session, callerID)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			sessions = append(sessions, session)
+		}
+		return nil
+	})
+	return sessions, err
+}
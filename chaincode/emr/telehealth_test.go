@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func telehealthBody(sessionID, recordID, startedAt, endedAt string) string {
+	return fmt.Sprintf(`{"sessionId":%q,"patientId":"patient1","clinicianId":"doctor1","participants":["nurse1"],"startedAt":%q,"endedAt":%q,"encounterRecordId":%q}`,
+		sessionID, startedAt, endedAt, recordID)
+}
+
+func TestTelehealthSessionAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "enc1", patient.id)
+	env.createRecord(doctor, "enc2", patient.id)
+
+	env.mustFail(doctor, "endedAt must not be before", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateTelehealthSession(ctx, telehealthBody("s1", "enc1", "2026-01-02T10:00:00Z", "2026-01-02T09:00:00Z"))
+	})
+	env.mustFail(patient, "only the clinician", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateTelehealthSession(ctx, telehealthBody("s1", "enc1", "2026-01-02T09:00:00Z", "2026-01-02T09:30:00Z"))
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateTelehealthSession(ctx, telehealthBody("s2", "enc2", "2026-01-03T09:00:00Z", "2026-01-03T09:30:00Z"))
+	})
+	env.expectEvent("TelehealthSessionCreated", nil)
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.CreateTelehealthSession(ctx, telehealthBody("s1", "enc1", "2026-01-02T09:00:00Z", "2026-01-02T09:30:00Z"))
+	})
+
+	list := func(id *testIdentity) []*TelehealthSession {
+		var sessions []*TelehealthSession
+		env.mustInvoke(id, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			sessions, err = env.cc.ListTelehealthSessions(ctx, patient.id)
+			return err
+		})
+		return sessions
+	}
+	if got := list(patient); len(got) != 2 || got[0].SessionID != "s1" {
+		t.Fatalf("patient must see sessions ordered by start: %+v", got)
+	}
+
+	// 参与者须对就诊记录持有授权
+	if got := list(nurse); len(got) != 0 {
+		t.Fatalf("participant without grant must see nothing: %+v", got)
+	}
+	env.grant(patient, "enc2", nurse.id, "read", "")
+	if got := list(nurse); len(got) != 1 || got[0].SessionID != "s2" {
+		t.Fatalf("participant must see granted session only: %+v", got)
+	}
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetTelehealthSession(ctx, "s1")
+		return err
+	})
+}
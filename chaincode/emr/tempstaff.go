@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxTemporaryStaffDays 临时人员单次登记的最长窗口
+const maxTemporaryStaffDays = 90
+
+const (
+	TempStaffActive    = "active"
+	TempStaffCancelled = "cancelled"
+	TempStaffExpired   = "expired"
+)
+
+// TemporaryStaff 临时/代班人员登记：其全部授权限制在窗口 [validFrom, validTo) 内，
+// 登记结束时批量撤销其授权
+type TemporaryStaff struct {
+	IdentityID   string `json:"identityId"`
+	SponsorID    string `json:"sponsorId"`
+	ValidFrom    string `json:"validFrom"`
+	ValidTo      string `json:"validTo"`
+	Status       string `json:"status"`
+	RegisteredAt string `json:"registeredAt"`
+	EndedAt      string `json:"endedAt,omitempty"`
+	EndedBy      string `json:"endedBy,omitempty"`
+}
+
+type TemporaryStaffEvent struct {
+	IdentityID   string `json:"identityId"`
+	SponsorID    string `json:"sponsorId"`
+	ValidTo      string `json:"validTo"`
+	Status       string `json:"status"`
+	RevokedCount int    `json:"revokedCount"`
+	Timestamp    string `json:"timestamp"`
+	CallerID     string `json:"callerId"`
+	EventType    string `json:"eventType"`
+}
+
+// tempStaffKey tempstaff:{identityId}
+func tempStaffKey(identityID string) string {
+	return "tempstaff:" + keySegment(identityID)
+}
+
+func getTemporaryStaff(ctx contractapi.TransactionContextInterface, identityID string) (*TemporaryStaff, error) {
+	var staff TemporaryStaff
+	found, err := getJSON(ctx, tempStaffKey(identityID), &staff)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// window 登记窗口；时间已在登记时校验
+func (staff *TemporaryStaff) window() (time.Time, time.Time) {
+	from, _ := time.Parse(time.RFC3339, staff.ValidFrom)
+	to, _ := time.Parse(time.RFC3339, staff.ValidTo)
+	return from, to
+}
+
+// limit 把授权限制在登记窗口内：生效时间取 validFrom，到期时间截断到 validTo
+func (staff *TemporaryStaff) limit(perm *AccessPermission) {
+	_, to := staff.window()
+	perm.ValidFrom = staff.ValidFrom
+	perm.ExpiresAt = capExpiresAt(perm.ExpiresAt, to)
+}
+
+// capTemporaryGrant 授权给临时人员时限制在其登记窗口内；登记已结束或已过期时拒绝授权
+func capTemporaryGrant(ctx contractapi.TransactionContextInterface, perm *AccessPermission) error {
+	staff, err := getTemporaryStaff(ctx, perm.GranteeID)
+	if err != nil || staff == nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	_, to := staff.window()
+	if staff.Status != TempStaffActive || !now.Before(to) {
+		return fmt.Errorf("temporary staff registration of %s has ended", perm.GranteeID)
+	}
+	staff.limit(perm)
+	return nil
+}
+
+// RegisterTemporaryStaff 持有效执业凭证的担保人登记临时人员及其有效窗口，其已有的有效授权一并限制在窗口内；
+// 登记结束（到期或取消）后可重新登记
+func (s *SmartContract) RegisterTemporaryStaff(ctx contractapi.TransactionContextInterface, identityID, sponsorID, validFrom, validTo string) error {
+	if err := validateAddress(identityID); err != nil {
+		return fmt.Errorf("invalid identityID: %w", err)
+	}
+	if identityID == sponsorID {
+		return fmt.Errorf("temporary staff cannot sponsor themselves")
+	}
+	isSponsor, err := callerIs(ctx, sponsorID)
+	if err != nil {
+		return err
+	}
+	if !isSponsor {
+		return fmt.Errorf("access denied: only %s can register temporary staff as sponsor", sponsorID)
+	}
+	if err := requireProviderCredential(ctx, sponsorID); err != nil {
+		return err
+	}
+	from, err := time.Parse(time.RFC3339, validFrom)
+	if err != nil {
+		return fmt.Errorf("invalid validFrom: %w", err)
+	}
+	to, err := time.Parse(time.RFC3339, validTo)
+	if err != nil {
+		return fmt.Errorf("invalid validTo: %w", err)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !to.After(from) || !to.After(now) || to.After(from.AddDate(0, 0, maxTemporaryStaffDays)) {
+		return fmt.Errorf("validTo must be after validFrom and now, and within %d days of validFrom", maxTemporaryStaffDays)
+	}
+	existing, err := getTemporaryStaff(ctx, identityID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status == TempStaffActive {
+		if _, existingTo := existing.window(); now.Before(existingTo) {
+			return fmt.Errorf("%s is already registered as temporary staff until %s", identityID, existing.ValidTo)
+		}
+	}
+
+	staff := TemporaryStaff{
+		IdentityID:   identityID,
+		SponsorID:    sponsorID,
+		ValidFrom:    from.UTC().Format(time.RFC3339),
+		ValidTo:      to.UTC().Format(time.RFC3339),
+		Status:       TempStaffActive,
+		RegisteredAt: now.Format(time.RFC3339),
+	}
+	if err := putJSON(ctx, tempStaffKey(identityID), staff); err != nil {
+		return err
+	}
+	err = scanIndex(ctx, granteeRecordIndex, []string{identityID}, func(attrs []string) error {
+		_, err := updateGrant(ctx, attrs[1], identityID, staff.RegisteredAt, func(perm *AccessPermission) {
+			if perm.IsActive {
+				staff.limit(perm)
+			}
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "TemporaryStaffRegistered", TemporaryStaffEvent{
+		IdentityID: identityID,
+		SponsorID:  sponsorID,
+		ValidTo:    staff.ValidTo,
+		Status:     staff.Status,
+		Timestamp:  staff.RegisteredAt,
+		CallerID:   sponsorID,
+		EventType:  "TemporaryStaffRegistered",
+	})
+}
+
+// EndTemporaryStaff 结束临时人员登记并经 grantee~recordId 索引批量撤销其全部授权：
+// 担保人可随时取消，登记到期后任何身份均可调用以完成清理
+func (s *SmartContract) EndTemporaryStaff(ctx contractapi.TransactionContextInterface, identityID string) error {
+	staff, err := getTemporaryStaff(ctx, identityID)
+	if err != nil {
+		return err
+	}
+	if staff == nil || staff.Status != TempStaffActive {
+		return fmt.Errorf("no active temporary staff registration for %s", identityID)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	isSponsor, err := callerIs(ctx, staff.SponsorID)
+	if err != nil {
+		return err
+	}
+	_, to := staff.window()
+	switch {
+	case now.Before(to) && isSponsor:
+		staff.Status = TempStaffCancelled
+	case !now.Before(to):
+		staff.Status = TempStaffExpired
+	default:
+		return fmt.Errorf("access denied: only sponsor %s can cancel the registration before %s", staff.SponsorID, staff.ValidTo)
+	}
+	staff.EndedAt = now.Format(time.RFC3339)
+	staff.EndedBy = callerID
+
+	revoked := 0
+	err = scanIndex(ctx, granteeRecordIndex, []string{identityID}, func(attrs []string) error {
+		perm, err := getPermission(ctx, attrs[1], identityID)
+		if err != nil || perm == nil || !perm.IsActive {
+			return err
+		}
+		if _, err := deactivateGrant(ctx, attrs[1], identityID, staff.EndedAt); err != nil {
+			return err
+		}
+		revoked++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := putJSON(ctx, tempStaffKey(identityID), staff); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "TemporaryStaffEnded", TemporaryStaffEvent{
+		IdentityID:   identityID,
+		SponsorID:    staff.SponsorID,
+		ValidTo:      staff.ValidTo,
+		Status:       staff.Status,
+		RevokedCount: revoked,
+		Timestamp:    staff.EndedAt,
+		CallerID:     callerID,
+		EventType:    "TemporaryStaffEnded",
+	})
+}
+
+// GetTemporaryStaff 返回临时人员登记；限本人、担保人与审计员
+func (s *SmartContract) GetTemporaryStaff(ctx contractapi.TransactionContextInterface, identityID string) (*TemporaryStaff, error) {
+	staff, err := getTemporaryStaff(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, fmt.Errorf("no temporary staff registration for %s", identityID)
+	}
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, subjectID := range []string{identityID, staff.SponsorID} {
+		if allowed {
+			break
+		}
+		if allowed, err = callerIs(ctx, subjectID); err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("access denied: only the registered identity, its sponsor or an auditor can view the registration")
+	}
+	return staff, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var locum = newIdentity("locum1", "Org1MSP")
+
+func (e *testEnv) registerLocum(from, to time.Duration) {
+	e.t.Helper()
+	e.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.RegisterTemporaryStaff(ctx, locum.id, doctor.id, e.stub.now.Add(from).Format(time.RFC3339), e.stub.now.Add(to).Format(time.RFC3339))
+	})
+}
+
+func TestTemporaryStaffGrantsFollowRegistrationWindow(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.grant(patient, "rec1", locum.id, "read", "")
+
+	env.mustFail(nurse, "only "+doctor.id, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterTemporaryStaff(ctx, locum.id, doctor.id, env.stub.now.Format(time.RFC3339), env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	})
+	env.mustFail(other, "no valid license credential", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterTemporaryStaff(ctx, locum.id, other.id, env.stub.now.Format(time.RFC3339), env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	})
+	validTo := env.stub.now.Add(25 * time.Hour).Format(time.RFC3339)
+	env.registerLocum(time.Hour, 25*time.Hour)
+	env.expectEvent("TemporaryStaffRegistered", nil)
+
+	// 登记前已有的授权同样被限制在窗口内
+	if perm := storedPermission(env, "rec1", locum.id); perm.ExpiresAt != validTo || perm.ValidFrom == "" {
+		t.Fatalf("existing grant must be capped to the registration: %+v", perm)
+	}
+	env.grant(patient, "rec2", locum.id, "read", env.stub.now.AddDate(0, 0, 30).Format(time.RFC3339))
+	if perm := storedPermission(env, "rec2", locum.id); perm.ExpiresAt != validTo {
+		t.Fatalf("new grant must be capped at validTo: %+v", perm)
+	}
+	if env.checkAccess("rec1", locum.id) {
+		t.Fatal("grants must not apply before the registration window")
+	}
+	env.advance(2 * time.Hour)
+	if !env.checkAccess("rec1", locum.id) || !env.checkAccess("rec2", locum.id) {
+		t.Fatal("grants must apply during the registration window")
+	}
+	env.mustFail(doctor, "already registered", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterTemporaryStaff(ctx, locum.id, doctor.id, env.stub.now.Format(time.RFC3339), env.stub.now.Add(time.Hour).Format(time.RFC3339))
+	})
+
+	env.mustFail(nurse, "only sponsor", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EndTemporaryStaff(ctx, locum.id)
+	})
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EndTemporaryStaff(ctx, locum.id)
+	})
+	var event TemporaryStaffEvent
+	env.expectEvent("TemporaryStaffEnded", &event)
+	if event.Status != TempStaffCancelled || event.RevokedCount != 2 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if env.checkAccess("rec1", locum.id) || env.checkAccess("rec2", locum.id) {
+		t.Fatal("cancelled registration must revoke all grants")
+	}
+	env.mustFail(patient, "has ended", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccess(ctx, "rec1", locum.id, "read")
+	})
+}
+
+func TestExpiredTemporaryStaffCanBeEndedByAnyone(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.registerLocum(0, 8*time.Hour)
+	env.grant(patient, "rec1", locum.id, "read", "")
+
+	env.advance(8 * time.Hour)
+	if env.checkAccess("rec1", locum.id) {
+		t.Fatal("grants must end with the registration")
+	}
+	env.mustInvoke(nurse, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EndTemporaryStaff(ctx, locum.id)
+	})
+	env.mustInvoke(auditor, func(ctx contractapi.TransactionContextInterface) error {
+		staff, err := env.cc.GetTemporaryStaff(ctx, locum.id)
+		if err == nil && (staff.Status != TempStaffExpired || staff.EndedBy != nurse.id) {
+			t.Fatalf("unexpected registration: %+v", staff)
+		}
+		return err
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.GetTemporaryStaff(ctx, locum.id)
+		return err
+	})
+	// 登记结束后可重新登记
+	env.registerLocum(0, time.Hour)
+}
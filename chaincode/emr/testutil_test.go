@@ -0,0 +1,414 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testStub 在 MockStub 基础上补齐交易时间、事件记录与分页查询
+type testStub struct {
+	*shimtest.MockStub
+	now    time.Time
+	events []*peer.ChaincodeEvent
+	reads  int
+	// 与 Fabric 一致：同一交易不能既做分页查询又写状态
+	paginated bool
+	wrote     bool
+	// function 为 BeforeTransaction 提供被调用的函数名
+	function string
+}
+
+func (s *testStub) GetFunctionAndParameters() (string, []string) {
+	return s.function, nil
+}
+
+var errPaginatedWrite = fmt.Errorf("transaction with paginated queries cannot have writes")
+
+func (s *testStub) markPaginated() error {
+	if s.wrote {
+		return errPaginatedWrite
+	}
+	s.paginated = true
+	return nil
+}
+
+func (s *testStub) PutState(key string, value []byte) error {
+	if s.paginated {
+		return errPaginatedWrite
+	}
+	s.wrote = true
+	return s.MockStub.PutState(key, value)
+}
+
+func (s *testStub) DelState(key string) error {
+	if s.paginated {
+		return errPaginatedWrite
+	}
+	s.wrote = true
+	return s.MockStub.DelState(key)
+}
+
+func (s *testStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.now), nil
+}
+
+// SetEvent 与 Fabric 一致：每笔交易只保留最后一次设置的事件
+func (s *testStub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	s.events = append(s.events, &peer.ChaincodeEvent{EventName: name, Payload: payload})
+	return nil
+}
+
+func (s *testStub) GetState(key string) ([]byte, error) {
+	s.reads++
+	return s.MockStub.GetState(key)
+}
+
+func (s *testStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.markPaginated(); err != nil {
+		return nil, nil, err
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	iterator, err := s.MockStub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return paginate(iterator, pageSize)
+}
+
+func (s *testStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.markPaginated(); err != nil {
+		return nil, nil, err
+	}
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	startKey := prefix
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	iterator := shimtest.NewMockStateRangeQueryIterator(s.MockStub, startKey, prefix+string(rune(0x10FFFF)))
+	return paginate(iterator, pageSize)
+}
+
+// GetPrivateDataByPartialCompositeKey MockStub 未实现，按集合内有序键做前缀扫描
+func (s *testStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	page := &sliceIterator{}
+	for key, value := range s.PvtState[collection] {
+		if strings.HasPrefix(key, prefix) {
+			page.items = append(page.items, &queryresult.KV{Namespace: collection, Key: key, Value: value})
+		}
+	}
+	sort.Slice(page.items, func(i, j int) bool { return page.items[i].Key < page.items[j].Key })
+	return page, nil
+}
+
+// GetQueryResultWithPagination 按键序遍历世界状态模拟 CouchDB 选择器，支持等值与 $gt/$gte/$lt/$lte
+func (s *testStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.markPaginated(); err != nil {
+		return nil, nil, err
+	}
+	var q struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(s.State))
+	for key := range s.State {
+		if key >= bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	matched := &sliceIterator{}
+	for _, key := range keys {
+		var doc map[string]interface{}
+		if json.Unmarshal(s.State[key], &doc) != nil || !selectorMatches(q.Selector, doc) {
+			continue
+		}
+		matched.items = append(matched.items, &queryresult.KV{Key: key, Value: s.State[key]})
+	}
+	return paginate(matched, pageSize)
+}
+
+func selectorMatches(selector, doc map[string]interface{}) bool {
+	for field, cond := range selector {
+		value, _ := doc[field].(string)
+		ops, isOps := cond.(map[string]interface{})
+		if !isOps {
+			if _, present := doc[field]; !present || doc[field] != cond {
+				return false
+			}
+			continue
+		}
+		for op, operand := range ops {
+			bound, _ := operand.(string)
+			ok := map[string]bool{"$gt": value > bound, "$gte": value >= bound, "$lt": value < bound, "$lte": value <= bound, "$eq": value == bound}[op]
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func paginate(iterator shim.StateQueryIteratorInterface, pageSize int32) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	defer iterator.Close()
+	page := &sliceIterator{}
+	metadata := &peer.QueryResponseMetadata{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if pageSize > 0 && int32(len(page.items)) == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		page.items = append(page.items, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.items))
+	return page, metadata, nil
+}
+
+type sliceIterator struct {
+	items []*queryresult.KV
+	pos   int
+}
+
+func (it *sliceIterator) HasNext() bool { return it.pos < len(it.items) }
+
+func (it *sliceIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	kv := it.items[it.pos]
+	it.pos++
+	return kv, nil
+}
+
+func (it *sliceIterator) Close() error { return nil }
+
+// testIdentity 模拟证书身份与属性
+type testIdentity struct {
+	id    string
+	msp   string
+	attrs map[string]string
+}
+
+func (i *testIdentity) GetID() (string, error)    { return i.id, nil }
+func (i *testIdentity) GetMSPID() (string, error) { return i.msp, nil }
+
+func (i *testIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := i.attrs[name]
+	return value, found, nil
+}
+
+func (i *testIdentity) AssertAttributeValue(name, value string) error {
+	if i.attrs[name] != value {
+		return fmt.Errorf("attribute %s does not equal %s", name, value)
+	}
+	return nil
+}
+
+func (i *testIdentity) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+func newIdentity(id, msp string, attrs ...string) *testIdentity {
+	identity := &testIdentity{id: id, msp: msp, attrs: map[string]string{}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		identity.attrs[attrs[i]] = attrs[i+1]
+	}
+	return identity
+}
+
+// testEnv 串起合约、桩与交易生命周期；失败的交易回滚写集，与 Fabric 行为一致
+type testEnv struct {
+	t    testing.TB
+	cc   *SmartContract
+	stub *testStub
+	txN  int
+}
+
+func newTestEnv(t testing.TB) *testEnv {
+	t.Helper()
+	stub := &testStub{
+		MockStub: shimtest.NewMockStub("emr", nil),
+		now:      time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+	}
+	env := &testEnv{t: t, cc: new(SmartContract), stub: stub}
+	env.license(doctor, nurse, specialist, physician2)
+	return env
+}
+
+var (
+	admin        = newIdentity("admin1", "Org1MSP", "role", "admin")
+	medicalBoard = newIdentity("medboard1", "RegulatorMSP")
+)
+
+// license 登记签发机构并为 providers 签发一年有效的执业凭证
+func (e *testEnv) license(providers ...*testIdentity) {
+	e.t.Helper()
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.RegisterCredentialIssuer(ctx, medicalBoard.id, "Medical Board")
+	})
+	expiresAt := e.stub.now.AddDate(1, 0, 0).Format(time.RFC3339)
+	for _, provider := range providers {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte("license:"+provider.id)))
+		e.mustInvoke(medicalBoard, func(ctx contractapi.TransactionContextInterface) error {
+			return e.cc.SubmitProviderCredential(ctx, provider.id, hash, expiresAt)
+		})
+	}
+}
+
+func (e *testEnv) advance(d time.Duration) {
+	e.stub.now = e.stub.now.Add(d)
+}
+
+func (e *testEnv) ctxFor(identity *testIdentity) *contractapi.TransactionContext {
+	e.txN++
+	e.stub.MockTransactionStart(fmt.Sprintf("tx%04d", e.txN))
+	e.stub.events = nil
+	e.stub.paginated, e.stub.wrote = false, false
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(e.stub)
+	ctx.SetClientIdentity(identity)
+	return ctx
+}
+
+// invoke 以 identity 身份执行 fn；fn 返回错误时撤销本次交易的全部写入
+func (e *testEnv) invoke(identity *testIdentity, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	ctx := e.ctxFor(identity)
+	snapshot := make(map[string][]byte, len(e.stub.State))
+	for k, v := range e.stub.State {
+		snapshot[k] = v
+	}
+	pvtSnapshot := make(map[string]map[string][]byte, len(e.stub.PvtState))
+	for c, m := range e.stub.PvtState {
+		pvtSnapshot[c] = make(map[string][]byte, len(m))
+		for k, v := range m {
+			pvtSnapshot[c][k] = v
+		}
+	}
+	err := fn(ctx)
+	if err != nil {
+		e.stub.State = snapshot
+		e.stub.PvtState = pvtSnapshot
+		keys := make([]string, 0, len(snapshot))
+		for k := range snapshot {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.stub.Keys = list.New()
+		for _, k := range keys {
+			e.stub.Keys.PushBack(k)
+		}
+		e.stub.events = nil
+	}
+	e.stub.MockTransactionEnd(e.stub.TxID)
+	return err
+}
+
+func (e *testEnv) mustInvoke(identity *testIdentity, fn func(ctx contractapi.TransactionContextInterface) error) {
+	e.t.Helper()
+	if err := e.invoke(identity, fn); err != nil {
+		e.t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func (e *testEnv) mustFail(identity *testIdentity, contains string, fn func(ctx contractapi.TransactionContextInterface) error) {
+	e.t.Helper()
+	err := e.invoke(identity, fn)
+	if err == nil {
+		e.t.Fatalf("expected error containing %q, got nil", contains)
+	}
+	if !strings.Contains(err.Error(), contains) {
+		e.t.Fatalf("expected error containing %q, got %v", contains, err)
+	}
+}
+
+// lastEvent 返回最近一笔交易最终生效的事件
+func (e *testEnv) lastEvent() *peer.ChaincodeEvent {
+	if len(e.stub.events) == 0 {
+		return nil
+	}
+	return e.stub.events[len(e.stub.events)-1]
+}
+
+func (e *testEnv) expectEvent(name string, payload interface{}) {
+	e.t.Helper()
+	event := e.lastEvent()
+	if event == nil {
+		e.t.Fatalf("expected event %s, got none", name)
+	}
+	if event.EventName != name {
+		e.t.Fatalf("expected event %s, got %s", name, event.EventName)
+	}
+	if payload != nil {
+		if err := json.Unmarshal(event.Payload, payload); err != nil {
+			e.t.Fatalf("failed to decode %s payload: %v", name, err)
+		}
+	}
+}
+
+// recordJSON 构造一条由 creator 为 patientID 创建的测试记录
+func recordJSON(creator *testIdentity, recordID, patientID string) string {
+	data, _ := json.Marshal(MedicalRecord{
+		RecordID:    recordID,
+		PatientID:   patientID,
+		CreatorID:   creator.id,
+		IPCSCID:     "bafy" + recordID,
+		ContentHash: strings.Repeat("a", 64),
+	})
+	return string(data)
+}
+
+// createRecord 以 creator 身份为 patientID 创建一条测试记录
+func (e *testEnv) createRecord(creator *testIdentity, recordID, patientID string) {
+	e.t.Helper()
+	e.mustInvoke(creator, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateMedicalRecord(ctx, recordJSON(creator, recordID, patientID))
+		return err
+	})
+}
+
+func (e *testEnv) grant(owner *testIdentity, recordID, granteeID, action, expiresAt string) {
+	e.t.Helper()
+	e.mustInvoke(owner, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.GrantAccessWithExpiry(ctx, recordID, granteeID, action, expiresAt)
+	})
+}
+
+func (e *testEnv) checkAccess(recordID, userID string) bool {
+	e.t.Helper()
+	var allowed bool
+	e.mustInvoke(newIdentity("query", "Org1MSP"), func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		allowed, err = e.cc.CheckAccess(ctx, recordID, userID)
+		return err
+	})
+	return allowed
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// maxTokenTTL 令牌与兑换后授权的最长有效期
+	maxTokenTTL = 7 * 24 * time.Hour
+	// minTokenSecretLength 链上公开 tokenHash，原文须有足够熵才不会被离线穷举
+	minTokenSecretLength = 32
+)
+
+// AccessToken 一次性访问令牌：患者把原文线下交给尚未确定或尚未注册的医生，链上只存 sha256(原文)。
+// 兑换分两步：先提交绑定兑换者的承诺，下一个区块再揭示原文，见 RedeemAccessToken
+type AccessToken struct {
+	TokenHash  string `json:"tokenHash"`
+	RecordID   string `json:"recordId"`
+	Action     string `json:"action"`
+	TTLSeconds int    `json:"ttlSeconds"`
+	CreatedBy  string `json:"createdBy"`
+	CreatedAt  string `json:"createdAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	RedeemedBy string `json:"redeemedBy,omitempty"`
+	RedeemedAt string `json:"redeemedAt,omitempty"`
+}
+
+// TokenCommitment 兑换承诺 sha256(原文 + ":" + 兑换者 ID)，不泄露原文
+type TokenCommitment struct {
+	Commitment  string `json:"commitment"`
+	RedeemerID  string `json:"redeemerId"`
+	CommittedAt string `json:"committedAt"`
+}
+
+type AccessTokenEvent struct {
+	TokenHash string `json:"tokenHash"`
+	RecordID  string `json:"recordId"`
+	GranteeID string `json:"granteeId,omitempty"`
+	ExpiresAt string `json:"expiresAt"`
+	Timestamp string `json:"timestamp"`
+	CallerID  string `json:"callerId"`
+	EventType string `json:"eventType"`
+}
+
+func accessTokenKey(tokenHash string) string {
+	return "token:" + tokenHash
+}
+
+func tokenCommitmentKey(commitment string) string {
+	return "token-commit:" + commitment
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenCommitment 兑换者在客户端计算同一值后调用 CommitTokenRedemption
+func tokenCommitment(secret, redeemerID string) string {
+	return sha256Hex(secret + ":" + redeemerID)
+}
+
+// CreateAccessToken 患者（或有 grant 范围的代理人）登记令牌，返回令牌 ID（即 tokenHash）。
+// 原文由客户端随机生成（建议 32 字节十六进制），兑换后得到一次性只读授权
+func (s *SmartContract) CreateAccessToken(ctx contractapi.TransactionContextInterface, recordID, action string, ttlSeconds int, tokenHash string) (string, error) {
+	if !sha256HexPattern.MatchString(tokenHash) {
+		return "", fmt.Errorf("tokenHash must be a lowercase hex sha256 digest")
+	}
+	if action != "read" {
+		return "", fmt.Errorf("invalid action: access tokens grant single-use read access")
+	}
+	ttl := time.Duration(ttlSeconds) * time.Second
+	if ttl <= 0 || ttl > maxTokenTTL {
+		return "", fmt.Errorf("ttlSeconds must be between 1 and %d", int(maxTokenTTL/time.Second))
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	if err := requireSinglePartyGrant(record); err != nil {
+		return "", err
+	}
+	if _, err := ownerOrAgent(ctx, record.PatientID, callerID, "grant"); err != nil {
+		return "", err
+	}
+	if err := requireUnlocked(ctx, record.PatientID); err != nil {
+		return "", err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	expiresAt := now.Add(ttl).Format(time.RFC3339)
+	if err := config.checkGrant(action, expiresAt, now); err != nil {
+		return "", err
+	}
+	exists, err := assetExists(ctx, accessTokenKey(tokenHash))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("access token already exists")
+	}
+
+	ts := now.Format(time.RFC3339)
+	token := AccessToken{
+		TokenHash:  tokenHash,
+		RecordID:   recordID,
+		Action:     action,
+		TTLSeconds: ttlSeconds,
+		CreatedBy:  callerID,
+		CreatedAt:  ts,
+		ExpiresAt:  expiresAt,
+	}
+	if err := putJSON(ctx, accessTokenKey(tokenHash), token); err != nil {
+		return "", err
+	}
+	return tokenHash, emitEvent(ctx, "AccessTokenCreated", AccessTokenEvent{
+		TokenHash: tokenHash,
+		RecordID:  recordID,
+		ExpiresAt: expiresAt,
+		Timestamp: ts,
+		CallerID:  callerID,
+		EventType: "AccessTokenCreated",
+	})
+}
+
+// CommitTokenRedemption 兑换第一步：登记 sha256(原文 + ":" + 调用者 ID)。
+// 承诺不泄露原文，且只能由对应身份兑换；分享码认领使用同一承诺
+func (s *SmartContract) CommitTokenRedemption(ctx contractapi.TransactionContextInterface, commitment string) error {
+	if !sha256HexPattern.MatchString(commitment) {
+		return fmt.Errorf("commitment must be a lowercase hex sha256 digest")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, tokenCommitmentKey(commitment))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("commitment already exists")
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, tokenCommitmentKey(commitment), TokenCommitment{Commitment: commitment, RedeemerID: callerID, CommittedAt: now})
+}
+
+// RedeemAccessToken 兑换第二步：揭示原文，为调用者写入 maxUses=1 的只读授权。
+// 原文随本交易写入区块，此后公开；承诺须已在更早的区块提交（读取的是已提交状态），
+// 在背书或排序环节看到原文的人无法再抢先兑换
+func (s *SmartContract) RedeemAccessToken(ctx contractapi.TransactionContextInterface, secret string) error {
+	if len(secret) < minTokenSecretLength {
+		return fmt.Errorf("invalid token")
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	tokenHash := sha256Hex(secret)
+	var token AccessToken
+	found, err := getJSON(ctx, accessTokenKey(tokenHash), &token)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("invalid token")
+	}
+	if token.RedeemedBy != "" {
+		return fmt.Errorf("access token has already been redeemed")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid stored expiresAt: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("access token expired at %s", token.ExpiresAt)
+	}
+	commitment := tokenCommitment(secret, callerID)
+	var commit TokenCommitment
+	found, err = getJSON(ctx, tokenCommitmentKey(commitment), &commit)
+	if err != nil {
+		return err
+	}
+	if !found || commit.RedeemerID != callerID {
+		return fmt.Errorf("no redemption commitment from %s: call CommitTokenRedemption first", callerID)
+	}
+
+	record, err := getRecord(ctx, token.RecordID)
+	if err != nil {
+		return err
+	}
+	if callerID == record.PatientID {
+		return fmt.Errorf("the patient cannot redeem their own access token")
+	}
+	if err := requireSinglePartyGrant(record); err != nil {
+		return err
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	grantExpiresAt := now.Add(time.Duration(token.TTLSeconds) * time.Second).Format(time.RFC3339)
+	if err := config.checkGrant(token.Action, grantExpiresAt, now); err != nil {
+		return err
+	}
+
+	ts := now.Format(time.RFC3339)
+	if err := storeGrant(ctx, record, AccessPermission{
+		RecordID:      record.RecordID,
+		GranteeID:     callerID,
+		Action:        token.Action,
+		ExpiresAt:     grantExpiresAt,
+		GrantedAt:     ts,
+		GrantedBy:     token.CreatedBy,
+		IsActive:      true,
+		MaxUses:       1,
+		RemainingUses: 1,
+	}); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(tokenCommitmentKey(commitment)); err != nil {
+		return fmt.Errorf("failed to delete commitment: %w", err)
+	}
+	token.RedeemedBy = callerID
+	token.RedeemedAt = ts
+	if err := putJSON(ctx, accessTokenKey(tokenHash), token); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AccessTokenRedeemed", AccessTokenEvent{
+		TokenHash: tokenHash,
+		RecordID:  record.RecordID,
+		GranteeID: callerID,
+		ExpiresAt: grantExpiresAt,
+		Timestamp: ts,
+		CallerID:  callerID,
+		EventType: "AccessTokenRedeemed",
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testTokenSecret = "9f2c4e6a8b0d1f3e5a7c9b1d3f5e7a9c"
+
+func (e *testEnv) createAccessToken(secret string, ttlSeconds int) {
+	e.t.Helper()
+	e.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := e.cc.CreateAccessToken(ctx, "rec1", "read", ttlSeconds, sha256Hex(secret))
+		return err
+	})
+}
+
+func (e *testEnv) commitRedemption(identity *testIdentity, secret string) {
+	e.t.Helper()
+	e.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.CommitTokenRedemption(ctx, tokenCommitment(secret, identity.id))
+	})
+}
+
+func TestRedeemAccessTokenGrantsSingleRead(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(nurse, "only the patient", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateAccessToken(ctx, "rec1", "read", 3600, sha256Hex(testTokenSecret))
+		return err
+	})
+	env.mustFail(patient, "invalid action", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateAccessToken(ctx, "rec1", "write", 3600, sha256Hex(testTokenSecret))
+		return err
+	})
+	env.createAccessToken(testTokenSecret, 3600)
+	var created AccessTokenEvent
+	env.expectEvent("AccessTokenCreated", &created)
+	if created.TokenHash != sha256Hex(testTokenSecret) || strings.Contains(string(env.lastEvent().Payload), testTokenSecret) {
+		t.Fatalf("unexpected event: %+v", created)
+	}
+
+	// 未提交承诺不能兑换，即便持有原文
+	env.mustFail(specialist, "call CommitTokenRedemption first", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, testTokenSecret)
+	})
+	env.commitRedemption(specialist, testTokenSecret)
+	// 他人的承诺绑定的是他人身份
+	env.mustFail(other, "call CommitTokenRedemption first", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, testTokenSecret)
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, testTokenSecret)
+	})
+	var redeemed AccessTokenEvent
+	env.expectEvent("AccessTokenRedeemed", &redeemed)
+	if redeemed.GranteeID != specialist.id {
+		t.Fatalf("unexpected event: %+v", redeemed)
+	}
+
+	// 原文已公开，抢先提交承诺也无法再次兑换
+	env.commitRedemption(other, testTokenSecret)
+	env.mustFail(other, "already been redeemed", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, testTokenSecret)
+	})
+
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.expectEvent("AccessExhausted", nil)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("token grant must allow a single read")
+	}
+}
+
+func TestExpiredAccessTokenCannotBeRedeemed(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustFail(patient, "ttlSeconds must be between", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.CreateAccessToken(ctx, "rec1", "read", 8*24*3600, sha256Hex(testTokenSecret))
+		return err
+	})
+	env.createAccessToken(testTokenSecret, 600)
+	env.commitRedemption(specialist, testTokenSecret)
+	env.advance(11 * time.Minute)
+	env.mustFail(specialist, "expired", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, testTokenSecret)
+	})
+	env.mustFail(specialist, "invalid token", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RedeemAccessToken(ctx, "short")
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	DocumentPreliminary = "preliminary"
+	DocumentFinal       = "final"
+
+	// TransitionFinalize preliminary → final，见 FinalizeRecord
+	TransitionFinalize = "finalize"
+	// TransitionShare 向他人授权，见 storeGrant
+	TransitionShare = "share"
+
+	dischargeSummaryType = "discharge-summary"
+)
+
+// transitionRule 返回非 nil 时拒绝该状态转换
+type transitionRule func(ctx contractapi.TransactionContextInterface, record *MedicalRecord) error
+
+// transitionRules 按记录类型注册的状态转换规则；注册了 finalize 规则的类型以 preliminary 创建
+var transitionRules = map[string]map[string][]transitionRule{
+	dischargeSummaryType: {
+		TransitionFinalize: {requireSignOff},
+		TransitionShare:    {requireSignOff},
+	},
+}
+
+func hasTransitionRules(recordType string) bool {
+	return len(transitionRules[recordType][TransitionFinalize]) > 0
+}
+
+// checkTransition 依次执行记录类型在该转换上注册的规则
+func checkTransition(ctx contractapi.TransactionContextInterface, record *MedicalRecord, transition string) error {
+	for _, rule := range transitionRules[record.RecordType][transition] {
+		if err := rule(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FinalizeRecord 创建者将 preliminary 记录定稿为 final，须通过该记录类型的 finalize 规则
+func (s *SmartContract) FinalizeRecord(ctx contractapi.TransactionContextInterface, recordID string) error {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if record.DocumentStatus != DocumentPreliminary {
+		return fmt.Errorf("record %s is not preliminary", recordID)
+	}
+	isCreator, err := callerIs(ctx, record.CreatorID)
+	if err != nil {
+		return err
+	}
+	if !isCreator {
+		return fmt.Errorf("access denied: only the record creator can finalize record %s", recordID)
+	}
+	if err := checkTransition(ctx, record, TransitionFinalize); err != nil {
+		return err
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	record.DocumentStatus = DocumentFinal
+	if err := putJSON(ctx, recordKey(recordID), record); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	return emitEvent(ctx, "RecordFinalized", RecordStatusEvent{
+		RecordID:  recordID,
+		PatientID: record.PatientID,
+		Status:    DocumentFinal,
+		Timestamp: now,
+		CallerID:  callerID,
+		EventType: "RecordFinalized",
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	TreatmentActive = "active"
+	TreatmentEnded  = "ended"
+)
+
+// TreatmentRelationship 机构证明的治疗关系；启用 requireTreatmentRelationship 时，治疗用途的读取须有有效关系
+type TreatmentRelationship struct {
+	PatientID     string `json:"patientId"`
+	ClinicianID   string `json:"clinicianId"`
+	MSPID         string `json:"mspId"`
+	Status        string `json:"status"`
+	StartedAt     string `json:"startedAt"`
+	EstablishedBy string `json:"establishedBy"`
+	EndedAt       string `json:"endedAt,omitempty"`
+	EndedBy       string `json:"endedBy,omitempty"`
+}
+
+type TreatmentRelationshipEvent struct {
+	PatientID   string `json:"patientId"`
+	ClinicianID string `json:"clinicianId"`
+	MSPID       string `json:"mspId"`
+	Timestamp   string `json:"timestamp"`
+	CallerID    string `json:"callerId"`
+	EventType   string `json:"eventType"`
+}
+
+// treatmentKey treatment:{patientId}:{clinicianId}
+func treatmentKey(patientID, clinicianID string) string {
+	return treatmentPrefix(patientID) + keySegment(clinicianID)
+}
+
+func treatmentPrefix(patientID string) string {
+	return "treatment:" + keySegment(patientID) + ":"
+}
+
+func getTreatmentRelationship(ctx contractapi.TransactionContextInterface, patientID, clinicianID string) (*TreatmentRelationship, error) {
+	var relationship TreatmentRelationship
+	found, err := getJSON(ctx, treatmentKey(patientID, clinicianID), &relationship)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &relationship, nil
+}
+
+// requireTreatmentRelationship 启用 requireTreatmentRelationship 时，治疗用途（含未声明用途）的读取须有有效治疗关系
+func requireTreatmentRelationship(ctx contractapi.TransactionContextInterface, config *ContractConfig, patientID, accessorID, purpose string) error {
+	if !featureEnabled(config, FeatureRequireTreatmentRelationship) || (purpose != "" && purpose != "treatment") {
+		return nil
+	}
+	relationship, err := getTreatmentRelationship(ctx, patientID, accessorID)
+	if err != nil {
+		return err
+	}
+	if relationship == nil || relationship.Status != TreatmentActive {
+		return fmt.Errorf("access denied: %s has no active treatment relationship with patient %s", accessorID, patientID)
+	}
+	return nil
+}
+
+func emitTreatmentRelationshipEvent(ctx contractapi.TransactionContextInterface, name string, relationship *TreatmentRelationship, callerID, now string) error {
+	return emitEvent(ctx, name, TreatmentRelationshipEvent{
+		PatientID:   relationship.PatientID,
+		ClinicianID: relationship.ClinicianID,
+		MSPID:       relationship.MSPID,
+		Timestamp:   now,
+		CallerID:    callerID,
+		EventType:   name,
+	})
+}
+
+// EstablishTreatmentRelationship 机构 admin 证明患者与临床人员之间的治疗关系；已结束的关系可重新建立
+func (s *SmartContract) EstablishTreatmentRelationship(ctx contractapi.TransactionContextInterface, patientID, clinicianID string) error {
+	if err := validateAddress(patientID); err != nil {
+		return fmt.Errorf("invalid patientID: %w", err)
+	}
+	if err := validateAddress(clinicianID); err != nil {
+		return fmt.Errorf("invalid clinicianID: %w", err)
+	}
+	if patientID == clinicianID {
+		return fmt.Errorf("patient and clinician must differ")
+	}
+	callerID, mspID, err := requireAdminCaller(ctx, "establish treatment relationships")
+	if err != nil {
+		return err
+	}
+	existing, err := getTreatmentRelationship(ctx, patientID, clinicianID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status == TreatmentActive {
+		return fmt.Errorf("treatment relationship between %s and %s is already active", patientID, clinicianID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	relationship := &TreatmentRelationship{
+		PatientID:     patientID,
+		ClinicianID:   clinicianID,
+		MSPID:         mspID,
+		Status:        TreatmentActive,
+		StartedAt:     now,
+		EstablishedBy: callerID,
+	}
+	if err := putJSON(ctx, treatmentKey(patientID, clinicianID), relationship); err != nil {
+		return err
+	}
+	return emitTreatmentRelationshipEvent(ctx, "TreatmentRelationshipEstablished", relationship, callerID, now)
+}
+
+// EndTreatmentRelationship 证明机构的 admin 结束治疗关系
+func (s *SmartContract) EndTreatmentRelationship(ctx contractapi.TransactionContextInterface, patientID, clinicianID string) error {
+	relationship, err := getTreatmentRelationship(ctx, patientID, clinicianID)
+	if err != nil {
+		return err
+	}
+	if relationship == nil || relationship.Status != TreatmentActive {
+		return fmt.Errorf("no active treatment relationship between %s and %s", patientID, clinicianID)
+	}
+	callerID, err := requireOrgAdmin(ctx, relationship.MSPID, "end this treatment relationship")
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	relationship.Status = TreatmentEnded
+	relationship.EndedAt = now
+	relationship.EndedBy = callerID
+	if err := putJSON(ctx, treatmentKey(patientID, clinicianID), relationship); err != nil {
+		return err
+	}
+	return emitTreatmentRelationshipEvent(ctx, "TreatmentRelationshipEnded", relationship, callerID, now)
+}
+
+// ListTreatmentRelationships 返回患者的全部治疗关系（含已结束）；限患者、有 consent 范围的代理人与审计员
+func (s *SmartContract) ListTreatmentRelationships(ctx contractapi.TransactionContextInterface, patientID string) ([]*TreatmentRelationship, error) {
+	allowed, err := isAuditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if _, err := requirePatientOrAgent(ctx, patientID, "consent", "list treatment relationships"); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := treatmentPrefix(patientID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan treatment relationships: %w", err)
+	}
+	defer iterator.Close()
+
+	relationships := []*TreatmentRelationship{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate treatment relationships: %w", err)
+		}
+		var relationship TreatmentRelationship
+		if err := json.Unmarshal(kv.Value, &relationship); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal treatment relationship: %w", err)
+		}
+		relationships = append(relationships, &relationship)
+	}
+	return relationships, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestTreatmentReadsRequireRelationship(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	env.setFeature(FeatureRequireTreatmentRelationship, true)
+	defer func() { env.stub.TransientMap = nil }()
+
+	env.mustFail(nurse, "no active treatment relationship", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	if !env.canRead(patient, "rec1") {
+		t.Fatal("patients need no treatment relationship")
+	}
+	// 非治疗用途不受影响
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("payment")}
+	if !env.canRead(nurse, "rec1") {
+		t.Fatal("only treatment-purpose reads require a relationship")
+	}
+	env.stub.TransientMap = map[string][]byte{purposeOfUseTransientKey: []byte("treatment")}
+
+	establish := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EstablishTreatmentRelationship(ctx, patient.id, nurse.id)
+	}
+	env.mustFail(doctor, "only admin", establish)
+	env.mustInvoke(admin, establish)
+	env.expectEvent("TreatmentRelationshipEstablished", nil)
+	env.mustFail(admin, "already active", establish)
+	if !env.canRead(nurse, "rec1") {
+		t.Fatal("an active relationship must allow treatment reads")
+	}
+
+	end := func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.EndTreatmentRelationship(ctx, patient.id, nurse.id)
+	}
+	env.mustFail(org2Admin, "only admin of Org1MSP", end)
+	env.mustInvoke(admin, end)
+	if env.canRead(nurse, "rec1") {
+		t.Fatal("grants must not outlive the treatment relationship")
+	}
+
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		relationships, err := env.cc.ListTreatmentRelationships(ctx, patient.id)
+		if err == nil && (len(relationships) != 1 || relationships[0].Status != TreatmentEnded || relationships[0].EndedBy != admin.id) {
+			t.Fatalf("unexpected relationships: %+v", relationships)
+		}
+		return err
+	})
+	env.mustFail(nurse, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListTreatmentRelationships(ctx, patient.id)
+		return err
+	})
+	// 已结束的关系可重新建立
+	env.mustInvoke(admin, establish)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AccessExhaustedEvent 最后一次使用限次授权的读取以此事件代替 RecordAccessed（每笔交易只保留一个事件）
+type AccessExhaustedEvent struct {
+	RecordID     string `json:"recordId"`
+	GranteeID    string `json:"granteeId"`
+	MaxUses      int    `json:"maxUses"`
+	PurposeOfUse string `json:"purposeOfUse,omitempty"`
+	ReasonCode   string `json:"reasonCode,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	EventType    string `json:"eventType"`
+}
+
+// consumeUse 调用者持有限次授权时递减剩余次数，归零即失效；返回本次是否用尽。
+// 患者与创建者不计次
+func consumeUse(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID string) (bool, error) {
+	if callerID == record.PatientID || callerID == record.CreatorID {
+		return false, nil
+	}
+	perm, err := getPermission(ctx, record.RecordID, callerID)
+	if err != nil || perm == nil || perm.MaxUses == 0 || !perm.IsActive {
+		return false, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return false, err
+	}
+	if _, err := updateGrant(ctx, record.RecordID, callerID, now, func(perm *AccessPermission) {
+		if perm.RemainingUses > 0 {
+			perm.RemainingUses--
+		}
+		if perm.RemainingUses == 0 {
+			perm.IsActive = false
+		}
+	}); err != nil {
+		return false, err
+	}
+	return perm.RemainingUses <= 1, nil
+}
+
+// recordAllowedRead 允许的读取：患者以外的读取须无逾期未交的访问理由、按策略须有治疗关系，计入披露报表，
+// 扣减限次授权并发出 RecordAccessed，用尽时改发 AccessExhausted
+func recordAllowedRead(ctx contractapi.TransactionContextInterface, record *MedicalRecord, callerID, purpose, reasonCode string) error {
+	if err := countAccess(ctx, record.RecordID, false); err != nil {
+		return err
+	}
+	if callerID != record.PatientID {
+		if err := requireNoOverdueObligations(ctx, callerID); err != nil {
+			return err
+		}
+		config, err := loadConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireTreatmentRelationship(ctx, config, record.PatientID, callerID, purpose); err != nil {
+			return err
+		}
+		if err := createAccessObligation(ctx, config, record, callerID); err != nil {
+			return err
+		}
+		if err := noteAckRead(ctx, record, callerID); err != nil {
+			return err
+		}
+		if err := recordDisclosure(ctx, Disclosure{RecordID: record.RecordID, PatientID: record.PatientID, AccessorID: callerID, PurposeOfUse: purpose}); err != nil {
+			return err
+		}
+	}
+	exhausted, err := consumeUse(ctx, record, callerID)
+	if err != nil {
+		return err
+	}
+	if !exhausted {
+		return emitRecordAccessedEvent(ctx, record.RecordID, callerID, purpose, reasonCode, true)
+	}
+	perm, err := getPermission(ctx, record.RecordID, callerID)
+	if err != nil {
+		return err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return emitEvent(ctx, "AccessExhausted", AccessExhaustedEvent{
+		RecordID:     record.RecordID,
+		GranteeID:    callerID,
+		MaxUses:      perm.MaxUses,
+		PurposeOfUse: purpose,
+		ReasonCode:   reasonCode,
+		Timestamp:    now,
+		EventType:    "AccessExhausted",
+	})
+}
+
+// GrantAccessWithUses 授予只读的限次授权，如“只让这位专科医生看一次影像”；maxUses 上限取配置 maxGrantUses，expiresAt 为空表示不过期
+func (s *SmartContract) GrantAccessWithUses(ctx contractapi.TransactionContextInterface, recordID, granteeID, expiresAt string, maxUses int) error {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if maxUses < 1 || maxUses > config.MaxGrantUses {
+		return fmt.Errorf("maxUses must be between 1 and %d", config.MaxGrantUses)
+	}
+	return grantAccess(ctx, recordID, granteeID, "read", expiresAt, maxUses, grantScope{})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestUseLimitedGrantExhausts(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+
+	env.mustFail(patient, "maxUses must be between", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithUses(ctx, "rec1", specialist.id, "", 0)
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantAccessWithUses(ctx, "rec1", specialist.id, "", 2)
+	})
+	var granted AccessGrantedEvent
+	env.expectEvent("AccessGranted", &granted)
+	if granted.MaxUses != 2 || granted.Action != "read" {
+		t.Fatalf("unexpected event: %+v", granted)
+	}
+
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.expectEvent("RecordAccessed", nil)
+	if result := env.auditedRead(specialist, "rec1"); !result.Allowed {
+		t.Fatalf("second use must be allowed: %+v", result)
+	}
+	var exhausted AccessExhaustedEvent
+	env.expectEvent("AccessExhausted", &exhausted)
+	if exhausted.GranteeID != specialist.id || exhausted.MaxUses != 2 {
+		t.Fatalf("unexpected event: %+v", exhausted)
+	}
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("exhausted grant must not allow access")
+	}
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		page, err := env.cc.GetUserPermissions(ctx, specialist.id, 10, "")
+		if err == nil && (len(page.Permissions) != 1 || page.Permissions[0].RemainingUses != 0 || page.Permissions[0].IsActive) {
+			t.Fatalf("unexpected permission: %+v", page.Permissions)
+		}
+		return err
+	})
+}
+
+func TestUnlimitedReadsAreNotCounted(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.grant(patient, "rec1", nurse.id, "read", "")
+	for i := 0; i < 3; i++ {
+		for _, identity := range []*testIdentity{patient, doctor, nurse} {
+			env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+				_, err := env.cc.ReadRecord(ctx, "rec1")
+				return err
+			})
+			env.expectEvent("RecordAccessed", nil)
+		}
+	}
+	if !env.checkAccess("rec1", nurse.id) {
+		t.Fatal("unlimited grant must stay active")
+	}
+}
+
+func TestListingsDoNotBypassUseLimits(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.createRecord(doctor, "rec2", patient.id)
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.ArchiveRecord(ctx, "rec2")
+	})
+	for _, recordID := range []string{"rec1", "rec2"} {
+		env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+			return env.cc.GrantAccessWithUses(ctx, recordID, specialist.id, "", 1)
+		})
+	}
+
+	// 列表与检索只给元数据：不带 CID，也不扣次数
+	listings := map[string]func(ctx contractapi.TransactionContextInterface) ([]*MedicalRecord, error){
+		"ListRecordsByPatient": func(ctx contractapi.TransactionContextInterface) ([]*MedicalRecord, error) {
+			return env.cc.ListRecordsByPatient(ctx, patient.id, "")
+		},
+		"ListRecordsByPatientAndTimeRange": func(ctx contractapi.TransactionContextInterface) ([]*MedicalRecord, error) {
+			page, err := env.cc.ListRecordsByPatientAndTimeRange(ctx, patient.id, "2026-01-01T00:00:00Z", "2026-12-31T00:00:00Z", 10, "")
+			if err != nil {
+				return nil, err
+			}
+			return page.Records, nil
+		},
+		"ListArchivedRecords": func(ctx contractapi.TransactionContextInterface) ([]*MedicalRecord, error) {
+			page, err := env.cc.ListArchivedRecords(ctx, patient.id, 10, "")
+			if err != nil {
+				return nil, err
+			}
+			return page.Records, nil
+		},
+		"SearchRecords": func(ctx contractapi.TransactionContextInterface) ([]*MedicalRecord, error) {
+			page, err := env.cc.SearchRecords(ctx, "indexPatientTimestamp", `{"patientId":"patient1","status":{"$gt":""}}`, 10, "")
+			if err != nil {
+				return nil, err
+			}
+			return page.Records, nil
+		},
+	}
+	for name, list := range listings {
+		for i := 0; i < 2; i++ {
+			env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+				records, err := list(ctx)
+				if err != nil {
+					return err
+				}
+				if len(records) == 0 {
+					t.Fatalf("%s: expected the granted records", name)
+				}
+				for _, rec := range records {
+					if rec.IPCSCID != "" || len(rec.Parts) != 0 || len(rec.Sections) != 0 {
+						t.Fatalf("%s leaked content pointers: %+v", name, rec)
+					}
+				}
+				return nil
+			})
+		}
+	}
+
+	if result := env.auditedRead(specialist, "rec1"); !result.Allowed {
+		t.Fatalf("the single use must still be available after listing: %+v", result)
+	}
+	env.expectEvent("AccessExhausted", nil)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("exhausted grant must not allow access")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RedactedView 隐私官发布的正式脱敏版本，与原记录关联；redactionManifestHash 为脱敏清单（删去哪些字段、依据）的 sha256。
+// 登记后不可覆盖，更正须登记新的 viewId
+type RedactedView struct {
+	RecordID              string `json:"recordId"`
+	ViewID                string `json:"viewId"`
+	RedactionManifestHash string `json:"redactionManifestHash"`
+	RedactedCID           string `json:"redactedCid"`
+	RegisteredBy          string `json:"registeredBy"`
+	MspID                 string `json:"mspId"`
+	RegisteredAt          string `json:"registeredAt"`
+}
+
+type RedactedViewRegisteredEvent struct {
+	RecordID              string `json:"recordId"`
+	PatientID             string `json:"patientId"`
+	ViewID                string `json:"viewId"`
+	RedactionManifestHash string `json:"redactionManifestHash"`
+	Timestamp             string `json:"timestamp"`
+	CallerID              string `json:"callerId"`
+	EventType             string `json:"eventType"`
+}
+
+// redactedViewKey redacted-view:{recordId}:{viewId}
+func redactedViewKey(recordID, viewID string) string {
+	return redactedViewPrefix(recordID) + keySegment(viewID)
+}
+
+func redactedViewPrefix(recordID string) string {
+	return "redacted-view:" + keySegment(recordID) + ":"
+}
+
+func getRedactedView(ctx contractapi.TransactionContextInterface, recordID, viewID string) (*RedactedView, error) {
+	var view RedactedView
+	found, err := getJSON(ctx, redactedViewKey(recordID, viewID), &view)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("record %s has no redacted view %q", recordID, viewID)
+	}
+	return &view, nil
+}
+
+// RegisterRedactedView privacy-officer 登记记录的脱敏视图；viewId 格式同 recordType
+func (s *SmartContract) RegisterRedactedView(ctx contractapi.TransactionContextInterface, recordID, viewID, redactionManifestHash, redactedCid string) error {
+	if !recordTypePattern.MatchString(viewID) {
+		return fmt.Errorf("invalid viewID: %q", viewID)
+	}
+	if !sha256HexPattern.MatchString(redactionManifestHash) {
+		return fmt.Errorf("redactionManifestHash must be a lowercase hex sha256 digest")
+	}
+	config, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := config.checkCid(redactedCid); err != nil {
+		return err
+	}
+	isOfficer, err := hasRole(ctx, privacyOfficerRole)
+	if err != nil {
+		return err
+	}
+	if !isOfficer {
+		return fmt.Errorf("access denied: only a privacy officer can register redacted views")
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	if redactedCid == record.IPCSCID {
+		return fmt.Errorf("redactedCid must differ from the original record")
+	}
+	exists, err := assetExists(ctx, redactedViewKey(recordID, viewID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("redacted view already exists: %s", viewID)
+	}
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := putJSON(ctx, redactedViewKey(recordID, viewID), RedactedView{
+		RecordID:              recordID,
+		ViewID:                viewID,
+		RedactionManifestHash: redactionManifestHash,
+		RedactedCID:           redactedCid,
+		RegisteredBy:          callerID,
+		MspID:                 mspID,
+		RegisteredAt:          now,
+	}); err != nil {
+		return err
+	}
+	return emitEvent(ctx, "RedactedViewRegistered", RedactedViewRegisteredEvent{
+		RecordID:              recordID,
+		PatientID:             record.PatientID,
+		ViewID:                viewID,
+		RedactionManifestHash: redactionManifestHash,
+		Timestamp:             now,
+		CallerID:              callerID,
+		EventType:             "RedactedViewRegistered",
+	})
+}
+
+// ListRedactedViews 列出记录的全部脱敏视图，按 viewId 排序；隐私官或可读取整条记录的调用者可查询，
+// 视图授权的持有者不能借此看到其他视图
+func (s *SmartContract) ListRedactedViews(ctx contractapi.TransactionContextInterface, recordID string) ([]*RedactedView, error) {
+	isOfficer, err := hasRole(ctx, privacyOfficerRole)
+	if err != nil {
+		return nil, err
+	}
+	if !isOfficer {
+		if err := s.requireRecordAccess(ctx, recordID); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := redactedViewPrefix(recordID)
+	iterator, err := ctx.GetStub().GetStateByRange(prefix, prefix[:len(prefix)-1]+";")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan redacted views: %w", err)
+	}
+	defer iterator.Close()
+
+	views := []*RedactedView{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate redacted views: %w", err)
+		}
+		var view RedactedView
+		if err := json.Unmarshal(kv.Value, &view); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal redacted view: %w", err)
+		}
+		views = append(views, &view)
+	}
+	return views, nil
+}
+
+// GrantViewAccess 授予只读的视图授权：被授权人只能以 ReadRedactedView 读取该视图，原记录对其不可见；
+// expiresAt 为空表示不过期，再次授权覆盖原授权
+func (s *SmartContract) GrantViewAccess(ctx contractapi.TransactionContextInterface, recordID, granteeID, viewID, expiresAt string) error {
+	if viewID == "" {
+		return fmt.Errorf("viewID is required: use GrantAccess for the whole record")
+	}
+	return grantAccess(ctx, recordID, granteeID, "read", expiresAt, 0, grantScope{viewID: viewID})
+}
+
+// ReadRedactedView 返回脱敏视图；视图授权的持有者与可读取整条记录的调用者可读取，
+// 与 ReadRecord 一样计入披露、扣减限次授权并发出 RecordAccessed
+func (s *SmartContract) ReadRedactedView(ctx contractapi.TransactionContextInterface, recordID, viewID string) (*RedactedView, error) {
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	view, err := getRedactedView(ctx, recordID, viewID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTargetRead(ctx, record, accessTarget{viewID: viewID}); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var redactionManifestHash = strings.Repeat("e", 64)
+
+func (e *testEnv) registerView(recordID, viewID string) {
+	e.t.Helper()
+	e.mustInvoke(privacyOfficer, func(ctx contractapi.TransactionContextInterface) error {
+		return e.cc.RegisterRedactedView(ctx, recordID, viewID, redactionManifestHash, "bafy"+viewID)
+	})
+}
+
+func TestRegisterRedactedView(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.registerView("rec1", "insurer")
+	var event RedactedViewRegisteredEvent
+	env.expectEvent("RedactedViewRegistered", &event)
+	if event.PatientID != patient.id || event.ViewID != "insurer" || event.CallerID != privacyOfficer.id {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	env.mustFail(doctor, "only a privacy officer", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRedactedView(ctx, "rec1", "research", redactionManifestHash, "bafyresearch")
+	})
+	env.mustFail(privacyOfficer, "already exists", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRedactedView(ctx, "rec1", "insurer", redactionManifestHash, "bafyother")
+	})
+	env.mustFail(privacyOfficer, "must differ", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRedactedView(ctx, "rec1", "research", redactionManifestHash, "bafyrec1")
+	})
+	env.mustFail(privacyOfficer, "sha256", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRedactedView(ctx, "rec1", "research", "abc", "bafyresearch")
+	})
+	env.mustFail(privacyOfficer, "record not found", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterRedactedView(ctx, "missing", "research", redactionManifestHash, "bafyresearch")
+	})
+
+	env.registerView("rec1", "research")
+	for _, identity := range []*testIdentity{patient, privacyOfficer} {
+		env.mustInvoke(identity, func(ctx contractapi.TransactionContextInterface) error {
+			views, err := env.cc.ListRedactedViews(ctx, "rec1")
+			if err == nil && (len(views) != 2 || views[0].ViewID != "insurer" || views[1].RedactedCID != "bafyresearch") {
+				t.Fatalf("unexpected views: %+v", views)
+			}
+			return err
+		})
+	}
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ListRedactedViews(ctx, "rec1")
+		return err
+	})
+}
+
+func TestViewGrantHidesOriginal(t *testing.T) {
+	env := newTestEnv(t)
+	env.createRecord(doctor, "rec1", patient.id)
+	env.registerView("rec1", "insurer")
+	env.registerView("rec1", "research")
+	env.mustFail(patient, "has no redacted view", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantViewAccess(ctx, "rec1", specialist.id, "legal", "")
+	})
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.GrantViewAccess(ctx, "rec1", specialist.id, "insurer", "")
+	})
+	var event AccessGrantedEvent
+	env.expectEvent("AccessGranted", &event)
+	if event.ViewID != "insurer" || event.Action != "read" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	env.mustInvoke(specialist, func(ctx contractapi.TransactionContextInterface) error {
+		view, err := env.cc.ReadRedactedView(ctx, "rec1", "insurer")
+		if err == nil && view.RedactedCID != "bafyinsurer" {
+			t.Fatalf("unexpected view: %+v", view)
+		}
+		return err
+	})
+	env.mustFail(specialist, "cannot read view research", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRedactedView(ctx, "rec1", "research")
+		return err
+	})
+	env.mustFail(specialist, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("view grant must not grant the original record")
+	}
+	env.dropAccessEntries("rec1", specialist.id)
+	if env.checkAccess("rec1", specialist.id) {
+		t.Fatal("view grant must not grant the original record via the access list")
+	}
+
+	// 整条记录的访问同样可读取视图
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRedactedView(ctx, "rec1", "research")
+		return err
+	})
+}
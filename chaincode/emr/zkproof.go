@@ -0,0 +1,334 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxProofBlobSize 证明原文上限；链上只存其哈希
+const maxProofBlobSize = 64 * 1024
+
+// proofSchemes 支持登记的证明体系
+var proofSchemes = []string{"groth16", "plonk", "bulletproofs"}
+
+// ProofOracle 链下证明验证服务：链码内完整验证 Groth16 等证明成本过高，由登记的验证服务验证并签名结果，
+// 链码以登记的 P-256 公钥校验签名后锚定
+type ProofOracle struct {
+	OracleID     string `json:"oracleId"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+	// Fingerprint 公钥 DER 的 sha256
+	Fingerprint  string `json:"fingerprint"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+	RevokedAt    string `json:"revokedAt,omitempty"`
+}
+
+// ProofVerifierKey 登记的验证密钥；vkHash 为验证密钥文件的 sha256，验证服务须用同一密钥验证。登记后不可覆盖
+type ProofVerifierKey struct {
+	KeyID        string `json:"keyId"`
+	Scheme       string `json:"scheme"`
+	VKHash       string `json:"vkHash"`
+	RegisteredBy string `json:"registeredBy"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+// ProofAttestation 验证服务对一次验证的签名结果；Signature 为 base64 的 ASN.1 ECDSA 签名，
+// 签名内容见 attributeProofMessage
+type ProofAttestation struct {
+	OracleID  string `json:"oracleId"`
+	Result    bool   `json:"result"`
+	Signature string `json:"signature"`
+}
+
+// AttributeProofVerification 锚定的验证结果；ContentHash 为验证时记录的内容哈希，记录更新后结果不再对应当前版本
+type AttributeProofVerification struct {
+	RecordID         string `json:"recordId"`
+	KeyID            string `json:"keyId"`
+	Scheme           string `json:"scheme"`
+	VKHash           string `json:"vkHash"`
+	ProofHash        string `json:"proofHash"`
+	PublicInputsHash string `json:"publicInputsHash"`
+	ContentHash      string `json:"contentHash"`
+	Result           bool   `json:"result"`
+	OracleID         string `json:"oracleId"`
+	SubmittedBy      string `json:"submittedBy"`
+	VerifiedAt       string `json:"verifiedAt"`
+	TxID             string `json:"txId"`
+}
+
+type AttributeProofVerifiedEvent struct {
+	RecordID    string `json:"recordId"`
+	KeyID       string `json:"keyId"`
+	Result      bool   `json:"result"`
+	OracleID    string `json:"oracleId"`
+	SubmittedBy string `json:"submittedBy"`
+	Timestamp   string `json:"timestamp"`
+	TxID        string `json:"txId"`
+	EventType   string `json:"eventType"`
+}
+
+func proofOracleKey(oracleID string) string {
+	return "proof-oracle:" + keySegment(oracleID)
+}
+
+func proofVerifierKey(keyID string) string {
+	return "proof-vk:" + keySegment(keyID)
+}
+
+// zkVerificationKey zkverify:{recordId}:{txId}
+func zkVerificationKey(recordID, txID string) string {
+	return "zkverify:" + keySegment(recordID) + ":" + txID
+}
+
+// parseOracleKey 解析 PEM 编码的 PKIX 公钥，只接受 P-256 ECDSA
+func parseOracleKey(publicKeyPem string) (*ecdsa.PublicKey, []byte, error) {
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, nil, fmt.Errorf("publicKeyPem must be a PEM encoded PUBLIC KEY")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, nil, fmt.Errorf("oracle keys must be ECDSA P-256")
+	}
+	return key, block.Bytes, nil
+}
+
+// attributeProofMessage 验证服务签名的内容：逐行列出验证密钥、记录版本、证明与公开输入的哈希及结果，
+// 签名绑定记录当前内容，不能挪用到其他记录或版本
+func attributeProofMessage(vk *ProofVerifierKey, record *MedicalRecord, proofHash, publicInputsHash string, result bool) []byte {
+	return []byte(strings.Join([]string{
+		"zkverify/v1",
+		vk.KeyID,
+		vk.Scheme,
+		vk.VKHash,
+		record.RecordID,
+		record.ContentHash,
+		proofHash,
+		publicInputsHash,
+		strconv.FormatBool(result),
+	}, "\n"))
+}
+
+// RegisterProofOracle admin 登记证明验证服务的公钥；同一 oracleId 不可覆盖，换钥须登记新的 oracleId
+func (s *SmartContract) RegisterProofOracle(ctx contractapi.TransactionContextInterface, oracleID, publicKeyPem string) error {
+	if err := validateAddress(oracleID); err != nil {
+		return fmt.Errorf("invalid oracleID: %w", err)
+	}
+	_, der, err := parseOracleKey(publicKeyPem)
+	if err != nil {
+		return err
+	}
+	callerID, _, err := requireAdminCaller(ctx, "register proof oracles")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, proofOracleKey(oracleID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("proof oracle already exists: %s", oracleID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, proofOracleKey(oracleID), ProofOracle{
+		OracleID:     oracleID,
+		PublicKeyPEM: publicKeyPem,
+		Fingerprint:  sha256Hex(string(der)),
+		RegisteredBy: callerID,
+		RegisteredAt: now,
+	})
+}
+
+// RevokeProofOracle admin 停用验证服务；已锚定的结果保留，之后的签名不再接受
+func (s *SmartContract) RevokeProofOracle(ctx contractapi.TransactionContextInterface, oracleID string) error {
+	if _, _, err := requireAdminCaller(ctx, "revoke proof oracles"); err != nil {
+		return err
+	}
+	oracle, err := s.GetProofOracle(ctx, oracleID)
+	if err != nil {
+		return err
+	}
+	if oracle.RevokedAt != "" {
+		return fmt.Errorf("proof oracle %s is already revoked", oracleID)
+	}
+	oracle.RevokedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, proofOracleKey(oracleID), oracle)
+}
+
+func (s *SmartContract) GetProofOracle(ctx contractapi.TransactionContextInterface, oracleID string) (*ProofOracle, error) {
+	var oracle ProofOracle
+	found, err := getJSON(ctx, proofOracleKey(oracleID), &oracle)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("proof oracle not found: %s", oracleID)
+	}
+	return &oracle, nil
+}
+
+// RegisterProofVerifierKey admin 登记验证密钥；keyId 格式同 recordType
+func (s *SmartContract) RegisterProofVerifierKey(ctx contractapi.TransactionContextInterface, keyID, scheme, vkHash string) error {
+	if !recordTypePattern.MatchString(keyID) {
+		return fmt.Errorf("invalid keyID: %q", keyID)
+	}
+	if !containsString(proofSchemes, scheme) {
+		return fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+	if !sha256HexPattern.MatchString(vkHash) {
+		return fmt.Errorf("vkHash must be a lowercase hex sha256 digest")
+	}
+	callerID, _, err := requireAdminCaller(ctx, "register verifier keys")
+	if err != nil {
+		return err
+	}
+	exists, err := assetExists(ctx, proofVerifierKey(keyID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("verifier key already exists: %s", keyID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return putJSON(ctx, proofVerifierKey(keyID), ProofVerifierKey{
+		KeyID:        keyID,
+		Scheme:       scheme,
+		VKHash:       vkHash,
+		RegisteredBy: callerID,
+		RegisteredAt: now,
+	})
+}
+
+func (s *SmartContract) GetProofVerifierKey(ctx contractapi.TransactionContextInterface, keyID string) (*ProofVerifierKey, error) {
+	var vk ProofVerifierKey
+	found, err := getJSON(ctx, proofVerifierKey(keyID), &vk)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("verifier key not found: %s", keyID)
+	}
+	return &vk, nil
+}
+
+// VerifyAttributeProof 锚定外部生成的属性证明（如“年满 18 岁”“HbA1c 低于阈值”）的验证结果。
+// attestationJson 为登记的验证服务对 attributeProofMessage 的签名；签名无效或验证服务已停用时交易失败。
+// 结果写入 zkverify 键并发出事件，不授予记录访问，任何调用者都可提交
+func (s *SmartContract) VerifyAttributeProof(ctx contractapi.TransactionContextInterface, recordID, keyID, proofBlob, publicInputsJson, attestationJson string) (*AttributeProofVerification, error) {
+	if proofBlob == "" || len(proofBlob) > maxProofBlobSize {
+		return nil, fmt.Errorf("proofBlob must be 1 to %d bytes", maxProofBlobSize)
+	}
+	if !json.Valid([]byte(publicInputsJson)) {
+		return nil, fmt.Errorf("publicInputsJson must be valid JSON")
+	}
+	var attestation ProofAttestation
+	if err := unmarshalArg(attestationJson, &attestation); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal attestation: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attestation.Signature)
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("attestation signature must be base64")
+	}
+	record, err := getRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	vk, err := s.GetProofVerifierKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	oracle, err := s.GetProofOracle(ctx, attestation.OracleID)
+	if err != nil {
+		return nil, err
+	}
+	if oracle.RevokedAt != "" {
+		return nil, fmt.Errorf("proof oracle %s was revoked at %s", oracle.OracleID, oracle.RevokedAt)
+	}
+	publicKey, _, err := parseOracleKey(oracle.PublicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	proofHash := sha256Hex(proofBlob)
+	publicInputsHash := sha256Hex(publicInputsJson)
+	digest := sha256.Sum256(attributeProofMessage(vk, record, proofHash, publicInputsHash, attestation.Result))
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signature) {
+		return nil, fmt.Errorf("invalid attestation signature from oracle %s", oracle.OracleID)
+	}
+
+	callerID, err := getCallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txID := ctx.GetStub().GetTxID()
+	verification := &AttributeProofVerification{
+		RecordID:         recordID,
+		KeyID:            keyID,
+		Scheme:           vk.Scheme,
+		VKHash:           vk.VKHash,
+		ProofHash:        proofHash,
+		PublicInputsHash: publicInputsHash,
+		ContentHash:      record.ContentHash,
+		Result:           attestation.Result,
+		OracleID:         oracle.OracleID,
+		SubmittedBy:      callerID,
+		VerifiedAt:       now,
+		TxID:             txID,
+	}
+	if err := putJSON(ctx, zkVerificationKey(recordID, txID), verification); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, "AttributeProofVerified", AttributeProofVerifiedEvent{
+		RecordID:    recordID,
+		KeyID:       keyID,
+		Result:      attestation.Result,
+		OracleID:    oracle.OracleID,
+		SubmittedBy: callerID,
+		Timestamp:   now,
+		TxID:        txID,
+		EventType:   "AttributeProofVerified",
+	}); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// GetAttributeProofVerification 按记录与 txId 取回锚定的验证结果
+func (s *SmartContract) GetAttributeProofVerification(ctx contractapi.TransactionContextInterface, recordID, txID string) (*AttributeProofVerification, error) {
+	var verification AttributeProofVerification
+	found, err := getJSON(ctx, zkVerificationKey(recordID, txID), &verification)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("attribute proof verification not found: %s", txID)
+	}
+	return &verification, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ageProof       = "groth16-proof-bytes"
+	ageInputs      = `{"threshold":18}`
+	vkHashForTests = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+type testOracle struct {
+	id  string
+	key *ecdsa.PrivateKey
+}
+
+func newTestOracle(t *testing.T, id string) *testOracle {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testOracle{id: id, key: key}
+}
+
+func (o *testOracle) publicKeyPEM() string {
+	der, _ := x509.MarshalPKIXPublicKey(&o.key.PublicKey)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// attest 按 attributeProofMessage 签名，模拟链下验证服务
+func (o *testOracle) attest(t *testing.T, vk *ProofVerifierKey, record *MedicalRecord, proof, inputs string, result bool) string {
+	digest := sha256.Sum256(attributeProofMessage(vk, record, sha256Hex(proof), sha256Hex(inputs), result))
+	signature, err := ecdsa.SignASN1(rand.Reader, o.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(ProofAttestation{OracleID: o.id, Result: result, Signature: base64.StdEncoding.EncodeToString(signature)})
+	return string(data)
+}
+
+func (e *testEnv) setupProofRegistry(oracle *testOracle) {
+	e.t.Helper()
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		if err := e.cc.RegisterProofOracle(ctx, oracle.id, oracle.publicKeyPEM()); err != nil {
+			return err
+		}
+		return e.cc.RegisterProofVerifierKey(ctx, "age-over", "groth16", vkHashForTests)
+	})
+}
+
+func (e *testEnv) storedRecord(recordID string) *MedicalRecord {
+	e.t.Helper()
+	var record MedicalRecord
+	e.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := getJSON(ctx, recordKey(recordID), &record)
+		return err
+	})
+	return &record
+}
+
+func TestVerifyAttributeProof(t *testing.T) {
+	env := newTestEnv(t)
+	oracle := newTestOracle(t, "zk-oracle1")
+	env.createRecord(doctor, "rec1", patient.id)
+	env.setupProofRegistry(oracle)
+	vk := &ProofVerifierKey{KeyID: "age-over", Scheme: "groth16", VKHash: vkHashForTests}
+	attestation := oracle.attest(t, vk, env.storedRecord("rec1"), ageProof, ageInputs, true)
+
+	checker := newIdentity("eligibility1", "Org3MSP")
+	var txID string
+	env.mustInvoke(checker, func(ctx contractapi.TransactionContextInterface) error {
+		verification, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, attestation)
+		if err == nil && (!verification.Result || verification.OracleID != oracle.id || verification.ProofHash != sha256Hex(ageProof)) {
+			t.Fatalf("unexpected verification: %+v", verification)
+		}
+		if err == nil {
+			txID = verification.TxID
+		}
+		return err
+	})
+	var event AttributeProofVerifiedEvent
+	env.expectEvent("AttributeProofVerified", &event)
+	if event.SubmittedBy != checker.id || !event.Result {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	env.mustInvoke(patient, func(ctx contractapi.TransactionContextInterface) error {
+		verification, err := env.cc.GetAttributeProofVerification(ctx, "rec1", txID)
+		if err == nil && verification.ContentHash != strings.Repeat("a", 64) {
+			t.Fatalf("unexpected verification: %+v", verification)
+		}
+		return err
+	})
+	// 验证结果不授予记录访问
+	env.mustFail(checker, "access denied", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.ReadRecord(ctx, "rec1")
+		return err
+	})
+
+	tampered := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"flipped result": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, strings.Replace(attestation, `"result":true`, `"result":false`, 1))
+			return err
+		},
+		"other inputs": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, `{"threshold":16}`, attestation)
+			return err
+		},
+		"other proof": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", "forged", ageInputs, attestation)
+			return err
+		},
+	}
+	for name, fn := range tampered {
+		if err := env.invoke(checker, fn); err == nil || !strings.Contains(err.Error(), "invalid attestation signature") {
+			t.Fatalf("%s: expected invalid signature, got %v", name, err)
+		}
+	}
+
+	// 签名绑定记录版本，更新后旧签名失效
+	env.mustInvoke(doctor, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.UpdateMedicalRecord(ctx, "rec1", "bafyrec1v2", strings.Repeat("f", 64))
+	})
+	env.mustFail(checker, "invalid attestation signature", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, attestation)
+		return err
+	})
+}
+
+func TestProofOracleRegistry(t *testing.T) {
+	env := newTestEnv(t)
+	oracle := newTestOracle(t, "zk-oracle1")
+	env.createRecord(doctor, "rec1", patient.id)
+	env.mustFail(doctor, "only admin", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterProofOracle(ctx, oracle.id, oracle.publicKeyPEM())
+	})
+	env.mustFail(admin, "PEM encoded", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterProofOracle(ctx, oracle.id, "not a key")
+	})
+	env.setupProofRegistry(oracle)
+	env.mustFail(admin, "already exists", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterProofVerifierKey(ctx, "age-over", "plonk", vkHashForTests)
+	})
+	env.mustFail(admin, "unsupported scheme", func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RegisterProofVerifierKey(ctx, "hba1c", "snark", vkHashForTests)
+	})
+
+	// 未登记的验证服务签名不被接受
+	vk := &ProofVerifierKey{KeyID: "age-over", Scheme: "groth16", VKHash: vkHashForTests}
+	record := env.storedRecord("rec1")
+	rogue := newTestOracle(t, "zk-rogue")
+	env.mustFail(patient, "proof oracle not found", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, rogue.attest(t, vk, record, ageProof, ageInputs, true))
+		return err
+	})
+	impostor := &testOracle{id: oracle.id, key: rogue.key}
+	env.mustFail(patient, "invalid attestation signature", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, impostor.attest(t, vk, record, ageProof, ageInputs, true))
+		return err
+	})
+
+	attestation := oracle.attest(t, vk, record, ageProof, ageInputs, true)
+	env.mustInvoke(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return env.cc.RevokeProofOracle(ctx, oracle.id)
+	})
+	env.mustFail(patient, "was revoked", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.cc.VerifyAttributeProof(ctx, "rec1", "age-over", ageProof, ageInputs, attestation)
+		return err
+	})
+}